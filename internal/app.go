@@ -5,17 +5,28 @@ import (
 	"fmt"
 	"io"
 	"strings"
-	"time"
 
 	"github.com/spf13/afero"
 	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
 
 	"github.com/aquasecurity/trivy-db/pkg/db"
 	"github.com/aquasecurity/trivy-db/pkg/types"
 	"github.com/aquasecurity/trivy/internal/client"
+	"github.com/aquasecurity/trivy/internal/diff"
+	"github.com/aquasecurity/trivy/internal/history"
+	"github.com/aquasecurity/trivy/internal/operation"
+	pluginCmd "github.com/aquasecurity/trivy/internal/plugin"
 	"github.com/aquasecurity/trivy/internal/server"
 	"github.com/aquasecurity/trivy/internal/standalone"
+	"github.com/aquasecurity/trivy/pkg/cache"
+	"github.com/aquasecurity/trivy/pkg/customanalyzer"
 	tdb "github.com/aquasecurity/trivy/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/github"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/progress"
+	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/pkg/utils"
 	"github.com/aquasecurity/trivy/pkg/vulnerability"
 )
@@ -29,24 +40,115 @@ var (
 	templateFlag = cli.StringFlag{
 		Name:   "template, t",
 		Value:  "",
-		Usage:  "output template",
+		Usage:  "output template, @ and the path of a template file, or junit",
 		EnvVar: "TRIVY_TEMPLATE",
 	}
 
 	formatFlag = cli.StringFlag{
 		Name:   "format, f",
 		Value:  "table",
-		Usage:  "format (table, json, template)",
+		Usage:  "format (table, json, template, top, osv, sarif, cyclonedx, cyclonedx-xml, spdx, spdx-json, gitlab, scc, kafka, html, sqlite)",
 		EnvVar: "TRIVY_FORMAT",
 	}
 
+	topFlag = cli.IntFlag{
+		Name:   "top",
+		Value:  report.DefaultTopN,
+		Usage:  "number of findings written with --format top",
+		EnvVar: "TRIVY_TOP",
+	}
+
+	sccSourceFlag = cli.StringFlag{
+		Name:   "scc-source",
+		Usage:  "Security Command Center source of the findings written with --format scc, e.g. organizations/123/sources/456",
+		EnvVar: "TRIVY_SCC_SOURCE",
+	}
+
+	kafkaBrokersFlag = cli.StringSliceFlag{
+		Name:   "kafka-brokers",
+		Usage:  "Kafka broker the findings are published to with --format kafka, e.g. localhost:9092, repeated for several brokers",
+		EnvVar: "TRIVY_KAFKA_BROKERS",
+	}
+
+	kafkaTopicFlag = cli.StringFlag{
+		Name:   "kafka-topic",
+		Usage:  "Kafka topic the findings are published to with --format kafka",
+		EnvVar: "TRIVY_KAFKA_TOPIC",
+	}
+
+	reportFlag = cli.StringFlag{
+		Name:   "report",
+		Value:  report.ReportAll,
+		Usage:  "all the findings, or the summary of the number of findings per severity of each target in table or json (all, summary)",
+		EnvVar: "TRIVY_REPORT",
+	}
+
+	artifactMetadataFlag = cli.BoolFlag{
+		Name:   "artifact-metadata",
+		Usage:  "write the JSON report with the metadata of the artifact, the scanner and the DB instead of the bare results of --format json",
+		EnvVar: "TRIVY_ARTIFACT_METADATA",
+	}
+
+	baseImageFlag = cli.StringFlag{
+		Name:   "base-image",
+		Value:  "",
+		Usage:  "base image to separate its vulnerabilities from the application ones in the table",
+		EnvVar: "TRIVY_BASE_IMAGE",
+	}
+
+	complianceFlag = cli.StringFlag{
+		Name:   "compliance",
+		Value:  "",
+		Usage:  "JSON file mapping compliance controls to the conditions to append their pass/fail to the table",
+		EnvVar: "TRIVY_COMPLIANCE",
+	}
+
 	inputFlag = cli.StringFlag{
 		Name:   "input, i",
 		Value:  "",
-		Usage:  "input file path instead of image name",
+		Usage:  "input file path of a Docker archive or an OCI layout instead of image name",
 		EnvVar: "TRIVY_INPUT",
 	}
 
+	inputListFlag = cli.StringFlag{
+		Name:   "input-list",
+		Usage:  "file listing the images to scan with those of the arguments, one per line, e.g. images.txt",
+		EnvVar: "TRIVY_INPUT_LIST",
+	}
+
+	concurrencyFlag = cli.IntFlag{
+		Name:   "concurrency",
+		Value:  5,
+		Usage:  "number of images scanned concurrently",
+		EnvVar: "TRIVY_CONCURRENCY",
+	}
+
+	runtimeFlag = cli.StringFlag{
+		Name:   "runtime",
+		Usage:  "container runtime to read the image from (docker, containerd, podman), the first one having the image by default",
+		EnvVar: "TRIVY_RUNTIME",
+	}
+
+	containerdSocketFlag = cli.StringFlag{
+		Name:   "containerd-socket",
+		Value:  containerd.DefaultSocket,
+		Usage:  "socket of containerd",
+		EnvVar: "TRIVY_CONTAINERD_SOCKET",
+	}
+
+	containerdNamespaceFlag = cli.StringFlag{
+		Name:   "containerd-namespace",
+		Value:  containerd.DefaultNamespace,
+		Usage:  "namespace of the images in containerd",
+		EnvVar: "TRIVY_CONTAINERD_NAMESPACE",
+	}
+
+	podmanSocketFlag = cli.StringFlag{
+		Name:   "podman-socket",
+		Usage:  "socket of the Podman service, the rootful then the rootless one by default",
+		EnvVar: "TRIVY_PODMAN_SOCKET",
+	}
+
 	severityFlag = cli.StringFlag{
 		Name:   "severity, s",
 		Value:  strings.Join(types.SeverityNames, ","),
@@ -56,7 +158,7 @@ var (
 
 	outputFlag = cli.StringFlag{
 		Name:   "output, o",
-		Usage:  "output file name",
+		Usage:  "output file name, gzipped when it ends with .gz, e.g. report.json.gz",
 		EnvVar: "TRIVY_OUTPUT",
 	}
 
@@ -67,12 +169,50 @@ var (
 		EnvVar: "TRIVY_EXIT_CODE",
 	}
 
+	exitOnSeverityFlag = cli.StringFlag{
+		Name:   "exit-on-severity",
+		Usage:  "exit with --exit-code only when findings of the severity or higher were found, e.g. CRITICAL",
+		EnvVar: "TRIVY_EXIT_ON_SEVERITY",
+	}
+
+	eolSeverityFlag = cli.StringFlag{
+		Name:   "eol-severity",
+		Value:  "HIGH",
+		Usage:  "severity of the finding of an OS no longer supported by its distribution, empty to only log a warning",
+		EnvVar: "TRIVY_EOL_SEVERITY",
+	}
+
+	exitOnEOLFlag = cli.IntFlag{
+		Name:   "exit-on-eol",
+		Usage:  "exit code when the OS is no longer supported by its distribution",
+		Value:  0,
+		EnvVar: "TRIVY_EXIT_ON_EOL",
+	}
+
 	skipUpdateFlag = cli.BoolFlag{
 		Name:   "skip-update",
 		Usage:  "skip db update",
 		EnvVar: "TRIVY_SKIP_UPDATE",
 	}
 
+	dbRepositoryFlag = cli.StringSliceFlag{
+		Name:   "db-repository",
+		Usage:  "repository of the DB as owner/repo on GitHub or the http(s) URL of a mirror, repeated for the mirrors tried in turn (default: \"" + github.DefaultRepository + "\")",
+		EnvVar: "TRIVY_DB_REPOSITORY",
+	}
+
+	advisoryDirFlag = cli.StringSliceFlag{
+		Name:   "advisory-dir",
+		Usage:  "directory of supplementary advisories in the OSV format matched with the DB, e.g. of internal packages, repeated for several directories",
+		EnvVar: "TRIVY_ADVISORY_DIR",
+	}
+
+	offlineScanFlag = cli.BoolFlag{
+		Name:   "offline-scan",
+		Usage:  "scan without any network access, with the local DB and images only (implies --skip-update)",
+		EnvVar: "TRIVY_OFFLINE_SCAN",
+	}
+
 	downloadDBOnlyFlag = cli.BoolFlag{
 		Name:   "download-db-only",
 		Usage:  "download/update vulnerability database but don't run a scan",
@@ -91,6 +231,43 @@ var (
 		EnvVar: "TRIVY_CLEAR_CACHE",
 	}
 
+	noCacheFlag = cli.BoolFlag{
+		Name:   "no-cache",
+		Usage:  "analyze every layer and match every package again, without reading or writing the layer and result caches",
+		EnvVar: "TRIVY_NO_CACHE",
+	}
+
+	skipDirsFlag = cli.StringFlag{
+		Name:   "skip-dirs",
+		Usage:  "comma-separated list of the directories not analyzed, names at any depth, e.g. node_modules, or paths from the root with a slash, e.g. usr/src/app/test, as glob patterns (implies --no-cache)",
+		EnvVar: "TRIVY_SKIP_DIRS",
+	}
+
+	skipFilesFlag = cli.StringFlag{
+		Name:   "skip-files",
+		Usage:  "comma-separated list of the files not analyzed, names at any depth or paths from the root with a slash, as glob patterns (implies --no-cache)",
+		EnvVar: "TRIVY_SKIP_FILES",
+	}
+
+	filePatternsFlag = cli.StringFlag{
+		Name:   "file-patterns",
+		Usage:  "comma-separated list of analyzer:pattern analyzing the files whose names match the glob pattern as the lock files of the analyzer, e.g. pipenv:Pipfile.*.lock (implies --no-cache)",
+		EnvVar: "TRIVY_FILE_PATTERNS",
+	}
+
+	configFileFlag = cli.StringFlag{
+		Name:   "config",
+		Value:  defaultConfigFile,
+		Usage:  "config file setting the flags by their names, read from the working directory by default when it exists",
+		EnvVar: "TRIVY_CONFIG",
+	}
+
+	profileFlag = cli.StringFlag{
+		Name:   "profile",
+		Usage:  "profile of the config file overriding its top-level flags, e.g. ci",
+		EnvVar: "TRIVY_PROFILE",
+	}
+
 	quietFlag = cli.BoolFlag{
 		Name:   "quiet, q",
 		Usage:  "suppress progress bar and log output",
@@ -103,12 +280,25 @@ var (
 		EnvVar: "TRIVY_NO_PROGRESS",
 	}
 
+	progressFlag = cli.StringFlag{
+		Name:   "progress",
+		Value:  progress.FormatBar,
+		Usage:  "progress of the DB update, the pull, the analysis of each layer and the matching on stderr (bar,json,none), the bar on a terminal only",
+		EnvVar: "TRIVY_PROGRESS",
+	}
+
 	ignoreUnfixedFlag = cli.BoolFlag{
 		Name:   "ignore-unfixed",
 		Usage:  "display only fixed vulnerabilities",
 		EnvVar: "TRIVY_IGNORE_UNFIXED",
 	}
 
+	separateDeferredFlag = cli.BoolFlag{
+		Name:   "separate-deferred",
+		Usage:  "report apart the vulnerabilities the vendor won't fix or deferred, when the DB records the decision",
+		EnvVar: "TRIVY_SEPARATE_DEFERRED",
+	}
+
 	debugFlag = cli.BoolFlag{
 		Name:   "debug, d",
 		Usage:  "debug mode",
@@ -128,6 +318,80 @@ var (
 		EnvVar: "TRIVY_VULN_TYPE",
 	}
 
+	securityChecksFlag = cli.StringFlag{
+		Name:   "security-checks",
+		Value:  "vuln",
+		Usage:  "comma-separated list of what security issues to detect (vuln,secret,config,license)",
+		EnvVar: "TRIVY_SECURITY_CHECKS",
+	}
+
+	secretConfigFlag = cli.StringFlag{
+		Name:   "secret-config",
+		Usage:  "JSON file of the secret rules and allow rules (secret check)",
+		EnvVar: "TRIVY_SECRET_CONFIG",
+	}
+
+	configPolicyFlag = cli.StringFlag{
+		Name:   "config-policy",
+		Usage:  "comma-separated list of Rego files or directories of the policies applied with the built-in ones (config check)",
+		EnvVar: "TRIVY_CONFIG_POLICY",
+	}
+
+	licenseForbiddenFlag = cli.StringFlag{
+		Name:   "license-forbidden",
+		Usage:  "comma-separated list of forbidden licenses, e.g. GPL-3.0, failing with --exit-code (license check)",
+		EnvVar: "TRIVY_LICENSE_FORBIDDEN",
+	}
+
+	goBinariesFlag = cli.BoolFlag{
+		Name:   "go-binaries",
+		Usage:  "detect the modules embedded in Go binaries in /, bin, usr/bin, usr/local/bin, app and ko-app, matched by the supplementary advisories only (slower)",
+		EnvVar: "TRIVY_GO_BINARIES",
+	}
+
+	goBinaryDirsFlag = cli.StringFlag{
+		Name:   "go-binary-dirs",
+		Usage:  "comma-separated list of directories of the Go binaries, e.g. opt/app, instead of the default ones (implies --go-binaries)",
+		EnvVar: "TRIVY_GO_BINARY_DIRS",
+	}
+
+	listAllPkgsFlag = cli.BoolFlag{
+		Name:   "list-all-pkgs",
+		Usage:  "list all the packages in the results, including those without vulnerabilities, as the BOM formats do",
+		EnvVar: "TRIVY_LIST_ALL_PKGS",
+	}
+
+	recordHistoryFlag = cli.BoolFlag{
+		Name:   "record-history",
+		Usage:  "record the scan with its vulnerabilities in the SQLite database of --history-db, queried with trivy history",
+		EnvVar: "TRIVY_RECORD_HISTORY",
+	}
+
+	historyDBFlag = cli.StringFlag{
+		Name:   "history-db",
+		Usage:  "SQLite database of the history of the scans, history.db of the cache directory by default",
+		EnvVar: "TRIVY_HISTORY_DB",
+	}
+
+	javaArchivesFlag = cli.BoolFlag{
+		Name:   "java-archives",
+		Usage:  "detect the Maven artifacts of the JAR, WAR and EAR files, including the nested ones, matched by the supplementary advisories only (slower)",
+		EnvVar: "TRIVY_JAVA_ARCHIVES",
+	}
+
+	customAnalyzerFlag = cli.StringSliceFlag{
+		Name:   "custom-analyzer",
+		Usage:  "directory of custom analyzers, WebAssembly modules (*.wasm) detecting the libraries of other files, repeated for several directories",
+		EnvVar: "TRIVY_CUSTOM_ANALYZER",
+	}
+
+	customAnalyzerRuntimeFlag = cli.StringFlag{
+		Name:   "custom-analyzer-runtime",
+		Value:  customanalyzer.DefaultRuntime,
+		Usage:  "command of the WASI runtime running the custom analyzers, followed by the module and its arguments",
+		EnvVar: "TRIVY_CUSTOM_ANALYZER_RUNTIME",
+	}
+
 	cacheDirFlag = cli.StringFlag{
 		Name:   "cache-dir",
 		Value:  utils.DefaultCacheDir(),
@@ -135,6 +399,43 @@ var (
 		EnvVar: "TRIVY_CACHE_DIR",
 	}
 
+	cacheBackendFlag = cli.StringFlag{
+		Name:   "cache-backend",
+		Value:  "fs",
+		Usage:  "cache backend of the analyzed layers, fs, memory or the URL of a Redis server shared by the scanners, e.g. redis://:password@redis:6379/0 or rediss:// over TLS",
+		EnvVar: "TRIVY_CACHE_BACKEND",
+	}
+
+	cacheTTLFlag = cli.DurationFlag{
+		Name:   "cache-ttl",
+		Usage:  "expire the layers cached in Redis after the duration, e.g. 72h; 0 keeps them",
+		EnvVar: "TRIVY_CACHE_TTL",
+	}
+
+	redisTLSFlag = cli.BoolFlag{
+		Name:   "redis-tls",
+		Usage:  "connect to the Redis cache backend over TLS",
+		EnvVar: "TRIVY_REDIS_TLS",
+	}
+
+	redisCACertFlag = cli.StringFlag{
+		Name:   "redis-ca",
+		Usage:  "PEM file of the CA certificates verifying the Redis server",
+		EnvVar: "TRIVY_REDIS_CA",
+	}
+
+	redisCertFlag = cli.StringFlag{
+		Name:   "redis-cert",
+		Usage:  "PEM file of the client certificate of the Redis server",
+		EnvVar: "TRIVY_REDIS_CERT",
+	}
+
+	redisKeyFlag = cli.StringFlag{
+		Name:   "redis-key",
+		Usage:  "PEM file of the key of the client certificate of the Redis server",
+		EnvVar: "TRIVY_REDIS_KEY",
+	}
+
 	ignoreFileFlag = cli.StringFlag{
 		Name:   "ignorefile",
 		Value:  vulnerability.DefaultIgnoreFile,
@@ -142,13 +443,145 @@ var (
 		EnvVar: "TRIVY_IGNOREFILE",
 	}
 
+	showSuppressedFlag = cli.BoolFlag{
+		Name:   "show-suppressed",
+		Usage:  "list the vulnerabilities dropped by the ignore file with their statements",
+		EnvVar: "TRIVY_SHOW_SUPPRESSED",
+	}
+
+	vexFlag = cli.StringFlag{
+		Name:   "vex",
+		Usage:  "OpenVEX or CSAF VEX document whose not_affected and fixed statements suppress vulnerabilities",
+		EnvVar: "TRIVY_VEX",
+	}
+
+	attestFlag = cli.BoolFlag{
+		Name:   "attest",
+		Usage:  "attach the results to the image in the registry as an in-toto attestation signed by --key",
+		EnvVar: "TRIVY_ATTEST",
+	}
+
+	verifyAttestationFlag = cli.BoolFlag{
+		Name:   "verify-attestation",
+		Usage:  "require an attestation of the image signed by --key before the scan",
+		EnvVar: "TRIVY_VERIFY_ATTESTATION",
+	}
+
+	keyFlag = cli.StringFlag{
+		Name:   "key",
+		Usage:  "cosign key of --attest, decrypted with $COSIGN_PASSWORD, or public key of --verify-attestation",
+		EnvVar: "TRIVY_KEY",
+	}
+
+	ignorePolicyFlag = cli.StringFlag{
+		Name:   "ignore-policy",
+		Usage:  "Rego file of the package trivy whose ignore rule drops vulnerabilities",
+		EnvVar: "TRIVY_IGNORE_POLICY",
+	}
+
+	exploitDataFlag = cli.BoolFlag{
+		Name:   "exploit-data",
+		Usage:  "annotate the vulnerabilities with their EPSS score and CISA KEV membership, downloaded daily into the cache directory",
+		EnvVar: "TRIVY_EXPLOIT_DATA",
+	}
+
+	epssAboveFlag = cli.Float64Flag{
+		Name:   "epss-above",
+		Usage:  "only show the vulnerabilities with an EPSS probability of exploitation above the threshold in [0, 1), e.g. 0.5",
+		EnvVar: "TRIVY_EPSS_ABOVE",
+	}
+
+	kevOnlyFlag = cli.BoolFlag{
+		Name:   "kev-only",
+		Usage:  "only show the vulnerabilities in the CISA Known Exploited Vulnerabilities catalog",
+		EnvVar: "TRIVY_KEV_ONLY",
+	}
+
+	notifyWebhookFlag = cli.StringFlag{
+		Name:   "notify-webhook",
+		Usage:  "URL to push the results to after the scan, retried with backoff on failures",
+		EnvVar: "TRIVY_NOTIFY_WEBHOOK",
+	}
+
+	notifyFormatFlag = cli.StringFlag{
+		Name:   "notify-format",
+		Value:  report.SinkWebhook,
+		Usage:  "payload of --notify-webhook (webhook: the findings, slack: a summary message, json: the JSON report)",
+		EnvVar: "TRIVY_NOTIFY_FORMAT",
+	}
+
+	notifySecretFlag = cli.StringFlag{
+		Name:   "notify-secret",
+		Usage:  "secret signing the payloads of --notify-webhook with HMAC-SHA256 in the X-Trivy-Signature-256 header",
+		EnvVar: "TRIVY_NOTIFY_SECRET",
+	}
+
+	outputPluginFlag = cli.StringFlag{
+		Name:   "output-plugin",
+		Usage:  "installed output plugin given the JSON results on stdin, writing to --output instead of --format",
+		EnvVar: "TRIVY_OUTPUT_PLUGIN",
+	}
+
+	metricsPushgatewayFlag = cli.StringFlag{
+		Name:   "metrics-pushgateway",
+		Usage:  "URL of a Prometheus Pushgateway to push the metrics of the scan to, e.g. http://pushgateway:9091",
+		EnvVar: "TRIVY_METRICS_PUSHGATEWAY",
+	}
+
+	dependencyTreeFlag = cli.BoolFlag{
+		Name:   "dependency-tree",
+		Usage:  "show the chain of the dependencies requiring each vulnerable package of package-lock.json",
+		EnvVar: "TRIVY_DEPENDENCY_TREE",
+	}
+
+	severitySourceFlag = cli.StringFlag{
+		Name:   "severity-source",
+		Usage:  "source of the reported severity when it rates the vulnerability (e.g. nvd, redhat), the data source of the result by default",
+		EnvVar: "TRIVY_SEVERITY_SOURCE",
+	}
+
+	maxDBAgeFlag = cli.DurationFlag{
+		Name:   "max-db-age",
+		Usage:  "fail when the DB was updated longer ago than it, e.g. 72h (0 disables the check)",
+		EnvVar: "TRIVY_MAX_DB_AGE",
+	}
+
+	staleDBGraceFlag = cli.DurationFlag{
+		Name:   "stale-db-grace",
+		Usage:  "only warn when the DB is older than --max-db-age by less than it",
+		EnvVar: "TRIVY_STALE_DB_GRACE",
+	}
+
+	customCACertFlag = cli.StringFlag{
+		Name:   "custom-ca-cert",
+		Usage:  "PEM file of the CA certificates trusted for all the outbound connections, e.g. of a proxy inspecting TLS",
+		EnvVar: "TRIVY_CUSTOM_CA_CERT",
+	}
+
+	insecureRegistryFlag = cli.StringSliceFlag{
+		Name:   "insecure-registry",
+		Usage:  "host of a registry whose certificate isn't verified, e.g. registry.local:5000, repeated for several registries",
+		EnvVar: "TRIVY_INSECURE_REGISTRY",
+	}
+
 	timeoutFlag = cli.DurationFlag{
 		Name:   "timeout",
-		Value:  time.Second * 120,
-		Usage:  "docker timeout",
+		Usage:  "timeout of the scan, the DB download and the image pull included, none by default, the pull of an image timing out after 2m without it",
 		EnvVar: "TRIVY_TIMEOUT",
 	}
 
+	partialResultsFlag = cli.BoolFlag{
+		Name:   "partial-results",
+		Usage:  "write the results of the vulnerability types scanned before --timeout or before the others failed, instead of failing",
+		EnvVar: "TRIVY_PARTIAL_RESULTS",
+	}
+
+	parallelFlag = cli.IntFlag{
+		Name:   "parallel",
+		Usage:  "number of layers extracted and lock files scanned concurrently, 0 for all the layers at once and the lock files in turn",
+		EnvVar: "TRIVY_PARALLEL",
+	}
+
 	lightFlag = cli.BoolFlag{
 		Name:   "light",
 		Usage:  "light mode: it's faster, but vulnerability descriptions and references are not displayed",
@@ -192,7 +625,7 @@ OPTIONS:
 	app := cli.NewApp()
 	app.Name = "trivy"
 	app.Version = version
-	app.ArgsUsage = "image_name"
+	app.ArgsUsage = "image_name [image_name...]"
 
 	app.Usage = "A simple and comprehensive vulnerability scanner for containers"
 
@@ -201,23 +634,90 @@ OPTIONS:
 	app.Flags = []cli.Flag{
 		templateFlag,
 		formatFlag,
+		topFlag,
+		sccSourceFlag,
+		kafkaBrokersFlag,
+		kafkaTopicFlag,
+		reportFlag,
+		artifactMetadataFlag,
+		baseImageFlag,
+		complianceFlag,
 		inputFlag,
+		inputListFlag,
+		concurrencyFlag,
+		runtimeFlag,
+		containerdSocketFlag,
+		containerdNamespaceFlag,
+		podmanSocketFlag,
 		severityFlag,
 		outputFlag,
+		listAllPkgsFlag,
+		recordHistoryFlag,
+		historyDBFlag,
 		exitCodeFlag,
+		exitOnSeverityFlag,
+		eolSeverityFlag,
+		exitOnEOLFlag,
 		skipUpdateFlag,
+		dbRepositoryFlag,
+		advisoryDirFlag,
+		offlineScanFlag,
 		downloadDBOnlyFlag,
+		maxDBAgeFlag,
+		staleDBGraceFlag,
 		resetFlag,
 		clearCacheFlag,
 		quietFlag,
+		configFileFlag,
+		profileFlag,
 		noProgressFlag,
+		progressFlag,
 		ignoreUnfixedFlag,
+		separateDeferredFlag,
 		debugFlag,
 		removedPkgsFlag,
 		vulnTypeFlag,
+		securityChecksFlag,
+		secretConfigFlag,
+		configPolicyFlag,
+		licenseForbiddenFlag,
+		goBinariesFlag,
+		goBinaryDirsFlag,
+		javaArchivesFlag,
+		customAnalyzerFlag,
+		customAnalyzerRuntimeFlag,
 		cacheDirFlag,
+		cacheBackendFlag,
+		cacheTTLFlag,
+		redisTLSFlag,
+		redisCACertFlag,
+		redisCertFlag,
+		redisKeyFlag,
 		ignoreFileFlag,
+		showSuppressedFlag,
+		vexFlag,
+		attestFlag,
+		verifyAttestationFlag,
+		keyFlag,
+		ignorePolicyFlag,
+		severitySourceFlag,
+		exploitDataFlag,
+		epssAboveFlag,
+		kevOnlyFlag,
+		notifyWebhookFlag,
+		notifyFormatFlag,
+		notifySecretFlag,
+		outputPluginFlag,
+		metricsPushgatewayFlag,
 		timeoutFlag,
+		customCACertFlag,
+		insecureRegistryFlag,
+		partialResultsFlag,
+		noCacheFlag,
+		skipDirsFlag,
+		skipFilesFlag,
+		filePatternsFlag,
+		parallelFlag,
 		lightFlag,
 
 		// deprecated options
@@ -241,7 +741,19 @@ OPTIONS:
 	app.Commands = []cli.Command{
 		NewClientCommand(),
 		NewServerCommand(),
+		NewFilesystemCommand(),
+		NewRootfsCommand(),
+		NewRepositoryCommand(),
+		NewSBOMCommand(),
+		NewKubernetesCommand(),
+		NewDBCommand(),
+		NewCacheCommand(),
+		NewDiffCommand(),
+		NewHistoryCommand(),
+		NewPluginCommand(),
 	}
+	withConfigFile(app)
+	app.Commands = append(app.Commands, pluginCmd.Commands(app.Commands)...)
 
 	app.Action = standalone.Run
 	return app
@@ -297,19 +809,48 @@ func NewClientCommand() cli.Command {
 		Flags: []cli.Flag{
 			templateFlag,
 			formatFlag,
+			topFlag,
+			sccSourceFlag,
+			kafkaBrokersFlag,
+			kafkaTopicFlag,
+			reportFlag,
 			inputFlag,
+			runtimeFlag,
+			containerdSocketFlag,
+			containerdNamespaceFlag,
+			podmanSocketFlag,
 			severityFlag,
 			outputFlag,
 			exitCodeFlag,
+			exitOnSeverityFlag,
+			eolSeverityFlag,
+			exitOnEOLFlag,
 			clearCacheFlag,
 			quietFlag,
+			configFileFlag,
+			profileFlag,
+			progressFlag,
 			ignoreUnfixedFlag,
 			debugFlag,
 			removedPkgsFlag,
 			vulnTypeFlag,
+			goBinariesFlag,
+			goBinaryDirsFlag,
+			javaArchivesFlag,
+			customAnalyzerFlag,
+			customAnalyzerRuntimeFlag,
 			ignoreFileFlag,
+			vexFlag,
 			cacheDirFlag,
 			timeoutFlag,
+			customCACertFlag,
+			insecureRegistryFlag,
+			partialResultsFlag,
+			notifyWebhookFlag,
+			notifyFormatFlag,
+			notifySecretFlag,
+			outputPluginFlag,
+			metricsPushgatewayFlag,
 
 			// original flags
 			token,
@@ -329,6 +870,402 @@ func NewClientCommand() cli.Command {
 	}
 }
 
+func NewFilesystemCommand() cli.Command {
+	return cli.Command{
+		Name:      "fs",
+		Usage:     "scan a local directory, e.g. a CI workspace",
+		ArgsUsage: "directory",
+		Action:    standalone.RunFilesystem,
+		Flags: []cli.Flag{
+			templateFlag,
+			formatFlag,
+			topFlag,
+			sccSourceFlag,
+			kafkaBrokersFlag,
+			kafkaTopicFlag,
+			reportFlag,
+			artifactMetadataFlag,
+			severityFlag,
+			outputFlag,
+			listAllPkgsFlag,
+			recordHistoryFlag,
+			historyDBFlag,
+			exitCodeFlag,
+			exitOnSeverityFlag,
+			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
+			offlineScanFlag,
+			maxDBAgeFlag,
+			staleDBGraceFlag,
+			quietFlag,
+			configFileFlag,
+			profileFlag,
+			noProgressFlag,
+			progressFlag,
+			ignoreUnfixedFlag,
+			separateDeferredFlag,
+			debugFlag,
+			vulnTypeFlag,
+			securityChecksFlag,
+			secretConfigFlag,
+			configPolicyFlag,
+			licenseForbiddenFlag,
+			goBinariesFlag,
+			goBinaryDirsFlag,
+			javaArchivesFlag,
+			customAnalyzerFlag,
+			customAnalyzerRuntimeFlag,
+			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			ignoreFileFlag,
+			showSuppressedFlag,
+			vexFlag,
+			ignorePolicyFlag,
+			severitySourceFlag,
+			exploitDataFlag,
+			epssAboveFlag,
+			kevOnlyFlag,
+			notifyWebhookFlag,
+			notifyFormatFlag,
+			notifySecretFlag,
+			outputPluginFlag,
+			metricsPushgatewayFlag,
+			dependencyTreeFlag,
+			timeoutFlag,
+			customCACertFlag,
+			partialResultsFlag,
+			noCacheFlag,
+			skipDirsFlag,
+			skipFilesFlag,
+			filePatternsFlag,
+			parallelFlag,
+			lightFlag,
+		},
+	}
+}
+
+func NewRootfsCommand() cli.Command {
+	return cli.Command{
+		Name:      "rootfs",
+		Usage:     "scan the root filesystem of a host, a VM or a container, e.g. of docker export or a mounted VM disk",
+		ArgsUsage: "root_dir",
+		Action:    standalone.RunRootfs,
+		Flags: []cli.Flag{
+			templateFlag,
+			formatFlag,
+			topFlag,
+			sccSourceFlag,
+			kafkaBrokersFlag,
+			kafkaTopicFlag,
+			reportFlag,
+			artifactMetadataFlag,
+			severityFlag,
+			outputFlag,
+			listAllPkgsFlag,
+			recordHistoryFlag,
+			historyDBFlag,
+			exitCodeFlag,
+			exitOnSeverityFlag,
+			eolSeverityFlag,
+			exitOnEOLFlag,
+			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
+			offlineScanFlag,
+			maxDBAgeFlag,
+			staleDBGraceFlag,
+			quietFlag,
+			configFileFlag,
+			profileFlag,
+			noProgressFlag,
+			progressFlag,
+			ignoreUnfixedFlag,
+			separateDeferredFlag,
+			debugFlag,
+			vulnTypeFlag,
+			securityChecksFlag,
+			secretConfigFlag,
+			configPolicyFlag,
+			licenseForbiddenFlag,
+			goBinariesFlag,
+			goBinaryDirsFlag,
+			javaArchivesFlag,
+			customAnalyzerFlag,
+			customAnalyzerRuntimeFlag,
+			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			ignoreFileFlag,
+			showSuppressedFlag,
+			vexFlag,
+			ignorePolicyFlag,
+			severitySourceFlag,
+			exploitDataFlag,
+			epssAboveFlag,
+			kevOnlyFlag,
+			notifyWebhookFlag,
+			notifyFormatFlag,
+			notifySecretFlag,
+			outputPluginFlag,
+			metricsPushgatewayFlag,
+			timeoutFlag,
+			customCACertFlag,
+			partialResultsFlag,
+			noCacheFlag,
+			skipDirsFlag,
+			skipFilesFlag,
+			filePatternsFlag,
+			parallelFlag,
+			lightFlag,
+		},
+	}
+}
+
+func NewRepositoryCommand() cli.Command {
+	return cli.Command{
+		Name:      "repo",
+		Usage:     "scan a remote git repository",
+		ArgsUsage: "repo_url",
+		Action:    standalone.RunRepository,
+		Flags: []cli.Flag{
+			templateFlag,
+			formatFlag,
+			topFlag,
+			sccSourceFlag,
+			kafkaBrokersFlag,
+			kafkaTopicFlag,
+			reportFlag,
+			artifactMetadataFlag,
+			severityFlag,
+			outputFlag,
+			listAllPkgsFlag,
+			recordHistoryFlag,
+			historyDBFlag,
+			exitCodeFlag,
+			exitOnSeverityFlag,
+			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
+			maxDBAgeFlag,
+			staleDBGraceFlag,
+			quietFlag,
+			configFileFlag,
+			profileFlag,
+			noProgressFlag,
+			progressFlag,
+			ignoreUnfixedFlag,
+			separateDeferredFlag,
+			debugFlag,
+			vulnTypeFlag,
+			securityChecksFlag,
+			secretConfigFlag,
+			configPolicyFlag,
+			licenseForbiddenFlag,
+			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			ignoreFileFlag,
+			showSuppressedFlag,
+			vexFlag,
+			ignorePolicyFlag,
+			severitySourceFlag,
+			exploitDataFlag,
+			epssAboveFlag,
+			kevOnlyFlag,
+			notifyWebhookFlag,
+			notifyFormatFlag,
+			notifySecretFlag,
+			outputPluginFlag,
+			metricsPushgatewayFlag,
+			dependencyTreeFlag,
+			timeoutFlag,
+			customCACertFlag,
+			partialResultsFlag,
+			noCacheFlag,
+			skipDirsFlag,
+			skipFilesFlag,
+			filePatternsFlag,
+			parallelFlag,
+			lightFlag,
+
+			cli.StringFlag{
+				Name:   "branch",
+				Usage:  "branch or tag to scan, the default branch if empty",
+				EnvVar: "TRIVY_BRANCH",
+			},
+			cli.StringFlag{
+				Name:   "tag",
+				Usage:  "tag to scan",
+				EnvVar: "TRIVY_TAG",
+			},
+			cli.StringFlag{
+				Name:   "commit",
+				Usage:  "full hash of the commit to scan",
+				EnvVar: "TRIVY_COMMIT",
+			},
+			cli.StringFlag{
+				Name:   "git-token",
+				Usage:  "token authenticating the clone of a private repository over HTTPS",
+				EnvVar: "TRIVY_GIT_TOKEN",
+			},
+		},
+	}
+}
+
+// NewSBOMCommand scans the packages of an SBOM, e.g. to re-scan the SBOMs of past releases as new vulnerabilities are published
+func NewSBOMCommand() cli.Command {
+	return cli.Command{
+		Name:      "sbom",
+		Usage:     "scan the packages of a CycloneDX or SPDX document",
+		ArgsUsage: "sbom_file",
+		Action:    standalone.RunSBOM,
+		Flags: []cli.Flag{
+			templateFlag,
+			formatFlag,
+			topFlag,
+			sccSourceFlag,
+			kafkaBrokersFlag,
+			kafkaTopicFlag,
+			reportFlag,
+			artifactMetadataFlag,
+			severityFlag,
+			outputFlag,
+			listAllPkgsFlag,
+			recordHistoryFlag,
+			historyDBFlag,
+			exitCodeFlag,
+			exitOnSeverityFlag,
+			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
+			offlineScanFlag,
+			maxDBAgeFlag,
+			staleDBGraceFlag,
+			quietFlag,
+			configFileFlag,
+			profileFlag,
+			noProgressFlag,
+			progressFlag,
+			ignoreUnfixedFlag,
+			separateDeferredFlag,
+			debugFlag,
+			vulnTypeFlag,
+			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			ignoreFileFlag,
+			showSuppressedFlag,
+			vexFlag,
+			ignorePolicyFlag,
+			severitySourceFlag,
+			exploitDataFlag,
+			epssAboveFlag,
+			kevOnlyFlag,
+			notifyWebhookFlag,
+			notifyFormatFlag,
+			notifySecretFlag,
+			outputPluginFlag,
+			metricsPushgatewayFlag,
+			timeoutFlag,
+			customCACertFlag,
+			partialResultsFlag,
+			noCacheFlag,
+			parallelFlag,
+			lightFlag,
+		},
+	}
+}
+
+// NewKubernetesCommand scans the images of the workloads of a cluster, e.g. to audit the images actually deployed
+func NewKubernetesCommand() cli.Command {
+	return cli.Command{
+		Name:   "k8s",
+		Usage:  "scan the images of the workloads in a Kubernetes cluster",
+		Action: standalone.RunKubernetes,
+		Flags: []cli.Flag{
+			formatFlag,
+			severityFlag,
+			outputFlag,
+			exitCodeFlag,
+			exitOnSeverityFlag,
+			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
+			maxDBAgeFlag,
+			staleDBGraceFlag,
+			quietFlag,
+			configFileFlag,
+			profileFlag,
+			noProgressFlag,
+			ignoreUnfixedFlag,
+			separateDeferredFlag,
+			debugFlag,
+			removedPkgsFlag,
+			vulnTypeFlag,
+			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			timeoutFlag,
+			customCACertFlag,
+			insecureRegistryFlag,
+			ignoreFileFlag,
+			vexFlag,
+			ignorePolicyFlag,
+			severitySourceFlag,
+			exploitDataFlag,
+			epssAboveFlag,
+			kevOnlyFlag,
+			parallelFlag,
+			lightFlag,
+
+			cli.StringFlag{
+				Name:   "kubeconfig",
+				Usage:  "kubeconfig file, the first file of KUBECONFIG or ~/.kube/config if empty, then the service account in a pod",
+				EnvVar: "TRIVY_KUBECONFIG",
+			},
+			cli.StringFlag{
+				Name:   "context",
+				Usage:  "context of the kubeconfig file, the current context if empty",
+				EnvVar: "TRIVY_CONTEXT",
+			},
+			cli.StringFlag{
+				Name:   "namespace, n",
+				Usage:  "namespace of the workloads, all the namespaces if empty",
+				EnvVar: "TRIVY_NAMESPACE",
+			},
+			cli.StringFlag{
+				Name:   "report",
+				Value:  "summary",
+				Usage:  "summary of the vulnerabilities per workload, or all with the vulnerabilities of each image (summary, all)",
+				EnvVar: "TRIVY_REPORT",
+			},
+			concurrencyFlag,
+		},
+	}
+}
+
 func NewServerCommand() cli.Command {
 	return cli.Command{
 		Name:    "server",
@@ -337,11 +1274,24 @@ func NewServerCommand() cli.Command {
 		Action:  server.Run,
 		Flags: []cli.Flag{
 			skipUpdateFlag,
+			dbRepositoryFlag,
+			advisoryDirFlag,
 			downloadDBOnlyFlag,
 			resetFlag,
 			quietFlag,
+			configFileFlag,
+			profileFlag,
 			debugFlag,
 			cacheDirFlag,
+			cacheBackendFlag,
+			cacheTTLFlag,
+			redisTLSFlag,
+			redisCACertFlag,
+			redisCertFlag,
+			redisKeyFlag,
+			timeoutFlag,
+			customCACertFlag,
+			insecureRegistryFlag,
 
 			// original flags
 			token,
@@ -352,6 +1302,209 @@ func NewServerCommand() cli.Command {
 				Usage:  "listen address",
 				EnvVar: "TRIVY_LISTEN",
 			},
+			cli.StringFlag{
+				Name:   "grpc-listen",
+				Usage:  "listen address of the gRPC server streaming the results of scans run on the server",
+				EnvVar: "TRIVY_GRPC_LISTEN",
+			},
+		},
+	}
+}
+
+func NewDBCommand() cli.Command {
+	flags := []cli.Flag{
+		quietFlag,
+		debugFlag,
+		cacheDirFlag,
+	}
+	return cli.Command{
+		Name:  "db",
+		Usage: "export and import the vulnerability DB for hosts without network access",
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "write the DB of the cache directory and its metadata to a tarball",
+				ArgsUsage: "bundle_file",
+				Action:    exportDB,
+				Flags:     flags,
+			},
+			{
+				Name:      "import",
+				Usage:     "load the DB of a tarball written by the export command into the cache directory",
+				ArgsUsage: "bundle_file",
+				Action:    importDB,
+				Flags:     flags,
+			},
 		},
 	}
 }
+
+func NewCacheCommand() cli.Command {
+	return cli.Command{
+		Name:  "cache",
+		Usage: "manage the caches of the analyzed layers and of the results",
+		Subcommands: []cli.Command{
+			{
+				Name:   "clean",
+				Usage:  "remove the analyzed layers and the results, or only the results with --results",
+				Action: cleanCache,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:   "results",
+						Usage:  "remove only the cached results, the next scans matching the packages of the cached layers again",
+						EnvVar: "TRIVY_RESULTS",
+					},
+					quietFlag,
+					debugFlag,
+					cacheDirFlag,
+					cacheBackendFlag,
+					redisTLSFlag,
+					redisCACertFlag,
+					redisCertFlag,
+					redisKeyFlag,
+				},
+			},
+		},
+	}
+}
+
+func NewDiffCommand() cli.Command {
+	return cli.Command{
+		Name:      "diff",
+		Usage:     "compare the JSON reports of two scans and show the new, fixed and unchanged vulnerabilities",
+		ArgsUsage: "baseline_report current_report",
+		Action:    diff.Run,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "format, f",
+				Value:  "table",
+				Usage:  "format (table, json)",
+				EnvVar: "TRIVY_FORMAT",
+			},
+			cli.IntFlag{
+				Name:   "exit-code",
+				Usage:  "Exit code when new vulnerabilities were found",
+				EnvVar: "TRIVY_EXIT_CODE",
+			},
+			severityFlag,
+			outputFlag,
+			quietFlag,
+			debugFlag,
+		},
+	}
+}
+
+// NewHistoryCommand is the command querying the history of the scans recorded with --record-history
+func NewHistoryCommand() cli.Command {
+	return cli.Command{
+		Name:      "history",
+		Usage:     "show how the vulnerabilities of an image changed over the scans recorded with --record-history",
+		ArgsUsage: "image_name",
+		Action:    history.Run,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "format, f",
+				Value:  "table",
+				Usage:  "format (table, json)",
+				EnvVar: "TRIVY_FORMAT",
+			},
+			cli.StringFlag{
+				Name:   "vuln",
+				Usage:  "vulnerability ID, e.g. CVE-2019-14697, to show when it first and last appeared in each package",
+				EnvVar: "TRIVY_VULN",
+			},
+			historyDBFlag,
+			cacheDirFlag,
+			outputFlag,
+			quietFlag,
+			debugFlag,
+		},
+	}
+}
+
+func NewPluginCommand() cli.Command {
+	flags := []cli.Flag{
+		quietFlag,
+		debugFlag,
+	}
+	return cli.Command{
+		Name:    "plugin",
+		Aliases: []string{"p"},
+		Usage:   "manage the plugins of ~/.trivy/plugins, run as subcommands or as output plugins",
+		Subcommands: []cli.Command{
+			{
+				Name:      "install",
+				Aliases:   []string{"i"},
+				Usage:     "install a plugin from a directory with plugin.yaml, an executable or a git repository",
+				ArgsUsage: "dir | executable | git_url",
+				Action:    pluginCmd.Install,
+				Flags:     flags,
+			},
+			{
+				Name:      "uninstall",
+				Aliases:   []string{"u"},
+				Usage:     "uninstall a plugin",
+				ArgsUsage: "plugin_name",
+				Action:    pluginCmd.Uninstall,
+				Flags:     flags,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "list the installed plugins",
+				Action:  pluginCmd.List,
+			},
+			{
+				Name:            "run",
+				Aliases:         []string{"r"},
+				Usage:           "run an installed plugin with its arguments",
+				ArgsUsage:       "plugin_name [args...]",
+				Action:          pluginCmd.Run,
+				SkipFlagParsing: true,
+			},
+		},
+	}
+}
+
+// cleanCache removes the cached results, and the analyzed layers of the cache backend unless only the results are removed
+func cleanCache(c *cli.Context) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	cacheDir := c.String("cache-dir")
+	if !c.Bool("results") {
+		cacheClient, err := cache.NewCache(c.String("cache-backend"), cacheDir, cache.RedisOptions{
+			TLS:    c.Bool("redis-tls"),
+			CACert: c.String("redis-ca"),
+			Cert:   c.String("redis-cert"),
+			Key:    c.String("redis-key"),
+		})
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the cache: %w", err)
+		}
+		if err = operation.NewCache(cacheClient).ClearImages(); err != nil {
+			return err
+		}
+	}
+	log.Logger.Info("Removing the cached results...")
+	return cache.ClearResults(cacheDir)
+}
+
+func exportDB(c *cli.Context) error {
+	return runDBBundle(c, operation.ExportDB)
+}
+
+func importDB(c *cli.Context) error {
+	return runDBBundle(c, operation.ImportDB)
+}
+
+func runDBBundle(c *cli.Context, run func(cacheDir, bundle string) error) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the path of the bundle is required")
+	}
+	return run(c.String("cache-dir"), c.Args().First())
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -12,7 +13,13 @@ import (
 	"golang.org/x/xerrors"
 
 	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/progress"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/utils"
 )
 
 type Config struct {
@@ -21,6 +28,8 @@ type Config struct {
 
 	Quiet bool
 	Debug bool
+	// Progress is the format of the events of the scan on stderr, one of progress.Formats
+	Progress string
 
 	CacheDir   string
 	ClearCache bool
@@ -29,14 +38,61 @@ type Config struct {
 	output   string
 	Format   string
 	Template string
+	TopN     int
+	// SCCSource is the Security Command Center source of the findings of --format scc
+	SCCSource string
+	// KafkaBrokers and KafkaTopic are where the findings of --format kafka are published
+	KafkaBrokers []string
+	KafkaTopic   string
+	// Report is report.ReportSummary, writing the number of findings per severity of each target, or report.ReportAll
+	Report string
 
+	// runtime, ContainerdSocket, ContainerdNamespace and PodmanSocket select where a local image is read from
+	runtime             string
+	ContainerdSocket    string
+	ContainerdNamespace string
+	PodmanSocket        string
+
+	// Timeout limits the whole run: the DB download, the image pull and the scan
 	Timeout         time.Duration
+	PartialResults  bool
 	ScanRemovedPkgs bool
+	GoBinaries      bool
+	goBinaryDirs    string
+	JavaArchives    bool
 	vulnType        string
 	severities      string
 	IgnoreFile      string
+	VEXFile         string
 	IgnoreUnfixed   bool
 	ExitCode        int
+	exitOnSeverity  string
+
+	// CustomAnalyzerDirs are the directories of the custom analyzers, see customanalyzer.LoadDir,
+	// run by the command of CustomAnalyzerRuntime
+	CustomAnalyzerDirs    []string
+	CustomAnalyzerRuntime string
+
+	// CustomCACert is the PEM file of the CA certificates trusted for all the outbound connections, and
+	// InsecureRegistries the hosts of the registries whose certificates aren't verified, see transport.Configure
+	CustomCACert       string
+	InsecureRegistries []string
+
+	// EOLSeverity is the severity of the finding of an OS no longer supported by its distribution, none when empty,
+	// and ExitOnEOL the exit code of the scan finding one
+	EOLSeverity string
+	ExitOnEOL   int
+
+	// NotifyWebhook is the URL the results are pushed to in NotifyFormat, see report.NewSink
+	NotifyWebhook string
+	NotifyFormat  string
+	NotifySecret  string
+
+	// OutputPlugin is the installed output plugin the results are written with instead of Format
+	OutputPlugin string
+
+	// MetricsPushgateway is the URL of the Pushgateway the metrics of the scan are pushed to
+	MetricsPushgateway string
 
 	RemoteAddr    string
 	token         string
@@ -47,9 +103,18 @@ type Config struct {
 	// these variables are generated by Init()
 	ImageName  string
 	VulnType   []string
-	Output     *os.File
+	Output     io.Writer
+	OutputPath string
+	// outputFile is the file of --output to close, if any
+	outputFile io.Closer
 	Severities []dbTypes.Severity
 	AppVersion string
+	// Runtime is the runtime of --runtime, daemon.Auto without the option
+	Runtime daemon.Runtime
+	// GoBinaryDirs are the directories of --go-binary-dirs, which implies GoBinaries, nil without the option
+	GoBinaryDirs []string
+	// ExitOnSeverities are the severity of --exit-on-severity and the higher ones, nil without the option
+	ExitOnSeverities []string
 }
 
 func New(c *cli.Context) (Config, error) {
@@ -63,8 +128,9 @@ func New(c *cli.Context) (Config, error) {
 		context: c,
 		logger:  logger,
 
-		Quiet: quiet,
-		Debug: debug,
+		Quiet:    quiet,
+		Debug:    debug,
+		Progress: c.String("progress"),
 
 		CacheDir:   c.String("cache-dir"),
 		ClearCache: c.Bool("clear-cache"),
@@ -73,14 +139,48 @@ func New(c *cli.Context) (Config, error) {
 		output:   c.String("output"),
 		Format:   c.String("format"),
 		Template: c.String("template"),
+		TopN:     c.Int("top"),
+		Report:   c.String("report"),
+
+		SCCSource:    c.String("scc-source"),
+		KafkaBrokers: c.StringSlice("kafka-brokers"),
+		KafkaTopic:   c.String("kafka-topic"),
+
+		runtime:             c.String("runtime"),
+		ContainerdSocket:    c.String("containerd-socket"),
+		ContainerdNamespace: c.String("containerd-namespace"),
+		PodmanSocket:        c.String("podman-socket"),
 
 		Timeout:         c.Duration("timeout"),
+		PartialResults:  c.Bool("partial-results"),
 		ScanRemovedPkgs: c.Bool("removed-pkgs"),
+		GoBinaries:      c.Bool("go-binaries"),
+		goBinaryDirs:    c.String("go-binary-dirs"),
+		JavaArchives:    c.Bool("java-archives"),
 		vulnType:        c.String("vuln-type"),
 		severities:      c.String("severity"),
 		IgnoreFile:      c.String("ignorefile"),
+		VEXFile:         c.String("vex"),
 		IgnoreUnfixed:   c.Bool("ignore-unfixed"),
 		ExitCode:        c.Int("exit-code"),
+		exitOnSeverity:  c.String("exit-on-severity"),
+
+		CustomAnalyzerDirs:    c.StringSlice("custom-analyzer"),
+		CustomAnalyzerRuntime: c.String("custom-analyzer-runtime"),
+
+		CustomCACert:       c.String("custom-ca-cert"),
+		InsecureRegistries: c.StringSlice("insecure-registry"),
+
+		EOLSeverity: c.String("eol-severity"),
+		ExitOnEOL:   c.Int("exit-on-eol"),
+
+		NotifyWebhook: c.String("notify-webhook"),
+		NotifyFormat:  c.String("notify-format"),
+		NotifySecret:  c.String("notify-secret"),
+
+		OutputPlugin: c.String("output-plugin"),
+
+		MetricsPushgateway: c.String("metrics-pushgateway"),
 
 		RemoteAddr:    c.String("remote"),
 		token:         c.String("token"),
@@ -90,8 +190,15 @@ func New(c *cli.Context) (Config, error) {
 }
 
 func (c *Config) Init() (err error) {
+	if c.Progress != "" && !utils.StringInSlice(c.Progress, progress.Formats) {
+		return xerrors.Errorf("invalid --progress: %s, expected one of %s", c.Progress, strings.Join(progress.Formats, ", "))
+	}
 	c.Severities = c.splitSeverity(c.severities)
 	c.VulnType = strings.Split(c.vulnType, ",")
+	if c.goBinaryDirs != "" {
+		c.GoBinaryDirs = strings.Split(c.goBinaryDirs, ",")
+		c.GoBinaries = true
+	}
 	c.AppVersion = c.context.App.Version
 	c.CustomHeaders = splitCustomHeaders(c.customHeaders)
 
@@ -100,6 +207,44 @@ func (c *Config) Init() (err error) {
 		c.CustomHeaders.Set(c.tokenHeader, c.token)
 	}
 
+	if c.Runtime, err = daemon.ParseRuntime(c.runtime); err != nil {
+		return xerrors.Errorf("invalid --runtime: %w", err)
+	}
+	if c.Format == "scc" && c.SCCSource == "" {
+		return xerrors.New("--format scc requires --scc-source")
+	}
+	if c.Format == "kafka" && (len(c.KafkaBrokers) == 0 || c.KafkaTopic == "") {
+		return xerrors.New("--format kafka requires --kafka-brokers and --kafka-topic")
+	}
+	if c.exitOnSeverity != "" {
+		if c.ExitCode == 0 {
+			c.logger.Warn("--exit-on-severity is ignored because --exit-code is not specified.")
+		}
+		if c.ExitOnSeverities, err = report.SeveritiesFrom(c.exitOnSeverity); err != nil {
+			return xerrors.Errorf("invalid --exit-on-severity: %w", err)
+		}
+	}
+	if c.EOLSeverity != "" {
+		severity, err := dbTypes.NewSeverity(strings.ToUpper(c.EOLSeverity))
+		if err != nil {
+			return xerrors.Errorf("invalid --eol-severity: %w", err)
+		}
+		c.EOLSeverity = severity.String()
+	}
+	if c.Report != "" && c.Report != report.ReportAll {
+		if c.Report != report.ReportSummary {
+			return xerrors.Errorf("invalid --report: %s is neither %s nor %s", c.Report, report.ReportSummary, report.ReportAll)
+		}
+		if c.Format != "table" && c.Format != "json" {
+			return xerrors.Errorf("--report summary doesn't support --format %s, use table or json", c.Format)
+		}
+	}
+	if c.NotifyWebhook != "" {
+		if _, err = report.NewSink(c.NotifyFormat, c.NotifyWebhook, c.NotifySecret); err != nil {
+			return xerrors.Errorf("invalid --notify-format: %w", err)
+		}
+	}
+
 	// --clear-cache doesn't conduct the scan
 	if c.ClearCache {
 		return nil
@@ -116,10 +261,18 @@ func (c *Config) Init() (err error) {
 	}
 
 	c.Output = os.Stdout
-	if c.output != "" {
-		if c.Output, err = os.Create(c.output); err != nil {
-			return xerrors.Errorf("failed to create an output file: %w", err)
+	if c.Format == "sqlite" {
+		// the database is appended to, not truncated
+		if c.output == "" {
+			return xerrors.New("--format sqlite requires --output to specify the database file")
+		}
+		c.OutputPath = c.output
+	} else if c.output != "" {
+		output, err := report.CreateOutput(c.output)
+		if err != nil {
+			return err
 		}
+		c.Output, c.outputFile = output, output
 	}
 
 	if c.Input == "" {
@@ -140,6 +293,27 @@ func (c *Config) Init() (err error) {
 	return nil
 }
 
+// DaemonOption returns the options of the runtimes a local image is read from
+// CloseOutput closes the file of --output, flushing the results, e.g. gzipped
+func (c Config) CloseOutput() error {
+	if c.outputFile == nil {
+		return nil
+	}
+	return c.outputFile.Close()
+}
+
+func (c Config) DaemonOption() daemon.Option {
+	return daemon.Option{
+		Runtime: c.Runtime,
+		Containerd: containerd.Option{
+			Socket:    c.ContainerdSocket,
+			Namespace: c.ContainerdNamespace,
+			Timeout:   c.Timeout,
+		},
+		Podman: podman.Option{Socket: c.PodmanSocket},
+	}
+}
+
 func (c *Config) splitSeverity(severity string) []dbTypes.Severity {
 	c.logger.Debugf("Severities: %s", severity)
 	var severities []dbTypes.Severity
@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"io"
 	"net/http"
 	"os"
 	"reflect"
@@ -79,7 +80,7 @@ func TestConfig_Init(t *testing.T) {
 		ExitCode       int
 		ImageName      string
 		VulnType       []string
-		Output         *os.File
+		Output         io.Writer
 		Severities     []dbTypes.Severity
 		AppVersion     string
 		onlyUpdate     string
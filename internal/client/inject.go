@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
 	"github.com/aquasecurity/trivy/pkg/rpc/client"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/vulnerability"
@@ -25,6 +27,24 @@ func initializeArchiveScanner(ctx context.Context, filePath string, layerCache c
 	return scanner.Scanner{}, nil
 }
 
+func initializeOCIScanner(filePath string, layerCache cache.ImageCache, customHeaders client.CustomHeaders,
+	url client.RemoteURL) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.RemoteOCISet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializeContainerdScanner(ctx context.Context, imageName string, opt containerd.Option, layerCache cache.ImageCache,
+	customHeaders client.CustomHeaders, url client.RemoteURL) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.RemoteContainerdSet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializePodmanScanner(ctx context.Context, imageName string, opt podman.Option, layerCache cache.ImageCache,
+	customHeaders client.CustomHeaders, url client.RemoteURL) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.RemotePodmanSet)
+	return scanner.Scanner{}, nil, nil
+}
+
 func initializeVulnerabilityClient() vulnerability.Client {
 	wire.Build(vulnerability.SuperSet)
 	return vulnerability.Client{}
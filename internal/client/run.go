@@ -3,15 +3,27 @@ package client
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/aquasecurity/trivy/internal/client/config"
 	"github.com/aquasecurity/trivy/pkg/cache"
+	"github.com/aquasecurity/trivy/pkg/customanalyzer"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/gobinary"
+	"github.com/aquasecurity/trivy/pkg/jar"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/metrics"
+	"github.com/aquasecurity/trivy/pkg/plugin"
+	"github.com/aquasecurity/trivy/pkg/progress"
 	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/report/sqlite"
 	"github.com/aquasecurity/trivy/pkg/rpc/client"
 	"github.com/aquasecurity/trivy/pkg/scanner"
+	"github.com/aquasecurity/trivy/pkg/transport"
 	"github.com/aquasecurity/trivy/pkg/types"
 	"github.com/aquasecurity/trivy/pkg/utils"
+	"github.com/aquasecurity/trivy/pkg/windows"
 	"github.com/urfave/cli"
 	"golang.org/x/xerrors"
 )
@@ -25,6 +37,10 @@ func Run(cliCtx *cli.Context) error {
 }
 
 func run(c config.Config) (err error) {
+	ctx, cancel := withTimeout(c)
+	defer cancel()
+	defer func() { err = explainTimeout(ctx, c, err) }()
+
 	if err = log.InitLogger(c.Debug, c.Quiet); err != nil {
 		return xerrors.Errorf("failed to initialize a logger: %w", err)
 	}
@@ -33,6 +49,17 @@ func run(c config.Config) (err error) {
 	if err = c.Init(); err != nil {
 		return xerrors.Errorf("failed to initialize options: %w", err)
 	}
+	defer func() {
+		if cerr := c.CloseOutput(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	if err = progress.Init(c.Progress, c.Quiet); err != nil {
+		return xerrors.Errorf("failed to initialize the progress: %w", err)
+	}
+	if err = transport.Configure(transport.Options{CACert: c.CustomCACert, InsecureRegistries: c.InsecureRegistries}); err != nil {
+		return xerrors.Errorf("failed to configure the outbound connections: %w", err)
+	}
 
 	// configure cache dir
 	utils.SetCacheDir(c.CacheDir)
@@ -43,12 +70,42 @@ func run(c config.Config) (err error) {
 		return nil
 	}
 
+	if c.GoBinaries {
+		gobinary.Register(c.GoBinaryDirs)
+	}
+	if c.JavaArchives {
+		jar.Register(nil)
+	}
+	// the version and the installed updates of Windows images are detected from their servicing packages
+	windows.Register()
+	if len(c.CustomAnalyzerDirs) > 0 {
+		if err = customanalyzer.Register(c.CustomAnalyzerDirs, c.CustomAnalyzerRuntime); err != nil {
+			return xerrors.Errorf("invalid --custom-analyzer: %w", err)
+		}
+	}
+
+	var outputPlugin *plugin.Plugin
+	if c.OutputPlugin != "" {
+		// loaded before the scan so that a missing plugin doesn't waste it
+		p, err := plugin.NewManager(plugin.DefaultDir()).Load(c.OutputPlugin)
+		if err != nil {
+			return xerrors.Errorf("unable to load the output plugin: %w", err)
+		}
+		outputPlugin = &p
+	}
+
 	var scanner scanner.Scanner
-	ctx := context.Background()
 	remoteCache := cache.NewRemoteCache(cache.RemoteURL(c.RemoteAddr), c.CustomHeaders)
 
 	cleanup := func() {}
-	if c.Input != "" {
+	if c.Input != "" && oci.IsLayout(c.Input) {
+		// scan an OCI image layout, a directory or a tarball
+		scanner, cleanup, err = initializeOCIScanner(c.Input, remoteCache,
+			client.CustomHeaders(c.CustomHeaders), client.RemoteURL(c.RemoteAddr))
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the OCI layout scanner: %w", err)
+		}
+	} else if c.Input != "" {
 		// scan tar file
 		scanner, err = initializeArchiveScanner(ctx, c.Input, remoteCache,
 			client.CustomHeaders(c.CustomHeaders), client.RemoteURL(c.RemoteAddr), c.Timeout)
@@ -56,23 +113,43 @@ func run(c config.Config) (err error) {
 			return xerrors.Errorf("unable to initialize the archive scanner: %w", err)
 		}
 	} else {
-		// scan an image in Docker Engine or Docker Registry
-		scanner, cleanup, err = initializeDockerScanner(ctx, c.ImageName, remoteCache,
-			client.CustomHeaders(c.CustomHeaders), client.RemoteURL(c.RemoteAddr), c.Timeout)
+		// scan an image in Docker Engine, containerd, Podman or Docker Registry
+		progress.Start(progress.Pull, c.ImageName)
+		customHeaders, remoteURL := client.CustomHeaders(c.CustomHeaders), client.RemoteURL(c.RemoteAddr)
+		opt := c.DaemonOption()
+		runtime := daemon.Resolve(ctx, c.ImageName, opt)
+		switch runtime {
+		case daemon.Containerd:
+			scanner, cleanup, err = initializeContainerdScanner(ctx, c.ImageName, opt.Containerd, remoteCache,
+				customHeaders, remoteURL)
+		case daemon.Podman:
+			scanner, cleanup, err = initializePodmanScanner(ctx, c.ImageName, opt.Podman, remoteCache,
+				customHeaders, remoteURL)
+		default:
+			scanner, cleanup, err = initializeDockerScanner(ctx, c.ImageName, remoteCache, customHeaders, remoteURL, c.Timeout)
+		}
 		if err != nil {
-			return xerrors.Errorf("unable to initialize the docker scanner: %w", err)
+			return xerrors.Errorf("unable to initialize the %s scanner: %w", runtime, types.ExplainTLSError(err))
 		}
+		progress.Finish(progress.Pull, c.ImageName)
 	}
 	defer cleanup()
 
 	scanOptions := types.ScanOptions{
 		VulnType:            c.VulnType,
 		ScanRemovedPackages: c.ScanRemovedPkgs,
+		IgnoreFile:          c.IgnoreFile,
+		VEXFile:             c.VEXFile,
+		PartialResults:      c.PartialResults,
+		EOLSeverity:         c.EOLSeverity,
 	}
 	log.Logger.Debugf("Vulnerability type:  %s", scanOptions.VulnType)
 
-	results, err := scanner.ScanImage(scanOptions)
-	if err != nil {
+	start := time.Now()
+	imageReport, err := scanner.ScanImageReport(ctx, scanOptions)
+	results := imageReport.Results
+	if err != nil && !partialResults(c, err) {
+		pushMetrics(c, start, nil, err)
 		return xerrors.Errorf("error in image scan: %w", err)
 	}
 
@@ -81,17 +158,95 @@ func run(c config.Config) (err error) {
 		results[i].Vulnerabilities = vulnClient.Filter(results[i].Vulnerabilities,
 			c.Severities, c.IgnoreUnfixed, c.IgnoreFile)
 	}
+	pushMetrics(c, start, results, nil)
 
-	if err = report.WriteResults(c.Format, c.Output, results, c.Template, false); err != nil {
+	if outputPlugin != nil {
+		writer := plugin.Writer{Plugin: *outputPlugin, Output: c.Output}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if c.Format == "sqlite" {
+		writer := sqlite.Writer{Path: c.OutputPath, Image: imageReport.Image.Name, ImageID: imageReport.Image.ID}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if err = report.WriteResults(results, report.Option{
+		Format:         c.Format,
+		Output:         c.Output,
+		OutputTemplate: c.Template,
+		TopN:           c.TopN,
+		Report:         c.Report,
+		ArtifactName:   imageReport.Image.Name,
+		SCCSource:      c.SCCSource,
+		KafkaBrokers:   c.KafkaBrokers,
+		KafkaTopic:     c.KafkaTopic,
+		ImageID:        imageReport.Image.ID,
+	}); err != nil {
 		return xerrors.Errorf("unable to write results: %w", err)
 	}
 
-	if c.ExitCode != 0 {
-		for _, result := range results {
-			if len(result.Vulnerabilities) > 0 {
-				os.Exit(c.ExitCode)
-			}
+	if c.NotifyWebhook != "" {
+		sink, err := report.NewSink(c.NotifyFormat, c.NotifyWebhook, c.NotifySecret)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the notification: %w", err)
+		}
+		if err = sink.Write(results); err != nil {
+			return xerrors.Errorf("unable to notify %s: %w", c.NotifyWebhook, err)
 		}
 	}
+
+	// flushed before the exit codes, which skip the deferred calls
+	if err = c.CloseOutput(); err != nil {
+		return err
+	}
+
+	if code := report.ExitCodeGate(c.ExitOnSeverities).ExitCode(results, c.ExitCode); code != 0 {
+		os.Exit(code)
+	}
+	if c.ExitOnEOL != 0 && results.HasEOL() {
+		os.Exit(c.ExitOnEOL)
+	}
 	return nil
 }
+
+// partialResults reports whether the results of the partial scan are written anyway with --partial-results
+func partialResults(c config.Config, err error) bool {
+	var partialErr *scanner.PartialScanError
+	if !c.PartialResults || !xerrors.As(err, &partialErr) {
+		return false
+	}
+	log.Logger.Warnf("The results are partial: %s", partialErr)
+	return true
+}
+
+// withTimeout returns the context of the run, done after --timeout
+func withTimeout(c config.Config) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.Timeout)
+}
+
+// explainTimeout tells the options of the run that timed out
+func explainTimeout(ctx context.Context, c config.Config, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return xerrors.Errorf("timed out after %s, see --timeout and --partial-results: %w", c.Timeout, err)
+}
+
+// pushMetrics pushes the metrics of the scan to --metrics-pushgateway, without the age of the DB of the server.
+// A failing push is only logged not to fail the scan.
+func pushMetrics(c config.Config, start time.Time, results report.Results, scanErr error) {
+	if c.MetricsPushgateway == "" {
+		return
+	}
+	metrics.ObserveScan(start, results, scanErr)
+	reg, err := metrics.NewRegistry(nil, nil, false)
+	if err == nil {
+		err = metrics.Push(c.MetricsPushgateway, reg)
+	}
+	if err != nil {
+		log.Logger.Warnf("Unable to push the metrics: %s", err)
+	}
+}
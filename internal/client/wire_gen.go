@@ -1,7 +1,8 @@
 // Code generated by Wire. DO NOT EDIT.
 
 //go:generate wire
-//+build !wireinject
+//go:build !wireinject
+// +build !wireinject
 
 package client
 
@@ -9,8 +10,13 @@ import (
 	"context"
 	"github.com/aquasecurity/fanal/analyzer"
 	"github.com/aquasecurity/fanal/cache"
-	"github.com/aquasecurity/fanal/extractor/docker"
 	"github.com/aquasecurity/trivy-db/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/extractor/archive"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/registry"
 	"github.com/aquasecurity/trivy/pkg/rpc/client"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/types"
@@ -23,16 +29,17 @@ import (
 func initializeDockerScanner(ctx context.Context, imageName string, layerCache cache.ImageCache, customHeaders client.CustomHeaders, url client.RemoteURL, timeout time.Duration) (scanner.Scanner, func(), error) {
 	scannerScanner := client.NewProtobufClient(url)
 	clientScanner := client.NewScanner(customHeaders, scannerScanner)
-	dockerOption, err := types.GetDockerOption(timeout)
+	dockerOption, err := registry.GetDockerOption(ctx, imageName, timeout)
 	if err != nil {
 		return scanner.Scanner{}, nil, err
 	}
-	extractor, cleanup, err := docker.NewDockerExtractor(ctx, imageName, dockerOption)
+	extractor, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
 	if err != nil {
 		return scanner.Scanner{}, nil, err
 	}
 	config := analyzer.New(extractor, layerCache)
-	scanner2 := scanner.NewScanner(clientScanner, config)
+	imageAnalyzer := scanner.NewImageAnalyzer(config)
+	scanner2 := scanner.NewScanner(clientScanner, imageAnalyzer)
 	return scanner2, func() {
 		cleanup()
 	}, nil
@@ -45,15 +52,61 @@ func initializeArchiveScanner(ctx context.Context, filePath string, layerCache c
 	if err != nil {
 		return scanner.Scanner{}, err
 	}
-	extractor, err := docker.NewDockerArchiveExtractor(ctx, filePath, dockerOption)
+	extractor, err := archive.NewExtractor(ctx, filePath, dockerOption)
 	if err != nil {
 		return scanner.Scanner{}, err
 	}
 	config := analyzer.New(extractor, layerCache)
-	scanner2 := scanner.NewScanner(clientScanner, config)
+	imageAnalyzer := scanner.NewImageAnalyzer(config)
+	scanner2 := scanner.NewScanner(clientScanner, imageAnalyzer)
 	return scanner2, nil
 }
 
+func initializeOCIScanner(filePath string, layerCache cache.ImageCache, customHeaders client.CustomHeaders, url client.RemoteURL) (scanner.Scanner, func(), error) {
+	scannerScanner := client.NewProtobufClient(url)
+	clientScanner := client.NewScanner(customHeaders, scannerScanner)
+	extractor, cleanup, err := oci.NewExtractor(filePath)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	config := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(config)
+	scanner2 := scanner.NewScanner(clientScanner, imageAnalyzer)
+	return scanner2, func() {
+		cleanup()
+	}, nil
+}
+
+func initializeContainerdScanner(ctx context.Context, imageName string, opt containerd.Option, layerCache cache.ImageCache, customHeaders client.CustomHeaders, url client.RemoteURL) (scanner.Scanner, func(), error) {
+	scannerScanner := client.NewProtobufClient(url)
+	clientScanner := client.NewScanner(customHeaders, scannerScanner)
+	extractor, cleanup, err := containerd.NewExtractor(ctx, imageName, opt)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	config := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(config)
+	scanner2 := scanner.NewScanner(clientScanner, imageAnalyzer)
+	return scanner2, func() {
+		cleanup()
+	}, nil
+}
+
+func initializePodmanScanner(ctx context.Context, imageName string, opt podman.Option, layerCache cache.ImageCache, customHeaders client.CustomHeaders, url client.RemoteURL) (scanner.Scanner, func(), error) {
+	scannerScanner := client.NewProtobufClient(url)
+	clientScanner := client.NewScanner(customHeaders, scannerScanner)
+	extractor, cleanup, err := podman.NewExtractor(ctx, imageName, opt)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	config := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(config)
+	scanner2 := scanner.NewScanner(clientScanner, imageAnalyzer)
+	return scanner2, func() {
+		cleanup()
+	}, nil
+}
+
 func initializeVulnerabilityClient() vulnerability.Client {
 	config := db.Config{}
 	vulnerabilityClient := vulnerability.NewClient(config)
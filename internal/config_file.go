@@ -0,0 +1,254 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
+)
+
+// defaultConfigFile is read from the working directory when it exists and --config isn't set
+const defaultConfigFile = "trivy.yaml"
+
+// profilesKey is the key of the profiles in the config file, the other keys being the names of the flags
+const profilesKey = "profiles"
+
+// configFile sets the flags by their names, e.g. "severity: HIGH,CRITICAL" or "ignore-unfixed: true",
+// the flags of the selected profile overriding the top-level ones
+type configFile struct {
+	Flags    map[string]interface{}
+	Profiles map[string]map[string]interface{}
+}
+
+func readConfigFile(filePath string) (configFile, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return configFile{}, xerrors.Errorf("unable to read the config file: %w", err)
+	}
+	var raw map[string]interface{}
+	if err = yaml.Unmarshal(b, &raw); err != nil {
+		return configFile{}, xerrors.Errorf("invalid config file %s: %w", filePath, err)
+	}
+
+	cf := configFile{Flags: map[string]interface{}{}, Profiles: map[string]map[string]interface{}{}}
+	for key, value := range raw {
+		if key != profilesKey {
+			cf.Flags[key] = value
+			continue
+		}
+		profiles, ok := value.(map[string]interface{})
+		if !ok {
+			return configFile{}, xerrors.Errorf("invalid config file %s: %s must map the profile names to their flags", filePath, profilesKey)
+		}
+		for name, p := range profiles {
+			flags, ok := p.(map[string]interface{})
+			if !ok {
+				return configFile{}, xerrors.Errorf("invalid config file %s: profile %s must map the flag names to their values", filePath, name)
+			}
+			cf.Profiles[name] = flags
+		}
+	}
+	return cf, nil
+}
+
+// values returns the values of the flags with the profile, if any
+func (cf configFile) values(profile string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for name, value := range cf.Flags {
+		values[name] = value
+	}
+	if profile == "" {
+		return values, nil
+	}
+	flags, ok := cf.Profiles[profile]
+	if !ok {
+		var names []string
+		for name := range cf.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, xerrors.Errorf("unknown profile %q in the config file, expected one of %s", profile, strings.Join(names, ", "))
+	}
+	for name, value := range flags {
+		values[name] = value
+	}
+	return values, nil
+}
+
+// validate rejects the names which aren't flags of any command, e.g. typos
+func (cf configFile) validate(known map[string]bool) error {
+	maps := []map[string]interface{}{cf.Flags}
+	for _, flags := range cf.Profiles {
+		maps = append(maps, flags)
+	}
+	for _, flags := range maps {
+		for name := range flags {
+			if !known[name] {
+				return xerrors.Errorf("unknown flag %q in the config file", name)
+			}
+		}
+	}
+	return nil
+}
+
+// withConfigFile sets the flags of the app and of its commands from the config file before their actions.
+// The flags set on the command line or by their environment variables have priority over the config file.
+func withConfigFile(app *cli.App) {
+	known := map[string]bool{}
+	addFlagNames(known, app.Flags, app.Commands)
+	app.Before = func(c *cli.Context) error {
+		return loadConfigFile(c, app.Flags, known)
+	}
+	setConfigFileBefore(app.Commands, known)
+}
+
+func setConfigFileBefore(commands []cli.Command, known map[string]bool) {
+	for i := range commands {
+		flags := commands[i].Flags
+		commands[i].Before = func(c *cli.Context) error {
+			return loadConfigFile(c, flags, known)
+		}
+		setConfigFileBefore(commands[i].Subcommands, known)
+	}
+}
+
+func addFlagNames(names map[string]bool, flags []cli.Flag, commands []cli.Command) {
+	for _, f := range flags {
+		for _, name := range flagNames(f) {
+			names[name] = true
+		}
+	}
+	for _, cmd := range commands {
+		addFlagNames(names, cmd.Flags, cmd.Subcommands)
+	}
+	delete(names, configFileFlag.Name)
+	delete(names, profileFlag.Name)
+}
+
+// flagNames returns the names of the flag, e.g. quiet and q
+func flagNames(f cli.Flag) []string {
+	var names []string
+	for _, name := range strings.Split(f.GetName(), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+// loadConfigFile sets the flags of the context, which aren't set yet, from the config file and the profile of
+// --config and --profile, those of the command or those before it, e.g. "trivy --profile ci fs .".
+func loadConfigFile(c *cli.Context, flags []cli.Flag, known map[string]bool) error {
+	filePath, explicit := configOption(c, configFileFlag.Name)
+	profile, _ := configOption(c, profileFlag.Name)
+	if filePath == "" {
+		filePath = defaultConfigFile
+	}
+	if !explicit {
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			if profile != "" {
+				return xerrors.Errorf("--profile %s needs a config file, %s doesn't exist", profile, filePath)
+			}
+			return nil
+		}
+	}
+
+	cf, err := readConfigFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err = cf.validate(known); err != nil {
+		return err
+	}
+	values, err := cf.values(profile)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range flags {
+		for _, name := range flagNames(f) {
+			value, ok := values[name]
+			if !ok {
+				continue
+			}
+			if c.IsSet(name) {
+				break
+			}
+			if err = setFlag(c, f, name, value); err != nil {
+				return xerrors.Errorf("invalid %s in the config file: %w", name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// configOption returns the value of --config or --profile and whether it's set, on the command or before it
+func configOption(c *cli.Context, name string) (string, bool) {
+	if c.IsSet(name) {
+		return c.String(name), true
+	}
+	if c.GlobalIsSet(name) {
+		return c.GlobalString(name), true
+	}
+	return "", false
+}
+
+// setFlag sets the flag from the value of the config file, a scalar or a list. The lists set the slice flags,
+// e.g. custom-headers, element by element and are joined by commas for the others, e.g. severity.
+func setFlag(c *cli.Context, f cli.Flag, name string, value interface{}) error {
+	var values []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			s, err := scalarString(elem)
+			if err != nil {
+				return err
+			}
+			values = append(values, s)
+		}
+	default:
+		s, err := scalarString(v)
+		if err != nil {
+			return err
+		}
+		values = []string{s}
+	}
+
+	if !isSliceFlag(f) {
+		values = []string{strings.Join(values, ",")}
+	}
+	for _, v := range values {
+		if err := c.Set(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scalarString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", xerrors.Errorf("expected a value or a list of values, got %s", fmt.Sprint(value))
+}
+
+func isSliceFlag(f cli.Flag) bool {
+	switch f.(type) {
+	case cli.StringSliceFlag, *cli.StringSliceFlag, cli.IntSliceFlag, *cli.IntSliceFlag,
+		cli.Int64SliceFlag, *cli.Int64SliceFlag:
+		return true
+	}
+	return false
+}
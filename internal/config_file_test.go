@@ -0,0 +1,192 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+const testConfigFile = `severity: HIGH,CRITICAL
+ignore-unfixed: true
+exit-code: 1
+custom-headers:
+  - "X-Team: security"
+  - "X-Env: ci"
+profiles:
+  ci:
+    format: json
+    exit-code: 2
+  nightly:
+    severity:
+      - LOW
+      - MEDIUM
+`
+
+type testFlags struct {
+	Severity      string
+	IgnoreUnfixed bool
+	ExitCode      int
+	Format        string
+	CustomHeaders []string
+}
+
+func newConfigFileTestApp(got *testFlags) *cli.App {
+	flags := []cli.Flag{
+		configFileFlag,
+		profileFlag,
+		cli.StringFlag{Name: "severity, s", Value: "UNKNOWN,LOW,MEDIUM,HIGH,CRITICAL", EnvVar: "TRIVY_TEST_SEVERITY"},
+		cli.BoolFlag{Name: "ignore-unfixed"},
+		cli.IntFlag{Name: "exit-code"},
+		cli.StringFlag{Name: "format, f", Value: "table"},
+	}
+	action := func(c *cli.Context) error {
+		*got = testFlags{
+			Severity:      c.String("severity"),
+			IgnoreUnfixed: c.Bool("ignore-unfixed"),
+			ExitCode:      c.Int("exit-code"),
+			Format:        c.String("format"),
+			CustomHeaders: c.StringSlice("custom-headers"),
+		}
+		return nil
+	}
+
+	app := cli.NewApp()
+	app.Flags = flags
+	app.Action = action
+	app.Commands = []cli.Command{
+		{
+			Name:   "client",
+			Action: action,
+			Flags:  append(flags, cli.StringSliceFlag{Name: "custom-headers"}),
+		},
+		{
+			Name:   "server",
+			Action: func(*cli.Context) error { return nil },
+			Flags:  []cli.Flag{cli.StringFlag{Name: "listen"}},
+		},
+	}
+	withConfigFile(app)
+	return app
+}
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "trivy.yaml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(testConfigFile), 0600))
+	invalidPath := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, ioutil.WriteFile(invalidPath, []byte("severity: HIGH\nseverty: LOW\n"), 0600))
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    testFlags
+		wantErr string
+	}{
+		{
+			name: "the top-level flags",
+			args: []string{"trivy", "--config", configPath},
+			want: testFlags{Severity: "HIGH,CRITICAL", IgnoreUnfixed: true, ExitCode: 1, Format: "table"},
+		},
+		{
+			name: "the lists of the slice flags",
+			args: []string{"trivy", "client", "--config", configPath},
+			want: testFlags{Severity: "HIGH,CRITICAL", IgnoreUnfixed: true, ExitCode: 1, Format: "table",
+				CustomHeaders: []string{"X-Team: security", "X-Env: ci"}},
+		},
+		{
+			name: "the profile overrides the top-level flags",
+			args: []string{"trivy", "--config", configPath, "--profile", "ci"},
+			want: testFlags{Severity: "HIGH,CRITICAL", IgnoreUnfixed: true, ExitCode: 2, Format: "json"},
+		},
+		{
+			name: "the list of a flag other than a slice is joined",
+			args: []string{"trivy", "--config", configPath, "--profile", "nightly"},
+			want: testFlags{Severity: "LOW,MEDIUM", IgnoreUnfixed: true, ExitCode: 1, Format: "table"},
+		},
+		{
+			name: "the flags override the config file",
+			args: []string{"trivy", "--config", configPath, "--profile", "ci", "-s", "CRITICAL", "--exit-code", "3"},
+			want: testFlags{Severity: "CRITICAL", IgnoreUnfixed: true, ExitCode: 3, Format: "json"},
+		},
+		{
+			name: "the environment variables override the config file",
+			args: []string{"trivy", "--config", configPath},
+			env:  map[string]string{"TRIVY_TEST_SEVERITY": "MEDIUM"},
+			want: testFlags{Severity: "MEDIUM", IgnoreUnfixed: true, ExitCode: 1, Format: "table"},
+		},
+		{
+			name: "the config file and the profile by the environment variables",
+			args: []string{"trivy", "client"},
+			env:  map[string]string{"TRIVY_CONFIG": configPath, "TRIVY_PROFILE": "ci"},
+			want: testFlags{Severity: "HIGH,CRITICAL", IgnoreUnfixed: true, ExitCode: 2, Format: "json",
+				CustomHeaders: []string{"X-Team: security", "X-Env: ci"}},
+		},
+		{
+			name: "the flags of another command are allowed",
+			args: []string{"trivy", "server", "--listen", "localhost:4954"},
+			env:  map[string]string{"TRIVY_CONFIG": configPath},
+		},
+		{
+			name:    "sad: unknown profile",
+			args:    []string{"trivy", "--config", configPath, "--profile", "weekly"},
+			wantErr: `unknown profile "weekly" in the config file, expected one of ci, nightly`,
+		},
+		{
+			name:    "sad: unknown flag",
+			args:    []string{"trivy", "--config", invalidPath},
+			wantErr: `unknown flag "severty" in the config file`,
+		},
+		{
+			name:    "sad: missing config file",
+			args:    []string{"trivy", "--config", filepath.Join(dir, "missing.yaml")},
+			wantErr: "unable to read the config file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+			var got testFlags
+			err := newConfigFileTestApp(&got).Run(tt.args)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigFile_WorkingDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	var got testFlags
+	require.NoError(t, newConfigFileTestApp(&got).Run([]string{"trivy"}), "the default config file is optional")
+	assert.Equal(t, testFlags{Severity: "UNKNOWN,LOW,MEDIUM,HIGH,CRITICAL", Format: "table"}, got)
+
+	err = newConfigFileTestApp(&got).Run([]string{"trivy", "--profile", "ci"})
+	require.Error(t, err)
+	assert.Equal(t, "--profile ci needs a config file, trivy.yaml doesn't exist", err.Error())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, defaultConfigFile), []byte(testConfigFile), 0600))
+	require.NoError(t, newConfigFileTestApp(&got).Run([]string{"trivy", "--profile", "ci"}))
+	assert.Equal(t, testFlags{Severity: "HIGH,CRITICAL", IgnoreUnfixed: true, ExitCode: 2, Format: "json"}, got)
+}
@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// Run compares the JSON reports of a baseline scan and a current one, e.g. of the base and the head of a pull request,
+// and exits with --exit-code only on the new vulnerabilities
+func Run(c *cli.Context) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	if c.NArg() != 2 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the baseline and the current reports are required")
+	}
+
+	severities := map[string]bool{}
+	for _, s := range strings.Split(c.String("severity"), ",") {
+		severity, err := dbTypes.NewSeverity(strings.ToUpper(strings.TrimSpace(s)))
+		if err != nil {
+			return xerrors.Errorf("invalid --severity: %w", err)
+		}
+		severities[severity.String()] = true
+	}
+
+	baseline, err := readReport(c.Args().Get(0))
+	if err != nil {
+		return xerrors.Errorf("unable to read the baseline report: %w", err)
+	}
+	current, err := readReport(c.Args().Get(1))
+	if err != nil {
+		return xerrors.Errorf("unable to read the current report: %w", err)
+	}
+	delta := report.NewDelta(filterSeverities(baseline, severities), filterSeverities(current, severities))
+
+	var output io.Writer = os.Stdout
+	closeOutput := func() error { return nil }
+	if path := c.String("output"); path != "" {
+		f, err := report.CreateOutput(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output, closeOutput = f, f.Close
+	}
+	if err = (report.DiffWriter{Output: output, Format: c.String("format")}).Write(delta); err != nil {
+		return xerrors.Errorf("unable to write the diff: %w", err)
+	}
+	// flushed before the exit code, which skips the deferred calls
+	if err = closeOutput(); err != nil {
+		return err
+	}
+
+	if code := c.Int("exit-code"); code != 0 && len(delta.Added) > 0 {
+		os.Exit(code)
+	}
+	return nil
+}
+
+func readReport(path string) (report.Results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// the reports of --output report.json.gz
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid gzip of %s: %w", path, err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return report.ReadResults(r)
+}
+
+// filterSeverities keeps the vulnerabilities of the severities, those without severity having the UNKNOWN one
+func filterSeverities(results report.Results, severities map[string]bool) report.Results {
+	for i := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range results[i].Vulnerabilities {
+			severity := vuln.Severity
+			if severity == "" {
+				severity = dbTypes.SeverityUnknown.String()
+			}
+			if severities[severity] {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "github.com/etcd-io/bbolt"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"github.com/aquasecurity/trivy-db/pkg/types"
+	dbFile "github.com/aquasecurity/trivy/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const (
+	packageJSON = `{"name": "app", "version": "1.0.0", "dependencies": {"express": "4.16.0"}}`
+	packageLock = `{
+  "name": "app",
+  "version": "1.0.0",
+  "lockfileVersion": 1,
+  "requires": true,
+  "dependencies": {
+    "body-parser": {"version": "1.18.2", "requires": {"qs": "6.5.1"}},
+    "express": {"version": "4.16.0", "requires": {"body-parser": "1.18.2"}},
+    "qs": {"version": "6.5.1"}
+  }
+}`
+	qsAdvisory = `{
+  "id": "ACME-2017-1000048",
+  "summary": "Prototype override protection bypass in qs",
+  "affected": [
+    {
+      "package": {"ecosystem": "npm", "name": "qs"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "6.5.2"}]}],
+      "database_specific": {"severity": "HIGH"}
+    }
+  ]
+}`
+)
+
+// newFilesystemFixture writes a project with only a lock file, the advisory of a vulnerable library of it,
+// and a DB in the cache directory with only its details, returning the root of the three
+func newFilesystemFixture(t *testing.T) string {
+	root, err := ioutil.TempDir("", "trivy-fs")
+	require.NoError(t, err)
+
+	for path, content := range map[string]string{
+		"project/package.json":                 packageJSON,
+		"project/package-lock.json":            packageLock,
+		"advisories/ACME-2017-1000048.json":    qsAdvisory,
+		"project/src/index.js":                 `require("express")`,
+		"project/config/settings.example.json": `{}`,
+	} {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	cacheDir := filepath.Join(root, "cache")
+	require.NoError(t, db.Init(cacheDir))
+	require.NoError(t, db.Config{}.BatchUpdate(func(tx *bolt.Tx) error {
+		return db.Config{}.PutVulnerability(tx, "ACME-2017-1000048", types.Vulnerability{
+			Title:    "Prototype override protection bypass in qs",
+			Severity: "HIGH",
+		})
+	}))
+	require.NoError(t, db.Close())
+	require.NoError(t, dbFile.NewMetadata(afero.NewOsFs(), cacheDir).Store(db.Metadata{
+		Version:    1,
+		Type:       db.TypeFull,
+		NextUpdate: time.Now().Add(time.Hour),
+		UpdatedAt:  time.Now(),
+	}))
+	return root
+}
+
+// runFilesystem runs trivy fs on the project of the fixture and returns the JSON results
+func runFilesystem(t *testing.T, root string, args ...string) report.Results {
+	return runCommand(t, root, "fs", filepath.Join(root, "project"), args...)
+}
+
+// runCommand runs the subcommand on the target with the DB and the advisories of the fixture
+// and returns the JSON results
+func runCommand(t *testing.T, root, command, target string, args ...string) report.Results {
+	output := filepath.Join(root, "results.json")
+	osArgs := append([]string{"trivy", command, "--quiet", "--skip-update", "--no-cache",
+		"--cache-dir", filepath.Join(root, "cache"), "--advisory-dir", filepath.Join(root, "advisories"),
+		"--format", "json", "--output", output}, args...)
+	osArgs = append(osArgs, target)
+	require.NoError(t, NewApp("dev").Run(osArgs))
+
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	var results report.Results
+	require.NoError(t, json.Unmarshal(b, &results))
+	return results
+}
+
+func TestFilesystemCommand(t *testing.T) {
+	root := newFilesystemFixture(t)
+	defer os.RemoveAll(root)
+
+	// a project without OS files is scanned for its libraries only
+	results := runFilesystem(t, root)
+	require.Len(t, results, 1)
+	assert.Equal(t, "package-lock.json", results[0].Target)
+	require.Len(t, results[0].Vulnerabilities, 1)
+	assert.Equal(t, "ACME-2017-1000048", results[0].Vulnerabilities[0].VulnerabilityID)
+	assert.Equal(t, "qs", results[0].Vulnerabilities[0].PkgName)
+}
+
+func TestFilesystemCommand_DependencyTree(t *testing.T) {
+	root := newFilesystemFixture(t)
+	defer os.RemoveAll(root)
+
+	results := runFilesystem(t, root, "--dependency-tree")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Vulnerabilities, 1)
+	assert.Equal(t, []string{"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+		results[0].Vulnerabilities[0].DependencyPath)
+
+	// the table lists the chain under the findings of the lock file
+	output := filepath.Join(root, "results.txt")
+	require.NoError(t, NewApp("dev").Run([]string{"trivy", "fs", "--quiet", "--skip-update", "--no-cache",
+		"--cache-dir", filepath.Join(root, "cache"), "--advisory-dir", filepath.Join(root, "advisories"),
+		"--dependency-tree", "--output", output, filepath.Join(root, "project")}))
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "Dependency origin tree (reversed)\n"+
+		"qs@6.5.1 (ACME-2017-1000048)\n"+
+		"└── body-parser@1.18.2\n"+
+		"    └── express@4.16.0\n")
+}
+
+func TestRepositoryCommand(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := newFilesystemFixture(t)
+	defer os.RemoveAll(root)
+
+	repo := filepath.Join(root, "project")
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// the clone is scanned as trivy fs scans a directory, without OS files
+	results := runCommand(t, root, "repo", "file://"+filepath.ToSlash(repo), "--dependency-tree")
+	require.Len(t, results, 1)
+	assert.Equal(t, "package-lock.json", results[0].Target)
+	require.Len(t, results[0].Vulnerabilities, 1)
+	assert.Equal(t, []string{"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+		results[0].Vulnerabilities[0].DependencyPath)
+}
@@ -0,0 +1,54 @@
+package history
+
+import (
+	"io"
+	"os"
+
+	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/report/sqlite"
+)
+
+// Run shows how the number of vulnerabilities of an image changed over the scans recorded with --record-history,
+// and with --vuln when the vulnerability first appeared in each package
+func Run(c *cli.Context) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the image is required")
+	}
+	image := c.Args().First()
+
+	path := c.String("history-db")
+	if path == "" {
+		path = sqlite.HistoryPath(c.String("cache-dir"))
+	}
+	vulnerabilityID := c.String("vuln")
+	history, err := sqlite.ReadHistory(path, image, vulnerabilityID)
+	if err != nil {
+		return xerrors.Errorf("unable to read the history of %s: %w", image, err)
+	}
+	if vulnerabilityID != "" && len(history.Findings) == 0 {
+		log.Logger.Infof("%s has never been found in %s", vulnerabilityID, image)
+	}
+
+	var output io.Writer = os.Stdout
+	closeOutput := func() error { return nil }
+	if path := c.String("output"); path != "" {
+		f, err := report.CreateOutput(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output, closeOutput = f, f.Close
+	}
+	if err = (report.HistoryWriter{Output: output, Format: c.String("format")}).Write(history); err != nil {
+		return xerrors.Errorf("unable to write the history: %w", err)
+	}
+	return closeOutput()
+}
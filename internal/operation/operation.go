@@ -3,6 +3,7 @@ package operation
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/spf13/afero"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/aquasecurity/fanal/cache"
 	"github.com/aquasecurity/trivy/pkg/db"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/transport"
 	"github.com/aquasecurity/trivy/pkg/utils"
 )
 
@@ -55,9 +57,10 @@ func (c Cache) ClearImages() error {
 	return nil
 }
 
-func DownloadDB(appVersion, cacheDir string, quiet, light, skipUpdate bool) error {
-	client := initializeDBClient(cacheDir, quiet)
-	ctx := context.Background()
+// DownloadDB downloads the DB into the cache directory from the repositories when it needs an update,
+// until the context is done
+func DownloadDB(ctx context.Context, appVersion, cacheDir string, repositories []string, quiet, light, skipUpdate bool) error {
+	client := initializeDBClient(cacheDir, quiet, repositories)
 	needsUpdate, err := client.NeedsUpdate(appVersion, light, skipUpdate)
 	if err != nil {
 		return xerrors.Errorf("database error: %w", err)
@@ -67,7 +70,7 @@ func DownloadDB(appVersion, cacheDir string, quiet, light, skipUpdate bool) erro
 		log.Logger.Info("Need to update DB")
 		log.Logger.Info("Downloading DB...")
 		if err := client.Download(ctx, cacheDir, light); err != nil {
-			return xerrors.Errorf("failed to download vulnerability DB: %w", err)
+			return xerrors.Errorf("failed to download vulnerability DB: %w", transport.ExplainTLSError(err))
 		}
 		if err = client.UpdateMetadata(cacheDir); err != nil {
 			return xerrors.Errorf("unable to update database metadata: %w", err)
@@ -81,6 +84,50 @@ func DownloadDB(appVersion, cacheDir string, quiet, light, skipUpdate bool) erro
 	return nil
 }
 
+// CheckDBAge fails when the DB is older than maxAge, after the grace period in which it warns
+func CheckDBAge(cacheDir string, maxAge, grace time.Duration) error {
+	client := initializeDBClient(cacheDir, true, nil)
+	if err := client.CheckAge(maxAge, grace); err != nil {
+		return xerrors.Errorf("stale DB: %w", err)
+	}
+	return nil
+}
+
+// ExportDB writes the DB of the cache directory to a bundle file for a host without network access
+func ExportDB(cacheDir, output string) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return xerrors.Errorf("unable to create the bundle: %w", err)
+	}
+	defer f.Close()
+
+	log.Logger.Infof("Exporting DB to %s...", output)
+	client := initializeDBClient(cacheDir, true, nil)
+	if err = client.Export(cacheDir, f); err != nil {
+		return xerrors.Errorf("failed to export the DB: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return xerrors.Errorf("failed to write the bundle: %w", err)
+	}
+	return nil
+}
+
+// ImportDB loads the DB of a bundle written by ExportDB into the cache directory
+func ImportDB(cacheDir, input string) error {
+	f, err := os.Open(input)
+	if err != nil {
+		return xerrors.Errorf("unable to open the bundle: %w", err)
+	}
+	defer f.Close()
+
+	log.Logger.Infof("Importing DB from %s...", input)
+	client := initializeDBClient(cacheDir, true, nil)
+	if err = client.Import(cacheDir, f); err != nil {
+		return xerrors.Errorf("failed to import the DB: %w", err)
+	}
+	return showDBInfo(cacheDir)
+}
+
 func showDBInfo(cacheDir string) error {
 	m := db.NewMetadata(afero.NewOsFs(), cacheDir)
 	metadata, err := m.Get()
@@ -16,9 +16,9 @@ import (
 
 // Injectors from inject.go:
 
-func initializeDBClient(cacheDir string, quiet bool) db.Client {
+func initializeDBClient(cacheDir string, quiet bool, repositories []string) db.Client {
 	config := db2.Config{}
-	client := github.NewClient()
+	client := github.NewClient(repositories)
 	progressBar := indicator.NewProgressBar(quiet)
 	realClock := clock.RealClock{}
 	fs := afero.NewOsFs()
@@ -0,0 +1,128 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/plugin"
+)
+
+// Install installs the plugin of a local directory, a local executable or a git repository into ~/.trivy/plugins
+func Install(c *cli.Context) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the directory, the executable or the git repository of the plugin is required")
+	}
+	p, err := plugin.NewManager(plugin.DefaultDir()).Install(context.Background(), c.Args().First())
+	if err != nil {
+		return xerrors.Errorf("unable to install the plugin: %w", err)
+	}
+	log.Logger.Infof("Installed the plugin %s %s", p.Name, p.Version)
+	return nil
+}
+
+// Uninstall removes the installed plugin of the name
+func Uninstall(c *cli.Context) error {
+	if err := log.InitLogger(c.Bool("debug"), c.Bool("quiet")); err != nil {
+		return xerrors.Errorf("failed to initialize a logger: %w", err)
+	}
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the name of the plugin is required")
+	}
+	if err := plugin.NewManager(plugin.DefaultDir()).Uninstall(c.Args().First()); err != nil {
+		return xerrors.Errorf("unable to uninstall the plugin: %w", err)
+	}
+	log.Logger.Infof("Uninstalled the plugin %s", c.Args().First())
+	return nil
+}
+
+// List shows the installed plugins
+func List(c *cli.Context) error {
+	plugins, err := plugin.NewManager(plugin.DefaultDir()).List()
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(c.App.Writer)
+	table.SetHeader([]string{"Name", "Version", "Type", "Usage"})
+	for _, p := range plugins {
+		kind := "subcommand"
+		if p.Output {
+			kind = "output"
+		}
+		table.Append([]string{p.Name, p.Version, kind, p.Usage})
+	}
+	table.Render()
+	return nil
+}
+
+// Run runs the installed plugin of the first argument with the other arguments
+func Run(c *cli.Context) error {
+	if c.NArg() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return xerrors.New("the name of the plugin is required")
+	}
+	p, err := plugin.NewManager(plugin.DefaultDir()).Load(c.Args().First())
+	if err != nil {
+		return err
+	}
+	return run(p, c.Args().Tail())
+}
+
+// Commands returns the subcommands of the installed plugins whose names aren't the names of the commands,
+// which run the plugins with their arguments as they are. The invalid plugins are left to trivy plugin list.
+func Commands(commands []cli.Command) []cli.Command {
+	plugins, err := plugin.NewManager(plugin.DefaultDir()).List()
+	if err != nil {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, command := range commands {
+		for _, name := range command.Names() {
+			names[name] = true
+		}
+	}
+
+	var pluginCommands []cli.Command
+	for _, p := range plugins {
+		if names[p.Name] {
+			continue
+		}
+		p := p
+		usage := p.Usage
+		if usage == "" {
+			usage = fmt.Sprintf("run the plugin %s", p.Name)
+		}
+		pluginCommands = append(pluginCommands, cli.Command{
+			Name:            p.Name,
+			Usage:           usage,
+			Description:     p.Description,
+			Category:        "plugins",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				return run(p, c.Args())
+			},
+		})
+	}
+	return pluginCommands
+}
+
+// run runs the plugin with the standard streams of trivy, exiting with its exit code when it fails
+func run(p plugin.Plugin, args []string) error {
+	err := p.Run(context.Background(), plugin.RunOptions{Args: args, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+	var exitErr *exec.ExitError
+	if xerrors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return cli.NewExitError("", exitErr.ExitCode())
+	}
+	return err
+}
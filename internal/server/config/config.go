@@ -1,8 +1,12 @@
 package config
 
 import (
+	"time"
+
 	"github.com/urfave/cli"
 	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/github"
 )
 
 type Config struct {
@@ -14,10 +18,30 @@ type Config struct {
 	Reset          bool
 	DownloadDBOnly bool
 	SkipUpdate     bool
+	// DBRepositories are the repositories of the DB, tried in turn, or github.DefaultRepository without any
+	DBRepositories []string
+	// AdvisoryDirs are the directories of the supplementary advisories in the OSV format, see advisory.LoadOSVDir
+	AdvisoryDirs []string
+
+	// CustomCACert is the PEM file of the CA certificates trusted for all the outbound connections, and
+	// InsecureRegistries the hosts of the registries whose certificates aren't verified, see transport.Configure
+	CustomCACert       string
+	InsecureRegistries []string
+
+	// CacheBackend is the cache of the layers put by the clients, see cache.NewCache, e.g. a Redis server shared by
+	// the servers, and CacheTTL and the Redis* options those of a Redis server
+	CacheBackend string
+	CacheTTL     time.Duration
+	RedisTLS     bool
+	RedisCACert  string
+	RedisCert    string
+	RedisKey     string
 
 	Listen      string
+	GRPCListen  string
 	Token       string
 	TokenHeader string
+	Timeout     time.Duration
 
 	// these variables are generated by Init()
 	AppVersion string
@@ -35,9 +59,23 @@ func New(c *cli.Context) Config {
 		Reset:          c.Bool("reset"),
 		DownloadDBOnly: c.Bool("download-db-only"),
 		SkipUpdate:     c.Bool("skip-update"),
+		DBRepositories: c.StringSlice("db-repository"),
+		AdvisoryDirs:   c.StringSlice("advisory-dir"),
 		Listen:         c.String("listen"),
+		GRPCListen:     c.String("grpc-listen"),
 		Token:          c.String("token"),
 		TokenHeader:    c.String("token-header"),
+		Timeout:        c.Duration("timeout"),
+
+		CustomCACert:       c.String("custom-ca-cert"),
+		InsecureRegistries: c.StringSlice("insecure-registry"),
+
+		CacheBackend: c.String("cache-backend"),
+		CacheTTL:     c.Duration("cache-ttl"),
+		RedisTLS:     c.Bool("redis-tls"),
+		RedisCACert:  c.String("redis-ca"),
+		RedisCert:    c.String("redis-cert"),
+		RedisKey:     c.String("redis-key"),
 	}
 }
 
@@ -45,6 +83,11 @@ func (c *Config) Init() (err error) {
 	if c.SkipUpdate && c.DownloadDBOnly {
 		return xerrors.New("The --skip-update and --download-db-only option can not be specified both")
 	}
+	for _, repository := range c.DBRepositories {
+		if err = github.CheckRepository(repository); err != nil {
+			return err
+		}
+	}
 
 	c.AppVersion = c.context.App.Version
 
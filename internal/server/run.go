@@ -1,15 +1,19 @@
 package server
 
 import (
+	"context"
+
 	"github.com/urfave/cli"
 	"golang.org/x/xerrors"
 
-	"github.com/aquasecurity/fanal/cache"
 	"github.com/aquasecurity/trivy-db/pkg/db"
 	"github.com/aquasecurity/trivy/internal/operation"
 	"github.com/aquasecurity/trivy/internal/server/config"
+	"github.com/aquasecurity/trivy/pkg/advisory"
+	"github.com/aquasecurity/trivy/pkg/cache"
 	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/rpc/server"
+	"github.com/aquasecurity/trivy/pkg/transport"
 	"github.com/aquasecurity/trivy/pkg/utils"
 )
 
@@ -26,24 +30,33 @@ func run(c config.Config) (err error) {
 	if err = c.Init(); err != nil {
 		return xerrors.Errorf("failed to initialize options: %w", err)
 	}
+	if err = transport.Configure(transport.Options{CACert: c.CustomCACert, InsecureRegistries: c.InsecureRegistries}); err != nil {
+		return xerrors.Errorf("failed to configure the outbound connections: %w", err)
+	}
 
 	// configure cache dir
 	utils.SetCacheDir(c.CacheDir)
 	log.Logger.Debugf("cache dir:  %s", utils.CacheDir())
 
-	fsCache, err := cache.NewFSCache(utils.CacheDir())
+	cacheClient, err := cache.NewCache(c.CacheBackend, utils.CacheDir(), cache.RedisOptions{
+		TTL:    c.CacheTTL,
+		TLS:    c.RedisTLS,
+		CACert: c.RedisCACert,
+		Cert:   c.RedisCert,
+		Key:    c.RedisKey,
+	})
 	if err != nil {
 		return xerrors.Errorf("unable to initialize cache: %w", err)
 	}
 
 	// server doesn't have image cache
-	cacheOperation := operation.NewCache(fsCache)
+	cacheOperation := operation.NewCache(cacheClient)
 	if c.Reset {
 		return cacheOperation.ClearDB()
 	}
 
 	// download the database file
-	if err = operation.DownloadDB(c.AppVersion, c.CacheDir, true, false, c.SkipUpdate); err != nil {
+	if err = operation.DownloadDB(context.Background(), c.AppVersion, c.CacheDir, c.DBRepositories, true, false, c.SkipUpdate); err != nil {
 		return err
 	}
 
@@ -54,6 +67,9 @@ func run(c config.Config) (err error) {
 	if err = db.Init(c.CacheDir); err != nil {
 		return xerrors.Errorf("error in vulnerability DB initialize: %w", err)
 	}
+	if err = advisory.RegisterOSVDirs(c.AdvisoryDirs); err != nil {
+		return xerrors.Errorf("invalid --advisory-dir: %w", err)
+	}
 
-	return server.ListenAndServe(c, fsCache)
+	return server.ListenAndServe(c, cacheClient)
 }
@@ -0,0 +1,109 @@
+package standalone
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/internal/standalone/config"
+	"github.com/aquasecurity/trivy/pkg/attestation"
+	dbFile "github.com/aquasecurity/trivy/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/registry"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+// attester verifies the former vulnerability attestations of the digest of the image in the registry
+// with --verify-attestation, and attaches the results of the scan with --attest
+type attester struct {
+	ref        name.Digest
+	options    []remote.Option
+	privateKey *ecdsa.PrivateKey
+	publicKey  *ecdsa.PublicKey
+}
+
+// newAttester resolves the digest of the image in the registry and reads the keys, before the scan
+// so that a wrong password doesn't waste it. The image of the digest is the one scanned.
+func newAttester(ctx context.Context, c config.Config) (*attester, error) {
+	a := &attester{}
+	var err error
+	if c.Attest {
+		if a.privateKey, err = attestation.LoadPrivateKey(c.Key); err != nil {
+			return nil, xerrors.Errorf("unable to load the key of --attest: %w", err)
+		}
+		a.publicKey = &a.privateKey.PublicKey
+	} else if a.publicKey, err = attestation.LoadPublicKey(c.Key); err != nil {
+		return nil, xerrors.Errorf("unable to load the key of --verify-attestation: %w", err)
+	}
+
+	opt, err := registry.GetDockerOption(ctx, c.ImageName, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	var nameOptions []name.Option
+	if opt.NonSSL {
+		nameOptions = append(nameOptions, name.Insecure)
+	}
+	ref, err := name.ParseReference(c.ImageName, nameOptions...)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid image %s: %w", c.ImageName, err)
+	}
+
+	if opt.UserName != "" || opt.Password != "" {
+		a.options = append(a.options, remote.WithAuth(&authn.Basic{Username: opt.UserName, Password: opt.Password}))
+	} else {
+		a.options = append(a.options, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	}
+
+	desc, err := remote.Get(ref, a.options...)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the digest of %s in the registry: %w", c.ImageName, err)
+	}
+	a.ref = ref.Context().Digest(desc.Digest.String())
+	log.Logger.Debugf("Attestations of %s", a.ref)
+	return a, nil
+}
+
+// verify requires an attestation of the image signed by the key
+func (a *attester) verify() error {
+	envelopes, err := attestation.Fetch(a.ref, a.options...)
+	if err != nil {
+		return xerrors.Errorf("unable to fetch the attestations: %w", err)
+	}
+	statement, err := attestation.VerifyLatest(envelopes, a.ref, a.publicKey)
+	if err != nil {
+		return err
+	}
+	log.Logger.Infof("Verified the attestation of %s scanned on %s by Trivy %s", a.ref,
+		statement.Predicate.Metadata.ScanFinishedOn.Format(time.RFC3339), statement.Predicate.Scanner.Version)
+	return nil
+}
+
+// attest attaches the results to the image as an attestation signed by the key
+func (a *attester) attest(c config.Config, results report.Results, started, finished time.Time) error {
+	var dbVersion string
+	if metadata, err := dbFile.NewMetadata(afero.NewOsFs(), c.CacheDir).Get(); err == nil {
+		dbVersion = strconv.Itoa(metadata.Version)
+	}
+	payload, err := json.Marshal(attestation.NewStatement(a.ref, results, c.AppVersion, dbVersion, started, finished))
+	if err != nil {
+		return xerrors.Errorf("unable to encode the statement: %w", err)
+	}
+	envelope, err := attestation.Sign(payload, a.privateKey)
+	if err != nil {
+		return err
+	}
+	if err = attestation.Attach(a.ref, envelope, a.options...); err != nil {
+		return xerrors.Errorf("unable to attach the attestation: %w", err)
+	}
+	log.Logger.Infof("Attached the attestation to %s", attestation.Tag(a.ref))
+	return nil
+}
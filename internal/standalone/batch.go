@@ -0,0 +1,118 @@
+package standalone
+
+import (
+	"context"
+	"os"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	fcache "github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/internal/standalone/config"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/registry"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/scanner"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// runBatch scans the images of the arguments and of --input-list, --concurrency at once, and writes one report
+// grouped by image. A failed image doesn't stop the others; it is reported and fails the run after the report.
+func runBatch(ctx context.Context, c config.Config, cacheClient fcache.Cache) error {
+	log.Logger.Infof("Scanning %d images", len(c.ImageNames))
+
+	scanOptions, err := newScanOptions(ctx, c)
+	if err != nil {
+		return err
+	}
+	scanOptions.BatchWorkers = c.Concurrency
+	factory := func(ctx context.Context, imageName string) (scanner.Analyzer, func(), error) {
+		ext, cleanup, err := newImageExtractor(ctx, c, imageName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return scanner.NewImageAnalyzer(analyzer.New(ext, cacheClient)), cleanup, nil
+	}
+	scans, err := initializeBatchScanner(factory, cacheClient).ScanImages(ctx, c.ImageNames, scanOptions)
+	if err != nil {
+		return xerrors.Errorf("error in the scan of the images: %w", err)
+	}
+
+	vulnClient := initializeVulnerabilityClient()
+	var batch report.Batch
+	for _, scan := range scans.Images {
+		image := report.BatchImage{Image: scan.Image}
+		if scan.Err != nil {
+			log.Logger.Warnf("Unable to scan %s: %s", scan.Image, scan.Err)
+			image.Error = scan.Err.Error()
+		} else {
+			for i := range scan.Results {
+				scan.Results[i].Vulnerabilities = vulnClient.Filter(scan.Results[i].Vulnerabilities,
+					c.Severities, c.IgnoreUnfixed, c.IgnoreFile)
+			}
+			image.Results = scan.Results
+		}
+		batch.Images = append(batch.Images, image)
+	}
+
+	writer := report.BatchWriter{Output: c.Output, Format: c.Format, Report: c.Report, Light: c.Light}
+	if err = writer.Write(batch); err != nil {
+		return xerrors.Errorf("unable to write results: %w", err)
+	}
+
+	// flushed before the exit codes, which skip the deferred calls
+	if err = c.CloseOutput(); err != nil {
+		return err
+	}
+
+	if code := report.ExitCodeGate(c.ExitOnSeverities).ExitCode(batch.Results(), c.ExitCode); code != 0 {
+		os.Exit(code)
+	}
+	if c.ExitOnEOL != 0 && batch.Results().HasEOL() {
+		os.Exit(c.ExitOnEOL)
+	}
+	if failed := batch.Failed(); len(failed) > 0 {
+		return xerrors.Errorf("failed to scan %d of %d images", len(failed), len(batch.Images))
+	}
+	return nil
+}
+
+// newImageExtractor returns the extractor of the image in the runtime selected by --runtime, as run does
+func newImageExtractor(ctx context.Context, c config.Config, imageName string) (extractor.Extractor, func(), error) {
+	opt := c.DaemonOption()
+	runtime := daemon.Resolve(ctx, imageName, opt)
+	if c.OfflineScan {
+		if err := checkLocalImage(ctx, imageName, runtime, opt); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch runtime {
+	case daemon.Containerd:
+		ext, cleanup, err := containerd.NewExtractor(ctx, imageName, opt.Containerd)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ext, cleanup, nil
+	case daemon.Podman:
+		ext, cleanup, err := podman.NewExtractor(ctx, imageName, opt.Podman)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ext, cleanup, nil
+	}
+	dockerOption, err := registry.GetDockerOption(ctx, imageName, c.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	ext, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
+	if err != nil {
+		return nil, nil, types.ExplainTLSError(err)
+	}
+	return ext, cleanup, nil
+}
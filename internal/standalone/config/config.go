@@ -1,6 +1,8 @@
 package config
 
 import (
+	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -11,7 +13,19 @@ import (
 	"golang.org/x/xerrors"
 
 	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/extractor/sftp"
+	"github.com/aquasecurity/trivy/pkg/github"
+	"github.com/aquasecurity/trivy/pkg/k8s"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/progress"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/scanner"
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
 )
 
 type Config struct {
@@ -21,33 +35,191 @@ type Config struct {
 	Quiet      bool
 	NoProgress bool
 	Debug      bool
+	// Progress is the format of the events of the scan on stderr, one of progress.Formats
+	Progress string
 
 	CacheDir       string
 	Reset          bool
 	DownloadDBOnly bool
 	SkipUpdate     bool
-	ClearCache     bool
+	// DBRepositories are the repositories of the DB, tried in turn, or github.DefaultRepository without any
+	DBRepositories []string
+	// AdvisoryDirs are the directories of the supplementary advisories in the OSV format, see advisory.LoadOSVDir
+	AdvisoryDirs []string
+	// OfflineScan scans without any network access: it implies SkipUpdate and rejects the options needing the network
+	OfflineScan  bool
+	MaxDBAge     time.Duration
+	StaleDBGrace time.Duration
+	ClearCache   bool
+	CacheBackend string
+	CacheTTL     time.Duration
+	// NoCache analyzes the layers with a cache of the run only and neither reads nor writes the cached results
+	NoCache bool
+
+	// SkipDirs, SkipFiles and FilePatterns filter the analyzed files, see types.ScanOptions. They imply NoCache
+	// as the layers analyzed with them differ from the cached ones.
+	SkipDirs     []string
+	SkipFiles    []string
+	FilePatterns []string
+	skipDirs     string
+	skipFiles    string
+	filePatterns string
+
+	// RedisTLS, RedisCACert, RedisCert and RedisKey are the TLS options of a Redis cache backend
+	RedisTLS    bool
+	RedisCACert string
+	RedisCert   string
+	RedisKey    string
 
 	Input    string
 	output   string
 	Format   string
 	Template string
+	TopN     int
+	// SCCSource is the Security Command Center source of the findings of --format scc
+	SCCSource string
+	// KafkaBrokers and KafkaTopic are where the findings of --format kafka are published
+	KafkaBrokers []string
+	KafkaTopic   string
+
+	// InputList is the file listing the images scanned with those of the arguments, one per line
+	InputList string
+
+	// runtime, ContainerdSocket, ContainerdNamespace and PodmanSocket select where a local image is read from
+	runtime             string
+	ContainerdSocket    string
+	ContainerdNamespace string
+	PodmanSocket        string
+
+	// Filesystem scans the directory of the argument instead of an image, with trivy fs
+	Filesystem bool
+	// Rootfs scans the root filesystem of the argument, e.g. of a VM, instead of an image, with trivy rootfs
+	Rootfs bool
+	// SBOM scans the packages of the CycloneDX or SPDX document of the argument instead of an image, with trivy sbom
+	SBOM bool
+	// Repository scans a revision of the git repository of the argument instead of an image, with trivy repo
+	Repository bool
+	Branch     string
+	Tag        string
+	Commit     string
+	GitToken   string
+	// Kubernetes scans the images of the workloads of a cluster instead of an image, with trivy k8s
+	Kubernetes  bool
+	Kubeconfig  string
+	KubeContext string
+	// Namespace is the namespace of the workloads, all the namespaces when empty
+	Namespace string
+	// Report is report.ReportSummary, writing the number of findings per severity of each target, or report.ReportAll.
+	// With trivy k8s the summary is of each workload. Concurrency is the number of images trivy k8s scans at once.
+	Report      string
+	Concurrency int
 
+	BaseImage  string
+	Compliance string
+
+	// Timeout limits the whole run: the DB download, the image pull and the scan
 	Timeout         time.Duration
+	PartialResults  bool
 	ScanRemovedPkgs bool
+	GoBinaries      bool
+	goBinaryDirs    string
+	JavaArchives    bool
 	vulnType        string
+	securityChecks  string
+	SecretConfig    string
+	configPolicy    string
 	Light           bool
 	severities      string
 	IgnoreFile      string
 	IgnoreUnfixed   bool
+	ShowSuppressed  bool
+	VEXFile         string
+	IgnorePolicy    string
+	SeveritySource  string
 	ExitCode        int
+	exitOnSeverity  string
+	Parallel        int
+
+	// CustomAnalyzerDirs are the directories of the custom analyzers, see customanalyzer.LoadDir,
+	// run by the command of CustomAnalyzerRuntime
+	CustomAnalyzerDirs    []string
+	CustomAnalyzerRuntime string
+
+	// CustomCACert is the PEM file of the CA certificates trusted for all the outbound connections, and
+	// InsecureRegistries the hosts of the registries whose certificates aren't verified, see transport.Configure
+	CustomCACert       string
+	InsecureRegistries []string
+
+	// ListAllPkgs lists all the packages in the results, which the BOM formats do anyway
+	ListAllPkgs bool
+
+	// RecordHistory records the scan in the SQLite database of HistoryDB, see sqlite.HistoryPath by default
+	RecordHistory bool
+	HistoryDB     string
+
+	// SeparateDeferred reports the vulnerabilities the vendor won't fix or deferred apart from the others
+	SeparateDeferred bool
+
+	// EOLSeverity is the severity of the finding of an OS no longer supported by its distribution, none when empty,
+	// and ExitOnEOL the exit code of the scan finding one
+	EOLSeverity string
+	ExitOnEOL   int
+
+	// NotifyWebhook is the URL the results are pushed to in NotifyFormat, see report.NewSink
+	NotifyWebhook string
+	NotifyFormat  string
+	NotifySecret  string
+
+	// OutputPlugin is the installed output plugin the results are written with instead of Format
+	OutputPlugin string
+
+	// MetricsPushgateway is the URL of the Pushgateway the metrics of the scan are pushed to
+	MetricsPushgateway string
+
+	licenseForbidden string
+
+	// ExploitData annotates the vulnerabilities with the EPSS scores and the KEV catalog,
+	// implied by EPSSAbove and KEVOnly
+	ExploitData bool
+	EPSSAbove   float64
+	KEVOnly     bool
+
+	// DependencyTree shows the dependency path of the vulnerable packages of trivy fs and trivy repo
+	DependencyTree bool
+
+	// ArtifactMetadata writes the report.Report with the metadata of the artifact with --format json
+	ArtifactMetadata bool
+
+	// Attest attaches the results to the image in the registry as an attestation signed by the private key of Key.
+	// VerifyAttestation requires an attestation of the image signed by Key before the scan.
+	Attest            bool
+	VerifyAttestation bool
+	Key               string
 
 	// these variables are generated by Init()
 	ImageName  string
 	VulnType   []string
-	Output     *os.File
+	Output     io.Writer
+	OutputPath string
+	// outputFile is the file of --output to close, if any
+	outputFile io.Closer
 	Severities []dbTypes.Severity
 	AppVersion string
+	// Runtime is the runtime of --runtime, daemon.Auto without the option
+	Runtime daemon.Runtime
+	// GoBinaryDirs are the directories of --go-binary-dirs, which implies GoBinaries, nil without the option
+	GoBinaryDirs []string
+	// SecurityChecks are the checks of --security-checks, nil without the option
+	SecurityChecks []string
+	// ConfigPolicies are the Rego files and directories of --config-policy
+	ConfigPolicies []string
+	// ForbiddenLicenses are the licenses of --license-forbidden
+	ForbiddenLicenses []string
+	// ExitOnSeverities are the severity of --exit-on-severity and the higher ones, nil without the option
+	ExitOnSeverities []string
+	// ImageNames are the images of the arguments and of --input-list when there are several, scanned in a batch,
+	// nil for one image. ImageName is the first one.
+	ImageNames []string
 
 	// deprecated
 	onlyUpdate string
@@ -70,27 +242,121 @@ func New(c *cli.Context) (Config, error) {
 
 		Quiet:      quiet,
 		NoProgress: c.Bool("no-progress"),
+		Progress:   c.String("progress"),
 		Debug:      debug,
 
 		CacheDir:       c.String("cache-dir"),
 		Reset:          c.Bool("reset"),
 		DownloadDBOnly: c.Bool("download-db-only"),
+		MaxDBAge:       c.Duration("max-db-age"),
+		StaleDBGrace:   c.Duration("stale-db-grace"),
 		SkipUpdate:     c.Bool("skip-update"),
+		DBRepositories: c.StringSlice("db-repository"),
+		AdvisoryDirs:   c.StringSlice("advisory-dir"),
+		OfflineScan:    c.Bool("offline-scan"),
 		ClearCache:     c.Bool("clear-cache"),
+		CacheBackend:   c.String("cache-backend"),
+		CacheTTL:       c.Duration("cache-ttl"),
+		NoCache:        c.Bool("no-cache"),
+		skipDirs:       c.String("skip-dirs"),
+		skipFiles:      c.String("skip-files"),
+		filePatterns:   c.String("file-patterns"),
+
+		RedisTLS:    c.Bool("redis-tls"),
+		RedisCACert: c.String("redis-ca"),
+		RedisCert:   c.String("redis-cert"),
+		RedisKey:    c.String("redis-key"),
 
 		Input:    c.String("input"),
 		output:   c.String("output"),
 		Format:   c.String("format"),
 		Template: c.String("template"),
+		TopN:     c.Int("top"),
+
+		SCCSource:    c.String("scc-source"),
+		KafkaBrokers: c.StringSlice("kafka-brokers"),
+		KafkaTopic:   c.String("kafka-topic"),
+
+		InputList: c.String("input-list"),
+
+		runtime:             c.String("runtime"),
+		ContainerdSocket:    c.String("containerd-socket"),
+		ContainerdNamespace: c.String("containerd-namespace"),
+		PodmanSocket:        c.String("podman-socket"),
+
+		Branch:   c.String("branch"),
+		Tag:      c.String("tag"),
+		Commit:   c.String("commit"),
+		GitToken: c.String("git-token"),
+
+		Kubeconfig:  c.String("kubeconfig"),
+		KubeContext: c.String("context"),
+		Namespace:   c.String("namespace"),
+		Report:      c.String("report"),
+		Concurrency: c.Int("concurrency"),
+
+		BaseImage:  c.String("base-image"),
+		Compliance: c.String("compliance"),
 
 		Timeout:         c.Duration("timeout"),
+		PartialResults:  c.Bool("partial-results"),
 		ScanRemovedPkgs: c.Bool("removed-pkgs"),
+		GoBinaries:      c.Bool("go-binaries"),
+		goBinaryDirs:    c.String("go-binary-dirs"),
+		JavaArchives:    c.Bool("java-archives"),
 		vulnType:        c.String("vuln-type"),
+		securityChecks:  c.String("security-checks"),
+		SecretConfig:    c.String("secret-config"),
+		configPolicy:    c.String("config-policy"),
 		Light:           c.Bool("light"),
 		severities:      c.String("severity"),
 		IgnoreFile:      c.String("ignorefile"),
 		IgnoreUnfixed:   c.Bool("ignore-unfixed"),
+		ShowSuppressed:  c.Bool("show-suppressed"),
+		VEXFile:         c.String("vex"),
+		IgnorePolicy:    c.String("ignore-policy"),
+		SeveritySource:  c.String("severity-source"),
 		ExitCode:        c.Int("exit-code"),
+		exitOnSeverity:  c.String("exit-on-severity"),
+		Parallel:        c.Int("parallel"),
+
+		CustomAnalyzerDirs:    c.StringSlice("custom-analyzer"),
+		CustomAnalyzerRuntime: c.String("custom-analyzer-runtime"),
+
+		CustomCACert:       c.String("custom-ca-cert"),
+		InsecureRegistries: c.StringSlice("insecure-registry"),
+
+		ListAllPkgs: c.Bool("list-all-pkgs"),
+
+		RecordHistory: c.Bool("record-history"),
+		HistoryDB:     c.String("history-db"),
+
+		SeparateDeferred: c.Bool("separate-deferred"),
+
+		EOLSeverity: c.String("eol-severity"),
+		ExitOnEOL:   c.Int("exit-on-eol"),
+
+		NotifyWebhook: c.String("notify-webhook"),
+		NotifyFormat:  c.String("notify-format"),
+		NotifySecret:  c.String("notify-secret"),
+
+		OutputPlugin: c.String("output-plugin"),
+
+		MetricsPushgateway: c.String("metrics-pushgateway"),
+
+		licenseForbidden: c.String("license-forbidden"),
+
+		ExploitData: c.Bool("exploit-data"),
+		EPSSAbove:   c.Float64("epss-above"),
+		KEVOnly:     c.Bool("kev-only"),
+
+		DependencyTree: c.Bool("dependency-tree"),
+
+		ArtifactMetadata: c.Bool("artifact-metadata"),
+
+		Attest:            c.Bool("attest"),
+		VerifyAttestation: c.Bool("verify-attestation"),
+		Key:               c.String("key"),
 
 		onlyUpdate:  c.String("only-update"),
 		refresh:     c.Bool("refresh"),
@@ -109,15 +375,157 @@ func (c *Config) Init() (err error) {
 	if c.Format == "template" && c.Template == "" {
 		c.logger.Warn("--format template is ignored because --template not is specified. Specify --template option when you use --format template.")
 	}
+	if c.BaseImage != "" && c.Format != "table" {
+		c.logger.Warnf("--base-image is ignored because --format %s is specified. Use --base-image option with --format table option.", c.Format)
+	}
+	if c.Compliance != "" && c.Format != "table" {
+		c.logger.Warnf("--compliance is ignored because --format %s is specified. Use --compliance option with --format table option.", c.Format)
+	}
 	if c.onlyUpdate != "" || c.refresh || c.autoRefresh {
 		c.logger.Warn("--only-update, --refresh and --auto-refresh are unnecessary and ignored now. These commands will be removed in the next version.")
 	}
+	if c.OfflineScan {
+		if err = c.checkOffline(); err != nil {
+			return err
+		}
+		c.SkipUpdate = true
+	}
 	if c.SkipUpdate && c.DownloadDBOnly {
 		return xerrors.New("The --skip-update and --download-db-only option can not be specified both")
 	}
+	for _, repository := range c.DBRepositories {
+		if err = github.CheckRepository(repository); err != nil {
+			return err
+		}
+	}
+
+	if c.Progress != "" && !utils.StringInSlice(c.Progress, progress.Formats) {
+		return xerrors.Errorf("invalid --progress: %s, expected one of %s", c.Progress, strings.Join(progress.Formats, ", "))
+	}
 
 	c.Severities = c.splitSeverity(c.severities)
 	c.VulnType = strings.Split(c.vulnType, ",")
+	if c.goBinaryDirs != "" {
+		c.GoBinaryDirs = strings.Split(c.goBinaryDirs, ",")
+		c.GoBinaries = true
+	}
+	if c.securityChecks != "" {
+		c.SecurityChecks = strings.Split(c.securityChecks, ",")
+		for _, check := range c.SecurityChecks {
+			switch check {
+			case types.SecurityCheckVulnerability, types.SecurityCheckSecret, types.SecurityCheckConfig, types.SecurityCheckLicense:
+			default:
+				return xerrors.Errorf("unknown security check: %s", check)
+			}
+		}
+	}
+	if c.configPolicy != "" {
+		c.ConfigPolicies = strings.Split(c.configPolicy, ",")
+	}
+	if c.licenseForbidden != "" {
+		if !utils.StringInSlice(types.SecurityCheckLicense, c.SecurityChecks) {
+			return xerrors.New("--license-forbidden requires --security-checks license")
+		}
+		c.ForbiddenLicenses = strings.Split(c.licenseForbidden, ",")
+	}
+	if c.skipDirs != "" {
+		c.SkipDirs = strings.Split(c.skipDirs, ",")
+	}
+	if c.skipFiles != "" {
+		c.SkipFiles = strings.Split(c.skipFiles, ",")
+	}
+	if c.filePatterns != "" {
+		c.FilePatterns = strings.Split(c.filePatterns, ",")
+		if _, err = scanner.ParseFilePatterns(c.FilePatterns); err != nil {
+			return xerrors.Errorf("invalid --file-patterns: %w", err)
+		}
+	}
+	if len(c.SkipDirs) > 0 || len(c.SkipFiles) > 0 || len(c.FilePatterns) > 0 {
+		c.NoCache = true
+	}
+	if c.NoCache && !c.ClearCache && !c.Reset {
+		c.CacheBackend = cache.MemoryBackend
+	}
+	if c.CacheBackend == "" || c.CacheBackend == "fs" || c.CacheBackend == cache.MemoryBackend {
+		if c.CacheTTL != 0 || c.RedisTLS || c.RedisCACert != "" || c.RedisCert != "" || c.RedisKey != "" {
+			c.logger.Warn("--cache-ttl and the --redis-* options are ignored because --cache-backend is not a Redis URL.")
+		}
+	} else if !strings.HasPrefix(c.CacheBackend, "redis://") && !strings.HasPrefix(c.CacheBackend, "rediss://") {
+		return xerrors.Errorf("unknown cache backend: %s", c.CacheBackend)
+	}
+	if c.Parallel < 0 {
+		return xerrors.Errorf("invalid --parallel: negative count %d", c.Parallel)
+	}
+	if c.Runtime, err = daemon.ParseRuntime(c.runtime); err != nil {
+		return xerrors.Errorf("invalid --runtime: %w", err)
+	}
+	if c.EPSSAbove < 0 || c.EPSSAbove >= 1 {
+		return xerrors.Errorf("invalid --epss-above: %g is not in [0, 1)", c.EPSSAbove)
+	}
+	if c.EPSSAbove > 0 || c.KEVOnly {
+		c.ExploitData = true
+	}
+	if c.exitOnSeverity != "" {
+		if c.ExitCode == 0 {
+			c.logger.Warn("--exit-on-severity is ignored because --exit-code is not specified.")
+		}
+		if c.ExitOnSeverities, err = report.SeveritiesFrom(c.exitOnSeverity); err != nil {
+			return xerrors.Errorf("invalid --exit-on-severity: %w", err)
+		}
+	}
+	if c.EOLSeverity != "" {
+		severity, err := dbTypes.NewSeverity(strings.ToUpper(c.EOLSeverity))
+		if err != nil {
+			return xerrors.Errorf("invalid --eol-severity: %w", err)
+		}
+		c.EOLSeverity = severity.String()
+	}
+	if c.NotifyWebhook != "" {
+		if _, err = report.NewSink(c.NotifyFormat, c.NotifyWebhook, c.NotifySecret); err != nil {
+			return xerrors.Errorf("invalid --notify-format: %w", err)
+		}
+	}
+	if c.Attest || c.VerifyAttestation {
+		if c.Key == "" {
+			return xerrors.New("--attest and --verify-attestation require --key")
+		}
+		if c.Filesystem || c.Rootfs || c.Repository || c.SBOM || c.Kubernetes || c.Input != "" {
+			return xerrors.New("--attest and --verify-attestation only support the images of a registry")
+		}
+	}
+	if !c.Kubernetes && c.Report != "" && c.Report != report.ReportAll {
+		if c.Report != report.ReportSummary {
+			return xerrors.Errorf("invalid --report: %s is neither %s nor %s", c.Report, report.ReportSummary, report.ReportAll)
+		}
+		if c.Format != "table" && c.Format != "json" {
+			return xerrors.Errorf("--report summary doesn't support --format %s, use table or json", c.Format)
+		}
+	}
+	if c.Format == "scc" && c.SCCSource == "" {
+		return xerrors.New("--format scc requires --scc-source")
+	}
+	if c.Format == "kafka" && (len(c.KafkaBrokers) == 0 || c.KafkaTopic == "") {
+		return xerrors.New("--format kafka requires --kafka-brokers and --kafka-topic")
+	}
+	if c.ArtifactMetadata {
+		if c.Format != "json" {
+			return xerrors.Errorf("--artifact-metadata doesn't support --format %s, use json", c.Format)
+		}
+		if c.Report == report.ReportSummary {
+			return xerrors.New("--artifact-metadata doesn't support --report summary")
+		}
+	}
+	if c.Kubernetes {
+		if c.Report != k8s.ReportSummary && c.Report != k8s.ReportAll {
+			return xerrors.Errorf("invalid --report: %s is neither %s nor %s", c.Report, k8s.ReportSummary, k8s.ReportAll)
+		}
+		if c.Format != "table" && c.Format != "json" {
+			return xerrors.Errorf("trivy k8s doesn't support --format %s, use table or json", c.Format)
+		}
+		if c.Concurrency < 1 {
+			return xerrors.Errorf("invalid --concurrency: %d is not positive", c.Concurrency)
+		}
+	}
 	c.AppVersion = c.context.App.Version
 
 	// --clear-cache, --download-db-only and --reset don't conduct the scan
@@ -126,29 +534,75 @@ func (c *Config) Init() (err error) {
 	}
 
 	args := c.context.Args()
-	if c.Input == "" && len(args) == 0 {
-		c.logger.Error(`trivy requires at least 1 argument or --input option`)
+	if c.Filesystem && len(args) != 1 {
+		c.logger.Error(`trivy fs requires a directory`)
+		return xerrors.New("arguments error")
+	} else if c.Rootfs && len(args) != 1 {
+		c.logger.Error(`trivy rootfs requires a root filesystem directory`)
+		return xerrors.New("arguments error")
+	} else if c.Repository && len(args) != 1 {
+		c.logger.Error(`trivy repo requires a repository URL`)
+		return xerrors.New("arguments error")
+	} else if c.SBOM && len(args) != 1 {
+		c.logger.Error(`trivy sbom requires an SBOM file`)
+		return xerrors.New("arguments error")
+	} else if c.Kubernetes && len(args) != 0 {
+		c.logger.Error(`trivy k8s takes no arguments, the cluster is the context of the kubeconfig file`)
+		return xerrors.New("arguments error")
+	} else if c.Input == "" && c.InputList == "" && len(args) == 0 && !c.Kubernetes {
+		c.logger.Error(`trivy requires at least 1 argument, --input or --input-list option`)
 		cli.ShowAppHelp(c.context)
 		return xerrors.New("arguments error")
-	} else if len(args) > 1 {
-		c.logger.Error(`multiple images cannot be specified`)
+	} else if c.Input != "" && (len(args) > 1 || c.InputList != "") {
+		c.logger.Error(`multiple images cannot be specified with --input`)
 		return xerrors.New("arguments error")
 	}
 
+	var revisions int
+	for _, revision := range []string{c.Branch, c.Tag, c.Commit} {
+		if revision != "" {
+			revisions++
+		}
+	}
+	if revisions > 1 {
+		return xerrors.New("only one of --branch, --tag and --commit can be specified")
+	}
+
 	c.Output = os.Stdout
-	if c.output != "" {
-		if c.Output, err = os.Create(c.output); err != nil {
-			return xerrors.Errorf("failed to create an output file: %w", err)
+	if c.Format == "sqlite" {
+		// the database is appended to, not truncated
+		if c.output == "" {
+			return xerrors.New("--format sqlite requires --output to specify the database file")
+		}
+		c.OutputPath = c.output
+	} else if c.output != "" {
+		output, err := report.CreateOutput(c.output)
+		if err != nil {
+			return err
 		}
+		c.Output, c.outputFile = output, output
 	}
 
-	if c.Input == "" {
-		c.ImageName = args[0]
+	var imageNames []string
+	if c.Input == "" && !c.Kubernetes {
+		if imageNames, err = c.imageNames(args); err != nil {
+			return err
+		}
+		c.ImageName = imageNames[0]
+	}
+	if len(imageNames) > 1 {
+		c.ImageNames = imageNames
+		if err = c.checkBatch(); err != nil {
+			return err
+		}
 	}
 
 	// Check whether 'latest' tag is used
-	if c.ImageName != "" {
-		image, err := registry.ParseImage(c.ImageName)
+	for _, imageName := range imageNames {
+		if c.Filesystem || c.Rootfs || c.Repository || c.SBOM || sftp.IsTarget(imageName) {
+			break
+		}
+		image, err := registry.ParseImage(imageName)
 		if err != nil {
 			return xerrors.Errorf("invalid image: %w", err)
 		}
@@ -160,6 +614,116 @@ func (c *Config) Init() (err error) {
 	return nil
 }
 
+// imageNames returns the images of the arguments followed by those of --input-list, each once.
+// The blank lines and the comments starting with # of the list are skipped.
+func (c *Config) imageNames(args []string) ([]string, error) {
+	imageNames := append([]string(nil), args...)
+	if c.InputList != "" {
+		b, err := ioutil.ReadFile(c.InputList)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read --input-list: %w", err)
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			imageNames = append(imageNames, line)
+		}
+	}
+
+	var unique []string
+	seen := map[string]bool{}
+	for _, imageName := range imageNames {
+		if !seen[imageName] {
+			seen[imageName] = true
+			unique = append(unique, imageName)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, xerrors.Errorf("no image in --input-list %s", c.InputList)
+	}
+	return unique, nil
+}
+
+// checkBatch fails with the first option the scan of several images doesn't support, which writes one report
+// of all the images in table or JSON
+func (c *Config) checkBatch() error {
+	if c.Format != "table" && c.Format != "json" {
+		return xerrors.Errorf("the scan of several images doesn't support --format %s, use table or json", c.Format)
+	}
+	if c.Concurrency < 1 {
+		return xerrors.Errorf("invalid --concurrency: %d is not positive", c.Concurrency)
+	}
+	for _, o := range []struct {
+		set    bool
+		option string
+	}{
+		{c.BaseImage != "", "--base-image"},
+		{c.Compliance != "", "--compliance"},
+		{c.OutputPlugin != "", "--output-plugin"},
+		{c.NotifyWebhook != "", "--notify-webhook"},
+		{c.MetricsPushgateway != "", "--metrics-pushgateway"},
+		{c.Attest || c.VerifyAttestation, "--attest and --verify-attestation"},
+		{c.ArtifactMetadata, "--artifact-metadata"},
+		// the images are read from Docker Engine or pulled from their registries
+		{c.Runtime == daemon.Containerd || c.Runtime == daemon.Podman, "--runtime " + string(c.Runtime)},
+	} {
+		if o.set {
+			return xerrors.Errorf("the scan of several images doesn't support %s", o.option)
+		}
+	}
+	for _, imageName := range c.ImageNames {
+		if sftp.IsTarget(imageName) {
+			return xerrors.Errorf("the scan of several images doesn't support the remote host %s", imageName)
+		}
+	}
+	return nil
+}
+
+// DaemonOption returns the options of the runtimes a local image is read from
+// checkOffline fails with the first option needing the network, which --offline-scan never accesses
+func (c *Config) checkOffline() error {
+	for _, o := range []struct {
+		set    bool
+		option string
+	}{
+		{c.DownloadDBOnly, "--download-db-only"},
+		{c.CacheBackend != "" && c.CacheBackend != "fs" && c.CacheBackend != cache.MemoryBackend, "--cache-backend " + c.CacheBackend},
+		{c.Repository, "trivy repo"},
+		{c.Kubernetes, "trivy k8s"},
+		{c.BaseImage != "", "--base-image"},
+		{c.NotifyWebhook != "", "--notify-webhook"},
+		{c.MetricsPushgateway != "", "--metrics-pushgateway"},
+		{c.Attest || c.VerifyAttestation, "--attest and --verify-attestation"},
+	} {
+		if o.set {
+			return xerrors.Errorf("--offline-scan doesn't support %s, which needs the network", o.option)
+		}
+	}
+	return nil
+}
+
+// CloseOutput closes the file of --output, flushing the results, e.g. gzipped
+func (c Config) CloseOutput() error {
+	if c.outputFile == nil {
+		return nil
+	}
+	return c.outputFile.Close()
+}
+
+func (c Config) DaemonOption() daemon.Option {
+	return daemon.Option{
+		Runtime: c.Runtime,
+		Containerd: containerd.Option{
+			Socket:    c.ContainerdSocket,
+			Namespace: c.ContainerdNamespace,
+			Timeout:   c.Timeout,
+		},
+		Podman: podman.Option{Socket: c.PodmanSocket},
+	}
+}
+
 func (c *Config) splitSeverity(severity string) []dbTypes.Severity {
 	c.logger.Debugf("Severities: %s", severity)
 	var severities []dbTypes.Severity
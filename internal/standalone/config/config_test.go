@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"io"
 	"os"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 
 	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
 )
@@ -61,26 +63,69 @@ func TestConfig_Init(t *testing.T) {
 		Reset          bool
 		DownloadDBOnly bool
 		SkipUpdate     bool
+		OfflineScan    bool
+		Progress       string
+		DBRepositories []string
 		ClearCache     bool
+		CacheBackend   string
+		CacheTTL       time.Duration
+		NoCache        bool
 		Input          string
+		Filesystem     bool
+		Rootfs         bool
+		Repository     bool
+		SBOM           bool
+		Kubernetes     bool
+		Report         string
+		Concurrency    int
+		Branch         string
+		Commit         string
 		output         string
 		Format         string
 		Template       string
 		Timeout        time.Duration
 		vulnType       string
+		securityChecks string
+		configPolicy   string
 		Light          bool
 		severities     string
 		IgnoreFile     string
 		IgnoreUnfixed  bool
 		ExitCode       int
+		exitOnSeverity string
+		Parallel       int
+		runtime        string
 		ImageName      string
 		VulnType       []string
-		Output         *os.File
+		Output         io.Writer
 		Severities     []dbTypes.Severity
 		AppVersion     string
 		onlyUpdate     string
 		refresh        bool
 		autoRefresh    bool
+
+		licenseForbidden string
+
+		goBinaryDirs string
+		skipDirs     string
+		skipFiles    string
+		filePatterns string
+
+		ExploitData bool
+		EPSSAbove   float64
+		KEVOnly     bool
+
+		NotifyWebhook string
+		NotifyFormat  string
+
+		Attest bool
+		Key    string
+
+		ArtifactMetadata bool
+
+		EOLSeverity string
+
+		InputList string
 	}
 	tests := []struct {
 		name    string
@@ -253,6 +298,631 @@ func TestConfig_Init(t *testing.T) {
 				onlyUpdate: "alpine",
 			},
 		},
+		{
+			name: "happy path: sftp target",
+			fields: fields{
+				severities: "CRITICAL",
+				vulnType:   "os",
+			},
+			args: []string{"sftp://root@example.com/"},
+			want: Config{
+				AppVersion: "0.0.0",
+				Severities: []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities: "CRITICAL",
+				ImageName:  "sftp://root@example.com/",
+				VulnType:   []string{"os"},
+				vulnType:   "os",
+				Output:     os.Stdout,
+			},
+		},
+		{
+			name: "happy path: filesystem",
+			fields: fields{
+				severities: "CRITICAL",
+				vulnType:   "library",
+				Filesystem: true,
+			},
+			args: []string{"./app:latest"},
+			want: Config{
+				AppVersion: "0.0.0",
+				Severities: []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities: "CRITICAL",
+				ImageName:  "./app:latest",
+				VulnType:   []string{"library"},
+				vulnType:   "library",
+				Filesystem: true,
+				Output:     os.Stdout,
+			},
+		},
+		{
+			name: "sad: filesystem without directory",
+			fields: fields{
+				severities: "MEDIUM",
+				Filesystem: true,
+			},
+			logs: []string{
+				"trivy fs requires a directory",
+			},
+			wantErr: "arguments error",
+		},
+		{
+			name: "happy path: rootfs",
+			fields: fields{
+				severities: "CRITICAL",
+				vulnType:   "os",
+				Rootfs:     true,
+			},
+			args: []string{"/mnt/vm"},
+			want: Config{
+				AppVersion: "0.0.0",
+				Severities: []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities: "CRITICAL",
+				ImageName:  "/mnt/vm",
+				VulnType:   []string{"os"},
+				vulnType:   "os",
+				Rootfs:     true,
+				Output:     os.Stdout,
+			},
+		},
+		{
+			name: "sad: rootfs without directory",
+			fields: fields{
+				severities: "MEDIUM",
+				Rootfs:     true,
+			},
+			logs: []string{
+				"trivy rootfs requires a root filesystem directory",
+			},
+			wantErr: "arguments error",
+		},
+		{
+			name: "sad: repository without URL",
+			fields: fields{
+				severities: "MEDIUM",
+				Repository: true,
+			},
+			logs: []string{
+				"trivy repo requires a repository URL",
+			},
+			wantErr: "arguments error",
+		},
+		{
+			name: "happy path: sbom",
+			fields: fields{
+				severities: "CRITICAL",
+				vulnType:   "os,library",
+				SBOM:       true,
+			},
+			args: []string{"bom.json"},
+			want: Config{
+				AppVersion: "0.0.0",
+				Severities: []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities: "CRITICAL",
+				ImageName:  "bom.json",
+				VulnType:   []string{"os", "library"},
+				vulnType:   "os,library",
+				SBOM:       true,
+				Output:     os.Stdout,
+			},
+		},
+		{
+			name: "sad: sbom without file",
+			fields: fields{
+				severities: "MEDIUM",
+				SBOM:       true,
+			},
+			logs: []string{
+				"trivy sbom requires an SBOM file",
+			},
+			wantErr: "arguments error",
+		},
+		{
+			name: "happy path: k8s",
+			fields: fields{
+				severities:  "CRITICAL",
+				vulnType:    "os,library",
+				Format:      "table",
+				Kubernetes:  true,
+				Report:      "summary",
+				Concurrency: 5,
+			},
+			want: Config{
+				AppVersion:  "0.0.0",
+				Severities:  []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities:  "CRITICAL",
+				VulnType:    []string{"os", "library"},
+				vulnType:    "os,library",
+				Format:      "table",
+				Kubernetes:  true,
+				Report:      "summary",
+				Concurrency: 5,
+				Output:      os.Stdout,
+			},
+		},
+		{
+			name: "sad: k8s with an argument",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "table",
+				Kubernetes:  true,
+				Report:      "all",
+				Concurrency: 5,
+			},
+			args: []string{"alpine:3.10"},
+			logs: []string{
+				"trivy k8s takes no arguments, the cluster is the context of the kubeconfig file",
+			},
+			wantErr: "arguments error",
+		},
+		{
+			name: "sad: k8s with an unknown report",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "table",
+				Kubernetes:  true,
+				Report:      "full",
+				Concurrency: 5,
+			},
+			wantErr: "invalid --report: full is neither summary nor all",
+		},
+		{
+			name: "sad: k8s with sarif",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "sarif",
+				Kubernetes:  true,
+				Report:      "summary",
+				Concurrency: 5,
+			},
+			wantErr: "trivy k8s doesn't support --format sarif",
+		},
+		{
+			name: "sad: summary report with sarif",
+			fields: fields{
+				severities: "MEDIUM",
+				Format:     "sarif",
+				Report:     "summary",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--report summary doesn't support --format sarif, use table or json",
+		},
+		{
+			name: "sad: scc without source",
+			fields: fields{
+				severities: "MEDIUM",
+				Format:     "scc",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--format scc requires --scc-source",
+		},
+		{
+			name: "sad: invalid EOL severity",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "table",
+				EOLSeverity: "SEVERE",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --eol-severity",
+		},
+		{
+			name: "sad: artifact metadata with table",
+			fields: fields{
+				severities:       "MEDIUM",
+				Format:           "table",
+				ArtifactMetadata: true,
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--artifact-metadata doesn't support --format table, use json",
+		},
+		{
+			name: "sad: unknown report",
+			fields: fields{
+				severities: "MEDIUM",
+				Format:     "json",
+				Report:     "counts",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --report: counts is neither summary nor all",
+		},
+		{
+			name: "sad: branch and commit",
+			fields: fields{
+				severities: "MEDIUM",
+				Repository: true,
+				Branch:     "main",
+				Commit:     "0123456789abcdef0123456789abcdef01234567",
+			},
+			args:    []string{"https://github.com/knqyf263/trivy-ci-test"},
+			wantErr: "only one of --branch, --tag and --commit can be specified",
+		},
+		{
+			name: "happy path: secrets",
+			fields: fields{
+				severities:     "CRITICAL",
+				vulnType:       "os",
+				securityChecks: "vuln,secret",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:     "0.0.0",
+				Severities:     []dbTypes.Severity{dbTypes.SeverityCritical},
+				severities:     "CRITICAL",
+				ImageName:      "alpine:3.10",
+				VulnType:       []string{"os"},
+				vulnType:       "os",
+				SecurityChecks: []string{"vuln", "secret"},
+				securityChecks: "vuln,secret",
+				Output:         os.Stdout,
+			},
+		},
+		{
+			name: "happy path: config policies",
+			fields: fields{
+				severities:     "HIGH",
+				securityChecks: "config",
+				configPolicy:   "policies,custom.rego",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:     "0.0.0",
+				Severities:     []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:     "HIGH",
+				ImageName:      "alpine:3.10",
+				VulnType:       []string{""},
+				SecurityChecks: []string{"config"},
+				securityChecks: "config",
+				ConfigPolicies: []string{"policies", "custom.rego"},
+				configPolicy:   "policies,custom.rego",
+				Output:         os.Stdout,
+			},
+		},
+		{
+			name: "happy path: forbidden licenses",
+			fields: fields{
+				severities:       "HIGH",
+				securityChecks:   "vuln,license",
+				licenseForbidden: "GPL-3.0,AGPL-3.0",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:        "0.0.0",
+				Severities:        []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:        "HIGH",
+				ImageName:         "alpine:3.10",
+				VulnType:          []string{""},
+				SecurityChecks:    []string{"vuln", "license"},
+				securityChecks:    "vuln,license",
+				ForbiddenLicenses: []string{"GPL-3.0", "AGPL-3.0"},
+				licenseForbidden:  "GPL-3.0,AGPL-3.0",
+				Output:            os.Stdout,
+			},
+		},
+		{
+			name: "sad: forbidden licenses without the license check",
+			fields: fields{
+				severities:       "HIGH",
+				securityChecks:   "vuln",
+				licenseForbidden: "GPL-3.0",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--license-forbidden requires --security-checks license",
+		},
+		{
+			name: "happy path: exit on severity",
+			fields: fields{
+				severities:     "MEDIUM,HIGH,CRITICAL",
+				ExitCode:       1,
+				exitOnSeverity: "high",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:       "0.0.0",
+				Severities:       []dbTypes.Severity{dbTypes.SeverityMedium, dbTypes.SeverityHigh, dbTypes.SeverityCritical},
+				severities:       "MEDIUM,HIGH,CRITICAL",
+				ImageName:        "alpine:3.10",
+				VulnType:         []string{""},
+				ExitCode:         1,
+				exitOnSeverity:   "high",
+				ExitOnSeverities: []string{"HIGH", "CRITICAL"},
+				Output:           os.Stdout,
+			},
+		},
+		{
+			name: "sad: exit on an unknown severity",
+			fields: fields{
+				severities:     "HIGH",
+				ExitCode:       1,
+				exitOnSeverity: "SEVERE",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --exit-on-severity",
+		},
+		{
+			name: "happy path: exit on severity without exit code",
+			fields: fields{
+				severities:     "HIGH",
+				exitOnSeverity: "CRITICAL",
+			},
+			args: []string{"alpine:3.10"},
+			logs: []string{
+				"--exit-on-severity is ignored because --exit-code is not specified.",
+			},
+			want: Config{
+				AppVersion:       "0.0.0",
+				Severities:       []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:       "HIGH",
+				ImageName:        "alpine:3.10",
+				VulnType:         []string{""},
+				exitOnSeverity:   "CRITICAL",
+				ExitOnSeverities: []string{"CRITICAL"},
+				Output:           os.Stdout,
+			},
+		},
+		{
+			name: "happy path: cache TTL without Redis",
+			fields: fields{
+				severities:   "HIGH",
+				CacheBackend: "fs",
+				CacheTTL:     time.Hour,
+			},
+			args: []string{"alpine:3.10"},
+			logs: []string{
+				"--cache-ttl and the --redis-* options are ignored because --cache-backend is not a Redis URL.",
+			},
+			want: Config{
+				AppVersion:   "0.0.0",
+				Severities:   []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:   "HIGH",
+				ImageName:    "alpine:3.10",
+				VulnType:     []string{""},
+				CacheBackend: "fs",
+				CacheTTL:     time.Hour,
+				Output:       os.Stdout,
+			},
+		},
+		{
+			name: "happy path: no cache",
+			fields: fields{
+				severities:   "HIGH",
+				CacheBackend: "fs",
+				NoCache:      true,
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:   "0.0.0",
+				Severities:   []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:   "HIGH",
+				ImageName:    "alpine:3.10",
+				VulnType:     []string{""},
+				CacheBackend: "memory",
+				NoCache:      true,
+				Output:       os.Stdout,
+			},
+		},
+		{
+			name: "happy path: path filters",
+			fields: fields{
+				severities:   "HIGH",
+				skipDirs:     "node_modules,usr/src/app/test",
+				skipFiles:    "*.sum",
+				filePatterns: "pipenv:Pipfile.*.lock",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:   "0.0.0",
+				Severities:   []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:   "HIGH",
+				ImageName:    "alpine:3.10",
+				VulnType:     []string{""},
+				CacheBackend: "memory",
+				NoCache:      true,
+				skipDirs:     "node_modules,usr/src/app/test",
+				skipFiles:    "*.sum",
+				filePatterns: "pipenv:Pipfile.*.lock",
+				SkipDirs:     []string{"node_modules", "usr/src/app/test"},
+				SkipFiles:    []string{"*.sum"},
+				FilePatterns: []string{"pipenv:Pipfile.*.lock"},
+				Output:       os.Stdout,
+			},
+		},
+		{
+			name: "happy path: go binary dirs",
+			fields: fields{
+				severities:   "HIGH",
+				goBinaryDirs: "opt/app,srv/",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:   "0.0.0",
+				Severities:   []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:   "HIGH",
+				ImageName:    "alpine:3.10",
+				VulnType:     []string{""},
+				GoBinaries:   true,
+				goBinaryDirs: "opt/app,srv/",
+				GoBinaryDirs: []string{"opt/app", "srv/"},
+				Output:       os.Stdout,
+			},
+		},
+		{
+			name: "happy path: offline scan",
+			fields: fields{
+				severities:  "HIGH",
+				OfflineScan: true,
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:  "0.0.0",
+				Severities:  []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:  "HIGH",
+				ImageName:   "alpine:3.10",
+				VulnType:    []string{""},
+				SkipUpdate:  true,
+				OfflineScan: true,
+				Output:      os.Stdout,
+			},
+		},
+		{
+			name: "sad: offline scan with a webhook",
+			fields: fields{
+				severities:    "HIGH",
+				OfflineScan:   true,
+				NotifyWebhook: "https://hooks.example.com/trivy",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--offline-scan doesn't support --notify-webhook, which needs the network",
+		},
+		{
+			name: "sad: offline scan with a Redis cache",
+			fields: fields{
+				severities:   "HIGH",
+				OfflineScan:  true,
+				CacheBackend: "redis://redis:6379",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--offline-scan doesn't support --cache-backend redis://redis:6379, which needs the network",
+		},
+		{
+			name: "sad: invalid progress",
+			fields: fields{
+				severities: "HIGH",
+				Progress:   "xml",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --progress: xml, expected one of bar, json, none",
+		},
+		{
+			name: "sad: invalid file pattern",
+			fields: fields{
+				severities:   "HIGH",
+				filePatterns: "pipenv:Pipfile.*.lock,Gemfile.lock",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: `invalid --file-patterns: invalid file pattern "Gemfile.lock", expected analyzer:pattern`,
+		},
+		{
+			name: "sad: invalid db repository",
+			fields: fields{
+				severities:     "HIGH",
+				DBRepositories: []string{"https://mirror.example.com/trivy-db", "trivy-db"},
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: `invalid DB repository "trivy-db", expected owner/repo or the http(s) URL of a mirror`,
+		},
+		{
+			name: "sad: unknown cache backend",
+			fields: fields{
+				severities:   "HIGH",
+				CacheBackend: "memcached://localhost:11211",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "unknown cache backend: memcached://localhost:11211",
+		},
+		{
+			name: "sad: negative parallel",
+			fields: fields{
+				severities: "HIGH",
+				Parallel:   -1,
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --parallel: negative count -1",
+		},
+		{
+			name: "happy path: forced runtime",
+			fields: fields{
+				severities: "HIGH",
+				runtime:    "containerd",
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion: "0.0.0",
+				Severities: []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities: "HIGH",
+				ImageName:  "alpine:3.10",
+				VulnType:   []string{""},
+				runtime:    "containerd",
+				Runtime:    daemon.Containerd,
+				Output:     os.Stdout,
+			},
+		},
+		{
+			name: "sad: unknown runtime",
+			fields: fields{
+				severities: "HIGH",
+				runtime:    "cri-o",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: `invalid --runtime: unknown runtime "cri-o"`,
+		},
+		{
+			name: "happy path: EPSS threshold and KEV",
+			fields: fields{
+				severities: "HIGH",
+				EPSSAbove:  0.5,
+				KEVOnly:    true,
+			},
+			args: []string{"alpine:3.10"},
+			want: Config{
+				AppVersion:  "0.0.0",
+				Severities:  []dbTypes.Severity{dbTypes.SeverityHigh},
+				severities:  "HIGH",
+				ImageName:   "alpine:3.10",
+				VulnType:    []string{""},
+				Output:      os.Stdout,
+				ExploitData: true,
+				EPSSAbove:   0.5,
+				KEVOnly:     true,
+			},
+		},
+		{
+			name: "sad: EPSS threshold out of range",
+			fields: fields{
+				severities: "HIGH",
+				EPSSAbove:  50,
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "invalid --epss-above: 50 is not in [0, 1)",
+		},
+		{
+			name: "sad: unknown notification format",
+			fields: fields{
+				severities:    "HIGH",
+				NotifyWebhook: "https://hooks.example.com/trivy",
+				NotifyFormat:  "teams",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: `invalid --notify-format: unknown sink format "teams"`,
+		},
+		{
+			name: "sad: attest without key",
+			fields: fields{
+				severities: "HIGH",
+				Attest:     true,
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "--attest and --verify-attestation require --key",
+		},
+		{
+			name: "sad: attest a directory",
+			fields: fields{
+				severities: "HIGH",
+				Filesystem: true,
+				Attest:     true,
+				Key:        "cosign.key",
+			},
+			args:    []string{"/app"},
+			wantErr: "--attest and --verify-attestation only support the images of a registry",
+		},
+		{
+			name: "sad: unknown security check",
+			fields: fields{
+				severities:     "MEDIUM",
+				securityChecks: "vuln,misconf",
+			},
+			args:    []string{"alpine:3.10"},
+			wantErr: "unknown security check: misconf",
+		},
 		{
 			name: "sad: skip and download db",
 			fields: fields{
@@ -267,23 +937,109 @@ func TestConfig_Init(t *testing.T) {
 			wantErr: "The --skip-update and --download-db-only option can not be specified both",
 		},
 		{
-			name: "sad: multiple image names",
+			name: "happy path: multiple image names",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "table",
+				Concurrency: 5,
+				InputList:   "testdata/images.txt",
+				vulnType:    "os",
+			},
+			args: []string{"centos:7", "debian:10"},
+			want: Config{
+				AppVersion:  "0.0.0",
+				Severities:  []dbTypes.Severity{dbTypes.SeverityMedium},
+				severities:  "MEDIUM",
+				Format:      "table",
+				Concurrency: 5,
+				InputList:   "testdata/images.txt",
+				VulnType:    []string{"os"},
+				vulnType:    "os",
+				ImageName:   "centos:7",
+				ImageNames:  []string{"centos:7", "debian:10", "alpine:3.10", "nginx:1.19"},
+				Output:      os.Stdout,
+			},
+		},
+		{
+			name: "happy path: duplicate image names",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "table",
+				Concurrency: 5,
+				InputList:   "testdata/images.txt",
+				vulnType:    "os",
+			},
+			args: []string{"centos:7", "alpine:3.10", "centos:7", "nginx:1.19"},
+			want: Config{
+				AppVersion:  "0.0.0",
+				Severities:  []dbTypes.Severity{dbTypes.SeverityMedium},
+				severities:  "MEDIUM",
+				Format:      "table",
+				Concurrency: 5,
+				InputList:   "testdata/images.txt",
+				VulnType:    []string{"os"},
+				vulnType:    "os",
+				ImageName:   "centos:7",
+				ImageNames:  []string{"centos:7", "alpine:3.10", "nginx:1.19"},
+				Output:      os.Stdout,
+			},
+		},
+		{
+			name: "sad: multiple image names with sarif",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "sarif",
+				Concurrency: 5,
+			},
+			args:    []string{"centos:7", "alpine:3.10"},
+			wantErr: "the scan of several images doesn't support --format sarif, use table or json",
+		},
+		{
+			name: "sad: multiple image names without concurrency",
 			fields: fields{
 				severities: "MEDIUM",
+				Format:     "json",
+			},
+			args:    []string{"centos:7", "alpine:3.10"},
+			wantErr: "invalid --concurrency: 0 is not positive",
+		},
+		{
+			name: "sad: multiple image names with a remote host",
+			fields: fields{
+				severities:  "MEDIUM",
+				Format:      "json",
+				Concurrency: 5,
+			},
+			args:    []string{"centos:7", "sftp://root@10.0.0.5/"},
+			wantErr: "the scan of several images doesn't support the remote host sftp://root@10.0.0.5/",
+		},
+		{
+			name: "sad: multiple image names with --input",
+			fields: fields{
+				severities: "MEDIUM",
+				Input:      "alpine.tar",
 			},
 			args: []string{"centos:7", "alpine:3.10"},
 			logs: []string{
-				"multiple images cannot be specified",
+				"multiple images cannot be specified with --input",
 			},
 			wantErr: "arguments error",
 		},
+		{
+			name: "sad: missing input list",
+			fields: fields{
+				severities: "MEDIUM",
+				InputList:  "testdata/missing.txt",
+			},
+			wantErr: "unable to read --input-list",
+		},
 		{
 			name: "sad: no image name",
 			fields: fields{
 				severities: "MEDIUM",
 			},
 			logs: []string{
-				"trivy requires at least 1 argument or --input option",
+				"trivy requires at least 1 argument, --input or --input-list option",
 			},
 			wantErr: "arguments error",
 		},
@@ -316,23 +1072,67 @@ func TestConfig_Init(t *testing.T) {
 				Reset:          tt.fields.Reset,
 				DownloadDBOnly: tt.fields.DownloadDBOnly,
 				SkipUpdate:     tt.fields.SkipUpdate,
+				OfflineScan:    tt.fields.OfflineScan,
+				Progress:       tt.fields.Progress,
+				DBRepositories: tt.fields.DBRepositories,
 				ClearCache:     tt.fields.ClearCache,
+				CacheBackend:   tt.fields.CacheBackend,
+				CacheTTL:       tt.fields.CacheTTL,
+				NoCache:        tt.fields.NoCache,
 				Input:          tt.fields.Input,
+				Filesystem:     tt.fields.Filesystem,
+				Rootfs:         tt.fields.Rootfs,
+				Repository:     tt.fields.Repository,
+				SBOM:           tt.fields.SBOM,
+				Kubernetes:     tt.fields.Kubernetes,
+				Report:         tt.fields.Report,
+				Concurrency:    tt.fields.Concurrency,
+				Branch:         tt.fields.Branch,
+				Commit:         tt.fields.Commit,
 				output:         tt.fields.output,
 				Format:         tt.fields.Format,
 				Template:       tt.fields.Template,
 				Timeout:        tt.fields.Timeout,
 				vulnType:       tt.fields.vulnType,
+				securityChecks: tt.fields.securityChecks,
+				configPolicy:   tt.fields.configPolicy,
 				Light:          tt.fields.Light,
 				severities:     tt.fields.severities,
 				IgnoreFile:     tt.fields.IgnoreFile,
 				IgnoreUnfixed:  tt.fields.IgnoreUnfixed,
 				ExitCode:       tt.fields.ExitCode,
+				exitOnSeverity: tt.fields.exitOnSeverity,
+				Parallel:       tt.fields.Parallel,
+				runtime:        tt.fields.runtime,
 				ImageName:      tt.fields.ImageName,
 				Output:         tt.fields.Output,
 				onlyUpdate:     tt.fields.onlyUpdate,
 				refresh:        tt.fields.refresh,
 				autoRefresh:    tt.fields.autoRefresh,
+
+				licenseForbidden: tt.fields.licenseForbidden,
+
+				goBinaryDirs: tt.fields.goBinaryDirs,
+
+				skipDirs:     tt.fields.skipDirs,
+				skipFiles:    tt.fields.skipFiles,
+				filePatterns: tt.fields.filePatterns,
+
+				ExploitData: tt.fields.ExploitData,
+				EPSSAbove:   tt.fields.EPSSAbove,
+				KEVOnly:     tt.fields.KEVOnly,
+
+				NotifyWebhook: tt.fields.NotifyWebhook,
+				NotifyFormat:  tt.fields.NotifyFormat,
+
+				Attest: tt.fields.Attest,
+				Key:    tt.fields.Key,
+
+				ArtifactMetadata: tt.fields.ArtifactMetadata,
+
+				EOLSeverity: tt.fields.EOLSeverity,
+
+				InputList: tt.fields.InputList,
 			}
 
 			err := c.Init()
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/vulnerability"
 	"github.com/google/wire"
@@ -24,6 +26,46 @@ func initializeArchiveScanner(ctx context.Context, filePath string, layerCache c
 	return scanner.Scanner{}, nil
 }
 
+func initializeOCIScanner(filePath string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (
+	scanner.Scanner, func(), error) {
+	wire.Build(scanner.StandaloneOCISet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializeContainerdScanner(ctx context.Context, imageName string, opt containerd.Option, layerCache cache.ImageCache,
+	localImageCache cache.LocalImageCache) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.StandaloneContainerdSet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializePodmanScanner(ctx context.Context, imageName string, opt podman.Option, layerCache cache.ImageCache,
+	localImageCache cache.LocalImageCache) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.StandalonePodmanSet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializeSFTPScanner(target string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache,
+	timeout time.Duration) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.StandaloneSFTPSet)
+	return scanner.Scanner{}, nil, nil
+}
+
+func initializeFilesystemScanner(root string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (
+	scanner.Scanner, error) {
+	wire.Build(scanner.StandaloneFilesystemSet)
+	return scanner.Scanner{}, nil
+}
+
+func initializeSBOMScanner(filePath string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) scanner.Scanner {
+	wire.Build(scanner.StandaloneSBOMSet)
+	return scanner.Scanner{}
+}
+
+func initializeBatchScanner(factory scanner.AnalyzerFactory, localImageCache cache.LocalImageCache) scanner.BatchScanner {
+	wire.Build(scanner.StandaloneBatchSet)
+	return scanner.BatchScanner{}
+}
+
 func initializeVulnerabilityClient() vulnerability.Client {
 	wire.Build(vulnerability.SuperSet)
 	return vulnerability.Client{}
@@ -0,0 +1,146 @@
+package standalone
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/urfave/cli"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/internal/standalone/config"
+	"github.com/aquasecurity/trivy/pkg/k8s"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/registry"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/scanner"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// RunKubernetes scans the images of the workloads of the cluster of the kubeconfig, each image once
+func RunKubernetes(cliCtx *cli.Context) error {
+	c, err := config.New(cliCtx)
+	if err != nil {
+		return err
+	}
+	c.Kubernetes = true
+	return runKubernetes(c)
+}
+
+func runKubernetes(c config.Config) (err error) {
+	ctx, cancel := withTimeout(c)
+	defer cancel()
+	defer func() { err = explainTimeout(ctx, c, err) }()
+
+	cacheClient, err := initialize(ctx, &c)
+	if err != nil || cacheClient == nil {
+		return err
+	}
+	defer func() { err = closeOutput(c, err) }()
+
+	kubeConfig, err := k8s.LoadConfig(c.Kubeconfig, c.KubeContext)
+	if err != nil {
+		return xerrors.Errorf("unable to load the kubeconfig: %w", err)
+	}
+	client, err := k8s.NewClient(kubeConfig)
+	if err != nil {
+		return xerrors.Errorf("unable to initialize the client of %s: %w", kubeConfig.Name, err)
+	}
+	workloads, err := client.ListWorkloads(ctx, c.Namespace)
+	if err != nil {
+		return xerrors.Errorf("unable to list the workloads of %s: %w", kubeConfig.Name, err)
+	}
+
+	// an image of several workloads is pulled with the first pull secret of its registry
+	pullSecrets := k8s.NewPullSecrets(client)
+	var images []string
+	credentials := map[string]registry.Credential{}
+	for _, w := range workloads {
+		creds, err := pullSecrets.Credentials(ctx, w)
+		if err != nil {
+			log.Logger.Warnf("Unable to get the image pull secrets of %s/%s/%s: %s", w.Namespace, w.Kind, w.Name, err)
+		}
+		for _, image := range w.Images {
+			cred, ok := credentials[image]
+			if !ok {
+				images = append(images, image)
+			}
+			if cred.Empty() {
+				credentials[image] = creds.For(image)
+			}
+		}
+	}
+	log.Logger.Infof("%d images of %d workloads in %s", len(images), len(workloads), kubeConfig.Name)
+
+	scanOptions, err := newScanOptions(ctx, c)
+	if err != nil {
+		return err
+	}
+	scanOptions.BatchWorkers = c.Concurrency
+	factory := func(ctx context.Context, imageName string) (scanner.Analyzer, func(), error) {
+		dockerOption, err := kubernetesDockerOption(ctx, imageName, credentials[imageName], c.Timeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		ext, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
+		if err != nil {
+			return nil, nil, types.ExplainTLSError(err)
+		}
+		return scanner.NewImageAnalyzer(analyzer.New(ext, cacheClient)), cleanup, nil
+	}
+	scans, err := initializeBatchScanner(factory, cacheClient).ScanImages(ctx, images, scanOptions)
+	if err != nil {
+		return xerrors.Errorf("error in the scan of the images: %w", err)
+	}
+
+	vulnClient := initializeVulnerabilityClient()
+	results := map[string]k8s.ImageResult{}
+	for _, scan := range scans.Images {
+		result := k8s.ImageResult{Image: scan.Image}
+		if scan.Err != nil {
+			// a failed image doesn't fail the scan of the cluster, it is reported
+			log.Logger.Warnf("Unable to scan %s: %s", scan.Image, scan.Err)
+			result.Error = scan.Err.Error()
+		} else {
+			for i := range scan.Results {
+				scan.Results[i].Vulnerabilities = vulnClient.Filter(scan.Results[i].Vulnerabilities,
+					c.Severities, c.IgnoreUnfixed, c.IgnoreFile)
+			}
+			result.Results = scan.Results
+		}
+		results[scan.Image] = result
+	}
+
+	r := k8s.NewReport(kubeConfig.Name, workloads, results)
+	writer := k8s.ReportWriter{Output: c.Output, Format: c.Format, Report: c.Report, Light: c.Light}
+	if err = writer.Write(r); err != nil {
+		return xerrors.Errorf("unable to write results: %w", err)
+	}
+
+	// flushed before the exit codes, which skip the deferred calls
+	if err = c.CloseOutput(); err != nil {
+		return err
+	}
+
+	if code := report.ExitCodeGate(c.ExitOnSeverities).ExitCode(r.Results(), c.ExitCode); code != 0 {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// kubernetesDockerOption returns the Docker option of the image with the credential of its pull secret,
+// which has priority over those of registry.Resolve
+func kubernetesDockerOption(ctx context.Context, imageName string, cred registry.Credential, timeout time.Duration) (ftypes.DockerOption, error) {
+	if cred.Empty() {
+		return registry.GetDockerOption(ctx, imageName, timeout)
+	}
+	opt, err := types.GetDockerOption(timeout)
+	if err != nil {
+		return ftypes.DockerOption{}, err
+	}
+	opt.UserName, opt.Password = cred.Username, cred.Password
+	return opt, nil
+}
@@ -0,0 +1,72 @@
+package standalone
+
+import (
+	"context"
+
+	"github.com/spf13/afero"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/internal/standalone/config"
+	dbFile "github.com/aquasecurity/trivy/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/extractor/archive"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/scanner"
+)
+
+// artifactName is the name of the scanned artifact in the report, the path of --input for an untagged archive
+func artifactName(c config.Config, imageRef ftypes.ImageReference) string {
+	if imageRef.Name != "" {
+		return imageRef.Name
+	} else if c.ImageName != "" {
+		return c.ImageName
+	}
+	return c.Input
+}
+
+// artifactMetadata returns the metadata of the scanned artifact, the scanner and the DB of --artifact-metadata.
+// The repo tags and digests are read from Docker Engine for its images and from the manifest of the archive of
+// --input, the metadata which can't be read is omitted.
+func artifactMetadata(ctx context.Context, c config.Config, r scanner.ImageReport, dockerImage bool) *report.ArtifactMetadata {
+	metadata := &report.ArtifactMetadata{
+		ImageID:        r.Image.ID,
+		Created:        r.Created,
+		OS:             r.OS,
+		EOSL:           r.EOSL,
+		ScannerVersion: c.AppVersion,
+	}
+
+	var err error
+	if dockerImage {
+		if metadata.RepoTags, metadata.RepoDigests, err = daemon.InspectDockerImage(ctx, c.ImageName); err != nil {
+			log.Logger.Debugf("Unable to get the repo tags of %s: %s", c.ImageName, err)
+		}
+	} else if c.Input != "" && !oci.IsLayout(c.Input) {
+		if metadata.RepoTags, err = archive.RepoTags(c.Input); err != nil {
+			log.Logger.Debugf("Unable to get the repo tags of %s: %s", c.Input, err)
+		}
+	}
+
+	db, err := dbFile.NewMetadata(afero.NewOsFs(), c.CacheDir).Get()
+	if err != nil {
+		log.Logger.Debugf("Unable to read the DB metadata: %s", err)
+		return metadata
+	}
+	metadata.DB = &report.DBMetadata{Version: db.Version, UpdatedAt: db.UpdatedAt, NextUpdate: db.NextUpdate}
+	return metadata
+}
+
+// repoDigest returns the first repo digest of the image of Docker Engine, empty for the other targets
+func repoDigest(ctx context.Context, c config.Config, dockerImage bool) string {
+	if !dockerImage {
+		return ""
+	}
+	_, digests, err := daemon.InspectDockerImage(ctx, c.ImageName)
+	if err != nil || len(digests) == 0 {
+		log.Logger.Debugf("Unable to get the repo digest of %s: %v", c.ImageName, err)
+		return ""
+	}
+	return digests[0]
+}
@@ -2,23 +2,46 @@ package standalone
 
 import (
 	"context"
-	"io/ioutil"
+	"fmt"
 	l "log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aquasecurity/trivy-db/pkg/db"
 
-	"github.com/aquasecurity/fanal/cache"
+	fcache "github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
 	"github.com/aquasecurity/trivy/internal/operation"
 	"github.com/aquasecurity/trivy/internal/standalone/config"
+	"github.com/aquasecurity/trivy/pkg/advisory"
+	"github.com/aquasecurity/trivy/pkg/cache"
+	"github.com/aquasecurity/trivy/pkg/customanalyzer"
+	dbFile "github.com/aquasecurity/trivy/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/extractor/daemon"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/extractor/sftp"
+	"github.com/aquasecurity/trivy/pkg/feed"
+	"github.com/aquasecurity/trivy/pkg/gobinary"
+	"github.com/aquasecurity/trivy/pkg/jar"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/metrics"
+	"github.com/aquasecurity/trivy/pkg/osrelease"
+	"github.com/aquasecurity/trivy/pkg/plugin"
+	"github.com/aquasecurity/trivy/pkg/progress"
+	"github.com/aquasecurity/trivy/pkg/registry"
 	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/report/sqlite"
 	"github.com/aquasecurity/trivy/pkg/scanner"
+	"github.com/aquasecurity/trivy/pkg/transport"
 	"github.com/aquasecurity/trivy/pkg/types"
 	"github.com/aquasecurity/trivy/pkg/utils"
+	"github.com/aquasecurity/trivy/pkg/windows"
+	"github.com/spf13/afero"
 	"github.com/urfave/cli"
 	"golang.org/x/xerrors"
+	"k8s.io/utils/clock"
 )
 
 func Run(cliCtx *cli.Context) error {
@@ -29,104 +52,573 @@ func Run(cliCtx *cli.Context) error {
 	return run(c)
 }
 
-func run(c config.Config) (err error) {
-	if err = log.InitLogger(c.Debug, c.Quiet); err != nil {
-		l.Fatal(err)
+// RunFilesystem scans the local directory of the argument, e.g. a CI workspace, as Run scans an image
+func RunFilesystem(cliCtx *cli.Context) error {
+	c, err := config.New(cliCtx)
+	if err != nil {
+		return err
 	}
+	c.Filesystem = true
+	return run(c)
+}
 
-	// initialize config
-	if err = c.Init(); err != nil {
-		return xerrors.Errorf("failed to initialize options: %w", err)
+// RunRootfs scans the root filesystem of the argument, e.g. of docker export or a mounted VM disk, detecting its OS
+// from os-release when the files of the distribution are missing
+func RunRootfs(cliCtx *cli.Context) error {
+	c, err := config.New(cliCtx)
+	if err != nil {
+		return err
 	}
+	c.Rootfs = true
+	return run(c)
+}
 
-	// configure cache dir
-	utils.SetCacheDir(c.CacheDir)
-	cacheClient, err := cache.NewFSCache(c.CacheDir)
+// RunRepository scans a revision of the git repository of the argument, cloned into a temporary directory
+func RunRepository(cliCtx *cli.Context) error {
+	c, err := config.New(cliCtx)
 	if err != nil {
-		return xerrors.Errorf("unable to initialize the cache: %w", err)
+		return err
 	}
+	c.Repository = true
+	return run(c)
+}
 
-	cacheOperation := operation.NewCache(cacheClient)
-	log.Logger.Debugf("cache dir:  %s", utils.CacheDir())
-
-	if c.Reset {
-		return cacheOperation.Reset()
-	}
-	if c.ClearCache {
-		return cacheOperation.ClearImages()
+// RunSBOM scans the packages of the CycloneDX or SPDX document of the argument, without the artifact it describes
+func RunSBOM(cliCtx *cli.Context) error {
+	c, err := config.New(cliCtx)
+	if err != nil {
+		return err
 	}
+	c.SBOM = true
+	return run(c)
+}
 
-	// download the database file
-	noProgress := c.Quiet || c.NoProgress
-	if err = operation.DownloadDB(c.AppVersion, c.CacheDir, noProgress, c.Light, c.SkipUpdate); err != nil {
+func run(c config.Config) (err error) {
+	ctx, cancel := withTimeout(c)
+	defer cancel()
+	defer func() { err = explainTimeout(ctx, c, err) }()
+
+	cacheClient, err := initialize(ctx, &c)
+	if err != nil || cacheClient == nil {
 		return err
 	}
-
-	if c.DownloadDBOnly {
-		return nil
+	defer db.Close()
+	defer func() { err = closeOutput(c, err) }()
+	if len(c.ImageNames) > 1 {
+		return runBatch(ctx, c, cacheClient)
 	}
 
-	if err = db.Init(c.CacheDir); err != nil {
-		return xerrors.Errorf("error in vulnerability DB initialize: %w", err)
+	// imageReport has the OS and the creation time of the scanned image, only its name for the other targets
+	var imageReport scanner.ImageReport
+	var scanner scanner.Scanner
+
+	var att *attester
+	if c.Attest || c.VerifyAttestation {
+		if att, err = newAttester(ctx, c); err != nil {
+			return err
+		}
+		if c.VerifyAttestation {
+			if err = att.verify(); err != nil {
+				return xerrors.Errorf("unable to verify the attestation of %s: %w", c.ImageName, err)
+			}
+		}
 	}
 
-	var scanner scanner.Scanner
-	ctx := context.Background()
+	var outputPlugin *plugin.Plugin
+	if c.OutputPlugin != "" {
+		// loaded before the scan so that a missing plugin doesn't waste it
+		p, err := plugin.NewManager(plugin.DefaultDir()).Load(c.OutputPlugin)
+		if err != nil {
+			return xerrors.Errorf("unable to load the output plugin: %w", err)
+		}
+		outputPlugin = &p
+	}
 
 	cleanup := func() {}
-	if c.Input != "" {
+	// dockerImage is true for the images of Docker Engine, whose repo tags and digests are inspected for the metadata
+	var dockerImage bool
+	if c.Repository {
+		// scan the clone of the repository as a local directory, created by ScanRepository under the temporary directory
+		scanner, err = initializeFilesystemScanner(os.TempDir(), cacheClient, cacheClient)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the repository scanner: %w", err)
+		}
+	} else if c.SBOM {
+		// scan the packages of an SBOM
+		scanner = initializeSBOMScanner(c.ImageName, cacheClient, cacheClient)
+	} else if c.Filesystem {
+		// scan a local directory
+		scanner, err = initializeFilesystemScanner(c.ImageName, cacheClient, cacheClient)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the filesystem scanner: %w", err)
+		}
+	} else if c.Rootfs {
+		// scan a root filesystem as a local directory, with the OS of os-release
+		osrelease.Register()
+		scanner, err = initializeFilesystemScanner(c.ImageName, cacheClient, cacheClient)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the root filesystem scanner: %w", err)
+		}
+	} else if c.Input != "" && oci.IsLayout(c.Input) {
+		// scan an OCI image layout, a directory or a tarball
+		scanner, cleanup, err = initializeOCIScanner(c.Input, cacheClient, cacheClient)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the OCI layout scanner: %w", err)
+		}
+	} else if c.Input != "" {
 		// scan tar file
 		scanner, err = initializeArchiveScanner(ctx, c.Input, cacheClient, cacheClient, c.Timeout)
 		if err != nil {
 			return xerrors.Errorf("unable to initialize the archive scanner: %w", err)
 		}
+	} else if sftp.IsTarget(c.ImageName) {
+		// scan the filesystem of a remote host
+		if c.OfflineScan {
+			return xerrors.Errorf("--offline-scan doesn't support the remote host %s", c.ImageName)
+		}
+		scanner, cleanup, err = initializeSFTPScanner(c.ImageName, cacheClient, cacheClient, c.Timeout)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the SFTP scanner: %w", err)
+		}
 	} else {
-		// scan an image in Docker Engine or Docker Registry
-		scanner, cleanup, err = initializeDockerScanner(ctx, c.ImageName, cacheClient, cacheClient, c.Timeout)
+		// scan an image in Docker Engine, containerd, Podman or Docker Registry
+		imageName := c.ImageName
+		if att != nil {
+			// the attested digest, which the tag may no longer point to by the time the image is pulled
+			imageName = att.ref.String()
+		}
+		progress.Start(progress.Pull, c.ImageName)
+		opt := c.DaemonOption()
+		runtime := daemon.Resolve(ctx, imageName, opt)
+		if c.OfflineScan {
+			// Docker would pull the missing image from its registry
+			if err = checkLocalImage(ctx, imageName, runtime, opt); err != nil {
+				return err
+			}
+		}
+		switch runtime {
+		case daemon.Containerd:
+			scanner, cleanup, err = initializeContainerdScanner(ctx, imageName, opt.Containerd, cacheClient, cacheClient)
+		case daemon.Podman:
+			scanner, cleanup, err = initializePodmanScanner(ctx, imageName, opt.Podman, cacheClient, cacheClient)
+		default:
+			scanner, cleanup, err = initializeDockerScanner(ctx, imageName, cacheClient, cacheClient, c.Timeout)
+			dockerImage = true
+		}
 		if err != nil {
-			return xerrors.Errorf("unable to initialize the docker scanner: %w", err)
+			return xerrors.Errorf("unable to initialize the %s scanner: %w", runtime, types.ExplainTLSError(err))
 		}
+		progress.Finish(progress.Pull, c.ImageName)
 	}
 	defer cleanup()
 
-	scanOptions := types.ScanOptions{
-		VulnType:            c.VulnType,
-		ScanRemovedPackages: c.ScanRemovedPkgs,
+	if !c.NoCache && len(c.AdvisoryDirs) == 0 {
+		// a rescan with the same DB only analyzes the missing layers, the supplementary advisories may have changed
+		resultCache, err := newResultCache(c)
+		if err != nil {
+			log.Logger.Warnf("The results aren't cached: %s", err)
+		} else {
+			scanner = scanner.WithResultCache(resultCache)
+		}
 	}
-	log.Logger.Debugf("Vulnerability type:  %s", scanOptions.VulnType)
 
-	results, err := scanner.ScanImage(scanOptions)
+	scanOptions, err := newScanOptions(ctx, c)
 	if err != nil {
+		return err
+	}
+
+	var results report.Results
+	start := time.Now()
+	if c.Filesystem {
+		imageReport.Image.Name = c.ImageName
+		if results, err = scanner.ScanFilesystem(ctx, c.ImageName, scanOptions); err != nil && !partialResults(c, err) {
+			pushMetrics(c, start, nil, err)
+			return xerrors.Errorf("error in filesystem scan: %w", err)
+		}
+	} else if c.Repository {
+		imageReport.Image.Name = c.ImageName
+		branch := c.Branch
+		if c.Tag != "" {
+			branch = c.Tag
+		}
+		if results, err = scanner.ScanRepository(ctx, c.ImageName, branch, c.Commit, scanOptions); err != nil && !partialResults(c, err) {
+			pushMetrics(c, start, nil, err)
+			return xerrors.Errorf("error in repository scan: %w", err)
+		}
+	} else if c.Rootfs {
+		imageReport.Image.Name = c.ImageName
+		if results, err = scanner.ScanRootfs(ctx, c.ImageName, scanOptions); err != nil && !partialResults(c, err) {
+			pushMetrics(c, start, nil, err)
+			return xerrors.Errorf("error in root filesystem scan: %w", err)
+		}
+	} else if imageReport, err = scanner.ScanImageReport(ctx, scanOptions); err != nil && !partialResults(c, err) {
+		pushMetrics(c, start, nil, err)
 		return xerrors.Errorf("error in image scan: %w", err)
+	} else {
+		results = imageReport.Results
 	}
+	imageRef := imageReport.Image
+	finished := time.Now()
 
 	vulnClient := initializeVulnerabilityClient()
 	for i := range results {
-		vulnClient.FillInfo(results[i].Vulnerabilities, results[i].Type)
 		results[i].Vulnerabilities = vulnClient.Filter(results[i].Vulnerabilities,
 			c.Severities, c.IgnoreUnfixed, c.IgnoreFile)
 	}
+	pushMetrics(c, start, results, nil)
 
-	template := c.Template
+	if outputPlugin != nil {
+		writer := plugin.Writer{Plugin: *outputPlugin, Output: c.Output}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if c.ArtifactMetadata {
+		writer := report.JSONWriter{
+			Output:       c.Output,
+			ArtifactName: artifactName(c, imageRef),
+			Metadata:     artifactMetadata(ctx, c, imageReport, dockerImage),
+		}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if c.Format == "sqlite" {
+		writer := sqlite.Writer{Path: c.OutputPath, Image: imageRef.Name, ImageID: imageRef.ID}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if c.BaseImage != "" && c.Format == "table" && c.Report != report.ReportSummary {
+		baseLayers, err := baseImageLayers(ctx, c.BaseImage, c.Timeout)
+		if err != nil {
+			return xerrors.Errorf("unable to get the layers of the base image: %w", err)
+		}
+		writer := report.BaseImageWriter{
+			TableWriter: report.TableWriter{Output: c.Output, Light: c.Light, Color: report.IsColorEnabled(c.Output)},
+			BaseLayers:  baseLayers,
+		}
+		if err = writer.Write(results); err != nil {
+			return xerrors.Errorf("unable to write results: %w", err)
+		}
+	} else if err = report.WriteResults(results, report.Option{
+		Format:         c.Format,
+		Output:         c.Output,
+		OutputTemplate: c.Template,
+		Light:          c.Light,
+		TopN:           c.TopN,
+		DependencyTree: c.DependencyTree,
+		Report:         c.Report,
+		ArtifactName:   artifactName(c, imageRef),
+		SCCSource:      c.SCCSource,
+		KafkaBrokers:   c.KafkaBrokers,
+		KafkaTopic:     c.KafkaTopic,
+		ImageID:        imageRef.ID,
+	}); err != nil {
+		return xerrors.Errorf("unable to write results: %w", err)
+	}
+
+	if c.RecordHistory {
+		if err = recordHistory(ctx, c, imageRef, dockerImage, start, results); err != nil {
+			return err
+		}
+	}
 
-	if strings.HasPrefix(c.Template, "@") {
-		buf, err := ioutil.ReadFile(strings.TrimPrefix(c.Template, "@"))
+	if c.Compliance != "" && c.Format == "table" {
+		controls, err := report.LoadControls(c.Compliance)
 		if err != nil {
-			return xerrors.Errorf("Error retrieving template from path: %w", err)
+			return xerrors.Errorf("unable to load the compliance mapping: %w", err)
+		}
+		if err = (report.ComplianceWriter{Output: c.Output, Controls: controls}).Write(results); err != nil {
+			return xerrors.Errorf("unable to write the compliance summary: %w", err)
 		}
-		template = string(buf)
 	}
 
-	if err = report.WriteResults(c.Format, c.Output, results, template, c.Light); err != nil {
-		return xerrors.Errorf("unable to write results: %w", err)
+	if c.NotifyWebhook != "" {
+		sink, err := report.NewSink(c.NotifyFormat, c.NotifyWebhook, c.NotifySecret)
+		if err != nil {
+			return xerrors.Errorf("unable to initialize the notification: %w", err)
+		}
+		if err = sink.Write(results); err != nil {
+			return xerrors.Errorf("unable to notify %s: %w", c.NotifyWebhook, err)
+		}
 	}
 
-	if c.ExitCode != 0 {
-		for _, result := range results {
-			if len(result.Vulnerabilities) > 0 {
-				os.Exit(c.ExitCode)
-			}
+	if c.Attest {
+		if err = att.attest(c, results, start, finished); err != nil {
+			return err
+		}
+	}
+
+	// flushed before the exit codes, which skip the deferred calls
+	if err = c.CloseOutput(); err != nil {
+		return err
+	}
+
+	if code := report.ExitCodeGate(c.ExitOnSeverities).ExitCode(results, c.ExitCode); code != 0 {
+		os.Exit(code)
+	}
+	if c.ExitOnEOL != 0 && results.HasEOL() {
+		os.Exit(c.ExitOnEOL)
+	}
+	return nil
+}
+
+// initialize initializes the logger, the options, the cache and the vulnerability DB of the scans.
+// The cache is nil after --reset, --clear-cache and --download-db-only, which don't conduct a scan.
+func initialize(ctx context.Context, c *config.Config) (fcache.Cache, error) {
+	if err := log.InitLogger(c.Debug, c.Quiet); err != nil {
+		l.Fatal(err)
+	}
+
+	// initialize config
+	if err := c.Init(); err != nil {
+		return nil, xerrors.Errorf("failed to initialize options: %w", err)
+	}
+	if err := progress.Init(c.Progress, c.Quiet || c.NoProgress); err != nil {
+		return nil, xerrors.Errorf("failed to initialize the progress: %w", err)
+	}
+	if err := transport.Configure(transport.Options{CACert: c.CustomCACert, InsecureRegistries: c.InsecureRegistries}); err != nil {
+		return nil, xerrors.Errorf("failed to configure the outbound connections: %w", err)
+	}
+
+	// configure cache dir
+	utils.SetCacheDir(c.CacheDir)
+	cacheClient, err := cache.NewCache(c.CacheBackend, c.CacheDir, cache.RedisOptions{
+		TTL:    c.CacheTTL,
+		TLS:    c.RedisTLS,
+		CACert: c.RedisCACert,
+		Cert:   c.RedisCert,
+		Key:    c.RedisKey,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to initialize the cache: %w", err)
+	}
+
+	cacheOperation := operation.NewCache(cacheClient)
+	log.Logger.Debugf("cache dir:  %s", utils.CacheDir())
+
+	if c.Reset {
+		return nil, cacheOperation.Reset()
+	}
+	if c.ClearCache {
+		if err = cacheOperation.ClearImages(); err != nil {
+			return nil, err
+		}
+		return nil, cache.ClearResults(c.CacheDir)
+	}
+
+	if c.OfflineScan {
+		// fails before the scan, the DB can't be downloaded
+		if err = dbFile.CheckLocalDB(c.CacheDir); err != nil {
+			return nil, xerrors.Errorf("--offline-scan requires the vulnerability DB in %s, e.g. loaded with trivy db import: %w",
+				c.CacheDir, err)
 		}
+		log.Logger.Info("Scanning offline, without any network access")
+	}
+
+	// download the database file
+	// the download bar would be mixed with the JSON lines of the progress
+	noProgress := c.Quiet || c.NoProgress || c.Progress == progress.FormatJSON
+	if !c.SkipUpdate {
+		progress.Start(progress.DBUpdate, "")
+	}
+	if err = operation.DownloadDB(ctx, c.AppVersion, c.CacheDir, c.DBRepositories, noProgress, c.Light, c.SkipUpdate); err != nil {
+		return nil, err
+	}
+	if !c.SkipUpdate {
+		progress.Finish(progress.DBUpdate, "")
+	}
+
+	if c.DownloadDBOnly {
+		return nil, nil
+	}
+
+	if err = operation.CheckDBAge(c.CacheDir, c.MaxDBAge, c.StaleDBGrace); err != nil {
+		return nil, err
+	}
+
+	if err = db.Init(c.CacheDir); err != nil {
+		return nil, xerrors.Errorf("error in vulnerability DB initialize: %w", err)
+	}
+
+	if err = advisory.RegisterOSVDirs(c.AdvisoryDirs); err != nil {
+		return nil, xerrors.Errorf("invalid --advisory-dir: %w", err)
+	}
+	if c.GoBinaries {
+		gobinary.Register(c.GoBinaryDirs)
+	}
+	if c.JavaArchives {
+		jar.Register(nil)
+	}
+	// the version and the installed updates of Windows images are detected from their servicing packages
+	windows.Register()
+	if len(c.CustomAnalyzerDirs) > 0 {
+		if err = customanalyzer.Register(c.CustomAnalyzerDirs, c.CustomAnalyzerRuntime); err != nil {
+			return nil, xerrors.Errorf("invalid --custom-analyzer: %w", err)
+		}
+	}
+	return cacheClient, nil
+}
+
+// recordHistory records the scan with the vulnerabilities of the results in the history queried by trivy history
+func recordHistory(ctx context.Context, c config.Config, imageRef ftypes.ImageReference, dockerImage bool,
+	scannedAt time.Time, results report.Results) error {
+	path := c.HistoryDB
+	if path == "" {
+		path = sqlite.HistoryPath(c.CacheDir)
+	}
+	writer := sqlite.Writer{
+		Path:       path,
+		Image:      imageRef.Name,
+		ImageID:    imageRef.ID,
+		RepoDigest: repoDigest(ctx, c, dockerImage),
+		ScannedAt:  scannedAt,
 	}
+	if err := writer.Write(results); err != nil {
+		return xerrors.Errorf("unable to record the history of %s: %w", imageRef.Name, err)
+	}
+	log.Logger.Debugf("Recorded the scan of %s in %s", imageRef.Name, path)
 	return nil
 }
+
+// closeOutput closes the output file of the results, failing the run when they can't be flushed
+func closeOutput(c config.Config, err error) error {
+	if cerr := c.CloseOutput(); cerr != nil && err == nil {
+		return cerr
+	}
+	return err
+}
+
+// runtimeNames are the names of the runtimes in the messages
+var runtimeNames = map[daemon.Runtime]string{
+	daemon.Docker:     "Docker Engine",
+	daemon.Containerd: "containerd",
+	daemon.Podman:     "Podman",
+}
+
+// checkLocalImage fails when the runtime resolved from opt doesn't have the image, which --offline-scan doesn't pull
+func checkLocalImage(ctx context.Context, imageName string, runtime daemon.Runtime, opt daemon.Option) error {
+	ok, err := daemon.HasImage(ctx, imageName, runtime, opt)
+	if err != nil {
+		return xerrors.Errorf("--offline-scan only scans the local images: %w", err)
+	} else if ok {
+		return nil
+	}
+	where := runtimeNames[runtime]
+	if opt.Runtime == daemon.Auto {
+		// none of them has it, see daemon.Resolve
+		where = "Docker Engine, containerd or Podman"
+	}
+	return xerrors.Errorf("--offline-scan doesn't pull %s, which isn't in %s: load it first or scan its tarball with --input",
+		imageName, where)
+}
+
+// newResultCache returns the cache of the results of the DB in the cache directory, identified by its version,
+// its type and its update time
+func newResultCache(c config.Config) (cache.ResultCache, error) {
+	metadata, err := dbFile.NewMetadata(afero.NewOsFs(), c.CacheDir).Get()
+	if err != nil {
+		return cache.ResultCache{}, xerrors.Errorf("unable to read the DB metadata: %w", err)
+	}
+	dbVersion := fmt.Sprintf("%d %d %s", metadata.Version, metadata.Type, metadata.UpdatedAt.UTC().Format(time.RFC3339Nano))
+	return cache.NewResultCache(c.CacheDir, dbVersion), nil
+}
+
+// newScanOptions returns the options of the scans of the config, with the EPSS scores and the KEV catalog
+// of --exploit-data
+func newScanOptions(ctx context.Context, c config.Config) (types.ScanOptions, error) {
+	scanOptions := types.ScanOptions{
+		VulnType:            c.VulnType,
+		ScanRemovedPackages: c.ScanRemovedPkgs,
+		IgnoreFile:          c.IgnoreFile,
+		ShowSuppressed:      c.ShowSuppressed,
+		VEXFile:             c.VEXFile,
+		IgnorePolicy:        c.IgnorePolicy,
+		SeveritySource:      c.SeveritySource,
+		SkipDBUpdate:        c.SkipUpdate,
+		SecurityChecks:      c.SecurityChecks,
+		SecretConfig:        c.SecretConfig,
+		ConfigPolicies:      c.ConfigPolicies,
+		ForbiddenLicenses:   c.ForbiddenLicenses,
+		Parallel:            c.Parallel,
+		DependencyTree:      c.DependencyTree,
+		PartialResults:      c.PartialResults,
+		SeparateDeferred:    c.SeparateDeferred,
+		EOLSeverity:         c.EOLSeverity,
+		SkipDirs:            c.SkipDirs,
+		SkipFiles:           c.SkipFiles,
+		FilePatterns:        c.FilePatterns,
+		GitToken:            c.GitToken,
+		// the BOM lists the packages without vulnerabilities too
+		ListAllPackages: c.ListAllPkgs || strings.HasPrefix(c.Format, "cyclonedx") || strings.HasPrefix(c.Format, "spdx"),
+	}
+	log.Logger.Debugf("Vulnerability type:  %s", scanOptions.VulnType)
+
+	if c.ExploitData {
+		var err error
+		feeds := feed.NewClient(c.CacheDir, feed.DefaultTTL, clock.RealClock{})
+		if scanOptions.EPSSScores, err = feeds.EPSSScores(ctx, c.SkipUpdate); err != nil {
+			return types.ScanOptions{}, xerrors.Errorf("unable to get the EPSS scores: %w", err)
+		}
+		if scanOptions.KnownExploited, err = feeds.KnownExploited(ctx, c.SkipUpdate); err != nil {
+			return types.ScanOptions{}, xerrors.Errorf("unable to get the KEV catalog: %w", err)
+		}
+		scanOptions.OnlyEPSSAbove = c.EPSSAbove
+		scanOptions.OnlyKnownExploited = c.KEVOnly
+	}
+	return scanOptions, nil
+}
+
+// partialResults reports whether the results of the partial scan are written anyway with --partial-results
+func partialResults(c config.Config, err error) bool {
+	var partialErr *scanner.PartialScanError
+	if !c.PartialResults || !xerrors.As(err, &partialErr) {
+		return false
+	}
+	log.Logger.Warnf("The results are partial: %s", partialErr)
+	return true
+}
+
+// withTimeout returns the context of the run, done after --timeout
+func withTimeout(c config.Config) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.Timeout)
+}
+
+// explainTimeout tells the options of the run that timed out
+func explainTimeout(ctx context.Context, c config.Config, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return xerrors.Errorf("timed out after %s, see --timeout and --partial-results: %w", c.Timeout, err)
+}
+
+// pushMetrics pushes the metrics of the scan to --metrics-pushgateway.
+// A failing push is only logged not to fail the scan.
+func pushMetrics(c config.Config, start time.Time, results report.Results, scanErr error) {
+	if c.MetricsPushgateway == "" {
+		return
+	}
+	metrics.ObserveScan(start, results, scanErr)
+	reg, err := metrics.NewRegistry(dbFile.NewMetadata(afero.NewOsFs(), c.CacheDir).Get, clock.RealClock{}, false)
+	if err == nil {
+		err = metrics.Push(c.MetricsPushgateway, reg)
+	}
+	if err != nil {
+		log.Logger.Warnf("Unable to push the metrics: %s", err)
+	}
+}
+
+// baseImageLayers returns the diff IDs of the layers in the base image
+func baseImageLayers(ctx context.Context, imageName string, timeout time.Duration) ([]string, error) {
+	dockerOption, err := registry.GetDockerOption(ctx, imageName, timeout)
+	if err != nil {
+		return nil, err
+	}
+	ext, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return ext.LayerIDs()
+}
@@ -1,7 +1,8 @@
 // Code generated by Wire. DO NOT EDIT.
 
 //go:generate wire
-//+build !wireinject
+//go:build !wireinject
+// +build !wireinject
 
 package standalone
 
@@ -9,10 +10,18 @@ import (
 	"context"
 	"github.com/aquasecurity/fanal/analyzer"
 	"github.com/aquasecurity/fanal/cache"
-	"github.com/aquasecurity/fanal/extractor/docker"
 	"github.com/aquasecurity/trivy-db/pkg/db"
 	"github.com/aquasecurity/trivy/pkg/detector/library"
 	"github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/extractor/archive"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/fs"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/extractor/sftp"
+	"github.com/aquasecurity/trivy/pkg/registry"
+	"github.com/aquasecurity/trivy/pkg/sbom"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/scanner/local"
 	"github.com/aquasecurity/trivy/pkg/types"
@@ -23,45 +32,174 @@ import (
 // Injectors from inject.go:
 
 func initializeDockerScanner(ctx context.Context, imageName string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache, timeout time.Duration) (scanner.Scanner, func(), error) {
-	applier := analyzer.NewApplier(localImageCache)
+	applier := local.NewApplier(localImageCache)
 	detector := ospkg.Detector{}
 	driverFactory := library.DriverFactory{}
 	libraryDetector := library.NewDetector(driverFactory)
-	localScanner := local.NewScanner(applier, detector, libraryDetector)
-	dockerOption, err := types.GetDockerOption(timeout)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	dockerOption, err := registry.GetDockerOption(ctx, imageName, timeout)
 	if err != nil {
 		return scanner.Scanner{}, nil, err
 	}
-	extractor, cleanup, err := docker.NewDockerExtractor(ctx, imageName, dockerOption)
+	extractor, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
 	if err != nil {
 		return scanner.Scanner{}, nil, err
 	}
-	config := analyzer.New(extractor, layerCache)
-	scannerScanner := scanner.NewScanner(localScanner, config)
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
 	return scannerScanner, func() {
 		cleanup()
 	}, nil
 }
 
 func initializeArchiveScanner(ctx context.Context, filePath string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache, timeout time.Duration) (scanner.Scanner, error) {
-	applier := analyzer.NewApplier(localImageCache)
+	applier := local.NewApplier(localImageCache)
 	detector := ospkg.Detector{}
 	driverFactory := library.DriverFactory{}
 	libraryDetector := library.NewDetector(driverFactory)
-	localScanner := local.NewScanner(applier, detector, libraryDetector)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
 	dockerOption, err := types.GetDockerOption(timeout)
 	if err != nil {
 		return scanner.Scanner{}, err
 	}
-	extractor, err := docker.NewDockerArchiveExtractor(ctx, filePath, dockerOption)
+	extractor, err := archive.NewExtractor(ctx, filePath, dockerOption)
+	if err != nil {
+		return scanner.Scanner{}, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, nil
+}
+
+func initializeOCIScanner(filePath string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (scanner.Scanner, func(), error) {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	extractor, cleanup, err := oci.NewExtractor(filePath)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, func() {
+		cleanup()
+	}, nil
+}
+
+func initializeContainerdScanner(ctx context.Context, imageName string, opt containerd.Option, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (scanner.Scanner, func(), error) {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	extractor, cleanup, err := containerd.NewExtractor(ctx, imageName, opt)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, func() {
+		cleanup()
+	}, nil
+}
+
+func initializePodmanScanner(ctx context.Context, imageName string, opt podman.Option, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (scanner.Scanner, func(), error) {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	extractor, cleanup, err := podman.NewExtractor(ctx, imageName, opt)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, func() {
+		cleanup()
+	}, nil
+}
+
+func initializeSFTPScanner(target string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache, timeout time.Duration) (scanner.Scanner, func(), error) {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	extractor, cleanup, err := sftp.NewExtractor(target, timeout)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, func() {
+		cleanup()
+	}, nil
+}
+
+func initializeFilesystemScanner(root string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) (scanner.Scanner, error) {
+	mergingApplier := local.NewMergingApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(mergingApplier, detector, libraryDetector, client)
+	extractor, err := fs.NewExtractor(root)
 	if err != nil {
 		return scanner.Scanner{}, err
 	}
-	config := analyzer.New(extractor, layerCache)
-	scannerScanner := scanner.NewScanner(localScanner, config)
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
 	return scannerScanner, nil
 }
 
+func initializeSBOMScanner(filePath string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache) scanner.Scanner {
+	mergingApplier := local.NewMergingApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(mergingApplier, detector, libraryDetector, client)
+	sbomAnalyzer := sbom.NewAnalyzer(filePath, layerCache)
+	scannerScanner := scanner.NewScanner(localScanner, sbomAnalyzer)
+	return scannerScanner
+}
+
+func initializeBatchScanner(factory scanner.AnalyzerFactory, localImageCache cache.LocalImageCache) scanner.BatchScanner {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	batchScanner := scanner.NewBatchScanner(localScanner, factory)
+	return batchScanner
+}
+
 func initializeVulnerabilityClient() vulnerability.Client {
 	config := db.Config{}
 	client := vulnerability.NewClient(config)
@@ -0,0 +1,213 @@
+package advisory
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knqyf263/go-version"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// The ecosystems of the packages, as named by OSV
+const (
+	EcosystemNpm       = "npm"
+	EcosystemPyPI      = "PyPI"
+	EcosystemRubyGems  = "RubyGems"
+	EcosystemCratesIO  = "crates.io"
+	EcosystemPackagist = "Packagist"
+	EcosystemGo        = "Go"
+	EcosystemMaven     = "Maven"
+)
+
+// Source is a source of supplementary advisories matched alongside the DB, e.g. an internal feed of the advisories
+// of the company packages. The sources are registered with Register.
+type Source interface {
+	// Name identifies the source, it is the DataSource of the vulnerabilities detected with its advisories
+	Name() string
+	// Get returns the advisories of the package of the ecosystem, e.g. EcosystemNpm, with the name as detected,
+	// lower case for the case-insensitive ecosystems
+	Get(ecosystem, pkgName string) ([]Advisory, error)
+}
+
+// Advisory is an advisory of a package of a supplementary source
+type Advisory struct {
+	VulnerabilityID string
+	// Aliases are the IDs of the same vulnerability in the other sources, e.g. the CVE ID
+	Aliases []string
+	// Ranges are the ranges of the affected versions, and Versions the affected versions listed one by one
+	Ranges   []Range
+	Versions []string
+	// CVSSVector is the CVSS v3 vector of the vulnerability, if any
+	CVSSVector string
+	// LastModified is the date the advisory was last modified, if known
+	LastModified *time.Time
+
+	// Title, Description, Severity and References are used when the DB doesn't know the vulnerability
+	dbTypes.Vulnerability
+}
+
+// Range is a range of the affected versions, from Introduced, all the versions when it is empty or "0", to Fixed
+// excluded or LastAffected included, or all the later versions without any
+type Range struct {
+	Introduced   string
+	Fixed        string
+	LastAffected string
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   []Source
+)
+
+// Register registers the source of supplementary advisories, replacing the one of the same name.
+// The sources are matched in the order they are registered.
+func Register(source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	for i, s := range sources {
+		if s.Name() == source.Name() {
+			sources[i] = source
+			return
+		}
+	}
+	sources = append(sources, source)
+}
+
+// Deregister deregisters the source of the name
+func Deregister(name string) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	for i, s := range sources {
+		if s.Name() == name {
+			sources = append(sources[:i], sources[i+1:]...)
+			return
+		}
+	}
+}
+
+// Sources returns the registered sources, in order
+func Sources() []Source {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	return append([]Source{}, sources...)
+}
+
+// Detect returns the vulnerabilities of the version of the package in the advisories of the registered sources.
+// The findings of the DB, in detected, take precedence: an advisory whose ID or one of its aliases was already
+// detected is skipped, and so are those of the sources registered later than the first one having it.
+func Detect(ecosystem, pkgName string, pkgVer *version.Version, detected []types.DetectedVulnerability) (
+	[]types.DetectedVulnerability, error) {
+	registered := Sources()
+	if ecosystem == "" || len(registered) == 0 {
+		return nil, nil
+	}
+
+	ids := map[string]bool{}
+	for _, vuln := range detected {
+		ids[vuln.VulnerabilityID] = true
+	}
+
+	var vulns []types.DetectedVulnerability
+	for _, source := range registered {
+		advisories, err := source.Get(ecosystem, pkgName)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get the advisories of %s: %w", source.Name(), err)
+		}
+		for _, adv := range advisories {
+			if ids[adv.VulnerabilityID] || anyDetected(ids, adv.Aliases) {
+				log.Logger.Debugf("%s of %s is already detected for %s", adv.VulnerabilityID, source.Name(), pkgName)
+				continue
+			}
+			fixedVersion, ok := adv.affects(pkgVer)
+			if !ok {
+				continue
+			}
+			ids[adv.VulnerabilityID] = true
+			for _, alias := range adv.Aliases {
+				ids[alias] = true
+			}
+			vulns = append(vulns, adv.detected(source.Name(), pkgName, pkgVer, fixedVersion))
+		}
+	}
+	return vulns, nil
+}
+
+func anyDetected(ids map[string]bool, aliases []string) bool {
+	for _, alias := range aliases {
+		if ids[alias] {
+			return true
+		}
+	}
+	return false
+}
+
+// affects reports whether the version is affected, with the fixed version of its range if any
+func (a Advisory) affects(v *version.Version) (string, bool) {
+	for _, s := range a.Versions {
+		if affected, err := version.NewVersion(s); err == nil && affected.Equal(v) {
+			return "", true
+		}
+	}
+	for _, r := range a.Ranges {
+		if r.contains(v) {
+			return r.Fixed, true
+		}
+	}
+	return "", false
+}
+
+// contains reports whether the version is in the range, the bounds that can't be parsed matching no version
+func (r Range) contains(v *version.Version) bool {
+	if r.Introduced != "" && r.Introduced != "0" {
+		introduced, err := version.NewVersion(r.Introduced)
+		if err != nil || v.LessThan(introduced) {
+			return false
+		}
+	}
+	if r.Fixed != "" {
+		fixed, err := version.NewVersion(r.Fixed)
+		if err != nil || !v.LessThan(fixed) {
+			return false
+		}
+	}
+	if r.LastAffected != "" {
+		lastAffected, err := version.NewVersion(r.LastAffected)
+		if err != nil || v.GreaterThan(lastAffected) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Advisory) detected(sourceName, pkgName string, pkgVer *version.Version, fixedVersion string) types.DetectedVulnerability {
+	vuln := types.DetectedVulnerability{
+		VulnerabilityID:  a.VulnerabilityID,
+		PkgName:          pkgName,
+		InstalledVersion: pkgVer.String(),
+		FixedVersion:     fixedVersion,
+		DataSource:       sourceName,
+		LastModified:     a.LastModified,
+		Vulnerability:    a.Vulnerability,
+	}
+	if vuln.Severity == "" {
+		vuln.Severity = dbTypes.SeverityUnknown.String()
+	}
+	if a.CVSSVector != "" {
+		vuln.CVSS = types.VendorCVSS{sourceName: {V3Vector: a.CVSSVector, Severity: vuln.Severity}}
+	}
+	return vuln
+}
+
+// normalizeName returns the name of the package as looked up, lower case for the case-insensitive ecosystems
+func normalizeName(ecosystem, pkgName string) string {
+	switch ecosystem {
+	case EcosystemPyPI, EcosystemPackagist:
+		return strings.ToLower(pkgName)
+	}
+	return pkgName
+}
@@ -0,0 +1,177 @@
+package advisory
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/knqyf263/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	if err := log.InitLogger(false, true); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(m.Run())
+}
+
+type fakeSource struct {
+	name       string
+	advisories map[string][]Advisory
+	err        error
+}
+
+func (s fakeSource) Name() string {
+	return s.name
+}
+
+func (s fakeSource) Get(ecosystem, pkgName string) ([]Advisory, error) {
+	return s.advisories[ecosystem+"/"+pkgName], s.err
+}
+
+func TestDetect(t *testing.T) {
+	modified := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+	internal := fakeSource{name: "internal", advisories: map[string][]Advisory{
+		"npm/lodash": {
+			{
+				VulnerabilityID: "ACME-2020-0001",
+				Aliases:         []string{"CVE-2020-8203"},
+				Ranges:          []Range{{Introduced: "0", Fixed: "4.17.19"}},
+			},
+			{
+				VulnerabilityID: "ACME-2020-0002",
+				Ranges:          []Range{{Introduced: "4.0.0", LastAffected: "4.17.15"}},
+				CVSSVector:      "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+				LastModified:    &modified,
+				Vulnerability:   dbTypes.Vulnerability{Title: "internal", Severity: "HIGH"},
+			},
+			{
+				VulnerabilityID: "ACME-2020-0003",
+				Versions:        []string{"4.17.12"},
+			},
+			{
+				VulnerabilityID: "ACME-2020-0004",
+				Ranges:          []Range{{Introduced: "5.0.0"}},
+			},
+		},
+	}}
+	mirror := fakeSource{name: "mirror", advisories: map[string][]Advisory{
+		"npm/lodash": {
+			{VulnerabilityID: "MIRROR-0001", Aliases: []string{"ACME-2020-0002"}, Ranges: []Range{{Introduced: "0"}}},
+			{VulnerabilityID: "MIRROR-0002", Ranges: []Range{{Introduced: "0"}}},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		sources  []Source
+		version  string
+		detected []types.DetectedVulnerability
+		want     []types.DetectedVulnerability
+		wantErr  string
+	}{
+		{
+			name:    "no source",
+			version: "4.17.12",
+		},
+		{
+			name:     "the DB takes precedence, then the sources in order",
+			sources:  []Source{internal, mirror},
+			version:  "4.17.12",
+			detected: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-8203"}},
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "ACME-2020-0002",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.12",
+					DataSource:       "internal",
+					LastModified:     &modified,
+					CVSS: types.VendorCVSS{
+						"internal": {V3Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", Severity: "HIGH"},
+					},
+					Vulnerability: dbTypes.Vulnerability{Title: "internal", Severity: "HIGH"},
+				},
+				{
+					VulnerabilityID:  "ACME-2020-0003",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.12",
+					DataSource:       "internal",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "UNKNOWN"},
+				},
+				{
+					VulnerabilityID:  "MIRROR-0002",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.12",
+					DataSource:       "mirror",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "UNKNOWN"},
+				},
+			},
+		},
+		{
+			name:    "ranges",
+			sources: []Source{internal},
+			version: "4.17.18",
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "ACME-2020-0001",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.18",
+					FixedVersion:     "4.17.19",
+					DataSource:       "internal",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "UNKNOWN"},
+				},
+			},
+		},
+		{
+			name:    "sad: the source fails",
+			sources: []Source{fakeSource{name: "broken", err: xerrors.New("unavailable")}},
+			version: "4.17.12",
+			wantErr: "failed to get the advisories of broken: unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range tt.sources {
+				Register(s)
+				defer Deregister(s.Name())
+			}
+			v, err := version.NewVersion(tt.version)
+			require.NoError(t, err)
+
+			got, err := Detect(EcosystemNpm, "lodash", v, tt.detected)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer Deregister("internal")
+	defer Deregister("mirror")
+
+	Register(fakeSource{name: "internal"})
+	Register(fakeSource{name: "mirror"})
+	Register(fakeSource{name: "internal", err: xerrors.New("replaced")})
+
+	got := Sources()
+	require.Len(t, got, 2)
+	assert.Equal(t, "internal", got[0].Name())
+	_, err := got[0].Get(EcosystemNpm, "lodash")
+	assert.EqualError(t, err, "replaced")
+	assert.Equal(t, "mirror", got[1].Name())
+
+	Deregister("internal")
+	assert.Len(t, Sources(), 1)
+}
@@ -0,0 +1,205 @@
+package advisory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// osvAdvisory is the part of an advisory of the OSV format, https://ossf.github.io/osv-schema/, that is matched
+type osvAdvisory struct {
+	ID        string   `json:"id"`
+	Modified  string   `json:"modified"`
+	Withdrawn string   `json:"withdrawn"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions         []string         `json:"versions"`
+		DatabaseSpecific databaseSpecific `json:"database_specific"`
+	} `json:"affected"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific databaseSpecific `json:"database_specific"`
+}
+
+// databaseSpecific has the severity given by some databases, e.g. GitHub, as LOW, MODERATE, HIGH or CRITICAL
+type databaseSpecific struct {
+	Severity string `json:"severity"`
+}
+
+// OSVSource is a source of the advisories of the OSV format read from a local directory
+type OSVSource struct {
+	name       string
+	advisories map[string][]Advisory
+}
+
+// RegisterOSVDirs loads the advisories of the directories with LoadOSVDir and registers them in order
+func RegisterOSVDirs(dirs []string) error {
+	for _, dir := range dirs {
+		s, err := LoadOSVDir(dir)
+		if err != nil {
+			return err
+		}
+		log.Logger.Infof("Supplementary advisories: %s", s.Name())
+		Register(s)
+	}
+	return nil
+}
+
+// LoadOSVDir reads the advisories of the JSON files of the directory and its subdirectories, one advisory of the OSV
+// format per file. The source is named after the path of the directory, e.g. "osv:advisories", the withdrawn
+// advisories and the ranges other than SEMVER and ECOSYSTEM, e.g. GIT, are ignored.
+func LoadOSVDir(dir string) (*OSVSource, error) {
+	s := &OSVSource{name: "osv:" + filepath.Clean(dir), advisories: map[string][]Advisory{}}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		if err = s.load(path); err != nil {
+			return xerrors.Errorf("invalid OSV advisory %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("unable to load the advisories of %s: %w", dir, err)
+	}
+	return s, nil
+}
+
+func (s *OSVSource) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var osv osvAdvisory
+	if err = json.NewDecoder(f).Decode(&osv); err != nil {
+		return err
+	}
+	if osv.ID == "" {
+		return xerrors.New("missing id")
+	}
+	if osv.Withdrawn != "" {
+		log.Logger.Debugf("%s is withdrawn", osv.ID)
+		return nil
+	}
+
+	base := Advisory{
+		VulnerabilityID: osv.ID,
+		Aliases:         osv.Aliases,
+		Vulnerability: dbTypes.Vulnerability{
+			Title:       osv.Summary,
+			Description: osv.Details,
+		},
+	}
+	if osv.Modified != "" {
+		modified, err := time.Parse(time.RFC3339, osv.Modified)
+		if err != nil {
+			return xerrors.Errorf("invalid modified date: %w", err)
+		}
+		base.LastModified = &modified
+	}
+	for _, severity := range osv.Severity {
+		if severity.Type == "CVSS_V3" {
+			base.CVSSVector = severity.Score
+		}
+	}
+	for _, ref := range osv.References {
+		base.References = append(base.References, ref.URL)
+	}
+
+	for _, affected := range osv.Affected {
+		if affected.Package.Name == "" {
+			return xerrors.New("missing package name")
+		}
+		adv := base
+		adv.Severity = osvSeverity(affected.DatabaseSpecific.Severity, osv.DatabaseSpecific.Severity)
+		adv.Versions = affected.Versions
+		for _, r := range affected.Ranges {
+			if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+				continue
+			}
+			// the events are in order, each introduced version opening a range closed by the next fixed or last affected one
+			var current *Range
+			for _, event := range r.Events {
+				switch {
+				case event.Introduced != "":
+					if current != nil {
+						adv.Ranges = append(adv.Ranges, *current)
+					}
+					current = &Range{Introduced: event.Introduced}
+				case current != nil && (event.Fixed != "" || event.LastAffected != ""):
+					current.Fixed, current.LastAffected = event.Fixed, event.LastAffected
+					adv.Ranges = append(adv.Ranges, *current)
+					current = nil
+				}
+			}
+			if current != nil {
+				adv.Ranges = append(adv.Ranges, *current)
+			}
+		}
+		key := osvKey(affected.Package.Ecosystem, affected.Package.Name)
+		s.advisories[key] = append(s.advisories[key], adv)
+	}
+	return nil
+}
+
+// osvSeverity returns the severity of the affected package, or of the advisory, MODERATE being MEDIUM
+func osvSeverity(severities ...string) string {
+	for _, severity := range severities {
+		severity = strings.ToUpper(severity)
+		if severity == "MODERATE" {
+			severity = dbTypes.SeverityMedium.String()
+		}
+		if s, err := dbTypes.NewSeverity(severity); err == nil {
+			return s.String()
+		}
+	}
+	return ""
+}
+
+// osvKey is the key of the advisories of the package, without the release some ecosystems are suffixed with,
+// e.g. "Debian:11"
+func osvKey(ecosystem, pkgName string) string {
+	ecosystem = strings.SplitN(ecosystem, ":", 2)[0]
+	return ecosystem + "/" + normalizeName(ecosystem, pkgName)
+}
+
+// Name implements Source
+func (s *OSVSource) Name() string {
+	return s.name
+}
+
+// Get implements Source
+func (s *OSVSource) Get(ecosystem, pkgName string) ([]Advisory, error) {
+	return s.advisories[osvKey(ecosystem, pkgName)], nil
+}
@@ -0,0 +1,67 @@
+package advisory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+func TestLoadOSVDir(t *testing.T) {
+	s, err := LoadOSVDir("testdata/osv")
+	require.NoError(t, err)
+	assert.Equal(t, "osv:testdata/osv", s.Name())
+
+	modified := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	got, err := s.Get(EcosystemNpm, "@acme/lodash")
+	require.NoError(t, err)
+	assert.Equal(t, []Advisory{
+		{
+			VulnerabilityID: "ACME-2020-0001",
+			Aliases:         []string{"CVE-2020-8203"},
+			Ranges: []Range{
+				{Introduced: "0", Fixed: "4.17.19"},
+				{Introduced: "5.0.0", LastAffected: "5.0.2"},
+			},
+			Versions:     []string{"6.0.0-rc.1"},
+			CVSSVector:   "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:H",
+			LastModified: &modified,
+			Vulnerability: dbTypes.Vulnerability{
+				Title:       "Prototype pollution in the internal fork of lodash",
+				Description: "zipObjectDeep allows prototype pollution.",
+				Severity:    "HIGH",
+				References:  []string{"https://security.acme.example/ACME-2020-0001"},
+			},
+		},
+	}, got, "the withdrawn advisory and the GIT range are ignored")
+
+	got, err = s.Get(EcosystemPyPI, "acme-client")
+	require.NoError(t, err)
+	assert.Equal(t, []Advisory{
+		{
+			VulnerabilityID: "ACME-2020-0002",
+			Ranges:          []Range{{Introduced: "1.0", Fixed: "1.2"}},
+			Vulnerability: dbTypes.Vulnerability{
+				Title:    "Insecure default of Acme-Client",
+				Severity: "MEDIUM",
+			},
+		},
+	}, got, "the PyPI names are case-insensitive")
+
+	got, err = s.Get(EcosystemNpm, "lodash")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestLoadOSVDir_Invalid(t *testing.T) {
+	_, err := LoadOSVDir("testdata/invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid OSV advisory testdata/invalid/missing-id.json: missing id")
+
+	_, err = LoadOSVDir("testdata/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to load the advisories of testdata/missing")
+}
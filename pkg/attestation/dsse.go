@@ -0,0 +1,83 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/xerrors"
+)
+
+// PayloadType is the type of the in-toto statements in the envelopes
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope (https://github.com/secure-systems-lab/dsse) of a signed payload,
+// the layer of an attestation of cosign
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is an ASN.1 ECDSA signature of the SHA-256 of the pre-authentication encoding of the payload
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// Sign returns the envelope of the payload signed by the key
+func Sign(payload []byte, key *ecdsa.PrivateKey) (Envelope, error) {
+	digest := sha256.Sum256(pae(PayloadType, payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return Envelope{}, xerrors.Errorf("unable to sign: %w", err)
+	}
+	sig, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	if err != nil {
+		return Envelope{}, xerrors.Errorf("unable to encode the signature: %w", err)
+	}
+	return Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// Verify returns the payload of the envelope when one of its signatures is of the key
+func (e Envelope) Verify(key *ecdsa.PublicKey) ([]byte, error) {
+	if e.PayloadType != PayloadType {
+		return nil, xerrors.Errorf("unexpected payload type %s", e.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid payload: %w", err)
+	}
+	digest := sha256.Sum256(pae(e.PayloadType, payload))
+	for _, s := range e.Signatures {
+		b, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		var sig ecdsaSignature
+		if _, err = asn1.Unmarshal(b, &sig); err != nil || sig.R == nil || sig.S == nil {
+			continue
+		}
+		if ecdsa.Verify(key, digest[:], sig.R, sig.S) {
+			return payload, nil
+		}
+	}
+	return nil, xerrors.New("no signature of the key")
+}
+
+// pae is the pre-authentication encoding of DSSE, the signed bytes
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
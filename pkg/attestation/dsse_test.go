@@ -0,0 +1,27 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelope_Verify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	e, err := Sign([]byte(`{"_type": "https://in-toto.io/Statement/v0.1"}`), key)
+	require.NoError(t, err)
+
+	payload, err := e.Verify(&key.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, `{"_type": "https://in-toto.io/Statement/v0.1"}`, string(payload))
+
+	// the payload type is signed too
+	e.PayloadType = "application/json"
+	_, err = e.Verify(&key.PublicKey)
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// PasswordEnv is the password of the encrypted private keys, as for cosign
+	PasswordEnv = "COSIGN_PASSWORD"
+
+	// the PEM types of the private keys encrypted by cosign generate-key-pair, before and since cosign 2.0
+	cosignPrivateKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	sigstorePrivateKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+)
+
+// encryptedKey is the PKCS #8 private key encrypted by cosign with a key of the password derived by scrypt
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey reads the ECDSA private key of a cosign key pair, decrypted with the password of
+// COSIGN_PASSWORD, or an unencrypted PKCS #8 or SEC 1 key in PEM
+func LoadPrivateKey(filePath string) (*ecdsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, xerrors.Errorf("%s isn't a PEM file", filePath)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case cosignPrivateKeyType, sigstorePrivateKeyType:
+		der, err := decrypt(block.Bytes, []byte(os.Getenv(PasswordEnv)))
+		if err != nil {
+			return nil, xerrors.Errorf("unable to decrypt %s with %s: %w", filePath, PasswordEnv, err)
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid private key %s: %w", filePath, err)
+		}
+	case "PRIVATE KEY":
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, xerrors.Errorf("invalid private key %s: %w", filePath, err)
+		}
+	case "EC PRIVATE KEY":
+		if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			return nil, xerrors.Errorf("invalid private key %s: %w", filePath, err)
+		}
+	default:
+		return nil, xerrors.Errorf("%s isn't a private key but %s", filePath, block.Type)
+	}
+
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, xerrors.Errorf("%s isn't an ECDSA key, the only keys supported", filePath)
+	}
+	return ecKey, nil
+}
+
+// LoadPublicKey reads the ECDSA public key of a cosign key pair, e.g. cosign.pub, or the public key
+// of a private key of LoadPrivateKey
+func LoadPublicKey(filePath string) (*ecdsa.PublicKey, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, xerrors.Errorf("%s isn't a PEM file", filePath)
+	}
+	if block.Type != "PUBLIC KEY" {
+		key, err := LoadPrivateKey(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &key.PublicKey, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid public key %s: %w", filePath, err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, xerrors.Errorf("%s isn't an ECDSA key, the only keys supported", filePath)
+	}
+	return ecKey, nil
+}
+
+// decrypt opens the secretbox of the encrypted key
+func decrypt(b, password []byte) ([]byte, error) {
+	var k encryptedKey
+	if err := json.Unmarshal(b, &k); err != nil {
+		return nil, xerrors.Errorf("invalid encrypted key: %w", err)
+	}
+	if k.KDF.Name != "scrypt" || k.Cipher.Name != "nacl/secretbox" {
+		return nil, xerrors.Errorf("unsupported encryption %s with %s", k.Cipher.Name, k.KDF.Name)
+	}
+	if len(k.Cipher.Nonce) != 24 {
+		return nil, xerrors.New("invalid nonce")
+	}
+
+	derived, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid scrypt parameters: %w", err)
+	}
+	var key [32]byte
+	var nonce [24]byte
+	copy(key[:], derived)
+	copy(nonce[:], k.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, xerrors.New("wrong password")
+	}
+	return der, nil
+}
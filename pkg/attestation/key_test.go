@@ -0,0 +1,113 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// writeCosignKey writes the key encrypted with the password as cosign generate-key-pair does, with a cheap scrypt
+func writeCosignKey(t *testing.T, filePath string, key *ecdsa.PrivateKey, password string) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var k encryptedKey
+	k.KDF.Name, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P = "scrypt", 1024, 8, 1
+	k.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	k.Cipher.Name, k.Cipher.Nonce = "nacl/secretbox", []byte("0123456789abcdef01234567")
+	derived, err := scrypt.Key([]byte(password), k.KDF.Salt, 1024, 8, 1, 32)
+	require.NoError(t, err)
+	var secret [32]byte
+	var nonce [24]byte
+	copy(secret[:], derived)
+	copy(nonce[:], k.Cipher.Nonce)
+	k.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+
+	b, err := json.Marshal(k)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filePath, pem.EncodeToMemory(&pem.Block{Type: cosignPrivateKeyType, Bytes: b}), 0600))
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-attestation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cosignKey := filepath.Join(dir, "cosign.key")
+	writeCosignKey(t, cosignKey, key, "s3cret")
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	ecKey := filepath.Join(dir, "ec.pem")
+	require.NoError(t, ioutil.WriteFile(ecKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	der, err = x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKey := filepath.Join(dir, "cosign.pub")
+	require.NoError(t, ioutil.WriteFile(publicKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	tests := []struct {
+		name     string
+		filePath string
+		password string
+		wantErr  string
+	}{
+		{
+			name:     "cosign key",
+			filePath: cosignKey,
+			password: "s3cret",
+		},
+		{
+			name:     "wrong password",
+			filePath: cosignKey,
+			password: "wrong",
+			wantErr:  "wrong password",
+		},
+		{
+			name:     "unencrypted key",
+			filePath: ecKey,
+		},
+		{
+			name:     "public key",
+			filePath: publicKey,
+			wantErr:  "isn't a private key but PUBLIC KEY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(PasswordEnv, tt.password)
+			defer os.Unsetenv(PasswordEnv)
+
+			got, err := LoadPrivateKey(tt.filePath)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, key, got)
+		})
+	}
+
+	t.Run("public key", func(t *testing.T) {
+		for _, filePath := range []string{publicKey, ecKey} {
+			got, err := LoadPublicKey(filePath)
+			require.NoError(t, err)
+			assert.Equal(t, &key.PublicKey, got)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package attestation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// envelopeMediaType is the media type of the layers of the attestations
+	envelopeMediaType = "application/vnd.dsse.envelope.v1+json"
+	// the annotations of the layers cosign requires
+	signatureAnnotation     = "dev.cosignproject.cosign/signature"
+	predicateTypeAnnotation = "predicateType"
+)
+
+// Tag returns the tag of the attestations of the image digest, sha256-<hex>.att in its repository as cosign does
+func Tag(ref name.Digest) name.Tag {
+	return ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + ".att")
+}
+
+// Attach appends the envelope to the attestations of the image digest, keeping the former ones
+func Attach(ref name.Digest, e Envelope, options ...remote.Option) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return xerrors.Errorf("unable to encode the envelope: %w", err)
+	}
+
+	tag := Tag(ref)
+	img, err := attestations(tag, options...)
+	if err != nil {
+		return err
+	}
+	img, err = mutate.Append(img, mutate.Addendum{
+		Layer: newLayer(b),
+		Annotations: map[string]string{
+			signatureAnnotation:     "",
+			predicateTypeAnnotation: PredicateTypeVuln,
+		},
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to append the attestation: %w", err)
+	}
+	if err = remote.Write(tag, img, options...); err != nil {
+		return xerrors.Errorf("unable to push %s: %w", tag, err)
+	}
+	return nil
+}
+
+// Fetch returns the envelopes of the attestations of the image digest, none without attestation
+func Fetch(ref name.Digest, options ...remote.Option) ([]Envelope, error) {
+	tag := Tag(ref)
+	img, err := attestations(tag, options...)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the layers of %s: %w", tag, err)
+	}
+
+	var envelopes []Envelope
+	for _, layer := range layers {
+		if mt, err := layer.MediaType(); err != nil || mt != envelopeMediaType {
+			continue
+		}
+		// the envelope is the blob, not gzipped
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get the attestation of %s: %w", tag, err)
+		}
+		var e Envelope
+		err = json.NewDecoder(rc).Decode(&e)
+		rc.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("invalid attestation of %s: %w", tag, err)
+		}
+		envelopes = append(envelopes, e)
+	}
+	return envelopes, nil
+}
+
+// attestations returns the image of the attestations of the tag, an empty one when the tag doesn't exist
+func attestations(tag name.Tag, options ...remote.Option) (v1.Image, error) {
+	img, err := remote.Image(tag, options...)
+	if err == nil {
+		return img, nil
+	}
+	var terr *transport.Error
+	if xerrors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return mutate.MediaType(empty.Image, types.OCIManifestSchema1), nil
+	}
+	return nil, xerrors.Errorf("unable to get the attestations %s: %w", tag, err)
+}
+
+// layer is an uncompressed layer of bytes, the envelope of an attestation
+type layer struct {
+	b    []byte
+	hash v1.Hash
+}
+
+func newLayer(b []byte) *layer {
+	sum := sha256.Sum256(b)
+	return &layer{b: b, hash: v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(sum[:])}}
+}
+
+func (l *layer) Digest() (v1.Hash, error) { return l.hash, nil }
+
+func (l *layer) DiffID() (v1.Hash, error) { return l.hash, nil }
+
+func (l *layer) Compressed() (io.ReadCloser, error) { return l.Uncompressed() }
+
+func (l *layer) Uncompressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.b)), nil
+}
+
+func (l *layer) Size() (int64, error) { return int64(len(l.b)), nil }
+
+func (l *layer) MediaType() (types.MediaType, error) { return envelopeMediaType, nil }
@@ -0,0 +1,89 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestAttachAndVerify(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+
+	// push an image to attest
+	repo, err := name.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/app")
+	require.NoError(t, err)
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(repo.Tag("1.0"), img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	ref := repo.Digest(digest.String())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	envelopes, err := Fetch(ref)
+	require.NoError(t, err)
+	assert.Empty(t, envelopes, "no attestation yet")
+
+	started := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	attest := func(key *ecdsa.PrivateKey, ref name.Digest, finished time.Time) {
+		results := report.Results{{
+			Target:          "app (alpine 3.11.5)",
+			Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"}},
+		}}
+		payload, err := json.Marshal(NewStatement(ref, results, "0.6.0", "1", started, finished))
+		require.NoError(t, err)
+		e, err := Sign(payload, key)
+		require.NoError(t, err)
+		require.NoError(t, Attach(ref, e))
+	}
+	attest(key, ref, started.Add(time.Minute))
+	attest(key, ref, started.Add(time.Hour))
+	attest(other, ref, started.Add(2*time.Hour))
+	// a statement of another image in the attestations of the image isn't verified
+	attest(key, repo.Digest("sha256:"+strings.Repeat("a", 64)), started.Add(3*time.Hour))
+
+	envelopes, err = Fetch(ref)
+	require.NoError(t, err)
+	require.Len(t, envelopes, 3, "the attestations are appended")
+
+	got, err := VerifyLatest(envelopes, ref, &key.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, []Subject{{Name: repo.Name(), Digest: map[string]string{"sha256": digest.Hex}}}, got.Subject)
+	assert.Equal(t, started.Add(time.Hour), got.Predicate.Metadata.ScanFinishedOn)
+	assert.Equal(t, "pkg:github/aquasecurity/trivy@0.6.0", got.Predicate.Scanner.URI)
+	assert.Equal(t, "CVE-2020-1967", got.Predicate.Scanner.Result[0].Vulnerabilities[0].VulnerabilityID)
+
+	unknown, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = VerifyLatest(envelopes, ref, &unknown.PublicKey)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no vulnerability attestation of "+ref.String()+" verified by the key")
+}
+
+func TestTag(t *testing.T) {
+	ref, err := name.NewDigest("ghcr.io/org/app@sha256:" + strings.Repeat("0", 64))
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/org/app:sha256-"+strings.Repeat("0", 64)+".att", Tag(ref).String())
+}
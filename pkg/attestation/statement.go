@@ -0,0 +1,119 @@
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const (
+	// StatementType is the type of the in-toto statements
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	// PredicateTypeVuln is the vulnerability scan predicate of cosign, as of cosign attest --type vuln
+	PredicateTypeVuln = "https://cosign.sigstore.dev/attestation/vuln/v1"
+)
+
+// Statement is an in-toto statement of the scan of an image digest
+type Statement struct {
+	Type          string        `json:"_type"`
+	PredicateType string        `json:"predicateType"`
+	Subject       []Subject     `json:"subject"`
+	Predicate     VulnPredicate `json:"predicate"`
+}
+
+// Subject is the image of the statement, its repository and the digest of its manifest
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// VulnPredicate is the vulnerability scan predicate of cosign, the JSON results of Trivy as the result
+type VulnPredicate struct {
+	Invocation struct {
+		Parameters interface{} `json:"parameters"`
+		URI        string      `json:"uri"`
+		EventID    string      `json:"event_id"`
+		BuilderID  string      `json:"builder.id"`
+	} `json:"invocation"`
+	Scanner struct {
+		URI     string `json:"uri"`
+		Version string `json:"version"`
+		DB      struct {
+			URI     string `json:"uri"`
+			Version string `json:"version"`
+		} `json:"db"`
+		Result report.Results `json:"result"`
+	} `json:"scanner"`
+	Metadata struct {
+		ScanStartedOn  time.Time `json:"scanStartedOn"`
+		ScanFinishedOn time.Time `json:"scanFinishedOn"`
+	} `json:"metadata"`
+}
+
+// NewStatement returns the statement of the results of the scan of the image digest by the version of Trivy,
+// with the version of the vulnerability DB, e.g. "1" for v1
+func NewStatement(ref name.Digest, results report.Results, version, dbVersion string, started, finished time.Time) Statement {
+	var p VulnPredicate
+	p.Scanner.URI = "pkg:github/aquasecurity/trivy@" + version
+	p.Scanner.Version = version
+	p.Scanner.DB.URI = "https://github.com/aquasecurity/trivy-db"
+	p.Scanner.DB.Version = dbVersion
+	p.Scanner.Result = results
+	p.Metadata.ScanStartedOn = started.UTC()
+	p.Metadata.ScanFinishedOn = finished.UTC()
+	return Statement{
+		Type:          StatementType,
+		PredicateType: PredicateTypeVuln,
+		Subject:       []Subject{subject(ref)},
+		Predicate:     p,
+	}
+}
+
+// VerifyLatest returns the latest scan of the statements of the envelopes signed by the key whose subject
+// is the image digest, ignoring the other envelopes, e.g. the attestations of other predicates
+func VerifyLatest(envelopes []Envelope, ref name.Digest, key *ecdsa.PublicKey) (Statement, error) {
+	want := subject(ref)
+	var latest *Statement
+	for _, e := range envelopes {
+		payload, err := e.Verify(key)
+		if err != nil {
+			continue
+		}
+		var s Statement
+		if err = json.Unmarshal(payload, &s); err != nil || s.PredicateType != PredicateTypeVuln {
+			continue
+		}
+		if !s.hasSubject(want) {
+			continue
+		}
+		if latest == nil || s.Predicate.Metadata.ScanFinishedOn.After(latest.Predicate.Metadata.ScanFinishedOn) {
+			latest = &s
+		}
+	}
+	if latest == nil {
+		return Statement{}, xerrors.Errorf("no vulnerability attestation of %s verified by the key", ref)
+	}
+	return *latest, nil
+}
+
+func (s Statement) hasSubject(want Subject) bool {
+	for _, sub := range s.Subject {
+		if sub.Name == want.Name && sub.Digest["sha256"] == want.Digest["sha256"] {
+			return true
+		}
+	}
+	return false
+}
+
+func subject(ref name.Digest) Subject {
+	return Subject{
+		Name:   ref.Context().Name(),
+		Digest: map[string]string{"sha256": strings.TrimPrefix(ref.DigestStr(), "sha256:")},
+	}
+}
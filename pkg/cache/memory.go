@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/types"
+)
+
+// MemoryBackend is the backend of NewCache keeping the analyses of a single run in memory, e.g. with --no-cache
+const MemoryBackend = "memory"
+
+// MemoryCache stores the images and the layers in memory, every layer of a run being analyzed again
+// without reading or writing the cache of the other runs
+type MemoryCache struct {
+	mu     sync.RWMutex
+	images map[string]types.ImageInfo
+	layers map[string]types.LayerInfo
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{images: map[string]types.ImageInfo{}, layers: map[string]types.LayerInfo{}}
+}
+
+func (c *MemoryCache) PutImage(imageID string, imageInfo types.ImageInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images[imageID] = imageInfo
+	return nil
+}
+
+func (c *MemoryCache) PutLayer(diffID string, layerInfo types.LayerInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.layers[diffID] = layerInfo
+	return nil
+}
+
+func (c *MemoryCache) GetImage(imageID string) (types.ImageInfo, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	imageInfo, ok := c.images[imageID]
+	if !ok {
+		return types.ImageInfo{}, xerrors.Errorf("image %s isn't in the cache", imageID)
+	}
+	return imageInfo, nil
+}
+
+func (c *MemoryCache) GetLayer(diffID string) (types.LayerInfo, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	layerInfo, ok := c.layers[diffID]
+	if !ok {
+		return types.LayerInfo{}, xerrors.Errorf("layer %s isn't in the cache", diffID)
+	}
+	return layerInfo, nil
+}
+
+// MissingLayers returns the layers and the image not analyzed yet in the run, and the layers cached as incomplete
+func (c *MemoryCache) MissingLayers(imageID string, layerIDs []string) (bool, []string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var missingLayerIDs []string
+	for _, layerID := range layerIDs {
+		if l, ok := c.layers[layerID]; !ok || l.SchemaVersion != types.LayerJSONSchemaVersion {
+			missingLayerIDs = append(missingLayerIDs, layerID)
+		}
+	}
+	_, ok := c.images[imageID]
+	return !ok, missingLayerIDs, nil
+}
+
+func (c *MemoryCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images = map[string]types.ImageInfo{}
+	c.layers = map[string]types.LayerInfo{}
+	return nil
+}
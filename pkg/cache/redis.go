@@ -0,0 +1,393 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/types"
+)
+
+const (
+	// redisPrefix is the prefix of the keys of the images and the layers, e.g. fanal::layer::sha256:...
+	redisPrefix      = "fanal::"
+	redisImagePrefix = redisPrefix + "image::"
+	redisLayerPrefix = redisPrefix + "layer::"
+
+	redisDialTimeout = 10 * time.Second
+	// redisCommandTimeout is the default of RedisOptions.CommandTimeout
+	redisCommandTimeout = time.Minute
+)
+
+// RedisOptions are the options of the Redis cache
+type RedisOptions struct {
+	// TTL expires the images and the layers that long after they are stored, zero keeps them
+	TTL time.Duration
+	// TLS connects over TLS, as the rediss scheme does. CACert is a PEM file of the CAs verifying the server,
+	// the system ones without it, and Cert and Key a PEM client certificate and its key.
+	TLS    bool
+	CACert string
+	Cert   string
+	Key    string
+	// CommandTimeout bounds each command and its reply, so that a hung server fails the scan instead of blocking it,
+	// a minute when zero
+	CommandTimeout time.Duration
+}
+
+// NewCache returns the cache of the analyzed images and layers for the backend: "fs" or empty for the BoltDB cache
+// in the cache directory, "memory" for a cache of the run only, or the URL of a Redis server, e.g. redis://:password@redis:6379/0
+// or rediss:// over TLS, to share the layers among scanners
+func NewCache(backend, cacheDir string, redisOptions RedisOptions) (cache.Cache, error) {
+	switch {
+	case backend == "" || backend == "fs":
+		return cache.NewFSCache(cacheDir)
+	case backend == MemoryBackend:
+		return NewMemoryCache(), nil
+	case strings.HasPrefix(backend, "redis://") || strings.HasPrefix(backend, "rediss://"):
+		return NewRedisCache(backend, redisOptions)
+	}
+	return nil, xerrors.Errorf("unknown cache backend: %s", backend)
+}
+
+// RedisCache stores the images and the layers as JSON in a Redis server, with the keys of the FS cache
+// prefixed with fanal::image:: and fanal::layer::
+type RedisCache struct {
+	client *redisClient
+	ttl    time.Duration
+}
+
+// NewRedisCache connects to the Redis server of the URL, redis://[[username]:password@]host[:port][/db] or rediss://
+// over TLS. The username authenticates as a user of the ACL of Redis 6, the default user without it.
+func NewRedisCache(redisURL string, options RedisOptions) (*RedisCache, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid Redis URL: %w", err)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	c := &redisClient{addr: addr, timeout: options.CommandTimeout}
+	if c.timeout == 0 {
+		c.timeout = redisCommandTimeout
+	}
+	if password, ok := u.User.Password(); ok {
+		c.username, c.password = u.User.Username(), password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, xerrors.Errorf("invalid Redis database %q: %w", db, err)
+		}
+	}
+	if u.Scheme == "rediss" || options.TLS {
+		if c.tlsConfig, err = redisTLSConfig(u.Hostname(), options); err != nil {
+			return nil, xerrors.Errorf("invalid Redis TLS options: %w", err)
+		}
+	}
+
+	// fails early on a wrong address or password
+	if _, err = c.do("PING"); err != nil {
+		return nil, xerrors.Errorf("unable to connect to Redis at %s: %w", addr, err)
+	}
+	return &RedisCache{client: c, ttl: options.TTL}, nil
+}
+
+func redisTLSConfig(serverName string, options RedisOptions) (*tls.Config, error) {
+	config := &tls.Config{ServerName: serverName}
+	if options.CACert != "" {
+		pem, err := ioutil.ReadFile(options.CACert)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read the CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, xerrors.Errorf("no certificate in %s", options.CACert)
+		}
+	}
+	if options.Cert != "" || options.Key != "" {
+		cert, err := tls.LoadX509KeyPair(options.Cert, options.Key)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func (c RedisCache) PutImage(imageID string, imageInfo types.ImageInfo) error {
+	b, err := json.Marshal(imageInfo)
+	if err != nil {
+		return xerrors.Errorf("unable to marshal image JSON (%s): %w", imageID, err)
+	}
+	if err = c.set(redisImagePrefix+imageID, b); err != nil {
+		return xerrors.Errorf("unable to store image information in Redis (%s): %w", imageID, err)
+	}
+	return nil
+}
+
+func (c RedisCache) PutLayer(diffID string, layerInfo types.LayerInfo) error {
+	if _, err := v1.NewHash(diffID); err != nil {
+		return xerrors.Errorf("invalid diffID (%s): %w", diffID, err)
+	}
+	b, err := json.Marshal(layerInfo)
+	if err != nil {
+		return xerrors.Errorf("unable to marshal layer JSON (%s): %w", diffID, err)
+	}
+	if err = c.set(redisLayerPrefix+diffID, b); err != nil {
+		return xerrors.Errorf("unable to store layer information in Redis (%s): %w", diffID, err)
+	}
+	return nil
+}
+
+func (c RedisCache) set(key string, value []byte) error {
+	args := []string{"SET", key, string(value)}
+	if c.ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(int64(c.ttl/time.Millisecond), 10))
+	}
+	_, err := c.client.do(args...)
+	return err
+}
+
+func (c RedisCache) GetImage(imageID string) (types.ImageInfo, error) {
+	var info types.ImageInfo
+	if err := c.get(redisImagePrefix+imageID, &info); err != nil {
+		return types.ImageInfo{}, xerrors.Errorf("unable to get image information from Redis (%s): %w", imageID, err)
+	}
+	return info, nil
+}
+
+func (c RedisCache) GetLayer(diffID string) (types.LayerInfo, error) {
+	var info types.LayerInfo
+	if err := c.get(redisLayerPrefix+diffID, &info); err != nil {
+		return types.LayerInfo{}, xerrors.Errorf("unable to get layer information from Redis (%s): %w", diffID, err)
+	}
+	return info, nil
+}
+
+func (c RedisCache) get(key string, v interface{}) error {
+	reply, err := c.client.do("GET", key)
+	if err != nil {
+		return err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return xerrors.New("not found")
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return xerrors.Errorf("JSON unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// MissingLayers returns the layers and the image missing from Redis or stored with another schema version,
+// as the FS cache does
+func (c RedisCache) MissingLayers(imageID string, layerIDs []string) (bool, []string, error) {
+	var missingLayerIDs []string
+	if len(layerIDs) > 0 {
+		args := []string{"MGET"}
+		for _, layerID := range layerIDs {
+			args = append(args, redisLayerPrefix+layerID)
+		}
+		reply, err := c.client.do(args...)
+		if err != nil {
+			return false, nil, xerrors.Errorf("unable to get the layers from Redis: %w", err)
+		}
+		values, _ := reply.([]interface{})
+		for i, layerID := range layerIDs {
+			var layerInfo types.LayerInfo
+			if i >= len(values) || !unmarshalReply(values[i], &layerInfo) ||
+				layerInfo.SchemaVersion != types.LayerJSONSchemaVersion {
+				missingLayerIDs = append(missingLayerIDs, layerID)
+			}
+		}
+	}
+
+	imageInfo, err := c.GetImage(imageID)
+	if err != nil {
+		// error means cache missed image info
+		return true, missingLayerIDs, nil
+	}
+	return imageInfo.SchemaVersion != types.ImageJSONSchemaVersion, missingLayerIDs, nil
+}
+
+func unmarshalReply(reply interface{}, v interface{}) bool {
+	b, ok := reply.([]byte)
+	return ok && json.Unmarshal(b, v) == nil
+}
+
+// Clear removes the images and the layers, leaving the other keys of the database
+func (c RedisCache) Clear() error {
+	cursor := "0"
+	for {
+		reply, err := c.client.do("SCAN", cursor, "MATCH", redisPrefix+"*", "COUNT", "100")
+		if err != nil {
+			return xerrors.Errorf("unable to list the cache in Redis: %w", err)
+		}
+		values, ok := reply.([]interface{})
+		if !ok || len(values) != 2 {
+			return xerrors.Errorf("unexpected SCAN reply: %v", reply)
+		}
+		next, _ := values[0].([]byte)
+		keys, _ := values[1].([]interface{})
+		if len(keys) > 0 {
+			args := []string{"DEL"}
+			for _, key := range keys {
+				if b, ok := key.([]byte); ok {
+					args = append(args, string(b))
+				}
+			}
+			if _, err = c.client.do(args...); err != nil {
+				return xerrors.Errorf("unable to remove the cache from Redis: %w", err)
+			}
+		}
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// redisClient sends the commands one at a time over a connection opened on the first one,
+// and reopened after a failure
+type redisClient struct {
+	addr      string
+	username  string
+	password  string
+	db        int
+	tlsConfig *tls.Config
+	timeout   time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply of the server, which leaves the connection usable
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// do returns the reply of the command: a string, an int64, []byte, nil or []interface{} of them
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(args)
+	if _, ok := err.(redisError); err != nil && !ok {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return reply, err
+}
+
+func (c *redisClient) connect() error {
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var conn net.Conn
+	var err error
+	if c.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, c.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if c.username != "" {
+		setup = append(setup, []string{"AUTH", c.username, c.password})
+	} else if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if _, err = c.roundTrip(args); err != nil {
+			conn.Close()
+			c.conn = nil
+			return xerrors.Errorf("%s failed: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+func (c *redisClient) roundTrip(args []string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// readReply reads a reply of the Redis serialization protocol
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, xerrors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			// a nil bulk string
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, xerrors.Errorf("invalid reply: %q", line)
+}
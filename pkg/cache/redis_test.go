@@ -0,0 +1,304 @@
+package cache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fcache "github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+)
+
+// fakeRedis serves the commands of the Redis cache from memory
+type fakeRedis struct {
+	listener net.Listener
+	// username is the user of the password, the default one when empty
+	username string
+	password string
+	// hang reads the commands without replying
+	hang bool
+
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]string
+	dbs    []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	r := &fakeRedis{listener: l, password: password, values: map[string]string{}, ttls: map[string]string{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := r.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if !authenticated && strings.ToUpper(args[0]) != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if r.hang {
+			continue
+		}
+		if strings.ToUpper(args[0]) == "AUTH" {
+			if username := strings.Join(args[1:len(args)-1], ""); username != r.username || args[len(args)-1] != r.password {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authenticated = true
+		}
+		io.WriteString(conn, r.reply(args))
+	}
+}
+
+func (r *fakeRedis) reply(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH":
+		return "+OK\r\n"
+	case "SELECT":
+		r.dbs = append(r.dbs, args[1])
+		return "+OK\r\n"
+	case "SET":
+		r.values[args[1]] = args[2]
+		if len(args) == 5 {
+			r.ttls[args[1]] = args[3] + " " + args[4]
+		}
+		return "+OK\r\n"
+	case "GET":
+		return bulk(r.values, args[1])
+	case "MGET":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-1)
+		for _, key := range args[1:] {
+			reply += bulk(r.values, key)
+		}
+		return reply
+	case "SCAN":
+		var keys []string
+		for key := range r.values {
+			if ok, _ := path.Match(args[3], key); ok {
+				keys = append(keys, key)
+			}
+		}
+		reply := fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+		for _, key := range keys {
+			reply += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+		}
+		return reply
+	case "DEL":
+		for _, key := range args[1:] {
+			delete(r.values, key)
+		}
+		return fmt.Sprintf(":%d\r\n", len(args)-1)
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func bulk(values map[string]string, key string) string {
+	v, ok := values[key]
+	if !ok {
+		return "$-1\r\n"
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for i := 0; i < n; i++ {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+func TestRedisCache(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.listener.Close()
+	server.values["other"] = "kept"
+	url := fmt.Sprintf("redis://:secret@%s/2", server.listener.Addr())
+
+	c, err := cache.NewRedisCache(url, cache.RedisOptions{TTL: time.Hour})
+	require.NoError(t, err)
+
+	const (
+		imageID = "sha256:58701fd185bda36cab0557bb6438661831267aa4a9e0b54211c4d5317a48aff4"
+		layerID = "sha256:24df0d4e20c0f42d3703bf1f1db2bdd77346c7956f74f423603d651e8e5ae8a7"
+		missing = "sha256:dffd9992ca398466a663c87c92cfea2a2db0ae0cf33fcb99da60eec52addbfc5"
+	)
+	layer := types.LayerInfo{
+		SchemaVersion: types.LayerJSONSchemaVersion,
+		OS:            &types.OS{Family: "alpine", Name: "3.10.2"},
+	}
+	image := types.ImageInfo{SchemaVersion: types.ImageJSONSchemaVersion, OS: "linux"}
+
+	missingImage, missingLayers, err := c.MissingLayers(imageID, []string{layerID, missing})
+	require.NoError(t, err)
+	assert.True(t, missingImage)
+	assert.Equal(t, []string{layerID, missing}, missingLayers)
+
+	require.NoError(t, c.PutLayer(layerID, layer))
+	require.NoError(t, c.PutImage(imageID, image))
+	assert.Error(t, c.PutLayer("invalid", layer))
+
+	missingImage, missingLayers, err = c.MissingLayers(imageID, []string{layerID, missing})
+	require.NoError(t, err)
+	assert.False(t, missingImage)
+	assert.Equal(t, []string{missing}, missingLayers)
+
+	gotLayer, err := c.GetLayer(layerID)
+	require.NoError(t, err)
+	assert.Equal(t, layer, gotLayer)
+	gotImage, err := c.GetImage(imageID)
+	require.NoError(t, err)
+	assert.Equal(t, image, gotImage)
+	_, err = c.GetLayer(missing)
+	assert.Error(t, err)
+
+	assert.Equal(t, "PX 3600000", server.ttls["fanal::layer::"+layerID])
+	assert.Equal(t, []string{"2"}, server.dbs)
+
+	// the other keys are kept
+	require.NoError(t, c.Clear())
+	assert.Equal(t, map[string]string{"other": "kept"}, server.values)
+	missingImage, _, err = c.MissingLayers(imageID, nil)
+	require.NoError(t, err)
+	assert.True(t, missingImage)
+}
+
+func TestNewRedisCache(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.listener.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		options cache.RedisOptions
+		wantErr string
+	}{
+		{
+			name:    "wrong password",
+			url:     fmt.Sprintf("redis://:wrong@%s", server.listener.Addr()),
+			wantErr: "AUTH failed: WRONGPASS invalid password",
+		},
+		{
+			name:    "no password",
+			url:     fmt.Sprintf("redis://%s", server.listener.Addr()),
+			wantErr: "NOAUTH Authentication required.",
+		},
+		{
+			name:    "password of the default user",
+			url:     fmt.Sprintf("redis://trivy:secret@%s", server.listener.Addr()),
+			wantErr: "AUTH failed: WRONGPASS invalid password",
+		},
+		{
+			name:    "invalid database",
+			url:     fmt.Sprintf("redis://%s/cache", server.listener.Addr()),
+			wantErr: `invalid Redis database "cache"`,
+		},
+		{
+			name:    "missing CA certificate",
+			url:     fmt.Sprintf("rediss://%s", server.listener.Addr()),
+			options: cache.RedisOptions{CACert: "testdata/missing.pem"},
+			wantErr: "unable to read the CA certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cache.NewRedisCache(tt.url, tt.options)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestNewRedisCache_Username(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.listener.Close()
+	server.username = "trivy"
+
+	c, err := cache.NewRedisCache(fmt.Sprintf("redis://trivy:secret@%s", server.listener.Addr()), cache.RedisOptions{})
+	require.NoError(t, err)
+	require.NoError(t, c.PutLayer("sha256:24df0d4e20c0f42d3703bf1f1db2bdd77346c7956f74f423603d651e8e5ae8a7", types.LayerInfo{SchemaVersion: 1}))
+}
+
+func TestNewRedisCache_CommandTimeout(t *testing.T) {
+	server := newFakeRedis(t, "")
+	defer server.listener.Close()
+	server.hang = true
+
+	start := time.Now()
+	_, err := cache.NewRedisCache(fmt.Sprintf("redis://%s", server.listener.Addr()),
+		cache.RedisOptions{CommandTimeout: 100 * time.Millisecond})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "i/o timeout")
+	assert.Less(t, int64(time.Since(start)), int64(10*time.Second))
+}
+
+func TestNewCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := cache.NewCache("fs", dir, cache.RedisOptions{})
+	require.NoError(t, err)
+	assert.IsType(t, fcache.FSCache{}, c)
+
+	c, err = cache.NewCache("memory", dir, cache.RedisOptions{})
+	require.NoError(t, err)
+	assert.IsType(t, &cache.MemoryCache{}, c)
+
+	_, err = cache.NewCache("memcached://localhost:11211", dir, cache.RedisOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown cache backend: memcached://localhost:11211")
+}
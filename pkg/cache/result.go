@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+// resultDir is the directory of the cached results in the cache directory
+const resultDir = "results"
+
+// ResultCache stores the results of the scans in the cache directory per DB version, a rescan of an artifact
+// with the same DB returning them without matching its packages again. The results of the other DB versions are
+// removed as soon as a result of the DB version is stored.
+type ResultCache struct {
+	dir string
+}
+
+// NewResultCache returns the result cache of the DB version, e.g. the version, the type and the update time of the DB
+func NewResultCache(cacheDir, dbVersion string) ResultCache {
+	return ResultCache{dir: filepath.Join(cacheDir, resultDir, hash(dbVersion))}
+}
+
+// Get returns the result of the key, false when it isn't cached
+func (c ResultCache) Get(key string) ([]byte, bool, error) {
+	b, err := ioutil.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, xerrors.Errorf("unable to read the cached result: %w", err)
+	}
+	return b, true, nil
+}
+
+// Put stores the result of the key and removes the results of the other DB versions
+func (c ResultCache) Put(key string, value []byte) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return xerrors.Errorf("unable to create the result cache: %w", err)
+	}
+	// written aside and renamed, a concurrent scan never reads a partial result
+	tmp, err := ioutil.TempFile(c.dir, "result-")
+	if err != nil {
+		return xerrors.Errorf("unable to create the result file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(value); err != nil {
+		tmp.Close()
+		return xerrors.Errorf("unable to write the result: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return xerrors.Errorf("unable to write the result: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return xerrors.Errorf("unable to store the result: %w", err)
+	}
+	return c.prune()
+}
+
+// prune removes the results of the other DB versions
+func (c ResultCache) prune() error {
+	parent := filepath.Dir(c.dir)
+	entries, err := ioutil.ReadDir(parent)
+	if err != nil {
+		return xerrors.Errorf("unable to read the result cache: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == filepath.Base(c.dir) {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(parent, entry.Name())); err != nil {
+			return xerrors.Errorf("unable to remove the results of another DB: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c ResultCache) path(key string) string {
+	return filepath.Join(c.dir, hash(key)+".json")
+}
+
+// ClearResults removes the cached results of every DB version
+func ClearResults(cacheDir string) error {
+	if err := os.RemoveAll(filepath.Join(cacheDir, resultDir)); err != nil {
+		return xerrors.Errorf("failed to remove the result cache: %w", err)
+	}
+	return nil
+}
+
+func hash(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/cache"
+)
+
+func TestResultCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := cache.NewResultCache(dir, "1 full 2020-05-01")
+	_, found, err := c.Get("alpine")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, c.Put("alpine", []byte(`{"Results":[]}`)))
+	b, found, err := c.Get("alpine")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, `{"Results":[]}`, string(b))
+
+	// a new DB doesn't see the results of the previous one, and removes them
+	updated := cache.NewResultCache(dir, "1 full 2020-05-02")
+	_, found, err = updated.Get("alpine")
+	require.NoError(t, err)
+	assert.False(t, found)
+	require.NoError(t, updated.Put("debian", []byte(`{}`)))
+	_, found, err = c.Get("alpine")
+	require.NoError(t, err)
+	assert.False(t, found)
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "results"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, cache.ClearResults(dir))
+	_, found, err = updated.Get("debian")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
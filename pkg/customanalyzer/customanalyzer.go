@@ -0,0 +1,296 @@
+package customanalyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// APIVersion is the version of the interface of the custom analyzers, returned by their info command
+const APIVersion = 1
+
+const (
+	// typePrefix prefixes the application types of the custom analyzers, e.g. custom:acme:npm
+	typePrefix = "custom:"
+
+	// timeout is the limit of the analysis of a file, or of the info of an analyzer
+	timeout = time.Minute
+)
+
+// DefaultRuntime is the command of the WASI runtime running the modules, followed by the module and its arguments.
+// Wasmtime gives the modules no file, network or environment variable unless asked to.
+const DefaultRuntime = "wasmtime run"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// wasmMagic starts the binary format of the WebAssembly modules
+var wasmMagic = []byte("\x00asm")
+
+// Info is the info of a custom analyzer, e.g.
+// {"APIVersion": 1, "Name": "acme", "Ecosystem": "npm", "RequiredFiles": ["acme.lock"]}
+type Info struct {
+	APIVersion int
+	// Name is the name of the analyzer, lower case letters, digits, ".", "_" and "-"
+	Name string
+	// Ecosystem is the ecosystem of the libraries, as named by OSV, e.g. npm or PyPI, whose vulnerabilities of the
+	// DB and of the supplementary advisories are detected. The other ecosystems match the supplementary advisories only.
+	Ecosystem string
+	// RequiredFiles are the files analyzed, by name, path.Match pattern of the name, e.g. "*.lock", path
+	// or directory ending with a slash, e.g. "opt/acme/"
+	RequiredFiles []string
+}
+
+// result is the result of the analysis of a file
+type result struct {
+	Libraries []ptypes.Library
+	// Error fails the analysis, e.g. of an invalid file
+	Error string
+}
+
+// Analyzer is a custom analyzer, a WebAssembly module compiled for WASI analyzing the files of a package manager,
+// e.g. the lock files of a proprietary one, run by a WASI runtime as a command:
+//   - with the argument info, it writes its info as JSON to the standard output
+//   - with the arguments analyze and the path of a file, it reads the file from the standard input and writes the
+//     libraries as JSON to the standard output, nothing without any
+//
+// Its standard error is logged at the debug level.
+type Analyzer struct {
+	path    string
+	runtime []string
+	info    Info
+}
+
+// Load loads the custom analyzer of the module of the file, run by the command of the runtime, e.g. DefaultRuntime
+func Load(filePath, runtime string) (*Analyzer, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the custom analyzer: %w", err)
+	}
+	if !bytes.HasPrefix(b, wasmMagic) {
+		return nil, xerrors.Errorf("%s is not a WebAssembly module", filePath)
+	}
+	if strings.TrimSpace(runtime) == "" {
+		runtime = DefaultRuntime
+	}
+
+	a := &Analyzer{path: filePath, runtime: strings.Fields(runtime), info: Info{Name: filepath.Base(filePath)}}
+	b, err = a.run(nil, "info")
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the info of %s: %w", filePath, err)
+	}
+
+	var info Info
+	if err = json.Unmarshal(b, &info); err != nil {
+		return nil, xerrors.Errorf("invalid info of %s: %w", filePath, err)
+	}
+	switch {
+	case info.APIVersion != APIVersion:
+		return nil, xerrors.Errorf("%s has the API version %d, expected %d", filePath, info.APIVersion, APIVersion)
+	case !namePattern.MatchString(info.Name):
+		return nil, xerrors.Errorf("invalid name %q of %s", info.Name, filePath)
+	case info.Ecosystem == "":
+		return nil, xerrors.Errorf("%s has no ecosystem", filePath)
+	case len(info.RequiredFiles) == 0:
+		return nil, xerrors.Errorf("%s has no required files", filePath)
+	}
+	for _, f := range info.RequiredFiles {
+		if _, err = path.Match(f, ""); err != nil || f == "" {
+			return nil, xerrors.Errorf("invalid required file %q of %s", f, filePath)
+		}
+	}
+	a.info = info
+	return a, nil
+}
+
+// LoadDir loads the custom analyzers of the .wasm files of the directory, in the order of their names
+func LoadDir(dir, runtime string) ([]*Analyzer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to list the custom analyzers of %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, xerrors.Errorf("no custom analyzer, *.wasm, in %s", dir)
+	}
+	sort.Strings(files)
+
+	var analyzers []*Analyzer
+	for _, f := range files {
+		a, err := Load(f, runtime)
+		if err != nil {
+			return nil, err
+		}
+		analyzers = append(analyzers, a)
+	}
+	return analyzers, nil
+}
+
+// Info returns the info of the analyzer
+func (a *Analyzer) Info() Info {
+	return a.info
+}
+
+// Name implements analyzer.LibraryAnalyzer, it is the application type of the libraries, see Type
+func (a *Analyzer) Name() string {
+	return Type(a.info.Name, a.info.Ecosystem)
+}
+
+// RequiredFiles implements analyzer.LibraryAnalyzer
+func (a *Analyzer) RequiredFiles() []string {
+	return a.info.RequiredFiles
+}
+
+// Analyze implements analyzer.LibraryAnalyzer, analyzing the required files
+func (a *Analyzer) Analyze(fileMap extractor.FileMap) (map[ftypes.FilePath][]ptypes.Library, error) {
+	var filePaths []string
+	for filePath := range fileMap {
+		if a.required(filePath) {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	sort.Strings(filePaths)
+
+	libMap := map[ftypes.FilePath][]ptypes.Library{}
+	for _, filePath := range filePaths {
+		libs, err := a.analyze(filePath, fileMap[filePath])
+		if err != nil {
+			return nil, xerrors.Errorf("custom analyzer %s failed to analyze %s: %w", a.info.Name, filePath, err)
+		}
+		if len(libs) > 0 {
+			libMap[ftypes.FilePath(filePath)] = libs
+		}
+	}
+	return libMap, nil
+}
+
+// required reports whether the file is one of the required files, as extracted by fanal
+func (a *Analyzer) required(filePath string) bool {
+	name := path.Base(filePath)
+	for _, f := range a.info.RequiredFiles {
+		if strings.HasSuffix(f, "/") {
+			if path.Clean(f) == path.Dir(filePath) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(f, name); ok || f == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analyzer) analyze(filePath string, content []byte) ([]ptypes.Library, error) {
+	b, err := a.run(content, "analyze", filePath)
+	if err != nil || len(bytes.TrimSpace(b)) == 0 {
+		return nil, err
+	}
+
+	var r result
+	if err = json.Unmarshal(b, &r); err != nil {
+		return nil, xerrors.Errorf("invalid result: %w", err)
+	}
+	if r.Error != "" {
+		return nil, xerrors.New(r.Error)
+	}
+	var libs []ptypes.Library
+	for _, lib := range r.Libraries {
+		if lib.Name != "" {
+			libs = append(libs, lib)
+		}
+	}
+	return libs, nil
+}
+
+// run runs the module with the arguments and the input, returning its output
+func (a *Analyzer) run(input []byte, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args = append(append(append([]string{}, a.runtime[1:]...), a.path), args...)
+	cmd := exec.CommandContext(ctx, a.runtime[0], args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = logWriter{name: a.info.Name}
+	b, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, xerrors.Errorf("timed out after %s", timeout)
+	} else if err != nil {
+		return nil, xerrors.Errorf("failed to run %s: %w", a.runtime[0], err)
+	}
+	return b, nil
+}
+
+// logWriter logs the standard error of the modules at the debug level
+type logWriter struct {
+	name string
+}
+
+func (w logWriter) Write(b []byte) (int, error) {
+	log.Logger.Debugf("%s: %s", w.name, strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+// Type returns the application type of the libraries of the custom analyzer of the ecosystem, e.g. custom:acme:npm.
+// The type keeps the ecosystem for the detection of their vulnerabilities, e.g. by a server.
+func Type(name, ecosystem string) string {
+	return typePrefix + name + ":" + ecosystem
+}
+
+// Ecosystem returns the ecosystem of the application type of a custom analyzer
+func Ecosystem(appType string) (string, bool) {
+	if !strings.HasPrefix(appType, typePrefix) {
+		return "", false
+	}
+	ss := strings.SplitN(strings.TrimPrefix(appType, typePrefix), ":", 2)
+	if len(ss) != 2 || ss[1] == "" {
+		return "", false
+	}
+	return ss[1], true
+}
+
+var registerOnce sync.Once
+
+// Register loads the custom analyzers of the directories, run by the command of the runtime, and enables them
+// after the analyzers of fanal. The analyzers of fanal are global, so only the first call is effective.
+func Register(dirs []string, runtime string) error {
+	var err error
+	registerOnce.Do(func() {
+		var analyzers []*Analyzer
+		names := map[string]string{}
+		for _, dir := range dirs {
+			var loaded []*Analyzer
+			if loaded, err = LoadDir(dir, runtime); err != nil {
+				return
+			}
+			for _, a := range loaded {
+				if p, ok := names[a.info.Name]; ok {
+					err = xerrors.Errorf("the custom analyzers %s and %s are both named %s", p, a.path, a.info.Name)
+					return
+				}
+				names[a.info.Name] = a.path
+			}
+			analyzers = append(analyzers, loaded...)
+		}
+		for _, a := range analyzers {
+			log.Logger.Infof("Custom analyzer: %s (%s) of %s", a.info.Name, a.info.Ecosystem, a.path)
+			analyzer.RegisterLibraryAnalyzer(a)
+		}
+	})
+	return err
+}
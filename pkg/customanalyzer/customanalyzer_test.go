@@ -0,0 +1,211 @@
+package customanalyzer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// echoRuntime is a WASI runtime running the modules of echoModule: info writes the info following the magic
+// of the module, and analyze logs the path and writes the content as the result
+const echoRuntime = `#!/bin/sh
+module=$1
+shift
+case $1 in
+info) tail -c +5 "$module" ;;
+analyze) echo "analyzing $2" >&2; cat ;;
+esac
+`
+
+// echoModule returns a module of the info for echoRuntime
+func echoModule(info string) []byte {
+	return []byte("\x00asm" + info)
+}
+
+// writeRuntime writes echoRuntime to the directory and returns its command
+func writeRuntime(t *testing.T, dir string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the runtime of the tests is a shell script")
+	}
+	filePath := filepath.Join(dir, "runtime")
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(echoRuntime), 0755))
+	return filePath
+}
+
+func writeModule(t *testing.T, dir, name string, b []byte) string {
+	filePath := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filePath, b, 0644))
+	return filePath
+}
+
+func TestLoad(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	tests := []struct {
+		name     string
+		module   []byte
+		wantInfo Info
+		wantErr  string
+	}{
+		{
+			name:   "happy path",
+			module: echoModule(`{"APIVersion": 1, "Name": "acme", "Ecosystem": "npm", "RequiredFiles": ["acme.lock", "*.acme"]}`),
+			wantInfo: Info{
+				APIVersion:    1,
+				Name:          "acme",
+				Ecosystem:     "npm",
+				RequiredFiles: []string{"acme.lock", "*.acme"},
+			},
+		},
+		{
+			name:    "unsupported API version",
+			module:  echoModule(`{"APIVersion": 2, "Name": "acme", "Ecosystem": "npm", "RequiredFiles": ["acme.lock"]}`),
+			wantErr: "has the API version 2, expected 1",
+		},
+		{
+			name:    "invalid name",
+			module:  echoModule(`{"APIVersion": 1, "Name": "Acme Lock", "Ecosystem": "npm", "RequiredFiles": ["acme.lock"]}`),
+			wantErr: `invalid name "Acme Lock"`,
+		},
+		{
+			name:    "no required files",
+			module:  echoModule(`{"APIVersion": 1, "Name": "acme", "Ecosystem": "npm"}`),
+			wantErr: "has no required files",
+		},
+		{
+			name:    "invalid info",
+			module:  echoModule(`{"APIVersion": 1`),
+			wantErr: "invalid info",
+		},
+		{
+			name:    "without info",
+			module:  echoModule(""),
+			wantErr: "invalid info",
+		},
+		{
+			name:    "not a module",
+			module:  []byte("#!/bin/sh"),
+			wantErr: "is not a WebAssembly module",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "custom-analyzer")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			a, err := Load(writeModule(t, dir, "acme.wasm", tt.module), writeRuntime(t, dir))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantInfo, a.Info())
+			assert.Equal(t, "custom:acme:npm", a.Name())
+		})
+	}
+}
+
+func TestLoad_MissingRuntime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "custom-analyzer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	module := writeModule(t, dir, "acme.wasm", echoModule(`{"APIVersion": 1}`))
+	_, err = Load(module, filepath.Join(dir, "wasmtime")+" run")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to get the info")
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	dir, err := ioutil.TempDir("", "custom-analyzer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	a, err := Load(writeModule(t, dir, "acme.wasm",
+		echoModule(`{"APIVersion": 1, "Name": "acme", "Ecosystem": "npm", "RequiredFiles": ["acme.lock", "*.acme", "opt/acme/"]}`)),
+		writeRuntime(t, dir))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		fileMap extractor.FileMap
+		want    map[ftypes.FilePath][]ptypes.Library
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			fileMap: extractor.FileMap{
+				"app/acme.lock":    []byte(`{"Libraries": [{"Name": "lodash", "Version": "4.17.4"}, {"Name": "", "Version": "1.0.0"}]}`),
+				"app/web.acme":     []byte(`{"Libraries": [{"Name": "jquery", "Version": "3.3.1"}]}`),
+				"opt/acme/plugins": []byte(`{"Libraries": [{"Name": "minimist", "Version": "1.2.0"}]}`),
+				"app/empty.acme":   []byte{},
+				"app/package.json": []byte(`{"name": "app"}`),
+				"opt/acme/x/y":     []byte(`invalid`),
+			},
+			want: map[ftypes.FilePath][]ptypes.Library{
+				"app/acme.lock":    {{Name: "lodash", Version: "4.17.4"}},
+				"app/web.acme":     {{Name: "jquery", Version: "3.3.1"}},
+				"opt/acme/plugins": {{Name: "minimist", Version: "1.2.0"}},
+			},
+		},
+		{
+			name: "error of the analyzer",
+			fileMap: extractor.FileMap{
+				"acme.lock": []byte(`{"Error": "unsupported lock file version"}`),
+			},
+			wantErr: "custom analyzer acme failed to analyze acme.lock: unsupported lock file version",
+		},
+		{
+			name: "invalid result",
+			fileMap: extractor.FileMap{
+				"acme.lock": []byte(`[`),
+			},
+			wantErr: "invalid result",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Analyze(tt.fileMap)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEcosystem(t *testing.T) {
+	tests := []struct {
+		appType string
+		want    string
+		wantOK  bool
+	}{
+		{appType: Type("acme", "npm"), want: "npm", wantOK: true},
+		{appType: "custom:acme:Go:x", want: "Go:x", wantOK: true},
+		{appType: "custom:acme"},
+		{appType: "npm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.appType, func(t *testing.T) {
+			got, ok := Ecosystem(tt.appType)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
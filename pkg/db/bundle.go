@@ -0,0 +1,145 @@
+package db
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// dbFileName is the name of the DB in the cache directory and in the bundle
+const dbFileName = "trivy.db"
+
+// Export writes the DB of the cache directory and its metadata as a gzipped tarball,
+// the bundle loaded by Import on a host without network access
+func (c Client) Export(cacheDir string, w io.Writer) error {
+	metadata, err := c.metadata.Get()
+	if err != nil {
+		return xerrors.Errorf("unable to get the DB metadata: %w", err)
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return xerrors.Errorf("unable to encode metadata: %w", err)
+	}
+
+	f, err := os.Open(db.Path(cacheDir))
+	if err != nil {
+		return xerrors.Errorf("unable to open DB file: %w", err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return xerrors.Errorf("unable to stat DB file: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	hdr := &tar.Header{Name: metadataFile, Mode: 0600, Size: int64(len(metadataJSON)), ModTime: stat.ModTime()}
+	if err = tw.WriteHeader(hdr); err != nil {
+		return xerrors.Errorf("failed to write the metadata header: %w", err)
+	}
+	if _, err = tw.Write(metadataJSON); err != nil {
+		return xerrors.Errorf("failed to write metadata: %w", err)
+	}
+	hdr = &tar.Header{Name: dbFileName, Mode: 0600, Size: stat.Size(), ModTime: stat.ModTime()}
+	if err = tw.WriteHeader(hdr); err != nil {
+		return xerrors.Errorf("failed to write the DB header: %w", err)
+	}
+	if _, err = io.Copy(tw, f); err != nil {
+		return xerrors.Errorf("failed to write DB file: %w", err)
+	}
+	if err = tw.Close(); err != nil {
+		return xerrors.Errorf("failed to close the tarball: %w", err)
+	}
+	if err = gw.Close(); err != nil {
+		return xerrors.Errorf("failed to close the gzip stream: %w", err)
+	}
+	return nil
+}
+
+// Import replaces the DB of the cache directory with the one of a bundle written by Export.
+// The bundle is rejected unless its DB schema is the one of this version of Trivy.
+func (c Client) Import(cacheDir string, r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return xerrors.Errorf("invalid gzip file: %w", err)
+	}
+	defer gr.Close()
+
+	dbPath := db.Path(cacheDir)
+	dbDir := filepath.Dir(dbPath)
+	if err = os.MkdirAll(dbDir, 0700); err != nil {
+		return xerrors.Errorf("failed to mkdir: %w", err)
+	}
+
+	// the DB is extracted next to the current one, which is only replaced by a valid bundle
+	tmp, err := ioutil.TempFile(dbDir, dbFileName+".")
+	if err != nil {
+		return xerrors.Errorf("unable to create a temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var metadata *db.Metadata
+	var hasDB bool
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return xerrors.Errorf("invalid tarball: %w", err)
+		}
+
+		switch hdr.Name {
+		case metadataFile:
+			metadata = &db.Metadata{}
+			if err = json.NewDecoder(tr).Decode(metadata); err != nil {
+				return xerrors.Errorf("unable to decode metadata: %w", err)
+			}
+		case dbFileName:
+			if _, err = io.Copy(tmp, tr); err != nil {
+				return xerrors.Errorf("failed to extract DB file: %w", err)
+			}
+			hasDB = true
+		default:
+			log.Logger.Debugf("Skipping %s of the DB bundle", hdr.Name)
+		}
+	}
+
+	switch {
+	case metadata == nil:
+		return xerrors.Errorf("%s not found in the bundle", metadataFile)
+	case !hasDB:
+		return xerrors.Errorf("%s not found in the bundle", dbFileName)
+	case db.SchemaVersion < metadata.Version:
+		return xerrors.Errorf("the DB schema of the bundle is newer than the one of this Trivy. Bundle: %d, Expected: %d",
+			metadata.Version, db.SchemaVersion)
+	case db.SchemaVersion > metadata.Version:
+		return xerrors.Errorf("the DB schema of the bundle is old. Bundle: %d, Expected: %d",
+			metadata.Version, db.SchemaVersion)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return xerrors.Errorf("failed to save DB file: %w", err)
+	}
+	// the stale metadata mustn't describe the new DB if storing the new one fails
+	if err = c.metadata.Delete(); err != nil {
+		log.Logger.Debug("no metadata file")
+	}
+	if err = os.Rename(tmp.Name(), dbPath); err != nil {
+		return xerrors.Errorf("failed to replace DB file: %w", err)
+	}
+	if err = c.metadata.Store(*metadata); err != nil {
+		return xerrors.Errorf("failed to store metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/indicator"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestClient_ExportImport(t *testing.T) {
+	err := log.InitLogger(false, true)
+	require.NoError(t, err, "failed to init logger")
+
+	metadata := db.Metadata{
+		Version:    db.SchemaVersion,
+		Type:       db.TypeFull,
+		NextUpdate: time.Date(2019, 10, 2, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	src, err := ioutil.TempDir("", "db")
+	require.NoError(t, err)
+	defer os.RemoveAll(src)
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "db"), 0700))
+	require.NoError(t, ioutil.WriteFile(db.Path(src), []byte("boltdb"), 0600))
+
+	fs := afero.NewMemMapFs()
+	srcMetadata := NewMetadata(fs, src)
+	require.NoError(t, srcMetadata.Store(metadata))
+
+	var bundle bytes.Buffer
+	err = NewClient(nil, nil, indicator.NewProgressBar(true), nil, srcMetadata).Export(src, &bundle)
+	require.NoError(t, err)
+
+	dst, err := ioutil.TempDir("", "db")
+	require.NoError(t, err)
+	defer os.RemoveAll(dst)
+
+	dstMetadata := NewMetadata(fs, dst)
+	err = NewClient(nil, nil, indicator.NewProgressBar(true), nil, dstMetadata).Import(dst, &bundle)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(db.Path(dst))
+	require.NoError(t, err)
+	assert.Equal(t, "boltdb", string(content))
+	got, err := dstMetadata.Get()
+	require.NoError(t, err)
+	assert.Equal(t, metadata, got)
+
+	// only the DB and its metadata are left in the DB directory
+	files, err := ioutil.ReadDir(filepath.Join(dst, "db"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestClient_Export(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fs := afero.NewMemMapFs()
+	metadata := NewMetadata(fs, dir)
+
+	err = NewClient(nil, nil, indicator.NewProgressBar(true), nil, metadata).Export(dir, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to get the DB metadata")
+
+	require.NoError(t, metadata.Store(db.Metadata{Version: db.SchemaVersion}))
+	err = NewClient(nil, nil, indicator.NewProgressBar(true), nil, metadata).Export(dir, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to open DB file")
+}
+
+func TestClient_Import(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         map[string]string
+		gzip          bool
+		expectedError string
+	}{
+		{
+			name: "newer schema",
+			files: map[string]string{
+				metadataFile: `{"Version": 100}`,
+				dbFileName:   "boltdb",
+			},
+			gzip:          true,
+			expectedError: "the DB schema of the bundle is newer than the one of this Trivy. Bundle: 100, Expected: 1",
+		},
+		{
+			name: "older schema",
+			files: map[string]string{
+				metadataFile: `{}`,
+				dbFileName:   "boltdb",
+			},
+			gzip:          true,
+			expectedError: "the DB schema of the bundle is old. Bundle: 0, Expected: 1",
+		},
+		{
+			name: "no DB",
+			files: map[string]string{
+				metadataFile: `{"Version": 1}`,
+			},
+			gzip:          true,
+			expectedError: "trivy.db not found in the bundle",
+		},
+		{
+			name: "no metadata",
+			files: map[string]string{
+				dbFileName: "boltdb",
+			},
+			gzip:          true,
+			expectedError: "metadata.json not found in the bundle",
+		},
+		{
+			name: "invalid metadata",
+			files: map[string]string{
+				metadataFile: `{`,
+			},
+			gzip:          true,
+			expectedError: "unable to decode metadata",
+		},
+		{
+			name:          "invalid gzip",
+			gzip:          false,
+			expectedError: "invalid gzip file",
+		},
+	}
+
+	err := log.InitLogger(false, true)
+	require.NoError(t, err, "failed to init logger")
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bundle bytes.Buffer
+			if tc.gzip {
+				gw := gzip.NewWriter(&bundle)
+				tw := tar.NewWriter(gw)
+				for name, content := range tc.files {
+					require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}))
+					_, err := tw.Write([]byte(content))
+					require.NoError(t, err)
+				}
+				require.NoError(t, tw.Close())
+				require.NoError(t, gw.Close())
+			} else {
+				bundle.WriteString("not gzip")
+			}
+
+			dir, err := ioutil.TempDir("", "db")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			// the current DB is kept
+			fs := afero.NewMemMapFs()
+			metadata := NewMetadata(fs, dir)
+			current := db.Metadata{Version: db.SchemaVersion, UpdatedAt: time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)}
+			require.NoError(t, metadata.Store(current))
+
+			err = NewClient(nil, nil, indicator.NewProgressBar(true), nil, metadata).Import(dir, &bundle)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+
+			got, err := metadata.Get()
+			require.NoError(t, err)
+			assert.Equal(t, current, got)
+			_, err = os.Stat(db.Path(dir))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+// the bundle is readable without Trivy
+func TestClient_Export_Tarball(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0700))
+	require.NoError(t, ioutil.WriteFile(db.Path(dir), []byte("boltdb"), 0600))
+
+	metadata := NewMetadata(afero.NewMemMapFs(), dir)
+	require.NoError(t, metadata.Store(db.Metadata{Version: db.SchemaVersion}))
+
+	var bundle bytes.Buffer
+	require.NoError(t, NewClient(nil, nil, indicator.NewProgressBar(true), nil, metadata).Export(dir, &bundle))
+
+	gr, err := gzip.NewReader(&bundle)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == metadataFile {
+			var m db.Metadata
+			require.NoError(t, json.NewDecoder(tr).Decode(&m))
+			assert.Equal(t, db.SchemaVersion, m.Version)
+		}
+	}
+	assert.Equal(t, "metadata.json,trivy.db", strings.Join(names, ","))
+}
@@ -3,11 +3,16 @@ package db
 import (
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/google/wire"
 	"github.com/spf13/afero"
 	"golang.org/x/xerrors"
@@ -17,6 +22,7 @@ import (
 	"github.com/aquasecurity/trivy/pkg/github"
 	"github.com/aquasecurity/trivy/pkg/indicator"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/utils"
 )
 
 const (
@@ -24,6 +30,18 @@ const (
 	lightDB = "trivy-light.db.gz"
 
 	metadataFile = "metadata.json"
+
+	// partialSuffix is the suffix of the compressed DB being downloaded, e.g. trivy.db.gz.partial
+	partialSuffix = ".partial"
+	// assetIDSuffix is the suffix of the file of the ID of the asset of the partial file, e.g. trivy.db.gz.partial.id
+	assetIDSuffix = ".id"
+)
+
+var (
+	// downloadRetries is the number of retries of the DB download, resumed from the partial file
+	downloadRetries uint64 = 5
+	// downloadRetryInterval is the delay before the first retry, growing exponentially
+	downloadRetryInterval = time.Second
 )
 
 var SuperSet = wire.NewSet(
@@ -51,6 +69,9 @@ var SuperSet = wire.NewSet(
 	wire.Bind(new(Operation), new(Client)),
 )
 
+// ErrNoLocalDB is returned when the DB updates are skipped but there is no DB in the cache directory
+var ErrNoLocalDB = xerrors.New("vulnerability DB not found and updates are skipped")
+
 type Operation interface {
 	NeedsUpdate(cliVersion string, skip, light bool) (need bool, err error)
 	Download(ctx context.Context, cacheDir string, light bool) (err error)
@@ -124,6 +145,33 @@ func (c Client) NeedsUpdate(cliVersion string, light, skip bool) (bool, error) {
 	return true, nil
 }
 
+// CheckAge fails when the DB was updated more than maxAge ago.
+// Within the grace period after maxAge, the stale DB is only warned about. A zero maxAge disables the check.
+func (c Client) CheckAge(maxAge, grace time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	metadata, err := c.metadata.Get()
+	if err != nil {
+		return xerrors.Errorf("unable to get the DB metadata: %w", err)
+	}
+
+	age := c.clock.Now().Sub(metadata.UpdatedAt)
+	switch {
+	case age <= maxAge:
+		return nil
+	case age <= maxAge+grace:
+		log.Logger.Warnf("The DB updated at %s is older than %s, which is tolerated for the grace period of %s",
+			metadata.UpdatedAt.Format(time.RFC3339), maxAge, grace)
+		return nil
+	}
+	return xerrors.Errorf("the DB updated at %s is older than %s", metadata.UpdatedAt.Format(time.RFC3339), maxAge)
+}
+
+// Download downloads the DB into the cache directory. The compressed file is written to a partial file first,
+// whose download is resumed when it's retried after an error, e.g. of a flaky proxy, or by the next run if it's
+// interrupted, as long as it's the same asset, and which is verified against the checksum published with it,
+// if any, before the DB is decompressed.
 func (c Client) Download(ctx context.Context, cacheDir string, light bool) error {
 	// Remove the metadata file before downloading DB
 	if err := c.metadata.Delete(); err != nil {
@@ -135,26 +183,44 @@ func (c Client) Download(ctx context.Context, cacheDir string, light bool) error
 		dbFile = lightDB
 	}
 
-	rc, size, err := c.githubClient.DownloadDB(ctx, dbFile)
-	if err != nil {
-		return xerrors.Errorf("failed to download vulnerability DB: %w", err)
+	dbPath := db.Path(cacheDir)
+	dbDir := filepath.Dir(dbPath)
+
+	if err := os.MkdirAll(dbDir, 0700); err != nil {
+		return xerrors.Errorf("failed to mkdir: %w", err)
 	}
-	defer rc.Close()
 
-	bar := c.pb.Start(int64(size))
-	barReader := bar.NewProxyReader(rc)
-	defer bar.Finish()
+	// the partial file of an interrupted run is resumed only if its asset is known, see downloadPartial
+	partialPath := filepath.Join(dbDir, dbFile+partialSuffix)
+	if id, err := ioutil.ReadFile(partialPath + assetIDSuffix); err != nil || len(id) == 0 {
+		if err = removePartial(partialPath); err != nil {
+			return err
+		}
+	}
 
-	gr, err := gzip.NewReader(barReader)
-	if err != nil {
-		return xerrors.Errorf("invalid gzip file: %w", err)
+	operation := func() error {
+		return c.downloadPartial(ctx, dbFile, partialPath)
+	}
+	b := backoff.WithContext(backoff.WithMaxRetries(utils.NewJitteredBackOff(downloadRetryInterval, 0, nil),
+		downloadRetries), ctx)
+	if err := backoff.RetryNotify(operation, b, func(err error, _ time.Duration) {
+		log.Logger.Warn(err)
+		log.Logger.Info("Retrying the DB download...")
+	}); err != nil {
+		// the partial file is kept for the next run
+		return err
 	}
+	defer removePartial(partialPath)
 
-	dbPath := db.Path(cacheDir)
-	dbDir := filepath.Dir(dbPath)
+	f, err := os.Open(partialPath)
+	if err != nil {
+		return xerrors.Errorf("unable to open the downloaded DB: %w", err)
+	}
+	defer f.Close()
 
-	if err = os.MkdirAll(dbDir, 0700); err != nil {
-		return xerrors.Errorf("failed to mkdir: %w", err)
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return xerrors.Errorf("invalid gzip file: %w", err)
 	}
 
 	file, err := os.Create(dbPath)
@@ -170,6 +236,113 @@ func (c Client) Download(ctx context.Context, cacheDir string, light bool) error
 	return nil
 }
 
+// downloadPartial downloads the rest of the partial file and verifies it once complete.
+// It's downloaded again from the beginning when it's of another asset, e.g. of the previous release.
+// The errors other than those of the network are permanent.
+func (c Client) downloadPartial(ctx context.Context, dbFile, partialPath string) error {
+	idPath := partialPath + assetIDSuffix
+	id, err := ioutil.ReadFile(idPath)
+	if err != nil && !os.IsNotExist(err) {
+		return backoff.Permanent(xerrors.Errorf("unable to read the asset of the partial download: %w", err))
+	}
+
+	f, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return backoff.Permanent(xerrors.Errorf("unable to open the partial download: %w", err))
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return backoff.Permanent(xerrors.Errorf("unable to read the partial download: %w", err))
+	}
+	if offset > 0 {
+		log.Logger.Infof("Resuming the DB download from %d bytes", offset)
+	}
+
+	asset, err := c.githubClient.DownloadDB(ctx, dbFile, offset)
+	if err != nil {
+		return xerrors.Errorf("failed to download vulnerability DB: %w", err)
+	}
+	if asset.Offset > 0 && asset.ID != string(id) {
+		asset.Close()
+		log.Logger.Info("The partial download is of another DB, downloading it again")
+		if asset, err = c.githubClient.DownloadDB(ctx, dbFile, 0); err != nil {
+			return xerrors.Errorf("failed to download vulnerability DB: %w", err)
+		}
+	}
+	defer asset.Close()
+	if err = ioutil.WriteFile(idPath, []byte(asset.ID), 0600); err != nil {
+		return backoff.Permanent(xerrors.Errorf("unable to write the asset of the partial download: %w", err))
+	}
+
+	// the download restarts from the beginning when the server doesn't resume it
+	if asset.Offset != offset {
+		if err = f.Truncate(asset.Offset); err != nil {
+			return backoff.Permanent(xerrors.Errorf("unable to truncate the partial download: %w", err))
+		}
+		if _, err = f.Seek(asset.Offset, io.SeekStart); err != nil {
+			return backoff.Permanent(xerrors.Errorf("unable to truncate the partial download: %w", err))
+		}
+	}
+
+	var total int64
+	if asset.Size > 0 {
+		total = asset.Size - asset.Offset
+	}
+	bar := c.pb.Start(total)
+	n, err := io.Copy(f, bar.NewProxyReader(asset))
+	bar.Finish()
+	if err != nil {
+		return xerrors.Errorf("DB download interrupted after %d bytes: %w", asset.Offset+n, err)
+	}
+	if asset.Size > 0 && asset.Offset+n != asset.Size {
+		return xerrors.Errorf("DB download interrupted after %d of %d bytes", asset.Offset+n, asset.Size)
+	}
+
+	if asset.SHA256 == "" {
+		log.Logger.Warnf("No checksum published with %s, the downloaded DB isn't verified", dbFile)
+		return nil
+	}
+	sum, err := fileSHA256(partialPath)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	if sum != asset.SHA256 {
+		// downloaded again from the beginning
+		if err = f.Truncate(0); err != nil {
+			return backoff.Permanent(xerrors.Errorf("unable to truncate the partial download: %w", err))
+		}
+		return xerrors.Errorf("checksum mismatch of the downloaded DB: expected %s, got %s", asset.SHA256, sum)
+	}
+	log.Logger.Debugf("Verified the checksum of the DB: %s", sum)
+	return nil
+}
+
+// removePartial removes the partial file and the ID of its asset
+func removePartial(partialPath string) error {
+	for _, filePath := range []string{partialPath, partialPath + assetIDSuffix} {
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("unable to remove the partial download: %w", err)
+		}
+	}
+	return nil
+}
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", xerrors.Errorf("unable to open the downloaded DB: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", xerrors.Errorf("unable to read the downloaded DB: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func (c Client) UpdateMetadata(cacheDir string) error {
 	log.Logger.Debug("Updating database metadata...")
 
@@ -196,6 +369,17 @@ type Metadata struct {
 	filePath string
 }
 
+// CheckLocalDB fails with ErrNoLocalDB when there is no DB in the cache directory, e.g. before scanning offline
+func CheckLocalDB(cacheDir string) error {
+	if _, err := os.Stat(filepath.Join(cacheDir, "db", "trivy.db")); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNoLocalDB
+		}
+		return xerrors.Errorf("unable to check the local DB: %w", err)
+	}
+	return nil
+}
+
 func NewMetadata(fs afero.Fs, cacheDir string) Metadata {
 	filePath := MetadataPath(cacheDir)
 	return Metadata{
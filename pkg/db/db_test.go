@@ -1,9 +1,13 @@
 package db
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -166,6 +170,68 @@ func TestClient_NeedsUpdate(t *testing.T) {
 	}
 }
 
+func TestClient_CheckAge(t *testing.T) {
+	updatedAt := time.Date(2019, 10, 4, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		now           time.Time
+		maxAge        time.Duration
+		grace         time.Duration
+		expectedError string
+	}{
+		{
+			name:   "fresh DB",
+			now:    updatedAt.Add(24 * time.Hour),
+			maxAge: 48 * time.Hour,
+		},
+		{
+			name:   "stale DB within the grace",
+			now:    updatedAt.Add(72 * time.Hour),
+			maxAge: 48 * time.Hour,
+			grace:  48 * time.Hour,
+		},
+		{
+			name:          "stale DB after the grace",
+			now:           updatedAt.Add(120 * time.Hour),
+			maxAge:        48 * time.Hour,
+			grace:         48 * time.Hour,
+			expectedError: "the DB updated at 2019-10-04T00:00:00Z is older than 48h0m0s",
+		},
+		{
+			name:          "stale DB without grace",
+			now:           updatedAt.Add(72 * time.Hour),
+			maxAge:        48 * time.Hour,
+			expectedError: "the DB updated at 2019-10-04T00:00:00Z is older than 48h0m0s",
+		},
+		{
+			name: "disabled",
+			now:  updatedAt.Add(720 * time.Hour),
+		},
+	}
+
+	require.NoError(t, log.InitLogger(false, true))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := NewMetadata(afero.NewMemMapFs(), "/cache")
+			require.NoError(t, metadata.Store(db.Metadata{Version: 1, Type: db.TypeFull, UpdatedAt: updatedAt}))
+
+			client := Client{
+				clock:    clocktesting.NewFakeClock(tc.now),
+				metadata: metadata,
+			}
+
+			err := client.CheckAge(tc.maxAge, tc.grace)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestClient_Download(t *testing.T) {
 	type getMetadataOutput struct {
 		metadata db.Metadata
@@ -227,10 +293,25 @@ func TestClient_Download(t *testing.T) {
 			},
 			expectedError: xerrors.New("invalid gzip file: unexpected EOF"),
 		},
+		{
+			name:  "happy path with checksum",
+			light: true,
+			downloadDB: []github.DownloadDBExpectation{
+				{
+					Args: github.DownloadDBInput{FileName: lightDB},
+					ReturnArgs: github.DownloadDBOutput{
+						FileName: "testdata/test.db.gz",
+						Size:     241,
+						SHA256:   "039a421bb3c95126a97311c1bbb90552ffa3bf1c288da3aa428989bca81fc037",
+					},
+				},
+			},
+		},
 	}
 
 	err := log.InitLogger(false, true)
 	require.NoError(t, err, "failed to init logger")
+	defer fastRetries()()
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -263,3 +344,163 @@ func TestClient_Download(t *testing.T) {
 		})
 	}
 }
+
+func fastRetries() func() {
+	retries, interval := downloadRetries, downloadRetryInterval
+	downloadRetries, downloadRetryInterval = 2, time.Millisecond
+	return func() {
+		downloadRetries, downloadRetryInterval = retries, interval
+	}
+}
+
+var errConnectionReset = xerrors.New("connection reset by peer")
+
+// flakyRepository resets the connection of the first download after some bytes, unless failAfter is 0,
+// and resumes the next ones
+type flakyRepository struct {
+	content   []byte
+	failAfter int
+	sha256    string
+	id        string
+	offsets   []int64
+}
+
+func (r *flakyRepository) DownloadDB(_ context.Context, _ string, offset int64) (github.Asset, error) {
+	r.offsets = append(r.offsets, offset)
+	var reader io.Reader = bytes.NewReader(r.content[offset:])
+	if len(r.offsets) == 1 && r.failAfter > 0 {
+		reader = io.MultiReader(bytes.NewReader(r.content[:r.failAfter]), errReader{err: errConnectionReset})
+	}
+	return github.Asset{ReadCloser: ioutil.NopCloser(reader), Offset: offset, Size: int64(len(r.content)),
+		SHA256: r.sha256, ID: r.id}, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestClient_Download_Resume(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+	defer fastRetries()()
+
+	content, err := ioutil.ReadFile("testdata/test.db.gz")
+	require.NoError(t, err)
+	gr, err := gzip.NewReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	want, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		sha256      string
+		wantOffsets []int64
+		wantErr     string
+	}{
+		{
+			name:        "resumed after the reset",
+			sha256:      "039a421bb3c95126a97311c1bbb90552ffa3bf1c288da3aa428989bca81fc037",
+			wantOffsets: []int64{0, 100},
+		},
+		{
+			name:        "downloaded again after a checksum mismatch",
+			sha256:      "0000000000000000000000000000000000000000000000000000000000000000",
+			wantOffsets: []int64{0, 100, 0},
+			wantErr:     "checksum mismatch of the downloaded DB: expected 0000000000000000000000000000000000000000000000000000000000000000, got 039a421bb3c95126a97311c1bbb90552ffa3bf1c288da3aa428989bca81fc037",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "db")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			repo := &flakyRepository{content: content, failAfter: 100, sha256: tt.sha256}
+			client := NewClient(nil, repo, indicator.NewProgressBar(true), nil, NewMetadata(afero.NewMemMapFs(), "/cache"))
+			err = client.Download(context.Background(), dir, false)
+			assert.Equal(t, tt.wantOffsets, repo.offsets)
+			partial, statErr := os.Stat(filepath.Join(dir, "db", fullDB+partialSuffix))
+			if tt.wantErr != "" {
+				require.NoError(t, statErr, "the partial file is kept for the next run")
+				assert.Zero(t, partial.Size(), "the partial file is truncated")
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, os.IsNotExist(statErr), "the partial file is removed")
+
+			got, err := ioutil.ReadFile(db.Path(dir))
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestClient_Download_ResumeNextRun(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	content, err := ioutil.ReadFile("testdata/test.db.gz")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		noPartialID bool
+		partialID   string
+		id          string
+		wantOffsets []int64
+	}{
+		{
+			name:        "the same asset",
+			partialID:   "aquasecurity/trivy-db@v1-2020123123/100",
+			id:          "aquasecurity/trivy-db@v1-2020123123/100",
+			wantOffsets: []int64{100},
+		},
+		{
+			name:        "another asset",
+			partialID:   "aquasecurity/trivy-db@v1-2020123100/99",
+			id:          "aquasecurity/trivy-db@v1-2020123123/100",
+			wantOffsets: []int64{100, 0},
+		},
+		{
+			name:        "an unknown asset",
+			wantOffsets: []int64{0},
+		},
+		{
+			name:        "no asset",
+			noPartialID: true,
+			id:          "aquasecurity/trivy-db@v1-2020123123/100",
+			wantOffsets: []int64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "db")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			// the partial file of an interrupted run
+			partialPath := filepath.Join(dir, "db", fullDB+partialSuffix)
+			require.NoError(t, os.MkdirAll(filepath.Dir(partialPath), 0700))
+			require.NoError(t, ioutil.WriteFile(partialPath, content[:100], 0600))
+			if !tt.noPartialID {
+				require.NoError(t, ioutil.WriteFile(partialPath+assetIDSuffix, []byte(tt.partialID), 0600))
+			}
+
+			repo := &flakyRepository{content: content, id: tt.id}
+			client := NewClient(nil, repo, indicator.NewProgressBar(true), nil, NewMetadata(afero.NewMemMapFs(), "/cache"))
+			require.NoError(t, client.Download(context.Background(), dir, false))
+			assert.Equal(t, tt.wantOffsets, repo.offsets)
+
+			_, err = os.Stat(db.Path(dir))
+			require.NoError(t, err)
+			for _, filePath := range []string{partialPath, partialPath + assetIDSuffix} {
+				_, err = os.Stat(filePath)
+				assert.True(t, os.IsNotExist(err), filePath)
+			}
+		})
+	}
+}
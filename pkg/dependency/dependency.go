@@ -0,0 +1,98 @@
+package dependency
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+// Graph is the dependency graph of a lock file. The packages are identified by ID, e.g. "qs@6.5.1".
+type Graph struct {
+	// Roots are the direct dependencies of the project
+	Roots []string
+	// Dependencies maps a package to the packages it requires
+	Dependencies map[string][]string
+}
+
+// ID returns the ID of the package in the graph
+func ID(name, version string) string {
+	return name + "@" + version
+}
+
+// Supported reports whether the dependency graph of the lock file can be parsed
+func Supported(filePath string) bool {
+	return filepath.Base(filePath) == "package-lock.json"
+}
+
+// ParseFile parses the dependency graph of the lock file, see Supported
+func ParseFile(filePath string) (Graph, error) {
+	if !Supported(filePath) {
+		return Graph{}, xerrors.Errorf("no dependency graph of %s", filepath.Base(filePath))
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Graph{}, xerrors.Errorf("unable to open %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	g, err := parseNpm(f, filepath.Join(filepath.Dir(filePath), "package.json"))
+	if err != nil {
+		return Graph{}, xerrors.Errorf("invalid %s: %w", filePath, err)
+	}
+	return g, nil
+}
+
+// Path returns the shortest chain of the packages from a direct dependency to the package, included at both ends,
+// e.g. ["express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"], or nil when no direct dependency requires it
+func (g Graph) Path(id string) []string {
+	parents := map[string]string{}
+	var queue []string
+	for _, root := range g.Roots {
+		if _, ok := parents[root]; !ok {
+			parents[root] = ""
+			queue = append(queue, root)
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == id {
+			var path []string
+			for p := current; p != ""; p = parents[p] {
+				path = append([]string{p}, path...)
+			}
+			return path
+		}
+		for _, dep := range g.Dependencies[current] {
+			if _, ok := parents[dep]; !ok {
+				parents[dep] = current
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return nil
+}
+
+// sortDependencies removes the duplicates of the packages installed at several places and sorts them
+func (g Graph) sortDependencies() {
+	for id, deps := range g.Dependencies {
+		set := map[string]bool{}
+		for _, dep := range deps {
+			set[dep] = true
+		}
+		g.Dependencies[id] = sortedIDs(set)
+	}
+}
+
+// sortedIDs returns the IDs of the set in order, so that the paths are the same across runs
+func sortedIDs(set map[string]bool) []string {
+	var ids []string
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
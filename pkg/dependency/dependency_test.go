@@ -0,0 +1,74 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		filePath  string
+		wantRoots []string
+		wantPaths map[string][]string
+		wantErr   string
+	}{
+		{
+			name:      "lockfile version 1 with package.json",
+			filePath:  "testdata/v1/package-lock.json",
+			wantRoots: []string{"express@4.16.0", "mocha@7.0.0", "qs@6.5.2"},
+			wantPaths: map[string][]string{
+				"qs@6.5.1":       {"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+				"qs@6.5.2":       {"qs@6.5.2"},
+				"bytes@3.0.0":    {"express@4.16.0", "body-parser@1.18.2", "bytes@3.0.0"},
+				"lodash@4.17.15": nil,
+			},
+		},
+		{
+			name:      "lockfile version 1 without package.json",
+			filePath:  "testdata/v1-without-manifest/package-lock.json",
+			wantRoots: []string{"express@4.16.0", "mocha@7.0.0"},
+			wantPaths: map[string][]string{
+				"qs@6.5.1": {"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+				"qs@6.5.2": {"express@4.16.0", "qs@6.5.2"},
+			},
+		},
+		{
+			name:      "lockfile version 2",
+			filePath:  "testdata/v2/package-lock.json",
+			wantRoots: []string{"express@4.16.0", "mocha@7.0.0", "qs@6.5.2"},
+			wantPaths: map[string][]string{
+				"qs@6.5.1":    {"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+				"qs@6.5.2":    {"qs@6.5.2"},
+				"bytes@3.0.0": {"express@4.16.0", "body-parser@1.18.2", "bytes@3.0.0"},
+			},
+		},
+		{
+			name:     "unsupported lock file",
+			filePath: "testdata/yarn.lock",
+			wantErr:  "no dependency graph of yarn.lock",
+		},
+		{
+			name:     "missing lock file",
+			filePath: "testdata/missing/package-lock.json",
+			wantErr:  "unable to open",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ParseFile(tt.filePath)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRoots, g.Roots)
+			for id, want := range tt.wantPaths {
+				assert.Equal(t, want, g.Path(id), id)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package dependency
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// packageLock is package-lock.json: the nested dependencies of lockfile version 1,
+// or the packages keyed by their node_modules path of lockfile versions 2 and 3
+type packageLock struct {
+	Dependencies map[string]lockDependency `json:"dependencies"`
+	Packages     map[string]lockPackage    `json:"packages"`
+}
+
+type lockDependency struct {
+	Version      string                    `json:"version"`
+	Requires     map[string]string         `json:"requires"`
+	Dependencies map[string]lockDependency `json:"dependencies"`
+}
+
+type lockPackage struct {
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// packageJSON is the manifest of the project, listing the direct dependencies of lockfile version 1
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// parseNpm parses package-lock.json. Lockfile version 1 doesn't list the direct dependencies,
+// which are read from the package.json next to it, or are the packages no other requires without it.
+func parseNpm(r io.Reader, manifestPath string) (Graph, error) {
+	var lock packageLock
+	if err := json.NewDecoder(r).Decode(&lock); err != nil {
+		return Graph{}, xerrors.Errorf("failed to decode package-lock.json: %w", err)
+	}
+	if len(lock.Packages) > 0 {
+		return parseNpmPackages(lock.Packages), nil
+	}
+
+	var manifest *packageJSON
+	b, err := ioutil.ReadFile(manifestPath)
+	if err == nil {
+		manifest = &packageJSON{}
+		if err = json.Unmarshal(b, manifest); err != nil {
+			return Graph{}, xerrors.Errorf("failed to decode package.json: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return Graph{}, xerrors.Errorf("unable to read package.json: %w", err)
+	}
+	return parseNpmDependencies(lock.Dependencies, manifest), nil
+}
+
+// parseNpmPackages resolves the dependencies of each package as Node.js does,
+// from its own node_modules up to the top-level one
+func parseNpmPackages(packages map[string]lockPackage) Graph {
+	resolve := func(dir, name string) (string, bool) {
+		for {
+			key := "node_modules/" + name
+			if dir != "" {
+				key = dir + "/" + key
+			}
+			if pkg, ok := packages[key]; ok {
+				return ID(pkgName(key), pkg.Version), true
+			}
+			if dir == "" {
+				return "", false
+			}
+			if i := strings.LastIndex(dir, "/node_modules/"); i >= 0 {
+				dir = dir[:i]
+			} else {
+				dir = ""
+			}
+		}
+	}
+	resolveAll := func(dir string, deps ...map[string]string) []string {
+		set := map[string]bool{}
+		for _, d := range deps {
+			for name := range d {
+				if id, ok := resolve(dir, name); ok {
+					set[id] = true
+				}
+			}
+		}
+		return sortedIDs(set)
+	}
+
+	g := Graph{Dependencies: map[string][]string{}}
+	for key, pkg := range packages {
+		if key == "" {
+			g.Roots = resolveAll("", pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies)
+			continue
+		}
+		if !strings.Contains(key, "node_modules/") {
+			// the workspaces aren't packages of the registry
+			continue
+		}
+		id := ID(pkgName(key), pkg.Version)
+		g.Dependencies[id] = append(g.Dependencies[id],
+			resolveAll(key, pkg.Dependencies, pkg.OptionalDependencies, pkg.PeerDependencies)...)
+	}
+	g.sortDependencies()
+	return g
+}
+
+// pkgName returns the name of the package of the node_modules path, e.g. @babel/core of node_modules/@babel/core
+func pkgName(key string) string {
+	i := strings.LastIndex(key, "node_modules/")
+	return key[i+len("node_modules/"):]
+}
+
+// parseNpmDependencies resolves the requires of each dependency from its nested dependencies up to the top-level ones
+func parseNpmDependencies(deps map[string]lockDependency, manifest *packageJSON) Graph {
+	g := Graph{Dependencies: map[string][]string{}}
+	required := map[string]bool{}
+
+	var walk func(scopes []map[string]lockDependency)
+	walk = func(scopes []map[string]lockDependency) {
+		for name, dep := range scopes[0] {
+			id := ID(name, dep.Version)
+			inner := append([]map[string]lockDependency{dep.Dependencies}, scopes...)
+			set := map[string]bool{}
+			for req := range dep.Requires {
+				for _, scope := range inner {
+					if d, ok := scope[req]; ok {
+						set[ID(req, d.Version)] = true
+						required[ID(req, d.Version)] = true
+						break
+					}
+				}
+			}
+			g.Dependencies[id] = append(g.Dependencies[id], sortedIDs(set)...)
+			if len(dep.Dependencies) > 0 {
+				walk(inner)
+			}
+		}
+	}
+	walk([]map[string]lockDependency{deps})
+
+	roots := map[string]bool{}
+	if manifest != nil {
+		for _, d := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.OptionalDependencies} {
+			for name := range d {
+				if dep, ok := deps[name]; ok {
+					roots[ID(name, dep.Version)] = true
+				}
+			}
+		}
+	} else {
+		for name, dep := range deps {
+			if id := ID(name, dep.Version); !required[id] {
+				roots[id] = true
+			}
+		}
+	}
+	g.Roots = sortedIDs(roots)
+	g.sortDependencies()
+	return g
+}
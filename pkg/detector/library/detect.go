@@ -13,6 +13,7 @@ import (
 
 	"golang.org/x/xerrors"
 
+	"github.com/aquasecurity/trivy/pkg/advisory"
 	"github.com/aquasecurity/trivy/pkg/types"
 )
 
@@ -50,27 +51,99 @@ func (d Detector) Detect(_, filePath string, _ time.Time, pkgs []ftypes.LibraryI
 	return vulns, nil
 }
 
+// DetectType is Detect selecting the driver by the application type, for the applications
+// whose file name doesn't tell it, e.g. Go binaries. The other types fall back to the file name.
+func (d Detector) DetectType(appType, filePath string, pkgs []ftypes.LibraryInfo) ([]types.DetectedVulnerability, error) {
+	driver := newTypedDriver(appType)
+	if driver == nil {
+		return d.Detect("", filePath, time.Time{}, pkgs)
+	}
+	log.Logger.Debugf("Detecting library vulnerabilities, path: %s", filePath)
+
+	vulns, err := detect(driver, pkgs)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to scan %s vulnerabilities: %w", driver.Type(), err)
+	}
+	return vulns, nil
+}
+
 func detect(driver Driver, libs []ftypes.LibraryInfo) ([]types.DetectedVulnerability, error) {
 	log.Logger.Infof("Detecting %s vulnerabilities...", driver.Type())
 	var vulnerabilities []types.DetectedVulnerability
+	extractor := versionExtractor(driver.Type())
 	for _, lib := range libs {
-		v, err := version.NewVersion(lib.Library.Version)
+		v, confidence, err := parseVersion(extractor, lib.Library.Version)
 		if err != nil {
-			log.Logger.Debugf("invalid version, library: %s, version: %s, error: %s\n",
+			// the library is still listed with --list-all-pkgs, only its vulnerabilities aren't detected
+			log.Logger.Warnf("The vulnerabilities of %s %s aren't detected, unable to parse the version: %s",
 				lib.Library.Name, lib.Library.Version, err)
 			continue
 		}
 
-		vulns, err := driver.Detect(lib.Library.Name, v)
+		name := matchName(driver.Type(), lib.Library.Name)
+		vulns, err := driver.Detect(name, v)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to detect %s vulnerabilities: %w", driver.Type(), err)
 		}
 
+		supplementary, err := advisory.Detect(ecosystemOf(driver), name, v, vulns)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to detect %s vulnerabilities in the supplementary advisories: %w",
+				driver.Type(), err)
+		}
+		vulns = append(vulns, supplementary...)
+
 		for i := range vulns {
+			// keep the name as installed even when matched case-insensitively
+			vulns[i].PkgName = lib.Library.Name
 			vulns[i].Layer = lib.Layer
+			vulns[i].MatchConfidence = confidence
+			if extractor != nil || confidence == types.MatchConfidenceVersionRange {
+				vulns[i].InstalledVersion = lib.Library.Version
+			}
 		}
 		vulnerabilities = append(vulnerabilities, vulns...)
 	}
 
 	return vulnerabilities, nil
 }
+
+// parseVersion parses the version normalized by the extractor.
+// When the extractor fails, the raw version is parsed and the match confidence is low.
+func parseVersion(extractor VersionExtractor, rawVersion string) (*version.Version, string, error) {
+	if extractor == nil {
+		v, err := version.NewVersion(rawVersion)
+		if err != nil {
+			if lower, ok := rangeLowerBound(rawVersion); ok {
+				if v, err = version.NewVersion(lower); err == nil {
+					return v, types.MatchConfidenceVersionRange, nil
+				}
+			}
+		}
+		return v, "", err
+	}
+
+	normalized, err := extractor(rawVersion)
+	if err == nil {
+		var v *version.Version
+		if v, err = version.NewVersion(normalized); err == nil {
+			return v, "", nil
+		}
+	}
+	log.Logger.Debugf("failed to extract the version from %s: %s", rawVersion, err)
+
+	v, err := version.NewVersion(rawVersion)
+	if err == nil {
+		return v, types.MatchConfidenceLow, nil
+	}
+
+	// the detected vulnerabilities of the lower bound may be fixed in the version actually installed
+	lower, ok := rangeLowerBound(rawVersion)
+	if !ok {
+		return nil, "", err
+	}
+	if v, err = version.NewVersion(lower); err != nil {
+		return nil, "", err
+	}
+	return v, types.MatchConfidenceVersionRange, nil
+}
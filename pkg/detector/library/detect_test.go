@@ -0,0 +1,358 @@
+package library
+
+import (
+	"os"
+	"testing"
+
+	"github.com/knqyf263/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/advisory"
+	"github.com/aquasecurity/trivy/pkg/customanalyzer"
+	"github.com/aquasecurity/trivy/pkg/detector/library/node"
+	"github.com/aquasecurity/trivy/pkg/gobinary"
+	"github.com/aquasecurity/trivy/pkg/jar"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.InitLogger(false, true)
+	os.Exit(m.Run())
+}
+
+// fakeDriver detects a vulnerability in the versions before 1.3.0
+type fakeDriver struct{}
+
+func (fakeDriver) ParseLockfile(*os.File) ([]ptypes.Library, error) {
+	return nil, nil
+}
+
+func (fakeDriver) Detect(pkgName string, pkgVer *version.Version) ([]types.DetectedVulnerability, error) {
+	if !pkgVer.LessThan(version.Must(version.NewVersion("1.3.0"))) {
+		return nil, nil
+	}
+	return []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "CVE-2020-0001",
+			PkgName:          pkgName,
+			InstalledVersion: pkgVer.String(),
+			FixedVersion:     "1.3.0",
+		},
+	}, nil
+}
+
+func (fakeDriver) Type() string {
+	return "fake"
+}
+
+func TestDetect_VersionExtractor(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor VersionExtractor
+		version   string
+		want      []types.DetectedVulnerability
+	}{
+		{
+			name:    "no extractor",
+			version: "1.2.3",
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "foo", InstalledVersion: "1.2.3", FixedVersion: "1.3.0"},
+			},
+		},
+		{
+			name:      "git hash normalized",
+			extractor: GitDescribeVersion,
+			version:   "v1.2.3-14-g2414721",
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "foo", InstalledVersion: "v1.2.3-14-g2414721", FixedVersion: "1.3.0"},
+			},
+		},
+		{
+			name:      "git hash normalized to a fixed version",
+			extractor: GitDescribeVersion,
+			version:   "v1.3.0-2-g2414721",
+		},
+		{
+			name: "extraction failure",
+			extractor: func(string) (string, error) {
+				return "", xerrors.New("error")
+			},
+			version: "1.2.3",
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-0001",
+					PkgName:          "foo",
+					InstalledVersion: "1.2.3",
+					FixedVersion:     "1.3.0",
+					MatchConfidence:  types.MatchConfidenceLow,
+				},
+			},
+		},
+		{
+			name: "invalid version",
+			extractor: func(string) (string, error) {
+				return "", xerrors.New("error")
+			},
+			version: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RegisterVersionExtractor("fake", tt.extractor)
+			defer RegisterVersionExtractor("fake", nil)
+
+			got, err := detect(fakeDriver{}, []ftypes.LibraryInfo{
+				{Library: ptypes.Library{Name: "foo", Version: tt.version}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetect_VersionRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    []types.DetectedVulnerability
+	}{
+		{
+			name:    "caret range",
+			version: "^1.2.3",
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-0001",
+					PkgName:          "foo",
+					InstalledVersion: "^1.2.3",
+					FixedVersion:     "1.3.0",
+					MatchConfidence:  types.MatchConfidenceVersionRange,
+				},
+			},
+		},
+		{
+			name:    "wildcard",
+			version: "1.x",
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-0001",
+					PkgName:          "foo",
+					InstalledVersion: "1.x",
+					FixedVersion:     "1.3.0",
+					MatchConfidence:  types.MatchConfidenceVersionRange,
+				},
+			},
+		},
+		{
+			name:    "lower bound fixed",
+			version: ">=1.3.1 <2.0.0",
+		},
+		{
+			name:    "no lower bound",
+			version: "*",
+		},
+		{
+			name:    "exact version",
+			version: "1.2.3",
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "foo", InstalledVersion: "1.2.3", FixedVersion: "1.3.0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detect(fakeDriver{}, []ftypes.LibraryInfo{
+				{Library: ptypes.Library{Name: "foo", Version: tt.version}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGitDescribeVersion(t *testing.T) {
+	tests := []struct {
+		rawVersion string
+		want       string
+		wantErr    string
+	}{
+		{rawVersion: "v1.2.3-14-g2414721", want: "1.2.3+14.g2414721"},
+		{rawVersion: "1.2.3-14-g2414721-dirty", want: "1.2.3+14.g2414721"},
+		{rawVersion: "v2.0", want: "2.0"},
+		{rawVersion: "2414721abcdef", wantErr: "no version tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rawVersion, func(t *testing.T) {
+			got, err := GitDescribeVersion(tt.rawVersion)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// pypiDriver has an advisory for "django" only
+type pypiDriver struct {
+	fakeDriver
+}
+
+func (pypiDriver) Detect(pkgName string, pkgVer *version.Version) ([]types.DetectedVulnerability, error) {
+	if pkgName != "django" {
+		return nil, nil
+	}
+	return fakeDriver{}.Detect(pkgName, pkgVer)
+}
+
+func (pypiDriver) Type() string {
+	return "pipenv"
+}
+
+func TestDetect_CaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name          string
+		caseSensitive *bool
+		want          []types.DetectedVulnerability
+	}{
+		{
+			name: "PyPI is case-insensitive by default",
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "Django", InstalledVersion: "1.2.3", FixedVersion: "1.3.0"},
+			},
+		},
+		{
+			name:          "configured as case-sensitive",
+			caseSensitive: boolPtr(true),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.caseSensitive != nil {
+				SetCaseSensitive("pipenv", *tt.caseSensitive)
+				defer SetCaseSensitive("pipenv", false)
+			}
+
+			got, err := detect(pypiDriver{}, []ftypes.LibraryInfo{
+				{Library: ptypes.Library{Name: "Django", Version: "1.2.3"}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestDetect_SupplementaryAdvisories(t *testing.T) {
+	s, err := advisory.LoadOSVDir("testdata/osv")
+	require.NoError(t, err)
+	advisory.Register(s)
+	defer advisory.Deregister(s.Name())
+
+	got, err := detect(pypiDriver{}, []ftypes.LibraryInfo{
+		{Library: ptypes.Library{Name: "Django", Version: "1.2.3"}},
+		{Library: ptypes.Library{Name: "Acme-Client", Version: "1.1"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2020-0001", PkgName: "Django", InstalledVersion: "1.2.3", FixedVersion: "1.3.0"},
+		{
+			VulnerabilityID:  "ACME-2020-0002",
+			PkgName:          "Acme-Client",
+			InstalledVersion: "1.1.0",
+			FixedVersion:     "1.2",
+			DataSource:       "osv:testdata/osv",
+			Vulnerability:    dbTypes.Vulnerability{Title: "Insecure default of Acme-Client", Severity: "MEDIUM"},
+		},
+	}, got, "the advisory of Django is already detected with the DB")
+}
+
+func TestDetect_CustomAnalyzer(t *testing.T) {
+	s, err := advisory.LoadOSVDir("testdata/osv")
+	require.NoError(t, err)
+	advisory.Register(s)
+	defer advisory.Deregister(s.Name())
+
+	assert.Equal(t, node.ScannerTypeNpm, newTypedDriver(customanalyzer.Type("acme", "npm")).Type(),
+		"the ecosystems of the DB are detected by their drivers")
+
+	driver := newTypedDriver(customanalyzer.Type("acme", "Hex"))
+	require.NotNil(t, driver)
+	got, err := detect(driver, []ftypes.LibraryInfo{
+		{Library: ptypes.Library{Name: "acme_plug", Version: "2.0.0"}},
+		{Library: ptypes.Library{Name: "acme_json", Version: "1.0.0"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "ACME-2020-0004",
+			PkgName:          "acme_plug",
+			InstalledVersion: "2.0.0",
+			FixedVersion:     "2.0.1",
+			DataSource:       "osv:testdata/osv",
+			Vulnerability:    dbTypes.Vulnerability{Title: "Path traversal in acme_plug", Severity: "HIGH"},
+		},
+	}, got)
+}
+
+func TestDetect_JavaArchives(t *testing.T) {
+	s, err := advisory.LoadOSVDir("testdata/osv")
+	require.NoError(t, err)
+	advisory.Register(s)
+	defer advisory.Deregister(s.Name())
+
+	// the Maven artifacts are only matched by the supplementary advisories
+	driver := newTypedDriver(jar.Type)
+	require.NotNil(t, driver)
+	got, err := detect(driver, []ftypes.LibraryInfo{
+		{Library: ptypes.Library{Name: "com.example:acme-logging", Version: "2.14.1"}},
+		{Library: ptypes.Library{Name: "com.example:acme-logging", Version: "2.15.0"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "ACME-2021-0005",
+			PkgName:          "com.example:acme-logging",
+			InstalledVersion: "2.14.1",
+			FixedVersion:     "2.15.0",
+			DataSource:       "osv:testdata/osv",
+			Vulnerability:    dbTypes.Vulnerability{Title: "Remote code execution in acme-logging", Severity: "CRITICAL"},
+		},
+	}, got)
+}
+
+func TestDetect_GoBinaries(t *testing.T) {
+	s, err := advisory.LoadOSVDir("testdata/osv")
+	require.NoError(t, err)
+	advisory.Register(s)
+	defer advisory.Deregister(s.Name())
+
+	// the Go modules are only matched by the supplementary advisories
+	driver := newTypedDriver(gobinary.Type)
+	require.NotNil(t, driver)
+	got, err := detect(driver, []ftypes.LibraryInfo{
+		{Library: ptypes.Library{Name: "example.com/acme/router", Version: "v1.4.1"}},
+		{Library: ptypes.Library{Name: "example.com/acme/router", Version: "v1.4.2"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "ACME-2022-0006",
+			PkgName:          "example.com/acme/router",
+			InstalledVersion: "1.4.1",
+			FixedVersion:     "1.4.2",
+			DataSource:       "osv:testdata/osv",
+			Vulnerability:    dbTypes.Vulnerability{Title: "Path traversal in acme/router", Severity: "HIGH"},
+		},
+	}, got)
+}
@@ -4,11 +4,15 @@ import (
 	"os"
 
 	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/advisory"
+	"github.com/aquasecurity/trivy/pkg/customanalyzer"
 	"github.com/aquasecurity/trivy/pkg/detector/library/bundler"
 	"github.com/aquasecurity/trivy/pkg/detector/library/cargo"
 	"github.com/aquasecurity/trivy/pkg/detector/library/composer"
 	"github.com/aquasecurity/trivy/pkg/detector/library/node"
 	"github.com/aquasecurity/trivy/pkg/detector/library/python"
+	"github.com/aquasecurity/trivy/pkg/gobinary"
+	"github.com/aquasecurity/trivy/pkg/jar"
 	"github.com/aquasecurity/trivy/pkg/types"
 	"github.com/knqyf263/go-version"
 )
@@ -48,3 +52,19 @@ func (d DriverFactory) NewDriver(filename string) Driver {
 	}
 	return scanner
 }
+
+// newTypedDriver returns the driver of the application types not given by the file name
+func newTypedDriver(appType string) Driver {
+	if ecosystem, ok := customanalyzer.Ecosystem(appType); ok {
+		return newEcosystemDriver(ecosystem)
+	}
+	switch appType {
+	case gobinary.Type:
+		// the DB has no advisories of the Go modules, they are matched by the supplementary advisories only
+		return advisoryDriver{ecosystem: advisory.EcosystemGo}
+	case jar.Type:
+		// the DB has no advisories of the Maven artifacts, they are matched by the supplementary advisories only
+		return advisoryDriver{ecosystem: advisory.EcosystemMaven}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package library
+
+import (
+	"os"
+
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/knqyf263/go-version"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/advisory"
+	"github.com/aquasecurity/trivy/pkg/detector/library/bundler"
+	"github.com/aquasecurity/trivy/pkg/detector/library/cargo"
+	"github.com/aquasecurity/trivy/pkg/detector/library/composer"
+	"github.com/aquasecurity/trivy/pkg/detector/library/node"
+	"github.com/aquasecurity/trivy/pkg/detector/library/python"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// ecosystems maps the driver types to the ecosystems of the supplementary advisories, see advisory.Source
+var ecosystems = map[string]string{
+	node.ScannerTypeNpm:      advisory.EcosystemNpm,
+	node.ScannerTypeYarn:     advisory.EcosystemNpm,
+	python.ScannerTypePipenv: advisory.EcosystemPyPI,
+	python.ScannerTypePoetry: advisory.EcosystemPyPI,
+	"bundler":                advisory.EcosystemRubyGems,
+	"cargo":                  advisory.EcosystemCratesIO,
+	"composer":               advisory.EcosystemPackagist,
+}
+
+// newEcosystemDriver returns the driver of the libraries of the ecosystem, e.g. detected by a custom analyzer.
+// The ecosystems without vulnerabilities in the DB only match the supplementary advisories.
+func newEcosystemDriver(ecosystem string) Driver {
+	switch ecosystem {
+	case advisory.EcosystemNpm:
+		return node.NewScanner(node.ScannerTypeNpm)
+	case advisory.EcosystemPyPI:
+		return python.NewScanner(python.ScannerTypePipenv)
+	case advisory.EcosystemRubyGems:
+		return bundler.NewScanner()
+	case advisory.EcosystemCratesIO:
+		return cargo.NewScanner()
+	case advisory.EcosystemPackagist:
+		return composer.NewScanner()
+	}
+	return advisoryDriver{ecosystem: ecosystem}
+}
+
+// ecosystemOf returns the ecosystem of the supplementary advisories of the driver
+func ecosystemOf(driver Driver) string {
+	if d, ok := driver.(advisoryDriver); ok {
+		return d.ecosystem
+	}
+	return ecosystems[driver.Type()]
+}
+
+// advisoryDriver is the driver of an ecosystem without vulnerabilities in the DB
+type advisoryDriver struct {
+	ecosystem string
+}
+
+func (d advisoryDriver) ParseLockfile(*os.File) ([]ptypes.Library, error) {
+	return nil, xerrors.Errorf("%s has no lock file", d.ecosystem)
+}
+
+func (d advisoryDriver) Detect(string, *version.Version) ([]types.DetectedVulnerability, error) {
+	return nil, nil
+}
+
+func (d advisoryDriver) Type() string {
+	return d.ecosystem
+}
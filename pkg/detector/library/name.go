@@ -0,0 +1,36 @@
+package library
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/trivy/pkg/detector/library/python"
+)
+
+var (
+	caseMu sync.RWMutex
+	// caseInsensitiveEcosystems are the ecosystems whose registries treat package names case-insensitively
+	caseInsensitiveEcosystems = map[string]bool{
+		python.ScannerTypePipenv: true,
+		python.ScannerTypePoetry: true,
+		"composer":               true,
+	}
+)
+
+// SetCaseSensitive configures whether the package names of an ecosystem, e.g. "npm" or "pipenv", are matched
+// case-sensitively against the advisories. PyPI and Packagist ecosystems are case-insensitive by default.
+func SetCaseSensitive(ecosystem string, caseSensitive bool) {
+	caseMu.Lock()
+	defer caseMu.Unlock()
+	caseInsensitiveEcosystems[ecosystem] = !caseSensitive
+}
+
+// matchName returns the name looked up in the advisories of the ecosystem
+func matchName(ecosystem, pkgName string) string {
+	caseMu.RLock()
+	defer caseMu.RUnlock()
+	if caseInsensitiveEcosystems[ecosystem] {
+		return strings.ToLower(pkgName)
+	}
+	return pkgName
+}
@@ -0,0 +1,70 @@
+package library
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// VersionExtractor normalizes a raw version of a library into a comparable version
+type VersionExtractor func(rawVersion string) (string, error)
+
+var (
+	extractorsMu      sync.RWMutex
+	versionExtractors = map[string]VersionExtractor{}
+)
+
+// RegisterVersionExtractor registers the version extractor of an ecosystem, e.g. "npm" or "bundler".
+// Nil removes the extractor of the ecosystem.
+func RegisterVersionExtractor(ecosystem string, extractor VersionExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	if extractor == nil {
+		delete(versionExtractors, ecosystem)
+		return
+	}
+	versionExtractors[ecosystem] = extractor
+}
+
+func versionExtractor(ecosystem string) VersionExtractor {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	return versionExtractors[ecosystem]
+}
+
+// e.g. ^1.2.3, ~1.2, >=1.0.0 <2.0.0, 1.x or 1.2.*
+var versionRangeRegexp = regexp.MustCompile(`^(?:\^|~>?|>=|=)?\s*v?(\d+(?:\.(?:\d+|[xX*]))*)`)
+
+// rangeLowerBound returns the lowest version of a version range, e.g. ^1.2.3 => 1.2.3 or 1.x => 1.0.
+// A range without lower bound, e.g. * or <2.0.0, isn't resolved.
+func rangeLowerBound(rawVersion string) (string, bool) {
+	m := versionRangeRegexp.FindStringSubmatch(strings.TrimSpace(rawVersion))
+	if m == nil {
+		return "", false
+	}
+	parts := strings.Split(m[1], ".")
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			parts[i] = "0"
+		}
+	}
+	return strings.Join(parts, "."), true
+}
+
+// e.g. v1.2.3-14-g2414721
+var gitDescribeRegexp = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:-(\d+)-g([0-9a-f]{7,40}))?(?:-dirty)?$`)
+
+// GitDescribeVersion extracts the version of the tag from the output of "git describe",
+// e.g. v1.2.3-14-g2414721 => 1.2.3+14.g2414721
+func GitDescribeVersion(rawVersion string) (string, error) {
+	m := gitDescribeRegexp.FindStringSubmatch(rawVersion)
+	if m == nil {
+		return "", xerrors.Errorf("no version tag in %s", rawVersion)
+	}
+	if m[2] == "" {
+		return m[1], nil
+	}
+	return m[1] + "+" + m[2] + ".g" + m[3], nil
+}
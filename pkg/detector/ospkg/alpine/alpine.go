@@ -37,6 +37,13 @@ var (
 		"3.9":  time.Date(2020, 11, 1, 23, 59, 59, 0, time.UTC),
 		"3.10": time.Date(2021, 5, 1, 23, 59, 59, 0, time.UTC),
 		"3.11": time.Date(2021, 11, 1, 23, 59, 59, 0, time.UTC),
+		"3.12": time.Date(2022, 5, 1, 23, 59, 59, 0, time.UTC),
+		"3.13": time.Date(2022, 11, 1, 23, 59, 59, 0, time.UTC),
+		"3.14": time.Date(2023, 5, 1, 23, 59, 59, 0, time.UTC),
+		"3.15": time.Date(2023, 11, 1, 23, 59, 59, 0, time.UTC),
+		"3.16": time.Date(2024, 5, 23, 23, 59, 59, 0, time.UTC),
+		"3.17": time.Date(2024, 11, 22, 23, 59, 59, 0, time.UTC),
+		"3.18": time.Date(2025, 5, 9, 23, 59, 59, 0, time.UTC),
 	}
 )
 
@@ -51,6 +58,16 @@ func NewScanner() *Scanner {
 }
 
 func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedVulnerability, error) {
+	return s.detect(osVer, pkgs, false)
+}
+
+// DetectRollup is Detect also matching the advisories of the major version, e.g. alpine 3 for alpine 3.11.
+// The advisory of the minor version wins when both have the same vulnerability.
+func (s *Scanner) DetectRollup(osVer string, pkgs []ftypes.Package) ([]types.DetectedVulnerability, error) {
+	return s.detect(osVer, pkgs, true)
+}
+
+func (s *Scanner) detect(osVer string, pkgs []ftypes.Package, rollup bool) ([]types.DetectedVulnerability, error) {
 	log.Logger.Info("Detecting Alpine vulnerabilities...")
 	if strings.Count(osVer, ".") > 1 {
 		osVer = osVer[:strings.LastIndex(osVer, ".")]
@@ -60,9 +77,9 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 
 	var vulns []types.DetectedVulnerability
 	for _, pkg := range pkgs {
-		advisories, err := s.vs.Get(osVer, pkg.Name)
+		advisories, err := s.getAdvisories(osVer, pkg.Name, rollup)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to get alpine advisories: %w", err)
+			return nil, err
 		}
 
 		installed := utils.FormatVersion(pkg)
@@ -94,20 +111,52 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 	return vulns, nil
 }
 
+func (s *Scanner) getAdvisories(osVer, pkgName string, rollup bool) ([]dbTypes.Advisory, error) {
+	advisories, err := s.vs.Get(osVer, pkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get alpine advisories: %w", err)
+	}
+	if !rollup || !strings.Contains(osVer, ".") {
+		return advisories, nil
+	}
+
+	majorVer := osVer[:strings.Index(osVer, ".")]
+	majorAdvisories, err := s.vs.Get(majorVer, pkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get alpine %s advisories: %w", majorVer, err)
+	}
+
+	found := map[string]struct{}{}
+	for _, adv := range advisories {
+		found[adv.VulnerabilityID] = struct{}{}
+	}
+	for _, adv := range majorAdvisories {
+		if _, ok := found[adv.VulnerabilityID]; !ok {
+			advisories = append(advisories, adv)
+		}
+	}
+	return advisories, nil
+}
+
 func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 	now := time.Now()
 	return s.isSupportedVersion(now, osFamily, osVer)
 }
 
 func (s *Scanner) isSupportedVersion(now time.Time, osFamily, osVer string) bool {
-	if strings.Count(osVer, ".") > 1 {
-		osVer = osVer[:strings.LastIndex(osVer, ".")]
-	}
-
-	eol, ok := eolDates[osVer]
+	eol, ok := s.EOLDate(osFamily, osVer)
 	if !ok {
 		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
 		return false
 	}
 	return now.Before(eol)
 }
+
+// EOLDate returns the end of the support of the minor version of the OS version, e.g. 3.11 for 3.11.5
+func (s *Scanner) EOLDate(_, osVer string) (time.Time, bool) {
+	if strings.Count(osVer, ".") > 1 {
+		osVer = osVer[:strings.LastIndex(osVer, ".")]
+	}
+	eol, ok := eolDates[osVer]
+	return eol, ok
+}
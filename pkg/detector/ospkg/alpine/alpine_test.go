@@ -203,6 +203,54 @@ func TestScanner_Detect(t *testing.T) {
 	}
 }
 
+func TestScanner_DetectRollup(t *testing.T) {
+	pkgs := []ftypes.Package{{Name: "openssl", Version: "1.1.1d-r3"}}
+	minor := []dbTypes.Advisory{
+		{VulnerabilityID: "CVE-2020-1967", FixedVersion: "1.1.1g-r0"},
+	}
+	major := []dbTypes.Advisory{
+		{VulnerabilityID: "CVE-2020-1967", FixedVersion: "1.1.1f-r0"}, // the advisory of 3.11 wins
+		{VulnerabilityID: "CVE-2019-1551", FixedVersion: "1.1.1e-r0"},
+	}
+
+	tests := []struct {
+		name   string
+		rollup bool
+		want   []types.DetectedVulnerability
+	}{
+		{
+			name:   "rollup",
+			rollup: true,
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3", FixedVersion: "1.1.1g-r0"},
+				{VulnerabilityID: "CVE-2019-1551", PkgName: "openssl", InstalledVersion: "1.1.1d-r3", FixedVersion: "1.1.1e-r0"},
+			},
+		},
+		{
+			name: "strict minor version",
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3", FixedVersion: "1.1.1g-r0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockVulnSrc := new(dbTypes.MockVulnSrc)
+			mockVulnSrc.On("Get", "3.11", "openssl").Return(minor, nil)
+			mockVulnSrc.On("Get", "3", "openssl").Return(major, nil)
+
+			s := &Scanner{vs: mockVulnSrc}
+			detect := s.Detect
+			if tt.rollup {
+				detect = s.DetectRollup
+			}
+			got, err := detect("3.11.5", pkgs)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestScanner_IsSupportedVersion(t *testing.T) {
 	vectors := map[string]struct {
 		now       time.Time
@@ -234,6 +282,18 @@ func TestScanner_IsSupportedVersion(t *testing.T) {
 			osVersion: "3.10",
 			expected:  true,
 		},
+		"alpine3.10 with EOL": {
+			now:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			osFamily:  "alpine",
+			osVersion: "3.10",
+			expected:  false,
+		},
+		"alpine3.18": {
+			now:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			osFamily:  "alpine",
+			osVersion: "3.18.4",
+			expected:  true,
+		},
 		"unknown": {
 			now:       time.Date(2019, 5, 2, 23, 59, 59, 0, time.UTC),
 			osFamily:  "alpine",
@@ -252,3 +312,14 @@ func TestScanner_IsSupportedVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestScanner_EOLDate(t *testing.T) {
+	s := NewScanner()
+
+	eol, ok := s.EOLDate("alpine", "3.9.6")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2020, 11, 1, 23, 59, 59, 0, time.UTC), eol)
+
+	_, ok = s.EOLDate("alpine", "unknown")
+	assert.False(t, ok)
+}
@@ -40,15 +40,21 @@ var (
 	}
 )
 
+// platformFormat is the format of the buckets of the unfixed Debian advisories in the DB
+const platformFormat = "debian %s"
+
 type Scanner struct {
 	ovalVs dbTypes.VulnSrc
 	vs     dbTypes.VulnSrc
+	// statuses tells the unfixed vulnerabilities the security tracker ignores or postpones, nil without the statuses
+	statuses utils.StatusGetter
 }
 
 func NewScanner() *Scanner {
 	return &Scanner{
-		ovalVs: debianoval.NewVulnSrc(),
-		vs:     debian.NewVulnSrc(),
+		ovalVs:   debianoval.NewVulnSrc(),
+		vs:       debian.NewVulnSrc(),
+		statuses: utils.NewAdvisoryStatuses(platformFormat),
 	}
 }
 
@@ -102,11 +108,18 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 		if err != nil {
 			return nil, xerrors.Errorf("failed to get debian advisory: %w", err)
 		}
+		var statuses map[string]string
+		if len(advisories) > 0 && s.statuses != nil {
+			if statuses, err = s.statuses.Get(osVer, pkg.SrcName); err != nil {
+				return nil, xerrors.Errorf("failed to get debian advisory statuses: %w", err)
+			}
+		}
 		for _, adv := range advisories {
 			vuln := types.DetectedVulnerability{
 				VulnerabilityID:  adv.VulnerabilityID,
 				PkgName:          pkg.Name,
 				InstalledVersion: installed,
+				Status:           statuses[adv.VulnerabilityID],
 				Layer:            pkg.Layer,
 			}
 			vulns = append(vulns, vuln)
@@ -121,14 +134,19 @@ func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 }
 
 func (s *Scanner) isSupportedVersion(now time.Time, osFamily, osVer string) bool {
-	if strings.Count(osVer, ".") > 0 {
-		osVer = osVer[:strings.Index(osVer, ".")]
-	}
-
-	eol, ok := eolDates[osVer]
+	eol, ok := s.EOLDate(osFamily, osVer)
 	if !ok {
 		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
 		return false
 	}
 	return now.Before(eol)
 }
+
+// EOLDate returns the end of the support of the major version of the OS version, e.g. 10 for 10.3
+func (s *Scanner) EOLDate(_, osVer string) (time.Time, bool) {
+	if strings.Count(osVer, ".") > 0 {
+		osVer = osVer[:strings.Index(osVer, ".")]
+	}
+	eol, ok := eolDates[osVer]
+	return eol, ok
+}
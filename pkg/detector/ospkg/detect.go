@@ -16,6 +16,7 @@ import (
 	"github.com/aquasecurity/trivy/pkg/detector/ospkg/redhat"
 	"github.com/aquasecurity/trivy/pkg/detector/ospkg/suse"
 	"github.com/aquasecurity/trivy/pkg/detector/ospkg/ubuntu"
+	"github.com/aquasecurity/trivy/pkg/detector/ospkg/windows"
 	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/types"
 )
@@ -38,9 +39,30 @@ type Driver interface {
 	IsSupportedVersion(string, string) bool
 }
 
+// RollupDriver is implemented by drivers also matching the advisories stored for the major version line of the OS,
+// e.g. alpine 3 for alpine 3.11
+type RollupDriver interface {
+	DetectRollup(string, []ftypes.Package) ([]types.DetectedVulnerability, error)
+}
+
+// EOLDateDriver is implemented by drivers knowing the end of the support of the OS versions
+type EOLDateDriver interface {
+	EOLDate(osFamily, osVer string) (time.Time, bool)
+}
+
 type Detector struct{}
 
 func (d Detector) Detect(_, osFamily, osName string, _ time.Time, pkgs []ftypes.Package) ([]types.DetectedVulnerability, bool, error) {
+	return d.detect(osFamily, osName, pkgs, false)
+}
+
+// DetectRollup is Detect also matching the advisories of the major OS version with the drivers supporting it.
+// The other drivers only match the advisories of the minor version.
+func (d Detector) DetectRollup(_, osFamily, osName string, _ time.Time, pkgs []ftypes.Package) ([]types.DetectedVulnerability, bool, error) {
+	return d.detect(osFamily, osName, pkgs, true)
+}
+
+func (d Detector) detect(osFamily, osName string, pkgs []ftypes.Package, rollup bool) ([]types.DetectedVulnerability, bool, error) {
 	driver := newDriver(osFamily, osName)
 	if driver == nil {
 		return nil, false, ErrUnsupportedOS
@@ -48,7 +70,16 @@ func (d Detector) Detect(_, osFamily, osName string, _ time.Time, pkgs []ftypes.
 
 	eosl := !driver.IsSupportedVersion(osFamily, osName)
 
-	vulns, err := driver.Detect(osName, pkgs)
+	detect := driver.Detect
+	if rollup {
+		if r, ok := driver.(RollupDriver); ok {
+			detect = r.DetectRollup
+		} else {
+			log.Logger.Debugf("%s advisories are only matched with the minor version", osFamily)
+		}
+	}
+
+	vulns, err := detect(osName, pkgs)
 	if err != nil {
 		return nil, false, xerrors.Errorf("failed detection: %w", err)
 	}
@@ -56,6 +87,24 @@ func (d Detector) Detect(_, osFamily, osName string, _ time.Time, pkgs []ftypes.
 	return vulns, eosl, nil
 }
 
+// IsSupportedVersion reports whether the OS version is still supported by the distribution
+func (d Detector) IsSupportedVersion(osFamily, osName string) (bool, error) {
+	driver := newDriver(osFamily, osName)
+	if driver == nil {
+		return false, ErrUnsupportedOS
+	}
+	return driver.IsSupportedVersion(osFamily, osName), nil
+}
+
+// EOLDate returns the end of the support of the OS version by the distribution, false when it isn't known
+func (d Detector) EOLDate(osFamily, osName string) (time.Time, bool) {
+	driver, ok := newDriver(osFamily, osName).(EOLDateDriver)
+	if !ok {
+		return time.Time{}, false
+	}
+	return driver.EOLDate(osFamily, osName)
+}
+
 func newDriver(osFamily, osName string) Driver {
 	// TODO: use DI and change struct names
 	var d Driver
@@ -78,6 +127,8 @@ func newDriver(osFamily, osName string) Driver {
 		d = suse.NewScanner(suse.SUSEEnterpriseLinux)
 	case fos.Photon:
 		d = photon.NewScanner()
+	case windows.Family:
+		d = windows.NewScanner()
 	default:
 		log.Logger.Warnf("unsupported os : %s", osFamily)
 		return nil
@@ -82,11 +82,7 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 }
 
 func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
-	if strings.Count(osVer, ".") > 0 {
-		osVer = osVer[:strings.Index(osVer, ".")]
-	}
-
-	eol, ok := eolDates[osVer]
+	eol, ok := s.EOLDate(osFamily, osVer)
 	if !ok {
 		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
 		return false
@@ -94,3 +90,12 @@ func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 
 	return s.clock.Now().Before(eol)
 }
+
+// EOLDate returns the end of the support of the major version of the OS version, e.g. 7 for 7.8
+func (s *Scanner) EOLDate(_, osVer string) (time.Time, bool) {
+	if strings.Count(osVer, ".") > 0 {
+		osVer = osVer[:strings.Index(osVer, ".")]
+	}
+	eol, ok := eolDates[osVer]
+	return eol, ok
+}
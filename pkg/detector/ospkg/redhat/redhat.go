@@ -37,13 +37,19 @@ var (
 	}
 )
 
+// platformFormat is the format of the buckets of the Red Hat advisories in the DB
+const platformFormat = "Red Hat Enterprise Linux %s"
+
 type Scanner struct {
 	vs dbTypes.VulnSrc
+	// statuses tells the unfixed vulnerabilities Red Hat won't fix or deferred, nil without the statuses
+	statuses utils.StatusGetter
 }
 
 func NewScanner() *Scanner {
 	return &Scanner{
-		vs: redhat.NewVulnSrc(),
+		vs:       redhat.NewVulnSrc(),
+		statuses: utils.NewAdvisoryStatuses(platformFormat),
 	}
 }
 
@@ -66,6 +72,13 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 		installed := utils.FormatVersion(pkg)
 		installedVersion := version.NewVersion(installed)
 
+		var statuses map[string]string
+		if len(advisories) > 0 && s.statuses != nil {
+			if statuses, err = s.statuses.Get(osVer, pkg.SrcName); err != nil {
+				return nil, xerrors.Errorf("failed to get Red Hat advisory statuses: %w", err)
+			}
+		}
+
 		for _, adv := range advisories {
 			if adv.FixedVersion != "" {
 				continue
@@ -74,6 +87,7 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 				VulnerabilityID:  adv.VulnerabilityID,
 				PkgName:          pkg.Name,
 				InstalledVersion: installed,
+				Status:           statuses[adv.VulnerabilityID],
 				Layer:            pkg.Layer,
 			}
 			vulns = append(vulns, vuln)
@@ -108,6 +122,16 @@ func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 }
 
 func (s *Scanner) isSupportedVersion(now time.Time, osFamily, osVer string) bool {
+	eolDate, ok := s.EOLDate(osFamily, osVer)
+	if !ok {
+		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
+		return false
+	}
+	return now.Before(eolDate)
+}
+
+// EOLDate returns the end of the support of the major version of the OS version of Red Hat or CentOS, e.g. 7 for 7.8
+func (s *Scanner) EOLDate(osFamily, osVer string) (time.Time, bool) {
 	if strings.Count(osVer, ".") > 0 {
 		osVer = osVer[:strings.Index(osVer, ".")]
 	}
@@ -119,9 +143,5 @@ func (s *Scanner) isSupportedVersion(now time.Time, osFamily, osVer string) bool
 	} else if osFamily == os.CentOS {
 		eolDate, ok = centosEOLDates[osVer]
 	}
-	if !ok {
-		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
-		return false
-	}
-	return now.Before(eolDate)
+	return eolDate, ok
 }
@@ -223,6 +223,52 @@ func TestScanner_Detect(t *testing.T) {
 	}
 }
 
+type fakeStatuses map[string]string
+
+func (f fakeStatuses) Get(_, _ string) (map[string]string, error) {
+	return f, nil
+}
+
+func TestScanner_Detect_Statuses(t *testing.T) {
+	mockVs := new(dbTypes.MockVulnSrc)
+	mockVs.ApplyGetExpectations([]dbTypes.GetExpectation{
+		{
+			Args: dbTypes.GetArgs{Release: "7", PkgName: "vim"},
+			Returns: dbTypes.GetReturns{
+				Advisories: []dbTypes.Advisory{
+					{VulnerabilityID: "CVE-2017-5953"},
+					{VulnerabilityID: "CVE-2017-6350"},
+				},
+			},
+		},
+		{
+			Args: dbTypes.GetArgs{Release: "7", PkgName: "vim-minimal"},
+		},
+	})
+	s := &Scanner{
+		vs:       mockVs,
+		statuses: fakeStatuses{"CVE-2017-5953": types.StatusWillNotFix},
+	}
+
+	got, err := s.Detect("7.6", []ftypes.Package{
+		{Name: "vim-minimal", Version: "7.4.160", Release: "5.el7", Epoch: 2, SrcName: "vim"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "CVE-2017-5953",
+			PkgName:          "vim-minimal",
+			InstalledVersion: "2:7.4.160-5.el7",
+			Status:           types.StatusWillNotFix,
+		},
+		{
+			VulnerabilityID:  "CVE-2017-6350",
+			PkgName:          "vim-minimal",
+			InstalledVersion: "2:7.4.160-5.el7",
+		},
+	}, got)
+}
+
 func TestScanner_IsSupportedVersion(t *testing.T) {
 	vectors := map[string]struct {
 		now       time.Time
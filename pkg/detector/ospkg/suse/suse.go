@@ -112,6 +112,17 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 }
 
 func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
+	eolDate, ok := s.EOLDate(osFamily, osVer)
+	if !ok {
+		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
+		return false
+	}
+
+	return s.clock.Now().Before(eolDate)
+}
+
+// EOLDate returns the end of the support of the OS version of SLES or openSUSE Leap, e.g. 15.1
+func (s *Scanner) EOLDate(osFamily, osVer string) (time.Time, bool) {
 	var eolDate time.Time
 	var ok bool
 
@@ -120,11 +131,5 @@ func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 	} else if osFamily == fos.OpenSUSELeap {
 		eolDate, ok = opensuseEolDates[osVer]
 	}
-
-	if !ok {
-		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
-		return false
-	}
-
-	return s.clock.Now().Before(eolDate)
+	return eolDate, ok
 }
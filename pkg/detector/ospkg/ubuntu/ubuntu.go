@@ -51,13 +51,19 @@ var (
 	}
 )
 
+// platformFormat is the format of the buckets of the Ubuntu advisories in the DB
+const platformFormat = "ubuntu %s"
+
 type Scanner struct {
 	vs dbTypes.VulnSrc
+	// statuses tells the unfixed vulnerabilities Ubuntu deferred or ignores, nil without the statuses
+	statuses utils.StatusGetter
 }
 
 func NewScanner() *Scanner {
 	return &Scanner{
-		vs: ubuntu.NewVulnSrc(),
+		vs:       ubuntu.NewVulnSrc(),
+		statuses: utils.NewAdvisoryStatuses(platformFormat),
 	}
 }
 
@@ -80,6 +86,7 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 			continue
 		}
 
+		var statuses map[string]string
 		for _, adv := range advisories {
 			vuln := types.DetectedVulnerability{
 				VulnerabilityID:  adv.VulnerabilityID,
@@ -90,6 +97,12 @@ func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedV
 			}
 
 			if adv.FixedVersion == "" {
+				if statuses == nil && s.statuses != nil {
+					if statuses, err = s.statuses.Get(osVer, pkg.SrcName); err != nil {
+						return nil, xerrors.Errorf("failed to get Ubuntu advisory statuses: %w", err)
+					}
+				}
+				vuln.Status = statuses[adv.VulnerabilityID]
 				vulns = append(vulns, vuln)
 				continue
 			}
@@ -114,10 +127,16 @@ func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
 }
 
 func (s *Scanner) isSupportedVersion(now time.Time, osFamily, osVer string) bool {
-	eol, ok := eolDates[osVer]
+	eol, ok := s.EOLDate(osFamily, osVer)
 	if !ok {
 		log.Logger.Warnf("This OS version is not on the EOL list: %s %s", osFamily, osVer)
 		return false
 	}
 	return now.Before(eol)
 }
+
+// EOLDate returns the end of the support of the OS version, e.g. 18.04
+func (s *Scanner) EOLDate(_, osVer string) (time.Time, bool) {
+	eol, ok := eolDates[osVer]
+	return eol, ok
+}
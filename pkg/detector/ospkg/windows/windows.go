@@ -0,0 +1,164 @@
+package windows
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	// Family is the OS family of Windows images
+	Family = "windows"
+
+	platformFormat = "Windows %s"
+	// advisories of a release are stored under a single package name
+	pkgName = "windows"
+	// supersedencePkgName stores the updates superseding each KB of a release, by KB
+	supersedencePkgName = "supersedence"
+)
+
+type advisoryGetter interface {
+	Get(release string, pkgName string) ([]dbTypes.Advisory, error)
+	// Supersedence returns the KBs superseding each KB of the release
+	Supersedence(release string) (map[string][]string, error)
+}
+
+// supersedence is the value of a KB in the supersedence bucket
+type supersedence struct {
+	SupersededBy []string
+}
+
+type vulnSrc struct {
+	dbc db.Operation
+}
+
+func (vs vulnSrc) Get(release string, pkgName string) ([]dbTypes.Advisory, error) {
+	advisories, err := vs.dbc.GetAdvisories(fmt.Sprintf(platformFormat, release), pkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get Windows advisories: %w", err)
+	}
+	return advisories, nil
+}
+
+func (vs vulnSrc) Supersedence(release string) (map[string][]string, error) {
+	values, err := vs.dbc.ForEachAdvisory(fmt.Sprintf(platformFormat, release), supersedencePkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get the Windows supersedence: %w", err)
+	}
+
+	supersededBy := map[string][]string{}
+	for kb, value := range values {
+		var s supersedence
+		if err = json.Unmarshal(value, &s); err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal the supersedence of %s: %w", kb, err)
+		}
+		for _, by := range s.SupersededBy {
+			supersededBy[NormalizeKB(kb)] = append(supersededBy[NormalizeKB(kb)], NormalizeKB(by))
+		}
+	}
+	return supersededBy, nil
+}
+
+// Scanner detects the updates missing from a Windows image.
+// The "packages" of a Windows image are its installed KB updates, and the fixed version of an advisory
+// lists the KBs resolving it. An update also resolves the advisories of the updates it supersedes, e.g. a cumulative
+// update those of the previous ones, so the advisories aren't matched without the supersedence of the release.
+type Scanner struct {
+	vs advisoryGetter
+}
+
+func NewScanner() *Scanner {
+	return &Scanner{
+		vs: vulnSrc{dbc: db.Config{}},
+	}
+}
+
+func (s *Scanner) Detect(osVer string, pkgs []ftypes.Package) ([]types.DetectedVulnerability, error) {
+	log.Logger.Info("Detecting Windows vulnerabilities...")
+	log.Logger.Debugf("Windows: os version: %s", osVer)
+	log.Logger.Debugf("Windows: the number of installed updates: %d", len(pkgs))
+
+	if len(pkgs) == 0 {
+		log.Logger.Warn("No installed KB updates were found, every Windows advisory is reported as missing")
+	}
+
+	installed := map[string]ftypes.Package{}
+	for _, pkg := range pkgs {
+		installed[NormalizeKB(pkg.Name)] = pkg
+	}
+
+	advisories, err := s.vs.Get(osVer, pkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get Windows advisory: %w", err)
+	}
+	if len(advisories) == 0 {
+		log.Logger.Warnf("No KB data for Windows %s in the DB", osVer)
+		return nil, nil
+	}
+	supersededBy, err := s.vs.Supersedence(osVer)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get Windows supersedence: %w", err)
+	}
+	if len(supersededBy) == 0 {
+		// the advisories fixed by a superseded update would all be reported as missing
+		log.Logger.Warnf("No KB supersedence for Windows %s in the DB, the missing updates aren't detected", osVer)
+		return nil, nil
+	}
+
+	var vulns []types.DetectedVulnerability
+	for _, adv := range advisories {
+		kbs := strings.FieldsFunc(adv.FixedVersion, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		for i := range kbs {
+			kbs[i] = NormalizeKB(kbs[i])
+		}
+		if len(kbs) == 0 || anyResolved(installed, supersededBy, kbs) {
+			continue
+		}
+		// the finding is the missing update rather than a vulnerable package
+		vulns = append(vulns, types.DetectedVulnerability{
+			VulnerabilityID: adv.VulnerabilityID,
+			PkgName:         kbs[0],
+			FixedVersion:    strings.Join(kbs, ", "),
+		})
+	}
+	return vulns, nil
+}
+
+// anyResolved reports whether one of the KBs, or an update superseding it, directly or not, is installed
+func anyResolved(installed map[string]ftypes.Package, supersededBy map[string][]string, kbs []string) bool {
+	queue := append([]string(nil), kbs...)
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		kb := queue[0]
+		queue = queue[1:]
+		if visited[kb] {
+			continue
+		}
+		visited[kb] = true
+		if _, ok := installed[kb]; ok {
+			return true
+		}
+		queue = append(queue, supersededBy[kb]...)
+	}
+	return false
+}
+
+// NormalizeKB returns the canonical form of a KB identifier, e.g. "kb5005565" and "5005565" become "KB5005565"
+func NormalizeKB(kb string) string {
+	kb = strings.ToUpper(strings.TrimSpace(kb))
+	return "KB" + strings.TrimPrefix(kb, "KB")
+}
+
+func (s *Scanner) IsSupportedVersion(osFamily, osVer string) bool {
+	return true
+}
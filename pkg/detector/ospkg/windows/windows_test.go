@@ -0,0 +1,89 @@
+package windows
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+type mockVulnSrc struct {
+	get          func(string, string) ([]dbTypes.Advisory, error)
+	supersededBy map[string][]string
+}
+
+func (m mockVulnSrc) Get(a string, b string) ([]dbTypes.Advisory, error) {
+	return m.get(a, b)
+}
+
+func (m mockVulnSrc) Supersedence(release string) (map[string][]string, error) {
+	return m.supersededBy, nil
+}
+
+func TestMain(m *testing.M) {
+	log.InitLogger(false, false)
+	os.Exit(m.Run())
+}
+
+func TestScanner_Detect(t *testing.T) {
+	get := func(release string, name string) ([]dbTypes.Advisory, error) {
+		assert.Equal(t, "10.0.17763", release)
+		assert.Equal(t, "windows", name)
+		return []dbTypes.Advisory{
+			// resolved by the installed update
+			{VulnerabilityID: "CVE-2021-0001", FixedVersion: "KB5005030"},
+			// resolved by either update
+			{VulnerabilityID: "CVE-2021-0002", FixedVersion: "KB5004244, 5005030"},
+			// resolved by the installed update superseding, through KB5004244, the one fixing it
+			{VulnerabilityID: "CVE-2021-0003", FixedVersion: "KB5003646"},
+			{VulnerabilityID: "CVE-2021-0004", FixedVersion: "kb5005568,KB5006672"},
+		}, nil
+	}
+
+	tests := []struct {
+		name         string
+		supersededBy map[string][]string
+		want         []types.DetectedVulnerability
+	}{
+		{
+			name: "happy path",
+			supersededBy: map[string][]string{
+				"KB5003646": {"KB5004244"},
+				"KB5004244": {"KB5005030"},
+				"KB5005030": {"KB5005568"},
+			},
+			want: []types.DetectedVulnerability{
+				{
+					VulnerabilityID: "CVE-2021-0004",
+					PkgName:         "KB5005568",
+					FixedVersion:    "KB5005568, KB5006672",
+				},
+			},
+		},
+		{
+			name: "no supersedence",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{vs: mockVulnSrc{get: get, supersededBy: tt.supersededBy}}
+			vulns, err := s.Detect("10.0.17763", []ftypes.Package{
+				{Name: "kb5005030"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, vulns)
+		})
+	}
+}
+
+func TestNormalizeKB(t *testing.T) {
+	for _, kb := range []string{"KB5005565", "kb5005565", "5005565", " KB5005565 "} {
+		assert.Equal(t, "KB5005565", NormalizeKB(kb), kb)
+	}
+}
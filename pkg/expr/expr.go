@@ -0,0 +1,338 @@
+// Package expr implements a minimal expression language used to filter findings.
+//
+// The grammar is:
+//
+//	expr       = or
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | primary
+//	primary    = "(" expr ")" | comparison
+//	comparison = operand [ ( "==" | "!=" ) operand ]
+//	operand    = identifier | string | "true" | "false"
+//
+// Identifiers refer to fields declared up front, so unknown fields and type mismatches
+// are reported by Parse rather than at evaluation time.
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/xerrors"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+)
+
+func (k Kind) String() string {
+	if k == Bool {
+		return "bool"
+	}
+	return "string"
+}
+
+// Expr is a parsed boolean expression
+type Expr struct {
+	root node
+}
+
+// Parse parses s. fields declares the identifiers available in the expression and their kinds.
+func Parse(s string, fields map[string]Kind) (*Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, xerrors.Errorf("unexpected token %q", p.tokens[p.pos].value)
+	}
+	if root.kind() != Bool {
+		return nil, xerrors.New("expression must evaluate to a bool")
+	}
+	return &Expr{root: root}, nil
+}
+
+// Eval evaluates the expression. values holds a string or bool for every declared field.
+func (e *Expr) Eval(values map[string]interface{}) bool {
+	return e.root.eval(values).(bool)
+}
+
+type tokenType int
+
+const (
+	tokenIdent tokenType = iota
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	typ   tokenType
+	value string
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, xerrors.Errorf("unterminated string at %d", i)
+			}
+			value, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, xerrors.Errorf("invalid string at %d: %w", i, err)
+			}
+			tokens = append(tokens, token{typ: tokenString, value: value})
+			i = j + 1
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{typ: tokenOperator, value: string(c)})
+			i++
+		case c == '!' && !strings.HasPrefix(s[i:], "!="):
+			tokens = append(tokens, token{typ: tokenOperator, value: "!"})
+			i++
+		case strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, token{typ: tokenOperator, value: s[i : i+2]})
+			i += 2
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for ; j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_'); j++ {
+			}
+			tokens = append(tokens, token{typ: tokenIdent, value: s[i:j]})
+			i = j
+		default:
+			return nil, xerrors.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]Kind
+}
+
+func (p *parser) peek(value string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].typ == tokenOperator && p.tokens[p.pos].value == value
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogical("||", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newLogical("&&", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek("!") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != Bool {
+			return nil, xerrors.Errorf("operator ! requires a bool, got %s", operand.kind())
+		}
+		return not{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.peek("(") {
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, xerrors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return n, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek("==") || p.peek("!=") {
+		op := p.tokens[p.pos].value
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != right.kind() {
+			return nil, xerrors.Errorf("mismatched types %s %s %s", left.kind(), op, right.kind())
+		}
+		return comparison{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, xerrors.New("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.typ {
+	case tokenString:
+		return literal{value: t.value}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		}
+		k, ok := p.fields[t.value]
+		if !ok {
+			return nil, xerrors.Errorf("unknown field: %s", t.value)
+		}
+		return field{name: t.value, k: k}, nil
+	}
+	return nil, xerrors.Errorf("unexpected token %q", t.value)
+}
+
+type node interface {
+	kind() Kind
+	eval(values map[string]interface{}) interface{}
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) kind() Kind {
+	if _, ok := l.value.(bool); ok {
+		return Bool
+	}
+	return String
+}
+
+func (l literal) eval(map[string]interface{}) interface{} {
+	return l.value
+}
+
+type field struct {
+	name string
+	k    Kind
+}
+
+func (f field) kind() Kind {
+	return f.k
+}
+
+func (f field) eval(values map[string]interface{}) interface{} {
+	v, ok := values[f.name]
+	if !ok {
+		if f.k == Bool {
+			return false
+		}
+		return ""
+	}
+	return v
+}
+
+type not struct {
+	operand node
+}
+
+func (n not) kind() Kind {
+	return Bool
+}
+
+func (n not) eval(values map[string]interface{}) interface{} {
+	return !n.operand.eval(values).(bool)
+}
+
+type comparison struct {
+	op          string
+	left, right node
+}
+
+func (c comparison) kind() Kind {
+	return Bool
+}
+
+func (c comparison) eval(values map[string]interface{}) interface{} {
+	equal := c.left.eval(values) == c.right.eval(values)
+	if c.op == "!=" {
+		return !equal
+	}
+	return equal
+}
+
+type logical struct {
+	op          string
+	left, right node
+}
+
+func newLogical(op string, left, right node) (node, error) {
+	if left.kind() != Bool || right.kind() != Bool {
+		return nil, xerrors.Errorf("operator %s requires bools, got %s and %s", op, left.kind(), right.kind())
+	}
+	return logical{op: op, left: left, right: right}, nil
+}
+
+func (l logical) kind() Kind {
+	return Bool
+}
+
+func (l logical) eval(values map[string]interface{}) interface{} {
+	if l.op == "&&" {
+		return l.left.eval(values).(bool) && l.right.eval(values).(bool)
+	}
+	return l.left.eval(values).(bool) || l.right.eval(values).(bool)
+}
@@ -0,0 +1,109 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/expr"
+)
+
+var fields = map[string]expr.Kind{
+	"severity": expr.String,
+	"type":     expr.String,
+	"fixed":    expr.Bool,
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		values  map[string]interface{}
+		want    bool
+		wantErr string
+	}{
+		{
+			name:   "comparison and bool field",
+			expr:   `severity == "CRITICAL" && fixed`,
+			values: map[string]interface{}{"severity": "CRITICAL", "fixed": true},
+			want:   true,
+		},
+		{
+			name:   "not fixed",
+			expr:   `severity == "CRITICAL" && fixed`,
+			values: map[string]interface{}{"severity": "CRITICAL", "fixed": false},
+			want:   false,
+		},
+		{
+			name:   "or with parentheses and negation",
+			expr:   `!(type == "npm") || severity != "LOW"`,
+			values: map[string]interface{}{"type": "npm", "severity": "HIGH"},
+			want:   true,
+		},
+		{
+			name:   "precedence of && over ||",
+			expr:   `type == "npm" || type == "yarn" && fixed`,
+			values: map[string]interface{}{"type": "npm", "fixed": false},
+			want:   true,
+		},
+		{
+			name:   "bool literal",
+			expr:   `fixed == false`,
+			values: map[string]interface{}{"fixed": false},
+			want:   true,
+		},
+		{
+			name:    "sad path: unknown field",
+			expr:    `score == "9.8"`,
+			wantErr: "unknown field: score",
+		},
+		{
+			name:    "sad path: mismatched types",
+			expr:    `fixed == "yes"`,
+			wantErr: "mismatched types",
+		},
+		{
+			name:    "sad path: string expression",
+			expr:    `severity`,
+			wantErr: "must evaluate to a bool",
+		},
+		{
+			name:    "sad path: non-bool operand",
+			expr:    `severity && fixed`,
+			wantErr: "requires bools",
+		},
+		{
+			name:    "sad path: unterminated string",
+			expr:    `severity == "HIGH`,
+			wantErr: "unterminated string",
+		},
+		{
+			name:    "sad path: missing parenthesis",
+			expr:    `(fixed`,
+			wantErr: "missing closing parenthesis",
+		},
+		{
+			name:    "sad path: trailing token",
+			expr:    `fixed fixed`,
+			wantErr: "unexpected token",
+		},
+		{
+			name:    "sad path: unexpected character",
+			expr:    `fixed > 1`,
+			wantErr: "unexpected character",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := expr.Parse(tt.expr, fields)
+			if tt.wantErr != "" {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, e.Eval(tt.values))
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+)
+
+const manifestFile = "manifest.json"
+
+// Extractor reads an image exported with `docker save`, gzipped or not, without pulling it.
+// The image is named after its first tag in the manifest of the archive, or the path of the archive when untagged.
+type Extractor struct {
+	*image.Extractor
+	imageName string
+}
+
+// descriptor is an image of the archive manifest
+type descriptor struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// NewExtractor returns the extractor of the single image in the archive.
+// Archives with several images are rejected as it isn't known which one to scan.
+func NewExtractor(ctx context.Context, fileName string, option types.DockerOption) (Extractor, error) {
+	manifest, err := readManifest(fileName)
+	if err != nil {
+		return Extractor{}, xerrors.Errorf("unable to read the manifest of %s: %w", fileName, err)
+	}
+	switch {
+	case len(manifest) == 0:
+		return Extractor{}, xerrors.Errorf("%s contains no image", fileName)
+	case len(manifest) > 1:
+		var names []string
+		for _, d := range manifest {
+			names = append(names, strings.Join(d.RepoTags, ", "))
+		}
+		return Extractor{}, xerrors.Errorf("%s contains %d images (%s): save the image to scan alone, e.g. `docker save -o image.tar <image>`",
+			fileName, len(manifest), strings.Join(names, "; "))
+	}
+
+	ext, err := image.NewDockerArchiveExtractor(fileName)
+	if err != nil {
+		return Extractor{}, err
+	}
+
+	imageName := fileName
+	if len(manifest[0].RepoTags) > 0 {
+		imageName = manifest[0].RepoTags[0]
+	}
+	return Extractor{Extractor: ext, imageName: imageName}, nil
+}
+
+func (e Extractor) ImageName() string {
+	return e.imageName
+}
+
+// RepoTags returns the tags of the image in the manifest of the archive, nil when it is untagged
+func RepoTags(fileName string) ([]string, error) {
+	manifest, err := readManifest(fileName)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the manifest of %s: %w", fileName, err)
+	} else if len(manifest) == 0 {
+		return nil, xerrors.Errorf("%s contains no image", fileName)
+	}
+	return manifest[0].RepoTags, nil
+}
+
+func readManifest(fileName string) ([]descriptor, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open the file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader
+	br := bufio.NewReader(f)
+	r = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, xerrors.Errorf("%s not found", manifestFile)
+		} else if err != nil {
+			return nil, xerrors.Errorf("invalid tar: %w", err)
+		}
+		if strings.TrimPrefix(hdr.Name, "./") != manifestFile {
+			continue
+		}
+
+		var manifest []descriptor
+		if err = json.NewDecoder(tr).Decode(&manifest); err != nil {
+			return nil, xerrors.Errorf("invalid %s: %w", manifestFile, err)
+		}
+		return manifest, nil
+	}
+}
@@ -0,0 +1,147 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/types"
+)
+
+const (
+	baseDiffID = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	appDiffID  = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+var config = `{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":["` + baseDiffID + `","` + appDiffID + `"]}}`
+
+func writeArchive(t *testing.T, dir, name string, gzipped bool, files map[string]string) string {
+	fileName := filepath.Join(dir, name)
+	f, err := os.Create(fileName)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var w io.Writer = f
+	if gzipped {
+		gw := gzip.NewWriter(f)
+		defer gw.Close()
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err = tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	return fileName
+}
+
+func TestNewExtractor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name          string
+		gzipped       bool
+		files         map[string]string
+		wantImageName string
+		wantErr       string
+	}{
+		{
+			name: "happy path",
+			files: map[string]string{
+				"manifest.json": `[{"Config":"config.json","RepoTags":["alpine:3.11","alpine:latest"],"Layers":["base.tar","app.tar"]}]`,
+				"config.json":   config,
+				"base.tar":      "",
+				"app.tar":       "",
+			},
+			wantImageName: "alpine:3.11",
+		},
+		{
+			name:    "happy path: gzipped",
+			gzipped: true,
+			files: map[string]string{
+				"manifest.json": `[{"Config":"config.json","RepoTags":["alpine:3.11"],"Layers":["base.tar","app.tar"]}]`,
+				"config.json":   config,
+				"base.tar":      "",
+				"app.tar":       "",
+			},
+			wantImageName: "alpine:3.11",
+		},
+		{
+			name: "happy path: untagged",
+			files: map[string]string{
+				"manifest.json": `[{"Config":"config.json","Layers":["base.tar","app.tar"]}]`,
+				"config.json":   config,
+				"base.tar":      "",
+				"app.tar":       "",
+			},
+		},
+		{
+			name: "sad path: several images",
+			files: map[string]string{
+				"manifest.json": `[{"Config":"a.json","RepoTags":["alpine:3.11"]},{"Config":"b.json","RepoTags":["debian:10","debian:buster"]}]`,
+			},
+			wantErr: "contains 2 images (alpine:3.11; debian:10, debian:buster): save the image to scan alone",
+		},
+		{
+			name:    "sad path: no manifest",
+			files:   map[string]string{"config.json": config},
+			wantErr: "manifest.json not found",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := writeArchive(t, dir, fmt.Sprintf("image%d.tar", i), tt.gzipped, tt.files)
+
+			e, err := NewExtractor(context.Background(), fileName, types.DockerOption{})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			wantImageName := tt.wantImageName
+			if wantImageName == "" {
+				wantImageName = fileName
+			}
+			assert.Equal(t, wantImageName, e.ImageName())
+
+			layerIDs, err := e.LayerIDs()
+			require.NoError(t, err)
+			assert.Equal(t, []string{baseDiffID, appDiffID}, layerIDs)
+		})
+	}
+}
+
+func TestRepoTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := writeArchive(t, dir, "image.tar.gz", true, map[string]string{
+		"manifest.json": `[{"Config":"config.json","RepoTags":["alpine:3.11","alpine:latest"],"Layers":["base.tar"]}]`,
+	})
+	repoTags, err := RepoTags(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpine:3.11", "alpine:latest"}, repoTags)
+
+	fileName = writeArchive(t, dir, "untagged.tar", false, map[string]string{
+		"manifest.json": `[{"Config":"config.json","Layers":["base.tar"]}]`,
+	})
+	repoTags, err = RepoTags(fileName)
+	require.NoError(t, err)
+	assert.Empty(t, repoTags)
+}
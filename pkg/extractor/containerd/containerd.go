@@ -0,0 +1,323 @@
+package containerd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	contentapi "github.com/containerd/containerd/api/services/content/v1"
+	imagesapi "github.com/containerd/containerd/api/services/images/v1"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	digest "github.com/opencontainers/go-digest"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+)
+
+const (
+	// DefaultSocket is the socket of containerd on Linux
+	DefaultSocket = "/run/containerd/containerd.sock"
+	// DefaultNamespace is the namespace of the images pulled by the CRI plugin, i.e. by Kubernetes
+	DefaultNamespace = "k8s.io"
+
+	// namespaceHeader is the gRPC header selecting the namespace of containerd
+	namespaceHeader = "containerd-namespace"
+)
+
+// ErrNotFound is returned when containerd doesn't have the image
+var ErrNotFound = xerrors.New("image not found in containerd")
+
+// Option is the containerd to read the images from
+type Option struct {
+	Socket    string
+	Namespace string
+	Timeout   time.Duration
+}
+
+// Extractor reads an image from the content store of containerd, e.g. on the Kubernetes nodes
+// whose runtime is containerd without Docker
+type Extractor struct {
+	*image.Extractor
+}
+
+// Available reports whether the socket of containerd exists, without connecting to it
+func Available(opt Option) bool {
+	fi, err := os.Stat(opt.socket())
+	return err == nil && fi.Mode()&os.ModeSocket != 0
+}
+
+// NewExtractor returns the extractor of the image in containerd. The image is looked up by the name as given,
+// then by its normalized name, e.g. docker.io/library/alpine:3.11 for alpine:3.11. The platform of Trivy is
+// selected of a multi-platform image. The returned function closes the connection.
+func NewExtractor(ctx context.Context, imageName string, opt Option) (*Extractor, func(), error) {
+	conn, err := dial(ctx, opt)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	cleanup := func() { conn.Close() }
+
+	ctx = metadata.AppendToOutgoingContext(ctx, namespaceHeader, opt.namespace())
+	store := &contentStore{ctx: ctx, client: contentapi.NewContentClient(conn)}
+	target, err := getImage(ctx, imagesapi.NewImagesClient(conn), imageName)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+
+	img, err := store.image(target)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, xerrors.Errorf("unable to read %s from containerd: %w", imageName, err)
+	}
+	return &Extractor{Extractor: image.NewExtractor(img, imageName)}, cleanup, nil
+}
+
+// HasImage reports whether containerd has the image
+func HasImage(ctx context.Context, imageName string, opt Option) (bool, error) {
+	conn, err := dial(ctx, opt)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, namespaceHeader, opt.namespace())
+	_, err = getImage(ctx, imagesapi.NewImagesClient(conn), imageName)
+	if xerrors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (o Option) socket() string {
+	if o.Socket == "" {
+		return DefaultSocket
+	}
+	return o.Socket
+}
+
+func (o Option) namespace() string {
+	if o.Namespace == "" {
+		return DefaultNamespace
+	}
+	return o.Namespace
+}
+
+func dial(ctx context.Context, opt Option) (*grpc.ClientConn, error) {
+	timeout := opt.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, opt.socket(), grpc.WithInsecure(), grpc.WithBlock(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}))
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect to containerd (%s): %w", opt.socket(), err)
+	}
+	return conn, nil
+}
+
+// getImage returns the descriptor of the manifest or the index of the image
+func getImage(ctx context.Context, client imagesapi.ImagesClient, imageName string) (v1.Descriptor, error) {
+	for _, n := range candidateNames(imageName) {
+		resp, err := client.Get(ctx, &imagesapi.GetImageRequest{Name: n})
+		if status.Code(err) == codes.NotFound {
+			continue
+		} else if err != nil {
+			return v1.Descriptor{}, xerrors.Errorf("unable to get %s from containerd: %w", n, err)
+		}
+		target := resp.Image.Target
+		h, err := v1.NewHash(target.Digest.String())
+		if err != nil {
+			return v1.Descriptor{}, xerrors.Errorf("invalid digest of %s: %w", n, err)
+		}
+		return v1.Descriptor{MediaType: types.MediaType(target.MediaType), Digest: h, Size: target.Size_}, nil
+	}
+	return v1.Descriptor{}, xerrors.Errorf("%s: %w", imageName, ErrNotFound)
+}
+
+// candidateNames returns the name as given and its normalized form, as containerd stores the images by full name
+func candidateNames(imageName string) []string {
+	names := []string{imageName}
+	ref, err := name.ParseReference(imageName)
+	if err != nil {
+		return names
+	}
+	registry := ref.Context().RegistryStr()
+	if registry == name.DefaultRegistry {
+		registry = "docker.io"
+	}
+	normalized := registry + "/" + ref.Context().RepositoryStr()
+	if d, ok := ref.(name.Digest); ok {
+		normalized += "@" + d.DigestStr()
+	} else {
+		normalized += ":" + ref.Identifier()
+	}
+	if normalized != imageName {
+		names = append(names, normalized)
+	}
+	return names
+}
+
+// contentStore reads the blobs of the images from the content store of containerd
+type contentStore struct {
+	ctx    context.Context
+	client contentapi.ContentClient
+}
+
+func (s *contentStore) read(h v1.Hash) ([]byte, error) {
+	rc, err := s.open(h)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (s *contentStore) open(h v1.Hash) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	stream, err := s.client.Read(ctx, &contentapi.ReadContentRequest{Digest: digest.Digest(h.String())})
+	if err != nil {
+		cancel()
+		return nil, xerrors.Errorf("unable to read %s: %w", h, err)
+	}
+	return &blobReader{stream: stream, cancel: cancel}, nil
+}
+
+// image returns the image of the manifest, or of the manifest of the platform of Trivy in the index
+func (s *contentStore) image(target v1.Descriptor) (v1.Image, error) {
+	switch target.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		b, err := s.read(target.Digest)
+		if err != nil {
+			return nil, err
+		}
+		index, err := v1.ParseIndexManifest(bytes.NewReader(b))
+		if err != nil {
+			return nil, xerrors.Errorf("invalid index: %w", err)
+		}
+		for _, desc := range index.Manifests {
+			if p := desc.Platform; p != nil && p.OS == "linux" && p.Architecture == runtime.GOARCH {
+				return s.image(desc)
+			}
+		}
+		return nil, xerrors.Errorf("no image of linux/%s in the index", runtime.GOARCH)
+	}
+
+	manifest, err := s.read(target.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return partial.CompressedToImage(&compressedImage{store: s, mediaType: target.MediaType, manifest: manifest})
+}
+
+// compressedImage is the core of an image in the content store, completed by partial.CompressedToImage
+type compressedImage struct {
+	store     *contentStore
+	mediaType types.MediaType
+	manifest  []byte
+}
+
+func (i *compressedImage) MediaType() (types.MediaType, error) {
+	return i.mediaType, nil
+}
+
+func (i *compressedImage) RawManifest() ([]byte, error) {
+	return i.manifest, nil
+}
+
+func (i *compressedImage) RawConfigFile() ([]byte, error) {
+	m, err := i.parseManifest()
+	if err != nil {
+		return nil, err
+	}
+	return i.store.read(m.Config.Digest)
+}
+
+func (i *compressedImage) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	m, err := i.parseManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, desc := range m.Layers {
+		if desc.Digest == h {
+			return &compressedLayer{store: i.store, desc: desc}, nil
+		}
+	}
+	return nil, xerrors.Errorf("layer %s not found in the manifest", h)
+}
+
+func (i *compressedImage) parseManifest() (*v1.Manifest, error) {
+	m := &v1.Manifest{}
+	if err := json.Unmarshal(i.manifest, m); err != nil {
+		return nil, xerrors.Errorf("invalid manifest: %w", err)
+	}
+	return m, nil
+}
+
+type compressedLayer struct {
+	store *contentStore
+	desc  v1.Descriptor
+}
+
+func (l *compressedLayer) Digest() (v1.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+func (l *compressedLayer) Compressed() (io.ReadCloser, error) {
+	return l.store.open(l.desc.Digest)
+}
+
+func (l *compressedLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+func (l *compressedLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
+
+// blobReader reads the chunks of a blob streamed by the content service
+type blobReader struct {
+	stream contentapi.Content_ReadClient
+	cancel context.CancelFunc
+	buf    []byte
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		resp, err := r.stream.Recv()
+		if err == io.EOF {
+			return 0, io.EOF
+		} else if err != nil {
+			return 0, xerrors.Errorf("failed to read the blob: %w", err)
+		}
+		r.buf = resp.Data
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *blobReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,261 @@
+package containerd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	contentapi "github.com/containerd/containerd/api/services/content/v1"
+	imagesapi "github.com/containerd/containerd/api/services/images/v1"
+	apitypes "github.com/containerd/containerd/api/types"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeContainerd serves the images of a namespace and their blobs, as the images and content services of containerd
+type fakeContainerd struct {
+	namespace string
+	images    map[string]apitypes.Descriptor
+	blobs     map[digest.Digest][]byte
+}
+
+// fakeImages and fakeContent implement the only methods called by the extractor
+type fakeImages struct {
+	imagesapi.ImagesServer
+	*fakeContainerd
+}
+
+type fakeContent struct {
+	contentapi.ContentServer
+	*fakeContainerd
+}
+
+func (f fakeImages) Get(ctx context.Context, req *imagesapi.GetImageRequest) (*imagesapi.GetImageResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ns := md.Get(namespaceHeader); len(ns) != 1 || ns[0] != f.namespace {
+		return nil, status.Errorf(codes.NotFound, "image %q: not found", req.Name)
+	}
+	target, ok := f.images[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "image %q: not found", req.Name)
+	}
+	return &imagesapi.GetImageResponse{Image: &imagesapi.Image{Name: req.Name, Target: target}}, nil
+}
+
+func (f fakeContent) Read(req *contentapi.ReadContentRequest, srv contentapi.Content_ReadServer) error {
+	b, ok := f.blobs[req.Digest]
+	if !ok {
+		return status.Errorf(codes.NotFound, "content %s: not found", req.Digest)
+	}
+	// sent in small chunks to go through several messages
+	for offset := 0; offset < len(b); offset += 100 {
+		end := offset + 100
+		if end > len(b) {
+			end = len(b)
+		}
+		if err := srv.Send(&contentapi.ReadContentResponse{Offset: int64(offset), Data: b[offset:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeContainerd) addImage(t *testing.T, imageName string, img v1.Image) {
+	manifest, err := img.RawManifest()
+	require.NoError(t, err)
+	config, err := img.RawConfigFile()
+	require.NoError(t, err)
+	mediaType, err := img.MediaType()
+	require.NoError(t, err)
+
+	manifestDigest := digest.FromBytes(manifest)
+	f.blobs[manifestDigest] = manifest
+	f.blobs[digest.FromBytes(config)] = config
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		rc, err := layer.Compressed()
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		f.blobs[digest.FromBytes(b)] = b
+	}
+	f.images[imageName] = apitypes.Descriptor{MediaType: string(mediaType), Digest: manifestDigest, Size_: int64(len(manifest))}
+}
+
+func newImage(t *testing.T, files map[string]string) v1.Image {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	b := buf.Bytes()
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	})
+	require.NoError(t, err)
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+	return img
+}
+
+// serve starts the fake containerd on a socket of dir
+func serve(t *testing.T, dir string, f *fakeContainerd) (string, func()) {
+	socket := filepath.Join(dir, "containerd.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	imagesapi.RegisterImagesServer(s, fakeImages{fakeContainerd: f})
+	contentapi.RegisterContentServer(s, fakeContent{fakeContainerd: f})
+	go s.Serve(l)
+	return socket, s.Stop
+}
+
+func TestNewExtractor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "containerd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	img := newImage(t, map[string]string{
+		"etc/alpine-release":    "3.11.5",
+		"app/package-lock.json": "{}",
+	})
+	f := &fakeContainerd{namespace: DefaultNamespace, images: map[string]apitypes.Descriptor{}, blobs: map[digest.Digest][]byte{}}
+	f.addImage(t, "docker.io/library/alpine:3.11", img)
+	socket, stop := serve(t, dir, f)
+	defer stop()
+
+	opt := Option{Socket: socket, Timeout: 5 * time.Second}
+	assert.True(t, Available(opt))
+
+	e, cleanup, err := NewExtractor(context.Background(), "alpine:3.11", opt)
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, "alpine:3.11", e.ImageName())
+
+	wantID, err := img.ConfigName()
+	require.NoError(t, err)
+	imageID, err := e.ImageID()
+	require.NoError(t, err)
+	assert.Equal(t, wantID.String(), imageID)
+
+	layerIDs, err := e.LayerIDs()
+	require.NoError(t, err)
+	require.Len(t, layerIDs, 1)
+	_, files, _, _, err := e.ExtractLayerFiles(layerIDs[0], []string{"etc/alpine-release", "package-lock.json"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"etc/alpine-release":    []byte("3.11.5"),
+		"app/package-lock.json": []byte("{}"),
+	}, map[string][]byte(files))
+}
+
+func TestHasImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "containerd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &fakeContainerd{namespace: DefaultNamespace, images: map[string]apitypes.Descriptor{}, blobs: map[digest.Digest][]byte{}}
+	f.addImage(t, "docker.io/library/alpine:3.11", newImage(t, map[string]string{"etc/alpine-release": "3.11.5"}))
+	socket, stop := serve(t, dir, f)
+	defer stop()
+
+	tests := []struct {
+		name      string
+		imageName string
+		namespace string
+		want      bool
+	}{
+		{
+			name:      "short name",
+			imageName: "alpine:3.11",
+			want:      true,
+		},
+		{
+			name:      "full name",
+			imageName: "docker.io/library/alpine:3.11",
+			want:      true,
+		},
+		{
+			name:      "another tag",
+			imageName: "alpine:3.10",
+			want:      false,
+		},
+		{
+			name:      "another namespace",
+			imageName: "alpine:3.11",
+			namespace: "default",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HasImage(context.Background(), tt.imageName, Option{Socket: socket, Namespace: tt.namespace})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, cleanup, err := NewExtractor(context.Background(), "alpine:3.10", Option{Socket: socket})
+	defer cleanup()
+	require.Error(t, err)
+	assert.True(t, xerrors.Is(err, ErrNotFound))
+}
+
+func TestAvailable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "containerd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	regular := filepath.Join(dir, "regular")
+	require.NoError(t, ioutil.WriteFile(regular, nil, 0600))
+	assert.False(t, Available(Option{Socket: regular}))
+	assert.False(t, Available(Option{Socket: filepath.Join(dir, "missing.sock")}))
+}
+
+func TestCandidateNames(t *testing.T) {
+	tests := []struct {
+		imageName string
+		want      []string
+	}{
+		{imageName: "alpine", want: []string{"alpine", "docker.io/library/alpine:latest"}},
+		{imageName: "alpine:3.11", want: []string{"alpine:3.11", "docker.io/library/alpine:3.11"}},
+		{imageName: "aquasec/trivy:0.5.0", want: []string{"aquasec/trivy:0.5.0", "docker.io/aquasec/trivy:0.5.0"}},
+		{imageName: "gcr.io/distroless/base:latest", want: []string{"gcr.io/distroless/base:latest"}},
+		{
+			imageName: "alpine@sha256:cb8a924afdf0229ef7515d9e5b3024e23b3eb03ddbba287f4a19c6ac90b8d221",
+			want: []string{
+				"alpine@sha256:cb8a924afdf0229ef7515d9e5b3024e23b3eb03ddbba287f4a19c6ac90b8d221",
+				"docker.io/library/alpine@sha256:cb8a924afdf0229ef7515d9e5b3024e23b3eb03ddbba287f4a19c6ac90b8d221",
+			},
+		},
+		{imageName: "INVALID", want: []string{"INVALID"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.imageName, func(t *testing.T) {
+			assert.Equal(t, tt.want, candidateNames(tt.imageName))
+		})
+	}
+}
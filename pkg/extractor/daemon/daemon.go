@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// Runtime is the container runtime an image is read from
+type Runtime string
+
+const (
+	// Auto selects the first available runtime having the image, see Resolve
+	Auto Runtime = ""
+	// Docker reads the image from Docker Engine, or pulls it from its registry
+	Docker Runtime = "docker"
+	// Containerd reads the image from the content store of containerd
+	Containerd Runtime = "containerd"
+	// Podman reads the image from the Podman service
+	Podman Runtime = "podman"
+
+	pingTimeout = 3 * time.Second
+)
+
+// Runtimes are the runtimes which can be forced with --runtime
+var Runtimes = []Runtime{Docker, Containerd, Podman}
+
+// Option configures the runtimes
+type Option struct {
+	Runtime    Runtime
+	Containerd containerd.Option
+	Podman     podman.Option
+}
+
+// pingDocker reports whether Docker Engine answers, replaced in tests
+var pingDocker = func(ctx context.Context) bool {
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	_, err = c.Ping(ctx)
+	return err == nil
+}
+
+// HasDockerImage reports whether Docker Engine has the image, which is then read without pulling it from its registry
+func HasDockerImage(ctx context.Context, imageName string) (bool, error) {
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return false, xerrors.Errorf("unable to connect to Docker Engine: %w", err)
+	}
+	defer c.Close()
+
+	if _, _, err = c.ImageInspectWithRaw(ctx, imageName); client.IsErrNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, xerrors.Errorf("unable to inspect %s in Docker Engine: %w", imageName, err)
+	}
+	return true, nil
+}
+
+// hasDockerImage is HasDockerImage, replaced in tests
+var hasDockerImage = HasDockerImage
+
+// HasImage reports whether the runtime has the image, Docker Engine for Auto
+func HasImage(ctx context.Context, imageName string, runtime Runtime, opt Option) (bool, error) {
+	switch runtime {
+	case Containerd:
+		return containerd.HasImage(ctx, imageName, opt.Containerd)
+	case Podman:
+		return podman.HasImage(ctx, imageName, opt.Podman)
+	}
+	return HasDockerImage(ctx, imageName)
+}
+
+// InspectDockerImage returns the tags and the digests of the repositories of the image in Docker Engine,
+// e.g. alpine:3.11 and alpine@sha256:b276d875..., the digests being known for the images pulled or pushed only
+func InspectDockerImage(ctx context.Context, imageName string) (repoTags, repoDigests []string, err error) {
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, nil, xerrors.Errorf("unable to connect to Docker Engine: %w", err)
+	}
+	defer c.Close()
+
+	inspect, _, err := c.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("unable to inspect %s in Docker Engine: %w", imageName, err)
+	}
+	return inspect.RepoTags, inspect.RepoDigests, nil
+}
+
+// ParseRuntime returns the runtime of its name, Auto for ""
+func ParseRuntime(s string) (Runtime, error) {
+	if s == "" {
+		return Auto, nil
+	}
+	for _, r := range Runtimes {
+		if string(r) == strings.ToLower(s) {
+			return r, nil
+		}
+	}
+	var names []string
+	for _, r := range Runtimes {
+		names = append(names, string(r))
+	}
+	return Auto, xerrors.Errorf("unknown runtime %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// Resolve returns the runtime to read the image from. A forced runtime is returned as it is. Otherwise the first
+// runtime having the image is selected: Docker when Docker Engine answers, then containerd and Podman when their
+// sockets exist, e.g. on the Kubernetes nodes running containerd. Docker is the fallback, which pulls the image
+// from its registry.
+func Resolve(ctx context.Context, imageName string, opt Option) Runtime {
+	if opt.Runtime != Auto {
+		return opt.Runtime
+	}
+	if pingDocker(ctx) {
+		ok, err := hasDockerImage(ctx, imageName)
+		if err != nil {
+			log.Logger.Debugf("Docker Engine unavailable: %s", err)
+		} else if ok {
+			return Docker
+		}
+	}
+
+	if containerd.Available(opt.Containerd) {
+		ok, err := containerd.HasImage(ctx, imageName, opt.Containerd)
+		if err != nil {
+			log.Logger.Debugf("containerd unavailable: %s", err)
+		} else if ok {
+			log.Logger.Debugf("Docker Engine doesn't have %s, reading it from containerd", imageName)
+			return Containerd
+		}
+	}
+
+	if podman.Socket(opt.Podman) != "" {
+		ok, err := podman.HasImage(ctx, imageName, opt.Podman)
+		if err != nil {
+			log.Logger.Debugf("Podman unavailable: %s", err)
+		} else if ok {
+			log.Logger.Debugf("Docker Engine doesn't have %s, reading it from Podman", imageName)
+			return Podman
+		}
+	}
+	return Docker
+}
@@ -0,0 +1,209 @@
+package daemon
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Runtime
+		wantErr string
+	}{
+		{name: "", want: Auto},
+		{name: "docker", want: Docker},
+		{name: "containerd", want: Containerd},
+		{name: "Podman", want: Podman},
+		{name: "cri-o", wantErr: `unknown runtime "cri-o", expected one of docker, containerd, podman`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRuntime(tt.name)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	dir, err := ioutil.TempDir("", "daemon")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a Podman service having alpine:3.11 only
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+	})
+	mux.HandleFunc("/v1.40/images/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.40/images/alpine:3.11/json" {
+			w.Write([]byte(`{"Id": "sha256:0123", "RepoTags": ["alpine:3.11", "alpine:latest"], "RepoDigests": ["alpine@sha256:4567"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "no such image"}`))
+	})
+	podmanSocket := filepath.Join(dir, "podman.sock")
+	l, err := net.Listen("unix", podmanSocket)
+	require.NoError(t, err)
+	s := &http.Server{Handler: mux}
+	go s.Serve(l)
+	defer s.Close()
+
+	opt := Option{
+		Containerd: containerd.Option{Socket: filepath.Join(dir, "containerd.sock")},
+		Podman:     podman.Option{Socket: podmanSocket},
+	}
+
+	tests := []struct {
+		name        string
+		runtime     Runtime
+		dockerAlive bool
+		dockerImage bool
+		imageName   string
+		want        Runtime
+	}{
+		{
+			name:      "forced",
+			runtime:   Containerd,
+			imageName: "alpine:3.11",
+			want:      Containerd,
+		},
+		{
+			name:        "Docker Engine has the image",
+			dockerAlive: true,
+			dockerImage: true,
+			imageName:   "alpine:3.11",
+			want:        Docker,
+		},
+		{
+			name:        "Docker Engine doesn't have the image",
+			dockerAlive: true,
+			imageName:   "alpine:3.11",
+			want:        Podman,
+		},
+		{
+			name:        "Docker Engine answers, the image is nowhere",
+			dockerAlive: true,
+			imageName:   "alpine:3.10",
+			want:        Docker,
+		},
+		{
+			name:      "Podman has the image",
+			imageName: "alpine:3.11",
+			want:      Podman,
+		},
+		{
+			name:      "nowhere",
+			imageName: "alpine:3.10",
+			want:      Docker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldPing := pingDocker
+			defer func() { pingDocker = oldPing }()
+			pingDocker = func(context.Context) bool { return tt.dockerAlive }
+			oldHas := hasDockerImage
+			defer func() { hasDockerImage = oldHas }()
+			hasDockerImage = func(context.Context, string) (bool, error) { return tt.dockerImage, nil }
+
+			o := opt
+			o.Runtime = tt.runtime
+			assert.Equal(t, tt.want, Resolve(context.Background(), tt.imageName, o))
+		})
+	}
+}
+
+// serveDocker serves a Docker Engine having alpine:3.11 only on the DOCKER_HOST socket, until the returned
+// function is called
+func serveDocker(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "daemon")
+	require.NoError(t, err)
+
+	// a Docker Engine having alpine:3.11 only
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+	})
+	mux.HandleFunc("/v1.40/images/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1.40/images/alpine:3.11/json" {
+			w.Write([]byte(`{"Id": "sha256:0123", "RepoTags": ["alpine:3.11", "alpine:latest"], "RepoDigests": ["alpine@sha256:4567"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "no such image"}`))
+	})
+	socket := filepath.Join(dir, "docker.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	s := &http.Server{Handler: mux}
+	go s.Serve(l)
+
+	oldHost := os.Getenv("DOCKER_HOST")
+	require.NoError(t, os.Setenv("DOCKER_HOST", "unix://"+socket))
+	return func() {
+		os.Setenv("DOCKER_HOST", oldHost)
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestHasDockerImage(t *testing.T) {
+	defer serveDocker(t)()
+
+	ok, err := HasDockerImage(context.Background(), "alpine:3.11")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = HasDockerImage(context.Background(), "alpine:3.10")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestHasImage(t *testing.T) {
+	defer serveDocker(t)()
+
+	ok, err := HasImage(context.Background(), "alpine:3.11", Auto, Option{})
+	require.NoError(t, err)
+	assert.True(t, ok, "Docker Engine")
+
+	// a Podman service of the same API, having the same images
+	ok, err = HasImage(context.Background(), "alpine:3.10", Podman,
+		Option{Podman: podman.Option{Socket: strings.TrimPrefix(os.Getenv("DOCKER_HOST"), "unix://")}})
+	require.NoError(t, err)
+	assert.False(t, ok, "Podman")
+}
+
+func TestInspectDockerImage(t *testing.T) {
+	defer serveDocker(t)()
+
+	repoTags, repoDigests, err := InspectDockerImage(context.Background(), "alpine:3.11")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpine:3.11", "alpine:latest"}, repoTags)
+	assert.Equal(t, []string{"alpine@sha256:4567"}, repoDigests)
+
+	_, _, err = InspectDockerImage(context.Background(), "alpine:3.10")
+	require.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/sftp"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// Extractor reads the files required by analyzers from a local directory, e.g. an extracted rootfs or a CI workspace.
+// As over SFTP, the directory is regarded as the root filesystem of an image with a single layer,
+// but the lock files are searched in the whole directory.
+type Extractor struct {
+	*sftp.Extractor
+	root string
+}
+
+// NewExtractor returns the extractor of the directory. Its image name is the path of the directory.
+func NewExtractor(root string) (*Extractor, error) {
+	absRoot, err := absDir(root)
+	if err != nil {
+		return nil, err
+	}
+	return newExtractor(root, absRoot, []string{"/"}), nil
+}
+
+// rootfsSkipDirs are the pseudo filesystems of a running host under the root, not searched for lock files
+var rootfsSkipDirs = []string{"proc", "sys", "dev", "run"}
+
+// NewRootfsExtractor returns the extractor of a root filesystem, e.g. of docker export, a mounted VM disk or a chroot.
+// The lock files are searched in its directories except the pseudo filesystems of a running host, e.g. /proc,
+// and the symlinks to directories, e.g. /bin to /usr/bin, which would be searched twice.
+func NewRootfsExtractor(root string) (*Extractor, error) {
+	absRoot, err := absDir(root)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(absRoot)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read %s: %w", root, err)
+	}
+	var appDirs []string
+	for _, fi := range fis {
+		if fi.IsDir() && !utils.StringInSlice(fi.Name(), rootfsSkipDirs) {
+			appDirs = append(appDirs, "/"+fi.Name())
+		}
+	}
+	return newExtractor(root, absRoot, appDirs), nil
+}
+
+func newExtractor(root, absRoot string, appDirs []string) *Extractor {
+	opt := sftp.Option{
+		Host:     "localhost",
+		Root:     filepath.ToSlash(absRoot),
+		AppDirs:  appDirs,
+		Symlinks: sftp.SymlinkFollow,
+	}
+	return &Extractor{Extractor: sftp.NewBackendExtractor(localBackend{}, opt), root: root}
+}
+
+// absDir returns the absolute path of the directory
+func absDir(root string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", xerrors.Errorf("invalid path (%s): %w", root, err)
+	}
+	fi, err := os.Stat(absRoot)
+	if err != nil {
+		return "", xerrors.Errorf("unable to stat %s: %w", root, err)
+	} else if !fi.IsDir() {
+		return "", xerrors.Errorf("%s is not a directory", root)
+	}
+	return absRoot, nil
+}
+
+func (e *Extractor) ImageName() string {
+	return e.root
+}
+
+// localBackend reads the local files as the SFTP backend reads the remote ones
+type localBackend struct{}
+
+func (localBackend) Open(path string) (io.ReadCloser, error) {
+	return os.Open(filepath.FromSlash(path))
+}
+
+// ReadDir doesn't follow the symlinks as ioutil.ReadDir uses lstat
+func (localBackend) ReadDir(path string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(filepath.FromSlash(path))
+}
+
+func (localBackend) ReadLink(path string) (string, error) {
+	return os.Readlink(filepath.FromSlash(path))
+}
+
+func (localBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/aquasecurity/fanal/analyzer/library/npm"
+	_ "github.com/aquasecurity/fanal/analyzer/os/alpine"
+	"github.com/aquasecurity/fanal/extractor"
+)
+
+func TestExtractor_ExtractLayerFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "fs")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"etc/alpine-release":                   "3.11.5",
+		"app/package-lock.json":                "{}",
+		"app/node_modules/a/package-lock.json": "{}",
+		"app/README.md":                        "not required",
+	}
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+
+	e, err := NewExtractor(root)
+	require.NoError(t, err)
+	assert.Equal(t, root, e.ImageName())
+
+	layerIDs, err := e.LayerIDs()
+	require.NoError(t, err)
+	require.Len(t, layerIDs, 1)
+
+	_, got, _, _, err := e.ExtractLayerFiles(layerIDs[0], nil)
+	require.NoError(t, err)
+	assert.Equal(t, extractor.FileMap{
+		"etc/alpine-release":    []byte("3.11.5"),
+		"app/package-lock.json": []byte("{}"),
+	}, got)
+}
+
+func TestNewExtractor(t *testing.T) {
+	f, err := ioutil.TempFile("", "fs")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Close()
+
+	_, err = NewExtractor(f.Name())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a directory")
+
+	_, err = NewExtractor(filepath.Join(f.Name(), "unknown"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to stat")
+}
+
+func TestNewRootfsExtractor(t *testing.T) {
+	root, err := ioutil.TempDir("", "rootfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"etc/alpine-release":                 "3.11.5",
+		"usr/lib/app/package-lock.json":      "{}",
+		"proc/1/root/app/package-lock.json":  "{}",
+		"run/containerd/package-lock.json":   "{}",
+		"srv/www/package-lock.json":          "{}",
+		"usr/lib/app/node_modules/README.md": "not required",
+	}
+	for name, content := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+	// a merged /usr, the lock files of /lib are those of /usr/lib
+	require.NoError(t, os.Symlink("usr/lib", filepath.Join(root, "lib")))
+
+	e, err := NewRootfsExtractor(root)
+	require.NoError(t, err)
+	assert.Equal(t, root, e.ImageName())
+
+	layerIDs, err := e.LayerIDs()
+	require.NoError(t, err)
+	_, got, _, _, err := e.ExtractLayerFiles(layerIDs[0], nil)
+	require.NoError(t, err)
+	assert.Equal(t, extractor.FileMap{
+		"etc/alpine-release":            []byte("3.11.5"),
+		"usr/lib/app/package-lock.json": []byte("{}"),
+		"srv/www/package-lock.json":     []byte("{}"),
+	}, got)
+}
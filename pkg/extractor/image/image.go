@@ -0,0 +1,253 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/analyzer/library"
+	"github.com/aquasecurity/fanal/extractor"
+	fimage "github.com/aquasecurity/fanal/extractor/image"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/fanal/utils"
+)
+
+const (
+	opq = ".wh..wh..opq"
+	wh  = ".wh."
+)
+
+// Extractor reads the files of an image of go-containerregistry as the Docker extractor of fanal does,
+// but with the path.Match patterns of the file names, e.g. "*.jar", and the size limit of the files
+type Extractor struct {
+	image     v1.Image
+	imageName string
+
+	mu sync.Mutex
+	// maxSize is the size limit of the files read, none when zero or negative,
+	// and skipped are the sizes of the larger files by path by layer
+	maxSize int64
+	skipped map[string]map[string]int64
+}
+
+// NewExtractor returns the extractor of the image, named imageName in the results
+func NewExtractor(img v1.Image, imageName string) *Extractor {
+	return &Extractor{image: img, imageName: imageName, skipped: map[string]map[string]int64{}}
+}
+
+// SetMaxFileSize skips the files larger than the size in bytes of their tar headers, without reading them.
+// Zero or a negative size disables it.
+func (e *Extractor) SetMaxFileSize(size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maxSize = size
+}
+
+// SkippedFiles returns the sizes of the files of the layer skipped by the size limit of its last extraction, by path
+func (e *Extractor) SkippedFiles(diffID string) map[string]int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.skipped[diffID]
+}
+
+// NewDockerExtractor returns the extractor of the image of the Docker daemon, or of its registry when the daemon
+// doesn't have it, as the Docker extractor of fanal
+func NewDockerExtractor(ctx context.Context, imageName string, option ftypes.DockerOption) (*Extractor, func(), error) {
+	img, cleanup, err := fimage.NewDockerImage(ctx, imageName, option)
+	if err != nil {
+		return nil, func() {}, xerrors.Errorf("unable to initialize a image struct: %w", err)
+	}
+	return NewExtractor(img, imageName), cleanup, nil
+}
+
+// NewDockerArchiveExtractor returns the extractor of the image of a `docker save` archive, named after the file
+func NewDockerArchiveExtractor(fileName string) (*Extractor, error) {
+	img, err := fimage.NewDockerArchiveImage(fileName)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to initialize a image struct: %w", err)
+	}
+	return NewExtractor(img, fileName), nil
+}
+
+func (e *Extractor) ImageName() string {
+	return e.imageName
+}
+
+func (e *Extractor) ImageID() (string, error) {
+	h, err := e.image.ConfigName()
+	if err != nil {
+		return "", xerrors.Errorf("unable to get the image ID: %w", err)
+	}
+	return h.String(), nil
+}
+
+func (e *Extractor) ConfigBlob() ([]byte, error) {
+	return e.image.RawConfigFile()
+}
+
+func (e *Extractor) LayerIDs() ([]string, error) {
+	conf, err := e.image.ConfigFile()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the config file: %w", err)
+	}
+
+	var layerIDs []string
+	for _, d := range conf.RootFS.DiffIDs {
+		layerIDs = append(layerIDs, d.String())
+	}
+	return layerIDs, nil
+}
+
+// LayerSizes returns the size in bytes of the layers by diff ID, as stored, i.e. compressed for compressed layers
+func (e *Extractor) LayerSizes() (map[string]int64, error) {
+	layers, err := e.image.Layers()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get the layers: %w", err)
+	}
+
+	sizes := map[string]int64{}
+	for _, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get the layer ID: %w", err)
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return nil, xerrors.Errorf("unable to get the size of the layer (%s): %w", diffID, err)
+		}
+		sizes[diffID.String()] = size
+	}
+	return sizes, nil
+}
+
+// ExtractLayerFiles reads the files of the layer, returning the digest
+// of the compressed layer, or an empty one for an uncompressed layer
+func (e *Extractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	h, err := v1.NewHash(diffID)
+	if err != nil {
+		return "", nil, nil, nil, xerrors.Errorf("invalid layer ID (%s): %w", diffID, err)
+	}
+	layer, err := e.image.LayerByDiffID(h)
+	if err != nil {
+		return "", nil, nil, nil, xerrors.Errorf("failed to get the layer (%s): %w", diffID, err)
+	}
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return "", nil, nil, nil, xerrors.Errorf("failed to get the media type (%s): %w", diffID, err)
+	}
+
+	var digest string
+	var rc io.ReadCloser
+	switch mediaType {
+	case types.OCIUncompressedLayer, types.OCIUncompressedRestrictedLayer, types.DockerUncompressedLayer:
+		// the blob is the tar itself
+		rc, err = layer.Compressed()
+	default:
+		var d v1.Hash
+		if d, err = layer.Digest(); err != nil {
+			return "", nil, nil, nil, xerrors.Errorf("failed to get the digest (%s): %w", diffID, err)
+		}
+		digest = d.String()
+		rc, err = layer.Uncompressed()
+	}
+	if err != nil {
+		return "", nil, nil, nil, xerrors.Errorf("failed to get the layer content (%s): %w", diffID, err)
+	}
+	defer rc.Close()
+
+	e.mu.Lock()
+	maxSize := e.maxSize
+	e.mu.Unlock()
+	files, opqDirs, whFiles, skipped, err := extractFiles(rc, filenames, maxSize)
+	if err != nil {
+		return "", nil, nil, nil, xerrors.Errorf("failed to extract files: %w", err)
+	}
+	e.mu.Lock()
+	e.skipped[diffID] = skipped
+	e.mu.Unlock()
+	return digest, files, opqDirs, whFiles, nil
+}
+
+// extractFiles reads the required files of the layer, except those larger than maxSize when it is positive,
+// returned with their sizes
+func extractFiles(layer io.Reader, filenames []string, maxSize int64) (extractor.FileMap, []string, []string,
+	map[string]int64, error) {
+	files := extractor.FileMap{}
+	skipped := map[string]int64{}
+	var opqDirs, whFiles []string
+
+	tr := tar.NewReader(layer)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, nil, nil, xerrors.Errorf("failed to extract the archive: %w", err)
+		}
+
+		filePath := strings.TrimLeft(filepath.Clean(hdr.Name), "/")
+		fileDir, fileName := filepath.Split(filePath)
+
+		// e.g. etc/.wh..wh..opq
+		if fileName == opq {
+			opqDirs = append(opqDirs, fileDir)
+			continue
+		}
+		// e.g. etc/.wh.hostname
+		if strings.HasPrefix(fileName, wh) {
+			whFiles = append(whFiles, filepath.Join(fileDir, strings.TrimPrefix(fileName, wh)))
+			continue
+		}
+
+		if isIgnored(filePath) || !matches(filePath, fileName, filenames) {
+			continue
+		}
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink || hdr.Typeflag == tar.TypeReg {
+			if maxSize > 0 && hdr.Size > maxSize {
+				skipped[filePath] = hdr.Size
+				continue
+			}
+			b, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, nil, nil, nil, xerrors.Errorf("failed to read file: %w", err)
+			}
+			files[filePath] = b
+		}
+	}
+	return files, opqDirs, whFiles, skipped, nil
+}
+
+// matches reports whether the file is required: its path or name is one of the file names or matches one of
+// the patterns, e.g. "*.jar", or it is directly in one of the directories ending with a slash
+func matches(filePath, fileName string, filenames []string) bool {
+	for _, s := range filenames {
+		if strings.HasSuffix(s, "/") && filepath.Clean(s) == filepath.Dir(filePath) {
+			return true
+		}
+		if s == filePath || s == fileName {
+			return true
+		}
+		if ok, _ := path.Match(s, fileName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isIgnored(filePath string) bool {
+	for _, dir := range strings.Split(filePath, utils.PathSeparator) {
+		if utils.StringInSlice(dir, library.IgnoreDirs) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package oci
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/utils"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+)
+
+const (
+	layoutFile   = "oci-layout"
+	manifestFile = "manifest.json"
+
+	// the annotations naming the images of the index, set by containerd/buildkit and by podman/skopeo
+	annotationImageName = "io.containerd.image.name"
+	annotationRefName   = "org.opencontainers.image.ref.name"
+)
+
+// Extractor reads an image of an OCI image layout without a daemon, e.g. the output of
+// `podman save --format oci-archive` or `buildctl build --output type=oci`.
+// The image is named after its annotation in the index, or the path of the layout when unnamed.
+type Extractor struct {
+	*image.Extractor
+}
+
+// IsLayout reports whether the input is an OCI image layout: a directory with an oci-layout file,
+// or a tarball of one, gzipped or not. The tarballs of `docker save`, which have a manifest.json, aren't
+// even when they also have an oci-layout file, so that they are still read as Docker archives.
+func IsLayout(fileName string) bool {
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return false
+	}
+	if fi.IsDir() {
+		_, err = os.Stat(filepath.Join(fileName, layoutFile))
+		return err == nil
+	}
+
+	var hasLayout, hasManifest bool
+	err = walkTar(fileName, func(hdr *tar.Header, _ io.Reader) error {
+		switch strings.TrimPrefix(hdr.Name, "./") {
+		case layoutFile:
+			hasLayout = true
+		case manifestFile:
+			hasManifest = true
+			return io.EOF
+		}
+		return nil
+	})
+	return err == nil && hasLayout && !hasManifest
+}
+
+// NewExtractor returns the extractor of the single image of the layout. The images of a multi-platform index
+// are narrowed down to the one of linux and the architecture of Trivy, and the layouts with several images
+// are rejected otherwise as it isn't known which one to scan. A tarball is extracted to a temporary directory,
+// removed by the returned function.
+func NewExtractor(fileName string) (*Extractor, func(), error) {
+	cleanup := func() {}
+	root := fileName
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return nil, cleanup, xerrors.Errorf("unable to stat %s: %w", fileName, err)
+	}
+	if !fi.IsDir() {
+		if root, err = ioutil.TempDir("", "trivy-oci"); err != nil {
+			return nil, cleanup, xerrors.Errorf("unable to create a temporary directory: %w", err)
+		}
+		cleanup = func() { os.RemoveAll(root) }
+		if err = extractLayout(fileName, root); err != nil {
+			cleanup()
+			return nil, func() {}, xerrors.Errorf("unable to extract %s: %w", fileName, err)
+		}
+	}
+
+	img, imageName, err := readImage(root)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, xerrors.Errorf("invalid OCI layout %s: %w", fileName, err)
+	}
+	if imageName == "" {
+		imageName = fileName
+	}
+	return &Extractor{Extractor: image.NewExtractor(img, imageName)}, cleanup, nil
+}
+
+// candidate is an image manifest of the layout, with the index referring to it
+type candidate struct {
+	index v1.ImageIndex
+	desc  v1.Descriptor
+	name  string
+}
+
+func readImage(root string) (v1.Image, string, error) {
+	index, err := layout.ImageIndexFromPath(root)
+	if err != nil {
+		return nil, "", xerrors.Errorf("unable to read the index: %w", err)
+	}
+	candidates, err := listImages(index, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(candidates) > 1 {
+		var matched []candidate
+		for _, c := range candidates {
+			if p := c.desc.Platform; p != nil && p.OS == "linux" && p.Architecture == runtime.GOARCH {
+				matched = append(matched, c)
+			}
+		}
+		if len(matched) == 1 {
+			candidates = matched
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, "", xerrors.New("no image")
+	case len(candidates) > 1:
+		var names []string
+		for _, c := range candidates {
+			name := c.name
+			if name == "" {
+				name = c.desc.Digest.String()
+			}
+			if p := c.desc.Platform; p != nil {
+				name += " (" + p.OS + "/" + p.Architecture + ")"
+			}
+			names = append(names, name)
+		}
+		return nil, "", xerrors.Errorf("%d images (%s): save the image to scan alone", len(candidates), strings.Join(names, "; "))
+	}
+
+	c := candidates[0]
+	img, err := c.index.Image(c.desc.Digest)
+	if err != nil {
+		return nil, "", xerrors.Errorf("unable to read the image %s: %w", c.desc.Digest, err)
+	}
+	return img, c.name, nil
+}
+
+// listImages returns the image manifests of the index and of the nested ones, named after the annotation
+// of their top-level descriptor
+func listImages(index v1.ImageIndex, name string) ([]candidate, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the index manifest: %w", err)
+	}
+
+	var candidates []candidate
+	for _, desc := range manifest.Manifests {
+		descName := name
+		if descName == "" {
+			descName = desc.Annotations[annotationImageName]
+		}
+		if descName == "" {
+			descName = desc.Annotations[annotationRefName]
+		}
+
+		switch desc.MediaType {
+		case types.OCIImageIndex, types.DockerManifestList:
+			child, err := index.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, xerrors.Errorf("unable to read the index %s: %w", desc.Digest, err)
+			}
+			children, err := listImages(child, descName)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, children...)
+		case types.OCIManifestSchema1, types.DockerManifestSchema2:
+			candidates = append(candidates, candidate{index: index, desc: desc, name: descName})
+		}
+	}
+	return candidates, nil
+}
+
+// extractLayout extracts the regular files and directories of the tarball under root,
+// rejecting the paths escaping it
+func extractLayout(fileName, root string) error {
+	return walkTar(fileName, func(hdr *tar.Header, r io.Reader) error {
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return xerrors.Errorf("invalid path: %s", hdr.Name)
+		}
+		target := filepath.Join(root, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(target, 0700)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err = io.Copy(f, r); err != nil {
+				return err
+			}
+			return f.Close()
+		}
+		return nil
+	})
+}
+
+// walkTar calls fn for each entry of the tarball, gzipped or not, until it returns an error.
+// io.EOF stops the walk without an error.
+func walkTar(fileName string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return xerrors.Errorf("unable to open the file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader
+	br := bufio.NewReader(f)
+	r = br
+	if utils.IsGzip(br) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return xerrors.Errorf("invalid gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return xerrors.Errorf("invalid tar: %w", err)
+		}
+		if err = fn(hdr, tr); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarBytes returns a tar of the files, in the order of the names
+func tarBytes(t *testing.T, names []string, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		content := files[name]
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func newImage(t *testing.T, files map[string]string) v1.Image {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	b := tarBytes(t, names, files)
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	})
+	require.NoError(t, err)
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+	return img
+}
+
+// tarLayout writes the layout directory as a gzipped tarball
+func tarLayout(t *testing.T, dir, fileName string) {
+	f, err := os.Create(fileName)
+	require.NoError(t, err)
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(b))}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestNewExtractor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	img := newImage(t, map[string]string{
+		"etc/alpine-release":                 "3.11.5",
+		"etc/.wh.hostname":                   "",
+		"usr/.wh..wh..opq":                   "",
+		"app/node_modules/package-lock.json": "{}",
+		"app/package-lock.json":              "{}",
+		"usr/share/doc/bash/copyright":       "License: GPL-3",
+	})
+	root := filepath.Join(dir, "layout")
+	p, err := layout.Write(root, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		annotationRefName: "alpine:3.11",
+	})))
+	archive := filepath.Join(dir, "alpine.tar.gz")
+	tarLayout(t, root, archive)
+
+	for _, input := range []string{root, archive} {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			assert.True(t, IsLayout(input))
+
+			e, cleanup, err := NewExtractor(input)
+			require.NoError(t, err)
+			defer cleanup()
+			assert.Equal(t, "alpine:3.11", e.ImageName())
+
+			wantID, err := img.ConfigName()
+			require.NoError(t, err)
+			imageID, err := e.ImageID()
+			require.NoError(t, err)
+			assert.Equal(t, wantID.String(), imageID)
+
+			layerIDs, err := e.LayerIDs()
+			require.NoError(t, err)
+			require.Len(t, layerIDs, 1)
+
+			layers, err := img.Layers()
+			require.NoError(t, err)
+			wantSize, err := layers[0].Size()
+			require.NoError(t, err)
+			sizes, err := e.LayerSizes()
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int64{layerIDs[0]: wantSize}, sizes)
+
+			digest, files, opqDirs, whFiles, err := e.ExtractLayerFiles(layerIDs[0],
+				[]string{"etc/alpine-release", "package-lock.json", "usr/share/doc/bash/"})
+			require.NoError(t, err)
+			wantDigest, err := layers[0].Digest()
+			require.NoError(t, err)
+			assert.Equal(t, wantDigest.String(), digest)
+			assert.Equal(t, map[string][]byte{
+				"etc/alpine-release":           []byte("3.11.5"),
+				"app/package-lock.json":        []byte("{}"),
+				"usr/share/doc/bash/copyright": []byte("License: GPL-3"),
+			}, map[string][]byte(files))
+			assert.Equal(t, []string{"usr/"}, opqDirs)
+			assert.Equal(t, []string{"etc/hostname"}, whFiles)
+		})
+	}
+}
+
+func TestNewExtractor_Index(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	native := newImage(t, map[string]string{"etc/alpine-release": "3.11.5"})
+	other := newImage(t, map[string]string{"etc/alpine-release": "3.11.6"})
+	otherArch := "s390x"
+	if runtime.GOARCH == otherArch {
+		otherArch = "arm64"
+	}
+	multiPlatform := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: native, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: runtime.GOARCH}}},
+		mutate.IndexAddendum{Add: other, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: otherArch}}},
+	)
+
+	tests := []struct {
+		name          string
+		write         func(p layout.Path) error
+		wantImage     v1.Image
+		wantImageName string
+		wantErr       string
+	}{
+		{
+			name: "happy path: the platform of Trivy",
+			write: func(p layout.Path) error {
+				return p.AppendIndex(multiPlatform, layout.WithAnnotations(map[string]string{
+					annotationImageName: "docker.io/library/alpine:3.11",
+				}))
+			},
+			wantImage:     native,
+			wantImageName: "docker.io/library/alpine:3.11",
+		},
+		{
+			name: "happy path: unnamed",
+			write: func(p layout.Path) error {
+				return p.AppendImage(native)
+			},
+			wantImage: native,
+		},
+		{
+			name: "sad path: several images",
+			write: func(p layout.Path) error {
+				if err := p.AppendImage(native, layout.WithAnnotations(map[string]string{annotationRefName: "3.11.5"})); err != nil {
+					return err
+				}
+				return p.AppendImage(other, layout.WithAnnotations(map[string]string{annotationRefName: "3.11.6"}))
+			},
+			wantErr: "2 images (3.11.5; 3.11.6): save the image to scan alone",
+		},
+		{
+			name: "sad path: no image",
+			write: func(p layout.Path) error {
+				return nil
+			},
+			wantErr: "no image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir(dir, "layout")
+			require.NoError(t, err)
+			p, err := layout.Write(root, empty.Index)
+			require.NoError(t, err)
+			require.NoError(t, tt.write(p))
+
+			e, cleanup, err := NewExtractor(root)
+			defer cleanup()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			wantImageName := tt.wantImageName
+			if wantImageName == "" {
+				wantImageName = root
+			}
+			assert.Equal(t, wantImageName, e.ImageName())
+			wantID, err := tt.wantImage.ConfigName()
+			require.NoError(t, err)
+			imageID, err := e.ImageID()
+			require.NoError(t, err)
+			assert.Equal(t, wantID.String(), imageID)
+		})
+	}
+}
+
+func TestIsLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name  string
+		files []string
+		want  bool
+	}{
+		{
+			name:  "OCI archive",
+			files: []string{"oci-layout", "index.json"},
+			want:  true,
+		},
+		{
+			name:  "Docker archive with an OCI layout",
+			files: []string{"oci-layout", "index.json", "manifest.json"},
+			want:  false,
+		},
+		{
+			name:  "Docker archive",
+			files: []string{"manifest.json"},
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := filepath.Join(dir, tt.name+".tar")
+			require.NoError(t, ioutil.WriteFile(fileName, tarBytes(t, tt.files, map[string]string{}), 0644))
+			assert.Equal(t, tt.want, IsLayout(fileName))
+		})
+	}
+
+	assert.False(t, IsLayout(dir))
+	assert.False(t, IsLayout(filepath.Join(dir, "missing")))
+}
+
+func TestNewExtractor_PathTraversal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "evil.tar")
+	b := tarBytes(t, []string{"oci-layout", "../evil"}, map[string]string{})
+	require.NoError(t, ioutil.WriteFile(fileName, b, 0644))
+
+	_, cleanup, err := NewExtractor(fileName)
+	defer cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid path: ../evil")
+}
@@ -0,0 +1,112 @@
+package podman
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+)
+
+// DefaultSocket is the socket of the rootful Podman service
+const DefaultSocket = "/run/podman/podman.sock"
+
+// Option is the Podman service to read the images from, through its Docker-compatible API
+type Option struct {
+	// Socket is the socket of the service. The rootful then the rootless sockets are tried when empty.
+	Socket string
+}
+
+// Extractor reads an image saved by the Podman service, for the hosts running Podman without Docker
+type Extractor struct {
+	*image.Extractor
+}
+
+// Socket returns the socket of the Podman service: the one of the option, the rootful one, or the rootless one
+// of $XDG_RUNTIME_DIR, whichever exists first. It returns "" when none exists.
+func Socket(opt Option) string {
+	sockets := []string{opt.Socket}
+	if opt.Socket == "" {
+		sockets = []string{DefaultSocket}
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			sockets = append(sockets, filepath.Join(dir, "podman", "podman.sock"))
+		}
+	}
+	for _, socket := range sockets {
+		if fi, err := os.Stat(socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return socket
+		}
+	}
+	return ""
+}
+
+// NewExtractor returns the extractor of the image in Podman. The image is saved to a temporary file,
+// removed by the returned function.
+func NewExtractor(ctx context.Context, imageName string, opt Option) (*Extractor, func(), error) {
+	c, err := newClient(opt)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	defer c.Close()
+
+	if _, _, err = c.ImageInspectWithRaw(ctx, imageName); err != nil {
+		return nil, func() {}, xerrors.Errorf("unable to inspect %s in Podman: %w", imageName, err)
+	}
+	rc, err := c.ImageSave(ctx, []string{imageName})
+	if err != nil {
+		return nil, func() {}, xerrors.Errorf("unable to save %s from Podman: %w", imageName, err)
+	}
+	defer rc.Close()
+
+	f, err := ioutil.TempFile("", "trivy-podman")
+	if err != nil {
+		return nil, func() {}, xerrors.Errorf("unable to create a temporary file: %w", err)
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	defer f.Close()
+	if _, err = io.Copy(f, rc); err != nil {
+		cleanup()
+		return nil, func() {}, xerrors.Errorf("failed to save %s from Podman: %w", imageName, err)
+	}
+
+	img, err := tarball.ImageFromPath(f.Name(), nil)
+	if err != nil {
+		cleanup()
+		return nil, func() {}, xerrors.Errorf("unable to read %s saved by Podman: %w", imageName, err)
+	}
+	return &Extractor{Extractor: image.NewExtractor(img, imageName)}, cleanup, nil
+}
+
+// HasImage reports whether Podman has the image
+func HasImage(ctx context.Context, imageName string, opt Option) (bool, error) {
+	c, err := newClient(opt)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+
+	if _, _, err = c.ImageInspectWithRaw(ctx, imageName); client.IsErrNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, xerrors.Errorf("unable to inspect %s in Podman: %w", imageName, err)
+	}
+	return true, nil
+}
+
+func newClient(opt Option) (*client.Client, error) {
+	socket := Socket(opt)
+	if socket == "" {
+		return nil, xerrors.New("no socket of Podman found, enable it with `systemctl enable --now podman.socket`")
+	}
+	c, err := client.NewClientWithOpts(client.WithHost("unix://"+socket), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, xerrors.Errorf("unable to connect to Podman (%s): %w", socket, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,149 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImage(t *testing.T, files map[string]string) v1.Image {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	b := buf.Bytes()
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	})
+	require.NoError(t, err)
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+	return img
+}
+
+// serve starts a fake Podman service with the image on a socket of dir
+func serve(t *testing.T, dir, imageName string, img v1.Image) (string, func()) {
+	tag, err := name.NewTag(imageName)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/v1.40/images/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1.40/images/get" && r.URL.Query().Get("names") == imageName:
+			require.NoError(t, tarball.Write(tag, img, w))
+		case r.URL.Path == "/v1.40/images/"+imageName+"/json":
+			w.Write([]byte(`{"Id": "sha256:0123"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "no such image"}`))
+		}
+	})
+
+	socket := filepath.Join(dir, "podman.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	s := &http.Server{Handler: mux}
+	go s.Serve(l)
+	return socket, func() { s.Close() }
+}
+
+func TestNewExtractor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podman")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	img := newImage(t, map[string]string{"etc/alpine-release": "3.11.5"})
+	socket, stop := serve(t, dir, "alpine:3.11", img)
+	defer stop()
+
+	e, cleanup, err := NewExtractor(context.Background(), "alpine:3.11", Option{Socket: socket})
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, "alpine:3.11", e.ImageName())
+
+	wantID, err := img.ConfigName()
+	require.NoError(t, err)
+	imageID, err := e.ImageID()
+	require.NoError(t, err)
+	assert.Equal(t, wantID.String(), imageID)
+
+	layerIDs, err := e.LayerIDs()
+	require.NoError(t, err)
+	require.Len(t, layerIDs, 1)
+	_, files, _, _, err := e.ExtractLayerFiles(layerIDs[0], []string{"etc/alpine-release"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"etc/alpine-release": []byte("3.11.5")}, map[string][]byte(files))
+
+	_, cleanup, err = NewExtractor(context.Background(), "alpine:3.10", Option{Socket: socket})
+	defer cleanup()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to inspect alpine:3.10 in Podman")
+}
+
+func TestHasImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podman")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socket, stop := serve(t, dir, "alpine:3.11", newImage(t, map[string]string{"etc/alpine-release": "3.11.5"}))
+	defer stop()
+
+	got, err := HasImage(context.Background(), "alpine:3.11", Option{Socket: socket})
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	got, err = HasImage(context.Background(), "alpine:3.10", Option{Socket: socket})
+	require.NoError(t, err)
+	assert.False(t, got)
+
+	_, err = HasImage(context.Background(), "alpine:3.11", Option{Socket: filepath.Join(dir, "missing.sock")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no socket of Podman found")
+}
+
+func TestSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "podman")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "podman"), 0700))
+	rootless := filepath.Join(dir, "podman", "podman.sock")
+	l, err := net.Listen("unix", rootless)
+	require.NoError(t, err)
+	defer l.Close()
+
+	oldDir := os.Getenv("XDG_RUNTIME_DIR")
+	defer os.Setenv("XDG_RUNTIME_DIR", oldDir)
+	os.Setenv("XDG_RUNTIME_DIR", dir)
+
+	// the rootless socket unless the rootful one exists on the host running the test
+	got := Socket(Option{})
+	assert.True(t, got == rootless || got == DefaultSocket, got)
+	assert.Equal(t, rootless, Socket(Option{Socket: rootless}))
+	assert.Equal(t, "", Socket(Option{Socket: filepath.Join(dir, "missing.sock")}))
+	assert.Equal(t, "", Socket(Option{Socket: dir}))
+}
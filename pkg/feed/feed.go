@@ -0,0 +1,162 @@
+package feed
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+	"k8s.io/utils/clock"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/vulnerability"
+)
+
+// DefaultTTL is the age after which a cached feed is downloaded again, as EPSS and KEV are published daily
+const DefaultTTL = 24 * time.Hour
+
+// Feed is a file published on the web and cached in the cache directory
+type Feed struct {
+	// Name is the name of the file in the cache directory
+	Name string
+	URL  string
+	// Gzip reports whether the published file is gzipped; it is cached decompressed
+	Gzip bool
+}
+
+var (
+	// EPSS are the EPSS scores of all the CVEs published by FIRST
+	EPSS = Feed{
+		Name: "epss_scores.csv",
+		URL:  "https://epss.cyentia.com/epss_scores-current.csv.gz",
+		Gzip: true,
+	}
+	// KEV is the Known Exploited Vulnerabilities catalog of CISA
+	KEV = Feed{
+		Name: "known_exploited_vulnerabilities.json",
+		URL:  "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json",
+	}
+)
+
+// Client downloads the feeds into the feeds directory of the cache directory
+type Client struct {
+	dir   string
+	ttl   time.Duration
+	clock clock.Clock
+}
+
+func NewClient(cacheDir string, ttl time.Duration, clock clock.Clock) Client {
+	return Client{
+		dir:   filepath.Join(cacheDir, "feeds"),
+		ttl:   ttl,
+		clock: clock,
+	}
+}
+
+// EPSSScores returns the EPSS probability per CVE ID, see Open
+func (c Client) EPSSScores(ctx context.Context, skipUpdate bool) (map[string]float64, error) {
+	f, err := c.Open(ctx, EPSS, skipUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return vulnerability.ParseEPSS(f)
+}
+
+// KnownExploited returns the CVE IDs of the KEV catalog, see Open
+func (c Client) KnownExploited(ctx context.Context, skipUpdate bool) (map[string]bool, error) {
+	f, err := c.Open(ctx, KEV, skipUpdate)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return vulnerability.ParseKEV(f)
+}
+
+// Open opens the cached feed, downloading it first when it is missing or older than the TTL.
+// With skipUpdate the cached feed is used however old it is, and it must exist.
+// When the download fails, a cached feed is still used with a warning.
+func (c Client) Open(ctx context.Context, feed Feed, skipUpdate bool) (*os.File, error) {
+	path := filepath.Join(c.dir, feed.Name)
+	stat, err := os.Stat(path)
+	switch {
+	case err == nil && (skipUpdate || c.clock.Since(stat.ModTime()) < c.ttl):
+		log.Logger.Debugf("Using the cached %s updated at %s", feed.Name, stat.ModTime().Format(time.RFC3339))
+	case err != nil && !os.IsNotExist(err):
+		return nil, xerrors.Errorf("unable to stat %s: %w", path, err)
+	case skipUpdate:
+		return nil, xerrors.Errorf("%s not found and updates are skipped", feed.Name)
+	default:
+		log.Logger.Infof("Downloading %s...", feed.URL)
+		if dlErr := c.download(ctx, feed, path); dlErr != nil {
+			if err != nil {
+				return nil, xerrors.Errorf("failed to download %s: %w", feed.URL, dlErr)
+			}
+			log.Logger.Warnf("Using the cached %s updated at %s as the download failed: %s",
+				feed.Name, stat.ModTime().Format(time.RFC3339), dlErr)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// download replaces the cached feed once it is fully downloaded, so that a failed download keeps the cached one
+func (c Client) download(ctx context.Context, feed Feed, path string) error {
+	req, err := http.NewRequest(http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return xerrors.Errorf("%s: %s", feed.URL, resp.Status)
+	}
+
+	var r io.Reader = resp.Body
+	if feed.Gzip {
+		gr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return xerrors.Errorf("invalid gzip file: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	if err = os.MkdirAll(c.dir, 0700); err != nil {
+		return xerrors.Errorf("failed to mkdir: %w", err)
+	}
+	tmp, err := ioutil.TempFile(c.dir, feed.Name+".*")
+	if err != nil {
+		return xerrors.Errorf("unable to create a temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		return xerrors.Errorf("failed to save %s: %w", feed.Name, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return xerrors.Errorf("failed to save %s: %w", feed.Name, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return xerrors.Errorf("failed to save %s: %w", feed.Name, err)
+	}
+	// the modification time is the update time compared with the TTL
+	now := c.clock.Now()
+	if err = os.Chtimes(path, now, now); err != nil {
+		return xerrors.Errorf("unable to set the update time of %s: %w", feed.Name, err)
+	}
+	return nil
+}
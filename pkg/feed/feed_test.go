@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestClient_Open(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/feed.json":
+			w.Write([]byte("new"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Date(2022, 2, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		url          string
+		cachedAt     time.Time
+		skipUpdate   bool
+		want         string
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "not cached",
+			url:          ts.URL + "/feed.json",
+			want:         "new",
+			wantRequests: 1,
+		},
+		{
+			name:     "cached",
+			url:      ts.URL + "/feed.json",
+			cachedAt: now.Add(-time.Hour),
+			want:     "cached",
+		},
+		{
+			name:         "expired",
+			url:          ts.URL + "/feed.json",
+			cachedAt:     now.Add(-25 * time.Hour),
+			want:         "new",
+			wantRequests: 1,
+		},
+		{
+			name:       "expired with skipped updates",
+			url:        ts.URL + "/feed.json",
+			cachedAt:   now.Add(-25 * time.Hour),
+			skipUpdate: true,
+			want:       "cached",
+		},
+		{
+			name:         "expired and the download fails",
+			url:          ts.URL + "/broken.json",
+			cachedAt:     now.Add(-25 * time.Hour),
+			want:         "cached",
+			wantRequests: 1,
+		},
+		{
+			name:         "not cached and the download fails",
+			url:          ts.URL + "/broken.json",
+			wantRequests: 1,
+			wantErr:      "failed to download",
+		},
+		{
+			name:       "not cached with skipped updates",
+			url:        ts.URL + "/feed.json",
+			skipUpdate: true,
+			wantErr:    "feed.json not found and updates are skipped",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			cacheDir, err := ioutil.TempDir("", "feed")
+			require.NoError(t, err)
+			defer os.RemoveAll(cacheDir)
+
+			if !tt.cachedAt.IsZero() {
+				path := filepath.Join(cacheDir, "feeds", "feed.json")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+				require.NoError(t, ioutil.WriteFile(path, []byte("cached"), 0600))
+				require.NoError(t, os.Chtimes(path, tt.cachedAt, tt.cachedAt))
+			}
+
+			c := NewClient(cacheDir, DefaultTTL, clocktesting.NewFakeClock(now))
+			f, err := c.Open(context.Background(), Feed{Name: "feed.json", URL: tt.url}, tt.skipUpdate)
+			assert.Equal(t, tt.wantRequests, requests)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer f.Close()
+
+			got, err := ioutil.ReadAll(f)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestClient_EPSSScores(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write([]byte("cve,epss,percentile\nCVE-2019-14697,0.00372,0.70686\n"))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer ts.Close()
+
+	oldEPSS := EPSS
+	defer func() { EPSS = oldEPSS }()
+	EPSS.URL = ts.URL
+
+	cacheDir, err := ioutil.TempDir("", "feed")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	now := time.Date(2022, 2, 4, 12, 0, 0, 0, time.UTC)
+	c := NewClient(cacheDir, DefaultTTL, clocktesting.NewFakeClock(now))
+	got, err := c.EPSSScores(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"CVE-2019-14697": 0.00372}, got)
+
+	// cached decompressed with the time of the clock
+	stat, err := os.Stat(filepath.Join(cacheDir, "feeds", EPSS.Name))
+	require.NoError(t, err)
+	assert.True(t, now.Equal(stat.ModTime()))
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// CloneOption tells which revision of a repository Clone checks out
+type CloneOption struct {
+	URL string
+	// Branch is the branch or the tag to clone; empty clones the default branch
+	Branch string
+	// Commit is the full hash of the commit to check out, fetched alone; it has priority over Branch
+	Commit string
+	// Token authenticates the clone over HTTPS, e.g. a GitHub or GitLab personal access token
+	Token string
+}
+
+// Clone clones a single revision of the repository with the git command into a temporary directory,
+// removed by the returned cleanup function. The .git directory is removed, only the files are kept.
+func Clone(ctx context.Context, opt CloneOption) (string, func(), error) {
+	if opt.URL == "" {
+		return "", nil, xerrors.New("no repository URL")
+	}
+	dir, err := ioutil.TempDir("", "trivy-repo-")
+	if err != nil {
+		return "", nil, xerrors.Errorf("failed to create a temporary directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	if err = clone(ctx, dir, opt); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err = os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		cleanup()
+		return "", nil, xerrors.Errorf("failed to remove the .git directory: %w", err)
+	}
+	return dir, cleanup, nil
+}
+
+func clone(ctx context.Context, dir string, opt CloneOption) error {
+	if opt.Commit == "" {
+		args := []string{"clone", "--quiet", "--depth", "1"}
+		if opt.Branch != "" {
+			args = append(args, "--branch", opt.Branch)
+		}
+		return run(ctx, "", opt.Token, append(args, "--", opt.URL, dir)...)
+	}
+
+	// a commit can't be cloned, it is fetched into an empty repository
+	if err := run(ctx, dir, "", "init", "--quiet"); err != nil {
+		return err
+	}
+	if err := run(ctx, dir, opt.Token, "fetch", "--quiet", "--depth", "1", "--", opt.URL, opt.Commit); err != nil {
+		return err
+	}
+	return run(ctx, dir, "", "checkout", "--quiet", "FETCH_HEAD")
+}
+
+// run runs the git subcommand without prompting for credentials.
+// The token is sent in a header so that it isn't stored in the URL of the remote.
+func run(ctx context.Context, dir, token string, args ...string) error {
+	subcommand := args[0]
+	if token != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		args = append([]string{"-c", "http.extraHeader=Authorization: Basic " + auth}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return xerrors.Errorf("git %s failed: %s: %w", subcommand, strings.TrimSpace(stderr.String()), err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository creates a repository whose Gemfile.lock tells the revision,
+// and returns its URL and the hash of the tagged commit
+func newTestRepository(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "repo")
+	require.NoError(t, err)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Gemfile.lock"), []byte(content), 0644))
+		git("add", "Gemfile.lock")
+		git("commit", "--quiet", "-m", content)
+	}
+
+	git("init", "--quiet")
+	git("checkout", "--quiet", "-b", "main")
+	commit("v1")
+	git("tag", "v1.0")
+	tagged := git("rev-parse", "HEAD")
+	git("checkout", "--quiet", "-b", "feature")
+	commit("feature")
+	git("checkout", "--quiet", "main")
+	commit("v2")
+	return "file://" + filepath.ToSlash(dir), tagged
+}
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	url, tagged := newTestRepository(t)
+	defer os.RemoveAll(strings.TrimPrefix(url, "file://"))
+
+	tests := []struct {
+		name    string
+		opt     CloneOption
+		want    string
+		wantErr string
+	}{
+		{
+			name: "default branch",
+			opt:  CloneOption{URL: url},
+			want: "v2",
+		},
+		{
+			name: "branch",
+			opt:  CloneOption{URL: url, Branch: "feature"},
+			want: "feature",
+		},
+		{
+			name: "tag",
+			opt:  CloneOption{URL: url, Branch: "v1.0"},
+			want: "v1",
+		},
+		{
+			name: "commit",
+			opt:  CloneOption{URL: url, Branch: "feature", Commit: tagged},
+			want: "v1",
+		},
+		{
+			name:    "unknown branch",
+			opt:     CloneOption{URL: url, Branch: "unknown"},
+			wantErr: "git clone failed",
+		},
+		{
+			name:    "no URL",
+			wantErr: "no repository URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup, err := Clone(context.Background(), tt.opt)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			content, err := ioutil.ReadFile(filepath.Join(dir, "Gemfile.lock"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+			_, err = os.Stat(filepath.Join(dir, ".git"))
+			assert.True(t, os.IsNotExist(err))
+
+			cleanup()
+			_, err = os.Stat(dir)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package github
 
 import (
+	"bufio"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,6 +22,12 @@ import (
 const (
 	owner = "aquasecurity"
 	repo  = "trivy-db"
+
+	// DefaultRepository is the repository of the DB releases, used without --db-repository
+	DefaultRepository = owner + "/" + repo
+
+	// checksumSuffix is the suffix of the file of the SHA-256 checksum published with a DB file, e.g. trivy.db.gz.sha256
+	checksumSuffix = ".sha256"
 )
 
 type RepositoryInterface interface {
@@ -42,15 +50,42 @@ func (r Repository) DownloadAsset(ctx context.Context, id int64) (io.ReadCloser,
 	return r.repository.DownloadReleaseAsset(ctx, r.owner, r.repoName, id)
 }
 
+// Asset is a DB file read from Offset, which is 0 when the download isn't resumed, e.g. by a server ignoring the range
+type Asset struct {
+	io.ReadCloser
+	Offset int64
+	// Size is the size of the whole file, 0 when it's unknown
+	Size int64
+	// SHA256 is the hex checksum of the whole file published with it, empty without any
+	SHA256 string
+	// ID identifies the version of the file, e.g. its release asset, for a partial download to be resumed only
+	// from the same version. It is empty when the source can't tell it.
+	ID string
+}
+
 type Operation interface {
-	DownloadDB(ctx context.Context, fileName string) (io.ReadCloser, int, error)
+	// DownloadDB downloads the DB file from the offset, e.g. the size of a partial download to resume
+	DownloadDB(ctx context.Context, fileName string, offset int64) (Asset, error)
 }
 
+// source is a repository of the DB files, the GitHub releases of a repository or a mirror
+type source interface {
+	download(ctx context.Context, fileName string, offset int64) (Asset, error)
+	String() string
+}
+
+// Client downloads the DB files from the repositories, in turn until one of them succeeds
 type Client struct {
-	Repository RepositoryInterface
+	sources []source
 }
 
-func NewClient() Client {
+// NewClient returns the client of the repositories, the GitHub repositories as owner/repo and the mirrors serving
+// the DB files in a directory as its http(s) URL, or of DefaultRepository without any
+func NewClient(repositories []string) Client {
+	if len(repositories) == 0 {
+		repositories = []string{DefaultRepository}
+	}
+
 	var client *http.Client
 	githubToken := os.Getenv("GITHUB_TOKEN")
 	if githubToken != "" {
@@ -61,73 +96,272 @@ func NewClient() Client {
 	}
 	gc := github.NewClient(client)
 
-	repo := Repository{
-		repository: gc.Repositories,
-		git:        gc.Git,
-		owner:      owner,
-		repoName:   repo,
+	var sources []source
+	for _, r := range repositories {
+		if isMirror(r) {
+			sources = append(sources, mirror{url: strings.TrimSuffix(r, "/"), client: http.DefaultClient})
+			continue
+		}
+		ss := strings.SplitN(r, "/", 2)
+		sources = append(sources, releases{
+			name: r,
+			repository: Repository{
+				repository: gc.Repositories,
+				git:        gc.Git,
+				owner:      ss[0],
+				repoName:   ss[1],
+			},
+		})
+	}
+	return Client{sources: sources}
+}
+
+// CheckRepository fails when the repository is neither a GitHub repository as owner/repo nor the URL of a mirror
+func CheckRepository(repository string) error {
+	if isMirror(repository) {
+		return nil
+	}
+	ss := strings.Split(repository, "/")
+	if len(ss) != 2 || ss[0] == "" || ss[1] == "" {
+		return xerrors.Errorf("invalid DB repository %q, expected owner/repo or the http(s) URL of a mirror", repository)
 	}
+	return nil
+}
+
+func isMirror(repository string) bool {
+	return strings.HasPrefix(repository, "http://") || strings.HasPrefix(repository, "https://")
+}
 
-	return Client{
-		Repository: repo,
+func (c Client) DownloadDB(ctx context.Context, fileName string, offset int64) (Asset, error) {
+	var lastErr error
+	var errs []string
+	for _, s := range c.sources {
+		asset, err := s.download(ctx, fileName, offset)
+		if err == nil {
+			return asset, nil
+		}
+		log.Logger.Debugf("Unable to download %s from %s: %s", fileName, s, err)
+		lastErr = err
+		errs = append(errs, fmt.Sprintf("%s: %s", s, err))
 	}
+	if len(errs) == 1 {
+		return Asset{}, lastErr
+	}
+	return Asset{}, xerrors.Errorf("DB file not found in any repository: %s", strings.Join(errs, "; "))
+}
+
+// releases downloads the DB files from the latest release of the schema version of a GitHub repository
+type releases struct {
+	name       string
+	repository RepositoryInterface
+}
+
+func (r releases) String() string {
+	return r.name
 }
 
-func (c Client) DownloadDB(ctx context.Context, fileName string) (io.ReadCloser, int, error) {
+func (r releases) download(ctx context.Context, fileName string, offset int64) (Asset, error) {
 	options := github.ListOptions{}
-	releases, _, err := c.Repository.ListReleases(ctx, &options)
+	rels, _, err := r.repository.ListReleases(ctx, &options)
 	if err != nil {
-		return nil, 0, xerrors.Errorf("failed to list releases: %w", err)
+		return Asset{}, xerrors.Errorf("failed to list releases: %w", err)
 	}
 
-	sort.Slice(releases, func(i, j int) bool {
-		return releases[i].GetPublishedAt().After(releases[j].GetPublishedAt().Time)
+	sort.Slice(rels, func(i, j int) bool {
+		return rels[i].GetPublishedAt().After(rels[j].GetPublishedAt().Time)
 	})
 
 	prefix := fmt.Sprintf("v%d", db.SchemaVersion)
-	for _, release := range releases {
+	for _, release := range rels {
 		log.Logger.Debugf("release name: %s", release.GetName())
 		if !strings.HasPrefix(release.GetName(), prefix) {
 			continue
 		}
 
 		for _, asset := range release.Assets {
-			rc, size, err := c.downloadAsset(ctx, asset, fileName)
+			a, err := r.downloadAsset(ctx, asset, fileName, offset)
 			if err != nil {
 				log.Logger.Debug(err)
 				continue
 			}
-			return rc, size, nil
+			if a.SHA256, err = r.checksum(ctx, release.Assets, fileName); err != nil {
+				a.Close()
+				return Asset{}, err
+			}
+			a.ID = fmt.Sprintf("%s@%s/%d", r.name, release.GetTagName(), asset.GetID())
+			return a, nil
 		}
 
 	}
-	return nil, 0, xerrors.New("DB file not found")
+	return Asset{}, xerrors.New("DB file not found")
 }
 
-func (c Client) downloadAsset(ctx context.Context, asset github.ReleaseAsset, fileName string) (io.ReadCloser, int, error) {
+func (r releases) downloadAsset(ctx context.Context, asset github.ReleaseAsset, fileName string, offset int64) (Asset, error) {
 	log.Logger.Debugf("asset name: %s", asset.GetName())
 	if asset.GetName() != fileName {
-		return nil, 0, xerrors.New("file name doesn't match")
+		return Asset{}, xerrors.New("file name doesn't match")
 	}
 
-	rc, url, err := c.Repository.DownloadAsset(ctx, asset.GetID())
+	rc, url, err := r.repository.DownloadAsset(ctx, asset.GetID())
 	if err != nil {
-		return nil, 0, xerrors.Errorf("unable to download the asset: %w", err)
+		return Asset{}, xerrors.Errorf("unable to download the asset: %w", err)
 	}
 
 	if rc != nil {
-		return rc, asset.GetSize(), nil
+		// the API doesn't resume
+		return Asset{ReadCloser: rc, Size: int64(asset.GetSize())}, nil
 	}
 
 	log.Logger.Debugf("asset URL: %s", url)
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return nil, 0, xerrors.Errorf("unable to download the asset via URL: %w", err)
+	a, err := get(ctx, http.DefaultClient, url, offset)
+	if err != nil {
+		return Asset{}, xerrors.Errorf("unable to download the asset via URL: %w", err)
+	}
+	return a, nil
+}
+
+// checksum returns the checksum of the file published in the same release, if any
+func (r releases) checksum(ctx context.Context, assets []github.ReleaseAsset, fileName string) (string, error) {
+	for _, asset := range assets {
+		if asset.GetName() != fileName+checksumSuffix {
+			continue
+		}
+		a, err := r.downloadAsset(ctx, asset, asset.GetName(), 0)
+		if err != nil {
+			return "", xerrors.Errorf("unable to download the checksum: %w", err)
+		}
+		defer a.Close()
+		return readChecksum(a)
 	}
+	return "", nil
+}
+
+// mirror downloads the DB files from a directory served over HTTP, with their checksums if any
+type mirror struct {
+	url    string
+	client *http.Client
+}
+
+func (m mirror) String() string {
+	return m.url
+}
 
-	size, err := strconv.Atoi(resp.Header.Get("Content-Length"))
+func (m mirror) download(ctx context.Context, fileName string, offset int64) (Asset, error) {
+	sum, err := m.checksum(ctx, fileName)
 	if err != nil {
-		return nil, 0, xerrors.Errorf("invalid size: %w", err)
+		return Asset{}, err
+	}
+	a, err := get(ctx, m.client, m.url+"/"+fileName, offset)
+	if err != nil {
+		return Asset{}, err
+	}
+	a.SHA256 = sum
+	return a, nil
+}
+
+func (m mirror) checksum(ctx context.Context, fileName string) (string, error) {
+	a, err := get(ctx, m.client, m.url+"/"+fileName+checksumSuffix, 0)
+	if xerrors.Is(err, errNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", xerrors.Errorf("unable to download the checksum: %w", err)
+	}
+	defer a.Close()
+	return readChecksum(a)
+}
+
+var errNotFound = xerrors.New("not found")
+
+// get downloads the file of the URL from the offset with a range request, from the beginning when the server
+// doesn't support it
+func get(ctx context.Context, client *http.Client, url string, offset int64) (Asset, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Asset{}, err
+	}
+	req = req.WithContext(ctx)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Asset{}, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		size := resp.ContentLength
+		if size < 0 {
+			size = 0
+		}
+		return Asset{ReadCloser: resp.Body, Size: size, ID: assetID(url, resp.Header)}, nil
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			resp.Body.Close()
+			return Asset{}, err
+		}
+		return Asset{ReadCloser: resp.Body, Offset: start, Size: size, ID: assetID(url, resp.Header)}, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// e.g. the partial file of a previous version, larger than the current one
+		resp.Body.Close()
+		return get(ctx, client, url, 0)
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return Asset{}, errNotFound
+	}
+	resp.Body.Close()
+	return Asset{}, xerrors.Errorf("%s returned %s", url, resp.Status)
+}
+
+// assetID returns the ID of the file of the URL from its ETag, or its Last-Modified without any, empty without both
+func assetID(url string, header http.Header) string {
+	validator := header.Get("ETag")
+	if validator == "" {
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		return ""
+	}
+	return url + " " + validator
+}
+
+// parseContentRange parses the start and the size of "bytes 100-199/200", the size being 0 for "*"
+func parseContentRange(s string) (int64, int64, error) {
+	var rangeSpec, sizeSpec string
+	if n, _ := fmt.Sscanf(s, "bytes %s", &rangeSpec); n != 1 || !strings.Contains(rangeSpec, "/") {
+		return 0, 0, xerrors.Errorf("invalid Content-Range: %q", s)
+	}
+	ss := strings.SplitN(rangeSpec, "/", 2)
+	rangeSpec, sizeSpec = ss[0], ss[1]
+
+	start, err := strconv.ParseInt(strings.SplitN(rangeSpec, "-", 2)[0], 10, 64)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("invalid Content-Range: %q", s)
+	}
+	if sizeSpec == "*" {
+		return start, 0, nil
+	}
+	size, err := strconv.ParseInt(sizeSpec, 10, 64)
+	if err != nil {
+		return 0, 0, xerrors.Errorf("invalid Content-Range: %q", s)
+	}
+	return start, size, nil
+}
+
+// readChecksum reads the checksum of the output of sha256sum, "<hex>  <file name>"
+func readChecksum(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(io.LimitReader(r, 1024)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", xerrors.Errorf("unable to read the checksum: %w", err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", xerrors.New("empty checksum")
+	}
+	sum := strings.ToLower(fields[0])
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != 32 {
+		return "", xerrors.Errorf("invalid SHA-256 checksum: %q", fields[0])
 	}
-	return resp.Body, size, nil
+	return sum, nil
 }
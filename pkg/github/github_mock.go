@@ -2,7 +2,6 @@ package github
 
 import (
 	"context"
-	"io"
 	"os"
 
 	"github.com/stretchr/testify/mock"
@@ -18,6 +17,7 @@ type DownloadDBInput struct {
 type DownloadDBOutput struct {
 	FileName string
 	Size     int
+	SHA256   string
 	Err      error
 }
 type DownloadDBExpectation struct {
@@ -28,30 +28,26 @@ type DownloadDBExpectation struct {
 func NewMockClient(downloadDBExpectations []DownloadDBExpectation) (*MockClient, error) {
 	mockDetector := new(MockClient)
 	for _, e := range downloadDBExpectations {
-		var rc io.ReadCloser
+		var asset Asset
 		if e.ReturnArgs.FileName != "" {
 			f, err := os.Open(e.ReturnArgs.FileName)
 			if err != nil {
 				return nil, err
 			}
-			rc = f
+			asset = Asset{ReadCloser: f, Size: int64(e.ReturnArgs.Size), SHA256: e.ReturnArgs.SHA256}
 		}
 
-		mockDetector.On("DownloadDB", mock.Anything, e.Args.FileName).Return(
-			rc, e.ReturnArgs.Size, e.ReturnArgs.Err)
+		mockDetector.On("DownloadDB", mock.Anything, e.Args.FileName, mock.Anything).Return(
+			asset, e.ReturnArgs.Err)
 	}
 	return mockDetector, nil
 }
 
-func (_m *MockClient) DownloadDB(ctx context.Context, fileName string) (io.ReadCloser, int, error) {
-	ret := _m.Called(ctx, fileName)
-	ret0 := ret.Get(0)
-	if ret0 == nil {
-		return nil, ret.Int(1), ret.Error(2)
-	}
-	rc, ok := ret0.(io.ReadCloser)
+func (_m *MockClient) DownloadDB(ctx context.Context, fileName string, offset int64) (Asset, error) {
+	ret := _m.Called(ctx, fileName, offset)
+	asset, ok := ret.Get(0).(Asset)
 	if !ok {
-		return nil, ret.Int(1), ret.Error(2)
+		return Asset{}, ret.Error(1)
 	}
-	return rc, ret.Int(1), ret.Error(2)
+	return asset, ret.Error(1)
 }
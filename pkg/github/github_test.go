@@ -447,21 +447,174 @@ func TestClient_DownloadDB(t *testing.T) {
 			}
 
 			client := Client{
-				Repository: mockRepo,
+				sources: []source{releases{name: DefaultRepository, repository: mockRepo}},
 			}
 
 			ctx := context.Background()
-			rc, _, err := client.DownloadDB(ctx, tc.fileName)
+			asset, err := client.DownloadDB(ctx, tc.fileName, 0)
 
 			switch {
 			case tc.expectedError != nil:
 				assert.EqualError(t, err, tc.expectedError.Error(), tc.name)
 			default:
 				assert.NoError(t, err, tc.name)
-				assert.NotNil(t, rc, tc.name)
+				assert.NotNil(t, asset.ReadCloser, tc.name)
 			}
 
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestClient_DownloadDB_Mirrors(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	content := "0123456789"
+	modTime := time.Date(2021, 8, 25, 12, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mirror/trivy.db.gz", "/no-checksum/trivy.db.gz":
+			// supports the range requests
+			http.ServeContent(w, r, "trivy.db.gz", modTime, strings.NewReader(content))
+		case "/mirror/trivy.db.gz.sha256":
+			_, _ = fmt.Fprintln(w, "84D89877F0D4041EFB6BF91A16F0248F2FD573E6AF05C19F96BEDB9F882F7882  trivy.db.gz")
+		case "/no-range/trivy.db.gz":
+			_, _ = fmt.Fprint(w, content)
+		case "/invalid-checksum/trivy.db.gz.sha256":
+			_, _ = fmt.Fprint(w, "foo")
+		case "/unavailable/trivy.db.gz":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	mirrors := func(paths ...string) Client {
+		var sources []source
+		for _, p := range paths {
+			sources = append(sources, mirror{url: ts.URL + p, client: ts.Client()})
+		}
+		return Client{sources: sources}
+	}
+
+	tests := []struct {
+		name       string
+		client     Client
+		offset     int64
+		want       string
+		wantOffset int64
+		wantSize   int64
+		wantSHA256 string
+		wantID     string
+		wantErr    string
+	}{
+		{
+			name:       "happy path",
+			client:     mirrors("/mirror"),
+			want:       content,
+			wantSize:   10,
+			wantSHA256: "84d89877f0d4041efb6bf91a16f0248f2fd573e6af05c19f96bedb9f882f7882",
+			wantID:     ts.URL + "/mirror/trivy.db.gz Wed, 25 Aug 2021 12:00:00 GMT",
+		},
+		{
+			name:       "resumed from the offset",
+			client:     mirrors("/mirror"),
+			offset:     4,
+			want:       "456789",
+			wantOffset: 4,
+			wantSize:   10,
+			wantSHA256: "84d89877f0d4041efb6bf91a16f0248f2fd573e6af05c19f96bedb9f882f7882",
+			wantID:     ts.URL + "/mirror/trivy.db.gz Wed, 25 Aug 2021 12:00:00 GMT",
+		},
+		{
+			name:     "the partial file is larger than the file",
+			client:   mirrors("/no-checksum"),
+			offset:   12,
+			want:     content,
+			wantSize: 10,
+			wantID:   ts.URL + "/no-checksum/trivy.db.gz Wed, 25 Aug 2021 12:00:00 GMT",
+		},
+		{
+			name:     "the server doesn't resume",
+			client:   mirrors("/no-range"),
+			offset:   4,
+			want:     content,
+			wantSize: 10,
+		},
+		{
+			name:       "the next mirror after a failure",
+			client:     mirrors("/unavailable", "/mirror"),
+			want:       content,
+			wantSize:   10,
+			wantSHA256: "84d89877f0d4041efb6bf91a16f0248f2fd573e6af05c19f96bedb9f882f7882",
+			wantID:     ts.URL + "/mirror/trivy.db.gz Wed, 25 Aug 2021 12:00:00 GMT",
+		},
+		{
+			name:    "invalid checksum",
+			client:  mirrors("/invalid-checksum"),
+			wantErr: `invalid SHA-256 checksum: "foo"`,
+		},
+		{
+			name:    "all the mirrors fail",
+			client:  mirrors("/unavailable", "/missing"),
+			wantErr: "DB file not found in any repository: " + ts.URL + "/unavailable: " + ts.URL + "/unavailable/trivy.db.gz returned 503 Service Unavailable; " + ts.URL + "/missing: not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, err := tt.client.DownloadDB(context.Background(), "trivy.db.gz", tt.offset)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			defer asset.Close()
+
+			b, err := ioutil.ReadAll(asset)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(b))
+			assert.Equal(t, tt.wantOffset, asset.Offset)
+			assert.Equal(t, tt.wantSize, asset.Size)
+			assert.Equal(t, tt.wantSHA256, asset.SHA256)
+			assert.Equal(t, tt.wantID, asset.ID)
+		})
+	}
+}
+
+func TestClient_DownloadDB_ReleaseChecksum(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("ListReleases", mock.Anything, mock.Anything).Return([]*github.RepositoryRelease{
+		{
+			ID:      github.Int64(1),
+			Name:    github.String("v1-2020123123"),
+			TagName: github.String("v1-2020123123"),
+			Assets: []github.ReleaseAsset{
+				{ID: github.Int64(100), Name: github.String("trivy.db.gz")},
+				{ID: github.Int64(101), Name: github.String("trivy.db.gz.sha256")},
+			},
+		},
+	}, nil, nil)
+	mockRepo.On("DownloadAsset", mock.Anything, int64(100)).Return(ioutil.NopCloser(strings.NewReader("foo")), "", nil)
+	mockRepo.On("DownloadAsset", mock.Anything, int64(101)).Return(ioutil.NopCloser(strings.NewReader(
+		"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae  trivy.db.gz\n")), "", nil)
+
+	client := Client{sources: []source{releases{name: DefaultRepository, repository: mockRepo}}}
+	asset, err := client.DownloadDB(context.Background(), "trivy.db.gz", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", asset.SHA256)
+	assert.Equal(t, "aquasecurity/trivy-db@v1-2020123123/100", asset.ID, "the ID of the release asset")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckRepository(t *testing.T) {
+	for _, repository := range []string{"aquasecurity/trivy-db", "https://mirror.example.com/trivy-db/", "http://10.0.0.1:8080"} {
+		assert.NoError(t, CheckRepository(repository), repository)
+	}
+	for _, repository := range []string{"trivy-db", "aquasecurity/trivy-db/v1", "/trivy-db", "ftp://mirror.example.com"} {
+		assert.Error(t, CheckRepository(repository), repository)
+	}
+}
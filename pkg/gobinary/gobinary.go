@@ -0,0 +1,105 @@
+package gobinary
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// Type is the application type of the modules embedded in Go binaries
+const Type = "gobinary"
+
+// DefaultDirs are the directories whose files are analyzed as Go binaries when none are given, including the root
+// and ko-app where the distroless images usually have them, e.g. /manager and /ko-app/server
+var DefaultDirs = []string{"./", "bin/", "usr/bin/", "usr/local/bin/", "app/", "ko-app/"}
+
+// Parse returns the modules embedded in a Go binary, with their replacements if any.
+// The binaries without module information, e.g. not built by Go or without module support, return an error.
+func Parse(r io.ReaderAt) ([]ptypes.Library, error) {
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the build information: %w", err)
+	}
+
+	var libs []ptypes.Library
+	for _, dep := range info.Deps {
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		libs = append(libs, ptypes.Library{Name: mod.Path, Version: mod.Version})
+	}
+	return libs, nil
+}
+
+type binaryAnalyzer struct {
+	dirs []string
+}
+
+func (a binaryAnalyzer) Analyze(fileMap extractor.FileMap) (map[ftypes.FilePath][]ptypes.Library, error) {
+	libMap := map[ftypes.FilePath][]ptypes.Library{}
+	for filename, content := range fileMap {
+		if !a.inDirs(filename) {
+			continue
+		}
+		libs, err := Parse(bytes.NewReader(content))
+		if err != nil {
+			// e.g. shell scripts or binaries stripped of their build information
+			log.Logger.Debugf("%s is not analyzed as a Go binary: %s", filename, err)
+			continue
+		}
+		if len(libs) > 0 {
+			libMap[ftypes.FilePath(filename)] = libs
+		}
+	}
+	return libMap, nil
+}
+
+func (a binaryAnalyzer) inDirs(filename string) bool {
+	dir := filepath.Dir(filename)
+	for _, d := range a.dirs {
+		if filepath.Clean(d) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredFiles are the directories, extracted with all their files
+func (a binaryAnalyzer) RequiredFiles() []string {
+	return a.dirs
+}
+
+func (a binaryAnalyzer) Name() string {
+	return Type
+}
+
+var registerOnce sync.Once
+
+// Register enables the analysis of the Go binaries in the directories, e.g. "usr/local/bin/", or DefaultDirs without any.
+// The analysis is disabled by default as every file in the directories is extracted.
+// The analyzers of fanal are global, so only the first call is effective and it can't be disabled afterwards.
+func Register(dirs []string) {
+	registerOnce.Do(func() {
+		if len(dirs) == 0 {
+			dirs = DefaultDirs
+		}
+		for i, dir := range dirs {
+			if !strings.HasSuffix(dir, "/") {
+				dirs[i] = dir + "/"
+			}
+		}
+		analyzer.RegisterLibraryAnalyzer(binaryAnalyzer{dirs: dirs})
+	})
+}
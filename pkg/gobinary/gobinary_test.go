@@ -0,0 +1,58 @@
+package gobinary
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.InitLogger(false, true)
+	os.Exit(m.Run())
+}
+
+// testBinary returns the test binary itself, a Go binary embedding the modules of the tests
+func testBinary(t *testing.T) []byte {
+	path, err := os.Executable()
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return b
+}
+
+func TestBinaryAnalyzer_Analyze(t *testing.T) {
+	binary := testBinary(t)
+	a := binaryAnalyzer{dirs: DefaultDirs}
+	got, err := a.Analyze(extractor.FileMap{
+		"usr/local/bin/app":   binary,
+		"usr/local/bin/run":   []byte("#!/bin/sh\nexec app\n"),
+		"manager":             binary,
+		"ko-app/server":       binary,
+		"opt/tools/app":       binary,
+		"app/Gemfile.lock":    []byte("GEM\n"),
+		"usr/bin/stripped-go": binary[:len(binary)/4],
+	})
+	require.NoError(t, err)
+
+	var paths []ftypes.FilePath
+	for path := range got {
+		paths = append(paths, path)
+	}
+	assert.ElementsMatch(t, []ftypes.FilePath{"usr/local/bin/app", "manager", "ko-app/server"}, paths)
+	assert.Contains(t, got["usr/local/bin/app"], ptypes.Library{Name: "github.com/stretchr/testify", Version: "v1.4.0"})
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse(strings.NewReader("#!/bin/sh\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to read the build information")
+}
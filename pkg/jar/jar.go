@@ -0,0 +1,275 @@
+package jar
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// Type is the application type of the Maven artifacts of the Java archives
+const Type = "jar"
+
+const (
+	// maxDepth is the number of nested archives opened, e.g. 2 for a JAR in the WEB-INF/lib of a WAR in an EAR
+	maxDepth = 3
+	// maxNestedSize is the size limit of a nested archive, read in memory
+	maxNestedSize = 256 << 20
+)
+
+var (
+	// Patterns are the names of the Java archives
+	Patterns = []string{"*.jar", "*.war", "*.ear"}
+	// DefaultDirs are the directories whose files are also extracted, e.g. for the archives with upper-case extensions
+	DefaultDirs = []string{"app/", "usr/share/java/", "usr/local/tomcat/lib/", "usr/local/tomcat/webapps/"}
+)
+
+// e.g. log4j-core-2.14.1.jar
+var fileNameRegexp = regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)\.[jwe]ar$`)
+
+// IsArchive reports whether the file is a Java archive by its name
+func IsArchive(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".jar", ".war", ".ear":
+		return true
+	}
+	return false
+}
+
+// Parse returns the Maven artifacts of a Java archive, named groupId:artifactId, from the pom.properties of
+// META-INF/maven, including those of the archives nested in it, e.g. in BOOT-INF/lib of a Spring Boot JAR,
+// in WEB-INF/lib of a WAR or in an EAR, and the shaded artifacts of a fat JAR.
+// An archive without pom.properties is identified by the Implementation-Vendor-Id of its manifest and its file name,
+// e.g. log4j-core-2.14.1.jar, the Implementation-Version of the manifest having priority over the version of the name.
+func Parse(r io.ReaderAt, size int64, filename string) ([]ptypes.Library, error) {
+	libs, err := parse(r, size, filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	return uniqueLibraries(libs), nil
+}
+
+func parse(r io.ReaderAt, size int64, filename string, depth int) ([]ptypes.Library, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open the archive: %w", err)
+	}
+
+	var libs []ptypes.Library
+	var manifest map[string]string
+	for _, f := range zr.File {
+		switch {
+		case isPomProperties(f.Name):
+			props, err := readProperties(f)
+			if err != nil {
+				log.Logger.Debugf("Invalid %s of %s: %s", f.Name, filename, err)
+				continue
+			}
+			if props["groupId"] == "" || props["artifactId"] == "" || props["version"] == "" {
+				continue
+			}
+			libs = append(libs, ptypes.Library{Name: props["groupId"] + ":" + props["artifactId"], Version: props["version"]})
+		case f.Name == "META-INF/MANIFEST.MF":
+			if manifest, err = readManifest(f); err != nil {
+				log.Logger.Debugf("Invalid manifest of %s: %s", filename, err)
+			}
+		case IsArchive(f.Name) && depth < maxDepth:
+			nested, err := parseNested(f, depth)
+			if err != nil {
+				log.Logger.Debugf("%s of %s is not analyzed: %s", f.Name, filename, err)
+				continue
+			}
+			libs = append(libs, nested...)
+		}
+	}
+
+	if !hasOwnPom(zr) {
+		if lib, ok := fromManifest(manifest, path.Base(filename)); ok {
+			libs = append(libs, lib)
+		}
+	}
+	return libs, nil
+}
+
+func parseNested(f *zip.File, depth int) ([]ptypes.Library, error) {
+	if f.UncompressedSize64 > maxNestedSize {
+		return nil, xerrors.Errorf("larger than %d bytes", maxNestedSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(rc, maxNestedSize))
+	if err != nil {
+		return nil, err
+	}
+	return parse(bytes.NewReader(b), int64(len(b)), f.Name, depth+1)
+}
+
+// isPomProperties reports whether the file is the pom.properties of an artifact, META-INF/maven/groupId/artifactId/pom.properties
+func isPomProperties(name string) bool {
+	parts := strings.Split(name, "/")
+	return len(parts) == 5 && parts[0] == "META-INF" && parts[1] == "maven" && parts[4] == "pom.properties"
+}
+
+// hasOwnPom reports whether the archive has a pom.properties, its own or the ones of the artifacts shaded in it
+func hasOwnPom(zr *zip.Reader) bool {
+	for _, f := range zr.File {
+		if isPomProperties(f.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromManifest returns the artifact of the archive from its manifest and its file name
+func fromManifest(manifest map[string]string, filename string) (ptypes.Library, bool) {
+	groupID := manifest["Implementation-Vendor-Id"]
+	m := fileNameRegexp.FindStringSubmatch(filename)
+	if groupID == "" || m == nil {
+		return ptypes.Library{}, false
+	}
+	version := manifest["Implementation-Version"]
+	if version == "" {
+		version = m[2]
+	}
+	return ptypes.Library{Name: groupID + ":" + m[1], Version: version}, true
+}
+
+// readProperties reads a Java properties file of key=value lines
+func readProperties(f *zip.File) (map[string]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	props := map[string]string{}
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		props[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return props, scanner.Err()
+}
+
+// readManifest reads the main attributes of a manifest, whose long values continue on the lines starting with a space
+func readManifest(f *zip.File) (map[string]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	attrs := map[string]string{}
+	var last string
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			// the sections of the entries follow the main attributes
+			break
+		}
+		if strings.HasPrefix(line, " ") && last != "" {
+			attrs[last] += line[1:]
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		last = strings.TrimSpace(kv[0])
+		attrs[last] = strings.TrimSpace(kv[1])
+	}
+	return attrs, scanner.Err()
+}
+
+func uniqueLibraries(libs []ptypes.Library) []ptypes.Library {
+	seen := map[ptypes.Library]bool{}
+	var unique []ptypes.Library
+	for _, lib := range libs {
+		if !seen[lib] {
+			seen[lib] = true
+			unique = append(unique, lib)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].Name != unique[j].Name {
+			return unique[i].Name < unique[j].Name
+		}
+		return unique[i].Version < unique[j].Version
+	})
+	return unique
+}
+
+type archiveAnalyzer struct {
+	dirs []string
+}
+
+func (a archiveAnalyzer) Analyze(fileMap extractor.FileMap) (map[ftypes.FilePath][]ptypes.Library, error) {
+	libMap := map[ftypes.FilePath][]ptypes.Library{}
+	for filename, content := range fileMap {
+		if !IsArchive(filename) {
+			continue
+		}
+		libs, err := Parse(bytes.NewReader(content), int64(len(content)), filename)
+		if err != nil {
+			log.Logger.Debugf("%s is not analyzed as a Java archive: %s", filename, err)
+			continue
+		}
+		if len(libs) > 0 {
+			libMap[ftypes.FilePath(filename)] = libs
+		}
+	}
+	return libMap, nil
+}
+
+// RequiredFiles are the patterns of the Java archives and the directories, extracted with all their files
+func (a archiveAnalyzer) RequiredFiles() []string {
+	return append(append([]string{}, Patterns...), a.dirs...)
+}
+
+func (a archiveAnalyzer) Name() string {
+	return Type
+}
+
+var registerOnce sync.Once
+
+// Register enables the analysis of the Java archives, found by their names with the extractors matching the patterns
+// of the file names, e.g. of trivy fs, and in the directories, e.g. "opt/app/", or DefaultDirs without any.
+// The analyzers of fanal are global, so only the first call is effective.
+func Register(dirs []string) {
+	registerOnce.Do(func() {
+		if len(dirs) == 0 {
+			dirs = DefaultDirs
+		}
+		for i, dir := range dirs {
+			if !strings.HasSuffix(dir, "/") {
+				dirs[i] = dir + "/"
+			}
+		}
+		analyzer.RegisterLibraryAnalyzer(archiveAnalyzer{dirs: dirs})
+	})
+}
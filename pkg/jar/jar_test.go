@@ -0,0 +1,160 @@
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestMain(m *testing.M) {
+	_ = log.InitLogger(false, true)
+	os.Exit(m.Run())
+}
+
+// archive returns a zip archive of the files in the order of the names
+func archive(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		require.NoError(t, err)
+		_, err = w.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func pom(groupID, artifactID, version string) string {
+	return "#Generated by Maven\nversion=" + version + "\ngroupId=" + groupID + "\nartifactId=" + artifactID + "\n"
+}
+
+func TestParse(t *testing.T) {
+	log4j := archive(t,
+		"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n",
+		"META-INF/maven/org.apache.logging.log4j/log4j-core/pom.properties", pom("org.apache.logging.log4j", "log4j-core", "2.14.1"),
+		"org/apache/logging/log4j/core/lookup/JndiLookup.class", "",
+	)
+	// without pom.properties, identified by its manifest and its name
+	commons := archive(t,
+		"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\r\nImplementation-Vendor-Id: commons-\r\n collections\r\nImplementation-Version: 3.2.1\r\n\r\nName: org/apache/\r\n",
+	)
+	unknown := archive(t, "META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n")
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		want     []ptypes.Library
+		wantErr  string
+	}{
+		{
+			name:     "jar",
+			filename: "app/log4j-core-2.14.1.jar",
+			content:  log4j,
+			want:     []ptypes.Library{{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}},
+		},
+		{
+			name:     "spring boot jar",
+			filename: "app/app.jar",
+			content: archive(t,
+				"META-INF/maven/com.example/app/pom.properties", pom("com.example", "app", "1.0.0"),
+				"BOOT-INF/lib/log4j-core-2.14.1.jar", string(log4j),
+				"BOOT-INF/lib/commons-collections-3.2.jar", string(commons),
+				"BOOT-INF/lib/unknown-1.0.jar", string(unknown),
+				"BOOT-INF/lib/broken.jar", "not a zip",
+			),
+			want: []ptypes.Library{
+				{Name: "com.example:app", Version: "1.0.0"},
+				{Name: "commons-collections:commons-collections", Version: "3.2.1"},
+				{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"},
+			},
+		},
+		{
+			name:     "shaded jar",
+			filename: "app/bundle.jar",
+			content: archive(t,
+				"META-INF/MANIFEST.MF", "Implementation-Vendor-Id: com.example\n",
+				"META-INF/maven/com.google.guava/guava/pom.properties", pom("com.google.guava", "guava", "20.0"),
+				"META-INF/maven/org.apache.logging.log4j/log4j-core/pom.properties", pom("org.apache.logging.log4j", "log4j-core", "2.14.1"),
+			),
+			want: []ptypes.Library{
+				{Name: "com.google.guava:guava", Version: "20.0"},
+				{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"},
+			},
+		},
+		{
+			name:     "ear of a war",
+			filename: "usr/local/tomcat/webapps/shop.ear",
+			content: archive(t,
+				"shop.war", string(archive(t, "WEB-INF/lib/log4j-core-2.14.1.jar", string(log4j))),
+			),
+			want: []ptypes.Library{{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}},
+		},
+		{
+			name:     "version from the name",
+			filename: "app/commons-collections-3.2.1.jar",
+			content:  archive(t, "META-INF/MANIFEST.MF", "Implementation-Vendor-Id: commons-collections\n"),
+			want:     []ptypes.Library{{Name: "commons-collections:commons-collections", Version: "3.2.1"}},
+		},
+		{
+			name:     "not a zip",
+			filename: "app/app.jar",
+			content:  []byte("#!/bin/sh\n"),
+			wantErr:  "unable to open the archive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(bytes.NewReader(tt.content), int64(len(tt.content)), tt.filename)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_MaxDepth(t *testing.T) {
+	nested := archive(t, "META-INF/maven/com.example/deep/pom.properties", pom("com.example", "deep", "1.0"))
+	for i := 0; i <= maxDepth; i++ {
+		nested = archive(t, "lib/nested.jar", string(nested))
+	}
+	got, err := Parse(bytes.NewReader(nested), int64(len(nested)), "app.jar")
+	require.NoError(t, err)
+	assert.Empty(t, got, "the archives deeper than maxDepth aren't opened")
+}
+
+func TestArchiveAnalyzer_Analyze(t *testing.T) {
+	app := archive(t, "META-INF/maven/com.example/app/pom.properties", pom("com.example", "app", "1.0.0"))
+	got, err := archiveAnalyzer{dirs: DefaultDirs}.Analyze(extractor.FileMap{
+		"app/app.jar":      app,
+		"app/run.sh":       []byte("#!/bin/sh\nexec java -jar app.jar\n"),
+		"opt/lib/app.WAR":  app,
+		"app/broken.jar":   []byte("not a zip"),
+		"app/Gemfile.lock": []byte("GEM\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[ftypes.FilePath][]ptypes.Library{
+		"app/app.jar":     {{Name: "com.example:app", Version: "1.0.0"}},
+		"opt/lib/app.WAR": {{Name: "com.example:app", Version: "1.0.0"}},
+	}, got)
+}
+
+func TestArchiveAnalyzer_RequiredFiles(t *testing.T) {
+	files := archiveAnalyzer{dirs: []string{"opt/app/"}}.RequiredFiles()
+	assert.Equal(t, "*.jar,*.war,*.ear,opt/app/", strings.Join(files, ","))
+}
@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// listLimit is the number of objects of a page of the lists
+const listLimit = 500
+
+// errNotFound is returned for the resources the API server doesn't serve, e.g. batch/v1 CronJobs before 1.21
+var errNotFound = xerrors.New("not found")
+
+// Client is a client of the few read-only endpoints of the API server trivy k8s needs
+type Client struct {
+	config Config
+	http   *http.Client
+
+	// the token of a credential plugin is got once
+	execOnce  sync.Once
+	execToken string
+	execErr   error
+}
+
+func NewClient(c Config) (*Client, error) {
+	if !strings.HasPrefix(c.Server, "https://") && !strings.HasPrefix(c.Server, "http://") {
+		return nil, xerrors.Errorf("invalid server of the cluster: %s", c.Server)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.Insecure}
+	if len(c.CAData) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(c.CAData) {
+			return nil, xerrors.New("invalid CA of the cluster")
+		}
+	}
+	if len(c.CertData) > 0 {
+		cert, err := tls.X509KeyPair(c.CertData, c.KeyData)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return &Client{
+		config: c,
+		http: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// get decodes the object of the path into v
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.config.Server, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", "application/json")
+	token, err := c.token()
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return xerrors.Errorf("unable to get %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return xerrors.Errorf("%s: %w", path, errNotFound)
+	} else if resp.StatusCode != http.StatusOK {
+		// the Status of the API server explains the error, e.g. the missing permission
+		var status struct {
+			Message string `json:"message"`
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(b, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(b))
+		}
+		return xerrors.Errorf("unable to get %s: %s: %s", path, resp.Status, status.Message)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return xerrors.Errorf("invalid response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// list returns the objects of the resource of the group version, e.g. apps/v1 deployments, in the namespace
+// or in all the namespaces when it is empty. The pages of the list are followed.
+func (c *Client) list(ctx context.Context, groupVersion, resource, namespace string) ([]object, error) {
+	path := "/apis/" + groupVersion
+	if groupVersion == "v1" {
+		path = "/api/v1"
+	}
+	if namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	path += "/" + resource
+
+	var objects []object
+	query := url.Values{"limit": {fmt.Sprint(listLimit)}}
+	for {
+		var l struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []object `json:"items"`
+		}
+		if err := c.get(ctx, path, query, &l); err != nil {
+			return nil, err
+		}
+		objects = append(objects, l.Items...)
+		if l.Metadata.Continue == "" {
+			return objects, nil
+		}
+		query.Set("continue", l.Metadata.Continue)
+	}
+}
+
+func (c *Client) token() (string, error) {
+	if c.config.Exec == nil || c.config.BearerToken != "" || c.config.TokenFile != "" {
+		return c.config.token()
+	}
+	c.execOnce.Do(func() {
+		c.execToken, c.execErr = c.config.token()
+	})
+	return c.execToken, c.execErr
+}
@@ -0,0 +1,210 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/registry"
+)
+
+// newTestServer serves the objects keyed by the path; the lists without a second page have no continue
+func newTestServer(t *testing.T, objects map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"kind": "Status", "message": "Unauthorized"}`)
+			return
+		}
+		path := r.URL.Path
+		if c := r.URL.Query().Get("continue"); c != "" {
+			path += "?continue=" + c
+		}
+		o, ok := objects[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind": "Status", "message": "not found"}`)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(o))
+	}))
+}
+
+func list(items ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"metadata": map[string]interface{}{}, "items": items}
+}
+
+func item(namespace, name string, spec interface{}, owners ...string) map[string]interface{} {
+	var refs []map[string]string
+	for _, owner := range owners {
+		refs = append(refs, map[string]string{"kind": owner})
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": namespace, "name": name, "ownerReferences": refs},
+		"spec":     spec,
+	}
+}
+
+func pod(images ...string) map[string]interface{} {
+	var containers []map[string]string
+	for _, image := range images {
+		containers = append(containers, map[string]string{"image": image})
+	}
+	return map[string]interface{}{"containers": containers}
+}
+
+func TestClient_ListWorkloads(t *testing.T) {
+	template := func(spec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"template": map[string]interface{}{"spec": spec}}
+	}
+	web := pod("nginx:1.19", "envoyproxy/envoy:v1.16.0")
+	web["initContainers"] = []map[string]string{{"image": "busybox:1.32"}, {"image": "nginx:1.19"}}
+	web["serviceAccountName"] = "web"
+	web["imagePullSecrets"] = []map[string]string{{"name": "regcred"}}
+
+	server := newTestServer(t, map[string]interface{}{
+		"/apis/apps/v1/deployments": list(item("default", "web", template(web))),
+		"/apis/apps/v1/statefulsets": map[string]interface{}{
+			"metadata": map[string]interface{}{"continue": "page2"},
+			"items":    []interface{}{item("db", "postgres", template(pod("postgres:12")))},
+		},
+		"/apis/apps/v1/statefulsets?continue=page2": list(item("db", "redis", template(pod("redis:6")))),
+		"/apis/apps/v1/daemonsets":                  list(),
+		"/apis/apps/v1/replicasets": list(
+			item("default", "web-5d9c", template(pod("nginx:1.19")), "Deployment"),
+		),
+		"/api/v1/replicationcontrollers": list(),
+		// batch/v1 CronJobs aren't served before 1.21
+		"/apis/batch/v1beta1/cronjobs": list(item("default", "backup", map[string]interface{}{
+			"jobTemplate": map[string]interface{}{"spec": template(pod("backup:1.0"))},
+		})),
+		"/apis/batch/v1/jobs": list(
+			item("default", "backup-1600000000", template(pod("backup:1.0")), "CronJob"),
+			item("default", "migrate", template(pod("migrate:1.0"))),
+		),
+		"/api/v1/pods": list(
+			item("default", "web-5d9c-x8k2p", pod("nginx:1.19"), "ReplicaSet"),
+			item("default", "debug", pod("alpine:3.12")),
+		),
+	})
+	defer server.Close()
+
+	c, err := NewClient(Config{Server: server.URL, BearerToken: "token"})
+	require.NoError(t, err)
+	got, err := c.ListWorkloads(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []Workload{
+		{Namespace: "default", Kind: "Deployment", Name: "web", Images: []string{"busybox:1.32", "nginx:1.19", "envoyproxy/envoy:v1.16.0"},
+			PullSecrets: []string{"regcred"}, ServiceAccount: "web"},
+		{Namespace: "db", Kind: "StatefulSet", Name: "postgres", Images: []string{"postgres:12"}},
+		{Namespace: "db", Kind: "StatefulSet", Name: "redis", Images: []string{"redis:6"}},
+		{Namespace: "default", Kind: "CronJob", Name: "backup", Images: []string{"backup:1.0"}},
+		{Namespace: "default", Kind: "Job", Name: "migrate", Images: []string{"migrate:1.0"}},
+		{Namespace: "default", Kind: "Pod", Name: "debug", Images: []string{"alpine:3.12"}},
+	}, got)
+}
+
+func TestClient_ListWorkloads_Error(t *testing.T) {
+	server := newTestServer(t, map[string]interface{}{})
+	defer server.Close()
+
+	c, err := NewClient(Config{Server: server.URL, BearerToken: "wrong"})
+	require.NoError(t, err)
+	_, err = c.ListWorkloads(context.Background(), "default")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to list the deployments")
+	assert.Contains(t, err.Error(), "/apis/apps/v1/namespaces/default/deployments: 401 Unauthorized: Unauthorized")
+}
+
+func TestPullSecrets_Credentials(t *testing.T) {
+	dockerConfig := func(auths string) map[string][]byte {
+		return map[string][]byte{".dockerconfigjson": []byte(`{"auths": ` + auths + `}`)}
+	}
+	basicAuth := base64.StdEncoding.EncodeToString([]byte("robot:p@ss:word"))
+
+	server := newTestServer(t, map[string]interface{}{
+		"/api/v1/namespaces/default/serviceaccounts/default": map[string]interface{}{
+			"imagePullSecrets": []map[string]string{{"name": "hub"}, {"name": "missing"}},
+		},
+		"/api/v1/namespaces/default/secrets/regcred": map[string]interface{}{
+			"type": secretTypeDockerConfigJSON,
+			"data": dockerConfig(`{"registry.example.com:5000": {"auth": "` + basicAuth + `"}}`),
+		},
+		"/api/v1/namespaces/default/secrets/hub": map[string]interface{}{
+			"type": secretTypeDockerConfigJSON,
+			"data": dockerConfig(`{
+				"https://index.docker.io/v1/": {"username": "hub-user", "password": "hub-pass"},
+				"registry.example.com:5000": {"username": "other", "password": "other"}
+			}`),
+		},
+		"/api/v1/namespaces/legacy/secrets/old": map[string]interface{}{
+			"type": secretTypeDockercfg,
+			"data": map[string][]byte{".dockercfg": []byte(`{"quay.io": {"username": "quay-user", "password": "quay-pass"}}`)},
+		},
+		"/api/v1/namespaces/broken/secrets/invalid": map[string]interface{}{
+			"type": secretTypeDockerConfigJSON,
+			"data": map[string][]byte{".dockerconfigjson": []byte(`{"auths": {"ghcr.io": {"auth": "bm9wYXNzd29yZA=="}}}`)},
+		},
+	})
+	defer server.Close()
+	c, err := NewClient(Config{Server: server.URL, BearerToken: "token"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		workload Workload
+		want     Credentials
+		wantErr  string
+	}{
+		{
+			name:     "pod secrets before the service account",
+			workload: Workload{Namespace: "default", PullSecrets: []string{"regcred"}},
+			want: Credentials{
+				"registry.example.com:5000": {Username: "robot", Password: "p@ss:word"},
+				"index.docker.io":           {Username: "hub-user", Password: "hub-pass"},
+			},
+		},
+		{
+			name:     "legacy dockercfg without service account",
+			workload: Workload{Namespace: "legacy", ServiceAccount: "builder", PullSecrets: []string{"old"}},
+			want: Credentials{
+				"quay.io": {Username: "quay-user", Password: "quay-pass"},
+			},
+		},
+		{
+			name:     "invalid auth",
+			workload: Workload{Namespace: "broken", PullSecrets: []string{"invalid"}},
+			wantErr:  "invalid pull secret broken/invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPullSecrets(c).Credentials(context.Background(), tt.workload)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCredentials_For(t *testing.T) {
+	creds := Credentials{
+		"index.docker.io":           {Username: "hub-user", Password: "hub-pass"},
+		"registry.example.com:5000": {Username: "robot", Password: "secret"},
+	}
+	assert.Equal(t, registry.Credential{Username: "hub-user", Password: "hub-pass"}, creds.For("nginx:1.19"))
+	assert.Equal(t, registry.Credential{Username: "robot", Password: "secret"}, creds.For("registry.example.com:5000/app@sha256:"+
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+	assert.Equal(t, registry.Credential{}, creds.For("ghcr.io/org/app:1.0"))
+}
@@ -0,0 +1,257 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// serviceAccountDir has the token and the CA of the service account of a pod
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// Config is the connection to the API server of a cluster
+type Config struct {
+	// Name is the name of the context, or "in-cluster" for the service account of the pod
+	Name   string
+	Server string
+
+	CAData   []byte
+	Insecure bool
+
+	BearerToken string
+	// TokenFile is read for each request, as the tokens of the service accounts are rotated
+	TokenFile string
+	Username  string
+	Password  string
+	CertData  []byte
+	KeyData   []byte
+	// Exec is the credential plugin returning the token, e.g. aws eks get-token
+	Exec *ExecConfig
+}
+
+// ExecConfig is the command of a client-go credential plugin
+type ExecConfig struct {
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+	Env     []execEnv `json:"env"`
+}
+
+type execEnv struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// kubeconfig is the part of a kubeconfig file needed to connect to a cluster
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData []byte `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string      `json:"token"`
+			TokenFile             string      `json:"tokenFile"`
+			Username              string      `json:"username"`
+			Password              string      `json:"password"`
+			ClientCertificate     string      `json:"client-certificate"`
+			ClientCertificateData []byte      `json:"client-certificate-data"`
+			ClientKey             string      `json:"client-key"`
+			ClientKeyData         []byte      `json:"client-key-data"`
+			Exec                  *ExecConfig `json:"exec"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// LoadConfig loads the context of the kubeconfig file, the current context when contextName is empty.
+// Without the path, the first file of KUBECONFIG or ~/.kube/config is loaded, then the service account
+// of the pod when trivy runs in the cluster. The files of KUBECONFIG are not merged.
+func LoadConfig(path, contextName string) (Config, error) {
+	if path == "" {
+		path = defaultKubeconfig()
+	}
+	if path == "" {
+		if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" && port != "" {
+			return inClusterConfig(host, port)
+		}
+		return Config{}, xerrors.New("no kubeconfig file, specify it with --kubeconfig")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, xerrors.Errorf("unable to read the kubeconfig file: %w", err)
+	}
+	var kc kubeconfig
+	if err = yaml.Unmarshal(b, &kc); err != nil {
+		return Config{}, xerrors.Errorf("invalid kubeconfig file %s: %w", path, err)
+	}
+	return kc.config(contextName, filepath.Dir(path))
+}
+
+// defaultKubeconfig returns the first existing file of KUBECONFIG, or ~/.kube/config
+func defaultKubeconfig() string {
+	paths := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".kube", "config"))
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); path != "" && err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func inClusterConfig(host, port string) (Config, error) {
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return Config{}, xerrors.Errorf("unable to read the CA of the service account: %w", err)
+	}
+	return Config{
+		Name:      "in-cluster",
+		Server:    "https://" + net.JoinHostPort(host, port),
+		CAData:    ca,
+		TokenFile: filepath.Join(serviceAccountDir, "token"),
+	}, nil
+}
+
+// config returns the connection of the context; the relative paths of the files are relative to dir,
+// the directory of the kubeconfig file
+func (kc kubeconfig) config(contextName, dir string) (Config, error) {
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return Config{}, xerrors.New("no current context in the kubeconfig file, specify it with --context")
+	}
+
+	c := Config{Name: contextName}
+	var clusterName, userName string
+	found := false
+	for _, ctx := range kc.Contexts {
+		if ctx.Name == contextName {
+			clusterName, userName, found = ctx.Context.Cluster, ctx.Context.User, true
+		}
+	}
+	if !found {
+		return Config{}, xerrors.Errorf("context %s not found in the kubeconfig file", contextName)
+	}
+
+	found = false
+	for _, cluster := range kc.Clusters {
+		if cluster.Name != clusterName {
+			continue
+		}
+		found = true
+		c.Server, c.CAData, c.Insecure = cluster.Cluster.Server, cluster.Cluster.CertificateAuthorityData, cluster.Cluster.InsecureSkipTLSVerify
+		if len(c.CAData) == 0 && cluster.Cluster.CertificateAuthority != "" {
+			var err error
+			if c.CAData, err = readFile(dir, cluster.Cluster.CertificateAuthority); err != nil {
+				return Config{}, xerrors.Errorf("unable to read the CA of the cluster %s: %w", clusterName, err)
+			}
+		}
+	}
+	if !found || c.Server == "" {
+		return Config{}, xerrors.Errorf("cluster %s of the context %s not found in the kubeconfig file", clusterName, contextName)
+	}
+
+	for _, user := range kc.Users {
+		if user.Name != userName {
+			continue
+		}
+		u := user.User
+		c.BearerToken, c.Username, c.Password, c.Exec = u.Token, u.Username, u.Password, u.Exec
+		c.CertData, c.KeyData = u.ClientCertificateData, u.ClientKeyData
+		if u.TokenFile != "" {
+			c.TokenFile = resolvePath(dir, u.TokenFile)
+		}
+		var err error
+		if len(c.CertData) == 0 && u.ClientCertificate != "" {
+			if c.CertData, err = readFile(dir, u.ClientCertificate); err != nil {
+				return Config{}, xerrors.Errorf("unable to read the client certificate of %s: %w", userName, err)
+			}
+		}
+		if len(c.KeyData) == 0 && u.ClientKey != "" {
+			if c.KeyData, err = readFile(dir, u.ClientKey); err != nil {
+				return Config{}, xerrors.Errorf("unable to read the client key of %s: %w", userName, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// token returns the bearer token of the requests, empty without one
+func (c Config) token() (string, error) {
+	switch {
+	case c.BearerToken != "":
+		return c.BearerToken, nil
+	case c.TokenFile != "":
+		b, err := ioutil.ReadFile(c.TokenFile)
+		if err != nil {
+			return "", xerrors.Errorf("unable to read the token file: %w", err)
+		}
+		return string(bytes.TrimSpace(b)), nil
+	case c.Exec != nil:
+		return c.Exec.token()
+	}
+	return "", nil
+}
+
+// token runs the credential plugin and returns the token of its ExecCredential
+func (e ExecConfig) token() (string, error) {
+	cmd := exec.Command(e.Command, e.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range e.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", xerrors.Errorf("the credential plugin %s failed: %w", e.Command, err)
+	}
+	var cred struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err = json.Unmarshal(out, &cred); err != nil {
+		return "", xerrors.Errorf("invalid output of the credential plugin %s: %w", e.Command, err)
+	}
+	if cred.Status.Token == "" {
+		return "", xerrors.Errorf("the credential plugin %s returned no token", e.Command)
+	}
+	return cred.Status.Token, nil
+}
+
+func readFile(dir, path string) ([]byte, error) {
+	return ioutil.ReadFile(resolvePath(dir, path))
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
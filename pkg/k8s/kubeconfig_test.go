@@ -0,0 +1,164 @@
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com:6443
+    certificate-authority-data: Y2EtZGF0YQ==
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com
+    certificate-authority: ca.crt
+    insecure-skip-tls-verify: true
+users:
+- name: dev-user
+  user:
+    token: dev-token
+- name: prod-user
+  user:
+    tokenFile: token
+    username: admin
+    password: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+- name: broken
+  context:
+    cluster: missing
+    user: dev-user
+`
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testKubeconfig), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca-file"), 0600))
+
+	tests := []struct {
+		name        string
+		contextName string
+		want        Config
+		wantErr     string
+	}{
+		{
+			name: "current context",
+			want: Config{
+				Name:        "dev",
+				Server:      "https://dev.example.com:6443",
+				CAData:      []byte("ca-data"),
+				BearerToken: "dev-token",
+			},
+		},
+		{
+			name:        "files relative to the kubeconfig",
+			contextName: "prod",
+			want: Config{
+				Name:      "prod",
+				Server:    "https://prod.example.com",
+				CAData:    []byte("ca-file"),
+				Insecure:  true,
+				TokenFile: filepath.Join(dir, "token"),
+				Username:  "admin",
+				Password:  "secret",
+			},
+		},
+		{
+			name:        "unknown context",
+			contextName: "staging",
+			wantErr:     "context staging not found",
+		},
+		{
+			name:        "unknown cluster",
+			contextName: "broken",
+			wantErr:     "cluster missing of the context broken not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadConfig(path, tt.contextName)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_token(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "bearer token",
+			config: Config{BearerToken: "token", TokenFile: tokenFile},
+			want:   "token",
+		},
+		{
+			name:   "token file",
+			config: Config{TokenFile: tokenFile},
+			want:   "file-token",
+		},
+		{
+			name: "credential plugin",
+			config: Config{Exec: &ExecConfig{
+				Command: "sh",
+				Args:    []string{"-c", `echo "{\"kind\": \"ExecCredential\", \"status\": {\"token\": \"$TOKEN\"}}"`},
+				Env:     []execEnv{{Name: "TOKEN", Value: "exec-token"}},
+			}},
+			want: "exec-token",
+		},
+		{
+			name:    "credential plugin without token",
+			config:  Config{Exec: &ExecConfig{Command: "echo", Args: []string{"{}"}}},
+			wantErr: "returned no token",
+		},
+		{
+			name: "anonymous",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.token()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const (
+	// ReportSummary reports the number of vulnerabilities per severity of each workload
+	ReportSummary = report.ReportSummary
+	// ReportAll reports the vulnerabilities of each image after the summary
+	ReportAll = report.ReportAll
+)
+
+// Report is the results of the images of the workloads of a cluster
+type Report struct {
+	ClusterName string
+	Resources   []Resource
+	// Summary is the number of vulnerabilities per severity of the cluster, counting each image once
+	Summary map[string]int `json:",omitempty"`
+}
+
+// Resource is a workload with the results of its images
+type Resource struct {
+	Namespace string
+	Kind      string
+	Name      string
+	Images    []ImageResult
+	// Summary is the number of vulnerabilities per severity of the images of the workload
+	Summary map[string]int `json:",omitempty"`
+}
+
+// ImageResult is the results of an image, or the error of its scan
+type ImageResult struct {
+	Image   string
+	Results report.Results `json:",omitempty"`
+	Error   string         `json:",omitempty"`
+}
+
+// NewReport returns the report of the workloads with the results of their images
+func NewReport(clusterName string, workloads []Workload, images map[string]ImageResult) Report {
+	r := Report{ClusterName: clusterName}
+	for _, w := range workloads {
+		resource := Resource{Namespace: w.Namespace, Kind: w.Kind, Name: w.Name}
+		for _, image := range w.Images {
+			resource.Images = append(resource.Images, images[image])
+		}
+		r.Resources = append(r.Resources, resource)
+	}
+	sort.SliceStable(r.Resources, func(i, j int) bool {
+		if r.Resources[i].Namespace != r.Resources[j].Namespace {
+			return r.Resources[i].Namespace < r.Resources[j].Namespace
+		}
+		return r.Resources[i].Kind+"/"+r.Resources[i].Name < r.Resources[j].Kind+"/"+r.Resources[j].Name
+	})
+	return r
+}
+
+// Results returns the results of the images of the cluster, each image once in the order of the workloads
+func (r Report) Results() report.Results {
+	var results report.Results
+	for _, image := range r.images() {
+		results = append(results, image.Results...)
+	}
+	return results
+}
+
+// Failed returns the images whose scan failed, each image once
+func (r Report) Failed() []ImageResult {
+	var failed []ImageResult
+	for _, image := range r.images() {
+		if image.Error != "" {
+			failed = append(failed, image)
+		}
+	}
+	return failed
+}
+
+func (r Report) images() []ImageResult {
+	var images []ImageResult
+	seen := map[string]bool{}
+	for _, resource := range r.Resources {
+		for _, image := range resource.Images {
+			if !seen[image.Image] {
+				seen[image.Image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// ReportWriter writes the report of a cluster in table or JSON, as the summary or with all the results
+type ReportWriter struct {
+	Output io.Writer
+	Format string
+	Report string
+	Light  bool
+}
+
+func (w ReportWriter) Write(r Report) error {
+	r.Summary = r.Results().SummaryBySeverity()
+	r.Resources = append([]Resource(nil), r.Resources...)
+	for i, resource := range r.Resources {
+		var results report.Results
+		for _, image := range resource.Images {
+			results = append(results, image.Results...)
+		}
+		r.Resources[i].Summary = results.SummaryBySeverity()
+	}
+
+	switch w.Format {
+	case "json":
+		return w.writeJSON(r)
+	case "table":
+		w.writeSummary(r)
+		if w.Report != ReportAll {
+			return nil
+		}
+		tw := report.TableWriter{Output: w.Output, Light: w.Light, Color: report.IsColorEnabled(w.Output)}
+		for _, image := range r.images() {
+			if err := tw.Write(image.Results); err != nil {
+				return xerrors.Errorf("failed to write the results of %s: %w", image.Image, err)
+			}
+		}
+		return nil
+	}
+	return xerrors.Errorf("unknown format of trivy k8s: %s", w.Format)
+}
+
+func (w ReportWriter) writeJSON(r Report) error {
+	if w.Report != ReportAll {
+		// the summary has the errors of the images, without their results
+		resources := make([]Resource, len(r.Resources))
+		for i, resource := range r.Resources {
+			resources[i] = resource
+			resources[i].Images = make([]ImageResult, len(resource.Images))
+			for j, image := range resource.Images {
+				resources[i].Images[j] = ImageResult{Image: image.Image, Error: image.Error}
+			}
+		}
+		r.Resources = resources
+	}
+	output, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal json: %w", err)
+	}
+	if _, err = fmt.Fprint(w.Output, string(output)); err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
+	}
+	return nil
+}
+
+// writeSummary writes a row of each workload with its number of vulnerabilities per severity,
+// and the total of the cluster, then the images whose scan failed
+func (w ReportWriter) writeSummary(r Report) {
+	fmt.Fprintf(w.Output, "\nCluster: %s\n", r.ClusterName)
+
+	table := tablewriter.NewWriter(w.Output)
+	header := []string{"Namespace", "Workload", "Images"}
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		header = append(header, dbTypes.SeverityNames[i])
+	}
+	table.SetHeader(header)
+	for _, resource := range r.Resources {
+		row := []string{resource.Namespace, resource.Kind + "/" + resource.Name, fmt.Sprint(len(resource.Images))}
+		table.Append(append(row, severityCounts(resource.Summary)...))
+	}
+	table.SetFooter(append([]string{"Total", fmt.Sprintf("%d workloads", len(r.Resources)),
+		fmt.Sprint(len(r.images()))}, severityCounts(r.Summary)...))
+	table.Render()
+
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w.Output, "\nFailed to scan %d images:\n", len(failed))
+	for _, image := range failed {
+		fmt.Fprintf(w.Output, "%s: %s\n", image.Image, image.Error)
+	}
+}
+
+// severityCounts returns the counts from the highest severity
+func severityCounts(summary map[string]int) []string {
+	var counts []string
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		counts = append(counts, fmt.Sprint(summary[dbTypes.SeverityNames[i]]))
+	}
+	return counts
+}
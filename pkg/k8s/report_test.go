@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func testReport() Report {
+	vuln := func(id, severity string) types.DetectedVulnerability {
+		v := types.DetectedVulnerability{VulnerabilityID: id, PkgName: "openssl", InstalledVersion: "1.1.1d-r3"}
+		v.Severity = severity
+		return v
+	}
+	workloads := []Workload{
+		{Namespace: "default", Kind: "Deployment", Name: "web", Images: []string{"nginx:1.19", "alpine:3.11"}},
+		{Namespace: "db", Kind: "StatefulSet", Name: "postgres", Images: []string{"postgres:12"}},
+		{Namespace: "default", Kind: "CronJob", Name: "backup", Images: []string{"alpine:3.11"}},
+	}
+	images := map[string]ImageResult{
+		"nginx:1.19": {Image: "nginx:1.19", Results: report.Results{
+			{Target: "nginx:1.19 (debian 10.4)", Vulnerabilities: []types.DetectedVulnerability{vuln("CVE-2020-1967", "HIGH")}},
+		}},
+		"alpine:3.11": {Image: "alpine:3.11", Results: report.Results{
+			{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: []types.DetectedVulnerability{
+				vuln("CVE-2020-1967", "HIGH"), vuln("CVE-2019-1551", "MEDIUM"),
+			}},
+		}},
+		"postgres:12": {Image: "postgres:12", Error: "unauthorized"},
+	}
+	return NewReport("prod", workloads, images)
+}
+
+func TestNewReport(t *testing.T) {
+	r := testReport()
+	var names []string
+	for _, resource := range r.Resources {
+		names = append(names, resource.Namespace+"/"+resource.Kind+"/"+resource.Name)
+	}
+	assert.Equal(t, []string{"db/StatefulSet/postgres", "default/CronJob/backup", "default/Deployment/web"}, names)
+	assert.Len(t, r.Results(), 2, "alpine:3.11 is counted once")
+	assert.Equal(t, []ImageResult{{Image: "postgres:12", Error: "unauthorized"}}, r.Failed())
+}
+
+func TestReportWriter_Write(t *testing.T) {
+	t.Run("summary table", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, ReportWriter{Output: &output, Format: "table", Report: ReportSummary}.Write(testReport()))
+		assert.Equal(t, `
+Cluster: prod
++-----------+----------------------+--------+----------+------+--------+-----+---------+
+| NAMESPACE |       WORKLOAD       | IMAGES | CRITICAL | HIGH | MEDIUM | LOW | UNKNOWN |
++-----------+----------------------+--------+----------+------+--------+-----+---------+
+| db        | StatefulSet/postgres |      1 |        0 |    0 |      0 |   0 |       0 |
+| default   | CronJob/backup       |      1 |        0 |    1 |      1 |   0 |       0 |
+| default   | Deployment/web       |      2 |        0 |    2 |      1 |   0 |       0 |
++-----------+----------------------+--------+----------+------+--------+-----+---------+
+|   TOTAL   |     3 WORKLOADS      |   3    |    0     |  2   |   1    |  0  |    0    |
++-----------+----------------------+--------+----------+------+--------+-----+---------+
+
+Failed to scan 1 images:
+postgres:12: unauthorized
+`, output.String())
+	})
+
+	t.Run("JSON summary", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, ReportWriter{Output: &output, Format: "json", Report: ReportSummary}.Write(testReport()))
+		var got Report
+		require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+		assert.Equal(t, map[string]int{"HIGH": 2, "MEDIUM": 1}, got.Summary)
+		require.Len(t, got.Resources, 3)
+		assert.Equal(t, map[string]int{"HIGH": 2, "MEDIUM": 1}, got.Resources[2].Summary)
+		assert.Equal(t, []ImageResult{{Image: "nginx:1.19"}, {Image: "alpine:3.11"}}, got.Resources[2].Images)
+		assert.Equal(t, []ImageResult{{Image: "postgres:12", Error: "unauthorized"}}, got.Resources[0].Images)
+	})
+
+	t.Run("JSON of all", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, ReportWriter{Output: &output, Format: "json", Report: ReportAll}.Write(testReport()))
+		var got Report
+		require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+		require.Len(t, got.Resources[2].Images, 2)
+		assert.Equal(t, "nginx:1.19 (debian 10.4)", got.Resources[2].Images[0].Results[0].Target)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := ReportWriter{Output: &bytes.Buffer{}, Format: "sarif", Report: ReportSummary}.Write(testReport())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown format of trivy k8s: sarif")
+	})
+}
@@ -0,0 +1,184 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/registry"
+)
+
+const (
+	secretTypeDockerConfigJSON = "kubernetes.io/dockerconfigjson"
+	secretTypeDockercfg        = "kubernetes.io/dockercfg"
+)
+
+// Credentials are the credentials of the image pull secrets keyed by the host of the registry
+type Credentials map[string]registry.Credential
+
+// For returns the credential of the registry of the image, an empty one without it
+func (c Credentials) For(image string) registry.Credential {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return registry.Credential{}
+	}
+	return c[ref.Context().RegistryStr()]
+}
+
+// PullSecrets resolves the credentials of the image pull secrets of the workloads, those of their pods
+// and of their service accounts as the kubelet does. The secrets and the service accounts are got once.
+// PullSecrets is not safe for concurrent use.
+type PullSecrets struct {
+	client          *Client
+	secrets         map[string]Credentials
+	serviceAccounts map[string][]string
+}
+
+func NewPullSecrets(c *Client) *PullSecrets {
+	return &PullSecrets{client: c, secrets: map[string]Credentials{}, serviceAccounts: map[string][]string{}}
+}
+
+// Credentials returns the credentials of the workload; the pull secrets of the pods come first
+func (p *PullSecrets) Credentials(ctx context.Context, w Workload) (Credentials, error) {
+	saNames, err := p.serviceAccountSecrets(ctx, w.Namespace, w.ServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	names := append(append([]string{}, w.PullSecrets...), saNames...)
+
+	creds := Credentials{}
+	for _, secretName := range names {
+		secret, err := p.secret(ctx, w.Namespace, secretName)
+		if err != nil {
+			return nil, err
+		}
+		for host, cred := range secret {
+			if _, ok := creds[host]; !ok {
+				creds[host] = cred
+			}
+		}
+	}
+	return creds, nil
+}
+
+// serviceAccountSecrets returns the image pull secrets of the service account, "default" when empty
+func (p *PullSecrets) serviceAccountSecrets(ctx context.Context, namespace, serviceAccount string) ([]string, error) {
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	key := namespace + "/" + serviceAccount
+	if names, ok := p.serviceAccounts[key]; ok {
+		return names, nil
+	}
+
+	var sa struct {
+		ImagePullSecrets []struct {
+			Name string `json:"name"`
+		} `json:"imagePullSecrets"`
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/serviceaccounts/" + url.PathEscape(serviceAccount)
+	if err := p.client.get(ctx, path, nil, &sa); err != nil && !xerrors.Is(err, errNotFound) {
+		return nil, xerrors.Errorf("unable to get the service account %s: %w", key, err)
+	}
+	var names []string
+	for _, s := range sa.ImagePullSecrets {
+		names = append(names, s.Name)
+	}
+	p.serviceAccounts[key] = names
+	return names, nil
+}
+
+// secret returns the credentials of the pull secret; a missing secret has none, as the kubelet ignores it
+func (p *PullSecrets) secret(ctx context.Context, namespace, secretName string) (Credentials, error) {
+	key := namespace + "/" + secretName
+	if creds, ok := p.secrets[key]; ok {
+		return creds, nil
+	}
+
+	var secret struct {
+		Type string            `json:"type"`
+		Data map[string][]byte `json:"data"`
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/secrets/" + url.PathEscape(secretName)
+	if err := p.client.get(ctx, path, nil, &secret); err != nil && !xerrors.Is(err, errNotFound) {
+		return nil, xerrors.Errorf("unable to get the secret %s: %w", key, err)
+	}
+
+	var creds Credentials
+	var err error
+	switch secret.Type {
+	case secretTypeDockerConfigJSON:
+		creds, err = parseDockerConfig(secret.Data[".dockerconfigjson"], true)
+	case secretTypeDockercfg:
+		creds, err = parseDockerConfig(secret.Data[".dockercfg"], false)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("invalid pull secret %s: %w", key, err)
+	}
+	p.secrets[key] = creds
+	return creds, nil
+}
+
+// parseDockerConfig parses the auths of a Docker config, {"auths": {...}} of .dockerconfigjson
+// or the auths themselves of the legacy .dockercfg
+func parseDockerConfig(b []byte, wrapped bool) (Credentials, error) {
+	type auth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	var auths map[string]auth
+	if wrapped {
+		var config struct {
+			Auths map[string]auth `json:"auths"`
+		}
+		if err := json.Unmarshal(b, &config); err != nil {
+			return nil, err
+		}
+		auths = config.Auths
+	} else if err := json.Unmarshal(b, &auths); err != nil {
+		return nil, err
+	}
+
+	creds := Credentials{}
+	for key, a := range auths {
+		cred := registry.Credential{Username: a.Username, Password: a.Password}
+		if cred.Empty() && a.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid auth of %s: %w", key, err)
+			}
+			i := strings.Index(string(decoded), ":")
+			if i < 0 {
+				return nil, xerrors.Errorf("invalid auth of %s: no password", key)
+			}
+			cred = registry.Credential{Username: string(decoded[:i]), Password: string(decoded[i+1:])}
+		}
+		if !cred.Empty() {
+			creds[registryHost(key)] = cred
+		}
+	}
+	return creds, nil
+}
+
+// registryHost returns the host of the key of the auths, which may be a URL, e.g. https://index.docker.io/v1/.
+// The hosts of Docker Hub are index.docker.io as in the image references.
+func registryHost(key string) string {
+	host := key
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case "docker.io", "registry-1.docker.io", "index.docker.io":
+		return name.DefaultRegistry
+	}
+	return host
+}
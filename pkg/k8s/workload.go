@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+)
+
+// Workload is a workload of the cluster with the images of its pods
+type Workload struct {
+	Namespace string
+	Kind      string
+	Name      string
+	// Images are the images of the containers and the init containers, once each
+	Images []string
+	// PullSecrets are the names of the image pull secrets of the pods, without those of the service account
+	PullSecrets    []string
+	ServiceAccount string
+}
+
+// workloadResources are the resources listed for the workloads. The objects owned by another, e.g. the
+// ReplicaSets of a Deployment and the Pods of a ReplicaSet, are skipped as their owner is listed.
+var workloadResources = []struct {
+	groupVersion string
+	resource     string
+	kind         string
+}{
+	{"apps/v1", "deployments", "Deployment"},
+	{"apps/v1", "statefulsets", "StatefulSet"},
+	{"apps/v1", "daemonsets", "DaemonSet"},
+	{"apps/v1", "replicasets", "ReplicaSet"},
+	{"v1", "replicationcontrollers", "ReplicationController"},
+	{"batch/v1", "cronjobs", "CronJob"},
+	{"batch/v1", "jobs", "Job"},
+	{"v1", "pods", "Pod"},
+}
+
+// object is the part of the workload objects with the pod spec: the spec of a Pod, the template
+// of the controllers and the job template of a CronJob
+type object struct {
+	Metadata struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		podSpec
+		Template    *podTemplate `json:"template"`
+		JobTemplate *struct {
+			Spec struct {
+				Template podTemplate `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+}
+
+type podTemplate struct {
+	Spec podSpec `json:"spec"`
+}
+
+type podSpec struct {
+	ServiceAccountName string `json:"serviceAccountName"`
+	ImagePullSecrets   []struct {
+		Name string `json:"name"`
+	} `json:"imagePullSecrets"`
+	InitContainers []container `json:"initContainers"`
+	Containers     []container `json:"containers"`
+}
+
+type container struct {
+	Image string `json:"image"`
+}
+
+func (o object) podSpec() podSpec {
+	switch {
+	case o.Spec.Template != nil:
+		return o.Spec.Template.Spec
+	case o.Spec.JobTemplate != nil:
+		return o.Spec.JobTemplate.Spec.Template.Spec
+	}
+	return o.Spec.podSpec
+}
+
+// ListWorkloads returns the workloads of the namespace, or of all the namespaces when it is empty
+func (c *Client) ListWorkloads(ctx context.Context, namespace string) ([]Workload, error) {
+	var workloads []Workload
+	for _, r := range workloadResources {
+		objects, err := c.list(ctx, r.groupVersion, r.resource, namespace)
+		if xerrors.Is(err, errNotFound) && r.resource == "cronjobs" {
+			// batch/v1 CronJobs are served since 1.21
+			objects, err = c.list(ctx, "batch/v1beta1", r.resource, namespace)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("unable to list the %s: %w", r.resource, err)
+		}
+		for _, o := range objects {
+			if len(o.Metadata.OwnerReferences) > 0 {
+				continue
+			}
+			if w := newWorkload(r.kind, o); len(w.Images) > 0 {
+				workloads = append(workloads, w)
+			}
+		}
+	}
+	return workloads, nil
+}
+
+func newWorkload(kind string, o object) Workload {
+	spec := o.podSpec()
+	w := Workload{
+		Namespace:      o.Metadata.Namespace,
+		Kind:           kind,
+		Name:           o.Metadata.Name,
+		ServiceAccount: spec.ServiceAccountName,
+	}
+	seen := map[string]bool{}
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		if c.Image != "" && !seen[c.Image] {
+			seen[c.Image] = true
+			w.Images = append(w.Images, c.Image)
+		}
+	}
+	for _, s := range spec.ImagePullSecrets {
+		w.PullSecrets = append(w.PullSecrets, s.Name)
+	}
+	return w
+}
@@ -0,0 +1,320 @@
+package licensing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/analyzer"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// The categories of the packages, the targets of the license results
+const (
+	CategoryOS     = "OS Packages"
+	CategoryNode   = "Node.js"
+	CategoryPython = "Python"
+)
+
+// Files are the files declaring the licenses of the packages: the database of apk,
+// the copyright files of dpkg in usr/share/doc/<package>/, the package.json files of npm
+// and the metadata of the installed Python packages in <package>.dist-info/ and <package>.egg-info/
+var Files = []string{"lib/apk/db/installed", "copyright", "package.json", "METADATA", "PKG-INFO"}
+
+var commonLicense = regexp.MustCompile(`/usr/share/common-licenses/([A-Za-z0-9.+-]*[A-Za-z0-9+])`)
+
+// Category returns the category of the packages whose licenses are declared in the file, or an empty string
+func Category(filePath string) string {
+	switch {
+	case filePath == "lib/apk/db/installed":
+		return CategoryOS
+	case path.Base(filePath) == "copyright" && path.Dir(path.Dir(filePath)) == "usr/share/doc":
+		return CategoryOS
+	case path.Base(filePath) == "package.json":
+		return CategoryNode
+	case path.Base(filePath) == "METADATA" && strings.HasSuffix(path.Dir(filePath), ".dist-info"),
+		path.Base(filePath) == "PKG-INFO" && strings.HasSuffix(path.Dir(filePath), ".egg-info"):
+		return CategoryPython
+	}
+	return ""
+}
+
+// Parse returns the licenses declared in the file, with the FilePath set. The files of no category have none.
+func Parse(filePath string, content []byte) []types.DetectedLicense {
+	var licenses []types.DetectedLicense
+	switch {
+	case Category(filePath) == "":
+		return nil
+	case filePath == "lib/apk/db/installed":
+		licenses = parseApk(content)
+	case path.Base(filePath) == "copyright":
+		licenses = parseCopyright(path.Base(path.Dir(filePath)), content)
+	case Category(filePath) == CategoryPython:
+		licenses = parsePythonMetadata(content)
+	default:
+		licenses = parsePackageJSON(content)
+	}
+	for i := range licenses {
+		licenses[i].FilePath = filePath
+	}
+	return licenses
+}
+
+// parseApk returns the L: field of each package of the database
+func parseApk(content []byte) []types.DetectedLicense {
+	var licenses []types.DetectedLicense
+	var name, license string
+	add := func() {
+		if name != "" && license != "" {
+			licenses = append(licenses, types.DetectedLicense{PkgName: name, Name: license})
+		}
+		name, license = "", ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			add()
+		case strings.HasPrefix(line, "P:"):
+			name = line[2:]
+		case strings.HasPrefix(line, "L:"):
+			license = line[2:]
+		}
+	}
+	add()
+	return licenses
+}
+
+// parseCopyright returns the License: fields of a machine-readable copyright file, otherwise the licenses
+// of /usr/share/common-licenses referred to, e.g. GPL-2
+func parseCopyright(pkgName string, content []byte) []types.DetectedLicense {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "License:") {
+			add(strings.TrimPrefix(line, "License:"))
+		}
+	}
+	if len(names) == 0 {
+		for _, m := range commonLicense.FindAllSubmatch(content, -1) {
+			add(string(m[1]))
+		}
+	}
+
+	var licenses []types.DetectedLicense
+	for _, name := range names {
+		licenses = append(licenses, types.DetectedLicense{PkgName: pkgName, Name: name})
+	}
+	return licenses
+}
+
+// parsePackageJSON returns the license field of the package, or the types of the deprecated licenses field
+func parsePackageJSON(content []byte) []types.DetectedLicense {
+	var pkg struct {
+		Name     string          `json:"name"`
+		License  json.RawMessage `json:"license"`
+		Licenses []struct {
+			Type string `json:"type"`
+		} `json:"licenses"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil || pkg.Name == "" {
+		return nil
+	}
+
+	var names []string
+	var license struct {
+		Type string `json:"type"`
+	}
+	var name string
+	switch {
+	case json.Unmarshal(pkg.License, &name) == nil && name != "":
+		names = append(names, name)
+	case json.Unmarshal(pkg.License, &license) == nil && license.Type != "":
+		names = append(names, license.Type)
+	default:
+		for _, l := range pkg.Licenses {
+			if l.Type != "" {
+				names = append(names, l.Type)
+			}
+		}
+	}
+
+	var licenses []types.DetectedLicense
+	for _, n := range names {
+		licenses = append(licenses, types.DetectedLicense{PkgName: pkg.Name, Name: n})
+	}
+	return licenses
+}
+
+// parsePythonMetadata returns the License-Expression or License field of the core metadata of the package,
+// otherwise the licenses of its trove classifiers, e.g. "MIT License" of "License :: OSI Approved :: MIT License"
+func parsePythonMetadata(content []byte) []types.DetectedLicense {
+	var name, expression, license string
+	var classifiers []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// the description follows the headers
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			name = value
+		case "License-Expression":
+			expression = value
+		case "License":
+			license = value
+		case "Classifier":
+			if strings.HasPrefix(value, "License ::") {
+				classifier := strings.Split(value, "::")
+				classifiers = append(classifiers, strings.TrimSpace(classifier[len(classifier)-1]))
+			}
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	var names []string
+	switch {
+	case expression != "":
+		names = append(names, expression)
+	case license != "" && license != "UNKNOWN":
+		names = append(names, license)
+	default:
+		names = classifiers
+	}
+
+	var licenses []types.DetectedLicense
+	for _, n := range names {
+		licenses = append(licenses, types.DetectedLicense{PkgName: name, Name: n})
+	}
+	return licenses
+}
+
+// Forbidden reports whether the license expression requires one of the forbidden licenses, e.g. "MIT AND GPL-3.0".
+// A choice of licenses, e.g. "MIT OR GPL-3.0", is forbidden only when all of them are.
+// The licenses are compared case-insensitively, with the suffixes -only, -or-later and + ignored.
+func Forbidden(expression string, forbidden []string) bool {
+	if len(forbidden) == 0 {
+		return false
+	}
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression))
+	p := &expressionParser{tokens: tokens, forbidden: forbidden}
+	return p.or()
+}
+
+// expressionParser evaluates an SPDX-like license expression, where AND binds tighter than OR.
+// The license names are the words between the operators and the parentheses, e.g. "GPL-2.0 WITH Classpath-exception-2.0".
+type expressionParser struct {
+	tokens    []string
+	pos       int
+	forbidden []string
+}
+
+func (p *expressionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *expressionParser) or() bool {
+	forbidden := p.and()
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		// evaluated apart so that the whole choice is consumed
+		choice := p.and()
+		forbidden = forbidden && choice
+	}
+	return forbidden
+}
+
+func (p *expressionParser) and() bool {
+	forbidden := p.license()
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		license := p.license()
+		forbidden = forbidden || license
+	}
+	return forbidden
+}
+
+func (p *expressionParser) license() bool {
+	if p.peek() == "(" {
+		p.pos++
+		forbidden := p.or()
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return forbidden
+	}
+	var words []string
+	for token := p.peek(); token != "" && token != "(" && token != ")" &&
+		!strings.EqualFold(token, "and") && !strings.EqualFold(token, "or"); token = p.peek() {
+		words = append(words, token)
+		p.pos++
+	}
+	if len(words) == 0 {
+		// skips an unbalanced parenthesis
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return false
+	}
+	return isForbidden(strings.Join(words, " "), p.forbidden)
+}
+
+func isForbidden(license string, forbidden []string) bool {
+	license = normalize(license)
+	for _, f := range forbidden {
+		if license != "" && strings.EqualFold(license, normalize(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(license string) string {
+	license = strings.TrimSpace(license)
+	for _, suffix := range []string{"-only", "-or-later", "+"} {
+		license = strings.TrimSuffix(license, suffix)
+	}
+	return license
+}
+
+var (
+	registerMu sync.Mutex
+	registered bool
+)
+
+// Register adds Files to the files extracted by the analysis of fanal, once as they are global
+func Register() {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if !registered {
+		registered = true
+		analyzer.AddRequiredFilenames(Files)
+	}
+}
@@ -0,0 +1,179 @@
+package licensing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		want     []types.DetectedLicense
+	}{
+		{
+			name:     "apk",
+			filePath: "lib/apk/db/installed",
+			content: `C:Q1zfx4Rq2cddGfMRtr
+P:musl
+V:1.1.22-r3
+L:MIT
+
+P:busybox
+V:1.30.1-r2
+L:GPL-2.0-only
+
+P:no-license
+V:1.0
+`,
+			want: []types.DetectedLicense{
+				{PkgName: "musl", Name: "MIT", FilePath: "lib/apk/db/installed"},
+				{PkgName: "busybox", Name: "GPL-2.0-only", FilePath: "lib/apk/db/installed"},
+			},
+		},
+		{
+			name:     "dpkg machine-readable copyright",
+			filePath: "usr/share/doc/libc6/copyright",
+			content: `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+
+Files: *
+Copyright: 1991-2018 Free Software Foundation, Inc.
+License: LGPL-2.1+
+ This library is free software.
+
+Files: debian/*
+License: GPL-2+
+
+Files: manual/*
+License: LGPL-2.1+
+`,
+			want: []types.DetectedLicense{
+				{PkgName: "libc6", Name: "LGPL-2.1+", FilePath: "usr/share/doc/libc6/copyright"},
+				{PkgName: "libc6", Name: "GPL-2+", FilePath: "usr/share/doc/libc6/copyright"},
+			},
+		},
+		{
+			name:     "dpkg common license",
+			filePath: "usr/share/doc/bash/copyright",
+			content:  "On Debian systems, the complete text of the GNU General Public License version 3 can be found in /usr/share/common-licenses/GPL-3.\n",
+			want: []types.DetectedLicense{
+				{PkgName: "bash", Name: "GPL-3", FilePath: "usr/share/doc/bash/copyright"},
+			},
+		},
+		{
+			name:     "copyright out of usr/share/doc",
+			filePath: "app/copyright",
+			content:  "License: MIT\n",
+		},
+		{
+			name:     "package.json",
+			filePath: "app/node_modules/lodash/package.json",
+			content:  `{"name": "lodash", "version": "4.17.15", "license": "MIT"}`,
+			want: []types.DetectedLicense{
+				{PkgName: "lodash", Name: "MIT", FilePath: "app/node_modules/lodash/package.json"},
+			},
+		},
+		{
+			name:     "package.json with the deprecated licenses",
+			filePath: "package.json",
+			content:  `{"name": "app", "licenses": [{"type": "MIT"}, {"type": "Apache-2.0"}]}`,
+			want: []types.DetectedLicense{
+				{PkgName: "app", Name: "MIT", FilePath: "package.json"},
+				{PkgName: "app", Name: "Apache-2.0", FilePath: "package.json"},
+			},
+		},
+		{
+			name:     "package.json with a license object",
+			filePath: "package.json",
+			content:  `{"name": "app", "license": {"type": "ISC"}}`,
+			want: []types.DetectedLicense{
+				{PkgName: "app", Name: "ISC", FilePath: "package.json"},
+			},
+		},
+		{
+			name:     "invalid package.json",
+			filePath: "package.json",
+			content:  `{`,
+		},
+		{
+			name:     "Python dist-info",
+			filePath: "usr/lib/python3.8/site-packages/requests-2.22.0.dist-info/METADATA",
+			content: `Metadata-Version: 2.1
+Name: requests
+Version: 2.22.0
+License: Apache 2.0
+Classifier: License :: OSI Approved :: Apache Software License
+
+License: not a header
+`,
+			want: []types.DetectedLicense{
+				{PkgName: "requests", Name: "Apache 2.0", FilePath: "usr/lib/python3.8/site-packages/requests-2.22.0.dist-info/METADATA"},
+			},
+		},
+		{
+			name:     "Python dist-info with a license expression",
+			filePath: "app/.venv/lib/python3.12/site-packages/attrs-24.2.0.dist-info/METADATA",
+			content:  "Metadata-Version: 2.4\nName: attrs\nLicense-Expression: MIT\nLicense: The MIT License\n",
+			want: []types.DetectedLicense{
+				{PkgName: "attrs", Name: "MIT", FilePath: "app/.venv/lib/python3.12/site-packages/attrs-24.2.0.dist-info/METADATA"},
+			},
+		},
+		{
+			name:     "Python egg-info with the classifiers",
+			filePath: "usr/lib/python2.7/site-packages/six-1.12.0.egg-info/PKG-INFO",
+			content: `Metadata-Version: 1.1
+Name: six
+License: UNKNOWN
+Classifier: Programming Language :: Python :: 2
+Classifier: License :: OSI Approved :: MIT License
+`,
+			want: []types.DetectedLicense{
+				{PkgName: "six", Name: "MIT License", FilePath: "usr/lib/python2.7/site-packages/six-1.12.0.egg-info/PKG-INFO"},
+			},
+		},
+		{
+			name:     "METADATA out of dist-info",
+			filePath: "app/METADATA",
+			content:  "Name: app\nLicense: MIT\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.filePath, []byte(tt.content)))
+		})
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	forbidden := []string{"GPL-3.0", "agpl-3.0"}
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: "GPL-3.0", want: true},
+		{expression: "GPL-3.0-only", want: true},
+		{expression: "GPL-3.0-or-later", want: true},
+		{expression: "GPL-3.0+", want: true},
+		{expression: "AGPL-3.0", want: true},
+		{expression: "GPL-2.0", want: false},
+		{expression: "MIT AND GPL-3.0", want: true},
+		{expression: "MIT OR GPL-3.0", want: false},
+		{expression: "(GPL-3.0 OR AGPL-3.0) AND MIT", want: true},
+		{expression: "GPL-3.0 or MIT", want: false},
+		{expression: "(MIT OR GPL-3.0) AND AGPL-3.0", want: true},
+		{expression: "(MIT OR GPL-3.0) AND Apache-2.0", want: false},
+		{expression: "MIT OR (GPL-3.0 AND BSD-3-Clause)", want: false},
+		{expression: "GPL-3.0 AND (MIT", want: true},
+		{expression: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			assert.Equal(t, tt.want, Forbidden(tt.expression, forbidden))
+		})
+	}
+	assert.False(t, Forbidden("GPL-3.0", nil))
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"golang.org/x/xerrors"
+	"k8s.io/utils/clock"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const namespace = "trivy"
+
+// The results of the scans and of the cache lookups
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+var (
+	// ScansTotal is the number of scans by result, ResultSuccess or ResultFailure
+	ScansTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scans_total",
+		Help:      "Number of scans performed, by result.",
+	}, []string{"result"})
+
+	// ScanDuration is the duration of the scans in seconds, from 100ms to about 7 minutes
+	ScanDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scan_duration_seconds",
+		Help:      "Duration of the scans in seconds.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+
+	// VulnerabilitiesTotal is the number of vulnerabilities found by severity
+	VulnerabilitiesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vulnerabilities_total",
+		Help:      "Number of vulnerabilities found, by severity.",
+	}, []string{"severity"})
+
+	// CacheLookupsTotal is the number of the images and layers looked up in the cache by result, ResultHit or ResultMiss
+	CacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Number of images and layers looked up in the cache, by result.",
+	}, []string{"result"})
+)
+
+// NewRegistry returns a registry of the metrics of Trivy, along with trivy_db_age_seconds when the metadata
+// of the DB is given, and with the metrics of the Go runtime and of the process when runtime is set
+func NewRegistry(metadata func() (db.Metadata, error), clock clock.Clock, runtime bool) (*prometheus.Registry, error) {
+	reg := prometheus.NewRegistry()
+	collectors := []prometheus.Collector{ScansTotal, ScanDuration, VulnerabilitiesTotal, CacheLookupsTotal}
+	if metadata != nil {
+		collectors = append(collectors, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "db_age_seconds",
+			Help:      "Age of the vulnerability DB in seconds, since it was built.",
+		}, func() float64 {
+			m, err := metadata()
+			if err != nil || m.UpdatedAt.IsZero() {
+				return 0
+			}
+			return clock.Since(m.UpdatedAt).Seconds()
+		}))
+	}
+	if runtime {
+		collectors = append(collectors, prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, xerrors.Errorf("failed to register a metric: %w", err)
+		}
+	}
+	return reg, nil
+}
+
+// ObserveScan records a scan started at start with its results, failed when err is set
+func ObserveScan(start time.Time, results report.Results, err error) {
+	ScanDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		ScansTotal.WithLabelValues(ResultFailure).Inc()
+		return
+	}
+	ScansTotal.WithLabelValues(ResultSuccess).Inc()
+
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			severity := vuln.Severity
+			if severity == "" {
+				severity = dbTypes.SeverityUnknown.String()
+			}
+			VulnerabilitiesTotal.WithLabelValues(severity).Inc()
+		}
+	}
+}
+
+// ObserveCacheLookup records a lookup of an image or a layer in the cache
+func ObserveCacheLookup(hit bool) {
+	if hit {
+		CacheLookupsTotal.WithLabelValues(ResultHit).Inc()
+	} else {
+		CacheLookupsTotal.WithLabelValues(ResultMiss).Inc()
+	}
+}
+
+// Push pushes the metrics of the registry to the Pushgateway, e.g. http://pushgateway:9091,
+// replacing those of the trivy job, for the one-shot scans in CI that can't be scraped
+func Push(url string, reg *prometheus.Registry) error {
+	if err := push.New(url, namespace).Gatherer(reg).Push(); err != nil {
+		return xerrors.Errorf("failed to push the metrics to %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/metrics"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestObserveScan(t *testing.T) {
+	success := testutil.ToFloat64(metrics.ScansTotal.WithLabelValues(metrics.ResultSuccess))
+	failure := testutil.ToFloat64(metrics.ScansTotal.WithLabelValues(metrics.ResultFailure))
+	high := testutil.ToFloat64(metrics.VulnerabilitiesTotal.WithLabelValues("HIGH"))
+	unknown := testutil.ToFloat64(metrics.VulnerabilitiesTotal.WithLabelValues("UNKNOWN"))
+
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-5482", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-5481", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-1547"},
+			},
+		},
+	}
+	metrics.ObserveScan(time.Now(), results, nil)
+	metrics.ObserveScan(time.Now(), nil, xerrors.New("unable to pull"))
+
+	assert.Equal(t, success+1, testutil.ToFloat64(metrics.ScansTotal.WithLabelValues(metrics.ResultSuccess)))
+	assert.Equal(t, failure+1, testutil.ToFloat64(metrics.ScansTotal.WithLabelValues(metrics.ResultFailure)))
+	assert.Equal(t, high+2, testutil.ToFloat64(metrics.VulnerabilitiesTotal.WithLabelValues("HIGH")))
+	assert.Equal(t, unknown+1, testutil.ToFloat64(metrics.VulnerabilitiesTotal.WithLabelValues("UNKNOWN")))
+}
+
+func TestNewRegistry(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	metadata := func() (db.Metadata, error) {
+		return db.Metadata{UpdatedAt: now.Add(-2 * time.Hour)}, nil
+	}
+
+	reg, err := metrics.NewRegistry(metadata, clocktesting.NewFakeClock(now), false)
+	require.NoError(t, err)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP trivy_db_age_seconds Age of the vulnerability DB in seconds, since it was built.
+# TYPE trivy_db_age_seconds gauge
+trivy_db_age_seconds 7200
+`), "trivy_db_age_seconds"))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, f := range families {
+		assert.True(t, strings.HasPrefix(f.GetName(), "trivy_"), f.GetName())
+	}
+}
+
+func TestPush(t *testing.T) {
+	var method, path, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	reg, err := metrics.NewRegistry(nil, nil, false)
+	require.NoError(t, err)
+	metrics.ObserveScan(time.Now(), nil, nil)
+
+	require.NoError(t, metrics.Push(ts.URL, reg))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/trivy", path)
+	assert.Contains(t, body, "trivy_scans_total")
+}
@@ -0,0 +1,116 @@
+package misconf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// dockerfileStage is a build stage of a Dockerfile in the input of the policies
+type dockerfileStage struct {
+	// From is the base image of the stage and Name its name given with AS
+	From     string              `json:"from"`
+	Name     string              `json:"name,omitempty"`
+	Commands []dockerfileCommand `json:"commands"`
+}
+
+// dockerfileCommand is an instruction of a Dockerfile, e.g. {"cmd": "user", "value": ["nobody"], "line": 3}.
+// The exec form is split as a JSON array, RUN, CMD, ENTRYPOINT and SHELL in the shell form are a single value,
+// and the arguments of the other instructions are split by whitespace.
+type dockerfileCommand struct {
+	Cmd   string   `json:"cmd"`
+	Value []string `json:"value"`
+	Line  int      `json:"line"`
+}
+
+// shellForms are the instructions whose shell form is a single command line
+var shellForms = map[string]bool{"run": true, "cmd": true, "entrypoint": true, "shell": true}
+
+// parseDockerfile returns the input of the policies of the Dockerfile: {"stages": [...]}.
+// The instructions before the first FROM, e.g. ARG, are in a stage without base image.
+func parseDockerfile(content []byte) (map[string]interface{}, error) {
+	var stages []dockerfileStage
+	var current *dockerfileStage
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var instruction string
+	var lineNum, startLine int
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if instruction == "" && (line == "" || strings.HasPrefix(line, "#")) {
+			continue
+		}
+		if instruction == "" {
+			startLine = lineNum
+		} else if strings.HasPrefix(line, "#") {
+			// comments are allowed between continuation lines
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			instruction += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		instruction += line
+
+		cmd := parseDockerfileCommand(instruction, startLine)
+		instruction = ""
+		if cmd.Cmd == "from" {
+			stages = append(stages, dockerfileStage{})
+			current = &stages[len(stages)-1]
+			current.From, current.Name = parseFrom(cmd.Value)
+		} else if current == nil {
+			stages = append(stages, dockerfileStage{})
+			current = &stages[len(stages)-1]
+		}
+		current.Commands = append(current.Commands, cmd)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read the Dockerfile: %w", err)
+	}
+	if len(stages) == 0 {
+		return nil, xerrors.New("no instruction in the Dockerfile")
+	}
+	return map[string]interface{}{"stages": stages}, nil
+}
+
+func parseDockerfileCommand(instruction string, line int) dockerfileCommand {
+	fields := strings.SplitN(instruction, " ", 2)
+	cmd := dockerfileCommand{Cmd: strings.ToLower(fields[0]), Line: line, Value: []string{}}
+	if len(fields) == 1 {
+		return cmd
+	}
+	args := strings.TrimSpace(fields[1])
+
+	var exec []string
+	if strings.HasPrefix(args, "[") && json.Unmarshal([]byte(args), &exec) == nil {
+		cmd.Value = exec
+	} else if shellForms[cmd.Cmd] {
+		cmd.Value = []string{args}
+	} else {
+		cmd.Value = strings.Fields(args)
+	}
+	return cmd
+}
+
+// parseFrom returns the image and the stage name of the arguments of FROM, e.g. ["--platform=linux/amd64", "golang", "AS", "build"]
+func parseFrom(args []string) (string, string) {
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+		}
+	}
+	switch {
+	case len(positional) == 0:
+		return "", ""
+	case len(positional) >= 3 && strings.EqualFold(positional[1], "as"):
+		return positional[0], positional[2]
+	default:
+		return positional[0], ""
+	}
+}
@@ -0,0 +1,34 @@
+package misconf
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/xerrors"
+)
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// ErrInvalidYAML is the error of the YAML files that can't be parsed, which may be anything but Kubernetes manifests
+var ErrInvalidYAML = xerrors.New("invalid YAML file")
+
+// parseKubernetes returns the manifests of the YAML documents with apiVersion and kind,
+// each one the input of the policies. The other documents are skipped.
+func parseKubernetes(content []byte) ([]map[string]interface{}, error) {
+	var manifests []map[string]interface{}
+	for _, doc := range documentSeparator.Split(string(content), -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var manifest map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &manifest); err != nil {
+			return nil, xerrors.Errorf("%w: %s", ErrInvalidYAML, err)
+		}
+		if manifest["apiVersion"] == nil || manifest["kind"] == nil {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
@@ -0,0 +1,256 @@
+package misconf
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// The types of the config files, also the second part of the packages of their policies
+const (
+	TypeDockerfile = "dockerfile"
+	TypeTerraform  = "terraform"
+	TypeKubernetes = "kubernetes"
+)
+
+// DefaultFiles are the config files searched for misconfigurations, by name or path.Match pattern of the name.
+// The images are only searched for the files named Dockerfile as the patterns can't be extracted from the layers.
+var DefaultFiles = []string{"Dockerfile", "Dockerfile.*", "*.Dockerfile", "*.tf", "*.yaml", "*.yml"}
+
+// policy is a compiled Rego module. Its package is <namespace>.<type>.<name>, e.g. trivy.dockerfile.DS001,
+// and its deny rule returns the messages of the misconfigurations, as strings or objects with a msg field.
+// The optional __rego_metadata__ rule gives the id, the title and the severity of the misconfigurations.
+type policy struct {
+	pkg      string
+	fileType string
+	metadata types.Misconfiguration
+	deny     rego.PreparedEvalQuery
+}
+
+// Scanner evaluates the policies against the config files
+type Scanner struct {
+	policies []policy
+}
+
+// NewScanner compiles BuiltinPolicies with the Rego files of the paths, either files or directories searched recursively
+func NewScanner(ctx context.Context, policyPaths []string) (*Scanner, error) {
+	modules := map[string]string{}
+	for name, module := range BuiltinPolicies {
+		modules["builtin/"+name] = module
+	}
+	for _, p := range policyPaths {
+		if err := loadPolicies(p, modules); err != nil {
+			return nil, xerrors.Errorf("failed to load the policies of %s: %w", p, err)
+		}
+	}
+
+	compiler, err := ast.CompileModules(modules)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to compile the policies: %w", err)
+	}
+
+	var names []string
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := &Scanner{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		module := compiler.Modules[name]
+		pkg := strings.TrimPrefix(module.Package.Path.String(), "data.")
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		p, err := newPolicy(ctx, compiler, pkg)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid policy %s: %w", name, err)
+		}
+		s.policies = append(s.policies, p)
+	}
+	return s, nil
+}
+
+// loadPolicies adds the .rego files of the path to the modules by path
+func loadPolicies(root string, modules map[string]string) error {
+	return filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(filePath) != ".rego" {
+			return nil
+		}
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		modules[filePath] = string(content)
+		return nil
+	})
+}
+
+func newPolicy(ctx context.Context, compiler *ast.Compiler, pkg string) (policy, error) {
+	parts := strings.Split(pkg, ".")
+	if len(parts) < 3 {
+		return policy{}, xerrors.Errorf("the package %s isn't <namespace>.<type>.<name>", pkg)
+	}
+	p := policy{
+		pkg:      pkg,
+		fileType: parts[1],
+		metadata: types.Misconfiguration{ID: parts[len(parts)-1], Severity: "UNKNOWN"},
+	}
+
+	rs, err := rego.New(rego.Query("data."+pkg+".__rego_metadata__"), rego.Compiler(compiler)).Eval(ctx)
+	if err != nil {
+		return policy{}, xerrors.Errorf("failed to evaluate the metadata: %w", err)
+	}
+	if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+		if metadata, ok := rs[0].Expressions[0].Value.(map[string]interface{}); ok {
+			if id, ok := metadata["id"].(string); ok {
+				p.metadata.ID = id
+			}
+			if title, ok := metadata["title"].(string); ok {
+				p.metadata.Title = title
+			}
+			if severity, ok := metadata["severity"].(string); ok {
+				p.metadata.Severity = strings.ToUpper(severity)
+			}
+		}
+	}
+
+	p.deny, err = rego.New(rego.Query("data."+pkg+".deny"), rego.Compiler(compiler)).PrepareForEval(ctx)
+	if err != nil {
+		return policy{}, xerrors.Errorf("failed to prepare the deny rule: %w", err)
+	}
+	return p, nil
+}
+
+// Type returns the type of the config file by its name, or an empty string for the other files.
+// The YAML files are Kubernetes manifests when they have apiVersion and kind.
+func Type(filePath string) string {
+	name := path.Base(filePath)
+	switch {
+	case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") || strings.HasSuffix(name, ".Dockerfile"):
+		return TypeDockerfile
+	case path.Ext(name) == ".tf":
+		return TypeTerraform
+	case path.Ext(name) == ".yaml" || path.Ext(name) == ".yml":
+		return TypeKubernetes
+	}
+	return ""
+}
+
+// Scan returns the type of the config file and its misconfigurations sorted by ID.
+// The files of unknown type, and the YAML files without Kubernetes manifests, have no type.
+func (s *Scanner) Scan(ctx context.Context, filePath string, content []byte) (string, []types.Misconfiguration, error) {
+	fileType := Type(filePath)
+	var inputs []interface{}
+	switch fileType {
+	case TypeDockerfile:
+		input, err := parseDockerfile(content)
+		if err != nil {
+			return "", nil, err
+		}
+		inputs = append(inputs, input)
+	case TypeTerraform:
+		input, err := parseTerraform(content)
+		if err != nil {
+			return "", nil, err
+		}
+		inputs = append(inputs, input)
+	case TypeKubernetes:
+		manifests, err := parseKubernetes(content)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(manifests) == 0 {
+			return "", nil, nil
+		}
+		for _, m := range manifests {
+			inputs = append(inputs, m)
+		}
+	default:
+		return "", nil, nil
+	}
+
+	var misconfs []types.Misconfiguration
+	for _, p := range s.policies {
+		if p.fileType != fileType {
+			continue
+		}
+		for _, input := range inputs {
+			messages, err := p.evaluate(ctx, input)
+			if err != nil {
+				return "", nil, xerrors.Errorf("failed to evaluate the policy %s: %w", p.pkg, err)
+			}
+			for _, msg := range messages {
+				m := p.metadata
+				m.Message = msg
+				misconfs = append(misconfs, m)
+			}
+		}
+	}
+	sort.SliceStable(misconfs, func(i, j int) bool {
+		if misconfs[i].ID != misconfs[j].ID {
+			return misconfs[i].ID < misconfs[j].ID
+		}
+		return misconfs[i].Message < misconfs[j].Message
+	})
+	return fileType, misconfs, nil
+}
+
+// evaluate returns the messages of the deny rule
+func (p policy) evaluate(ctx context.Context, input interface{}) ([]string, error) {
+	rs, err := p.deny.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, err
+	}
+	var messages []string
+	for _, r := range rs {
+		for _, expr := range r.Expressions {
+			values, ok := expr.Value.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, v := range values {
+				switch v := v.(type) {
+				case string:
+					messages = append(messages, v)
+				case map[string]interface{}:
+					messages = append(messages, fmt.Sprint(v["msg"]))
+				}
+			}
+		}
+	}
+	return messages, nil
+}
+
+var (
+	registerMu sync.Mutex
+	registered bool
+)
+
+// Register adds DefaultFiles to the files extracted by the analysis of fanal, once as they are global
+func Register() {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if !registered {
+		registered = true
+		analyzer.AddRequiredFilenames(DefaultFiles)
+	}
+}
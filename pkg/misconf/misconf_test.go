@@ -0,0 +1,207 @@
+package misconf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestScanner_Scan(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		wantType string
+		want     []types.Misconfiguration
+	}{
+		{
+			name:     "Dockerfile",
+			filePath: "app/Dockerfile",
+			content: `FROM golang:1.14 AS build
+COPY . /src
+RUN go build -o /app \
+    ./cmd/app
+
+FROM alpine
+ADD config.json /etc/app/
+USER root
+`,
+			wantType: TypeDockerfile,
+			want: []types.Misconfiguration{
+				{ID: "DS001", Title: "':latest' tag used", Severity: "MEDIUM", Message: "Specify a tag other than latest for the image 'alpine'"},
+				{ID: "DS002", Title: "Image user should not be 'root'", Severity: "HIGH", Message: "The last USER command of the last stage switches to 'root'"},
+				{ID: "DS005", Title: "ADD instead of COPY", Severity: "LOW", Message: "Consider using 'COPY' instead of 'ADD' for 'config.json' at line 7"},
+			},
+		},
+		{
+			name:     "Dockerfile without misconfiguration",
+			filePath: "build.Dockerfile",
+			content:  "FROM alpine:3.11 AS base\nFROM base\nUSER nobody\n",
+			wantType: TypeDockerfile,
+		},
+		{
+			name:     "Kubernetes",
+			filePath: "deploy/app.yaml",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+        - name: app
+          image: app:1.0
+          securityContext:
+            privileged: true
+`,
+			wantType: TypeKubernetes,
+			want: []types.Misconfiguration{
+				{ID: "KSV009", Title: "Access to host network", Severity: "HIGH", Message: "Deployment 'app' should not set 'spec.hostNetwork' to true"},
+				{ID: "KSV017", Title: "Privileged container", Severity: "HIGH", Message: "Container 'app' of Deployment 'app' should not be privileged"},
+			},
+		},
+		{
+			name:     "YAML without manifest",
+			filePath: ".github/workflows/ci.yml",
+			content:  "on: push\n",
+		},
+		{
+			name:     "Terraform",
+			filePath: "infra/main.tf",
+			content: `resource "aws_s3_bucket" "logs" {
+  acl = "private"
+}
+
+resource "aws_s3_bucket" "site" {
+  acl = "public-read"
+}
+
+resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}
+`,
+			wantType: TypeTerraform,
+			want: []types.Misconfiguration{
+				{ID: "TF001", Title: "S3 bucket with a public ACL", Severity: "HIGH", Message: "The S3 bucket 'site' has the public ACL 'public-read'"},
+				{ID: "TF002", Title: "Security group open to the internet", Severity: "CRITICAL", Message: "The security group 'web' allows ingress from 0.0.0.0/0"},
+			},
+		},
+		{
+			name:     "other file",
+			filePath: "app/main.go",
+			content:  "package main",
+		},
+	}
+
+	s, err := NewScanner(context.Background(), nil)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, got, err := s.Scan(context.Background(), tt.filePath, []byte(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, gotType)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewScanner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policies")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docker"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker", "healthcheck.rego"), []byte(`package user.dockerfile.healthcheck
+
+deny[msg] {
+	not any_healthcheck
+	msg := "Add a HEALTHCHECK"
+}
+
+any_healthcheck {
+	input.stages[_].commands[_].cmd == "healthcheck"
+}
+`), 0644))
+
+	t.Run("user policy", func(t *testing.T) {
+		s, err := NewScanner(context.Background(), []string{dir})
+		require.NoError(t, err)
+		_, got, err := s.Scan(context.Background(), "Dockerfile", []byte("FROM alpine:3.11\nUSER nobody\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []types.Misconfiguration{
+			{ID: "healthcheck", Severity: "UNKNOWN", Message: "Add a HEALTHCHECK"},
+		}, got)
+	})
+
+	t.Run("sad path: invalid package", func(t *testing.T) {
+		invalid := filepath.Join(dir, "invalid.rego")
+		require.NoError(t, ioutil.WriteFile(invalid, []byte("package dockerfile\n\ndeny[msg] { msg := \"x\" }\n"), 0644))
+		defer os.Remove(invalid)
+
+		_, err := NewScanner(context.Background(), []string{dir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "isn't <namespace>.<type>.<name>")
+	})
+
+	t.Run("sad path: syntax error", func(t *testing.T) {
+		invalid := filepath.Join(dir, "syntax.rego")
+		require.NoError(t, ioutil.WriteFile(invalid, []byte("package user.dockerfile.syntax\n\ndeny[msg] {\n"), 0644))
+		defer os.Remove(invalid)
+
+		_, err := NewScanner(context.Background(), []string{dir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to compile the policies")
+	})
+}
+
+func TestParseDockerfile(t *testing.T) {
+	got, err := parseDockerfile([]byte(`# syntax=docker/dockerfile:1
+ARG VERSION=3.11
+FROM --platform=linux/amd64 alpine:${VERSION} AS base
+RUN apk add --no-cache \
+    # the certificates
+    ca-certificates
+CMD ["/bin/sh", "-c", "echo hello"]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"stages": []dockerfileStage{
+		{
+			Commands: []dockerfileCommand{
+				{Cmd: "arg", Value: []string{"VERSION=3.11"}, Line: 2},
+			},
+		},
+		{
+			From: "alpine:${VERSION}",
+			Name: "base",
+			Commands: []dockerfileCommand{
+				{Cmd: "from", Value: []string{"--platform=linux/amd64", "alpine:${VERSION}", "AS", "base"}, Line: 3},
+				{Cmd: "run", Value: []string{"apk add --no-cache  ca-certificates"}, Line: 4},
+				{Cmd: "cmd", Value: []string{"/bin/sh", "-c", "echo hello"}, Line: 7},
+			},
+		},
+	}}, got)
+}
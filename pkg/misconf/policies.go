@@ -0,0 +1,189 @@
+package misconf
+
+// BuiltinPolicies are the Rego modules of the policies applied with the user ones, by file name
+var BuiltinPolicies = map[string]string{
+	"dockerfile/latest_tag.rego": `package trivy.dockerfile.DS001
+
+__rego_metadata__ := {
+	"id": "DS001",
+	"title": "':latest' tag used",
+	"severity": "MEDIUM",
+}
+
+stage_names[name] {
+	name := lower(input.stages[_].name)
+	name != ""
+}
+
+untagged(image) {
+	parts := split(image, "/")
+	not contains(parts[count(parts) - 1], ":")
+}
+
+untagged(image) {
+	endswith(image, ":latest")
+}
+
+deny[msg] {
+	image := input.stages[_].from
+	image != ""
+	image != "scratch"
+	not stage_names[lower(image)]
+	not contains(image, "@")
+	not contains(image, "$")
+	untagged(image)
+	msg := sprintf("Specify a tag other than latest for the image '%s'", [image])
+}
+`,
+
+	"dockerfile/root_user.rego": `package trivy.dockerfile.DS002
+
+__rego_metadata__ := {
+	"id": "DS002",
+	"title": "Image user should not be 'root'",
+	"severity": "HIGH",
+}
+
+root_users := {"root", "0", "root:root", "0:0"}
+
+last_users[users] {
+	stage := input.stages[count(input.stages) - 1]
+	users := [c.value[0] | c := stage.commands[_]; c.cmd == "user"; count(c.value) > 0]
+}
+
+deny[msg] {
+	users := last_users[_]
+	count(users) == 0
+	msg := "Specify at least 1 USER command in the last stage with a user other than 'root'"
+}
+
+deny[msg] {
+	users := last_users[_]
+	user := users[count(users) - 1]
+	root_users[user]
+	msg := sprintf("The last USER command of the last stage switches to '%s'", [user])
+}
+`,
+
+	"dockerfile/add_instead_of_copy.rego": `package trivy.dockerfile.DS005
+
+__rego_metadata__ := {
+	"id": "DS005",
+	"title": "ADD instead of COPY",
+	"severity": "LOW",
+}
+
+archive(src) {
+	suffixes := {".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tar.xz"}
+	endswith(src, suffixes[_])
+}
+
+deny[msg] {
+	c := input.stages[_].commands[_]
+	c.cmd == "add"
+	srcs := [v | v := c.value[_]; not startswith(v, "--")]
+	src := srcs[0]
+	not startswith(src, "http://")
+	not startswith(src, "https://")
+	not archive(src)
+	msg := sprintf("Consider using 'COPY' instead of 'ADD' for '%s' at line %d", [src, c.line])
+}
+`,
+
+	"kubernetes/privileged.rego": `package trivy.kubernetes.KSV017
+
+__rego_metadata__ := {
+	"id": "KSV017",
+	"title": "Privileged container",
+	"severity": "HIGH",
+}
+
+pod_specs[spec] {
+	spec := input.spec
+	spec.containers
+}
+
+pod_specs[spec] {
+	spec := input.spec.template.spec
+}
+
+pod_specs[spec] {
+	spec := input.spec.jobTemplate.spec.template.spec
+}
+
+deny[msg] {
+	c := pod_specs[_].containers[_]
+	c.securityContext.privileged == true
+	msg := sprintf("Container '%s' of %s '%s' should not be privileged", [c.name, input.kind, input.metadata.name])
+}
+`,
+
+	"kubernetes/host_network.rego": `package trivy.kubernetes.KSV009
+
+__rego_metadata__ := {
+	"id": "KSV009",
+	"title": "Access to host network",
+	"severity": "HIGH",
+}
+
+pod_specs[spec] {
+	spec := input.spec
+	spec.containers
+}
+
+pod_specs[spec] {
+	spec := input.spec.template.spec
+}
+
+pod_specs[spec] {
+	spec := input.spec.jobTemplate.spec.template.spec
+}
+
+deny[msg] {
+	pod_specs[_].hostNetwork == true
+	msg := sprintf("%s '%s' should not set 'spec.hostNetwork' to true", [input.kind, input.metadata.name])
+}
+`,
+
+	"terraform/s3_public_acl.rego": `package trivy.terraform.TF001
+
+__rego_metadata__ := {
+	"id": "TF001",
+	"title": "S3 bucket with a public ACL",
+	"severity": "HIGH",
+}
+
+public_acls := {"public-read", "public-read-write"}
+
+deny[msg] {
+	bucket := input.resource.aws_s3_bucket[name]
+	public_acls[bucket.acl]
+	msg := sprintf("The S3 bucket '%s' has the public ACL '%s'", [name, bucket.acl])
+}
+`,
+
+	"terraform/open_ingress.rego": `package trivy.terraform.TF002
+
+__rego_metadata__ := {
+	"id": "TF002",
+	"title": "Security group open to the internet",
+	"severity": "CRITICAL",
+}
+
+ingresses[[name, rule]] {
+	rule := input.resource.aws_security_group[name].ingress
+	is_object(rule)
+}
+
+ingresses[[name, rule]] {
+	rule := input.resource.aws_security_group[name].ingress[_]
+	is_object(rule)
+}
+
+deny[msg] {
+	[name, rule] := ingresses[_]
+	rule.cidr_blocks[_] == "0.0.0.0/0"
+	msg := sprintf("The security group '%s' allows ingress from 0.0.0.0/0", [name])
+}
+`,
+}
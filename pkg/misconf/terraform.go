@@ -0,0 +1,81 @@
+package misconf
+
+import (
+	"github.com/hashicorp/hcl"
+	"golang.org/x/xerrors"
+)
+
+// parseTerraform returns the input of the policies of a Terraform file in the HCL syntax of Terraform 0.11,
+// with the blocks as nested objects, e.g. {"resource": {"aws_s3_bucket": {"b": {"acl": "private"}}}}.
+// The repeated blocks of the same resource, e.g. ingress, are an array.
+func parseTerraform(content []byte) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := hcl.Unmarshal(content, &raw); err != nil {
+		return nil, xerrors.Errorf("failed to parse the Terraform file: %w", err)
+	}
+	input, _ := normalizeHCL(raw).(map[string]interface{})
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	return input, nil
+}
+
+// normalizeHCL merges the arrays of objects decoded by HCL for each block into an object.
+// An array whose objects have the same keys with values other than objects is kept.
+func normalizeHCL(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		normalized := map[string]interface{}{}
+		for key, value := range v {
+			normalized[key] = normalizeHCL(value)
+		}
+		return normalized
+	case []map[string]interface{}:
+		var values []interface{}
+		for _, m := range v {
+			values = append(values, normalizeHCL(m))
+		}
+		if merged, ok := mergeObjects(values); ok {
+			return merged
+		}
+		return values
+	case []interface{}:
+		var values []interface{}
+		for _, value := range v {
+			values = append(values, normalizeHCL(value))
+		}
+		if len(values) > 0 {
+			if _, isObject := values[0].(map[string]interface{}); isObject {
+				if merged, ok := mergeObjects(values); ok {
+					return merged
+				}
+			}
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// mergeObjects merges the objects recursively. It fails when they aren't all objects,
+// or when two of them have the same key with values other than objects.
+func mergeObjects(values []interface{}) (map[string]interface{}, bool) {
+	merged := map[string]interface{}{}
+	for _, value := range values {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for key, v := range m {
+			existing, found := merged[key]
+			if !found {
+				merged[key] = v
+				continue
+			}
+			if merged[key], ok = mergeObjects([]interface{}{existing, v}); !ok {
+				return nil, false
+			}
+		}
+	}
+	return merged, true
+}
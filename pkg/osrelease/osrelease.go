@@ -0,0 +1,94 @@
+package osrelease
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	aos "github.com/aquasecurity/fanal/analyzer/os"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// families are the OS families of the IDs of os-release
+var families = map[string]string{
+	"alpine":              aos.Alpine,
+	"debian":              aos.Debian,
+	"ubuntu":              aos.Ubuntu,
+	"centos":              aos.CentOS,
+	"rhel":                aos.RedHat,
+	"fedora":              aos.Fedora,
+	"amzn":                aos.Amazon,
+	"ol":                  aos.Oracle,
+	"opensuse-leap":       aos.OpenSUSELeap,
+	"opensuse-tumbleweed": aos.OpenSUSETumbleweed,
+	"sles":                aos.SLES,
+	"photon":              aos.Photon,
+}
+
+// Parse returns the OS of an os-release file from its ID and VERSION_ID. The derivatives of a family, e.g. ID_LIKE=debian,
+// aren't detected as their packages differ from it.
+func Parse(content []byte) (ftypes.OS, error) {
+	var id, version string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		kv := strings.SplitN(strings.TrimSpace(scanner.Text()), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(kv[1], `"'`)
+		switch kv[0] {
+		case "ID":
+			id = value
+		case "VERSION_ID":
+			version = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ftypes.OS{}, xerrors.Errorf("unable to read os-release: %w", err)
+	}
+
+	family, ok := families[id]
+	if !ok {
+		return ftypes.OS{}, xerrors.Errorf("os-release: unsupported ID %q: %w", id, aos.AnalyzeOSError)
+	}
+	if version == "" && family != aos.OpenSUSETumbleweed {
+		return ftypes.OS{}, xerrors.Errorf("os-release: no VERSION_ID of %s: %w", id, aos.AnalyzeOSError)
+	}
+	return ftypes.OS{Family: family, Name: version}, nil
+}
+
+// osReleaseAnalyzer detects the OS from os-release, after the analyzers of the distributions failed
+type osReleaseAnalyzer struct{}
+
+func (a osReleaseAnalyzer) Analyze(fileMap extractor.FileMap) (ftypes.OS, error) {
+	for _, filename := range a.RequiredFiles() {
+		if content, ok := fileMap[filename]; ok {
+			return Parse(content)
+		}
+	}
+	return ftypes.OS{}, xerrors.Errorf("os-release: %w", aos.AnalyzeOSError)
+}
+
+// RequiredFiles are the os-release files, the one of /etc first as it overrides the one of /usr/lib
+func (a osReleaseAnalyzer) RequiredFiles() []string {
+	return []string{
+		"etc/os-release",
+		"usr/lib/os-release",
+	}
+}
+
+var registerOnce sync.Once
+
+// Register enables the detection of the OS from os-release when the files of the distributions, e.g. etc/debian_version,
+// are missing, as in the minimal root filesystems of hosts and VMs. It is registered after the analyzers of fanal,
+// which are tried first, and only the first call is effective.
+func Register() {
+	registerOnce.Do(func() {
+		analyzer.RegisterOSAnalyzer(osReleaseAnalyzer{})
+	})
+}
@@ -0,0 +1,81 @@
+package osrelease
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aos "github.com/aquasecurity/fanal/analyzer/os"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+func TestOSReleaseAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		fileMap extractor.FileMap
+		want    ftypes.OS
+		wantErr string
+	}{
+		{
+			name: "debian",
+			fileMap: extractor.FileMap{
+				"etc/os-release": []byte(`PRETTY_NAME="Debian GNU/Linux 10 (buster)"
+NAME="Debian GNU/Linux"
+VERSION_ID="10"
+VERSION="10 (buster)"
+ID=debian
+HOME_URL="https://www.debian.org/"
+`),
+			},
+			want: ftypes.OS{Family: aos.Debian, Name: "10"},
+		},
+		{
+			name: "ubuntu in /usr/lib",
+			fileMap: extractor.FileMap{
+				"usr/lib/os-release": []byte("NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\nVERSION_ID=\"20.04\"\n"),
+			},
+			want: ftypes.OS{Family: aos.Ubuntu, Name: "20.04"},
+		},
+		{
+			name: "/etc first",
+			fileMap: extractor.FileMap{
+				"etc/os-release":     []byte("ID=alpine\nVERSION_ID=3.11.5\n"),
+				"usr/lib/os-release": []byte("ID=alpine\nVERSION_ID=3.10.0\n"),
+			},
+			want: ftypes.OS{Family: aos.Alpine, Name: "3.11.5"},
+		},
+		{
+			name: "derivative",
+			fileMap: extractor.FileMap{
+				"etc/os-release": []byte("ID=linuxmint\nID_LIKE=\"ubuntu debian\"\nVERSION_ID=\"20\"\n"),
+			},
+			wantErr: `unsupported ID "linuxmint"`,
+		},
+		{
+			name: "no version",
+			fileMap: extractor.FileMap{
+				"etc/os-release": []byte("ID=debian\n"),
+			},
+			wantErr: "no VERSION_ID of debian",
+		},
+		{
+			name:    "no os-release",
+			fileMap: extractor.FileMap{"etc/debian_version": []byte("10.3\n")},
+			wantErr: "no target os",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := osReleaseAnalyzer{}.Analyze(tt.fileMap)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
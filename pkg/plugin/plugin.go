@@ -0,0 +1,298 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/git"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const (
+	// manifestFile describes the plugin of a directory
+	manifestFile = "plugin.yaml"
+	// dirEnv is the directory of the plugin in the environment of its executable
+	dirEnv = "TRIVY_PLUGIN_DIR"
+)
+
+// Plugin is an executable run as a subcommand of trivy, or as an output plugin reading the JSON results on stdin
+type Plugin struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+	// Output reports whether the plugin is an output plugin of --output-plugin
+	Output bool `json:"output"`
+	// Bin is the path of the executable relative to the directory of the plugin, see Platforms
+	Bin string `json:"bin"`
+	// Platforms are the executables of the operating systems and the architectures, before Bin
+	Platforms []Platform `json:"platforms"`
+
+	// Dir is the directory of the plugin, and Executable the path of its executable
+	Dir        string `json:"-"`
+	Executable string `json:"-"`
+}
+
+// Platform is the executable of the plugin for an operating system and an architecture, any without them
+type Platform struct {
+	Selector struct {
+		OS   string `json:"os"`
+		Arch string `json:"arch"`
+	} `json:"selector"`
+	Bin string `json:"bin"`
+}
+
+// Manager installs the plugins into a directory, each one in its subdirectory with the plugin.yaml manifest
+// or as an executable file
+type Manager struct {
+	dir string
+}
+
+// NewManager returns the manager of the plugins of the directory, created by Install
+func NewManager(dir string) Manager {
+	return Manager{dir: dir}
+}
+
+// DefaultDir returns ~/.trivy/plugins
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.TempDir()
+	}
+	return filepath.Join(home, ".trivy", "plugins")
+}
+
+// List returns the installed plugins sorted by name. A missing directory has no plugins, and the invalid
+// plugins are errors.
+func (m Manager) List() ([]Plugin, error) {
+	entries, err := ioutil.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, xerrors.Errorf("unable to read the plugin directory: %w", err)
+	}
+
+	var plugins []Plugin
+	for _, entry := range entries {
+		p, err := load(filepath.Join(m.dir, entry.Name()))
+		if xerrors.Is(err, errNotPlugin) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Load returns the installed plugin of the name
+func (m Manager) Load(name string) (Plugin, error) {
+	plugins, err := m.List()
+	if err != nil {
+		return Plugin{}, err
+	}
+	for _, p := range plugins {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Plugin{}, xerrors.Errorf("plugin %s isn't installed in %s", name, m.dir)
+}
+
+// Install installs the plugin of a local directory with the plugin.yaml manifest, a local executable
+// or a git repository with the manifest, replacing the installed plugin of the same name
+func (m Manager) Install(ctx context.Context, src string) (Plugin, error) {
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		dir, cleanup, err := git.Clone(ctx, git.CloneOption{URL: src})
+		if err != nil {
+			return Plugin{}, xerrors.Errorf("%s is neither a local plugin nor a git repository: %w", src, err)
+		}
+		defer cleanup()
+		return m.installDir(dir)
+	} else if err != nil {
+		return Plugin{}, xerrors.Errorf("unable to read %s: %w", src, err)
+	}
+
+	if info.IsDir() {
+		return m.installDir(src)
+	}
+	if info.Mode()&0111 == 0 {
+		return Plugin{}, xerrors.Errorf("%s isn't executable", src)
+	}
+	if err = os.MkdirAll(m.dir, 0700); err != nil {
+		return Plugin{}, xerrors.Errorf("unable to create the plugin directory: %w", err)
+	}
+	dst := filepath.Join(m.dir, filepath.Base(src))
+	if err = os.RemoveAll(dst); err != nil {
+		return Plugin{}, xerrors.Errorf("unable to remove the installed plugin: %w", err)
+	}
+	if err = copyFile(src, dst, info.Mode()); err != nil {
+		return Plugin{}, err
+	}
+	return load(dst)
+}
+
+func (m Manager) installDir(src string) (Plugin, error) {
+	p, err := load(src)
+	if xerrors.Is(err, errNotPlugin) {
+		return Plugin{}, xerrors.Errorf("%s has no %s", src, manifestFile)
+	} else if err != nil {
+		return Plugin{}, err
+	}
+	if p.Name == "" || p.Name != filepath.Base(p.Name) || p.Name[0] == '.' {
+		return Plugin{}, xerrors.Errorf("invalid plugin name %q in %s", p.Name, manifestFile)
+	}
+
+	dst := filepath.Join(m.dir, p.Name)
+	if err = os.RemoveAll(dst); err != nil {
+		return Plugin{}, xerrors.Errorf("unable to remove the installed plugin: %w", err)
+	}
+	if err = copyDir(src, dst); err != nil {
+		return Plugin{}, xerrors.Errorf("unable to copy the plugin: %w", err)
+	}
+	return load(dst)
+}
+
+// Uninstall removes the installed plugin of the name
+func (m Manager) Uninstall(name string) error {
+	p, err := m.Load(name)
+	if err != nil {
+		return err
+	}
+	path := p.Dir
+	if path == m.dir {
+		// an executable file
+		path = p.Executable
+	}
+	if err = os.RemoveAll(path); err != nil {
+		return xerrors.Errorf("unable to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// RunOptions are the arguments and the standard streams of a run of a plugin
+type RunOptions struct {
+	Args   []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run runs the executable of the plugin with the environment of trivy and TRIVY_PLUGIN_DIR.
+// A failed run returns the *exec.ExitError of its exit code.
+func (p Plugin) Run(ctx context.Context, opts RunOptions) error {
+	cmd := exec.CommandContext(ctx, p.Executable, opts.Args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = opts.Stdin, opts.Stdout, opts.Stderr
+	cmd.Env = append(os.Environ(), dirEnv+"="+p.Dir)
+	if err := cmd.Run(); err != nil {
+		return xerrors.Errorf("plugin %s failed: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Writer writes the results with an output plugin, given the JSON results on stdin and writing to Output
+type Writer struct {
+	Plugin Plugin
+	Output io.Writer
+}
+
+func (w Writer) Write(results report.Results) error {
+	var b bytes.Buffer
+	if err := (&report.JsonWriter{Output: &b}).Write(results); err != nil {
+		return err
+	}
+	return w.Plugin.Run(context.Background(), RunOptions{Stdin: &b, Stdout: w.Output, Stderr: os.Stderr})
+}
+
+var errNotPlugin = xerrors.New("not a plugin")
+
+// load reads the plugin of the directory, or of the executable file
+func load(path string) (Plugin, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Plugin{}, xerrors.Errorf("unable to read the plugin %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		if info.Mode()&0111 == 0 {
+			return Plugin{}, errNotPlugin
+		}
+		return Plugin{Name: filepath.Base(path), Dir: filepath.Dir(path), Executable: path}, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(path, manifestFile))
+	if os.IsNotExist(err) {
+		return Plugin{}, errNotPlugin
+	} else if err != nil {
+		return Plugin{}, xerrors.Errorf("unable to read the manifest of %s: %w", path, err)
+	}
+	var p Plugin
+	if err = yaml.Unmarshal(b, &p); err != nil {
+		return Plugin{}, xerrors.Errorf("invalid manifest of %s: %w", path, err)
+	}
+	p.Dir = path
+
+	bin := p.Bin
+	for _, platform := range p.Platforms {
+		s := platform.Selector
+		if (s.OS == "" || s.OS == runtime.GOOS) && (s.Arch == "" || s.Arch == runtime.GOARCH) {
+			bin = platform.Bin
+			break
+		}
+	}
+	if bin == "" {
+		return Plugin{}, xerrors.Errorf("plugin %s has no executable for %s/%s", p.Name, runtime.GOOS, runtime.GOARCH)
+	}
+	p.Executable = filepath.Join(path, filepath.FromSlash(bin))
+	return p, nil
+}
+
+// copyDir copies the files of the directory, keeping their modes
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0700)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode())
+		}
+		// the symbolic links aren't followed out of the plugin
+		return nil
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return xerrors.Errorf("unable to open %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return xerrors.Errorf("unable to create %s: %w", dst, err)
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return xerrors.Errorf("unable to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
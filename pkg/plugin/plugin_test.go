@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), mode))
+}
+
+func TestManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugins of the test are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "trivy-plugin")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// a plugin with its manifest and another platform
+	src := filepath.Join(dir, "src", "hello")
+	writeFile(t, filepath.Join(src, manifestFile), `name: hello
+version: 0.1.0
+usage: say hello
+platforms:
+  - selector:
+      os: plan9
+    bin: ./plan9/hello
+bin: ./hello.sh
+`, 0600)
+	writeFile(t, filepath.Join(src, "hello.sh"), "#!/bin/sh\necho \"hello $* from $(basename $TRIVY_PLUGIN_DIR)\"\nexit 3\n", 0700)
+	// an output plugin counting the results
+	count := filepath.Join(dir, "src", "count")
+	writeFile(t, filepath.Join(count, manifestFile), "name: count\noutput: true\nbin: count.sh\n", 0600)
+	writeFile(t, filepath.Join(count, "count.sh"), "#!/bin/sh\ngrep -o '\"Target\"' | wc -l\n", 0700)
+	// an executable plugin without manifest
+	bare := filepath.Join(dir, "src", "trivy-bare")
+	writeFile(t, bare, "#!/bin/sh\necho bare\n", 0700)
+
+	m := NewManager(filepath.Join(dir, "plugins"))
+	plugins, err := m.List()
+	require.NoError(t, err)
+	assert.Empty(t, plugins, "the directory doesn't exist yet")
+
+	for _, path := range []string{src, count, bare} {
+		_, err = m.Install(context.Background(), path)
+		require.NoError(t, err)
+	}
+	// a file of the directory that isn't executable isn't a plugin
+	writeFile(t, filepath.Join(dir, "plugins", "README"), "plugins", 0600)
+
+	plugins, err = m.List()
+	require.NoError(t, err)
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"count", "hello", "trivy-bare"}, names)
+	assert.True(t, plugins[0].Output)
+	assert.Equal(t, "0.1.0", plugins[1].Version)
+	assert.Equal(t, filepath.Join(dir, "plugins", "hello", "hello.sh"), plugins[1].Executable)
+
+	t.Run("run", func(t *testing.T) {
+		p, err := m.Load("hello")
+		require.NoError(t, err)
+		var stdout bytes.Buffer
+		err = p.Run(context.Background(), RunOptions{Args: []string{"world"}, Stdout: &stdout})
+		assert.Equal(t, "hello world from hello\n", stdout.String())
+		var exitErr *exec.ExitError
+		require.True(t, xerrors.As(err, &exitErr))
+		assert.Equal(t, 3, exitErr.ExitCode())
+	})
+
+	t.Run("output plugin", func(t *testing.T) {
+		p, err := m.Load("count")
+		require.NoError(t, err)
+		var output bytes.Buffer
+		err = Writer{Plugin: p, Output: &output}.Write(report.Results{
+			{Target: "alpine:3.11", Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967"}}},
+			{Target: "app/package-lock.json"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2", strings.TrimSpace(output.String()))
+	})
+
+	t.Run("uninstall", func(t *testing.T) {
+		require.NoError(t, m.Uninstall("hello"))
+		require.NoError(t, m.Uninstall("trivy-bare"))
+		plugins, err := m.List()
+		require.NoError(t, err)
+		require.Len(t, plugins, 1)
+		assert.Equal(t, "count", plugins[0].Name)
+
+		err = m.Uninstall("hello")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "plugin hello isn't installed")
+	})
+}
+
+func TestManager_Install_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-plugin")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "noexec"), "#!/bin/sh\n", 0600)
+	writeFile(t, filepath.Join(dir, "traversal", manifestFile), "name: ../escape\nbin: run.sh\n", 0600)
+	writeFile(t, filepath.Join(dir, "nobin", manifestFile), "name: nobin\n", 0600)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "nomanifest"), 0700))
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "not executable", src: filepath.Join(dir, "noexec"), wantErr: "isn't executable"},
+		{name: "invalid name", src: filepath.Join(dir, "traversal"), wantErr: `invalid plugin name "../escape"`},
+		{name: "no executable", src: filepath.Join(dir, "nobin"), wantErr: "plugin nobin has no executable"},
+		{name: "no manifest", src: filepath.Join(dir, "nomanifest"), wantErr: "has no plugin.yaml"},
+	}
+	m := NewManager(filepath.Join(dir, "plugins"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Install(context.Background(), tt.src)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
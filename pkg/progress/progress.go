@@ -0,0 +1,212 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// Phase is a step of a scan
+type Phase string
+
+const (
+	// DBUpdate is the download of the vulnerability DB
+	DBUpdate Phase = "db-update"
+	// Pull is the access to the image, e.g. its manifest and config in the registry
+	Pull Phase = "pull"
+	// Analyze is the analysis of the layers, one step per layer
+	Analyze Phase = "analyze"
+	// Match is the detection of the vulnerabilities of the packages
+	Match Phase = "match"
+)
+
+// Status is the state of a phase in an event
+type Status string
+
+const (
+	Started  Status = "started"
+	Running  Status = "running"
+	Finished Status = "finished"
+)
+
+const (
+	// FormatBar renders the events as a progress bar on a terminal, nothing otherwise
+	FormatBar = "bar"
+	// FormatJSON writes every event as a JSON line, e.g. for a CI wrapper
+	FormatJSON = "json"
+	// FormatNone reports nothing
+	FormatNone = "none"
+)
+
+// Formats are the values of --progress
+var Formats = []string{FormatBar, FormatJSON, FormatNone}
+
+// Event is the start, a step or the end of a phase. Current and Total count the steps, e.g. the analyzed layers.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Phase   Phase     `json:"phase"`
+	Status  Status    `json:"status"`
+	Target  string    `json:"target,omitempty"`
+	Current int       `json:"current,omitempty"`
+	Total   int       `json:"total,omitempty"`
+	// Detail is the item of the step, e.g. the diff ID of the analyzed layer
+	Detail string `json:"detail,omitempty"`
+}
+
+// Reporter receives the events of the scan, possibly from several goroutines
+type Reporter interface {
+	Report(Event)
+}
+
+var (
+	mu       sync.RWMutex
+	reporter Reporter = nopReporter{}
+	// now is replaced in tests
+	now = time.Now
+)
+
+// SetReporter sets the reporter of the events, nil to report nothing
+func SetReporter(r Reporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r == nil {
+		r = nopReporter{}
+	}
+	reporter = r
+}
+
+// Emit reports the event at the current time
+func Emit(e Event) {
+	e.Time = now()
+	mu.RLock()
+	r := reporter
+	mu.RUnlock()
+	r.Report(e)
+}
+
+// Start reports the start of the phase
+func Start(phase Phase, target string) {
+	Emit(Event{Phase: phase, Status: Started, Target: target})
+}
+
+// Step reports the current step of the total ones of the phase
+func Step(phase Phase, current, total int, detail string) {
+	Emit(Event{Phase: phase, Status: Running, Current: current, Total: total, Detail: detail})
+}
+
+// Finish reports the end of the phase
+func Finish(phase Phase, target string) {
+	Emit(Event{Phase: phase, Status: Finished, Target: target})
+}
+
+// Init reports the events in the format on stderr, nothing without format.
+// Quiet suppresses the bar, e.g. with --quiet or --no-progress, but not the JSON lines.
+func Init(format string, quiet bool) error {
+	if format == "" || (format == FormatBar && quiet) {
+		format = FormatNone
+	}
+	r, err := NewReporter(format, os.Stderr)
+	if err != nil {
+		return err
+	}
+	SetReporter(r)
+	return nil
+}
+
+// NewReporter returns the reporter of the format writing to w, usually stderr.
+// The bar is only rendered on a terminal.
+func NewReporter(format string, w *os.File) (Reporter, error) {
+	switch format {
+	case FormatBar:
+		if !isTerminal(w) {
+			return nopReporter{}, nil
+		}
+		return &barReporter{w: w}, nil
+	case FormatJSON:
+		return &jsonReporter{w: w}, nil
+	case FormatNone:
+		return nopReporter{}, nil
+	}
+	return nil, xerrors.Errorf("unknown progress format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Report(Event) {}
+
+// jsonReporter writes an event per line
+type jsonReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (r *jsonReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// a failing write must not fail the scan
+	_ = json.NewEncoder(r.w).Encode(e)
+}
+
+// barWidth is the number of characters of the bar
+const barWidth = 30
+
+// barReporter rewrites the line of the current phase, which is kept once finished
+type barReporter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start map[Phase]time.Time
+}
+
+func (r *barReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.start == nil {
+		r.start = map[Phase]time.Time{}
+	}
+
+	var line string
+	switch e.Status {
+	case Started:
+		r.start[e.Phase] = e.Time
+		line = fmt.Sprintf("%-9s %s", e.Phase, e.Target)
+	case Running:
+		line = fmt.Sprintf("%-9s %s %d/%d %s", e.Phase, bar(e.Current, e.Total), e.Current, e.Total, shorten(e.Detail))
+	case Finished:
+		line = fmt.Sprintf("%-9s %s done in %s\n", e.Phase, e.Target, e.Time.Sub(r.start[e.Phase]).Round(time.Millisecond))
+	}
+	// clears the rest of the previous line
+	fmt.Fprintf(r.w, "\r\x1b[K%s", line)
+}
+
+func bar(current, total int) string {
+	if total <= 0 {
+		return "[" + strings.Repeat(" ", barWidth) + "]"
+	}
+	if current > total {
+		current = total
+	}
+	n := current * barWidth / total
+	return "[" + strings.Repeat("=", n) + strings.Repeat(" ", barWidth-n) + "]"
+}
+
+// shorten keeps the beginning of the digests, e.g. sha256:0123456789ab
+func shorten(s string) string {
+	if len(s) > 19 {
+		return s[:19]
+	}
+	return s
+}
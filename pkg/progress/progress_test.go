@@ -0,0 +1,110 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Report(e Event) {
+	r.events = append(r.events, e)
+}
+
+func fixedNow() func() {
+	old := now
+	clock := time.Date(2021, 8, 25, 12, 20, 30, 0, time.UTC)
+	now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return func() { now = old }
+}
+
+func TestEmit(t *testing.T) {
+	defer fixedNow()()
+	r := &recorder{}
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	Start(Analyze, "alpine:3.11")
+	Step(Analyze, 1, 2, "sha256:0123")
+	Finish(Analyze, "alpine:3.11")
+
+	require.Len(t, r.events, 3)
+	assert.Equal(t, Event{Time: time.Date(2021, 8, 25, 12, 20, 31, 0, time.UTC), Phase: Analyze, Status: Started,
+		Target: "alpine:3.11"}, r.events[0])
+	assert.Equal(t, Event{Time: time.Date(2021, 8, 25, 12, 20, 32, 0, time.UTC), Phase: Analyze, Status: Running,
+		Current: 1, Total: 2, Detail: "sha256:0123"}, r.events[1])
+	assert.Equal(t, Finished, r.events[2].Status)
+
+	SetReporter(nil)
+	Start(Match, "alpine:3.11")
+	assert.Len(t, r.events, 3, "the events aren't reported after the reporter is unset")
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := &jsonReporter{w: &buf}
+	r.Report(Event{Time: time.Date(2021, 8, 25, 12, 20, 30, 0, time.UTC), Phase: Analyze, Status: Running,
+		Current: 3, Total: 12, Detail: "sha256:0123"})
+	r.Report(Event{Time: time.Date(2021, 8, 25, 12, 20, 31, 0, time.UTC), Phase: Match, Status: Started,
+		Target: "alpine:3.11"})
+
+	assert.Equal(t, `{"time":"2021-08-25T12:20:30Z","phase":"analyze","status":"running","current":3,"total":12,"detail":"sha256:0123"}
+{"time":"2021-08-25T12:20:31Z","phase":"match","status":"started","target":"alpine:3.11"}
+`, buf.String())
+
+	// every line is an event
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+	}
+}
+
+func TestBarReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := &barReporter{w: &buf}
+	start := time.Date(2021, 8, 25, 12, 20, 30, 0, time.UTC)
+	r.Report(Event{Time: start, Phase: Analyze, Status: Started, Target: "alpine:3.11"})
+	r.Report(Event{Time: start, Phase: Analyze, Status: Running, Current: 1, Total: 3,
+		Detail: "sha256:0123456789abcdef0123456789abcdef"})
+	r.Report(Event{Time: start.Add(1500 * time.Millisecond), Phase: Analyze, Status: Finished, Target: "alpine:3.11"})
+
+	lines := strings.Split(buf.String(), "\r\x1b[K")
+	assert.Equal(t, []string{
+		"",
+		"analyze   alpine:3.11",
+		"analyze   [==========                    ] 1/3 sha256:0123456789ab",
+		"analyze   alpine:3.11 done in 1.5s\n",
+	}, lines)
+}
+
+func TestNewReporter(t *testing.T) {
+	f, err := ioutil.TempFile("", "progress")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	r, err := NewReporter(FormatBar, f)
+	require.NoError(t, err)
+	assert.Equal(t, nopReporter{}, r, "the bar is only rendered on a terminal")
+
+	r, err = NewReporter(FormatJSON, f)
+	require.NoError(t, err)
+	assert.IsType(t, &jsonReporter{}, r)
+
+	_, err = NewReporter("xml", f)
+	require.Error(t, err)
+	assert.Equal(t, `unknown progress format "xml", expected one of bar, json, none`, err.Error())
+}
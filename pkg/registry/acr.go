@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	azureScope = "https://management.azure.com/.default"
+	// acrUsername is the username of the refresh tokens of ACR
+	acrUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+var (
+	// azureAuthority and acrScheme are replaced in tests
+	azureAuthority = "https://login.microsoftonline.com"
+	acrScheme      = "https"
+)
+
+// acrExchanger exchanges an Azure AD token of the service principal of AZURE_TENANT_ID, AZURE_CLIENT_ID and
+// AZURE_CLIENT_SECRET for a refresh token of the registry, as `az acr login` does
+type acrExchanger struct{}
+
+func (acrExchanger) Match(host string) bool {
+	for _, suffix := range []string{".azurecr.io", ".azurecr.cn", ".azurecr.us"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (acrExchanger) Exchange(ctx context.Context, host string) (Credential, error) {
+	tenantID, clientID, clientSecret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if tenantID == "" || clientID == "" || clientSecret == "" {
+		return Credential{}, nil
+	}
+
+	var aadToken struct {
+		AccessToken string `json:"access_token"`
+	}
+	err := postForm(ctx, azureAuthority+"/"+url.PathEscape(tenantID)+"/oauth2/v2.0/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"scope":         {azureScope},
+	}, &aadToken)
+	if err != nil {
+		return Credential{}, xerrors.Errorf("failed to get an Azure AD token: %w", err)
+	}
+
+	var acrToken struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = postForm(ctx, acrScheme+"://"+host+"/oauth2/exchange", url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {host},
+		"tenant":       {tenantID},
+		"access_token": {aadToken.AccessToken},
+	}, &acrToken)
+	if err != nil {
+		return Credential{}, xerrors.Errorf("failed to exchange the Azure AD token: %w", err)
+	}
+	return Credential{Username: acrUsername, Password: acrToken.RefreshToken}, nil
+}
+
+// postForm posts the form and decodes the JSON response into v
+func postForm(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return xerrors.Errorf("%s: %s", endpoint, resp.Status)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return xerrors.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACRExchanger_Exchange(t *testing.T) {
+	var host string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.URL.Path {
+		case "/tenant/oauth2/v2.0/token":
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "client", r.PostForm.Get("client_id"))
+			assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+			w.Write([]byte(`{"access_token": "aad-token"}`))
+		case "/oauth2/exchange":
+			assert.Equal(t, "access_token", r.PostForm.Get("grant_type"))
+			assert.Equal(t, host, r.PostForm.Get("service"))
+			assert.Equal(t, "tenant", r.PostForm.Get("tenant"))
+			if r.PostForm.Get("access_token") != "aad-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"refresh_token": "acr-token"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	host = strings.TrimPrefix(ts.URL, "http://")
+
+	oldAuthority, oldScheme := azureAuthority, acrScheme
+	defer func() { azureAuthority, acrScheme = oldAuthority, oldScheme }()
+	azureAuthority, acrScheme = ts.URL, "http"
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		want    Credential
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			envs: map[string]string{"AZURE_TENANT_ID": "tenant", "AZURE_CLIENT_ID": "client", "AZURE_CLIENT_SECRET": "secret"},
+			want: Credential{Username: acrUsername, Password: "acr-token"},
+		},
+		{
+			name: "no service principal",
+			envs: map[string]string{"AZURE_TENANT_ID": "", "AZURE_CLIENT_ID": "", "AZURE_CLIENT_SECRET": ""},
+			want: Credential{},
+		},
+		{
+			name:    "unknown tenant",
+			envs:    map[string]string{"AZURE_TENANT_ID": "other", "AZURE_CLIENT_ID": "client", "AZURE_CLIENT_SECRET": "secret"},
+			wantErr: "failed to get an Azure AD token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer setenv(tt.envs)()
+
+			got, err := acrExchanger{}.Exchange(context.Background(), host)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
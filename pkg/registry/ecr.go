@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"golang.org/x/xerrors"
+)
+
+// ecrHost matches the hosts of ECR, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com
+var ecrHost = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// newECRClient returns the client of ECR in the region, with the credentials of the AWS SDK, replaced in tests
+var newECRClient = func(region string) (ecriface.ECRAPI, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(region)},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = sess.Config.Credentials.Get(); err != nil {
+		return nil, errNoCredentials
+	}
+	return ecr.New(sess), nil
+}
+
+// ecrExchanger gets the authorization token of the registry of the account and the region of the host
+type ecrExchanger struct{}
+
+func (ecrExchanger) Match(host string) bool {
+	return ecrHost.MatchString(host)
+}
+
+func (ecrExchanger) Exchange(ctx context.Context, host string) (Credential, error) {
+	m := ecrHost.FindStringSubmatch(host)
+	if m == nil {
+		return Credential{}, xerrors.Errorf("not an ECR registry: %s", host)
+	}
+	accountID, region := m[1], m[2]
+
+	client, err := newECRClient(region)
+	if err == errNoCredentials {
+		return Credential{}, nil
+	} else if err != nil {
+		return Credential{}, xerrors.Errorf("unable to create an AWS session: %w", err)
+	}
+	out, err := client.GetAuthorizationTokenWithContext(ctx, &ecr.GetAuthorizationTokenInput{
+		RegistryIds: []*string{aws.String(accountID)},
+	})
+	if err != nil {
+		return Credential{}, xerrors.Errorf("failed to get the authorization token: %w", err)
+	}
+	for _, data := range out.AuthorizationData {
+		b, err := base64.StdEncoding.DecodeString(aws.StringValue(data.AuthorizationToken))
+		if err != nil {
+			return Credential{}, xerrors.Errorf("invalid authorization token: %w", err)
+		}
+		// e.g. AWS:eyJwYXlsb2...
+		if s := strings.SplitN(string(b), ":", 2); len(s) == 2 {
+			return Credential{Username: s[0], Password: s[1]}, nil
+		}
+	}
+	return Credential{}, xerrors.New("no authorization token")
+}
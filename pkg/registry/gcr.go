@@ -0,0 +1,47 @@
+package registry
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/xerrors"
+)
+
+const (
+	gcrScope = "https://www.googleapis.com/auth/cloud-platform"
+	// gcrUsername is the username of the OAuth2 access tokens of GCR and Artifact Registry
+	gcrUsername = "oauth2accesstoken"
+)
+
+// googleTokenSource returns the token source of the application default credentials, replaced in tests
+var googleTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+	creds, err := google.FindDefaultCredentials(ctx, gcrScope)
+	if err != nil {
+		return nil, errNoCredentials
+	}
+	return creds.TokenSource, nil
+}
+
+// gcrExchanger gets an access token of the application default credentials of Google Cloud,
+// e.g. GOOGLE_APPLICATION_CREDENTIALS or the service account of the instance
+type gcrExchanger struct{}
+
+func (gcrExchanger) Match(host string) bool {
+	return host == "gcr.io" || strings.HasSuffix(host, ".gcr.io") || strings.HasSuffix(host, "-docker.pkg.dev")
+}
+
+func (gcrExchanger) Exchange(ctx context.Context, host string) (Credential, error) {
+	ts, err := googleTokenSource(ctx)
+	if err == errNoCredentials {
+		return Credential{}, nil
+	} else if err != nil {
+		return Credential{}, xerrors.Errorf("unable to find the Google credentials: %w", err)
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return Credential{}, xerrors.Errorf("failed to get an access token: %w", err)
+	}
+	return Credential{Username: gcrUsername, Password: token.AccessToken}, nil
+}
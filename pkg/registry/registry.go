@@ -0,0 +1,197 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/transport"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	// configEnv is the path of the file of the per-registry credentials
+	configEnv = "TRIVY_REGISTRY_CONFIG"
+	// envPrefix prefixes the per-registry credentials of the environment,
+	// e.g. TRIVY_REGISTRY_GHCR_IO_USERNAME and TRIVY_REGISTRY_GHCR_IO_PASSWORD for ghcr.io
+	envPrefix = "TRIVY_REGISTRY_"
+)
+
+// Credential is the basic credential of a registry
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Empty reports whether the credential is anonymous
+func (c Credential) Empty() bool {
+	return c.Username == "" && c.Password == ""
+}
+
+// Config is the file of the per-registry credentials of TRIVY_REGISTRY_CONFIG, in YAML or JSON
+type Config struct {
+	// Registries are the credentials keyed by the host of the registry, e.g. registry.example.com:5000
+	Registries map[string]Credential `json:"registries"`
+}
+
+// exchanger returns a credential of a cloud registry for the credentials of its cloud, e.g. the AWS ones for ECR
+type exchanger interface {
+	// Match reports whether the host is a registry of the cloud
+	Match(host string) bool
+	// Exchange returns the credential of the host, or an empty one without the credentials of the cloud
+	Exchange(ctx context.Context, host string) (Credential, error)
+}
+
+// errNoCredentials is returned when the credentials of the cloud aren't configured, which isn't an error
+// as the image may be public
+var errNoCredentials = xerrors.New("no credentials")
+
+var exchangers = []exchanger{ecrExchanger{}, gcrExchanger{}, acrExchanger{}}
+
+// GetDockerOption returns the option of types.GetDockerOption with the credential of the registry of the image,
+// see Resolve. TRIVY_INSECURE skips the verification of the registry in http.DefaultTransport rather than in the
+// transport of the extractor, which ignores the proxy.
+func GetDockerOption(ctx context.Context, imageName string, timeout time.Duration) (ftypes.DockerOption, error) {
+	opt, err := types.GetDockerOption(timeout)
+	if err != nil {
+		return ftypes.DockerOption{}, err
+	}
+	if ref, err := name.ParseReference(imageName); err == nil && opt.InsecureSkipTLSVerify {
+		if err = transport.AddInsecureHost(ref.Context().RegistryStr()); err != nil {
+			return ftypes.DockerOption{}, err
+		}
+		opt.InsecureSkipTLSVerify = false
+	}
+	cred, err := Resolve(ctx, imageName, Credential{Username: opt.UserName, Password: opt.Password})
+	if err != nil {
+		return ftypes.DockerOption{}, xerrors.Errorf("unable to get the credential of %s: %w", imageName, err)
+	}
+	opt.UserName, opt.Password = cred.Username, cred.Password
+	return opt, nil
+}
+
+// Resolve returns the credential of the registry of the image, the first one of
+//  1. the credential of the registry in the environment, then in the file of TRIVY_REGISTRY_CONFIG
+//  2. the global credential, i.e. TRIVY_USERNAME and TRIVY_PASSWORD
+//  3. the Docker config file, ~/.docker/config.json, including its credential helpers and store
+//  4. the token exchanged for the credentials of the cloud of ECR, GCR, Artifact Registry and ACR
+//
+// An empty credential is returned for anonymous access. The failures of the Docker config file
+// and of the token exchange are only logged, as the image may be public or in Docker Engine.
+func Resolve(ctx context.Context, imageName string, global Credential) (Credential, error) {
+	ref, err := name.ParseReference(imageName)
+	if err != nil {
+		// e.g. the file names of --input, left to the extractor
+		return global, nil
+	}
+	host := ref.Context().RegistryStr()
+
+	cred, err := registryCredential(host)
+	if err != nil {
+		return Credential{}, err
+	} else if !cred.Empty() {
+		log.Logger.Debugf("Using the credential of %s", host)
+		return cred, nil
+	}
+	if !global.Empty() {
+		return global, nil
+	}
+
+	if cred, err = dockerConfigCredential(host); err != nil {
+		log.Logger.Debugf("Docker config unavailable for %s: %s", host, err)
+	} else if !cred.Empty() {
+		log.Logger.Debugf("Using the credential of %s in the Docker config", host)
+		return cred, nil
+	}
+
+	for _, e := range exchangers {
+		if !e.Match(host) {
+			continue
+		}
+		if cred, err = e.Exchange(ctx, host); err != nil {
+			log.Logger.Warnf("Unable to get a token of %s: %s", host, err)
+		} else if !cred.Empty() {
+			log.Logger.Debugf("Using the token of %s", host)
+		}
+		return cred, nil
+	}
+	return Credential{}, nil
+}
+
+// registryCredential returns the credential of the host in the environment, then in the config file
+func registryCredential(host string) (Credential, error) {
+	keys := hostKeys(host)
+	for _, key := range keys {
+		prefix := envPrefix + envName(key)
+		cred := Credential{Username: os.Getenv(prefix + "_USERNAME"), Password: os.Getenv(prefix + "_PASSWORD")}
+		if !cred.Empty() {
+			return cred, nil
+		}
+	}
+
+	fileName := os.Getenv(configEnv)
+	if fileName == "" {
+		return Credential{}, nil
+	}
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return Credential{}, xerrors.Errorf("unable to read %s: %w", configEnv, err)
+	}
+	var config Config
+	if err = yaml.Unmarshal(b, &config); err != nil {
+		return Credential{}, xerrors.Errorf("invalid %s: %w", configEnv, err)
+	}
+	for _, key := range keys {
+		if cred, ok := config.Registries[key]; ok {
+			return cred, nil
+		}
+	}
+	return Credential{}, nil
+}
+
+// dockerConfigCredential returns the credential of the host in the Docker config file. The identity tokens
+// aren't basic credentials, and are left to the keychain of the extractor.
+func dockerConfigCredential(host string) (Credential, error) {
+	cf, err := dockerconfig.Load(os.Getenv("DOCKER_CONFIG"))
+	if err != nil {
+		return Credential{}, err
+	}
+	key := host
+	if host == name.DefaultRegistry {
+		key = authn.DefaultAuthKey
+	}
+	auth, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{Username: auth.Username, Password: auth.Password}, nil
+}
+
+// hostKeys returns the names of the host in the per-registry credentials, docker.io for Docker Hub
+func hostKeys(host string) []string {
+	if host == name.DefaultRegistry {
+		return []string{host, "docker.io"}
+	}
+	return []string{host}
+}
+
+// envName returns the host in the names of the environment variables, e.g. REGISTRY_EXAMPLE_COM_5000
+func envName(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, host)
+}
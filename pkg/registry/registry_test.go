@@ -0,0 +1,250 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// setenv sets the environment variables and returns the function restoring them
+func setenv(envs map[string]string) func() {
+	old := map[string]*string{}
+	for key, value := range envs {
+		if v, ok := os.LookupEnv(key); ok {
+			old[key] = &v
+		} else {
+			old[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, v := range old {
+			if v == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *v)
+			}
+		}
+	}
+}
+
+type mockECR struct {
+	ecriface.ECRAPI
+	registryIDs []string
+}
+
+func (m *mockECR) GetAuthorizationTokenWithContext(_ aws.Context, input *ecr.GetAuthorizationTokenInput,
+	_ ...request.Option) (*ecr.GetAuthorizationTokenOutput, error) {
+	m.registryIDs = aws.StringValueSlice(input.RegistryIds)
+	token := base64.StdEncoding.EncodeToString([]byte("AWS:ecr-token"))
+	return &ecr.GetAuthorizationTokenOutput{
+		AuthorizationData: []*ecr.AuthorizationData{{AuthorizationToken: aws.String(token)}},
+	}, nil
+}
+
+func TestResolve(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	dir, err := ioutil.TempDir("", "registry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "registries.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(`registries:
+  registry.example.com:
+    username: file-user
+    password: file-password
+  docker.io:
+    username: hub-user
+    password: hub-password
+`), 0600))
+
+	dockerConfigDir := filepath.Join(dir, "docker")
+	require.NoError(t, os.MkdirAll(dockerConfigDir, 0700))
+	auth := base64.StdEncoding.EncodeToString([]byte("docker-user:docker-password"))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dockerConfigDir, "config.json"),
+		[]byte(`{"auths": {"ghcr.io": {"auth": "`+auth+`"}}}`), 0600))
+
+	ecrClient := &mockECR{}
+	oldECRClient, oldTokenSource := newECRClient, googleTokenSource
+	defer func() { newECRClient, googleTokenSource = oldECRClient, oldTokenSource }()
+	newECRClient = func(region string) (ecriface.ECRAPI, error) {
+		assert.Equal(t, "eu-west-1", region)
+		return ecrClient, nil
+	}
+	googleTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gcr-token"}), nil
+	}
+
+	tests := []struct {
+		name      string
+		imageName string
+		envs      map[string]string
+		global    Credential
+		want      Credential
+		wantErr   string
+	}{
+		{
+			name:      "registry in the environment",
+			imageName: "registry.example.com/app:1.0",
+			envs: map[string]string{
+				"TRIVY_REGISTRY_REGISTRY_EXAMPLE_COM_USERNAME": "env-user",
+				"TRIVY_REGISTRY_REGISTRY_EXAMPLE_COM_PASSWORD": "env-password",
+				configEnv: configFile,
+			},
+			global: Credential{Username: "global-user", Password: "global-password"},
+			want:   Credential{Username: "env-user", Password: "env-password"},
+		},
+		{
+			name:      "registry in the config file",
+			imageName: "registry.example.com/app:1.0",
+			envs:      map[string]string{configEnv: configFile},
+			global:    Credential{Username: "global-user", Password: "global-password"},
+			want:      Credential{Username: "file-user", Password: "file-password"},
+		},
+		{
+			name:      "Docker Hub in the config file",
+			imageName: "alpine:3.11",
+			envs:      map[string]string{configEnv: configFile},
+			want:      Credential{Username: "hub-user", Password: "hub-password"},
+		},
+		{
+			name:      "global",
+			imageName: "registry.example.com/app:1.0",
+			global:    Credential{Username: "global-user", Password: "global-password"},
+			want:      Credential{Username: "global-user", Password: "global-password"},
+		},
+		{
+			name:      "Docker config",
+			imageName: "ghcr.io/aquasecurity/trivy:0.5.0",
+			envs:      map[string]string{"DOCKER_CONFIG": dockerConfigDir},
+			want:      Credential{Username: "docker-user", Password: "docker-password"},
+		},
+		{
+			name:      "ECR",
+			imageName: "123456789012.dkr.ecr.eu-west-1.amazonaws.com/app:1.0",
+			envs:      map[string]string{"DOCKER_CONFIG": dockerConfigDir},
+			want:      Credential{Username: "AWS", Password: "ecr-token"},
+		},
+		{
+			name:      "Artifact Registry",
+			imageName: "europe-docker.pkg.dev/project/repo/app:1.0",
+			envs:      map[string]string{"DOCKER_CONFIG": dockerConfigDir},
+			want:      Credential{Username: gcrUsername, Password: "gcr-token"},
+		},
+		{
+			name:      "ACR without a service principal",
+			imageName: "example.azurecr.io/app:1.0",
+			envs:      map[string]string{"DOCKER_CONFIG": dockerConfigDir, "AZURE_CLIENT_SECRET": ""},
+			want:      Credential{},
+		},
+		{
+			name:      "anonymous",
+			imageName: "quay.io/app:1.0",
+			envs:      map[string]string{"DOCKER_CONFIG": dockerConfigDir},
+			want:      Credential{},
+		},
+		{
+			name:      "file name of --input",
+			imageName: "/tmp/Alpine.tar",
+			want:      Credential{},
+		},
+		{
+			name:      "missing config file",
+			imageName: "registry.example.com/app:1.0",
+			envs:      map[string]string{configEnv: filepath.Join(dir, "missing.yaml")},
+			wantErr:   "unable to read TRIVY_REGISTRY_CONFIG",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envs := map[string]string{configEnv: "", "DOCKER_CONFIG": filepath.Join(dir, "missing")}
+			for key, value := range tt.envs {
+				envs[key] = value
+			}
+			defer setenv(envs)()
+
+			got, err := Resolve(context.Background(), tt.imageName, tt.global)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, []string{"123456789012"}, ecrClient.registryIDs)
+}
+
+func TestGetDockerOption_Insecure(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+	defaultTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "https://")
+
+	defer setenv(map[string]string{"TRIVY_INSECURE": "true"})()
+	opt, err := GetDockerOption(context.Background(), host+"/app:1.0", 0)
+	require.NoError(t, err)
+	// the registry is skipped by the default transport, which honors the proxy, instead of the extractor
+	assert.False(t, opt.InsecureSkipTLSVerify)
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "REGISTRY_EXAMPLE_COM_5000", envName("registry.example.com:5000"))
+	assert.Equal(t, "GHCR_IO", envName("ghcr.io"))
+}
+
+func TestExchanger_Match(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "123456789012.dkr.ecr.us-east-1.amazonaws.com", want: "ecr"},
+		{host: "123456789012.dkr.ecr-fips.us-gov-west-1.amazonaws.com", want: "ecr"},
+		{host: "123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn", want: "ecr"},
+		{host: "gcr.io", want: "gcr"},
+		{host: "eu.gcr.io", want: "gcr"},
+		{host: "us-central1-docker.pkg.dev", want: "gcr"},
+		{host: "example.azurecr.io", want: "acr"},
+		{host: "amazonaws.com", want: ""},
+		{host: "index.docker.io", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			var got string
+			for name, e := range map[string]exchanger{"ecr": ecrExchanger{}, "gcr": gcrExchanger{}, "acr": acrExchanger{}} {
+				if e.Match(tt.host) {
+					got = name
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
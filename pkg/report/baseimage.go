@@ -0,0 +1,64 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// ClassifyFindings splits the vulnerabilities into the ones introduced in a layer of the base image
+// and the ones introduced by the application. All the vulnerabilities are application ones without base layers.
+// A target without vulnerabilities in the base image is only in the application results.
+func ClassifyFindings(results Results, baseLayers []string) (base Results, app Results) {
+	for _, result := range results {
+		var baseVulns, appVulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.Layer.DiffID != "" && utils.StringInSlice(vuln.Layer.DiffID, baseLayers) {
+				baseVulns = append(baseVulns, vuln)
+			} else {
+				appVulns = append(appVulns, vuln)
+			}
+		}
+
+		if len(baseVulns) > 0 {
+			baseResult := result
+			baseResult.Vulnerabilities = baseVulns
+			base = append(base, baseResult)
+		}
+		if len(appVulns) > 0 || len(baseVulns) == 0 {
+			appResult := result
+			appResult.Vulnerabilities = appVulns
+			app = append(app, appResult)
+		}
+	}
+	return base, app
+}
+
+// BaseImageWriter writes the tables of the base image and the application vulnerabilities in separate sections.
+// BaseLayers are the diff IDs of the layers in the base image.
+type BaseImageWriter struct {
+	TableWriter
+	BaseLayers []string
+}
+
+func (bw BaseImageWriter) Write(results Results) error {
+	base, app := ClassifyFindings(results, bw.BaseLayers)
+	for _, section := range []struct {
+		title   string
+		results Results
+	}{
+		{title: "Base image", results: base},
+		{title: "Application", results: app},
+	} {
+		fmt.Fprintf(bw.Output, "\n%s findings\n", section.title)
+		if len(section.results) == 0 {
+			fmt.Fprintln(bw.Output, "None")
+			continue
+		}
+		if err := bw.TableWriter.Write(section.results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
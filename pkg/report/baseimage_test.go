@@ -0,0 +1,93 @@
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+func vulnIDs(results report.Results) map[string][]string {
+	ids := map[string][]string{}
+	for _, result := range results {
+		ids[result.Target] = []string{}
+		for _, vuln := range result.Vulnerabilities {
+			ids[result.Target] = append(ids[result.Target], vuln.VulnerabilityID)
+		}
+	}
+	return ids
+}
+
+func TestClassifyFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		baseLayers []string
+		wantBase   map[string][]string
+		wantApp    map[string][]string
+	}{
+		{
+			name:       "layers of the base image",
+			baseLayers: []string{"sha256:base"},
+			wantBase: map[string][]string{
+				"alpine:3.11 (alpine 3.11.5)": {"CVE-2020-1967", "CVE-2020-1968"},
+			},
+			wantApp: map[string][]string{
+				"alpine:3.11 (alpine 3.11.5)": {"CVE-2020-2000", "CVE-2020-2001"},
+				"app/package-lock.json":       {"NSWG-ECO-428", "CVE-2019-11358"},
+			},
+		},
+		{
+			name:       "all the layers in the base image",
+			baseLayers: []string{"sha256:base", "sha256:curl"},
+			wantBase: map[string][]string{
+				"alpine:3.11 (alpine 3.11.5)": {"CVE-2020-1967", "CVE-2020-1968", "CVE-2020-2000", "CVE-2020-2001"},
+				"app/package-lock.json":       {"NSWG-ECO-428"},
+			},
+			wantApp: map[string][]string{
+				"app/package-lock.json": {"CVE-2019-11358"},
+			},
+		},
+		{
+			name:     "no base image",
+			wantBase: map[string][]string{},
+			wantApp: map[string][]string{
+				"alpine:3.11 (alpine 3.11.5)": {"CVE-2020-1967", "CVE-2020-1968", "CVE-2020-2000", "CVE-2020-2001"},
+				"app/package-lock.json":       {"NSWG-ECO-428", "CVE-2019-11358"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, app := report.ClassifyFindings(multiLayerResults, tt.baseLayers)
+			assert.Equal(t, tt.wantBase, vulnIDs(base))
+			assert.Equal(t, tt.wantApp, vulnIDs(app))
+		})
+	}
+}
+
+func TestBaseImageWriter_Write(t *testing.T) {
+	output := bytes.Buffer{}
+	writer := report.BaseImageWriter{
+		TableWriter: report.TableWriter{Output: &output, Light: true},
+		BaseLayers:  []string{"sha256:base"},
+	}
+	require.NoError(t, writer.Write(multiLayerResults))
+
+	got := output.String()
+	baseIndex := strings.Index(got, "Base image findings")
+	appIndex := strings.Index(got, "Application findings")
+	require.True(t, baseIndex >= 0 && appIndex > baseIndex, got)
+	assert.Contains(t, got[baseIndex:appIndex], "CVE-2020-1967")
+	assert.NotContains(t, got[baseIndex:appIndex], "CVE-2020-2000")
+	assert.Contains(t, got[appIndex:], "CVE-2020-2000")
+	assert.NotContains(t, got[appIndex:], "CVE-2020-1967")
+
+	output.Reset()
+	writer.BaseLayers = nil
+	require.NoError(t, writer.Write(multiLayerResults))
+	assert.Contains(t, output.String(), "Base image findings\nNone\n")
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// ImageResults are the results of an image scanned in a batch
+type ImageResults struct {
+	Image   string
+	Results Results
+}
+
+// BatchFinding is a vulnerability of a package with the images it is found in
+type BatchFinding struct {
+	Images []string
+	types.DetectedVulnerability
+}
+
+// DedupFindings emits each pair of vulnerability ID and package name once across the batch,
+// in the order they first appear. The details are taken from the first image.
+func DedupFindings(batch []ImageResults) []BatchFinding {
+	type key struct {
+		vulnID  string
+		pkgName string
+	}
+
+	var findings []BatchFinding
+	index := map[key]int{}
+	for _, image := range batch {
+		for _, result := range image.Results {
+			for _, vuln := range result.Vulnerabilities {
+				k := key{vulnID: vuln.VulnerabilityID, pkgName: vuln.PkgName}
+				i, ok := index[k]
+				if !ok {
+					index[k] = len(findings)
+					findings = append(findings, BatchFinding{
+						Images:                []string{image.Image},
+						DetectedVulnerability: vuln,
+					})
+					continue
+				}
+				// the same vulnerability can be found in several results of an image
+				if images := findings[i].Images; images[len(images)-1] != image.Image {
+					findings[i].Images = append(images, image.Image)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// Batch is the results of the images scanned in one run, e.g. the images of the arguments of trivy
+type Batch struct {
+	Images []BatchImage
+	// Summary is the number of vulnerabilities per severity of all the images
+	Summary map[string]int `json:",omitempty"`
+}
+
+// BatchImage is the results of an image of a batch, or the error of its scan
+type BatchImage struct {
+	Image   string
+	Results Results `json:",omitempty"`
+	Error   string  `json:",omitempty"`
+	// Summary is the number of vulnerabilities per severity of the image
+	Summary map[string]int `json:",omitempty"`
+}
+
+// Results returns the results of all the images, in their order
+func (b Batch) Results() Results {
+	var results Results
+	for _, image := range b.Images {
+		results = append(results, image.Results...)
+	}
+	return results
+}
+
+// Failed returns the images whose scan failed
+func (b Batch) Failed() []BatchImage {
+	var failed []BatchImage
+	for _, image := range b.Images {
+		if image.Error != "" {
+			failed = append(failed, image)
+		}
+	}
+	return failed
+}
+
+// BatchWriter writes the results of a batch grouped by image in table or JSON, as the summary or with all the results
+type BatchWriter struct {
+	Output io.Writer
+	Format string
+	Report string
+	Light  bool
+}
+
+func (w BatchWriter) Write(b Batch) error {
+	b.Summary = b.Results().SummaryBySeverity()
+	b.Images = append([]BatchImage(nil), b.Images...)
+	for i, image := range b.Images {
+		b.Images[i].Summary = image.Results.SummaryBySeverity()
+	}
+
+	switch w.Format {
+	case "json":
+		if w.Report == ReportSummary {
+			// the summary has the errors of the images, without their results
+			for i := range b.Images {
+				b.Images[i].Results = nil
+			}
+		}
+		output, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return xerrors.Errorf("failed to marshal json: %w", err)
+		}
+		if _, err = fmt.Fprint(w.Output, string(output)); err != nil {
+			return xerrors.Errorf("failed to write json: %w", err)
+		}
+		return nil
+	case "table":
+		w.writeSummary(b)
+		if w.Report == ReportSummary {
+			return nil
+		}
+		tw := TableWriter{Output: w.Output, Light: w.Light, Color: IsColorEnabled(w.Output)}
+		for _, image := range b.Images {
+			if err := tw.Write(image.Results); err != nil {
+				return xerrors.Errorf("failed to write the results of %s: %w", image.Image, err)
+			}
+		}
+		return nil
+	}
+	return xerrors.Errorf("the scan of several images doesn't support --format %s, use table or json", w.Format)
+}
+
+// writeSummary writes a row of each image with its number of vulnerabilities per severity, and the total
+// of the batch, then the images whose scan failed
+func (w BatchWriter) writeSummary(b Batch) {
+	severities := summarySeverities(b.Summary)
+	table := tablewriter.NewWriter(w.Output)
+	table.SetHeader(append([]string{"Image"}, severities...))
+	for _, image := range b.Images {
+		table.Append(append([]string{image.Image}, summaryCounts(image.Summary, severities)...))
+	}
+	table.SetFooter(append([]string{fmt.Sprintf("Total: %d images", len(b.Images))},
+		summaryCounts(b.Summary, severities)...))
+	table.Render()
+
+	failed := b.Failed()
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w.Output, "\nFailed to scan %d images:\n", len(failed))
+	for _, image := range failed {
+		fmt.Fprintf(w.Output, "%s: %s\n", image.Image, image.Error)
+	}
+}
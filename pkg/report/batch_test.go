@@ -0,0 +1,146 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestDedupFindings(t *testing.T) {
+	openssl := types.DetectedVulnerability{
+		VulnerabilityID:  "CVE-2020-1967",
+		PkgName:          "openssl",
+		InstalledVersion: "1.1.1d-r3",
+		FixedVersion:     "1.1.1g-r0",
+		Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+	}
+	musl := types.DetectedVulnerability{
+		VulnerabilityID:  "CVE-2019-14697",
+		PkgName:          "musl",
+		InstalledVersion: "1.1.20-r4",
+		FixedVersion:     "1.1.20-r5",
+		Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+	}
+	libssl := openssl
+	libssl.PkgName = "libssl1.1"
+
+	batch := []report.ImageResults{
+		{
+			Image: "alpine:3.10",
+			Results: report.Results{
+				{Target: "alpine:3.10 (alpine 3.10.4)", Vulnerabilities: []types.DetectedVulnerability{openssl, musl}},
+			},
+		},
+		{
+			Image: "nginx:1.17-alpine",
+			Results: report.Results{
+				{Target: "nginx:1.17-alpine (alpine 3.10.4)", Vulnerabilities: []types.DetectedVulnerability{openssl, libssl}},
+			},
+		},
+		{
+			Image: "redis:5-alpine",
+			Results: report.Results{
+				{Target: "redis:5-alpine (alpine 3.10.4)", Vulnerabilities: []types.DetectedVulnerability{openssl}},
+				{Target: "app/package-lock.json"},
+			},
+		},
+	}
+
+	want := []report.BatchFinding{
+		{
+			Images:                []string{"alpine:3.10", "nginx:1.17-alpine", "redis:5-alpine"},
+			DetectedVulnerability: openssl,
+		},
+		{
+			Images:                []string{"alpine:3.10"},
+			DetectedVulnerability: musl,
+		},
+		{
+			Images:                []string{"nginx:1.17-alpine"},
+			DetectedVulnerability: libssl,
+		},
+	}
+	assert.Equal(t, want, report.DedupFindings(batch))
+	assert.Empty(t, report.DedupFindings(nil))
+}
+
+func testBatch() report.Batch {
+	return report.Batch{Images: []report.BatchImage{
+		{
+			Image: "alpine:3.10",
+			Results: report.Results{{Target: "alpine:3.10 (alpine 3.10.2)", Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-1549", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+			}}},
+		},
+		{
+			Image:   "nginx:1.19",
+			Results: report.Results{{Target: "nginx:1.19 (debian 10.4)"}},
+		},
+		{Image: "registry.example.com/app:1.0", Error: "unauthorized"},
+	}}
+}
+
+func TestBatchWriter_Write(t *testing.T) {
+	t.Run("summary table", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, report.BatchWriter{Output: &output, Format: "table", Report: report.ReportSummary}.Write(testBatch()))
+		assert.Equal(t, `+------------------------------+----------+------+--------+-----+---------+
+|            IMAGE             | CRITICAL | HIGH | MEDIUM | LOW | UNKNOWN |
++------------------------------+----------+------+--------+-----+---------+
+| alpine:3.10                  |        0 |    1 |      1 |   0 |       0 |
+| nginx:1.19                   |        0 |    0 |      0 |   0 |       0 |
+| registry.example.com/app:1.0 |        0 |    0 |      0 |   0 |       0 |
++------------------------------+----------+------+--------+-----+---------+
+|       TOTAL: 3 IMAGES        |    0     |  1   |   1    |  0  |    0    |
++------------------------------+----------+------+--------+-----+---------+
+
+Failed to scan 1 images:
+registry.example.com/app:1.0: unauthorized
+`, output.String())
+	})
+
+	t.Run("JSON summary", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, report.BatchWriter{Output: &output, Format: "json", Report: report.ReportSummary}.Write(testBatch()))
+		var got report.Batch
+		require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+		assert.Equal(t, report.Batch{
+			Images: []report.BatchImage{
+				{Image: "alpine:3.10", Summary: map[string]int{"HIGH": 1, "MEDIUM": 1}},
+				{Image: "nginx:1.19"},
+				{Image: "registry.example.com/app:1.0", Error: "unauthorized"},
+			},
+			Summary: map[string]int{"HIGH": 1, "MEDIUM": 1},
+		}, got)
+	})
+
+	t.Run("JSON of all", func(t *testing.T) {
+		var output bytes.Buffer
+		require.NoError(t, report.BatchWriter{Output: &output, Format: "json", Report: report.ReportAll}.Write(testBatch()))
+		var got report.Batch
+		require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+		require.Len(t, got.Images, 3)
+		assert.Equal(t, "alpine:3.10 (alpine 3.10.2)", got.Images[0].Results[0].Target)
+		assert.Len(t, got.Images[0].Results[0].Vulnerabilities, 2)
+		assert.Empty(t, got.Images[2].Results)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := report.BatchWriter{Output: &bytes.Buffer{}, Format: "sarif"}.Write(testBatch())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the scan of several images doesn't support --format sarif")
+	})
+}
+
+func TestBatch_Failed(t *testing.T) {
+	assert.Equal(t, []report.BatchImage{{Image: "registry.example.com/app:1.0", Error: "unauthorized"}}, testBatch().Failed())
+	assert.Len(t, testBatch().Results(), 2)
+}
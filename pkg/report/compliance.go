@@ -0,0 +1,127 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// Control is a control of a compliance standard, e.g. PCI DSS 6.2, with the conditions the results must meet
+type Control struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// MaxSeverity is the highest severity allowed; any severity is allowed when empty
+	MaxSeverity string `json:"max_severity,omitempty"`
+	// NoEOLOS requires an OS still supported by the distribution
+	NoEOLOS bool `json:"no_eol_os,omitempty"`
+}
+
+// ControlResult is the evaluation of a control. Violations explain why the control failed.
+type ControlResult struct {
+	Control
+	Passed     bool
+	Violations []string
+}
+
+// LoadControls reads the controls from a JSON file, e.g. [{"id": "PCI-6.2", "max_severity": "HIGH"}]
+func LoadControls(filePath string) ([]Control, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to open the compliance mapping: %w", err)
+	}
+	defer f.Close()
+
+	var controls []Control
+	if err = json.NewDecoder(f).Decode(&controls); err != nil {
+		return nil, xerrors.Errorf("failed to decode the compliance mapping: %w", err)
+	}
+	return controls, nil
+}
+
+// EvaluateCompliance evaluates the results against each control
+func EvaluateCompliance(controls []Control, results Results) ([]ControlResult, error) {
+	var evaluated []ControlResult
+	for _, control := range controls {
+		var violations []string
+		if control.MaxSeverity != "" {
+			maxSeverity, err := dbTypes.NewSeverity(control.MaxSeverity)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid max severity of the control %s: %w", control.ID, err)
+			}
+			if v := severityViolation(maxSeverity, results); v != "" {
+				violations = append(violations, v)
+			}
+		}
+		if control.NoEOLOS {
+			for _, result := range results {
+				if result.EOSL {
+					violations = append(violations, fmt.Sprintf("%s is no longer supported", result.Target))
+				}
+			}
+		}
+		evaluated = append(evaluated, ControlResult{
+			Control:    control,
+			Passed:     len(violations) == 0,
+			Violations: violations,
+		})
+	}
+	return evaluated, nil
+}
+
+// severityViolation returns e.g. "1 findings above HIGH (1 CRITICAL)", or an empty string without such findings.
+// Findings without a severity are UNKNOWN, the lowest severity.
+func severityViolation(maxSeverity dbTypes.Severity, results Results) string {
+	var above []TopFinding
+	for _, f := range flattenFindings(results) {
+		severity, _ := dbTypes.NewSeverity(f.Severity)
+		if severity > maxSeverity {
+			above = append(above, f)
+		}
+	}
+	if len(above) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d findings above %s%s", len(above), maxSeverity, severityBreakdown(above))
+}
+
+// ComplianceWriter writes a compliance summary section with the pass/fail of each control
+type ComplianceWriter struct {
+	Output   io.Writer
+	Controls []Control
+}
+
+func (cw ComplianceWriter) Write(results Results) error {
+	evaluated, err := EvaluateCompliance(cw.Controls, results)
+	if err != nil {
+		return xerrors.Errorf("failed to evaluate compliance: %w", err)
+	}
+
+	var passed int
+	for _, r := range evaluated {
+		if r.Passed {
+			passed++
+		}
+	}
+	fmt.Fprintf(cw.Output, "\nCompliance summary: %d of %d controls passed\n", passed, len(evaluated))
+	for _, r := range evaluated {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		line := fmt.Sprintf("%s %s", status, r.ID)
+		if r.Description != "" {
+			line += ": " + r.Description
+		}
+		if len(r.Violations) > 0 {
+			line += " (" + strings.Join(r.Violations, "; ") + ")"
+		}
+		fmt.Fprintln(cw.Output, line)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestEvaluateCompliance(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "debian:8 (debian 8.11)",
+			Type:   "debian",
+			EOSL:   true,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+				{VulnerabilityID: "CVE-2020-0002", PkgName: "bash", Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+				{VulnerabilityID: "CVE-2020-0003", PkgName: "tar"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		controls []report.Control
+		want     []report.ControlResult
+		wantErr  string
+	}{
+		{
+			name:     "control failing due to a CRITICAL finding",
+			controls: []report.Control{{ID: "PCI-6.2", MaxSeverity: "HIGH"}},
+			want: []report.ControlResult{
+				{
+					Control:    report.Control{ID: "PCI-6.2", MaxSeverity: "HIGH"},
+					Violations: []string{"1 findings above HIGH (1 CRITICAL)"},
+				},
+			},
+		},
+		{
+			name:     "control passing",
+			controls: []report.Control{{ID: "NIST-RA-5", MaxSeverity: "CRITICAL"}},
+			want: []report.ControlResult{
+				{Control: report.Control{ID: "NIST-RA-5", MaxSeverity: "CRITICAL"}, Passed: true},
+			},
+		},
+		{
+			name:     "EOL OS",
+			controls: []report.Control{{ID: "NIST-SI-2", NoEOLOS: true}},
+			want: []report.ControlResult{
+				{
+					Control:    report.Control{ID: "NIST-SI-2", NoEOLOS: true},
+					Violations: []string{"debian:8 (debian 8.11) is no longer supported"},
+				},
+			},
+		},
+		{
+			name:     "invalid severity",
+			controls: []report.Control{{ID: "PCI-6.2", MaxSeverity: "SEVERE"}},
+			wantErr:  "invalid max severity of the control PCI-6.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := report.EvaluateCompliance(tt.controls, results)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestComplianceWriter_Write(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.3)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+			},
+		},
+	}
+	controls := []report.Control{
+		{ID: "PCI-6.2", Description: "Critical patches installed", MaxSeverity: "HIGH"},
+		{ID: "NIST-SI-2", NoEOLOS: true},
+	}
+
+	output := bytes.Buffer{}
+	require.NoError(t, report.ComplianceWriter{Output: &output, Controls: controls}.Write(results))
+	assert.Equal(t, `
+Compliance summary: 1 of 2 controls passed
+FAIL PCI-6.2: Critical patches installed (1 findings above HIGH (1 CRITICAL))
+PASS NIST-SI-2
+`, output.String())
+}
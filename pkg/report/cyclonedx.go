@@ -0,0 +1,252 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	// CycloneDXSpecVersion is the version of the CycloneDX specification written by CycloneDXWriter
+	CycloneDXSpecVersion = "1.4"
+	// CycloneDXXMLNamespace is the namespace of the XML documents
+	CycloneDXXMLNamespace = "http://cyclonedx.org/schema/bom/" + CycloneDXSpecVersion
+
+	CycloneDXFormatJSON = "json"
+	CycloneDXFormatXML  = "xml"
+)
+
+// purlTypes maps a result type to the package URL type and namespace of its packages
+var purlTypes = map[string][2]string{
+	"npm":      {"npm", ""},
+	"yarn":     {"npm", ""},
+	"bundler":  {"gem", ""},
+	"pipenv":   {"pypi", ""},
+	"poetry":   {"pypi", ""},
+	"cargo":    {"cargo", ""},
+	"composer": {"composer", ""},
+	"jar":      {"maven", ""},
+	"alpine":   {"apk", "alpine"},
+	"debian":   {"deb", "debian"},
+	"ubuntu":   {"deb", "ubuntu"},
+	"redhat":   {"rpm", "redhat"},
+	"centos":   {"rpm", "centos"},
+	"amazon":   {"rpm", "amazon"},
+	"oracle":   {"rpm", "oracle"},
+	"photon":   {"rpm", "photon"},
+}
+
+// CycloneDXBOM is a CycloneDX document (https://cyclonedx.org/docs/1.4/json/), in JSON or XML
+type CycloneDXBOM struct {
+	XMLName         xml.Name                 `json:"-" xml:"bom"`
+	XMLNS           string                   `json:"-" xml:"xmlns,attr"`
+	BOMFormat       string                   `json:"bomFormat" xml:"-"`
+	SpecVersion     string                   `json:"specVersion" xml:"-"`
+	Version         int                      `json:"version" xml:"version,attr"`
+	Metadata        CycloneDXMetadata        `json:"metadata" xml:"metadata"`
+	Components      []CycloneDXComponent     `json:"components" xml:"components>component"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities" xml:"vulnerabilities>vulnerability"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string          `json:"timestamp" xml:"timestamp"`
+	Tools     []CycloneDXTool `json:"tools" xml:"tools>tool"`
+}
+
+type CycloneDXTool struct {
+	Vendor string `json:"vendor" xml:"vendor"`
+	Name   string `json:"name" xml:"name"`
+}
+
+type CycloneDXComponent struct {
+	BOMRef  string `json:"bom-ref" xml:"bom-ref,attr"`
+	Type    string `json:"type" xml:"type,attr"`
+	Name    string `json:"name" xml:"name"`
+	Version string `json:"version,omitempty" xml:"version,omitempty"`
+	PURL    string `json:"purl,omitempty" xml:"purl,omitempty"`
+}
+
+type CycloneDXVulnerability struct {
+	ID          string            `json:"id" xml:"id"`
+	Ratings     []CycloneDXRating `json:"ratings,omitempty" xml:"ratings>rating,omitempty"`
+	Description string            `json:"description,omitempty" xml:"description,omitempty"`
+	Affects     []CycloneDXAffect `json:"affects" xml:"affects>target"`
+}
+
+type CycloneDXRating struct {
+	Severity string `json:"severity" xml:"severity"`
+}
+
+type CycloneDXAffect struct {
+	Ref string `json:"ref" xml:"ref"`
+}
+
+// CycloneDXWriter writes the packages of the findings as CycloneDX components, with their vulnerabilities
+// affecting them by bom-ref. The packages without vulnerabilities are only listed when the results have
+// all the packages, with ScanOptions.ListAllPackages.
+type CycloneDXWriter struct {
+	Output io.Writer
+	// Format is CycloneDXFormatJSON or CycloneDXFormatXML; empty writes JSON
+	Format string
+	// Timestamp is the time of the document; the current time is used when it is zero
+	Timestamp time.Time
+}
+
+func (cw CycloneDXWriter) Write(results Results) error {
+	timestamp := cw.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	bom := NewCycloneDXBOM(results, timestamp)
+
+	var output []byte
+	var err error
+	switch cw.Format {
+	case "", CycloneDXFormatJSON:
+		output, err = json.MarshalIndent(bom, "", "  ")
+	case CycloneDXFormatXML:
+		bom.XMLNS = CycloneDXXMLNamespace
+		output, err = xml.MarshalIndent(bom, "", "  ")
+		output = append([]byte(xml.Header), output...)
+	default:
+		return xerrors.Errorf("unknown CycloneDX format: %s", cw.Format)
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to marshal the CycloneDX BOM: %w", err)
+	}
+	if _, err = cw.Output.Write(output); err != nil {
+		return xerrors.Errorf("failed to write the CycloneDX BOM: %w", err)
+	}
+	return nil
+}
+
+// NewCycloneDXBOM converts the results into a BOM with the components and the vulnerabilities in the order they first appear
+func NewCycloneDXBOM(results Results, timestamp time.Time) CycloneDXBOM {
+	bom := CycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: CycloneDXSpecVersion,
+		Version:     1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: timestamp.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Vendor: "aquasecurity", Name: "trivy"}},
+		},
+		Components:      []CycloneDXComponent{},
+		Vulnerabilities: []CycloneDXVulnerability{},
+	}
+
+	components := map[string]struct{}{}
+	addComponent := func(component CycloneDXComponent) {
+		if _, ok := components[component.BOMRef]; !ok {
+			components[component.BOMRef] = struct{}{}
+			bom.Components = append(bom.Components, component)
+		}
+	}
+	vulns := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			component := newCycloneDXComponent(result.Type, vuln.PkgName, vuln.InstalledVersion)
+			addComponent(component)
+
+			i, ok := vulns[vuln.VulnerabilityID]
+			if !ok {
+				i = len(bom.Vulnerabilities)
+				vulns[vuln.VulnerabilityID] = i
+				bom.Vulnerabilities = append(bom.Vulnerabilities, newCycloneDXVulnerability(vuln))
+			}
+			affect := CycloneDXAffect{Ref: component.BOMRef}
+			if !containsAffect(bom.Vulnerabilities[i].Affects, affect) {
+				bom.Vulnerabilities[i].Affects = append(bom.Vulnerabilities[i].Affects, affect)
+			}
+		}
+		for _, pkg := range result.Packages {
+			addComponent(newCycloneDXComponent(result.Type, pkg.Name, pkg.Version))
+		}
+	}
+	return bom
+}
+
+func newCycloneDXComponent(resultType, name, version string) CycloneDXComponent {
+	component := CycloneDXComponent{
+		Type:    "library",
+		Name:    name,
+		Version: version,
+		PURL:    PackageURL(resultType, name, version),
+	}
+	component.BOMRef = component.PURL
+	if component.BOMRef == "" {
+		component.BOMRef = fmt.Sprintf("%s/%s@%s", resultType, name, version)
+	}
+	return component
+}
+
+func newCycloneDXVulnerability(vuln types.DetectedVulnerability) CycloneDXVulnerability {
+	v := CycloneDXVulnerability{ID: vuln.VulnerabilityID, Description: vuln.Description}
+	if vuln.Severity != "" {
+		v.Ratings = []CycloneDXRating{{Severity: cycloneDXSeverity(vuln.Severity)}}
+	}
+	return v
+}
+
+// cycloneDXSeverity maps a severity to the CycloneDX one, e.g. HIGH to high
+func cycloneDXSeverity(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW":
+		return strings.ToLower(severity)
+	default:
+		return "unknown"
+	}
+}
+
+func containsAffect(affects []CycloneDXAffect, affect CycloneDXAffect) bool {
+	for _, a := range affects {
+		if a == affect {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageURL returns the package URL (https://github.com/package-url/purl-spec) of the package of the result type,
+// e.g. pkg:npm/lodash@4.17.4, or an empty string for unknown types
+func PackageURL(resultType, name, version string) string {
+	t, ok := purlTypes[resultType]
+	if !ok {
+		return ""
+	}
+	purlType, namespace := t[0], t[1]
+
+	// e.g. @babel/core for npm and symfony/http-foundation for composer
+	if i := strings.LastIndex(name, "/"); i >= 0 && (purlType == "npm" || purlType == "composer") {
+		namespace, name = name[:i], name[i+1:]
+	}
+	// e.g. org.apache.logging.log4j:log4j-core for maven
+	if i := strings.LastIndex(name, ":"); i >= 0 && purlType == "maven" {
+		namespace, name = name[:i], name[i+1:]
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:" + purlType + "/")
+	if namespace != "" {
+		b.WriteString(purlEscape(namespace) + "/")
+	}
+	b.WriteString(purlEscape(name))
+	if version != "" {
+		b.WriteString("@" + purlEscape(version))
+	}
+	return b.String()
+}
+
+// purlEscaper escapes the characters the package URLs require escaped but url.PathEscape doesn't
+var purlEscaper = strings.NewReplacer("@", "%40", ":", "%3A", "+", "%2B")
+
+func purlEscape(s string) string {
+	return purlEscaper.Replace(url.PathEscape(s))
+}
@@ -0,0 +1,166 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func cycloneDXResults() report.Results {
+	openssl := func(id string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{
+			VulnerabilityID:  id,
+			PkgName:          "openssl",
+			InstalledVersion: "1.1.1d-r3",
+			Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+		}
+	}
+	return report.Results{
+		{
+			Target:          "alpine:3.11 (alpine 3.11.3)",
+			Type:            "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{openssl("CVE-2020-1967"), openssl("CVE-2019-1551")},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2019-10744",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.4",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "CRITICAL", Description: "Prototype pollution"},
+				},
+				{
+					VulnerabilityID:  "CVE-2019-10744",
+					PkgName:          "@types/lodash",
+					InstalledVersion: "4.14.1",
+				},
+			},
+		},
+	}
+}
+
+func TestCycloneDXWriter_Write(t *testing.T) {
+	timestamp := time.Date(2020, 4, 13, 18, 21, 39, 0, time.UTC)
+	wantComponents := []report.CycloneDXComponent{
+		{BOMRef: "pkg:apk/alpine/openssl@1.1.1d-r3", Type: "library", Name: "openssl", Version: "1.1.1d-r3", PURL: "pkg:apk/alpine/openssl@1.1.1d-r3"},
+		{BOMRef: "pkg:npm/lodash@4.17.4", Type: "library", Name: "lodash", Version: "4.17.4", PURL: "pkg:npm/lodash@4.17.4"},
+		{BOMRef: "pkg:npm/%40types/lodash@4.14.1", Type: "library", Name: "@types/lodash", Version: "4.14.1", PURL: "pkg:npm/%40types/lodash@4.14.1"},
+	}
+	wantVulns := []report.CycloneDXVulnerability{
+		{
+			ID:      "CVE-2020-1967",
+			Ratings: []report.CycloneDXRating{{Severity: "high"}},
+			Affects: []report.CycloneDXAffect{{Ref: "pkg:apk/alpine/openssl@1.1.1d-r3"}},
+		},
+		{
+			ID:      "CVE-2019-1551",
+			Ratings: []report.CycloneDXRating{{Severity: "high"}},
+			Affects: []report.CycloneDXAffect{{Ref: "pkg:apk/alpine/openssl@1.1.1d-r3"}},
+		},
+		{
+			ID:          "CVE-2019-10744",
+			Ratings:     []report.CycloneDXRating{{Severity: "critical"}},
+			Description: "Prototype pollution",
+			Affects:     []report.CycloneDXAffect{{Ref: "pkg:npm/lodash@4.17.4"}, {Ref: "pkg:npm/%40types/lodash@4.14.1"}},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		output := bytes.Buffer{}
+		cw := report.CycloneDXWriter{Output: &output, Format: report.CycloneDXFormatJSON, Timestamp: timestamp}
+		require.NoError(t, cw.Write(cycloneDXResults()))
+
+		var bom report.CycloneDXBOM
+		require.NoError(t, json.Unmarshal(output.Bytes(), &bom))
+		assert.Equal(t, "CycloneDX", bom.BOMFormat)
+		assert.Equal(t, "1.4", bom.SpecVersion)
+		assert.Equal(t, 1, bom.Version)
+		assert.Equal(t, "2020-04-13T18:21:39Z", bom.Metadata.Timestamp)
+		assert.Equal(t, wantComponents, bom.Components)
+		assert.Equal(t, wantVulns, bom.Vulnerabilities)
+		// the golden files are written with -update and reviewed against the CycloneDX 1.4 specification
+		assertGolden(t, "testdata/cyclonedx.json.golden", output.Bytes())
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		output := bytes.Buffer{}
+		cw := report.CycloneDXWriter{Output: &output, Format: report.CycloneDXFormatXML, Timestamp: timestamp}
+		require.NoError(t, cw.Write(cycloneDXResults()))
+
+		var bom report.CycloneDXBOM
+		require.NoError(t, xml.Unmarshal(output.Bytes(), &bom))
+		assert.Equal(t, xml.Name{Space: "http://cyclonedx.org/schema/bom/1.4", Local: "bom"}, bom.XMLName)
+		assert.Equal(t, 1, bom.Version)
+		assert.Equal(t, "2020-04-13T18:21:39Z", bom.Metadata.Timestamp)
+		assert.Equal(t, wantComponents, bom.Components)
+		assert.Equal(t, wantVulns, bom.Vulnerabilities)
+		assertGolden(t, "testdata/cyclonedx.xml.golden", output.Bytes())
+	})
+
+	t.Run("all packages", func(t *testing.T) {
+		results := cycloneDXResults()
+		results[0].Packages = []types.InstalledPackage{
+			{Name: "musl", Version: "1.1.24-r2"},
+			{Name: "openssl", Version: "1.1.1d-r3"},
+		}
+		results[1].Packages = []types.InstalledPackage{
+			{Name: "lodash", Version: "4.17.4"},
+			{Name: "react", Version: "16.13.1"},
+		}
+		output := bytes.Buffer{}
+		cw := report.CycloneDXWriter{Output: &output, Timestamp: timestamp}
+		require.NoError(t, cw.Write(results))
+
+		var bom report.CycloneDXBOM
+		require.NoError(t, json.Unmarshal(output.Bytes(), &bom))
+		var refs []string
+		for _, c := range bom.Components {
+			refs = append(refs, c.BOMRef)
+		}
+		assert.Equal(t, []string{
+			"pkg:apk/alpine/openssl@1.1.1d-r3",
+			"pkg:apk/alpine/musl@1.1.24-r2",
+			"pkg:npm/lodash@4.17.4",
+			"pkg:npm/%40types/lodash@4.14.1",
+			"pkg:npm/react@16.13.1",
+		}, refs)
+		assert.Equal(t, wantVulns, bom.Vulnerabilities)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		cw := report.CycloneDXWriter{Output: &bytes.Buffer{}, Format: "spdx"}
+		assert.Error(t, cw.Write(cycloneDXResults()))
+	})
+}
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		resultType, name, version string
+		want                      string
+	}{
+		{"npm", "lodash", "4.17.4", "pkg:npm/lodash@4.17.4"},
+		{"yarn", "@babel/core", "7.9.0", "pkg:npm/%40babel/core@7.9.0"},
+		{"composer", "symfony/http-foundation", "v4.2.1", "pkg:composer/symfony/http-foundation@v4.2.1"},
+		{"debian", "libc6", "2.28-10+deb10u1", "pkg:deb/debian/libc6@2.28-10%2Bdeb10u1"},
+		{"redhat", "openssl", "1:1.0.2k-19.el7", "pkg:rpm/redhat/openssl@1%3A1.0.2k-19.el7"},
+		{"pipenv", "django", "", "pkg:pypi/django"},
+		{"jar", "org.apache.logging.log4j:log4j-core", "2.14.1", "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+		{"unknown", "foo", "1.0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, report.PackageURL(tt.resultType, tt.name, tt.version))
+		})
+	}
+}
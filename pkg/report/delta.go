@@ -0,0 +1,117 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// Delta is the findings added and removed since a baseline scan, and those present in both
+type Delta struct {
+	Added     []TopFinding
+	Removed   []TopFinding
+	Unchanged []TopFinding
+}
+
+// NewDelta compares the findings by target, package and vulnerability ID.
+// The OS packages are compared by OS family, whatever the image and the OS version in their targets,
+// so that two images, e.g. of a base image update, can be compared, and the libraries by lock file path.
+// A finding whose installed version or severity changed is neither added nor removed.
+func NewDelta(baseline, current Results) Delta {
+	baseFindings := findingsByKey(baseline)
+	currentFindings := findingsByKey(current)
+
+	var delta Delta
+	for _, f := range keyedFindings(current) {
+		if _, ok := baseFindings[f.key]; !ok {
+			delta.Added = append(delta.Added, f.TopFinding)
+		} else {
+			delta.Unchanged = append(delta.Unchanged, f.TopFinding)
+		}
+	}
+	for _, f := range keyedFindings(baseline) {
+		if _, ok := currentFindings[f.key]; !ok {
+			delta.Removed = append(delta.Removed, f.TopFinding)
+		}
+	}
+	return delta
+}
+
+func flattenFindings(results Results) []TopFinding {
+	var findings []TopFinding
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			findings = append(findings, TopFinding{Target: result.Target, DetectedVulnerability: vuln})
+		}
+	}
+	return findings
+}
+
+// keyedFinding is a finding with the key identifying it across scans, see NewDelta
+type keyedFinding struct {
+	TopFinding
+	key string
+}
+
+func keyedFindings(results Results) []keyedFinding {
+	var findings []keyedFinding
+	for _, result := range results {
+		target := deltaTarget(result)
+		for _, vuln := range result.Vulnerabilities {
+			findings = append(findings, keyedFinding{
+				TopFinding: TopFinding{Target: result.Target, DetectedVulnerability: vuln},
+				key:        strings.Join([]string{target, vuln.PkgName, vuln.VulnerabilityID}, "\x00"),
+			})
+		}
+	}
+	return findings
+}
+
+func findingsByKey(results Results) map[string]struct{} {
+	keys := map[string]struct{}{}
+	for _, f := range keyedFindings(results) {
+		keys[f.key] = struct{}{}
+	}
+	return keys
+}
+
+// deltaTarget returns the class and the type of the OS packages, e.g. "os-pkgs alpine", and the target otherwise.
+// The results of the reports without class have OS targets ending with the type, e.g. "alpine:3.10 (alpine 3.10.4)".
+func deltaTarget(result Result) string {
+	if result.Class == ClassOSPkgs ||
+		result.Class == "" && result.Type != "" && strings.HasSuffix(result.Target, ")") && strings.Contains(result.Target, " ("+result.Type+" ") {
+		return ClassOSPkgs + " " + result.Type
+	}
+	return result.Target
+}
+
+// DeltaComment renders the delta as a one-line Markdown summary for pull request comments,
+// e.g. "**Vulnerabilities**: +3 new (1 CRITICAL, 2 HIGH), -2 resolved (2 LOW)"
+func DeltaComment(delta Delta) string {
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 {
+		return "**Vulnerabilities**: no new or resolved findings"
+	}
+	return fmt.Sprintf("**Vulnerabilities**: +%d new%s, -%d resolved%s",
+		len(delta.Added), severityBreakdown(delta.Added), len(delta.Removed), severityBreakdown(delta.Removed))
+}
+
+// severityBreakdown returns the counts from the highest severity, e.g. " (1 CRITICAL, 2 HIGH)"
+func severityBreakdown(findings []TopFinding) string {
+	counts := map[string]int{}
+	for _, f := range findings {
+		countSeverity(counts, f.Severity)
+	}
+
+	var parts []string
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		severity := dbTypes.SeverityNames[i]
+		if n := counts[severity]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, severity))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,144 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func deltaVuln(id, pkgName, severity string) types.DetectedVulnerability {
+	return types.DetectedVulnerability{
+		VulnerabilityID: id,
+		PkgName:         pkgName,
+		Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+	}
+}
+
+func TestDeltaComment(t *testing.T) {
+	baseline := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-1547", "openssl", "LOW"),
+				deltaVuln("CVE-2019-1549", "openssl", "LOW"),
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+			},
+		},
+	}
+	current := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+				deltaVuln("CVE-2020-1967", "openssl", "CRITICAL"),
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-11358", "jquery", "MEDIUM"),
+				deltaVuln("CVE-2020-7598", "minimist", "MEDIUM"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		baseline report.Results
+		current  report.Results
+		want     string
+	}{
+		{
+			name:     "added and resolved",
+			baseline: baseline,
+			current:  current,
+			want:     "**Vulnerabilities**: +3 new (1 CRITICAL, 2 MEDIUM), -2 resolved (2 LOW)",
+		},
+		{
+			name:     "only resolved",
+			baseline: baseline,
+			want:     "**Vulnerabilities**: +0 new, -3 resolved (1 HIGH, 2 LOW)",
+		},
+		{
+			name:     "no changes",
+			baseline: baseline,
+			current:  baseline,
+			want:     "**Vulnerabilities**: no new or resolved findings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := report.NewDelta(tt.baseline, tt.current)
+			assert.Equal(t, tt.want, report.DeltaComment(delta))
+		})
+	}
+}
+
+func TestNewDelta_Images(t *testing.T) {
+	baseline := report.Results{
+		{
+			Target: "myapp:1.0 (alpine 3.10.4)",
+			Class:  report.ClassOSPkgs,
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-1547", "openssl", "LOW"),
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Class:  report.ClassLangPkgs,
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-11358", "jquery", "MEDIUM"),
+			},
+		},
+	}
+	current := report.Results{
+		{
+			Target: "myapp:1.1 (alpine 3.11.5)",
+			Class:  report.ClassOSPkgs,
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+				deltaVuln("CVE-2020-1967", "openssl", "CRITICAL"),
+			},
+		},
+		{
+			Target: "web/package-lock.json",
+			Class:  report.ClassLangPkgs,
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-11358", "jquery", "MEDIUM"),
+			},
+		},
+	}
+
+	ids := func(findings []report.TopFinding) []string {
+		var ids []string
+		for _, f := range findings {
+			ids = append(ids, f.Target+" "+f.VulnerabilityID)
+		}
+		return ids
+	}
+
+	// the OS packages of the two images are compared whatever their names and OS versions,
+	// the libraries by lock file
+	delta := report.NewDelta(baseline, current)
+	assert.Equal(t, []string{"myapp:1.1 (alpine 3.11.5) CVE-2020-1967", "web/package-lock.json CVE-2019-11358"}, ids(delta.Added))
+	assert.Equal(t, []string{"myapp:1.0 (alpine 3.10.4) CVE-2019-1547", "app/package-lock.json CVE-2019-11358"}, ids(delta.Removed))
+	assert.Equal(t, []string{"myapp:1.1 (alpine 3.11.5) CVE-2019-5482"}, ids(delta.Unchanged))
+
+	// the reports without class
+	for _, results := range []report.Results{baseline, current} {
+		for i := range results {
+			results[i].Class = ""
+		}
+	}
+	delta = report.NewDelta(baseline, current)
+	assert.Equal(t, []string{"myapp:1.1 (alpine 3.11.5) CVE-2019-5482"}, ids(delta.Unchanged))
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// DependencyCounts is the number of vulnerabilities of direct and transitive dependencies,
+// and of the dependencies without relationship data
+type DependencyCounts struct {
+	Direct     int
+	Transitive int
+	Unknown    int
+}
+
+// CountDependencies counts the vulnerabilities by the relationship of their package
+func CountDependencies(vulns []types.DetectedVulnerability) DependencyCounts {
+	var counts DependencyCounts
+	for _, vuln := range vulns {
+		switch vuln.Relationship {
+		case types.RelationshipDirect:
+			counts.Direct++
+		case types.RelationshipIndirect:
+			counts.Transitive++
+		default:
+			counts.Unknown++
+		}
+	}
+	return counts
+}
+
+// String is e.g. "Direct: 1, Transitive: 2", or only the combined count when no relationship is known
+func (c DependencyCounts) String() string {
+	if c.Direct == 0 && c.Transitive == 0 {
+		return fmt.Sprintf("Dependencies: %d (no dependency relationship data)", c.Unknown)
+	}
+	s := fmt.Sprintf("Direct: %d, Transitive: %d", c.Direct, c.Transitive)
+	if c.Unknown > 0 {
+		s += fmt.Sprintf(", Unknown: %d", c.Unknown)
+	}
+	return s
+}
+
+// writeDependencyOrigins lists each vulnerable package with the chain of the packages requiring it,
+// up to the direct dependency to upgrade. The packages without a dependency path aren't listed.
+func writeDependencyOrigins(output io.Writer, vulns []types.DetectedVulnerability) {
+	var pkgs []string
+	paths := map[string][]string{}
+	vulnIDs := map[string][]string{}
+	for _, vuln := range vulns {
+		if len(vuln.DependencyPath) == 0 {
+			continue
+		}
+		pkg := vuln.DependencyPath[len(vuln.DependencyPath)-1]
+		if _, ok := paths[pkg]; !ok {
+			pkgs = append(pkgs, pkg)
+			paths[pkg] = vuln.DependencyPath
+		}
+		vulnIDs[pkg] = append(vulnIDs[pkg], vuln.VulnerabilityID)
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(output, "\nDependency origin tree (reversed)\n")
+	for _, pkg := range pkgs {
+		path := paths[pkg]
+		line := fmt.Sprintf("%s (%s)", pkg, strings.Join(vulnIDs[pkg], ", "))
+		if len(path) == 1 {
+			line += " [direct]"
+		}
+		fmt.Fprintln(output, line)
+		for i := len(path) - 2; i >= 0; i-- {
+			fmt.Fprintf(output, "%s└── %s\n", strings.Repeat("    ", len(path)-2-i), path[i])
+		}
+	}
+}
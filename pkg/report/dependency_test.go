@@ -0,0 +1,130 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestCountDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		vulns []types.DetectedVulnerability
+		want  string
+	}{
+		{
+			name: "direct and transitive",
+			vulns: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", Relationship: types.RelationshipDirect},
+				{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist", Relationship: types.RelationshipIndirect},
+				{VulnerabilityID: "CVE-2020-8116", PkgName: "dot-prop", Relationship: types.RelationshipIndirect},
+			},
+			want: "Direct: 1, Transitive: 2",
+		},
+		{
+			name: "partially known",
+			vulns: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", Relationship: types.RelationshipDirect},
+				{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist"},
+			},
+			want: "Direct: 1, Transitive: 0, Unknown: 1",
+		},
+		{
+			name: "no relationship data",
+			vulns: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash"},
+				{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist"},
+			},
+			want: "Dependencies: 2 (no dependency relationship data)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, report.CountDependencies(tt.vulns).String())
+		})
+	}
+}
+
+func TestTableWriter_DependencyCounts(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			Class:  report.ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4",
+					Relationship: types.RelationshipDirect, Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+				{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist", InstalledVersion: "0.0.8",
+					Relationship: types.RelationshipIndirect, Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+			},
+		},
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Class:  report.ClassOSPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3",
+					Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	// the counts come with the dependency tree, which sets the relationships
+	writer, err := report.NewWriter(report.Option{Format: "table", Output: &tableWritten, Light: true, DependencyTree: true})
+	require.NoError(t, err)
+	assert.NoError(t, writer.Write(results))
+	assert.Equal(t, `Direct: 1, Transitive: 1
+
++----------+------------------+----------+-------------------+---------------+
+| LIBRARY  | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |
++----------+------------------+----------+-------------------+---------------+
+| lodash   | CVE-2019-10744   | CRITICAL | 4.17.4            |               |
++----------+------------------+----------+-------------------+---------------+
+| minimist | CVE-2020-7598    | MEDIUM   | 0.0.8             |               |
++----------+------------------+----------+-------------------+---------------+
++---------+------------------+----------+-------------------+---------------+
+| LIBRARY | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |
++---------+------------------+----------+-------------------+---------------+
+| openssl | CVE-2020-1967    | HIGH     | 1.1.1d-r3         |               |
++---------+------------------+----------+-------------------+---------------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_DependencyTree(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			Class:  report.ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2017-1000048", PkgName: "qs", InstalledVersion: "6.5.1",
+					DependencyPath: []string{"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+					Vulnerability:  dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2022-24999", PkgName: "qs", InstalledVersion: "6.5.1",
+					DependencyPath: []string{"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+					Vulnerability:  dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4",
+					DependencyPath: []string{"lodash@4.17.4"},
+					Vulnerability:  dbTypes.Vulnerability{Severity: "CRITICAL"}},
+				{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist", InstalledVersion: "0.0.8",
+					Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true, DependencyTree: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Contains(t, tableWritten.String(), `
+Dependency origin tree (reversed)
+qs@6.5.1 (CVE-2017-1000048, CVE-2022-24999)
+└── body-parser@1.18.2
+    └── express@4.16.0
+lodash@4.17.4 (CVE-2019-10744) [direct]
+`)
+	assert.NotContains(t, tableWritten.String(), "minimist@")
+}
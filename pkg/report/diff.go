@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+)
+
+// ReadResults reads the results of a report written with --format json,
+// either the bare results of JsonWriter or the Report of JSONWriter
+func ReadResults(r io.Reader) (Results, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the report: %w", err)
+	}
+
+	var results Results
+	if b = bytes.TrimSpace(b); bytes.HasPrefix(b, []byte("{")) {
+		var report Report
+		if err = json.Unmarshal(b, &report); err != nil {
+			return nil, xerrors.Errorf("invalid report: %w", err)
+		}
+		if report.SchemaVersion > SchemaVersion {
+			return nil, xerrors.Errorf("unsupported schema version %d", report.SchemaVersion)
+		}
+		results = report.Results
+	} else if err = json.Unmarshal(b, &results); err != nil {
+		return nil, xerrors.Errorf("invalid report: %w", err)
+	}
+	return results, nil
+}
+
+// DiffWriter writes the vulnerabilities new, fixed and unchanged since a baseline scan, in table or json
+type DiffWriter struct {
+	Output io.Writer
+	Format string
+}
+
+// DiffReport is the delta written by DiffWriter in json
+type DiffReport struct {
+	New       []TopFinding `json:"New"`
+	Fixed     []TopFinding `json:"Fixed"`
+	Unchanged []TopFinding `json:"Unchanged"`
+}
+
+func (dw DiffWriter) Write(delta Delta) error {
+	switch dw.Format {
+	case "json":
+		output, err := json.MarshalIndent(DiffReport{New: delta.Added, Fixed: delta.Removed, Unchanged: delta.Unchanged}, "", "  ")
+		if err != nil {
+			return xerrors.Errorf("failed to marshal json: %w", err)
+		}
+		if _, err = dw.Output.Write(output); err != nil {
+			return xerrors.Errorf("failed to write json: %w", err)
+		}
+	case "table":
+		dw.writeTable("New", delta.Added)
+		dw.writeTable("Fixed", delta.Removed)
+		dw.writeTable("Unchanged", delta.Unchanged)
+	default:
+		return xerrors.Errorf("unknown format: %v", dw.Format)
+	}
+	return nil
+}
+
+func (dw DiffWriter) writeTable(title string, findings []TopFinding) {
+	fmt.Fprintf(dw.Output, "\n%s: %d%s\n", title, len(findings), severityBreakdown(findings))
+	if len(findings) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(dw.Output)
+	table.SetHeader([]string{"Target", "Library", "Vulnerability ID", "Severity", "Installed Version", "Fixed Version"})
+	for _, f := range findings {
+		table.Append([]string{f.Target, f.PkgName, f.VulnerabilityID, f.Severity, f.InstalledVersion, f.FixedVersion})
+	}
+	table.Render()
+}
@@ -0,0 +1,122 @@
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestReadResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    report.Results
+		wantErr string
+	}{
+		{
+			name:  "bare results",
+			input: `[{"Target": "alpine:3.10 (alpine 3.10.4)", "Vulnerabilities": [{"VulnerabilityID": "CVE-2019-5482", "PkgName": "curl"}]}]`,
+			want: report.Results{
+				{
+					Target:          "alpine:3.10 (alpine 3.10.4)",
+					Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-5482", PkgName: "curl"}},
+				},
+			},
+		},
+		{
+			name:  "report with a schema version",
+			input: `{"SchemaVersion": 1, "Results": [{"Target": "app/package-lock.json", "Vulnerabilities": null}]}`,
+			want:  report.Results{{Target: "app/package-lock.json"}},
+		},
+		{
+			name:    "newer schema version",
+			input:   `{"SchemaVersion": 2, "Results": []}`,
+			wantErr: "unsupported schema version 2",
+		},
+		{
+			name:    "not a report",
+			input:   `FROM alpine`,
+			wantErr: "invalid report",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := report.ReadResults(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDiffWriter_Write(t *testing.T) {
+	baseline := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-1547", "openssl", "LOW"),
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+			},
+		},
+	}
+	current := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-5482", "curl", "HIGH"),
+				deltaVuln("CVE-2020-1967", "openssl", "CRITICAL"),
+			},
+		},
+	}
+	delta := report.NewDelta(baseline, current)
+
+	t.Run("table", func(t *testing.T) {
+		written := bytes.Buffer{}
+		require.NoError(t, report.DiffWriter{Output: &written, Format: "table"}.Write(delta))
+		assert.Equal(t, `
+New: 1 (1 CRITICAL)
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+|           TARGET            | LIBRARY | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+| alpine:3.10 (alpine 3.10.4) | openssl | CVE-2020-1967    | CRITICAL |                   |               |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+
+Fixed: 1 (1 LOW)
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+|           TARGET            | LIBRARY | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+| alpine:3.10 (alpine 3.10.4) | openssl | CVE-2019-1547    | LOW      |                   |               |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+
+Unchanged: 1 (1 HIGH)
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+|           TARGET            | LIBRARY | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+| alpine:3.10 (alpine 3.10.4) | curl    | CVE-2019-5482    | HIGH     |                   |               |
++-----------------------------+---------+------------------+----------+-------------------+---------------+
+`, written.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		written := bytes.Buffer{}
+		require.NoError(t, report.DiffWriter{Output: &written, Format: "json"}.Write(delta))
+		assert.Contains(t, written.String(), `"New": [`)
+		assert.Contains(t, written.String(), `"VulnerabilityID": "CVE-2020-1967"`)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := report.DiffWriter{Output: &bytes.Buffer{}, Format: "sarif"}.Write(delta)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown format")
+	})
+}
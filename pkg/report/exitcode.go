@@ -0,0 +1,142 @@
+package report
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// informationalSeverities are reported but don't fail a Gate unless it is forced
+var informationalSeverities = []string{"INFO", dbTypes.SeverityUnknown.String()}
+
+// IsInformational reports whether the severity is informational, i.e. INFO, UNKNOWN or empty
+func IsInformational(severity string) bool {
+	if severity == "" {
+		return true
+	}
+	for _, s := range informationalSeverities {
+		if strings.EqualFold(s, severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSeverity reports whether any vulnerability of the results has one of the severities, e.g. "HIGH" or "CRITICAL".
+// A vulnerability without severity has the UNKNOWN one. No severity never matches.
+func (results Results) HasSeverity(severities []string) bool {
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			severity := vuln.Severity
+			if severity == "" {
+				severity = dbTypes.SeverityUnknown.String()
+			}
+			for _, s := range severities {
+				if strings.EqualFold(s, severity) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Gate decides whether the results fail a policy, e.g. a CI build, separately from what is reported:
+// it fails when a vulnerability, secret or misconfiguration has one of the Severities, or any severity with AnyFinding
+// and no Severities, or when a license is forbidden whatever its severity. No Severities fail nothing else.
+// The informational findings never fail it, even with their severity in Severities, unless Force is set.
+type Gate struct {
+	Severities []string
+	AnyFinding bool
+	Force      bool
+}
+
+// Fails reports whether the results fail the gate
+func (g Gate) Fails(results Results) bool {
+	matches := func(severity string) bool {
+		if severity == "" {
+			severity = dbTypes.SeverityUnknown.String()
+		}
+		if !g.Force && IsInformational(severity) {
+			return false
+		}
+		if len(g.Severities) == 0 {
+			return g.AnyFinding
+		}
+		for _, s := range g.Severities {
+			if strings.EqualFold(s, severity) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			if matches(vuln.Severity) {
+				return true
+			}
+		}
+		for _, secret := range result.Secrets {
+			if matches(secret.Severity) {
+				return true
+			}
+		}
+		for _, m := range result.Misconfigurations {
+			if matches(m.Severity) {
+				return true
+			}
+		}
+		for _, l := range result.Licenses {
+			if l.Forbidden {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExitCode returns code when the results fail the gate and zero otherwise
+func (g Gate) ExitCode(results Results, code int) int {
+	if g.Fails(results) {
+		return code
+	}
+	return 0
+}
+
+// ExitCode returns the exit status of the scan: code when a finding of the results has one of the severities,
+// e.g. to fail the build on HIGH or CRITICAL, and zero otherwise, e.g. without severities or results.
+// The informational findings are ignored as by Gate.
+func ExitCode(results Results, severities []string, code int) int {
+	return Gate{Severities: severities}.ExitCode(results, code)
+}
+
+// ExitCodeGate returns the gate of --exit-code with the severities of --exit-on-severity: unlike the Gate of ExitCode,
+// any finding fails it without severities, and it is forced, so that the findings of the UNKNOWN or INFO severity
+// fail the scan as the other ones
+func ExitCodeGate(severities []string) Gate {
+	return Gate{Severities: severities, AnyFinding: true, Force: true}
+}
+
+// SeveritiesFrom returns the severity and the higher ones, e.g. HIGH and CRITICAL for HIGH,
+// to gate the results at a minimum severity
+func SeveritiesFrom(severity string) ([]string, error) {
+	s, err := dbTypes.NewSeverity(strings.ToUpper(severity))
+	if err != nil {
+		return nil, xerrors.Errorf("invalid severity: %w", err)
+	}
+	return append([]string(nil), dbTypes.SeverityNames[s:]...), nil
+}
+
+// HasEOL reports whether the OS of a result is no longer supported by its distribution, i.e. whether the results
+// fail --exit-on-eol, with or without its finding
+func (results Results) HasEOL() bool {
+	for _, result := range results {
+		if result.EOSL || result.EOL != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestExitCode(t *testing.T) {
+	results := Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+				{VulnerabilityID: "CVE-2020-8169"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		results    Results
+		severities []string
+		want       int
+	}{
+		{
+			name:       "HIGH found",
+			results:    results,
+			severities: []string{"HIGH", "CRITICAL"},
+			want:       1,
+		},
+		{
+			name:       "only lower severities",
+			results:    results[:1],
+			severities: []string{"HIGH", "CRITICAL"},
+			want:       0,
+		},
+		{
+			name:       "lower case severity",
+			results:    results,
+			severities: []string{"high"},
+			want:       1,
+		},
+		{
+			name:       "no results",
+			severities: []string{"HIGH", "CRITICAL"},
+			want:       0,
+		},
+		{
+			name:       "results without vulnerabilities",
+			results:    Results{{Target: "alpine:3.11 (alpine 3.11.5)"}},
+			severities: []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"},
+			want:       0,
+		},
+		{
+			name:    "no severities",
+			results: results,
+			want:    0,
+		},
+		{
+			name: "no results nor severities",
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExitCode(tt.results, tt.severities, 1))
+			assert.Equal(t, tt.want != 0, tt.results.HasSeverity(tt.severities))
+		})
+	}
+}
+
+func TestGate_Fails(t *testing.T) {
+	results := Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+				{VulnerabilityID: "CVE-2020-8169"},
+				{VulnerabilityID: "CVE-2020-8177", Vulnerability: dbTypes.Vulnerability{Severity: "INFO"}},
+			},
+		},
+	}
+	all := []string{"INFO", "UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+	tests := []struct {
+		name    string
+		results Results
+		gate    Gate
+		want    bool
+	}{
+		{
+			name:    "LOW fails",
+			results: results,
+			gate:    Gate{Severities: all},
+			want:    true,
+		},
+		{
+			name:    "informational findings don't fail",
+			results: Results{{Target: results[0].Target, Vulnerabilities: results[0].Vulnerabilities[1:]}},
+			gate:    Gate{Severities: all},
+			want:    false,
+		},
+		{
+			name:    "forced informational findings fail",
+			results: Results{{Target: results[0].Target, Vulnerabilities: results[0].Vulnerabilities[2:]}},
+			gate:    Gate{Severities: []string{"INFO"}, Force: true},
+			want:    true,
+		},
+		{
+			name:    "UNKNOWN gate not forced",
+			results: results,
+			gate:    Gate{Severities: []string{"UNKNOWN"}},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.gate.Fails(tt.results))
+		})
+	}
+
+	// the informational findings are still in the report
+	var buf bytes.Buffer
+	require.NoError(t, (&JsonWriter{Output: &buf}).Write(results))
+	assert.Contains(t, buf.String(), "CVE-2020-8169")
+	assert.Contains(t, buf.String(), "CVE-2020-8177")
+	assert.True(t, results.HasSeverity([]string{"UNKNOWN"}))
+}
+
+func TestExitCodeGate(t *testing.T) {
+	unknown := Results{{
+		Target:          "alpine:3.11 (alpine 3.11.5)",
+		Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-8169"}},
+	}}
+	assert.Equal(t, 1, ExitCodeGate(nil).ExitCode(unknown, 1), "any finding fails --exit-code")
+	assert.Equal(t, 1, ExitCodeGate([]string{"UNKNOWN"}).ExitCode(unknown, 1))
+	assert.Equal(t, 0, ExitCodeGate([]string{"HIGH", "CRITICAL"}).ExitCode(unknown, 1))
+	assert.Equal(t, 0, ExitCodeGate(nil).ExitCode(Results{{Target: "alpine:3.11 (alpine 3.11.5)"}}, 1))
+}
+
+func TestSeveritiesFrom(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     []string
+		wantErr  string
+	}{
+		{severity: "CRITICAL", want: []string{"CRITICAL"}},
+		{severity: "medium", want: []string{"MEDIUM", "HIGH", "CRITICAL"}},
+		{severity: "UNKNOWN", want: []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}},
+		{severity: "SEVERE", wantErr: "invalid severity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			got, err := SeveritiesFrom(tt.severity)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGate_Fails_Findings(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    Results
+		severities []string
+		want       bool
+	}{
+		{
+			name: "any vulnerability",
+			results: Results{{Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+			}}},
+			want: true,
+		},
+		{
+			name: "vulnerability below the severities",
+			results: Results{{Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2020-8169"},
+			}}},
+			severities: []string{"CRITICAL"},
+			want:       false,
+		},
+		{
+			name: "vulnerability without severity",
+			results: Results{{Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-8169"},
+			}}},
+			severities: []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"},
+			want:       false,
+		},
+		{
+			name:    "informational secret without severities",
+			results: Results{{Secrets: []types.SecretFinding{{RuleID: "generic-token", Severity: "INFO"}}}},
+			want:    false,
+		},
+		{
+			name:       "critical secret",
+			results:    Results{{Secrets: []types.SecretFinding{{RuleID: "aws-access-key-id", Severity: "CRITICAL"}}}},
+			severities: []string{"CRITICAL"},
+			want:       true,
+		},
+		{
+			name:       "misconfiguration below the severities",
+			results:    Results{{Misconfigurations: []types.Misconfiguration{{ID: "DS002", Severity: "HIGH"}}}},
+			severities: []string{"CRITICAL"},
+			want:       false,
+		},
+		{
+			name:       "forbidden license whatever the severities",
+			results:    Results{{Licenses: []types.DetectedLicense{{PkgName: "musl", Name: "GPL-3.0", Forbidden: true}}}},
+			severities: []string{"CRITICAL"},
+			want:       true,
+		},
+		{
+			name:    "allowed license",
+			results: Results{{Licenses: []types.DetectedLicense{{PkgName: "musl", Name: "MIT"}}}},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Gate{Severities: tt.severities, AnyFinding: true}.Fails(tt.results))
+		})
+	}
+}
+
+func TestResults_HasEOL(t *testing.T) {
+	assert.False(t, Results{{Target: "alpine:3.11 (alpine 3.11.5)", Type: "alpine"}}.HasEOL())
+	assert.True(t, Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine", EOSL: true}}.HasEOL())
+	assert.True(t, Results{
+		{Target: "alpine:3.9 (alpine 3.9.6)", Class: ClassEOL, EOL: &types.EOLFinding{Family: "alpine", Name: "3.9.6"}},
+	}.HasEOL())
+}
@@ -0,0 +1,236 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	// GitLabSchemaVersion is the version of the container scanning report schema written by GitLabWriter
+	GitLabSchemaVersion = "14.0.0"
+
+	gitLabScanType   = "container_scanning"
+	gitLabTimeLayout = "2006-01-02T15:04:05"
+)
+
+// GitLabReport is a GitLab container scanning report
+// (https://gitlab.com/gitlab-org/security-products/security-report-schemas)
+type GitLabReport struct {
+	Version         string                `json:"version"`
+	Vulnerabilities []GitLabVulnerability `json:"vulnerabilities"`
+	Remediations    []GitLabRemediation   `json:"remediations"`
+	Scan            GitLabScan            `json:"scan"`
+}
+
+type GitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Category    string             `json:"category"`
+	Name        string             `json:"name,omitempty"`
+	Message     string             `json:"message,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Severity    string             `json:"severity"`
+	Confidence  string             `json:"confidence"`
+	Solution    string             `json:"solution,omitempty"`
+	Scanner     GitLabScanner      `json:"scanner"`
+	Location    GitLabLocation     `json:"location"`
+	Identifiers []GitLabIdentifier `json:"identifiers"`
+	Links       []GitLabLink       `json:"links,omitempty"`
+}
+
+type GitLabScanner struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type GitLabLocation struct {
+	Dependency      GitLabDependency `json:"dependency"`
+	OperatingSystem string           `json:"operating_system"`
+	Image           string           `json:"image"`
+}
+
+type GitLabDependency struct {
+	Package GitLabPackage `json:"package"`
+	Version string        `json:"version"`
+}
+
+type GitLabPackage struct {
+	Name string `json:"name"`
+}
+
+type GitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type GitLabLink struct {
+	URL string `json:"url"`
+}
+
+// GitLabRemediation is never written, the fixes being in the solutions of the vulnerabilities
+type GitLabRemediation struct {
+	Fixes   []GitLabRef `json:"fixes"`
+	Summary string      `json:"summary"`
+	Diff    string      `json:"diff"`
+}
+
+type GitLabRef struct {
+	ID string `json:"id"`
+}
+
+type GitLabScan struct {
+	Scanner   GitLabScanScanner `json:"scanner"`
+	Type      string            `json:"type"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Status    string            `json:"status"`
+}
+
+type GitLabScanScanner struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	Vendor  GitLabVendor `json:"vendor"`
+}
+
+type GitLabVendor struct {
+	Name string `json:"name"`
+}
+
+// GitLabWriter writes the findings as a GitLab container scanning report, the artifact of the container_scanning job.
+// The image and the OS of the findings are those of the OS result, e.g. "alpine:3.11 (alpine 3.11.5)".
+type GitLabWriter struct {
+	Output io.Writer
+	// Version is the version of Trivy in the scanner of the scan
+	Version string
+	// StartTime and EndTime are the times of the scan; EndTime is the current time when it is zero,
+	// and StartTime is EndTime when it is zero
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (gw GitLabWriter) Write(results Results) error {
+	end := gw.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := gw.StartTime
+	if start.IsZero() {
+		start = end
+	}
+
+	output, err := json.MarshalIndent(NewGitLabReport(results, gw.Version, start, end), "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal the GitLab report: %w", err)
+	}
+	if _, err = gw.Output.Write(output); err != nil {
+		return xerrors.Errorf("failed to write the GitLab report: %w", err)
+	}
+	return nil
+}
+
+// NewGitLabReport converts the findings of the results into GitLab vulnerabilities
+func NewGitLabReport(results Results, version string, start, end time.Time) GitLabReport {
+	var image, operatingSystem string
+	for _, result := range results {
+		if result.Class == ClassOSPkgs {
+			image, operatingSystem = splitOSTarget(result.Target)
+			break
+		}
+	}
+
+	r := GitLabReport{
+		Version:         GitLabSchemaVersion,
+		Vulnerabilities: []GitLabVulnerability{},
+		Remediations:    []GitLabRemediation{},
+		Scan: GitLabScan{
+			Scanner: GitLabScanScanner{
+				ID:      "trivy",
+				Name:    "Trivy",
+				Version: version,
+				Vendor:  GitLabVendor{Name: "Aqua Security"},
+			},
+			Type:      gitLabScanType,
+			StartTime: start.UTC().Format(gitLabTimeLayout),
+			EndTime:   end.UTC().Format(gitLabTimeLayout),
+			Status:    "success",
+		},
+	}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			r.Vulnerabilities = append(r.Vulnerabilities, newGitLabVulnerability(vuln, image, operatingSystem))
+		}
+	}
+	return r
+}
+
+func newGitLabVulnerability(vuln types.DetectedVulnerability, image, operatingSystem string) GitLabVulnerability {
+	v := GitLabVulnerability{
+		ID:          vuln.VulnerabilityID,
+		Category:    gitLabScanType,
+		Name:        vuln.VulnerabilityID,
+		Message:     vuln.Title,
+		Description: vuln.Description,
+		Severity:    gitLabSeverity(vuln.Severity),
+		Confidence:  "Unknown",
+		Scanner:     GitLabScanner{ID: "trivy", Name: "Trivy"},
+		Location: GitLabLocation{
+			Dependency: GitLabDependency{
+				Package: GitLabPackage{Name: vuln.PkgName},
+				Version: vuln.InstalledVersion,
+			},
+			OperatingSystem: operatingSystem,
+			Image:           image,
+		},
+		Identifiers: []GitLabIdentifier{{
+			Type:  identifierType(vuln.VulnerabilityID),
+			Name:  vuln.VulnerabilityID,
+			Value: vuln.VulnerabilityID,
+		}},
+	}
+	if vuln.FixedVersion != "" {
+		v.Solution = fmt.Sprintf("Upgrade %s to %s", vuln.PkgName, vuln.FixedVersion)
+	}
+	if strings.HasPrefix(vuln.VulnerabilityID, "CVE-") {
+		v.Identifiers[0].URL = "https://cve.mitre.org/cgi-bin/cvename.cgi?name=" + vuln.VulnerabilityID
+	}
+	for _, ref := range vuln.References {
+		v.Links = append(v.Links, GitLabLink{URL: ref})
+	}
+	return v
+}
+
+// gitLabSeverity maps a severity to the GitLab one, e.g. HIGH to High
+func gitLabSeverity(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW":
+		return severity[:1] + strings.ToLower(severity[1:])
+	default:
+		return "Unknown"
+	}
+}
+
+// identifierType returns the lower-cased prefix of the ID, e.g. cve for CVE-2020-1967 and rustsec for RUSTSEC-2019-0001
+func identifierType(id string) string {
+	if i := strings.Index(id, "-"); i > 0 {
+		return strings.ToLower(id[:i])
+	}
+	return "trivy"
+}
+
+// splitOSTarget returns the image and the OS of the target of an OS result, e.g. alpine:3.11 and alpine 3.11.5
+func splitOSTarget(target string) (string, string) {
+	i := strings.LastIndex(target, " (")
+	if i < 0 || !strings.HasSuffix(target, ")") {
+		return target, ""
+	}
+	return target[:i], target[i+2 : len(target)-1]
+}
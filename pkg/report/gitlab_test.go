@@ -0,0 +1,109 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestGitLabWriter_Write(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Class:  report.ClassOSPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Vulnerability: dbTypes.Vulnerability{
+						Title:      "openssl: Segmentation fault in SSL_check_chain",
+						Severity:   "HIGH",
+						References: []string{"https://www.openssl.org/news/secadv/20200421.txt"},
+					},
+				},
+			},
+		},
+		{
+			Target: "app/Cargo.lock",
+			Type:   "cargo",
+			Class:  report.ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "RUSTSEC-2019-0001", PkgName: "ammonia", InstalledVersion: "1.2.0"},
+			},
+		},
+	}
+
+	output := new(bytes.Buffer)
+	w := report.GitLabWriter{
+		Output:    output,
+		Version:   "0.6.0",
+		StartTime: time.Date(2020, 4, 21, 10, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2020, 4, 21, 10, 1, 30, 0, time.UTC),
+	}
+	require.NoError(t, w.Write(results))
+
+	var got map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+	for _, key := range []string{"version", "vulnerabilities", "remediations", "scan"} {
+		assert.Contains(t, got, key)
+	}
+
+	var r report.GitLabReport
+	require.NoError(t, json.Unmarshal(output.Bytes(), &r))
+	assert.Equal(t, report.GitLabScan{
+		Scanner: report.GitLabScanScanner{
+			ID: "trivy", Name: "Trivy", Version: "0.6.0", Vendor: report.GitLabVendor{Name: "Aqua Security"},
+		},
+		Type:      "container_scanning",
+		StartTime: "2020-04-21T10:00:00",
+		EndTime:   "2020-04-21T10:01:30",
+		Status:    "success",
+	}, r.Scan)
+	assert.Empty(t, r.Remediations)
+
+	require.Len(t, r.Vulnerabilities, 2)
+	assert.Equal(t, report.GitLabVulnerability{
+		ID:         "CVE-2020-1967",
+		Category:   "container_scanning",
+		Name:       "CVE-2020-1967",
+		Message:    "openssl: Segmentation fault in SSL_check_chain",
+		Severity:   "High",
+		Confidence: "Unknown",
+		Solution:   "Upgrade openssl to 1.1.1g-r0",
+		Scanner:    report.GitLabScanner{ID: "trivy", Name: "Trivy"},
+		Location: report.GitLabLocation{
+			Dependency: report.GitLabDependency{
+				Package: report.GitLabPackage{Name: "openssl"},
+				Version: "1.1.1d-r3",
+			},
+			OperatingSystem: "alpine 3.11.5",
+			Image:           "alpine:3.11",
+		},
+		Identifiers: []report.GitLabIdentifier{{
+			Type:  "cve",
+			Name:  "CVE-2020-1967",
+			Value: "CVE-2020-1967",
+			URL:   "https://cve.mitre.org/cgi-bin/cvename.cgi?name=CVE-2020-1967",
+		}},
+		Links: []report.GitLabLink{{URL: "https://www.openssl.org/news/secadv/20200421.txt"}},
+	}, r.Vulnerabilities[0])
+
+	lib := r.Vulnerabilities[1]
+	assert.Equal(t, "RUSTSEC-2019-0001", lib.ID)
+	assert.Equal(t, "Unknown", lib.Severity)
+	assert.Empty(t, lib.Solution)
+	assert.Equal(t, "rustsec", lib.Identifiers[0].Type)
+	assert.Equal(t, "alpine:3.11", lib.Location.Image)
+	assert.Equal(t, "ammonia", lib.Location.Dependency.Package.Name)
+}
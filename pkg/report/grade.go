@@ -0,0 +1,44 @@
+package report
+
+import (
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// Grade grades the image of the results with the rubric, e.g. types.DefaultGradeRubric
+func Grade(results Results, rubric types.GradeRubric) string {
+	counts, _, eosl := countSeverities(results)
+
+	grade := rubric.FailGrade
+	index := len(rubric.Grades)
+	for i, limit := range rubric.Grades {
+		if meetsLimit(counts, limit.MaxCounts) {
+			grade, index = limit.Grade, i
+			break
+		}
+	}
+
+	// an end-of-life OS caps the grade, and a worse grade is kept
+	if eosl && rubric.EOSLGrade != "" && gradeIndex(rubric, rubric.EOSLGrade) > index {
+		grade = rubric.EOSLGrade
+	}
+	return grade
+}
+
+func meetsLimit(counts map[string]int, maxCounts map[string]int) bool {
+	for severity, max := range maxCounts {
+		if counts[severity] > max {
+			return false
+		}
+	}
+	return true
+}
+
+// gradeIndex returns the rank of the grade in the rubric from the best, the fail grade being the worst
+func gradeIndex(rubric types.GradeRubric, grade string) int {
+	for i, limit := range rubric.Grades {
+		if limit.Grade == grade {
+			return i
+		}
+	}
+	return len(rubric.Grades)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestGrade(t *testing.T) {
+	vulns := func(severity string, n int) []types.DetectedVulnerability {
+		var vulns []types.DetectedVulnerability
+		for i := 0; i < n; i++ {
+			vulns = append(vulns, types.DetectedVulnerability{Vulnerability: dbTypes.Vulnerability{Severity: severity}})
+		}
+		return vulns
+	}
+
+	tests := []struct {
+		name    string
+		results Results
+		rubric  *types.GradeRubric
+		want    string
+	}{
+		{
+			name:    "clean image",
+			results: Results{{Target: "alpine:3.11 (alpine 3.11.5)"}},
+			want:    "A",
+		},
+		{
+			name: "few medium findings",
+			results: Results{
+				{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: vulns("MEDIUM", 3)},
+				{Target: "app/package-lock.json", Vulnerabilities: vulns("LOW", 40)},
+			},
+			want: "A",
+		},
+		{
+			name: "high findings",
+			results: Results{
+				{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: vulns("HIGH", 3)},
+				{Target: "app/package-lock.json", Vulnerabilities: vulns("HIGH", 3)},
+			},
+			want: "C",
+		},
+		{
+			name:    "EOL OS",
+			results: Results{{Target: "alpine:3.9 (alpine 3.9.6)", EOSL: true}},
+			want:    "D",
+		},
+		{
+			name: "critical findings in an EOL OS",
+			results: Results{
+				{Target: "alpine:3.9 (alpine 3.9.6)", EOSL: true, Vulnerabilities: vulns("CRITICAL", 12)},
+			},
+			want: "F",
+		},
+		{
+			name:    "custom rubric",
+			results: Results{{Target: "alpine:3.9 (alpine 3.9.6)", EOSL: true, Vulnerabilities: vulns("HIGH", 1)}},
+			rubric: &types.GradeRubric{
+				Grades:    []types.GradeLimit{{Grade: "pass", MaxCounts: map[string]int{"CRITICAL": 0}}},
+				FailGrade: "fail",
+				EOSLGrade: "fail",
+			},
+			want: "fail",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rubric := types.DefaultGradeRubric
+			if tt.rubric != nil {
+				rubric = *tt.rubric
+			}
+			assert.Equal(t, tt.want, Grade(tt.results, rubric))
+		})
+	}
+}
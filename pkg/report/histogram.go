@@ -0,0 +1,62 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// ApplicationLayer is the bucket of findings without layer information
+const ApplicationLayer = "application"
+
+// LayerHistogram is the number of vulnerabilities per severity in a layer
+type LayerHistogram struct {
+	DiffID string
+	Counts map[string]int
+}
+
+// NewLayerHistograms counts the vulnerabilities per Layer.DiffID in the order the layers first appear.
+// An empty severity is counted as UNKNOWN.
+func NewLayerHistograms(results Results) []LayerHistogram {
+	var histograms []LayerHistogram
+	index := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			diffID := vuln.Layer.DiffID
+			if diffID == "" {
+				diffID = ApplicationLayer
+			}
+			i, ok := index[diffID]
+			if !ok {
+				i = len(histograms)
+				index[diffID] = i
+				histograms = append(histograms, LayerHistogram{DiffID: diffID, Counts: map[string]int{}})
+			}
+
+			countSeverity(histograms[i].Counts, vuln.Severity)
+		}
+	}
+	return histograms
+}
+
+func writeLayerHistograms(output io.Writer, histograms []LayerHistogram) {
+	if len(histograms) == 0 {
+		return
+	}
+
+	fmt.Fprintf(output, "\nSeverities per layer\n")
+	table := tablewriter.NewWriter(output)
+	table.SetHeader(append([]string{"Layer"}, dbTypes.SeverityNames...))
+	for _, h := range histograms {
+		row := []string{h.DiffID}
+		for _, severity := range dbTypes.SeverityNames {
+			row = append(row, strconv.Itoa(h.Counts[severity]))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,95 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+var multiLayerResults = report.Results{
+	{
+		Target: "alpine:3.11 (alpine 3.11.5)",
+		Type:   "alpine",
+		Vulnerabilities: []types.DetectedVulnerability{
+			{
+				VulnerabilityID: "CVE-2020-1967",
+				PkgName:         "openssl",
+				Layer:           ftypes.Layer{DiffID: "sha256:base"},
+				Vulnerability:   dbTypes.Vulnerability{Severity: "HIGH"},
+			},
+			{
+				VulnerabilityID: "CVE-2020-1968",
+				PkgName:         "libssl1.1",
+				Layer:           ftypes.Layer{DiffID: "sha256:base"},
+				Vulnerability:   dbTypes.Vulnerability{Severity: "CRITICAL"},
+			},
+			{
+				VulnerabilityID: "CVE-2020-2000",
+				PkgName:         "curl",
+				Layer:           ftypes.Layer{DiffID: "sha256:curl"},
+				Vulnerability:   dbTypes.Vulnerability{Severity: "HIGH"},
+			},
+			{
+				VulnerabilityID: "CVE-2020-2001",
+				PkgName:         "curl",
+				Layer:           ftypes.Layer{DiffID: "sha256:curl"},
+			},
+		},
+	},
+	{
+		Target: "app/package-lock.json",
+		Type:   "npm",
+		Vulnerabilities: []types.DetectedVulnerability{
+			{
+				VulnerabilityID: "NSWG-ECO-428",
+				PkgName:         "jquery",
+				Layer:           ftypes.Layer{DiffID: "sha256:curl"},
+				Vulnerability:   dbTypes.Vulnerability{Severity: "MEDIUM"},
+			},
+			{
+				VulnerabilityID: "CVE-2019-11358",
+				PkgName:         "jquery",
+				Vulnerability:   dbTypes.Vulnerability{Severity: "MEDIUM"},
+			},
+		},
+	},
+}
+
+func TestNewLayerHistograms(t *testing.T) {
+	got := report.NewLayerHistograms(multiLayerResults)
+	want := []report.LayerHistogram{
+		{DiffID: "sha256:base", Counts: map[string]int{"HIGH": 1, "CRITICAL": 1}},
+		{DiffID: "sha256:curl", Counts: map[string]int{"HIGH": 1, "UNKNOWN": 1, "MEDIUM": 1}},
+		{DiffID: report.ApplicationLayer, Counts: map[string]int{"MEDIUM": 1}},
+	}
+	assert.Equal(t, want, got)
+
+	assert.Empty(t, report.NewLayerHistograms(report.Results{{Target: "empty"}}))
+}
+
+func TestTableWriter_LayerHistogram(t *testing.T) {
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true, LayerHistogram: true}
+	assert.NoError(t, tw.Write(multiLayerResults))
+	assert.Contains(t, tableWritten.String(), `
+Severities per layer
++-------------+---------+-----+--------+------+----------+
+|    LAYER    | UNKNOWN | LOW | MEDIUM | HIGH | CRITICAL |
++-------------+---------+-----+--------+------+----------+
+| sha256:base |       0 |   0 |      0 |    1 |        1 |
+| sha256:curl |       1 |   0 |      1 |    1 |        0 |
+| application |       0 |   0 |      1 |    0 |        0 |
++-------------+---------+-----+--------+------+----------+
+`)
+
+	tableWritten.Reset()
+	tw.LayerHistogram = false
+	assert.NoError(t, tw.Write(multiLayerResults))
+	assert.NotContains(t, tableWritten.String(), "Severities per layer")
+}
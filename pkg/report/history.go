@@ -0,0 +1,123 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// History is the trend of the vulnerabilities of an image over its recorded scans, written by HistoryWriter
+type History struct {
+	Image string
+	// Scans are the recorded scans of the image, the oldest first
+	Scans []HistoryScan
+	// Findings are the vulnerabilities queried in the scans, with the scans they first and last appeared in
+	Findings []HistoryFinding `json:",omitempty"`
+}
+
+// HistoryScan is a recorded scan with its number of vulnerabilities per severity
+type HistoryScan struct {
+	ScannedAt  time.Time
+	ImageID    string
+	RepoDigest string `json:",omitempty"`
+	Total      int
+	Severities map[string]int `json:",omitempty"`
+}
+
+// HistoryFinding is a vulnerability of a package over the recorded scans
+type HistoryFinding struct {
+	VulnerabilityID string
+	PkgName         string
+	// Severity is the severity of the latest scan finding it
+	Severity  string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Fixed is true when the latest scan no longer finds it
+	Fixed bool
+}
+
+// HistoryWriter writes the history of an image in table or json
+type HistoryWriter struct {
+	Output io.Writer
+	Format string
+}
+
+func (hw HistoryWriter) Write(history History) error {
+	switch hw.Format {
+	case "json":
+		output, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			return xerrors.Errorf("failed to marshal json: %w", err)
+		}
+		if _, err = hw.Output.Write(output); err != nil {
+			return xerrors.Errorf("failed to write json: %w", err)
+		}
+	case "table":
+		hw.writeScans(history)
+		if len(history.Findings) > 0 {
+			hw.writeFindings(history.Findings)
+		}
+	default:
+		return xerrors.Errorf("unknown format: %v", hw.Format)
+	}
+	return nil
+}
+
+func (hw HistoryWriter) writeScans(history History) {
+	fmt.Fprintf(hw.Output, "\n%s: %d scans\n", history.Image, len(history.Scans))
+
+	severities := dbTypes.SeverityNames
+	header := []string{"Scanned At", "Image ID", "Repo Digest", "Total", "Change"}
+	for i := len(severities) - 1; i >= 0; i-- {
+		header = append(header, severities[i])
+	}
+
+	table := tablewriter.NewWriter(hw.Output)
+	table.SetHeader(header)
+	for i, scan := range history.Scans {
+		change := ""
+		if i > 0 {
+			change = fmt.Sprintf("%+d", scan.Total-history.Scans[i-1].Total)
+		}
+		row := []string{scan.ScannedAt.UTC().Format(time.RFC3339), shortDigest(scan.ImageID),
+			shortDigest(scan.RepoDigest), strconv.Itoa(scan.Total), change}
+		for j := len(severities) - 1; j >= 0; j-- {
+			row = append(row, strconv.Itoa(scan.Severities[severities[j]]))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+func (hw HistoryWriter) writeFindings(findings []HistoryFinding) {
+	fmt.Fprintf(hw.Output, "\n%s\n", findings[0].VulnerabilityID)
+
+	table := tablewriter.NewWriter(hw.Output)
+	table.SetHeader([]string{"Library", "Severity", "First Seen", "Last Seen", "Status"})
+	for _, f := range findings {
+		status := "present"
+		if f.Fixed {
+			status = "fixed"
+		}
+		table.Append([]string{f.PkgName, f.Severity, f.FirstSeen.UTC().Format(time.RFC3339),
+			f.LastSeen.UTC().Format(time.RFC3339), status})
+	}
+	table.Render()
+}
+
+// shortDigest shortens the hex of a digest to 12 characters, as docker does for the image IDs
+func shortDigest(digest string) string {
+	i := strings.Index(digest, "sha256:")
+	if i < 0 || len(digest) < i+len("sha256:")+12 {
+		return digest
+	}
+	return digest[:i] + digest[i+len("sha256:"):i+len("sha256:")+12]
+}
@@ -0,0 +1,51 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+func TestHistoryWriter_Write(t *testing.T) {
+	history := report.History{
+		Image: "alpine:3.10",
+		Scans: []report.HistoryScan{
+			{ScannedAt: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC), ImageID: "sha256:961769676411f082461f9ef46626dd7a2d1e2b2a38e6a44364bcbecf51e66dd4",
+				Total: 1, Severities: map[string]int{"MEDIUM": 1}},
+			{ScannedAt: time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC), ImageID: "sha256:961769676411f082461f9ef46626dd7a2d1e2b2a38e6a44364bcbecf51e66dd4",
+				RepoDigest: "alpine@sha256:451eee8bedcb2f029756dc3e9d73bab0e7943c1ac55cff3a4861c52a0fdd3e98",
+				Total:      3, Severities: map[string]int{"MEDIUM": 1, "HIGH": 2}},
+		},
+		Findings: []report.HistoryFinding{
+			{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", Severity: "HIGH",
+				FirstSeen: time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC), LastSeen: time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	t.Run("table", func(t *testing.T) {
+		output := bytes.Buffer{}
+		require.NoError(t, report.HistoryWriter{Output: &output, Format: "table"}.Write(history))
+		got := output.String()
+		assert.Contains(t, got, "alpine:3.10: 2 scans")
+		assert.Contains(t, got, "| 2020-03-02T12:00:00Z | 961769676411 | alpine@451eee8bedcb |     3 | +2     |")
+		assert.Contains(t, got, "CVE-2019-14697")
+		assert.Contains(t, got, "| musl    | HIGH     | 2020-03-02T12:00:00Z | 2020-03-02T12:00:00Z | present |")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		output := bytes.Buffer{}
+		require.NoError(t, report.HistoryWriter{Output: &output, Format: "json"}.Write(history))
+		assert.Contains(t, output.String(), `"FirstSeen": "2020-03-02T12:00:00Z"`)
+		assert.NotContains(t, output.String(), `"RepoDigest": ""`)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := report.HistoryWriter{Output: &bytes.Buffer{}, Format: "sarif"}.Write(history)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+// htmlTemplate has a summary table with the number of vulnerabilities per severity of each target,
+// and the details of each vulnerability in an expandable element
+var htmlTemplate = template.Must(template.New("html report").Funcs(template.FuncMap{
+	"severityCount": severityCount,
+	"severityColor": severityColor,
+	"cveURL":        cveURL,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Trivy Report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.count { text-align: right; }
+details { margin: 4px 0; }
+summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>Trivy Report</h1>
+<h2>Summary</h2>
+<table>
+<tr><th>Target</th>{{ range $.Severities }}<th style="color: {{ severityColor . }}">{{ . }}</th>{{ end }}</tr>
+{{- range .Results }}
+{{- $count := severityCount . }}
+<tr><td>{{ .Target }}</td>{{ range $.Severities }}<td class="count">{{ index $count . }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- range .Results }}
+<h2>{{ .Target }}</h2>
+{{- if not .Vulnerabilities }}
+<p>No vulnerabilities</p>
+{{- end }}
+{{- range .Vulnerabilities }}
+<details>
+<summary><span style="color: {{ severityColor .Severity }}">{{ .Severity }}</span> {{ .VulnerabilityID }} {{ .PkgName }} {{ .InstalledVersion }}{{ with .FixedVersion }} (fixed in {{ . }}){{ end }}</summary>
+{{- with .Title }}
+<p><b>{{ . }}</b></p>
+{{- end }}
+{{- with .Description }}
+<p>{{ . }}</p>
+{{- end }}
+<ul>
+{{- with cveURL .VulnerabilityID }}
+<li><a href="{{ . }}">{{ . }}</a></li>
+{{- end }}
+{{- range .References }}
+<li><a href="{{ . }}">{{ . }}</a></li>
+{{- end }}
+</ul>
+</details>
+{{- end }}
+{{- end }}
+</body>
+</html>
+`))
+
+// HTMLWriter writes a standalone HTML page for the readers of the reports who don't read JSON
+type HTMLWriter struct {
+	Output io.Writer
+}
+
+func (hw HTMLWriter) Write(results Results) error {
+	// the highest severities first
+	var severities []string
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		severities = append(severities, dbTypes.SeverityNames[i])
+	}
+	data := struct {
+		Severities []string
+		Results    Results
+	}{Severities: severities, Results: results}
+	if err := htmlTemplate.Execute(hw.Output, data); err != nil {
+		return xerrors.Errorf("failed to write the HTML report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestHTMLWriter_Write(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Vulnerability: dbTypes.Vulnerability{
+						Title:      "openssl: Segmentation fault in SSL_check_chain",
+						Severity:   "HIGH",
+						References: []string{"https://www.openssl.org/news/secadv/20200421.txt"},
+					},
+				},
+				{
+					VulnerabilityID:  "CVE-2020-1971",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					Vulnerability: dbTypes.Vulnerability{
+						Description: "A NULL pointer dereference with <EDIPartyName>",
+						Severity:    "HIGH",
+					},
+				},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, report.HTMLWriter{Output: &buf}.Write(results))
+	got := buf.String()
+
+	// the summary, with the highest severities first
+	assert.Contains(t, got, `<tr><th>Target</th><th style="color: #d32f2f">CRITICAL</th><th style="color: #c2185b">HIGH</th>`+
+		`<th style="color: #f57c00">MEDIUM</th><th style="color: #1976d2">LOW</th><th style="color: #0097a7">UNKNOWN</th></tr>`)
+	assert.Contains(t, got, `<tr><td>alpine:3.11 (alpine 3.11.5)</td><td class="count">0</td><td class="count">2</td>`+
+		`<td class="count">0</td><td class="count">0</td><td class="count">0</td></tr>`)
+	assert.Contains(t, got, `<tr><td>app/package-lock.json</td><td class="count">0</td><td class="count">0</td>`+
+		`<td class="count">0</td><td class="count">0</td><td class="count">0</td></tr>`)
+
+	// the details
+	assert.Contains(t, got, `<details>
+<summary><span style="color: #c2185b">HIGH</span> CVE-2020-1967 openssl 1.1.1d-r3 (fixed in 1.1.1g-r0)</summary>
+<p><b>openssl: Segmentation fault in SSL_check_chain</b></p>
+<ul>
+<li><a href="https://nvd.nist.gov/vuln/detail/CVE-2020-1967">https://nvd.nist.gov/vuln/detail/CVE-2020-1967</a></li>
+<li><a href="https://www.openssl.org/news/secadv/20200421.txt">https://www.openssl.org/news/secadv/20200421.txt</a></li>
+</ul>
+</details>`)
+	assert.Contains(t, got, `<p>A NULL pointer dereference with &lt;EDIPartyName&gt;</p>`)
+	assert.Contains(t, got, "<h2>app/package-lock.json</h2>\n<p>No vulnerabilities</p>")
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// UnknownInstruction is the group of findings without the created_by command of their layer
+const UnknownInstruction = "unknown instruction"
+
+var (
+	// e.g. "|2 VERSION=1.0 USER=app /bin/sh -c make" for RUN with build arguments
+	buildArgsRegexp = regexp.MustCompile(`^\|\d+(?:\s+\S+=\S*)*\s+`)
+	shellPrefixes   = []string{"/bin/sh -c ", "/bin/bash -c ", "cmd /S /C "}
+)
+
+// ParseCreatedBy returns the Dockerfile instruction of a created_by command in the image history, e.g.
+//
+//	/bin/sh -c #(nop) ADD file:a1b2 in /      => ADD file:a1b2 in /
+//	/bin/sh -c apk add --no-cache curl        => RUN apk add --no-cache curl
+//	RUN /bin/sh -c apk add curl # buildkit    => RUN apk add curl
+//	COPY . /app # buildkit                    => COPY . /app
+func ParseCreatedBy(createdBy string) string {
+	cmd := strings.TrimSpace(createdBy)
+	// BuildKit records the instruction itself followed by a comment
+	cmd = strings.TrimSpace(strings.TrimSuffix(cmd, "# buildkit"))
+	cmd = strings.TrimPrefix(cmd, "RUN ")
+	cmd = buildArgsRegexp.ReplaceAllString(cmd, "")
+
+	shell := false
+	for _, prefix := range shellPrefixes {
+		if strings.HasPrefix(cmd, prefix) {
+			cmd = strings.TrimSpace(strings.TrimPrefix(cmd, prefix))
+			shell = true
+			break
+		}
+	}
+
+	if strings.HasPrefix(cmd, "#(nop)") {
+		return strings.Join(strings.Fields(strings.TrimPrefix(cmd, "#(nop)")), " ")
+	}
+	if shell || strings.HasPrefix(createdBy, "RUN ") {
+		return "RUN " + cmd
+	}
+	return cmd
+}
+
+// InstructionGroup is the vulnerabilities introduced by a Dockerfile instruction
+type InstructionGroup struct {
+	Instruction string
+	Findings    []TopFinding
+}
+
+// NewInstructionGroups groups the vulnerabilities by the instruction creating their layer
+// in the order the instructions first appear
+func NewInstructionGroups(results Results) []InstructionGroup {
+	var groups []InstructionGroup
+	index := map[string]int{}
+	for _, f := range flattenFindings(results) {
+		instruction := UnknownInstruction
+		if f.LayerCreatedBy != "" {
+			instruction = ParseCreatedBy(f.LayerCreatedBy)
+		}
+		i, ok := index[instruction]
+		if !ok {
+			i = len(groups)
+			index[instruction] = i
+			groups = append(groups, InstructionGroup{Instruction: instruction})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+	return groups
+}
+
+func writeInstructionGroups(output io.Writer, groups []InstructionGroup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	fmt.Fprintf(output, "\nVulnerabilities per Dockerfile instruction\n")
+	for _, g := range groups {
+		var ids []string
+		for _, f := range g.Findings {
+			ids = append(ids, f.VulnerabilityID)
+		}
+		fmt.Fprintf(output, "%s introduced %d vulnerabilities: %s\n", g.Instruction, len(g.Findings), strings.Join(ids, ", "))
+	}
+}
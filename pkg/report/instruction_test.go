@@ -0,0 +1,74 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestParseCreatedBy(t *testing.T) {
+	tests := []struct {
+		createdBy string
+		want      string
+	}{
+		{createdBy: "/bin/sh -c #(nop) ADD file:a1b2 in / ", want: "ADD file:a1b2 in /"},
+		{createdBy: `/bin/sh -c #(nop)  CMD ["/bin/sh"]`, want: `CMD ["/bin/sh"]`},
+		{createdBy: "/bin/sh -c apk add --no-cache curl", want: "RUN apk add --no-cache curl"},
+		{createdBy: "|1 VERSION=1.0 /bin/sh -c make install", want: "RUN make install"},
+		{createdBy: "RUN /bin/sh -c apk add curl # buildkit", want: "RUN apk add curl"},
+		{createdBy: "RUN |2 A=1 B=2 /bin/sh -c make # buildkit", want: "RUN make"},
+		{createdBy: "COPY . /app # buildkit", want: "COPY . /app"},
+		{createdBy: "WORKDIR /app", want: "WORKDIR /app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.createdBy, func(t *testing.T) {
+			assert.Equal(t, tt.want, report.ParseCreatedBy(tt.createdBy))
+		})
+	}
+}
+
+func TestNewInstructionGroups(t *testing.T) {
+	vuln := func(id, createdBy string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{VulnerabilityID: id, PkgName: "pkg", LayerCreatedBy: createdBy}
+	}
+	apkAdd := "/bin/sh -c apk add --no-cache curl"
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				vuln("CVE-2020-1967", "/bin/sh -c #(nop) ADD file:a1b2 in / "),
+				vuln("CVE-2020-8169", apkAdd),
+				vuln("CVE-2020-8177", apkAdd),
+				vuln("CVE-2020-8231", apkAdd),
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				// the same layer built by BuildKit
+				vuln("CVE-2020-8285", "RUN /bin/sh -c apk add --no-cache curl # buildkit"),
+				vuln("CVE-2019-11358", ""),
+			},
+		},
+	}
+
+	var got []string
+	for _, g := range report.NewInstructionGroups(results) {
+		got = append(got, g.Instruction)
+	}
+	assert.Equal(t, []string{"ADD file:a1b2 in /", "RUN apk add --no-cache curl", report.UnknownInstruction}, got)
+
+	output := bytes.Buffer{}
+	tw := report.TableWriter{Output: &output, Light: true, ByInstruction: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Contains(t, output.String(), `
+Vulnerabilities per Dockerfile instruction
+ADD file:a1b2 in / introduced 1 vulnerabilities: CVE-2020-1967
+RUN apk add --no-cache curl introduced 4 vulnerabilities: CVE-2020-8169, CVE-2020-8177, CVE-2020-8231, CVE-2020-8285
+unknown instruction introduced 1 vulnerabilities: CVE-2019-11358
+`)
+}
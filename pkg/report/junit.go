@@ -0,0 +1,29 @@
+package report
+
+// junitTemplate writes a test suite per result and a failed test case per vulnerability, e.g. for the test reports
+// of Jenkins and GitLab
+const junitTemplate = `<?xml version="1.0" ?>
+<testsuites>
+{{- range . }}
+{{- $failures := len .Vulnerabilities }}
+    <testsuite tests="{{ $failures }}" failures="{{ $failures }}" name="{{ escapeXML .Target }}" errors="0" skipped="0" time="">
+    {{- if not (eq .Type "") }}
+        <properties>
+            <property name="type" value="{{ escapeXML .Type }}"></property>
+        </properties>
+    {{- end -}}
+    {{ range .Vulnerabilities }}
+        <testcase classname="{{ escapeXML .PkgName }}-{{ escapeXML .InstalledVersion }}" name="[{{ .Severity }}] {{ escapeXML .VulnerabilityID }}" time="">
+            <failure message="{{ escapeXML .Title }}" type="description">{{ escapeXML .Description }}{{ with cveURL .VulnerabilityID }}
+{{ . }}{{ end }}</failure>
+        </testcase>
+    {{- end }}
+    </testsuite>
+{{- end }}
+</testsuites>
+`
+
+// builtinTemplates are the templates given by name instead of their content, e.g. --template junit
+var builtinTemplates = map[string]string{
+	"junit": junitTemplate,
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/cenkalti/backoff"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// KafkaFindingIDHeader is the header of the Kafka messages with the FindingID of the finding
+const KafkaFindingIDHeader = "FindingID"
+
+// KafkaProducer sends messages to Kafka, e.g. a sarama.SyncProducer
+type KafkaProducer interface {
+	SendMessages(msgs []*sarama.ProducerMessage) error
+}
+
+// NewKafkaProducer connects a producer to the brokers, e.g. "localhost:9092"
+func NewKafkaProducer(brokers []string) (sarama.SyncProducer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V0_11_0_0 // for the headers
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to connect to the Kafka brokers %v: %w", brokers, err)
+	}
+	return producer, nil
+}
+
+// newKafkaProducer connects the producer of the kafka format, replaced in tests
+var newKafkaProducer = func(brokers []string) (KafkaProducer, error) {
+	return NewKafkaProducer(brokers)
+}
+
+// KafkaWriter publishes a JSON message of WebhookFinding per finding to the Kafka topic.
+// The messages are keyed by ImageDigest so that the findings of an image go to the same partition,
+// and the FindingID of the finding is in the KafkaFindingIDHeader header.
+// Findings are sent in batches of BatchSize; a BatchSize of zero sends all findings at once.
+type KafkaWriter struct {
+	Producer    KafkaProducer
+	Topic       string
+	ImageDigest string
+	BatchSize   int
+
+	// MaxRetries is the number of retries of the messages of a batch failing e.g. with unavailable brokers
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+func (kw KafkaWriter) Write(results Results) error {
+	var batch []*sarama.ProducerMessage
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			msg, err := kw.message(WebhookFinding{Target: result.Target, Type: result.Type, DetectedVulnerability: vuln})
+			if err != nil {
+				return err
+			}
+			batch = append(batch, msg)
+			if kw.BatchSize > 0 && len(batch) >= kw.BatchSize {
+				if err = kw.send(batch); err != nil {
+					return err
+				}
+				batch = nil
+			}
+		}
+	}
+
+	// final flush
+	if len(batch) > 0 {
+		return kw.send(batch)
+	}
+	return nil
+}
+
+func (kw KafkaWriter) message(finding WebhookFinding) (*sarama.ProducerMessage, error) {
+	value, err := json.Marshal(finding)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to marshal the finding: %w", err)
+	}
+	return &sarama.ProducerMessage{
+		Topic: kw.Topic,
+		Key:   sarama.StringEncoder(kw.ImageDigest),
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(KafkaFindingIDHeader), Value: []byte(finding.FindingID)},
+		},
+	}, nil
+}
+
+// send sends the messages, retrying only the failed ones
+func (kw KafkaWriter) send(msgs []*sarama.ProducerMessage) error {
+	pending := msgs
+	operation := func() error {
+		err := kw.Producer.SendMessages(pending)
+		if errs, ok := err.(sarama.ProducerErrors); ok {
+			pending = nil
+			for _, e := range errs {
+				pending = append(pending, e.Msg)
+			}
+		}
+		return err
+	}
+
+	if err := backoff.RetryNotify(operation, kw.backOff(), func(err error, _ time.Duration) {
+		log.Logger.Warn(err)
+		log.Logger.Infof("Retrying %d Kafka messages...", len(pending))
+	}); err != nil {
+		return xerrors.Errorf("failed to publish %d findings to the Kafka topic %s: %w", len(pending), kw.Topic, err)
+	}
+	return nil
+}
+
+func (kw KafkaWriter) backOff() backoff.BackOff {
+	retries := kw.MaxRetries
+	if retries == 0 {
+		retries = defaultWebhookRetries
+	}
+	interval := defaultWebhookRetryInterval
+	if kw.RetryInterval > 0 {
+		interval = kw.RetryInterval
+	}
+	return backoff.WithMaxRetries(utils.NewJitteredBackOff(interval, 0, nil), uint64(retries))
+}
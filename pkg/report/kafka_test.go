@@ -0,0 +1,163 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// fakeKafkaProducer captures the sent messages. The first failures calls fail with err,
+// only for the first message when err is sarama.ProducerErrors.
+type fakeKafkaProducer struct {
+	failures int
+	err      error
+	calls    [][]*sarama.ProducerMessage
+	sent     []*sarama.ProducerMessage
+}
+
+func (p *fakeKafkaProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.calls = append(p.calls, msgs)
+	if len(p.calls) <= p.failures {
+		if _, ok := p.err.(sarama.ProducerErrors); ok {
+			p.sent = append(p.sent, msgs[1:]...)
+			return sarama.ProducerErrors{{Msg: msgs[0], Err: sarama.ErrNotLeaderForPartition}}
+		}
+		return p.err
+	}
+	p.sent = append(p.sent, msgs...)
+	return nil
+}
+
+func TestKafkaWriter_Write(t *testing.T) {
+	results := Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", FindingID: "1f0f6e13"},
+				{VulnerabilityID: "CVE-2020-8169", PkgName: "curl", FindingID: "5c1d2b9a"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", FindingID: "9e2a7c40"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		batchSize int
+		producer  *fakeKafkaProducer
+		wantCalls []int
+		wantErr   string
+	}{
+		{
+			name:      "all findings at once",
+			producer:  &fakeKafkaProducer{},
+			wantCalls: []int{3},
+		},
+		{
+			name:      "batches",
+			batchSize: 2,
+			producer:  &fakeKafkaProducer{},
+			wantCalls: []int{2, 1},
+		},
+		{
+			name:      "brokers unavailable then recovered",
+			producer:  &fakeKafkaProducer{failures: 2, err: sarama.ErrOutOfBrokers},
+			wantCalls: []int{3, 3, 3},
+		},
+		{
+			name:      "failed message retried alone",
+			producer:  &fakeKafkaProducer{failures: 1, err: sarama.ProducerErrors{}},
+			wantCalls: []int{3, 1},
+		},
+		{
+			name:     "brokers unavailable",
+			producer: &fakeKafkaProducer{failures: 10, err: sarama.ErrOutOfBrokers},
+			// the first try and 3 retries
+			wantCalls: []int{3, 3, 3, 3},
+			wantErr:   "failed to publish 3 findings to the Kafka topic findings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kw := KafkaWriter{
+				Producer:      tt.producer,
+				Topic:         "findings",
+				ImageDigest:   "sha256:a187dde48cd289ac374ad8539930628314bc581a481cdb41409c9289419ddb72",
+				BatchSize:     tt.batchSize,
+				RetryInterval: time.Millisecond,
+			}
+			err := kw.Write(results)
+
+			var calls []int
+			for _, c := range tt.producer.calls {
+				calls = append(calls, len(c))
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var ids []string
+			for _, msg := range tt.producer.sent {
+				assert.Equal(t, "findings", msg.Topic)
+				assert.Equal(t, sarama.StringEncoder(kw.ImageDigest), msg.Key)
+				require.Len(t, msg.Headers, 1)
+				assert.Equal(t, KafkaFindingIDHeader, string(msg.Headers[0].Key))
+
+				value, err := msg.Value.Encode()
+				require.NoError(t, err)
+				var finding WebhookFinding
+				require.NoError(t, json.Unmarshal(value, &finding))
+				assert.Equal(t, string(msg.Headers[0].Value), finding.FindingID)
+				ids = append(ids, finding.FindingID)
+			}
+			assert.ElementsMatch(t, []string{"1f0f6e13", "5c1d2b9a", "9e2a7c40"}, ids)
+		})
+	}
+}
+
+func TestNewWriter_Kafka(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	var gotBrokers []string
+	defer func(f func([]string) (KafkaProducer, error)) { newKafkaProducer = f }(newKafkaProducer)
+	newKafkaProducer = func(brokers []string) (KafkaProducer, error) {
+		gotBrokers = brokers
+		return producer, nil
+	}
+
+	writer, err := NewWriter(Option{Format: "kafka", KafkaBrokers: []string{"localhost:9092"}, KafkaTopic: "findings",
+		ImageID: "sha256:a187dde48cd289ac374ad8539930628314bc581a481cdb41409c9289419ddb72"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost:9092"}, gotBrokers)
+
+	err = writer.Write(Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", FindingID: "1f0f6e13"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, producer.sent, 1)
+	assert.Equal(t, "findings", producer.sent[0].Topic)
+	assert.Equal(t, sarama.StringEncoder("sha256:a187dde48cd289ac374ad8539930628314bc581a481cdb41409c9289419ddb72"),
+		producer.sent[0].Key)
+}
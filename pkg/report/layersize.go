@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// VulnerableLayer is the size of a layer with vulnerabilities.
+// Size is zero when the size of the layer is unknown.
+type VulnerableLayer struct {
+	DiffID   string
+	Size     int64
+	Findings int
+}
+
+// NewVulnerableLayers returns the layers introducing vulnerabilities in the order they first appear.
+// Vulnerabilities without layer information aren't attributed to any layer.
+func NewVulnerableLayers(results Results) []VulnerableLayer {
+	var layers []VulnerableLayer
+	index := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			diffID := vuln.Layer.DiffID
+			if diffID == "" {
+				continue
+			}
+			i, ok := index[diffID]
+			if !ok {
+				i = len(layers)
+				index[diffID] = i
+				layers = append(layers, VulnerableLayer{DiffID: diffID})
+			}
+			if vuln.LayerSize > 0 {
+				layers[i].Size = vuln.LayerSize
+			}
+			layers[i].Findings++
+		}
+	}
+	return layers
+}
+
+// VulnerableBytes returns the total size of the layers and whether the sizes of some layers are unknown
+func VulnerableBytes(layers []VulnerableLayer) (total int64, unknown bool) {
+	for _, l := range layers {
+		if l.Size == 0 {
+			unknown = true
+		}
+		total += l.Size
+	}
+	return total, unknown
+}
+
+func writeVulnerableLayers(output io.Writer, layers []VulnerableLayer) {
+	if len(layers) == 0 {
+		return
+	}
+
+	total, unknown := VulnerableBytes(layers)
+	fmt.Fprintf(output, "\nVulnerable bytes: %d", total)
+	if unknown {
+		fmt.Fprint(output, " (some layer sizes are unknown)")
+	}
+	fmt.Fprintln(output)
+
+	table := tablewriter.NewWriter(output)
+	table.SetHeader([]string{"Layer", "Size", "Findings"})
+	for _, l := range layers {
+		size := "-"
+		if l.Size > 0 {
+			size = strconv.FormatInt(l.Size, 10)
+		}
+		table.Append([]string{l.DiffID, size, strconv.Itoa(l.Findings)})
+	}
+	table.Render()
+}
@@ -0,0 +1,80 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+// withLayerSizes copies the results setting the layer sizes of the findings
+func withLayerSizes(results report.Results, sizes map[string]int64) report.Results {
+	var copied report.Results
+	for _, result := range results {
+		r := result
+		r.Vulnerabilities = append(r.Vulnerabilities[:0:0], result.Vulnerabilities...)
+		for i, vuln := range r.Vulnerabilities {
+			r.Vulnerabilities[i].LayerSize = sizes[vuln.Layer.DiffID]
+		}
+		copied = append(copied, r)
+	}
+	return copied
+}
+
+func TestNewVulnerableLayers(t *testing.T) {
+	tests := []struct {
+		name        string
+		sizes       map[string]int64
+		want        []report.VulnerableLayer
+		wantTotal   int64
+		wantUnknown bool
+	}{
+		{
+			name:  "all sizes",
+			sizes: map[string]int64{"sha256:base": 5599651, "sha256:curl": 1479789},
+			want: []report.VulnerableLayer{
+				{DiffID: "sha256:base", Size: 5599651, Findings: 2},
+				{DiffID: "sha256:curl", Size: 1479789, Findings: 3},
+			},
+			wantTotal: 7079440,
+		},
+		{
+			name:  "missing size",
+			sizes: map[string]int64{"sha256:base": 5599651},
+			want: []report.VulnerableLayer{
+				{DiffID: "sha256:base", Size: 5599651, Findings: 2},
+				{DiffID: "sha256:curl", Findings: 3},
+			},
+			wantTotal:   5599651,
+			wantUnknown: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := report.NewVulnerableLayers(withLayerSizes(multiLayerResults, tt.sizes))
+			assert.Equal(t, tt.want, got)
+
+			total, unknown := report.VulnerableBytes(got)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantUnknown, unknown)
+		})
+	}
+}
+
+func TestTableWriter_VulnerableBytes(t *testing.T) {
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true, VulnerableBytes: true}
+	results := withLayerSizes(multiLayerResults, map[string]int64{"sha256:base": 5599651})
+	assert.NoError(t, tw.Write(results))
+	assert.Contains(t, tableWritten.String(), `
+Vulnerable bytes: 5599651 (some layer sizes are unknown)
++-------------+---------+----------+
+|    LAYER    |  SIZE   | FINDINGS |
++-------------+---------+----------+
+| sha256:base | 5599651 |        2 |
+| sha256:curl | -       |        3 |
++-------------+---------+----------+
+`)
+}
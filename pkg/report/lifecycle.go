@@ -0,0 +1,71 @@
+package report
+
+import "time"
+
+// HistoricalScan is the results of a past scan, e.g. loaded from its JSON output
+type HistoricalScan struct {
+	ScannedAt time.Time
+	Results   Results
+}
+
+// FindingLifecycle is when a finding, identified by target, package and vulnerability ID as by NewDelta,
+// was present across scans.
+// A finding reappearing after being resolved has a period per appearance.
+type FindingLifecycle struct {
+	Target          string
+	PkgName         string
+	VulnerabilityID string
+	Periods         []FindingPeriod
+}
+
+// FindingPeriod is from the first scan a finding appears in to the first scan without it.
+// Resolved is nil while the finding is present in the latest scan.
+type FindingPeriod struct {
+	Introduced time.Time
+	Resolved   *time.Time
+}
+
+// Open returns whether the finding is present in the latest scan
+func (l FindingLifecycle) Open() bool {
+	return len(l.Periods) > 0 && l.Periods[len(l.Periods)-1].Resolved == nil
+}
+
+// NewFindingLifecycles computes the lifecycle of each finding across the scans ordered from the oldest.
+// The lifecycles are ordered by the first appearance of their finding.
+func NewFindingLifecycles(scans []HistoricalScan) []FindingLifecycle {
+	var lifecycles []FindingLifecycle
+	index := map[string]int{}
+	for _, scan := range scans {
+		present := map[string]struct{}{}
+		for _, f := range keyedFindings(scan.Results) {
+			key := f.key
+			if _, ok := present[key]; ok {
+				continue
+			}
+			present[key] = struct{}{}
+
+			i, ok := index[key]
+			if !ok {
+				i = len(lifecycles)
+				index[key] = i
+				lifecycles = append(lifecycles, FindingLifecycle{
+					Target:          f.Target,
+					PkgName:         f.PkgName,
+					VulnerabilityID: f.VulnerabilityID,
+				})
+			}
+			if !lifecycles[i].Open() {
+				lifecycles[i].Periods = append(lifecycles[i].Periods, FindingPeriod{Introduced: scan.ScannedAt})
+			}
+		}
+
+		for key, i := range index {
+			if _, ok := present[key]; ok || !lifecycles[i].Open() {
+				continue
+			}
+			resolved := scan.ScannedAt
+			lifecycles[i].Periods[len(lifecycles[i].Periods)-1].Resolved = &resolved
+		}
+	}
+	return lifecycles
+}
@@ -0,0 +1,60 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestNewFindingLifecycles(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2020, 4, d, 0, 0, 0, 0, time.UTC)
+	}
+	dayPtr := func(d int) *time.Time {
+		t := day(d)
+		return &t
+	}
+	scan := func(d int, ids ...string) report.HistoricalScan {
+		var vulns []types.DetectedVulnerability
+		for _, id := range ids {
+			vulns = append(vulns, types.DetectedVulnerability{VulnerabilityID: id, PkgName: "openssl"})
+		}
+		return report.HistoricalScan{
+			ScannedAt: day(d),
+			Results:   report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: vulns}},
+		}
+	}
+
+	got := report.NewFindingLifecycles([]report.HistoricalScan{
+		scan(1, "CVE-2020-0001", "CVE-2020-0002"),
+		scan(2, "CVE-2020-0002", "CVE-2020-0003"),
+		scan(3, "CVE-2020-0001", "CVE-2020-0003"),
+	})
+
+	target := "alpine:3.11 (alpine 3.11.5)"
+	want := []report.FindingLifecycle{
+		{
+			Target: target, PkgName: "openssl", VulnerabilityID: "CVE-2020-0001",
+			// reappeared after being resolved
+			Periods: []report.FindingPeriod{
+				{Introduced: day(1), Resolved: dayPtr(2)},
+				{Introduced: day(3)},
+			},
+		},
+		{
+			Target: target, PkgName: "openssl", VulnerabilityID: "CVE-2020-0002",
+			Periods: []report.FindingPeriod{{Introduced: day(1), Resolved: dayPtr(3)}},
+		},
+		{
+			Target: target, PkgName: "openssl", VulnerabilityID: "CVE-2020-0003",
+			Periods: []report.FindingPeriod{{Introduced: day(2)}},
+		},
+	}
+	assert.Equal(t, want, got)
+	assert.True(t, got[0].Open())
+	assert.False(t, got[1].Open())
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// OSVSchemaVersion is the version of the OSV schema written by OSVWriter
+const OSVSchemaVersion = "1.2.0"
+
+// osvEcosystems maps a result type to the OSV ecosystem of its packages
+var osvEcosystems = map[string]string{
+	"npm":      "npm",
+	"yarn":     "npm",
+	"bundler":  "RubyGems",
+	"pipenv":   "PyPI",
+	"poetry":   "PyPI",
+	"cargo":    "crates.io",
+	"composer": "Packagist",
+	"jar":      "Maven",
+	"alpine":   "Alpine",
+	"debian":   "Debian",
+	"ubuntu":   "Ubuntu",
+}
+
+// OSVRecord is a vulnerability in the OSV schema (https://ossf.github.io/osv-schema/)
+type OSVRecord struct {
+	SchemaVersion    string               `json:"schema_version"`
+	ID               string               `json:"id"`
+	Modified         string               `json:"modified"`
+	Aliases          []string             `json:"aliases,omitempty"`
+	Summary          string               `json:"summary,omitempty"`
+	Details          string               `json:"details,omitempty"`
+	Affected         []OSVAffected        `json:"affected"`
+	References       []OSVReference       `json:"references,omitempty"`
+	DatabaseSpecific *OSVDatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+type OSVAffected struct {
+	Package  OSVPackage `json:"package"`
+	Ranges   []OSVRange `json:"ranges,omitempty"`
+	Versions []string   `json:"versions,omitempty"`
+}
+
+type OSVPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type OSVRange struct {
+	Type   string     `json:"type"`
+	Events []OSVEvent `json:"events"`
+}
+
+type OSVEvent struct {
+	Introduced string `json:"introduced,omitempty"`
+	Fixed      string `json:"fixed,omitempty"`
+}
+
+type OSVReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// OSVDatabaseSpecific has the severity as the OSV severity field requires a CVSS vector
+type OSVDatabaseSpecific struct {
+	Severity string `json:"severity,omitempty"`
+}
+
+// OSVWriter writes the vulnerabilities as OSV records.
+// A vulnerability found in several packages is a single record affecting all of them.
+type OSVWriter struct {
+	Output io.Writer
+	// Modified is the modified time of the records; the current time is used when it is zero
+	Modified time.Time
+}
+
+func (ow OSVWriter) Write(results Results) error {
+	modified := ow.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	output, err := json.MarshalIndent(struct {
+		Vulns []OSVRecord `json:"vulns"`
+	}{Vulns: NewOSVRecords(results, modified)}, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal OSV records: %w", err)
+	}
+	if _, err = fmt.Fprint(ow.Output, string(output)); err != nil {
+		return xerrors.Errorf("failed to write OSV records: %w", err)
+	}
+	return nil
+}
+
+// NewOSVRecords converts the vulnerabilities into OSV records in the order they first appear
+func NewOSVRecords(results Results, modified time.Time) []OSVRecord {
+	records := []OSVRecord{}
+	index := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			i, ok := index[vuln.VulnerabilityID]
+			if !ok {
+				i = len(records)
+				index[vuln.VulnerabilityID] = i
+				records = append(records, newOSVRecord(vuln, modified))
+			}
+			records[i].Affected = append(records[i].Affected, newOSVAffected(result.Type, vuln))
+		}
+	}
+	return records
+}
+
+func newOSVRecord(vuln types.DetectedVulnerability, modified time.Time) OSVRecord {
+	record := OSVRecord{
+		SchemaVersion: OSVSchemaVersion,
+		ID:            vuln.VulnerabilityID,
+		Modified:      modified.UTC().Format(time.RFC3339),
+		Summary:       vuln.Title,
+		Details:       vuln.Description,
+	}
+	for _, ref := range vuln.References {
+		record.References = append(record.References, OSVReference{Type: "WEB", URL: ref})
+		// e.g. https://nvd.nist.gov/vuln/detail/CVE-2019-11358 for NSWG-ECO-428
+		if alias := cveAlias(ref); alias != "" && alias != vuln.VulnerabilityID && !utils.StringInSlice(alias, record.Aliases) {
+			record.Aliases = append(record.Aliases, alias)
+		}
+	}
+	if vuln.Severity != "" {
+		record.DatabaseSpecific = &OSVDatabaseSpecific{Severity: vuln.Severity}
+	}
+	return record
+}
+
+func newOSVAffected(resultType string, vuln types.DetectedVulnerability) OSVAffected {
+	ecosystem, ok := osvEcosystems[resultType]
+	if !ok {
+		ecosystem = resultType
+	}
+
+	affected := OSVAffected{
+		Package:  OSVPackage{Ecosystem: ecosystem, Name: vuln.PkgName},
+		Versions: []string{vuln.InstalledVersion},
+	}
+	// a range can't be expressed with constraints like ">= 3.4.0" or multiple fixed versions
+	if vuln.FixedVersion != "" && !strings.ContainsAny(vuln.FixedVersion, "<>=~^, ") {
+		affected.Ranges = []OSVRange{
+			{
+				Type:   "ECOSYSTEM",
+				Events: []OSVEvent{{Introduced: "0"}, {Fixed: vuln.FixedVersion}},
+			},
+		}
+	}
+	return affected
+}
+
+// cveAlias returns the CVE ID at the end of a reference URL
+func cveAlias(ref string) string {
+	i := strings.LastIndex(ref, "CVE-")
+	if i < 0 {
+		return ""
+	}
+	id := ref[i:]
+	if j := strings.IndexAny(id, "/?#&.:"); j >= 0 {
+		id = id[:j]
+	}
+	parts := strings.Split(id, "-")
+	if len(parts) != 3 || len(parts[1]) != 4 || len(parts[2]) < 4 || !isDigits(parts[1]) || !isDigits(parts[2]) {
+		return ""
+	}
+	return id
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,134 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestOSVWriter_Write(t *testing.T) {
+	modified := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "NSWG-ECO-428",
+					PkgName:          "jquery",
+					InstalledVersion: "3.3.1",
+					FixedVersion:     "3.4.0",
+					Vulnerability: dbTypes.Vulnerability{
+						Title:    "prototype pollution",
+						Severity: "MEDIUM",
+						References: []string{
+							"https://nvd.nist.gov/vuln/detail/CVE-2019-11358",
+						},
+					},
+				},
+				{
+					VulnerabilityID:  "CVE-2020-7598",
+					PkgName:          "minimist",
+					InstalledVersion: "1.2.0",
+					FixedVersion:     ">= 1.2.3, < 2.0.0",
+					Vulnerability: dbTypes.Vulnerability{
+						Severity: "MEDIUM",
+					},
+				},
+			},
+		},
+		{
+			Target: "app/yarn.lock",
+			Type:   "yarn",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "NSWG-ECO-428",
+					PkgName:          "jquery",
+					InstalledVersion: "3.2.0",
+					FixedVersion:     "3.4.0",
+					Vulnerability: dbTypes.Vulnerability{
+						Severity: "MEDIUM",
+					},
+				},
+			},
+		},
+	}
+
+	want := []report.OSVRecord{
+		{
+			SchemaVersion: report.OSVSchemaVersion,
+			ID:            "NSWG-ECO-428",
+			Modified:      "2020-03-01T12:00:00Z",
+			Aliases:       []string{"CVE-2019-11358"},
+			Summary:       "prototype pollution",
+			Affected: []report.OSVAffected{
+				{
+					Package: report.OSVPackage{Ecosystem: "npm", Name: "jquery"},
+					Ranges: []report.OSVRange{
+						{Type: "ECOSYSTEM", Events: []report.OSVEvent{{Introduced: "0"}, {Fixed: "3.4.0"}}},
+					},
+					Versions: []string{"3.3.1"},
+				},
+				{
+					Package: report.OSVPackage{Ecosystem: "npm", Name: "jquery"},
+					Ranges: []report.OSVRange{
+						{Type: "ECOSYSTEM", Events: []report.OSVEvent{{Introduced: "0"}, {Fixed: "3.4.0"}}},
+					},
+					Versions: []string{"3.2.0"},
+				},
+			},
+			References: []report.OSVReference{
+				{Type: "WEB", URL: "https://nvd.nist.gov/vuln/detail/CVE-2019-11358"},
+			},
+			DatabaseSpecific: &report.OSVDatabaseSpecific{Severity: "MEDIUM"},
+		},
+		{
+			SchemaVersion: report.OSVSchemaVersion,
+			ID:            "CVE-2020-7598",
+			Modified:      "2020-03-01T12:00:00Z",
+			Affected: []report.OSVAffected{
+				{
+					Package:  report.OSVPackage{Ecosystem: "npm", Name: "minimist"},
+					Versions: []string{"1.2.0"},
+				},
+			},
+			DatabaseSpecific: &report.OSVDatabaseSpecific{Severity: "MEDIUM"},
+		},
+	}
+
+	output := bytes.Buffer{}
+	writer := report.OSVWriter{Output: &output, Modified: modified}
+	require.NoError(t, writer.Write(results))
+
+	var got struct {
+		Vulns []report.OSVRecord `json:"vulns"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &got))
+	assert.Equal(t, want, got.Vulns)
+
+	// the fields required by the OSV schema
+	var raw struct {
+		Vulns []map[string]interface{} `json:"vulns"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &raw))
+	for _, record := range raw.Vulns {
+		for _, field := range []string{"schema_version", "id", "modified", "affected"} {
+			assert.Contains(t, record, field)
+		}
+	}
+}
+
+func TestOSVWriter_WriteEmpty(t *testing.T) {
+	output := bytes.Buffer{}
+	writer := report.OSVWriter{Output: &output}
+	require.NoError(t, writer.Write(report.Results{{Target: "alpine:3.11"}}))
+	assert.JSONEq(t, `{"vulns": []}`, output.String())
+}
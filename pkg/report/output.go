@@ -0,0 +1,61 @@
+package report
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// CreateOutput creates the output file of the path, gzipped when it ends with .gz, e.g. report.json.gz.
+// The writes are buffered, and the output must be closed to flush them, closing it again does nothing.
+func CreateOutput(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create an output file: %w", err)
+	}
+	o := &outputFile{file: f}
+	if strings.HasSuffix(path, ".gz") {
+		o.gzip = gzip.NewWriter(f)
+		o.buf = bufio.NewWriter(o.gzip)
+	} else {
+		o.buf = bufio.NewWriter(f)
+	}
+	return o, nil
+}
+
+type outputFile struct {
+	file   *os.File
+	gzip   *gzip.Writer
+	buf    *bufio.Writer
+	closed bool
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	return o.buf.Write(p)
+}
+
+// Close flushes the writes and closes the file, even when the flush fails
+func (o *outputFile) Close() error {
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+
+	err := o.buf.Flush()
+	if o.gzip != nil {
+		if gerr := o.gzip.Close(); err == nil {
+			err = gerr
+		}
+	}
+	if ferr := o.file.Close(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to write the output file: %w", err)
+	}
+	return nil
+}
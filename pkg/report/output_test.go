@@ -0,0 +1,60 @@
+package report_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+func TestCreateOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	results := report.Results{{Target: "app/package-lock.json", Type: "npm"}}
+	write := func(path string) {
+		output, err := report.CreateOutput(path)
+		require.NoError(t, err)
+		require.NoError(t, report.JsonWriter{Output: output}.Write(results))
+		require.NoError(t, output.Close())
+		require.NoError(t, output.Close(), "closing again does nothing")
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		path := filepath.Join(dir, "report.json")
+		write(path)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		got, err := report.ReadResults(f)
+		require.NoError(t, err)
+		assert.Equal(t, results, got)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		path := filepath.Join(dir, "report.json.gz")
+		write(path)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		got, err := report.ReadResults(gr)
+		require.NoError(t, err)
+		assert.Equal(t, results, got)
+	})
+
+	t.Run("unknown directory", func(t *testing.T) {
+		_, err := report.CreateOutput(filepath.Join(dir, "unknown", "report.json"))
+		assert.Error(t, err)
+	})
+}
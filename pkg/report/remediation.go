@@ -0,0 +1,94 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// NoFixAvailable is the action of the findings without a fixed version
+const NoFixAvailable = "no fix available"
+
+// upgradeCommands maps a result type to the command upgrading a package of it
+var upgradeCommands = map[string]string{
+	"alpine":                       "apk add --upgrade %s",
+	"debian":                       "apt-get install --only-upgrade %s",
+	"ubuntu":                       "apt-get install --only-upgrade %s",
+	"redhat":                       "yum update %s",
+	"centos":                       "yum update %s",
+	"amazon":                       "yum update %s",
+	"oracle":                       "yum update %s",
+	"photon":                       "tdnf update %s",
+	"opensuse.leap":                "zypper update %s",
+	"suse linux enterprise server": "zypper update %s",
+	"bundler":                      "bundle update %s",
+	"cargo":                        "cargo update -p %s",
+	"npm":                          "npm update %s",
+	"yarn":                         "yarn upgrade %s",
+	"pipenv":                       "pipenv update %s",
+	"poetry":                       "poetry update %s",
+	"composer":                     "composer update %s",
+}
+
+// installCommands maps a result type to the command installing a given version of a package of it
+var installCommands = map[string]string{
+	"npm":      "npm install %s@%s",
+	"yarn":     "yarn upgrade %s@%s",
+	"pipenv":   "pipenv install %s==%s",
+	"poetry":   "poetry add %s@%s",
+	"composer": "composer require %s:%s",
+}
+
+// Remediation returns the command fixing the vulnerability, or NoFixAvailable without a fixed version.
+// The packages of an OS are upgraded to the latest version, so one command fixes all their vulnerabilities;
+// a library is installed at the fixed version when the ecosystem allows it and the fixed version is a single one.
+func Remediation(resultType string, vuln types.DetectedVulnerability) string {
+	if vuln.FixedVersion == "" {
+		return NoFixAvailable
+	}
+	if format, ok := installCommands[resultType]; ok && !strings.ContainsAny(vuln.FixedVersion, "<>=~^, ") {
+		return fmt.Sprintf(format, vuln.PkgName, vuln.FixedVersion)
+	}
+	if format, ok := upgradeCommands[resultType]; ok {
+		return fmt.Sprintf(format, vuln.PkgName)
+	}
+	return fmt.Sprintf("upgrade %s to %s", vuln.PkgName, vuln.FixedVersion)
+}
+
+// RemediationGroup is the findings fixed by a single action
+type RemediationGroup struct {
+	Action   string
+	Findings []TopFinding
+}
+
+// GroupByRemediation groups the findings by their remediation, the actions fixing the most findings first
+// and the findings without a fix last
+func GroupByRemediation(results Results) []RemediationGroup {
+	var groups []RemediationGroup
+	index := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			action := Remediation(result.Type, vuln)
+			i, ok := index[action]
+			if !ok {
+				i = len(groups)
+				index[action] = i
+				groups = append(groups, RemediationGroup{Action: action})
+			}
+			groups[i].Findings = append(groups[i].Findings, TopFinding{Target: result.Target, DetectedVulnerability: vuln})
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Action == NoFixAvailable) != (groups[j].Action == NoFixAvailable) {
+			return groups[j].Action == NoFixAvailable
+		}
+		if len(groups[i].Findings) != len(groups[j].Findings) {
+			return len(groups[i].Findings) > len(groups[j].Findings)
+		}
+		return groups[i].Action < groups[j].Action
+	})
+	return groups
+}
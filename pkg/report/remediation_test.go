@@ -0,0 +1,59 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestGroupByRemediation(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "debian:10 (debian 10.3)",
+			Type:   "debian",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "libssl1.1", FixedVersion: "1.1.1d-0+deb10u3"},
+				{VulnerabilityID: "CVE-2020-0002", PkgName: "libssl1.1", FixedVersion: "1.1.1d-0+deb10u3"},
+				{VulnerabilityID: "CVE-2020-0003", PkgName: "libssl1.1", FixedVersion: "1.1.1g-1"},
+				{VulnerabilityID: "CVE-2020-0004", PkgName: "libc6", FixedVersion: "2.28-10+deb10u1"},
+				{VulnerabilityID: "CVE-2020-0005", PkgName: "libc6"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0006", PkgName: "lodash", FixedVersion: "4.17.19"},
+				{VulnerabilityID: "CVE-2020-0007", PkgName: "jquery", FixedVersion: ">=3.5.0"},
+			},
+		},
+	}
+
+	groups := report.GroupByRemediation(results)
+	got := map[string][]string{}
+	var actions []string
+	for _, group := range groups {
+		actions = append(actions, group.Action)
+		for _, f := range group.Findings {
+			got[group.Action] = append(got[group.Action], f.VulnerabilityID)
+		}
+	}
+	assert.Equal(t, []string{
+		"apt-get install --only-upgrade libssl1.1",
+		"apt-get install --only-upgrade libc6",
+		"npm install lodash@4.17.19",
+		"npm update jquery",
+		report.NoFixAvailable,
+	}, actions)
+	assert.Equal(t, map[string][]string{
+		"apt-get install --only-upgrade libssl1.1": {"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003"},
+		"apt-get install --only-upgrade libc6":     {"CVE-2020-0004"},
+		"npm install lodash@4.17.19":               {"CVE-2020-0006"},
+		"npm update jquery":                        {"CVE-2020-0007"},
+		report.NoFixAvailable:                      {"CVE-2020-0005"},
+	}, got)
+	assert.Equal(t, "debian:10 (debian 10.3)", groups[0].Findings[0].Target)
+}
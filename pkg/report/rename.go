@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// renamedResult is a result whose findings are marshaled with renamed fields
+type renamedResult struct {
+	Result
+	Vulnerabilities []json.RawMessage `json:"Vulnerabilities"`
+}
+
+// renameFindingFields renames the fields of the findings of the result
+func renameFindingFields(result Result, names map[string]string) (renamedResult, error) {
+	r := renamedResult{Result: result}
+	for _, vuln := range result.Vulnerabilities {
+		b, err := json.Marshal(vuln)
+		if err != nil {
+			return renamedResult{}, xerrors.Errorf("failed to marshal %s: %w", vuln.VulnerabilityID, err)
+		}
+		if b, err = renameFields(b, names); err != nil {
+			return renamedResult{}, xerrors.Errorf("failed to rename the fields of %s: %w", vuln.VulnerabilityID, err)
+		}
+		r.Vulnerabilities = append(r.Vulnerabilities, b)
+	}
+	return r, nil
+}
+
+// renameFields renames the top-level keys of a JSON object, keeping their order
+func renameFields(object []byte, names map[string]string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; dec.More(); i++ {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, xerrors.Errorf("unexpected token: %v", token)
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
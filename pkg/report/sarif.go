@@ -0,0 +1,192 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	SARIFVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// SARIFRootPath is the base of the locations of the lock files, the root of the scanned repository or filesystem
+	SARIFRootPath = "ROOTPATH"
+)
+
+// sarifLevels maps a severity to the SARIF level of its results; the others are notes
+var sarifLevels = map[string]string{
+	"CRITICAL": "error",
+	"HIGH":     "error",
+	"MEDIUM":   "warning",
+}
+
+// SARIFLog is a SARIF 2.1.0 log (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html)
+// with a single run, e.g. for the GitHub code scanning
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a vulnerability; its results are the packages it is found in
+type SARIFRule struct {
+	ID               string            `json:"id"`
+	ShortDescription *SARIFMessage     `json:"shortDescription,omitempty"`
+	FullDescription  *SARIFMessage     `json:"fullDescription,omitempty"`
+	HelpURI          string            `json:"helpUri,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type SARIFResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    SARIFMessage      `json:"message"`
+	Locations  []SARIFLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// SARIFWriter writes the vulnerabilities as a SARIF log.
+// The layer introducing a vulnerability is in the layerDigest and layerDiffID properties of its result.
+// The lock files are located relative to SARIFRootPath, e.g. node-app/package-lock.json, for the GitHub code scanning
+// to find them in the repository.
+type SARIFWriter struct {
+	Output io.Writer
+	// Version is the version of Trivy in the tool of the run
+	Version string
+}
+
+func (sw SARIFWriter) Write(results Results) error {
+	output, err := json.MarshalIndent(NewSARIFLog(results, sw.Version), "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal SARIF: %w", err)
+	}
+	if _, err = fmt.Fprint(sw.Output, string(output)); err != nil {
+		return xerrors.Errorf("failed to write SARIF: %w", err)
+	}
+	return nil
+}
+
+// NewSARIFLog converts the vulnerabilities into SARIF results with a rule per vulnerability in the order they first appear
+func NewSARIFLog(results Results, version string) SARIFLog {
+	run := SARIFRun{
+		Tool: SARIFTool{
+			Driver: SARIFDriver{
+				Name:           "Trivy",
+				Version:        version,
+				InformationURI: "https://github.com/aquasecurity/trivy",
+				Rules:          []SARIFRule{},
+			},
+		},
+		Results: []SARIFResult{},
+	}
+	index := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			i, ok := index[vuln.VulnerabilityID]
+			if !ok {
+				i = len(run.Tool.Driver.Rules)
+				index[vuln.VulnerabilityID] = i
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(vuln))
+			}
+			run.Results = append(run.Results, newSARIFResult(newSARIFArtifactLocation(result), i, vuln))
+		}
+	}
+	return SARIFLog{Version: SARIFVersion, Schema: sarifSchema, Runs: []SARIFRun{run}}
+}
+
+func newSARIFRule(vuln types.DetectedVulnerability) SARIFRule {
+	rule := SARIFRule{ID: vuln.VulnerabilityID}
+	if vuln.Title != "" {
+		rule.ShortDescription = &SARIFMessage{Text: vuln.Title}
+	}
+	if vuln.Description != "" {
+		rule.FullDescription = &SARIFMessage{Text: vuln.Description}
+	}
+	if len(vuln.References) > 0 {
+		rule.HelpURI = vuln.References[0]
+	}
+	if vuln.Severity != "" {
+		rule.Properties = map[string]string{"severity": vuln.Severity}
+	}
+	return rule
+}
+
+func newSARIFArtifactLocation(result Result) SARIFArtifactLocation {
+	if result.Class != ClassLangPkgs {
+		return SARIFArtifactLocation{URI: result.Target}
+	}
+	return SARIFArtifactLocation{
+		URI:       strings.TrimLeft(strings.TrimPrefix(filepath.ToSlash(result.Target), "./"), "/"),
+		URIBaseID: SARIFRootPath,
+	}
+}
+
+func newSARIFResult(location SARIFArtifactLocation, ruleIndex int, vuln types.DetectedVulnerability) SARIFResult {
+	level, ok := sarifLevels[vuln.Severity]
+	if !ok {
+		level = "note"
+	}
+
+	text := fmt.Sprintf("Package: %s\nInstalled Version: %s\nVulnerability: %s\nSeverity: %s\nFixed Version: %s",
+		vuln.PkgName, vuln.InstalledVersion, vuln.VulnerabilityID, vuln.Severity, vuln.FixedVersion)
+	result := SARIFResult{
+		RuleID:    vuln.VulnerabilityID,
+		RuleIndex: ruleIndex,
+		Level:     level,
+		Message:   SARIFMessage{Text: text},
+		Locations: []SARIFLocation{
+			{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: location}},
+		},
+	}
+	if vuln.Layer.Digest != "" || vuln.Layer.DiffID != "" {
+		result.Properties = map[string]string{}
+		if vuln.Layer.Digest != "" {
+			result.Properties["layerDigest"] = vuln.Layer.Digest
+		}
+		if vuln.Layer.DiffID != "" {
+			result.Properties["layerDiffID"] = vuln.Layer.DiffID
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares the output with the golden file, which is written instead with -update.
+// The JSON outputs are compared as JSON.
+func assertGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	if *update {
+		require.NoError(t, ioutil.WriteFile(golden, got, 0644))
+	}
+	want, err := ioutil.ReadFile(golden)
+	require.NoError(t, err)
+	if json.Valid(want) {
+		assert.JSONEq(t, string(want), string(got))
+		return
+	}
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestSARIFWriter_Write(t *testing.T) {
+	openssl := types.DetectedVulnerability{
+		VulnerabilityID:  "CVE-2020-1967",
+		PkgName:          "openssl",
+		InstalledVersion: "1.1.1d-r3",
+		FixedVersion:     "1.1.1g-r0",
+		Layer: ftypes.Layer{
+			Digest: "sha256:cbdbe7a5bc2a134ca8ec91be58565ec07d037386d1f1d8385412d224deafca08",
+			DiffID: "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10",
+		},
+		Vulnerability: dbTypes.Vulnerability{
+			Title:      "openssl: Segmentation fault in SSL_check_chain",
+			Severity:   "HIGH",
+			References: []string{"https://nvd.nist.gov/vuln/detail/CVE-2020-1967"},
+		},
+	}
+	results := report.Results{
+		{
+			Target:          "alpine:3.11 (alpine 3.11.3)",
+			Type:            "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{openssl},
+		},
+		{
+			Target: "/node-app/package-lock.json",
+			Type:   "npm",
+			Class:  report.ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "NSWG-ECO-428",
+					PkgName:          "jquery",
+					InstalledVersion: "3.3.9",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+				},
+				{
+					VulnerabilityID:  "CVE-2020-0001",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.4",
+				},
+			},
+		},
+		{
+			Target:          "libssl (alpine 3.11.3)",
+			Type:            "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{openssl},
+		},
+	}
+
+	written := bytes.Buffer{}
+	require.NoError(t, report.SARIFWriter{Output: &written, Version: "0.2.0"}.Write(results))
+
+	// the golden file is written with -update and reviewed against the SARIF 2.1.0 specification
+	assertGolden(t, "testdata/sarif.json.golden", written.Bytes())
+
+	var got report.SARIFLog
+	require.NoError(t, json.Unmarshal(written.Bytes(), &got))
+	require.Len(t, got.Runs, 1)
+	run := got.Runs[0]
+	assert.Equal(t, "2.1.0", got.Version)
+	assert.Equal(t, "0.2.0", run.Tool.Driver.Version)
+
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	assert.Equal(t, []string{"CVE-2020-1967", "NSWG-ECO-428", "CVE-2020-0001"}, ruleIDs)
+
+	require.Len(t, run.Results, 4)
+	assert.Equal(t, report.SARIFResult{
+		RuleID:    "CVE-2020-1967",
+		RuleIndex: 0,
+		Level:     "error",
+		Message: report.SARIFMessage{
+			Text: "Package: openssl\nInstalled Version: 1.1.1d-r3\nVulnerability: CVE-2020-1967\nSeverity: HIGH\nFixed Version: 1.1.1g-r0",
+		},
+		Locations: []report.SARIFLocation{
+			{PhysicalLocation: report.SARIFPhysicalLocation{
+				ArtifactLocation: report.SARIFArtifactLocation{URI: "alpine:3.11 (alpine 3.11.3)"},
+			}},
+		},
+		Properties: map[string]string{
+			"layerDigest": "sha256:cbdbe7a5bc2a134ca8ec91be58565ec07d037386d1f1d8385412d224deafca08",
+			"layerDiffID": "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10",
+		},
+	}, run.Results[0])
+
+	var levels []string
+	for _, result := range run.Results {
+		levels = append(levels, result.Level)
+	}
+	assert.Equal(t, []string{"error", "warning", "note", "error"}, levels)
+	assert.Equal(t, 0, run.Results[3].RuleIndex)
+	assert.Equal(t, report.SARIFArtifactLocation{URI: "node-app/package-lock.json", URIBaseID: "ROOTPATH"},
+		run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation)
+}
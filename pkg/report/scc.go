@@ -0,0 +1,145 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const (
+	sccCategoryOS       = "OS_VULNERABILITY"
+	sccCategorySoftware = "SOFTWARE_VULNERABILITY"
+	sccSeverityUnknown  = "SEVERITY_UNSPECIFIED"
+)
+
+// sccSeverities maps a severity to the SCC one; the others are SEVERITY_UNSPECIFIED
+var sccSeverities = map[string]string{
+	"CRITICAL": "CRITICAL",
+	"HIGH":     "HIGH",
+	"MEDIUM":   "MEDIUM",
+	"LOW":      "LOW",
+}
+
+// SCCFinding is a finding of the Google Cloud Security Command Center
+// (https://cloud.google.com/security-command-center/docs/reference/rest/v1/organizations.sources.findings)
+type SCCFinding struct {
+	Name             string            `json:"name"`
+	Parent           string            `json:"parent"`
+	ResourceName     string            `json:"resourceName"`
+	State            string            `json:"state"`
+	Category         string            `json:"category"`
+	ExternalURI      string            `json:"externalUri,omitempty"`
+	SourceProperties map[string]string `json:"sourceProperties,omitempty"`
+	EventTime        string            `json:"eventTime"`
+	Severity         string            `json:"severity"`
+	FindingClass     string            `json:"findingClass"`
+	Vulnerability    *SCCVulnerability `json:"vulnerability,omitempty"`
+}
+
+type SCCVulnerability struct {
+	CVE SCCCVE `json:"cve"`
+}
+
+type SCCCVE struct {
+	ID string `json:"id"`
+}
+
+// SCCWriter writes the vulnerabilities as SCC findings of the Source, e.g. "organizations/123/sources/456",
+// found in the ResourceName, e.g. the full resource name of the image in Artifact Registry.
+// The finding IDs are the same across scans of the same target.
+type SCCWriter struct {
+	Output       io.Writer
+	Source       string
+	ResourceName string
+	// EventTime is the time the findings were detected; the current time is used when it is zero
+	EventTime time.Time
+}
+
+func (sw SCCWriter) Write(results Results) error {
+	findings, err := sw.findings(results)
+	if err != nil {
+		return err
+	}
+	output, err := json.MarshalIndent(struct {
+		Findings []SCCFinding `json:"findings"`
+	}{Findings: findings}, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal SCC findings: %w", err)
+	}
+	if _, err = fmt.Fprint(sw.Output, string(output)); err != nil {
+		return xerrors.Errorf("failed to write SCC findings: %w", err)
+	}
+	return nil
+}
+
+func (sw SCCWriter) findings(results Results) ([]SCCFinding, error) {
+	if sw.Source == "" {
+		return nil, xerrors.New("the SCC source is required")
+	} else if sw.ResourceName == "" {
+		return nil, xerrors.New("the SCC resource name is required")
+	}
+
+	eventTime := sw.EventTime
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	findings := []SCCFinding{}
+	for _, result := range results {
+		category := sccCategorySoftware
+		if result.Class == ClassOSPkgs {
+			category = sccCategoryOS
+		}
+		for _, vuln := range result.Vulnerabilities {
+			findings = append(findings, sw.finding(result.Target, category, eventTime, vuln))
+		}
+	}
+	return findings, nil
+}
+
+func (sw SCCWriter) finding(target, category string, eventTime time.Time, vuln types.DetectedVulnerability) SCCFinding {
+	severity, ok := sccSeverities[vuln.Severity]
+	if !ok {
+		severity = sccSeverityUnknown
+	}
+
+	finding := SCCFinding{
+		Name:         sw.Source + "/findings/" + sccFindingID(target, vuln),
+		Parent:       sw.Source,
+		ResourceName: sw.ResourceName,
+		State:        "ACTIVE",
+		Category:     category,
+		SourceProperties: map[string]string{
+			"target":           target,
+			"packageName":      vuln.PkgName,
+			"installedVersion": vuln.InstalledVersion,
+			"fixedVersion":     vuln.FixedVersion,
+			"vulnerabilityId":  vuln.VulnerabilityID,
+		},
+		EventTime:    eventTime.UTC().Format(time.RFC3339),
+		Severity:     severity,
+		FindingClass: "VULNERABILITY",
+	}
+	if len(vuln.References) > 0 {
+		finding.ExternalURI = vuln.References[0]
+	}
+	if strings.HasPrefix(vuln.VulnerabilityID, "CVE-") {
+		finding.Vulnerability = &SCCVulnerability{CVE: SCCCVE{ID: vuln.VulnerabilityID}}
+	}
+	return finding
+}
+
+// sccFindingID is alphanumeric of 32 characters as required by SCC
+func sccFindingID(target string, vuln types.DetectedVulnerability) string {
+	key := strings.Join([]string{target, vuln.PkgName, vuln.InstalledVersion, vuln.VulnerabilityID}, "\x00")
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:16])
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestSCCWriter_Write(t *testing.T) {
+	results := Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Class:  ClassOSPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Vulnerability: dbTypes.Vulnerability{
+						Severity:   "HIGH",
+						References: []string{"https://www.openssl.org/news/secadv/20200421.txt"},
+					},
+				},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Class:  ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "NSWG-ECO-516", PkgName: "lodash", InstalledVersion: "4.17.4"},
+			},
+		},
+	}
+	eventTime := time.Date(2020, 4, 21, 12, 0, 0, 0, time.UTC)
+
+	t.Run("findings", func(t *testing.T) {
+		var buf bytes.Buffer
+		sw := SCCWriter{
+			Output:       &buf,
+			Source:       "organizations/123/sources/456",
+			ResourceName: "//artifactregistry.googleapis.com/projects/p/locations/us/repositories/r/dockerImages/alpine",
+			EventTime:    eventTime,
+		}
+		require.NoError(t, sw.Write(results))
+
+		var got struct {
+			Findings []SCCFinding `json:"findings"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Len(t, got.Findings, 2)
+
+		f := got.Findings[0]
+		assert.Regexp(t, `^organizations/123/sources/456/findings/[0-9a-f]{32}$`, f.Name)
+		assert.Equal(t, "organizations/123/sources/456", f.Parent)
+		assert.Equal(t, sw.ResourceName, f.ResourceName)
+		assert.Equal(t, "ACTIVE", f.State)
+		assert.Equal(t, "OS_VULNERABILITY", f.Category)
+		assert.Equal(t, "HIGH", f.Severity)
+		assert.Equal(t, "VULNERABILITY", f.FindingClass)
+		assert.Equal(t, "2020-04-21T12:00:00Z", f.EventTime)
+		assert.Equal(t, "https://www.openssl.org/news/secadv/20200421.txt", f.ExternalURI)
+		assert.Equal(t, &SCCVulnerability{CVE: SCCCVE{ID: "CVE-2020-1967"}}, f.Vulnerability)
+		assert.Equal(t, "openssl", f.SourceProperties["packageName"])
+
+		f = got.Findings[1]
+		assert.Equal(t, "SOFTWARE_VULNERABILITY", f.Category)
+		assert.Equal(t, "SEVERITY_UNSPECIFIED", f.Severity)
+		assert.Nil(t, f.Vulnerability)
+		assert.NotEqual(t, got.Findings[0].Name, f.Name)
+	})
+
+	t.Run("severity mapping", func(t *testing.T) {
+		for severity, want := range map[string]string{
+			"CRITICAL": "CRITICAL",
+			"HIGH":     "HIGH",
+			"MEDIUM":   "MEDIUM",
+			"LOW":      "LOW",
+			"UNKNOWN":  "SEVERITY_UNSPECIFIED",
+		} {
+			sw := SCCWriter{Source: "organizations/123/sources/456", ResourceName: "image"}
+			f := sw.finding("alpine:3.11", sccCategoryOS, eventTime, types.DetectedVulnerability{
+				VulnerabilityID: "CVE-2020-1967",
+				Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+			})
+			assert.Equal(t, want, f.Severity, severity)
+		}
+	})
+
+	t.Run("format", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer, err := NewWriter(Option{Format: "scc", Output: &buf, ArtifactName: "alpine:3.11",
+			SCCSource: "organizations/123/sources/456"})
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(results))
+
+		var got struct {
+			Findings []SCCFinding `json:"findings"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Len(t, got.Findings, 2)
+		assert.Equal(t, "organizations/123/sources/456", got.Findings[0].Parent)
+		assert.Equal(t, "alpine:3.11", got.Findings[0].ResourceName, "the resource is the scanned artifact")
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := SCCWriter{Output: &bytes.Buffer{}, ResourceName: "image"}.Write(results)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the SCC source is required")
+	})
+}
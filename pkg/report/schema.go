@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// SchemaVersion is the version of the report written by JSONWriter. It is bumped on breaking changes of the schema.
+const SchemaVersion = 1
+
+// Report is the top-level object written by JSONWriter
+type Report struct {
+	SchemaVersion    int               `json:"SchemaVersion"`
+	ArtifactName     string            `json:"ArtifactName,omitempty"`
+	ArtifactMetadata *ArtifactMetadata `json:"ArtifactMetadata,omitempty"`
+	Results          Results           `json:"Results"`
+}
+
+// ArtifactMetadata describes the scanned artifact and the scan itself, so that a report can be audited on its own.
+// The fields unknown for the artifact, e.g. the repo tags of a filesystem, are omitted.
+type ArtifactMetadata struct {
+	ImageID     string     `json:",omitempty"`
+	RepoTags    []string   `json:",omitempty"`
+	RepoDigests []string   `json:",omitempty"`
+	Created     *time.Time `json:",omitempty"`
+	// OS is the detected OS, and EOSL is true when it is no longer supported by the distribution
+	OS   *ftypes.OS `json:",omitempty"`
+	EOSL bool       `json:",omitempty"`
+
+	ScannerVersion string      `json:",omitempty"`
+	DB             *DBMetadata `json:",omitempty"`
+}
+
+// DBMetadata is the version of the vulnerability DB used for the scan
+type DBMetadata struct {
+	Version    int
+	UpdatedAt  time.Time
+	NextUpdate time.Time
+}
+
+// JSONWriter writes the results in a Report so that parsers can pin its SchemaVersion,
+// unlike JsonWriter writing the bare results. ArtifactName and Metadata are optional.
+// The results are streamed one at a time, as by JsonWriter.
+type JSONWriter struct {
+	Output       io.Writer
+	ArtifactName string
+	Metadata     *ArtifactMetadata
+}
+
+func (jw JSONWriter) Write(results Results) error {
+	r := Report{
+		SchemaVersion:    SchemaVersion,
+		ArtifactName:     jw.ArtifactName,
+		ArtifactMetadata: jw.Metadata,
+	}
+	output, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("failed to marshal json: %w", err)
+	}
+
+	// the results, last in the report, are streamed in place of null
+	header := bytes.TrimSuffix(output, []byte("null\n}"))
+	if _, err = jw.Output.Write(header); err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
+	}
+	if err = writeJSONResults(jw.Output, "  ", results, nil); err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
+	}
+	if _, err = io.WriteString(jw.Output, "\n}"); err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestJSONWriter(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.3)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1968",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Layer:            ftypes.Layer{DiffID: "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					Vulnerability: dbTypes.Vulnerability{
+						Title:    "openssl: Raccoon Attack",
+						Severity: "MEDIUM",
+					},
+				},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+		},
+	}
+
+	written := bytes.Buffer{}
+	require.NoError(t, report.JSONWriter{Output: &written}.Write(results))
+
+	var got report.Report
+	require.NoError(t, json.Unmarshal(written.Bytes(), &got))
+	assert.Equal(t, report.SchemaVersion, got.SchemaVersion)
+	assert.Equal(t, results, got.Results)
+}
+
+func TestJSONWriter_ArtifactMetadata(t *testing.T) {
+	created := time.Date(2020, 4, 23, 10, 15, 16, 0, time.UTC)
+	metadata := &report.ArtifactMetadata{
+		ImageID:        "sha256:a187dde48cd289ac374ad8539930628314bc581a481cdb41409c9289419ddb72",
+		RepoTags:       []string{"alpine:3.11"},
+		RepoDigests:    []string{"alpine@sha256:b276d875eeed9c7d3f1cfa7edb06b22ed22b14219a7d67c52c56612330348239"},
+		Created:        &created,
+		OS:             &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+		ScannerVersion: "0.9.1",
+		DB: &report.DBMetadata{
+			Version:    1,
+			UpdatedAt:  time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			NextUpdate: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	written := bytes.Buffer{}
+	require.NoError(t, report.JSONWriter{Output: &written, ArtifactName: "alpine:3.11", Metadata: metadata}.Write(nil))
+
+	var got report.Report
+	require.NoError(t, json.Unmarshal(written.Bytes(), &got))
+	assert.Equal(t, "alpine:3.11", got.ArtifactName)
+	assert.Equal(t, metadata, got.ArtifactMetadata)
+
+	// the unknown fields are omitted
+	assert.NotContains(t, written.String(), "EOSL")
+
+	written.Reset()
+	require.NoError(t, report.JSONWriter{Output: &written}.Write(nil))
+	assert.NotContains(t, written.String(), "ArtifactMetadata")
+}
+
+func TestJSONWriter_Stream(t *testing.T) {
+	results := report.Results{
+		{Target: "alpine:3.11 (alpine 3.11.3)", Type: "alpine", Packages: []types.InstalledPackage{{Name: "musl", Version: "1.1.24-r0"}}},
+		{Target: "app/package-lock.json", Type: "npm"},
+	}
+	want, err := json.MarshalIndent(report.Report{
+		SchemaVersion: report.SchemaVersion,
+		ArtifactName:  "alpine:3.11",
+		Results:       results,
+	}, "", "  ")
+	require.NoError(t, err)
+
+	var written bytes.Buffer
+	require.NoError(t, report.JSONWriter{Output: &written, ArtifactName: "alpine:3.11"}.Write(results))
+	assert.Equal(t, string(want), written.String(), "the streamed results are written as a whole")
+}
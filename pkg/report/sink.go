@@ -0,0 +1,104 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// The formats of the sinks of NewSink
+const (
+	SinkWebhook = "webhook"
+	SinkSlack   = "slack"
+	SinkJSON    = "json"
+)
+
+// SinkFormats are the formats of NewSink
+var SinkFormats = []string{SinkWebhook, SinkSlack, SinkJSON}
+
+// NewSink returns the writer pushing the results to the URL of a downstream system:
+// the findings of WebhookWriter, a message of SlackWriter or the report of JSONPostWriter.
+// The payloads are signed with the secret when it is set, see WebhookSignatureHeader.
+func NewSink(format, url, secret string) (Writer, error) {
+	switch format {
+	case SinkWebhook:
+		return WebhookWriter{URL: url, Secret: secret}, nil
+	case SinkSlack:
+		return SlackWriter{URL: url, Secret: secret}, nil
+	case SinkJSON:
+		return JSONPostWriter{URL: url, Secret: secret}, nil
+	}
+	return nil, xerrors.Errorf("unknown sink format %q, expected one of %s", format, strings.Join(SinkFormats, ", "))
+}
+
+// SlackWriter posts the number of vulnerabilities per severity and target to a Slack incoming webhook
+type SlackWriter struct {
+	URL    string
+	Client *http.Client
+	Secret string
+
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (sw SlackWriter) Write(results Results) error {
+	body, err := json.Marshal(slackMessage{Text: SlackSummary(results)})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal the Slack message: %w", err)
+	}
+	if err = postJSON(sw.Client, sw.URL, body, sw.Secret, retryBackOff(sw.MaxRetries, sw.RetryInterval, 0)); err != nil {
+		return xerrors.Errorf("failed to post to Slack: %w", err)
+	}
+	return nil
+}
+
+// SlackSummary renders the results in Slack markup, e.g.
+//
+//	*Trivy* found 3 vulnerabilities (1 CRITICAL, 2 HIGH)
+//	• `alpine:3.10 (alpine 3.10.4)`: 1 (1 CRITICAL)
+//	• `app/package-lock.json`: 2 (2 HIGH)
+func SlackSummary(results Results) string {
+	findings := flattenFindings(results)
+	if len(findings) == 0 {
+		return "*Trivy* found no vulnerabilities"
+	}
+
+	lines := []string{fmt.Sprintf("*Trivy* found %d vulnerabilities%s", len(findings), severityBreakdown(findings))}
+	for _, result := range results {
+		if len(result.Vulnerabilities) == 0 {
+			continue
+		}
+		targetFindings := flattenFindings(Results{result})
+		lines = append(lines, fmt.Sprintf("• `%s`: %d%s", result.Target, len(targetFindings), severityBreakdown(targetFindings)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSONPostWriter POSTs the Report of JSONWriter in a single request, for the systems ingesting the JSON reports
+type JSONPostWriter struct {
+	URL    string
+	Client *http.Client
+	Secret string
+
+	MaxRetries    int
+	RetryInterval time.Duration
+}
+
+func (jw JSONPostWriter) Write(results Results) error {
+	body, err := json.Marshal(Report{SchemaVersion: SchemaVersion, Results: results})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal json: %w", err)
+	}
+	if err = postJSON(jw.Client, jw.URL, body, jw.Secret, retryBackOff(jw.MaxRetries, jw.RetryInterval, 0)); err != nil {
+		return xerrors.Errorf("failed to post the report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package report_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestNewSink(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.4)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2020-1967", "openssl", "CRITICAL"),
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				deltaVuln("CVE-2019-11358", "jquery", "HIGH"),
+				deltaVuln("CVE-2020-7598", "minimist", "HIGH"),
+			},
+		},
+		{Target: "app/Gemfile.lock"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		secret   string
+		wantBody string
+		wantErr  string
+	}{
+		{
+			name:     "webhook",
+			format:   report.SinkWebhook,
+			wantBody: `"Findings":[`,
+		},
+		{
+			name:     "slack",
+			format:   report.SinkSlack,
+			wantBody: `{"text":"*Trivy* found 3 vulnerabilities (1 CRITICAL, 2 HIGH)\n• ` + "`alpine:3.10 (alpine 3.10.4)`" + `: 1 (1 CRITICAL)\n• ` + "`app/package-lock.json`" + `: 2 (2 HIGH)"}`,
+		},
+		{
+			name:     "signed json",
+			format:   report.SinkJSON,
+			secret:   "s3cr3t",
+			wantBody: `{"SchemaVersion":1,"Results":[`,
+		},
+		{
+			name:    "unknown format",
+			format:  "teams",
+			wantErr: `unknown sink format "teams"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			var signature string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				signature = r.Header.Get(report.WebhookSignatureHeader)
+				body, _ = ioutil.ReadAll(r.Body)
+			}))
+			defer ts.Close()
+
+			sink, err := report.NewSink(tt.format, ts.URL, tt.secret)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, sink.Write(results))
+
+			assert.Contains(t, string(body), tt.wantBody)
+			assert.True(t, json.Valid(body))
+			if tt.secret != "" {
+				assert.Equal(t, report.Sign(body, tt.secret), signature)
+			} else {
+				assert.Empty(t, signature)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac s3cr3t
+	assert.Equal(t, "sha256=608b0c406f3dda19702d71a048483b8c331283106d80a208e3cf43dbde505286", report.Sign([]byte("{}"), "s3cr3t"))
+}
+
+func TestSlackWriter_Retry(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	sw := report.SlackWriter{URL: ts.URL, RetryInterval: time.Millisecond}
+	require.NoError(t, sw.Write(nil))
+	assert.Equal(t, 2, requests)
+}
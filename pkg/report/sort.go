@@ -0,0 +1,51 @@
+package report
+
+import (
+	"sort"
+
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// SortEPSS orders the findings by descending EPSS probability, then by descending severity and then by vulnerability ID.
+// Findings without EPSS are sorted after the ones with it.
+const SortEPSS = "epss"
+
+// SortFindings sorts the findings of each result in the sort mode before they are written.
+// An empty mode keeps the order of the scan.
+func SortFindings(results Results, mode string) error {
+	var less func(vi, vj types.DetectedVulnerability) bool
+	switch mode {
+	case "":
+		return nil
+	case SortEPSS:
+		less = lessEPSS
+	default:
+		return xerrors.Errorf("unknown sort mode: %s", mode)
+	}
+
+	for _, result := range results {
+		vulns := result.Vulnerabilities
+		sort.SliceStable(vulns, func(i, j int) bool {
+			return less(vulns[i], vulns[j])
+		})
+	}
+	return nil
+}
+
+func lessEPSS(vi, vj types.DetectedVulnerability) bool {
+	switch {
+	case vi.EPSS != nil && vj.EPSS == nil:
+		return true
+	case vi.EPSS == nil && vj.EPSS != nil:
+		return false
+	case vi.EPSS != nil && *vi.EPSS != *vj.EPSS:
+		return *vi.EPSS > *vj.EPSS
+	}
+	if ret := dbTypes.CompareSeverityString(vj.Severity, vi.Severity); ret != 0 {
+		return ret > 0
+	}
+	return vi.VulnerabilityID < vj.VulnerabilityID
+}
@@ -0,0 +1,85 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestSortFindings(t *testing.T) {
+	epss := func(score float64) *float64 {
+		return &score
+	}
+	vuln := func(id, severity string, score *float64) types.DetectedVulnerability {
+		return types.DetectedVulnerability{
+			VulnerabilityID: id,
+			EPSS:            score,
+			Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "epss",
+			mode: report.SortEPSS,
+			want: []string{
+				"CVE-2020-0003", // 0.9 LOW
+				"CVE-2020-0005", // 0.2 CRITICAL
+				"CVE-2020-0001", // 0.2 HIGH
+				"CVE-2020-0006", // 0.2 HIGH
+				"CVE-2020-0004", // CRITICAL
+				"CVE-2020-0002", // MEDIUM
+			},
+		},
+		{
+			name: "no sort mode",
+			want: []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004", "CVE-2020-0005", "CVE-2020-0006"},
+		},
+		{
+			name:    "unknown sort mode",
+			mode:    "cvss",
+			wantErr: "unknown sort mode: cvss",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := report.Results{
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						vuln("CVE-2020-0001", "HIGH", epss(0.2)),
+						vuln("CVE-2020-0002", "MEDIUM", nil),
+						vuln("CVE-2020-0003", "LOW", epss(0.9)),
+						vuln("CVE-2020-0004", "CRITICAL", nil),
+						vuln("CVE-2020-0005", "CRITICAL", epss(0.2)),
+						vuln("CVE-2020-0006", "HIGH", epss(0.2)),
+					},
+				},
+			}
+
+			err := report.SortFindings(results, tt.mode)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for _, v := range results[0].Vulnerabilities {
+				got = append(got, v.VulnerabilityID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
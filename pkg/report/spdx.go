@@ -0,0 +1,273 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// SPDXVersion is the version of the SPDX specification written by SPDXWriter
+	SPDXVersion = "SPDX-2.3"
+
+	SPDXFormatJSON     = "json"
+	SPDXFormatTagValue = "tag-value"
+
+	spdxNoAssertion = "NOASSERTION"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxArtifactID  = "SPDXRef-Artifact"
+)
+
+// spdxSuppliers are the organizations distributing the OS packages of a result type
+var spdxSuppliers = map[string]string{
+	"alpine": "Alpine Linux",
+	"debian": "Debian",
+	"ubuntu": "Canonical",
+	"redhat": "Red Hat",
+	"centos": "CentOS",
+	"amazon": "Amazon Web Services",
+	"oracle": "Oracle",
+	"photon": "VMware",
+}
+
+// spdxLicenseExpression matches the licenses usable as SPDX license expressions, e.g. "MIT" or "GPL-2.0-only OR MIT"
+var spdxLicenseExpression = regexp.MustCompile(`^[A-Za-z0-9.+-]+( (AND|OR|WITH) [A-Za-z0-9.+-]+)*$`)
+
+// SPDXDocument is an SPDX document (https://spdx.github.io/spdx-spec/v2.3/), written in JSON or tag-value
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	Supplier         string            `json:"supplier"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	PrimaryPurpose   string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// SPDXWriter writes the packages of the results as an SPDX document describing the artifact,
+// which contains a package per target containing its packages. The packages without vulnerabilities
+// are only listed when the results have all the packages, with ScanOptions.ListAllPackages.
+type SPDXWriter struct {
+	Output io.Writer
+	// Format is SPDXFormatJSON or SPDXFormatTagValue; empty writes JSON
+	Format string
+	// ArtifactName is the name of the document and of the package of the artifact, e.g. the image name
+	ArtifactName string
+	// Timestamp is the time of the document; the current time is used when it is zero
+	Timestamp time.Time
+}
+
+func (sw SPDXWriter) Write(results Results) error {
+	timestamp := sw.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	doc := NewSPDXDocument(results, sw.ArtifactName, timestamp)
+
+	var output []byte
+	var err error
+	switch sw.Format {
+	case "", SPDXFormatJSON:
+		if output, err = json.MarshalIndent(doc, "", "  "); err != nil {
+			return xerrors.Errorf("failed to marshal the SPDX document: %w", err)
+		}
+	case SPDXFormatTagValue:
+		output = []byte(doc.TagValue())
+	default:
+		return xerrors.Errorf("unknown SPDX format: %s", sw.Format)
+	}
+	if _, err = sw.Output.Write(output); err != nil {
+		return xerrors.Errorf("failed to write the SPDX document: %w", err)
+	}
+	return nil
+}
+
+// NewSPDXDocument converts the results into a document with the packages in the order they first appear.
+// The namespace of the document is unique to the artifact and the timestamp.
+func NewSPDXDocument(results Results, artifactName string, timestamp time.Time) SPDXDocument {
+	if artifactName == "" {
+		artifactName = "trivy"
+	}
+	created := timestamp.UTC().Format(time.RFC3339)
+	doc := SPDXDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              artifactName,
+		DocumentNamespace: fmt.Sprintf("https://aquasecurity.github.io/trivy/spdx/%s-%s", spdxName(artifactName), spdxHash(artifactName, created)),
+		CreationInfo: SPDXCreationInfo{
+			Created:  created,
+			Creators: []string{"Organization: aquasecurity", "Tool: trivy"},
+		},
+		Packages: []SPDXPackage{{
+			SPDXID:           spdxArtifactID,
+			Name:             artifactName,
+			Supplier:         spdxNoAssertion,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+		}},
+		Relationships: []SPDXRelationship{{Element: spdxDocumentID, Type: "DESCRIBES", Related: spdxArtifactID}},
+	}
+
+	ids := map[string]struct{}{}
+	addPackage := func(pkg SPDXPackage) {
+		if _, ok := ids[pkg.SPDXID]; !ok {
+			ids[pkg.SPDXID] = struct{}{}
+			doc.Packages = append(doc.Packages, pkg)
+		}
+	}
+	relationships := map[SPDXRelationship]struct{}{}
+	contains := func(parent, child string) {
+		r := SPDXRelationship{Element: parent, Type: "CONTAINS", Related: child}
+		if _, ok := relationships[r]; !ok {
+			relationships[r] = struct{}{}
+			doc.Relationships = append(doc.Relationships, r)
+		}
+	}
+
+	for _, result := range results {
+		purpose := "APPLICATION"
+		if result.Class == ClassOSPkgs {
+			purpose = "OPERATING-SYSTEM"
+		}
+		target := SPDXPackage{
+			SPDXID:           "SPDXRef-Target-" + spdxHash(result.Target),
+			Name:             result.Target,
+			Supplier:         spdxNoAssertion,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			PrimaryPurpose:   purpose,
+		}
+		addPackage(target)
+		contains(spdxArtifactID, target.SPDXID)
+
+		// the packages first for their licenses
+		for _, p := range result.Packages {
+			pkg := newSPDXPackage(result.Type, p.Name, p.Version, p.License)
+			addPackage(pkg)
+			contains(target.SPDXID, pkg.SPDXID)
+		}
+		for _, vuln := range result.Vulnerabilities {
+			pkg := newSPDXPackage(result.Type, vuln.PkgName, vuln.InstalledVersion, "")
+			addPackage(pkg)
+			contains(target.SPDXID, pkg.SPDXID)
+		}
+	}
+	return doc
+}
+
+func newSPDXPackage(resultType, name, version, license string) SPDXPackage {
+	pkg := SPDXPackage{
+		SPDXID:           "SPDXRef-Package-" + spdxHash(resultType, name, version),
+		Name:             name,
+		VersionInfo:      version,
+		Supplier:         spdxNoAssertion,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		PrimaryPurpose:   "LIBRARY",
+	}
+	if supplier, ok := spdxSuppliers[resultType]; ok {
+		pkg.Supplier = "Organization: " + supplier
+	}
+	if spdxLicenseExpression.MatchString(license) {
+		pkg.LicenseDeclared = license
+	}
+	if purl := PackageURL(resultType, name, version); purl != "" {
+		pkg.ExternalRefs = []SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}}
+	}
+	return pkg
+}
+
+// TagValue renders the document in the tag-value format
+func (doc SPDXDocument) TagValue() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "SPDXVersion: %s\n", doc.SPDXVersion)
+	fmt.Fprintf(&b, "DataLicense: %s\n", doc.DataLicense)
+	fmt.Fprintf(&b, "SPDXID: %s\n", doc.SPDXID)
+	fmt.Fprintf(&b, "DocumentName: %s\n", doc.Name)
+	fmt.Fprintf(&b, "DocumentNamespace: %s\n", doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		fmt.Fprintf(&b, "Creator: %s\n", creator)
+	}
+	fmt.Fprintf(&b, "Created: %s\n", doc.CreationInfo.Created)
+
+	for _, pkg := range doc.Packages {
+		fmt.Fprintf(&b, "\n##### Package: %s\n\n", pkg.Name)
+		fmt.Fprintf(&b, "PackageName: %s\n", pkg.Name)
+		fmt.Fprintf(&b, "SPDXID: %s\n", pkg.SPDXID)
+		if pkg.VersionInfo != "" {
+			fmt.Fprintf(&b, "PackageVersion: %s\n", pkg.VersionInfo)
+		}
+		fmt.Fprintf(&b, "PackageSupplier: %s\n", pkg.Supplier)
+		fmt.Fprintf(&b, "PackageDownloadLocation: %s\n", pkg.DownloadLocation)
+		fmt.Fprintf(&b, "FilesAnalyzed: %t\n", pkg.FilesAnalyzed)
+		fmt.Fprintf(&b, "PackageLicenseConcluded: %s\n", pkg.LicenseConcluded)
+		fmt.Fprintf(&b, "PackageLicenseDeclared: %s\n", pkg.LicenseDeclared)
+		if pkg.PrimaryPurpose != "" {
+			fmt.Fprintf(&b, "PrimaryPackagePurpose: %s\n", pkg.PrimaryPurpose)
+		}
+		for _, ref := range pkg.ExternalRefs {
+			fmt.Fprintf(&b, "ExternalRef: %s %s %s\n", ref.ReferenceCategory, ref.ReferenceType, ref.ReferenceLocator)
+		}
+	}
+
+	b.WriteString("\n##### Relationships\n\n")
+	for _, r := range doc.Relationships {
+		fmt.Fprintf(&b, "Relationship: %s %s %s\n", r.Element, r.Type, r.Related)
+	}
+	return b.String()
+}
+
+// spdxHash returns a short hash of the values for the SPDX identifiers, which only allow letters, digits, "." and "-"
+func spdxHash(values ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// spdxNameReplacer replaces the characters of the image names not allowed in the path of the namespace
+var spdxNameReplacer = strings.NewReplacer("/", "-", ":", "-", "@", "-", " ", "-")
+
+func spdxName(name string) string {
+	return spdxNameReplacer.Replace(name)
+}
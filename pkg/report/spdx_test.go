@@ -0,0 +1,140 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func spdxResults() report.Results {
+	results := cycloneDXResults()
+	results[0].Class = report.ClassOSPkgs
+	results[0].Packages = []types.InstalledPackage{
+		{Name: "musl", Version: "1.1.24-r0", License: "MIT"},
+		{Name: "openssl", Version: "1.1.1d-r3", License: "OpenSSL"},
+	}
+	results[1].Class = report.ClassLangPkgs
+	results[1].Packages = []types.InstalledPackage{
+		{Name: "lodash", Version: "4.17.4", License: "MIT License, see LICENSE"},
+	}
+	return results
+}
+
+// validateSPDX checks the required fields and that the relationships refer to packages of the document
+func validateSPDX(t *testing.T, doc report.SPDXDocument) {
+	ids := map[string]struct{}{doc.SPDXID: {}}
+	for _, pkg := range doc.Packages {
+		require.Regexp(t, `^SPDXRef-[A-Za-z0-9.-]+$`, pkg.SPDXID)
+		require.NotEmpty(t, pkg.Name)
+		require.NotEmpty(t, pkg.Supplier)
+		require.NotEmpty(t, pkg.DownloadLocation)
+		require.NotEmpty(t, pkg.LicenseDeclared)
+		ids[pkg.SPDXID] = struct{}{}
+	}
+	assert.Len(t, ids, len(doc.Packages)+1, "unique SPDX identifiers")
+	for _, r := range doc.Relationships {
+		assert.Contains(t, ids, r.Element)
+		assert.Contains(t, ids, r.Related)
+	}
+}
+
+func TestSPDXWriter_Write(t *testing.T) {
+	timestamp := time.Date(2020, 4, 13, 18, 21, 39, 0, time.UTC)
+
+	t.Run("json", func(t *testing.T) {
+		written := bytes.Buffer{}
+		sw := report.SPDXWriter{Output: &written, ArtifactName: "myapp:1.0", Timestamp: timestamp}
+		require.NoError(t, sw.Write(spdxResults()))
+
+		var doc report.SPDXDocument
+		require.NoError(t, json.Unmarshal(written.Bytes(), &doc))
+		validateSPDX(t, doc)
+
+		assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+		assert.Equal(t, "myapp:1.0", doc.Name)
+		assert.Regexp(t, `^https://aquasecurity.github.io/trivy/spdx/myapp-1.0-[0-9a-f]{16}$`, doc.DocumentNamespace)
+		assert.Equal(t, "2020-04-13T18:21:39Z", doc.CreationInfo.Created)
+
+		var names []string
+		pkgs := map[string]report.SPDXPackage{}
+		for _, pkg := range doc.Packages {
+			names = append(names, pkg.Name)
+			pkgs[pkg.Name] = pkg
+		}
+		assert.Equal(t, []string{"myapp:1.0", "alpine:3.11 (alpine 3.11.3)", "musl", "openssl",
+			"app/package-lock.json", "lodash", "@types/lodash"}, names)
+
+		musl := pkgs["musl"]
+		assert.Equal(t, "1.1.24-r0", musl.VersionInfo)
+		assert.Equal(t, "Organization: Alpine Linux", musl.Supplier)
+		assert.Equal(t, "MIT", musl.LicenseDeclared)
+		assert.Equal(t, []report.SPDXExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl",
+			ReferenceLocator: "pkg:apk/alpine/musl@1.1.24-r0"}}, musl.ExternalRefs)
+		assert.Equal(t, "OPERATING-SYSTEM", pkgs["alpine:3.11 (alpine 3.11.3)"].PrimaryPurpose)
+
+		// free text isn't a license expression
+		assert.Equal(t, "NOASSERTION", pkgs["lodash"].LicenseDeclared)
+		assert.Equal(t, "NOASSERTION", pkgs["lodash"].Supplier)
+
+		assert.Contains(t, doc.Relationships, report.SPDXRelationship{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: "SPDXRef-Artifact"})
+		assert.Contains(t, doc.Relationships, report.SPDXRelationship{Element: "SPDXRef-Artifact", Type: "CONTAINS", Related: pkgs["app/package-lock.json"].SPDXID})
+		assert.Contains(t, doc.Relationships, report.SPDXRelationship{Element: pkgs["app/package-lock.json"].SPDXID, Type: "CONTAINS", Related: pkgs["@types/lodash"].SPDXID})
+		assert.Len(t, doc.Relationships, 1+2+4)
+	})
+
+	t.Run("tag-value", func(t *testing.T) {
+		written := bytes.Buffer{}
+		sw := report.SPDXWriter{Output: &written, Format: report.SPDXFormatTagValue, ArtifactName: "myapp:1.0", Timestamp: timestamp}
+		require.NoError(t, sw.Write(spdxResults()))
+
+		doc := report.NewSPDXDocument(spdxResults(), "myapp:1.0", timestamp)
+		assert.Contains(t, written.String(), `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: myapp:1.0
+DocumentNamespace: `+doc.DocumentNamespace+`
+Creator: Organization: aquasecurity
+Creator: Tool: trivy
+Created: 2020-04-13T18:21:39Z
+`)
+		assert.Contains(t, written.String(), `
+##### Package: musl
+
+PackageName: musl
+SPDXID: `+doc.Packages[2].SPDXID+`
+PackageVersion: 1.1.24-r0
+PackageSupplier: Organization: Alpine Linux
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: false
+PackageLicenseConcluded: NOASSERTION
+PackageLicenseDeclared: MIT
+PrimaryPackagePurpose: LIBRARY
+ExternalRef: PACKAGE-MANAGER purl pkg:apk/alpine/musl@1.1.24-r0
+`)
+		assert.Contains(t, written.String(), "\n##### Relationships\n\nRelationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Artifact\n")
+	})
+
+	t.Run("format", func(t *testing.T) {
+		written := bytes.Buffer{}
+		writer, err := report.NewWriter(report.Option{Format: "spdx-json", Output: &written, ArtifactName: "/app"})
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(spdxResults()))
+
+		var doc report.SPDXDocument
+		require.NoError(t, json.Unmarshal(written.Bytes(), &doc))
+		assert.Equal(t, "/app", doc.Name, "the document is named after the scanned artifact")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := report.SPDXWriter{Output: &bytes.Buffer{}, Format: "yaml"}.Write(nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown SPDX format")
+	})
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+// HistoryPath returns the default path of the database of the history of the scans in the cache directory
+func HistoryPath(cacheDir string) string {
+	return filepath.Join(cacheDir, "history.db")
+}
+
+// ReadHistory reads the scans of the image recorded by Writer in the database at the path, with the number of
+// their vulnerabilities per severity, and the scans the vulnerability of the ID first and last appeared in, if any
+func ReadHistory(path, image, vulnerabilityID string) (report.History, error) {
+	// sql.Open would create the database
+	if _, err := os.Stat(path); err != nil {
+		return report.History{}, xerrors.Errorf("no history at %s: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return report.History{}, xerrors.Errorf("failed to open %s: %w", path, err)
+	}
+	defer db.Close()
+	if err = migrate(db); err != nil {
+		return report.History{}, err
+	}
+
+	history := report.History{Image: image}
+	var scanIDs []int64
+	scans := map[int64]*report.HistoryScan{}
+	rows, err := db.Query(`SELECT id, scanned_at, image_id, repo_digest FROM scans WHERE image = ? ORDER BY scanned_at, id`,
+		image)
+	if err != nil {
+		return report.History{}, xerrors.Errorf("failed to query the scans: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var scannedAt string
+		var scan report.HistoryScan
+		if err = rows.Scan(&id, &scannedAt, &scan.ImageID, &scan.RepoDigest); err != nil {
+			return report.History{}, xerrors.Errorf("failed to read the scan: %w", err)
+		}
+		if scan.ScannedAt, err = time.Parse(time.RFC3339, scannedAt); err != nil {
+			return report.History{}, xerrors.Errorf("invalid time of the scan %d: %w", id, err)
+		}
+		history.Scans = append(history.Scans, scan)
+		scanIDs = append(scanIDs, id)
+	}
+	if err = rows.Err(); err != nil {
+		return report.History{}, xerrors.Errorf("failed to read the scans: %w", err)
+	}
+	if len(history.Scans) == 0 {
+		return report.History{}, xerrors.Errorf("no scan of %s is recorded in %s", image, path)
+	}
+	for i, id := range scanIDs {
+		scans[id] = &history.Scans[i]
+	}
+
+	if err = readCounts(db, image, scans); err != nil {
+		return report.History{}, err
+	}
+	if vulnerabilityID != "" {
+		latest := scanIDs[len(scanIDs)-1]
+		if history.Findings, err = readFindings(db, image, vulnerabilityID, latest); err != nil {
+			return report.History{}, err
+		}
+	}
+	return history, nil
+}
+
+// readCounts counts the vulnerabilities per severity of the scans of the image
+func readCounts(db *sql.DB, image string, scans map[int64]*report.HistoryScan) error {
+	rows, err := db.Query(`SELECT s.id, v.severity, COUNT(v.id)
+FROM vulnerabilities v JOIN targets t ON v.target_id = t.id JOIN scans s ON t.scan_id = s.id
+WHERE s.image = ? GROUP BY s.id, v.severity`, image)
+	if err != nil {
+		return xerrors.Errorf("failed to count the vulnerabilities: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var severity string
+		var count int
+		if err = rows.Scan(&id, &severity, &count); err != nil {
+			return xerrors.Errorf("failed to count the vulnerabilities: %w", err)
+		}
+		scan := scans[id]
+		if scan.Severities == nil {
+			scan.Severities = map[string]int{}
+		}
+		scan.Severities[severity] += count
+		scan.Total += count
+	}
+	if err = rows.Err(); err != nil {
+		return xerrors.Errorf("failed to count the vulnerabilities: %w", err)
+	}
+	return nil
+}
+
+// readFindings reads the first and the last scans finding the vulnerability in each package, in the order of
+// their first appearance
+func readFindings(db *sql.DB, image, vulnerabilityID string, latest int64) ([]report.HistoryFinding, error) {
+	rows, err := db.Query(`SELECT s.id, s.scanned_at, v.pkg_name, v.severity
+FROM vulnerabilities v JOIN targets t ON v.target_id = t.id JOIN scans s ON t.scan_id = s.id
+WHERE s.image = ? AND v.vulnerability_id = ? ORDER BY s.scanned_at, s.id, v.id`, image, vulnerabilityID)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to query %s: %w", vulnerabilityID, err)
+	}
+	defer rows.Close()
+
+	var findings []report.HistoryFinding
+	indexes := map[string]int{}
+	for rows.Next() {
+		var id int64
+		var scannedAt, pkgName, severity string
+		if err = rows.Scan(&id, &scannedAt, &pkgName, &severity); err != nil {
+			return nil, xerrors.Errorf("failed to read %s: %w", vulnerabilityID, err)
+		}
+		t, err := time.Parse(time.RFC3339, scannedAt)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid time of the scan %d: %w", id, err)
+		}
+
+		i, ok := indexes[pkgName]
+		if !ok {
+			i = len(findings)
+			indexes[pkgName] = i
+			findings = append(findings, report.HistoryFinding{
+				VulnerabilityID: vulnerabilityID,
+				PkgName:         pkgName,
+				FirstSeen:       t,
+			})
+		}
+		findings[i].Severity = severity
+		findings[i].LastSeen = t
+		findings[i].Fixed = id != latest
+	}
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.Errorf("failed to read %s: %w", vulnerabilityID, err)
+	}
+	return findings, nil
+}
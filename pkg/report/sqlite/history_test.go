@@ -0,0 +1,110 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/report/sqlite"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func vuln(id, pkgName, severity string) types.DetectedVulnerability {
+	return types.DetectedVulnerability{
+		VulnerabilityID: id,
+		PkgName:         pkgName,
+		Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+	}
+}
+
+func TestReadHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := sqlite.HistoryPath(dir)
+
+	day := func(d int) time.Time {
+		return time.Date(2020, 3, d, 12, 0, 0, 0, time.UTC)
+	}
+	scans := []struct {
+		image     string
+		imageID   string
+		scannedAt time.Time
+		vulns     []types.DetectedVulnerability
+	}{
+		{"alpine:3.10", "sha256:1", day(1), []types.DetectedVulnerability{vuln("CVE-2019-1549", "openssl", "MEDIUM")}},
+		{"alpine:3.10", "sha256:2", day(2), []types.DetectedVulnerability{
+			vuln("CVE-2019-1549", "openssl", "MEDIUM"),
+			vuln("CVE-2019-14697", "musl", "HIGH"),
+			vuln("CVE-2019-14697", "musl-utils", "HIGH"),
+		}},
+		{"alpine:3.10", "sha256:3", day(3), []types.DetectedVulnerability{vuln("CVE-2019-14697", "musl", "CRITICAL")}},
+		{"alpine:3.11", "sha256:4", day(3), []types.DetectedVulnerability{vuln("CVE-2019-14697", "musl", "HIGH")}},
+	}
+	for _, s := range scans {
+		w := sqlite.Writer{Path: path, Image: s.image, ImageID: s.imageID, ScannedAt: s.scannedAt}
+		require.NoError(t, w.Write(report.Results{{Target: s.image, Type: "alpine", Vulnerabilities: s.vulns}}))
+	}
+
+	got, err := sqlite.ReadHistory(path, "alpine:3.10", "CVE-2019-14697")
+	require.NoError(t, err)
+	assert.Equal(t, report.History{
+		Image: "alpine:3.10",
+		Scans: []report.HistoryScan{
+			{ScannedAt: day(1), ImageID: "sha256:1", Total: 1, Severities: map[string]int{"MEDIUM": 1}},
+			{ScannedAt: day(2), ImageID: "sha256:2", Total: 3, Severities: map[string]int{"MEDIUM": 1, "HIGH": 2}},
+			{ScannedAt: day(3), ImageID: "sha256:3", Total: 1, Severities: map[string]int{"CRITICAL": 1}},
+		},
+		Findings: []report.HistoryFinding{
+			{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", Severity: "CRITICAL", FirstSeen: day(2), LastSeen: day(3)},
+			{VulnerabilityID: "CVE-2019-14697", PkgName: "musl-utils", Severity: "HIGH", FirstSeen: day(2), LastSeen: day(2),
+				Fixed: true},
+		},
+	}, got)
+
+	_, err = sqlite.ReadHistory(path, "alpine:3.9", "")
+	assert.Error(t, err, "no scan of the image")
+	_, err = sqlite.ReadHistory(filepath.Join(dir, "missing.db"), "alpine:3.10", "")
+	assert.Error(t, err, "no database")
+	_, err = os.Stat(filepath.Join(dir, "missing.db"))
+	assert.True(t, os.IsNotExist(err), "the missing database isn't created")
+}
+
+func TestReadHistory_PreviousVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trivy.db")
+
+	// the database of --format sqlite without the repo digests
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE scans (
+  id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  scanned_at TEXT NOT NULL,
+  image      TEXT NOT NULL,
+  image_id   TEXT NOT NULL
+);
+INSERT INTO scans (scanned_at, image, image_id) VALUES ('2020-03-01T12:00:00Z', 'alpine:3.10', 'sha256:1');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	w := sqlite.Writer{Path: path, Image: "alpine:3.10", ImageID: "sha256:2", RepoDigest: "alpine@sha256:3",
+		ScannedAt: time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, w.Write(nil))
+
+	got, err := sqlite.ReadHistory(path, "alpine:3.10", "")
+	require.NoError(t, err)
+	assert.Equal(t, []report.HistoryScan{
+		{ScannedAt: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC), ImageID: "sha256:1"},
+		{ScannedAt: time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC), ImageID: "sha256:2", RepoDigest: "alpine@sha256:3"},
+	}, got.Scans)
+}
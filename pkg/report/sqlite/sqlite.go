@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	// the pure Go driver as the release binaries are built without cgo
+	_ "modernc.org/sqlite"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS scans (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  scanned_at  TEXT NOT NULL,
+  image       TEXT NOT NULL,
+  image_id    TEXT NOT NULL,
+  repo_digest TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS targets (
+  id      INTEGER PRIMARY KEY AUTOINCREMENT,
+  scan_id INTEGER NOT NULL REFERENCES scans(id),
+  target  TEXT NOT NULL,
+  type    TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS vulnerabilities (
+  id                INTEGER PRIMARY KEY AUTOINCREMENT,
+  target_id         INTEGER NOT NULL REFERENCES targets(id),
+  vulnerability_id  TEXT NOT NULL,
+  pkg_name          TEXT NOT NULL,
+  installed_version TEXT NOT NULL,
+  fixed_version     TEXT NOT NULL,
+  severity          TEXT NOT NULL,
+  title             TEXT NOT NULL,
+  layer_diff_id     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS scans_image ON scans(image);
+CREATE INDEX IF NOT EXISTS targets_scan_id ON targets(scan_id);
+CREATE INDEX IF NOT EXISTS vulnerabilities_target_id ON vulnerabilities(target_id);
+`
+
+// Writer appends a scan to the SQLite database at Path, creating the database and the tables if absent.
+// Each scan is a row in scans, with a row in targets per result and in vulnerabilities per finding.
+type Writer struct {
+	Path    string
+	Image   string
+	ImageID string
+	// RepoDigest is the repo digest of the image, e.g. alpine@sha256:..., if known
+	RepoDigest string
+	// ScannedAt is the scan timestamp; the current time is used when it is zero
+	ScannedAt time.Time
+}
+
+func (w Writer) Write(results report.Results) (err error) {
+	db, err := sql.Open("sqlite", w.Path)
+	if err != nil {
+		return xerrors.Errorf("failed to open %s: %w", w.Path, err)
+	}
+	defer db.Close()
+
+	if err = migrate(db); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return xerrors.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	scannedAt := w.ScannedAt
+	if scannedAt.IsZero() {
+		scannedAt = time.Now()
+	}
+	res, err := tx.Exec(`INSERT INTO scans (scanned_at, image, image_id, repo_digest) VALUES (?, ?, ?, ?)`,
+		scannedAt.UTC().Format(time.RFC3339), w.Image, w.ImageID, w.RepoDigest)
+	if err != nil {
+		return xerrors.Errorf("failed to insert the scan: %w", err)
+	}
+	scanID, err := res.LastInsertId()
+	if err != nil {
+		return xerrors.Errorf("failed to get the scan ID: %w", err)
+	}
+
+	for _, result := range results {
+		res, err = tx.Exec(`INSERT INTO targets (scan_id, target, type) VALUES (?, ?, ?)`,
+			scanID, result.Target, result.Type)
+		if err != nil {
+			return xerrors.Errorf("failed to insert the target %s: %w", result.Target, err)
+		}
+		targetID, err := res.LastInsertId()
+		if err != nil {
+			return xerrors.Errorf("failed to get the target ID: %w", err)
+		}
+
+		for _, vuln := range result.Vulnerabilities {
+			_, err = tx.Exec(`INSERT INTO vulnerabilities
+  (target_id, vulnerability_id, pkg_name, installed_version, fixed_version, severity, title, layer_diff_id)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				targetID, vuln.VulnerabilityID, vuln.PkgName, vuln.InstalledVersion, vuln.FixedVersion,
+				vuln.Severity, vuln.Title, vuln.Layer.DiffID)
+			if err != nil {
+				return xerrors.Errorf("failed to insert %s: %w", vuln.VulnerabilityID, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return xerrors.Errorf("failed to commit the scan: %w", err)
+	}
+	return nil
+}
+
+// migrate creates the tables, adding the columns missing in the databases of the previous versions
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('scans')`)
+	if err != nil {
+		return xerrors.Errorf("failed to get the columns of the scans: %w", err)
+	}
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return xerrors.Errorf("failed to get the columns of the scans: %w", err)
+		}
+		columns[name] = true
+	}
+	if err = rows.Err(); err != nil {
+		return xerrors.Errorf("failed to get the columns of the scans: %w", err)
+	}
+
+	// the scans of the previous versions have no repo digest
+	if len(columns) > 0 && !columns["repo_digest"] {
+		if _, err = db.Exec(`ALTER TABLE scans ADD COLUMN repo_digest TEXT NOT NULL DEFAULT ''`); err != nil {
+			return xerrors.Errorf("failed to add the repo digest of the scans: %w", err)
+		}
+	}
+	if _, err = db.Exec(schema); err != nil {
+		return xerrors.Errorf("failed to create the tables: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/report/sqlite"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestWriter_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-sqlite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trivy.db")
+
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.2)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2019-14697",
+					PkgName:          "musl",
+					InstalledVersion: "1.1.22-r2",
+					FixedVersion:     "1.1.22-r3",
+					Vulnerability: dbTypes.Vulnerability{
+						Title:    "musl libc through 1.1.23 has an x87 floating-point stack adjustment imbalance",
+						Severity: "HIGH",
+					},
+				},
+				{
+					VulnerabilityID:  "CVE-2019-1549",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1c-r0",
+					FixedVersion:     "1.1.1d-r0",
+					Vulnerability: dbTypes.Vulnerability{
+						Severity: "MEDIUM",
+					},
+				},
+			},
+		},
+		{
+			Target: "app/Gemfile.lock",
+			Type:   "bundler",
+		},
+	}
+
+	first := sqlite.Writer{
+		Path:      path,
+		Image:     "alpine:3.10",
+		ImageID:   "sha256:961769676411f082461f9ef46626dd7a2d1e2b2a38e6a44364bcbecf51e66dd4",
+		ScannedAt: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, first.Write(results))
+
+	// the second scan is appended to the existing database
+	second := first
+	second.ScannedAt = time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, second.Write(results[:1]))
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT s.scanned_at, s.image_id, t.target, v.vulnerability_id, v.pkg_name, v.severity
+FROM vulnerabilities v JOIN targets t ON v.target_id = t.id JOIN scans s ON t.scan_id = s.id
+ORDER BY s.id, v.id`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		ScannedAt, ImageID, Target, VulnerabilityID, PkgName, Severity string
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.ScannedAt, &r.ImageID, &r.Target, &r.VulnerabilityID, &r.PkgName, &r.Severity))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	imageID := first.ImageID
+	target := "alpine:3.10 (alpine 3.10.2)"
+	assert.Equal(t, []row{
+		{"2020-03-01T12:00:00Z", imageID, target, "CVE-2019-14697", "musl", "HIGH"},
+		{"2020-03-01T12:00:00Z", imageID, target, "CVE-2019-1549", "openssl", "MEDIUM"},
+		{"2020-03-02T12:00:00Z", imageID, target, "CVE-2019-14697", "musl", "HIGH"},
+		{"2020-03-02T12:00:00Z", imageID, target, "CVE-2019-1549", "openssl", "MEDIUM"},
+	}, got)
+
+	var targets int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM targets`).Scan(&targets))
+	assert.Equal(t, 3, targets)
+}
+
+func TestWriter_Write_InvalidPath(t *testing.T) {
+	w := sqlite.Writer{Path: filepath.Join("testdata", "missing", "trivy.db")}
+	err := w.Write(nil)
+	require.Error(t, err)
+}
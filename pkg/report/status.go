@@ -0,0 +1,67 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// StatusLine summarizes the results of the image in one line for chat integrations,
+// e.g. "alpine:3.11 — 2 vulns (1 HIGH, 1 MEDIUM), OS EOL, grade D" with types.DefaultGradeRubric.
+// Severities are listed from the highest and omitted when nothing is found; "vulns" is used for any count.
+func StatusLine(results Results, image ftypes.ImageReference) string {
+	counts, total, eosl := countSeverities(results)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s — %d vulns", image.Name, total)
+
+	var bySeverity []string
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		severity := dbTypes.SeverityNames[i]
+		if counts[severity] > 0 {
+			bySeverity = append(bySeverity, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	if len(bySeverity) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(bySeverity, ", "))
+	}
+
+	if eosl {
+		b.WriteString(", OS EOL")
+	}
+	fmt.Fprintf(&b, ", grade %s", Grade(results, types.DefaultGradeRubric))
+	return b.String()
+}
+
+// SummaryBySeverity returns the number of vulnerabilities per severity across all the targets.
+// Vulnerabilities without severity are counted as UNKNOWN, the severities without any have no entry.
+func (results Results) SummaryBySeverity() map[string]int {
+	counts := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			countSeverity(counts, vuln.Severity)
+		}
+	}
+	return counts
+}
+
+// countSeverity counts a finding of the severity, those without severity being UNKNOWN.
+// The counts of the reports, summaries and exit codes are all made with it.
+func countSeverity(counts map[string]int, severity string) {
+	if severity == "" {
+		severity = dbTypes.SeverityUnknown.String()
+	}
+	counts[severity]++
+}
+
+// countSeverities returns the number of findings per severity, the total and whether an OS is end-of-life
+func countSeverities(results Results) (counts map[string]int, total int, eosl bool) {
+	for _, result := range results {
+		eosl = eosl || result.EOSL
+		total += len(result.Vulnerabilities)
+	}
+	return results.SummaryBySeverity(), total, eosl
+}
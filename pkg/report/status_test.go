@@ -0,0 +1,108 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestStatusLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		results Results
+		want    string
+	}{
+		{
+			name: "EOL OS",
+			results: Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11.5)",
+					EOSL:   true,
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+					},
+				},
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2019-10744", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+					},
+				},
+			},
+			want: "alpine:3.11 — 2 vulns (1 HIGH, 1 MEDIUM), OS EOL, grade D",
+		},
+		{
+			name: "unknown severity",
+			results: Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11.5)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+						{VulnerabilityID: "CVE-2020-8169"},
+						{VulnerabilityID: "CVE-2020-8177", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+					},
+				},
+			},
+			want: "alpine:3.11 — 3 vulns (2 CRITICAL, 1 UNKNOWN), grade D",
+		},
+		{
+			name:    "no vulnerabilities",
+			results: Results{{Target: "alpine:3.11 (alpine 3.11.5)"}},
+			want:    "alpine:3.11 — 0 vulns, grade A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StatusLine(tt.results, ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine"})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResults_SummaryBySeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		results Results
+		want    map[string]int
+	}{
+		{
+			name: "mixed severities",
+			results: Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11.5)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-1967", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+						{VulnerabilityID: "CVE-2020-28928", Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"}},
+						{VulnerabilityID: "CVE-2020-0001"},
+					},
+				},
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2019-10744", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+						{VulnerabilityID: "CVE-2020-8203", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+						{VulnerabilityID: "NSWG-ECO-516", Vulnerability: dbTypes.Vulnerability{Severity: "UNKNOWN"}},
+					},
+				},
+				{
+					Target: "app/Gemfile.lock",
+				},
+			},
+			want: map[string]int{"CRITICAL": 1, "HIGH": 2, "MEDIUM": 1, "UNKNOWN": 2},
+		},
+		{
+			name:    "no vulnerabilities",
+			results: Results{{Target: "alpine:3.11 (alpine 3.11.5)"}},
+			want:    map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.results.SummaryBySeverity())
+		})
+	}
+}
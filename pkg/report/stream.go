@@ -0,0 +1,50 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// writeJSONResults writes the results as json.MarshalIndent does with the prefix and an indent of two spaces,
+// marshaling one result at a time so that the memory of the huge reports stays bounded by their largest result.
+// The fields of the findings are renamed by the names, if any.
+func writeJSONResults(w io.Writer, prefix string, results Results, names map[string]string) error {
+	switch {
+	case results == nil:
+		_, err := io.WriteString(w, "null")
+		return err
+	case len(results) == 0:
+		_, err := io.WriteString(w, "[]")
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	indent := prefix + "  "
+	bw.WriteString("[\n")
+	for i, result := range results {
+		var v interface{} = result
+		if len(names) > 0 {
+			renamed, err := renameFindingFields(result, names)
+			if err != nil {
+				return xerrors.Errorf("failed to rename fields: %w", err)
+			}
+			v = renamed
+		}
+		b, err := json.MarshalIndent(v, indent, "  ")
+		if err != nil {
+			return xerrors.Errorf("failed to marshal the result of %s: %w", result.Target, err)
+		}
+		if i > 0 {
+			bw.WriteString(",\n")
+		}
+		bw.WriteString(indent)
+		if _, err = bw.Write(b); err != nil {
+			return err
+		}
+	}
+	bw.WriteString("\n" + prefix + "]")
+	return bw.Flush()
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"golang.org/x/xerrors"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+)
+
+const (
+	// ReportSummary reports the number of findings per severity of each target instead of the findings
+	ReportSummary = "summary"
+	// ReportAll reports the findings
+	ReportAll = "all"
+)
+
+// Summary is the number of findings per severity of each target and of all the targets
+type Summary struct {
+	Targets []TargetSummary
+	// Total is the number of findings per severity of all the targets
+	Total map[string]int
+}
+
+// TargetSummary is the number of findings per severity of a target. Every severity has an entry, even without findings,
+// and the findings without severity are counted as UNKNOWN.
+type TargetSummary struct {
+	Target            string
+	Type              string `json:",omitempty"`
+	Class             string `json:",omitempty"`
+	Vulnerabilities   map[string]int
+	Secrets           map[string]int `json:",omitempty"`
+	Misconfigurations map[string]int `json:",omitempty"`
+}
+
+// NewSummary returns the summary of the results, the secrets and the misconfigurations being counted apart
+// only for the targets having some
+func NewSummary(results Results) Summary {
+	summary := Summary{Targets: []TargetSummary{}, Total: severityMap()}
+	for _, result := range results {
+		target := TargetSummary{Target: result.Target, Type: result.Type, Class: result.Class, Vulnerabilities: severityMap()}
+		for _, vuln := range result.Vulnerabilities {
+			countSeverity(target.Vulnerabilities, vuln.Severity)
+			countSeverity(summary.Total, vuln.Severity)
+		}
+		if len(result.Secrets) > 0 {
+			target.Secrets = severityMap()
+			for _, secret := range result.Secrets {
+				countSeverity(target.Secrets, secret.Severity)
+				countSeverity(summary.Total, secret.Severity)
+			}
+		}
+		if len(result.Misconfigurations) > 0 {
+			target.Misconfigurations = severityMap()
+			for _, misconf := range result.Misconfigurations {
+				countSeverity(target.Misconfigurations, misconf.Severity)
+				countSeverity(summary.Total, misconf.Severity)
+			}
+		}
+		summary.Targets = append(summary.Targets, target)
+	}
+	return summary
+}
+
+// Findings returns the number of findings per severity of the target, of all kinds
+func (t TargetSummary) Findings() map[string]int {
+	counts := map[string]int{}
+	for _, m := range []map[string]int{t.Vulnerabilities, t.Secrets, t.Misconfigurations} {
+		for severity, n := range m {
+			counts[severity] += n
+		}
+	}
+	return counts
+}
+
+func severityMap() map[string]int {
+	m := map[string]int{}
+	for _, severity := range dbTypes.SeverityNames {
+		m[severity] = 0
+	}
+	return m
+}
+
+// SummaryWriter writes the summary of the results instead of the findings, as a table of the targets with their
+// number of findings per severity and the total of all the targets, or as the JSON of Summary
+type SummaryWriter struct {
+	Output io.Writer
+	// Format is table or json
+	Format string
+}
+
+func (sw SummaryWriter) Write(results Results) error {
+	summary := NewSummary(results)
+	switch sw.Format {
+	case "json":
+		output, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return xerrors.Errorf("failed to marshal json: %w", err)
+		}
+		if _, err = fmt.Fprintln(sw.Output, string(output)); err != nil {
+			return xerrors.Errorf("failed to write json: %w", err)
+		}
+		return nil
+	case "table":
+		sw.writeTable(summary)
+		return nil
+	}
+	return xerrors.Errorf("the summary doesn't support --format %s, use table or json", sw.Format)
+}
+
+func (sw SummaryWriter) writeTable(summary Summary) {
+	severities := summarySeverities(summary.Total)
+	table := tablewriter.NewWriter(sw.Output)
+	table.SetHeader(append([]string{"Target", "Type"}, severities...))
+	for _, target := range summary.Targets {
+		kind := target.Type
+		if kind == "" {
+			kind = target.Class
+		}
+		table.Append(append([]string{target.Target, kind}, summaryCounts(target.Findings(), severities)...))
+	}
+	table.SetFooter(append([]string{"Total", fmt.Sprintf("%d targets", len(summary.Targets))},
+		summaryCounts(summary.Total, severities)...))
+	table.Render()
+}
+
+// summarySeverities returns the severities from the highest, followed by the custom levels of the counts
+// in alphabetical order, e.g. of ScanOptions.SeverityLevels
+func summarySeverities(counts map[string]int) []string {
+	var severities, custom []string
+	for i := len(dbTypes.SeverityNames) - 1; i >= 0; i-- {
+		severities = append(severities, dbTypes.SeverityNames[i])
+	}
+	for severity := range counts {
+		if _, err := dbTypes.NewSeverity(severity); err != nil {
+			custom = append(custom, severity)
+		}
+	}
+	sort.Strings(custom)
+	return append(severities, custom...)
+}
+
+func summaryCounts(counts map[string]int, severities []string) []string {
+	var row []string
+	for _, severity := range severities {
+		row = append(row, fmt.Sprint(counts[severity]))
+	}
+	return row
+}
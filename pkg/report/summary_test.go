@@ -0,0 +1,96 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+var summaryResults = report.Results{
+	{
+		Target: "alpine:3.10 (alpine 3.10.4)",
+		Type:   "alpine",
+		Class:  report.ClassOSPkgs,
+		Vulnerabilities: []types.DetectedVulnerability{
+			topVuln("CVE-2020-0001", "MEDIUM", 0),
+			topVuln("CVE-2020-0002", "CRITICAL", 0),
+			topVuln("CVE-2020-0003", "", 0),
+		},
+	},
+	{
+		Target: "app/config.yaml",
+		Class:  report.ClassSecret,
+		Secrets: []types.SecretFinding{
+			{RuleID: "aws-access-key-id", Severity: "CRITICAL"},
+		},
+	},
+	{
+		Target: "app/package-lock.json",
+		Type:   "npm",
+		Class:  report.ClassLangPkgs,
+	},
+}
+
+func TestNewSummary(t *testing.T) {
+	summary := report.NewSummary(summaryResults)
+
+	require.Len(t, summary.Targets, 3)
+	assert.Equal(t, map[string]int{"UNKNOWN": 1, "LOW": 0, "MEDIUM": 1, "HIGH": 0, "CRITICAL": 1}, summary.Targets[0].Vulnerabilities)
+	assert.Nil(t, summary.Targets[0].Secrets)
+	assert.Equal(t, 1, summary.Targets[1].Secrets["CRITICAL"])
+	assert.Equal(t, map[string]int{"UNKNOWN": 0, "LOW": 0, "MEDIUM": 0, "HIGH": 0, "CRITICAL": 0}, summary.Targets[2].Vulnerabilities)
+	assert.Equal(t, map[string]int{"UNKNOWN": 1, "LOW": 0, "MEDIUM": 1, "HIGH": 0, "CRITICAL": 2}, summary.Total)
+}
+
+func TestSummaryWriter_Write(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.SummaryWriter{Output: &buf, Format: "table"}.Write(summaryResults))
+		assert.Equal(t, `+-----------------------------+-----------+----------+------+--------+-----+---------+
+|           TARGET            |   TYPE    | CRITICAL | HIGH | MEDIUM | LOW | UNKNOWN |
++-----------------------------+-----------+----------+------+--------+-----+---------+
+| alpine:3.10 (alpine 3.10.4) | alpine    |        1 |    0 |      1 |   0 |       1 |
+| app/config.yaml             | secret    |        1 |    0 |      0 |   0 |       0 |
+| app/package-lock.json       | npm       |        0 |    0 |      0 |   0 |       0 |
++-----------------------------+-----------+----------+------+--------+-----+---------+
+|            TOTAL            | 3 TARGETS |    2     |  0   |   1    |  0  |    1    |
++-----------------------------+-----------+----------+------+--------+-----+---------+
+`, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.SummaryWriter{Output: &buf, Format: "json"}.Write(summaryResults))
+		var got report.Summary
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		assert.Equal(t, report.NewSummary(summaryResults), got)
+		assert.NotContains(t, buf.String(), "CVE-2020-0001", "only the counts are written")
+	})
+
+	t.Run("custom severity levels", func(t *testing.T) {
+		var buf bytes.Buffer
+		results := report.Results{{Target: "app/Gemfile.lock", Vulnerabilities: []types.DetectedVulnerability{topVuln("CVE-2020-0001", "MODERATE", 0)}}}
+		require.NoError(t, report.SummaryWriter{Output: &buf, Format: "table"}.Write(results))
+		assert.Contains(t, buf.String(), "MODERATE")
+	})
+
+	t.Run("summary report of NewWriter", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteResults(summaryResults, report.Option{Output: &buf, Format: "json", Report: report.ReportSummary}))
+		var got report.Summary
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		assert.Equal(t, report.NewSummary(summaryResults), got)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		err := report.SummaryWriter{Output: &bytes.Buffer{}, Format: "sarif"}.Write(summaryResults)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the summary doesn't support --format sarif")
+	})
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// templateFuncs are the functions available in the output templates:
+//   - escapeXML escapes a string for XML and HTML, e.g. {{ escapeXML .Title }}
+//   - severityCount counts the vulnerabilities per severity of Results, a Result or its Vulnerabilities,
+//     e.g. {{ $c := severityCount . }}{{ $c.CRITICAL }} critical
+//   - colorizeSeverity wraps a severity in the ANSI escape codes of the table, e.g. {{ colorizeSeverity .Severity }}
+//   - severityColor returns the hex color of a severity for HTML, e.g. <td style="color: {{ severityColor .Severity }}">
+//   - cveURL returns the NVD page of a CVE, and an empty string for the other IDs, e.g. {{ cveURL .VulnerabilityID }}
+var templateFuncs = template.FuncMap{
+	"escapeXML":        escapeXML,
+	"severityCount":    severityCount,
+	"colorizeSeverity": colorizeSeverity,
+	"severityColor":    severityColor,
+	"cveURL":           cveURL,
+}
+
+type TemplateWriter struct {
+	Output   io.Writer
+	Template *template.Template
+}
+
+// NewTemplateWriter parses the Go template, the template in the file of an @ prefixed path, e.g. "@contrib/html.tpl",
+// or a built-in template by name, e.g. "junit". A malformed template fails here rather than when writing the results.
+func NewTemplateWriter(output io.Writer, outputTemplate string) (*TemplateWriter, error) {
+	if builtin, ok := builtinTemplates[outputTemplate]; ok {
+		outputTemplate = builtin
+	} else if strings.HasPrefix(outputTemplate, "@") {
+		buf, err := ioutil.ReadFile(strings.TrimPrefix(outputTemplate, "@"))
+		if err != nil {
+			return nil, xerrors.Errorf("error retrieving template from path: %w", err)
+		}
+		outputTemplate = string(buf)
+	}
+
+	tmpl, err := template.New("output template").Funcs(templateFuncs).Parse(outputTemplate)
+	if err != nil {
+		return nil, xerrors.Errorf("error parsing template: %w", err)
+	}
+	return &TemplateWriter{Output: output, Template: tmpl}, nil
+}
+
+func (tw TemplateWriter) Write(results Results) error {
+	err := tw.Template.Execute(tw.Output, results)
+	if err != nil {
+		return xerrors.Errorf("failed to write with template: %w", err)
+	}
+	return nil
+}
+
+func escapeXML(s string) (string, error) {
+	var b bytes.Buffer
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// severityCount has every severity, UNKNOWN for the vulnerabilities without one
+func severityCount(v interface{}) (map[string]int, error) {
+	var vulns []types.DetectedVulnerability
+	switch v := v.(type) {
+	case Results:
+		for _, result := range v {
+			vulns = append(vulns, result.Vulnerabilities...)
+		}
+	case Result:
+		vulns = v.Vulnerabilities
+	case []types.DetectedVulnerability:
+		vulns = v
+	default:
+		return nil, xerrors.Errorf("severityCount of %T", v)
+	}
+
+	counts := severityMap()
+	for _, vuln := range vulns {
+		countSeverity(counts, vuln.Severity)
+	}
+	return counts, nil
+}
+
+// htmlSeverityColors are the colors of the severities in HTML, close to those of the table
+var htmlSeverityColors = map[string]string{
+	"CRITICAL": "#d32f2f",
+	"HIGH":     "#c2185b",
+	"MEDIUM":   "#f57c00",
+	"LOW":      "#1976d2",
+	"UNKNOWN":  "#0097a7",
+}
+
+// severityColor is black for an unknown severity name
+func severityColor(severity string) string {
+	if c, ok := htmlSeverityColors[severity]; ok {
+		return c
+	}
+	return "#000000"
+}
+
+func cveURL(vulnID string) string {
+	if !strings.HasPrefix(vulnID, "CVE-") {
+		return ""
+	}
+	return "https://nvd.nist.gov/vuln/detail/" + vulnID
+}
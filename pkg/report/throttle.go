@@ -0,0 +1,35 @@
+package report
+
+import (
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// tokenBucket paces the findings to rate per second with a burst of one second's worth
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+func newTokenBucket(rate float64, c clock.Clock) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: rate, last: c.Now(), clock: c}
+}
+
+// take consumes n tokens, blocking until they are accumulated.
+// More tokens than the burst can be taken at once and are paid back by waiting.
+func (b *tokenBucket) take(n int) {
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens < 0 {
+		b.clock.Sleep(time.Duration(-b.tokens / b.rate * float64(time.Second)))
+	}
+}
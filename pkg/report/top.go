@@ -0,0 +1,75 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// DefaultTopN is the number of findings written by the top format by default
+const DefaultTopN = 10
+
+// TopFinding is a vulnerability with the target it is found in
+type TopFinding struct {
+	Target string
+	types.DetectedVulnerability
+}
+
+// TopFindings returns the n findings with the highest severity across all targets.
+// Findings with the same severity are ordered by the highest CVSS score and then by vulnerability ID.
+func TopFindings(results Results, n int) []TopFinding {
+	var findings []TopFinding
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			findings = append(findings, TopFinding{Target: result.Target, DetectedVulnerability: vuln})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if ret := dbTypes.CompareSeverityString(findings[j].Severity, findings[i].Severity); ret != 0 {
+			return ret > 0
+		}
+		if si, sj := findings[i].CVSS.MaxScore(), findings[j].CVSS.MaxScore(); si != sj {
+			return si > sj
+		}
+		return findings[i].VulnerabilityID < findings[j].VulnerabilityID
+	})
+
+	if n >= 0 && len(findings) > n {
+		findings = findings[:n]
+	}
+	return findings
+}
+
+// TopWriter writes the N worst findings across all targets in a table
+type TopWriter struct {
+	Output io.Writer
+	N      int
+}
+
+func (tw TopWriter) Write(results Results) error {
+	findings := TopFindings(results, tw.N)
+	if _, err := fmt.Fprintf(tw.Output, "Top %d findings\n", len(findings)); err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Target", "Library", "Vulnerability ID", "Severity", "CVSS", "Installed Version", "Fixed Version"})
+	for _, f := range findings {
+		score := "-"
+		if s := f.CVSS.MaxScore(); s > 0 {
+			score = fmt.Sprintf("%.1f", s)
+		}
+		table.Append([]string{f.Target, f.PkgName, f.VulnerabilityID, f.Severity, score, f.InstalledVersion, f.FixedVersion})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,95 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func topVuln(id, severity string, score float64) types.DetectedVulnerability {
+	vuln := types.DetectedVulnerability{
+		VulnerabilityID: id,
+		PkgName:         "pkg-" + id,
+		Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+	}
+	if score > 0 {
+		vuln.CVSS = types.VendorCVSS{"nvd": {V3Score: score}}
+	}
+	return vuln
+}
+
+var topResults = report.Results{
+	{
+		Target: "alpine:3.10 (alpine 3.10.4)",
+		Vulnerabilities: []types.DetectedVulnerability{
+			topVuln("CVE-2020-0001", "MEDIUM", 5.3),
+			topVuln("CVE-2020-0002", "CRITICAL", 9.1),
+			topVuln("CVE-2020-0003", "HIGH", 7.5),
+		},
+	},
+	{
+		Target: "app/package-lock.json",
+		Vulnerabilities: []types.DetectedVulnerability{
+			topVuln("CVE-2020-0004", "CRITICAL", 9.8),
+			topVuln("CVE-2020-0006", "HIGH", 0),
+			topVuln("CVE-2020-0005", "HIGH", 0),
+			topVuln("CVE-2020-0007", "LOW", 3.1),
+		},
+	},
+}
+
+func TestTopFindings(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{
+			name: "top 5",
+			n:    5,
+			// ties in severity are broken by the CVSS score, then by the ID
+			want: []string{"CVE-2020-0004", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0005", "CVE-2020-0006"},
+		},
+		{
+			name: "more than the findings",
+			n:    10,
+			want: []string{"CVE-2020-0004", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0005", "CVE-2020-0006",
+				"CVE-2020-0001", "CVE-2020-0007"},
+		},
+		{
+			name: "zero",
+			n:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range report.TopFindings(topResults, tt.n) {
+				got = append(got, f.VulnerabilityID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	findings := report.TopFindings(topResults, 2)
+	assert.Equal(t, "app/package-lock.json", findings[0].Target)
+	assert.Equal(t, "alpine:3.10 (alpine 3.10.4)", findings[1].Target)
+}
+
+func TestReportWriter_Top(t *testing.T) {
+	topWritten := bytes.Buffer{}
+	assert.NoError(t, report.WriteResults(topResults, report.Option{Format: "top", Output: &topWritten, TopN: 2}))
+	assert.Equal(t, `Top 2 findings
++-----------------------------+-------------------+------------------+----------+------+-------------------+---------------+
+|           TARGET            |      LIBRARY      | VULNERABILITY ID | SEVERITY | CVSS | INSTALLED VERSION | FIXED VERSION |
++-----------------------------+-------------------+------------------+----------+------+-------------------+---------------+
+| app/package-lock.json       | pkg-CVE-2020-0004 | CVE-2020-0004    | CRITICAL |  9.8 |                   |               |
+| alpine:3.10 (alpine 3.10.4) | pkg-CVE-2020-0002 | CVE-2020-0002    | CRITICAL |  9.1 |                   |               |
++-----------------------------+-------------------+------------------+----------+------+-------------------+---------------+
+`, topWritten.String())
+}
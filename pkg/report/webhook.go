@@ -0,0 +1,177 @@
+package report
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"golang.org/x/xerrors"
+	"k8s.io/utils/clock"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+const (
+	defaultWebhookRetries       = 3
+	defaultWebhookRetryInterval = time.Second
+)
+
+// WebhookSignatureHeader is the header of the HMAC-SHA256 of the payload with the secret of the webhook,
+// e.g. "sha256=6b2c...", for the receiver to verify the sender
+const WebhookSignatureHeader = "X-Trivy-Signature-256"
+
+// WebhookFinding is a vulnerability posted to a webhook along with the target it was found in
+type WebhookFinding struct {
+	Target string `json:"Target"`
+	Type   string `json:"Type,omitempty"`
+	types.DetectedVulnerability
+}
+
+type webhookPayload struct {
+	Findings []WebhookFinding `json:"Findings"`
+}
+
+// WebhookWriter POSTs findings to a URL as JSON.
+// Findings are accumulated and delivered in batches of BatchSize, the rest is flushed at the end.
+// A BatchSize of zero delivers all findings in a single request.
+type WebhookWriter struct {
+	URL       string
+	BatchSize int
+	Client    *http.Client
+	// Secret signs the payloads in WebhookSignatureHeader when set
+	Secret string
+
+	// MaxRetries is the number of retries of a batch failing with a network error or a 5xx response,
+	// with an exponential backoff from RetryInterval. Zero retries 3 times, a negative number disables the retries.
+	MaxRetries    int
+	RetryInterval time.Duration
+	// RetryJitter randomizes the retry delays within ±RetryJitter of each delay; zero uses utils.DefaultJitter
+	RetryJitter float64
+
+	// DeliveryRate is the maximum number of findings delivered per second for rate-limited sinks.
+	// Deliveries exceeding it block until they are allowed. Zero is unlimited.
+	DeliveryRate float64
+	Clock        clock.Clock
+}
+
+func (ww WebhookWriter) Write(results Results) error {
+	var bucket *tokenBucket
+	if ww.DeliveryRate > 0 {
+		c := ww.Clock
+		if c == nil {
+			c = clock.RealClock{}
+		}
+		bucket = newTokenBucket(ww.DeliveryRate, c)
+	}
+
+	var batch []WebhookFinding
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			batch = append(batch, WebhookFinding{
+				Target:                result.Target,
+				Type:                  result.Type,
+				DetectedVulnerability: vuln,
+			})
+			if ww.BatchSize > 0 && len(batch) >= ww.BatchSize {
+				if err := ww.deliver(batch, bucket); err != nil {
+					return err
+				}
+				batch = nil
+			}
+		}
+	}
+
+	// final flush
+	if len(batch) > 0 {
+		if err := ww.deliver(batch, bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ww WebhookWriter) deliver(findings []WebhookFinding, bucket *tokenBucket) error {
+	if bucket != nil {
+		bucket.take(len(findings))
+	}
+
+	body, err := json.Marshal(webhookPayload{Findings: findings})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	if err = postJSON(ww.Client, ww.URL, body, ww.Secret, ww.backOff()); err != nil {
+		return xerrors.Errorf("failed to deliver %d findings to the webhook: %w", len(findings), err)
+	}
+	return nil
+}
+
+// postJSON POSTs the JSON body, retrying on the network errors and the 5xx responses
+func postJSON(client *http.Client, url string, body []byte, secret string, b backoff.BackOff) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	operation := func() error {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set(WebhookSignatureHeader, Sign(body, secret))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			return xerrors.Errorf("webhook returned %s", resp.Status)
+		} else if resp.StatusCode >= 300 {
+			return backoff.Permanent(xerrors.Errorf("webhook returned %s", resp.Status))
+		}
+		return nil
+	}
+
+	return backoff.RetryNotify(operation, b, func(err error, _ time.Duration) {
+		log.Logger.Warn(err)
+		log.Logger.Info("Retrying webhook delivery...")
+	})
+}
+
+// Sign returns the value of WebhookSignatureHeader of the payload
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (ww WebhookWriter) backOff() backoff.BackOff {
+	return retryBackOff(ww.MaxRetries, ww.RetryInterval, ww.RetryJitter)
+}
+
+// retryBackOff returns the backoff of the retries of a delivery, with the defaults of the zero values.
+// A negative number of retries disables them.
+func retryBackOff(retries int, interval time.Duration, jitter float64) backoff.BackOff {
+	if retries < 0 {
+		// WithMaxRetries retries forever with zero
+		return &backoff.StopBackOff{}
+	} else if retries == 0 {
+		retries = defaultWebhookRetries
+	}
+	if interval <= 0 {
+		interval = defaultWebhookRetryInterval
+	}
+	b := utils.NewJitteredBackOff(interval, jitter, nil)
+	return backoff.WithMaxRetries(b, uint64(retries))
+}
@@ -0,0 +1,159 @@
+package report_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestWebhookWriter_Write(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "musl"},
+				{VulnerabilityID: "CVE-2020-0002", PkgName: "musl"},
+				{VulnerabilityID: "CVE-2020-0003", PkgName: "openssl"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0004", PkgName: "jquery"},
+				{VulnerabilityID: "CVE-2020-0005", PkgName: "lodash"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		batchSize   int
+		maxRetries  int
+		failures    int
+		wantBatches [][]string
+		wantErr     string
+	}{
+		{
+			name:      "happy path",
+			batchSize: 2,
+			wantBatches: [][]string{
+				{"CVE-2020-0001", "CVE-2020-0002"},
+				{"CVE-2020-0003", "CVE-2020-0004"},
+				{"CVE-2020-0005"},
+			},
+		},
+		{
+			name:      "no batching",
+			batchSize: 0,
+			wantBatches: [][]string{
+				{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004", "CVE-2020-0005"},
+			},
+		},
+		{
+			// the zero MaxRetries retries defaultWebhookRetries times
+			name:      "retry a failed batch",
+			batchSize: 5,
+			failures:  2,
+			wantBatches: [][]string{
+				{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004", "CVE-2020-0005"},
+			},
+		},
+		{
+			name:      "sad path: retries exhausted",
+			batchSize: 5,
+			failures:  10,
+			wantErr:   "failed to deliver 5 findings to the webhook",
+		},
+		{
+			name:       "sad path: retries disabled",
+			batchSize:  5,
+			maxRetries: -1,
+			failures:   1,
+			wantErr:    "failed to deliver 5 findings to the webhook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var gotBatches [][]string
+			failures := tt.failures
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if failures > 0 {
+					failures--
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				var payload struct {
+					Findings []report.WebhookFinding
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+
+				var ids []string
+				for _, f := range payload.Findings {
+					ids = append(ids, f.VulnerabilityID)
+				}
+				gotBatches = append(gotBatches, ids)
+			}))
+			defer ts.Close()
+
+			w := report.WebhookWriter{
+				URL:           ts.URL,
+				BatchSize:     tt.batchSize,
+				MaxRetries:    tt.maxRetries,
+				RetryInterval: time.Millisecond,
+			}
+			err := w.Write(results)
+			if tt.wantErr != "" {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBatches, gotBatches)
+		})
+	}
+}
+
+func TestWebhookWriter_DeliveryRate(t *testing.T) {
+	var vulns []types.DetectedVulnerability
+	for _, id := range []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004", "CVE-2020-0005"} {
+		vulns = append(vulns, types.DetectedVulnerability{VulnerabilityID: id})
+	}
+	start := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakeClock(start)
+
+	var mu sync.Mutex
+	var got []time.Duration
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, clock.Since(start))
+	}))
+	defer ts.Close()
+
+	w := report.WebhookWriter{
+		URL:          ts.URL,
+		BatchSize:    1,
+		DeliveryRate: 2,
+		Clock:        clock,
+	}
+	require.NoError(t, w.Write(report.Results{{Target: "app/package-lock.json", Vulnerabilities: vulns}}))
+	// a burst of one second's worth, then a finding every half a second
+	assert.Equal(t, []time.Duration{0, 0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond}, got)
+}
@@ -1,12 +1,10 @@
 package report
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
-	"text/template"
 
 	"golang.org/x/xerrors"
 
@@ -19,52 +17,281 @@ import (
 type Results []Result
 
 type Result struct {
-	Target          string                        `json:"Target"`
-	Type            string                        `json:"Type,omitempty"`
+	Target string `json:"Target"`
+	Type   string `json:"Type,omitempty"`
+	// Class tells which scan produced the result: ClassOSPkgs, ClassLangPkgs, ClassSecret, ClassConfig or ClassLicense
+	Class           string                        `json:"Class,omitempty"`
 	Vulnerabilities []types.DetectedVulnerability `json:"Vulnerabilities"`
+	YankedPackages  []types.YankedPackage         `json:"YankedPackages,omitempty"`
+	// UnmaintainedPackages are the archived and unmaintained packages kept by ScanOptions.ScanMaintenance
+	UnmaintainedPackages []types.UnmaintainedPackage `json:"UnmaintainedPackages,omitempty"`
+	Config               []types.ConfigFinding       `json:"Config,omitempty"`
+	// Secrets are the secrets found in the file of Target with the secret check
+	Secrets []types.SecretFinding `json:"Secrets,omitempty"`
+	// Misconfigurations are the policies failed by the config file of Target with the config check
+	Misconfigurations []types.Misconfiguration `json:"Misconfigurations,omitempty"`
+	// Licenses are the licenses of the packages of the category of Target with the license check
+	Licenses []types.DetectedLicense `json:"Licenses,omitempty"`
+	// Suppressed are the vulnerabilities dropped by the ignore file with ScanOptions.ShowSuppressed, and by VEX
+	Suppressed []types.SuppressedVulnerability `json:"Suppressed,omitempty"`
+	// Packages are all the packages of the target with ScanOptions.ListAllPackages
+	Packages []types.InstalledPackage `json:"Packages,omitempty"`
+	// Fallback is true when the vulnerabilities are detected by the fallback driver
+	Fallback bool `json:"Fallback,omitempty"`
+	// Truncated is the number of findings per severity dropped by ScanOptions.SeverityLimits
+	Truncated map[string]int `json:"Truncated,omitempty"`
+	// Uncommon has the findings below ScanOptions.MinAffectedCount when they are kept apart
+	Uncommon []types.DetectedVulnerability `json:"Uncommon,omitempty"`
+	// Deferred has the findings the vendor won't fix or deferred, apart from the others with ScanOptions.SeparateDeferred
+	Deferred []types.DetectedVulnerability `json:"Deferred,omitempty"`
+	// DuplicatePaths are the paths of the lock files with the same content as Target, with ScanOptions.DedupeIdenticalFiles
+	DuplicatePaths []string `json:"DuplicatePaths,omitempty"`
+	// EOSL is true when the OS of the result is no longer supported by the distribution
+	EOSL bool `json:"EOSL,omitempty"`
+	// EOL is the finding of the OS no longer supported of the result of ClassEOL, with ScanOptions.EOLSeverity
+	EOL *types.EOLFinding `json:"EOL,omitempty"`
+	// Status tells whether the target was scanned, skipped or failed, and StatusReason why it was not scanned
+	Status       string `json:"Status,omitempty"`
+	StatusReason string `json:"StatusReason,omitempty"`
 }
 
-func WriteResults(format string, output io.Writer, results Results, outputTemplate string, light bool) error {
+const (
+	ClassOSPkgs   = "os-pkgs"
+	ClassLangPkgs = "lang-pkgs"
+	ClassSecret   = "secret"
+	ClassConfig   = "config"
+	ClassLicense  = "license"
+	ClassEOL      = "eol"
+)
+
+const (
+	StatusScanned = "scanned"
+	StatusSkipped = "skipped"
+	StatusError   = "error"
+)
+
+// Option configures the writer of NewWriter
+type Option struct {
+	Format         string
+	Output         io.Writer
+	OutputTemplate string
+	Light          bool
+	// TopN is the number of findings of the top format
+	TopN int
+	// DependencyTree lists under the table of a lock file the chain of the packages requiring each vulnerable package,
+	// and breaks down its total into the vulnerabilities of direct and transitive dependencies
+	DependencyTree bool
+	// Report is ReportSummary to write the number of findings per severity of each target instead of the findings
+	Report string
+	// ArtifactName is the name of the scanned artifact, e.g. the image or the directory, the resource of the findings
+	// of the scc format and the name of the document of the spdx formats
+	ArtifactName string
+	// SCCSource is the Security Command Center source of the findings of the scc format, e.g. "organizations/123/sources/456"
+	SCCSource string
+	// KafkaBrokers and KafkaTopic are where the findings of the kafka format are published
+	KafkaBrokers []string
+	KafkaTopic   string
+	// ImageID keys the messages of the kafka format so that the findings of an image go to the same partition
+	ImageID string
+}
+
+func WriteResults(results Results, option Option) error {
+	writer, err := NewWriter(option)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Write(results); err != nil {
+		return xerrors.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// NewWriter returns the writer of the format, e.g. to wrap it in a PreWriteWriter
+func NewWriter(option Option) (Writer, error) {
+	output := option.Output
+	if option.Report == ReportSummary {
+		return &SummaryWriter{Output: output, Format: option.Format}, nil
+	}
+
 	var writer Writer
-	switch format {
+	switch option.Format {
 	case "table":
-		writer = &TableWriter{Output: output, Light: light}
+		writer = &TableWriter{Output: output, Light: option.Light, Color: IsColorEnabled(output),
+			DependencyTree: option.DependencyTree, DependencyCounts: option.DependencyTree}
 	case "json":
 		writer = &JsonWriter{Output: output}
+	case "top":
+		writer = &TopWriter{Output: output, N: option.TopN}
+	case "osv":
+		writer = &OSVWriter{Output: output}
+	case "sarif":
+		writer = &SARIFWriter{Output: output}
+	case "cyclonedx":
+		writer = &CycloneDXWriter{Output: output, Format: CycloneDXFormatJSON}
+	case "cyclonedx-xml":
+		writer = &CycloneDXWriter{Output: output, Format: CycloneDXFormatXML}
+	case "spdx":
+		writer = &SPDXWriter{Output: output, Format: SPDXFormatTagValue, ArtifactName: option.ArtifactName}
+	case "spdx-json":
+		writer = &SPDXWriter{Output: output, Format: SPDXFormatJSON, ArtifactName: option.ArtifactName}
+	case "gitlab":
+		writer = &GitLabWriter{Output: output}
+	case "scc":
+		writer = &SCCWriter{Output: output, Source: option.SCCSource, ResourceName: option.ArtifactName}
+	case "kafka":
+		producer, err := newKafkaProducer(option.KafkaBrokers)
+		if err != nil {
+			return nil, err
+		}
+		writer = &KafkaWriter{Producer: producer, Topic: option.KafkaTopic, ImageDigest: option.ImageID}
+	case "html":
+		writer = &HTMLWriter{Output: output}
 	case "template":
-		tmpl, err := template.New("output template").Parse(outputTemplate)
+		tw, err := NewTemplateWriter(output, option.OutputTemplate)
 		if err != nil {
-			return xerrors.Errorf("error parsing template: %w", err)
+			return nil, err
 		}
-		writer = &TemplateWriter{Output: output, Template: tmpl}
+		writer = tw
 	default:
-		return xerrors.Errorf("unknown format: %v", format)
-	}
-
-	if err := writer.Write(results); err != nil {
-		return xerrors.Errorf("failed to write results: %w", err)
+		return nil, xerrors.Errorf("unknown format: %v", option.Format)
 	}
-	return nil
+	return writer, nil
 }
 
 type Writer interface {
 	Write(Results) error
 }
 
+// PreWriteWriter transforms the results with Hook, e.g. to mask or reshape the findings, before writing them with Writer.
+// The hook is called once per write whatever the format.
+type PreWriteWriter struct {
+	Writer Writer
+	Hook   func(Results) Results
+}
+
+func (pw PreWriteWriter) Write(results Results) error {
+	if pw.Hook != nil {
+		results = pw.Hook(results)
+	}
+	return pw.Writer.Write(results)
+}
+
 type TableWriter struct {
 	Output io.Writer
 	Light  bool
+
+	// Color wraps severity labels in ANSI escape codes
+	Color bool
+
+	// LayerHistogram appends the number of vulnerabilities per severity in each layer
+	LayerHistogram bool
+
+	// ByInstruction appends the vulnerabilities introduced by each Dockerfile instruction
+	ByInstruction bool
+
+	// VulnerableBytes appends the size of each layer introducing vulnerabilities
+	VulnerableBytes bool
+
+	// CVSSSources appends a column with the CVSS base score of each source (e.g. nvd, redhat).
+	// At most MaxCVSSSources can be given to keep the table readable.
+	CVSSSources []string
+
+	// FixStatus appends a column telling at a glance whether each vulnerability has a fix
+	FixStatus bool
+
+	// DependencyCounts breaks down the total of the libraries into the vulnerabilities of direct
+	// and transitive dependencies
+	DependencyCounts bool
+
+	// DependencyTree lists under the table of a lock file the chain of the packages requiring each vulnerable package,
+	// from types.DetectedVulnerability.DependencyPath
+	DependencyTree bool
+}
+
+// MaxCVSSSources is the maximum number of CVSS columns in the table
+const MaxCVSSSources = 2
+
+var severityColors = map[string]string{
+	"CRITICAL": "\x1b[31m", // red
+	"HIGH":     "\x1b[35m", // magenta
+	"MEDIUM":   "\x1b[33m", // yellow
+	"LOW":      "\x1b[34m", // blue
+	"UNKNOWN":  "\x1b[36m", // cyan
+}
+
+const colorReset = "\x1b[0m"
+
+// fixStatus is the marker of the fix column: whether a fixed version is known, why not when the vendor decided it,
+// and whether the installed version already satisfies it
+func fixStatus(v types.DetectedVulnerability) string {
+	switch {
+	case v.FixedVersion == "" && v.Status == types.StatusWillNotFix:
+		return "will not fix"
+	case v.FixedVersion == "" && v.Status == types.StatusFixDeferred:
+		return "fix deferred"
+	case v.FixedVersion == "" && v.Status == types.StatusEndOfLife:
+		return "end of life"
+	case v.FixedVersion == "":
+		return "no fix"
+	case v.IsFixed:
+		return "fixed"
+	default:
+		return "fix"
+	}
+}
+
+// IsColorEnabled reports whether the output is a terminal and NO_COLOR is not set
+func IsColorEnabled(output io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func colorizeSeverity(severity string) string {
+	c, ok := severityColors[severity]
+	if !ok {
+		return severity
+	}
+	return c + severity + colorReset
 }
 
 func (tw TableWriter) Write(results Results) error {
+	if len(tw.CVSSSources) > MaxCVSSSources {
+		return xerrors.Errorf("too many CVSS sources: %d (max %d)", len(tw.CVSSSources), MaxCVSSSources)
+	}
 	for _, result := range results {
 		tw.write(result)
 	}
+	if tw.LayerHistogram {
+		writeLayerHistograms(tw.Output, NewLayerHistograms(results))
+	}
+	if tw.ByInstruction {
+		writeInstructionGroups(tw.Output, NewInstructionGroups(results))
+	}
+	if tw.VulnerableBytes {
+		writeVulnerableLayers(tw.Output, NewVulnerableLayers(results))
+	}
 	return nil
 }
 func (tw TableWriter) write(result Result) {
 	table := tablewriter.NewWriter(tw.Output)
 	header := []string{"Library", "Vulnerability ID", "Severity", "Installed Version", "Fixed Version"}
+	if tw.FixStatus {
+		header = append(header, "Fix")
+	}
+	for _, source := range tw.CVSSSources {
+		header = append(header, source+" CVSS")
+	}
 	if !tw.Light {
 		header = append(header, "Title")
 	}
@@ -82,12 +309,20 @@ func (tw TableWriter) write(result Result) {
 		if len(splittedTitle) >= 12 {
 			title = strings.Join(splittedTitle[:12], " ") + "..."
 		}
-		var row []string
-		if tw.Output == os.Stdout {
-			row = []string{v.PkgName, v.VulnerabilityID, dbTypes.ColorizeSeverity(v.Severity),
-				v.InstalledVersion, v.FixedVersion}
-		} else {
-			row = []string{v.PkgName, v.VulnerabilityID, v.Severity, v.InstalledVersion, v.FixedVersion}
+		severity := v.Severity
+		if tw.Color {
+			severity = colorizeSeverity(v.Severity)
+		}
+		row := []string{v.PkgName, v.VulnerabilityID, severity, v.InstalledVersion, v.FixedVersion}
+		if tw.FixStatus {
+			row = append(row, fixStatus(v))
+		}
+		for _, source := range tw.CVSSSources {
+			score := "-"
+			if cvss, ok := v.CVSS[source]; ok && cvss.BaseScore() > 0 {
+				score = fmt.Sprintf("%.1f", cvss.BaseScore())
+			}
+			row = append(row, score)
 		}
 
 		if !tw.Light {
@@ -104,43 +339,210 @@ func (tw TableWriter) write(result Result) {
 
 	fmt.Printf("\n%s\n", result.Target)
 	fmt.Println(strings.Repeat("=", len(result.Target)))
+	if result.Status == StatusSkipped || result.Status == StatusError {
+		fmt.Fprintf(tw.Output, "%s: %s\n", strings.Title(result.Status), result.StatusReason)
+		return
+	}
+	if result.Class == ClassSecret {
+		tw.writeSecrets(result.Secrets)
+		return
+	}
+	if result.Class == ClassConfig {
+		tw.writeMisconfs(result.Misconfigurations)
+		return
+	}
+	if result.Class == ClassLicense {
+		tw.writeLicenses(result.Licenses)
+		return
+	}
+	if result.Class == ClassEOL && result.EOL != nil {
+		tw.writeEOL(*result.EOL)
+		return
+	}
 	fmt.Printf("Total: %d (%s)\n\n", len(result.Vulnerabilities), strings.Join(results, ", "))
+	if tw.DependencyCounts && result.Class != ClassOSPkgs && len(result.Vulnerabilities) > 0 {
+		fmt.Fprintf(tw.Output, "%s\n\n", CountDependencies(result.Vulnerabilities))
+	}
 
-	if len(result.Vulnerabilities) == 0 {
-		return
+	if len(result.Vulnerabilities) > 0 {
+		table.SetAutoMergeCells(true)
+		table.SetRowLine(true)
+		table.Render()
+
+		for _, severity := range dbTypes.SeverityNames {
+			if n := result.Truncated[severity]; n > 0 {
+				fmt.Fprintf(tw.Output, "%d more %s findings are truncated\n", n, severity)
+			}
+		}
+	}
+
+	if tw.DependencyTree && result.Class != ClassOSPkgs {
+		writeDependencyOrigins(tw.Output, result.Vulnerabilities)
+	}
+
+	if len(result.Deferred) > 0 {
+		tw.writeDeferred(result.Deferred)
+	}
+
+	if len(result.UnmaintainedPackages) > 0 {
+		tw.writeUnmaintained(result.UnmaintainedPackages)
+	}
+
+	if len(result.Suppressed) > 0 {
+		tw.writeSuppressed(result.Suppressed)
+	}
+}
+
+// writeDeferred lists the vulnerabilities the vendor won't fix or deferred apart from the actionable ones
+func (tw TableWriter) writeDeferred(vulns []types.DetectedVulnerability) {
+	fmt.Fprintf(tw.Output, "\nDeferred by the vendor: %d\n\n", len(vulns))
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Library", "Vulnerability ID", "Severity", "Installed Version", "Status"})
+	for _, v := range vulns {
+		severity := v.Severity
+		if tw.Color {
+			severity = colorizeSeverity(v.Severity)
+		}
+		table.Append([]string{v.PkgName, v.VulnerabilityID, severity, v.InstalledVersion, fixStatus(v)})
+	}
+	table.Render()
+}
+
+// writeUnmaintained lists the archived and unmaintained packages apart from the vulnerabilities
+func (tw TableWriter) writeUnmaintained(pkgs []types.UnmaintainedPackage) {
+	fmt.Fprintf(tw.Output, "\nUnmaintained packages: %d\n\n", len(pkgs))
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Library", "Installed Version", "Status"})
+	for _, pkg := range pkgs {
+		table.Append([]string{pkg.PkgName, pkg.InstalledVersion, pkg.Status})
+	}
+	table.Render()
+}
+
+// writeSuppressed lists the vulnerabilities dropped by the ignore file and the VEX document apart from the others.
+// The statement of VEX starts with its status and justification, e.g. "VEX not_affected (vulnerable_code_not_present)".
+func (tw TableWriter) writeSuppressed(vulns []types.SuppressedVulnerability) {
+	fmt.Fprintf(tw.Output, "\nSuppressed vulnerabilities: %d\n\n", len(vulns))
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Library", "Vulnerability ID", "Statement", "Expires"})
+	for _, v := range vulns {
+		expires := ""
+		if v.ExpiredAt != nil {
+			expires = v.ExpiredAt.Format("2006-01-02")
+		}
+		statement := v.Statement
+		if v.VEXStatus != "" {
+			status := "VEX " + v.VEXStatus
+			if v.VEXJustification != "" {
+				status += " (" + v.VEXJustification + ")"
+			}
+			statement = strings.TrimSuffix(status+": "+v.Statement, ": ")
+		}
+		table.Append([]string{v.PkgName, v.VulnerabilityID, statement, expires})
+	}
+	table.Render()
+}
+
+// writeSecrets lists the secrets of a file with their masked lines
+func (tw TableWriter) writeSecrets(secrets []types.SecretFinding) {
+	severityCount := map[string]int{}
+	for _, s := range secrets {
+		severityCount[s.Severity]++
 	}
+	var counts []string
+	for _, severity := range dbTypes.SeverityNames {
+		counts = append(counts, fmt.Sprintf("%s: %d", severity, severityCount[severity]))
+	}
+	fmt.Fprintf(tw.Output, "Secrets: %d (%s)\n\n", len(secrets), strings.Join(counts, ", "))
 
-	table.SetAutoMergeCells(true)
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Category", "Rule ID", "Severity", "Line", "Match"})
+	for _, s := range secrets {
+		severity := s.Severity
+		if tw.Color {
+			severity = colorizeSeverity(s.Severity)
+		}
+		line := fmt.Sprint(s.StartLine)
+		if s.EndLine > s.StartLine {
+			line = fmt.Sprintf("%d-%d", s.StartLine, s.EndLine)
+		}
+		table.Append([]string{s.Category, s.RuleID, severity, line, s.Match})
+	}
 	table.SetRowLine(true)
 	table.Render()
-	return
 }
 
-type JsonWriter struct {
-	Output io.Writer
+// writeMisconfs lists the misconfigurations of a config file
+func (tw TableWriter) writeMisconfs(misconfs []types.Misconfiguration) {
+	severityCount := map[string]int{}
+	for _, m := range misconfs {
+		severityCount[m.Severity]++
+	}
+	var counts []string
+	for _, severity := range dbTypes.SeverityNames {
+		counts = append(counts, fmt.Sprintf("%s: %d", severity, severityCount[severity]))
+	}
+	fmt.Fprintf(tw.Output, "Misconfigurations: %d (%s)\n\n", len(misconfs), strings.Join(counts, ", "))
+
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"ID", "Title", "Severity", "Message"})
+	for _, m := range misconfs {
+		severity := m.Severity
+		if tw.Color {
+			severity = colorizeSeverity(m.Severity)
+		}
+		table.Append([]string{m.ID, m.Title, severity, m.Message})
+	}
+	table.SetRowLine(true)
+	table.Render()
 }
 
-func (jw JsonWriter) Write(results Results) error {
-	output, err := json.MarshalIndent(results, "", "  ")
-	if err != nil {
-		return xerrors.Errorf("failed to marshal json: %w", err)
+// writeEOL writes the finding of the OS no longer supported by its distribution
+func (tw TableWriter) writeEOL(finding types.EOLFinding) {
+	severity := finding.Severity
+	if tw.Color {
+		severity = colorizeSeverity(finding.Severity)
 	}
+	fmt.Fprintf(tw.Output, "End of life: %s (%s)\n\n", finding.Message, severity)
+}
 
-	if _, err = fmt.Fprint(jw.Output, string(output)); err != nil {
-		return xerrors.Errorf("failed to write json: %w", err)
+// writeLicenses lists the licenses of the packages, with the forbidden ones marked
+func (tw TableWriter) writeLicenses(licenses []types.DetectedLicense) {
+	forbidden := 0
+	for _, l := range licenses {
+		if l.Forbidden {
+			forbidden++
+		}
 	}
-	return nil
+	fmt.Fprintf(tw.Output, "Licenses: %d (forbidden: %d)\n\n", len(licenses), forbidden)
+
+	table := tablewriter.NewWriter(tw.Output)
+	table.SetHeader([]string{"Package", "License", "Forbidden"})
+	for _, l := range licenses {
+		mark := ""
+		if l.Forbidden {
+			mark = "yes"
+			if tw.Color {
+				mark = severityColors["CRITICAL"] + mark + colorReset
+			}
+		}
+		table.Append([]string{l.PkgName, l.Name, mark})
+	}
+	table.Render()
 }
 
-type TemplateWriter struct {
-	Output   io.Writer
-	Template *template.Template
+// JsonWriter writes the bare results, streamed one at a time
+type JsonWriter struct {
+	Output io.Writer
+
+	// FieldNames renames the fields of the findings, e.g. {"VulnerabilityID": "cve_id"}.
+	// The fields not in it keep their names.
+	FieldNames map[string]string
 }
 
-func (tw TemplateWriter) Write(results Results) error {
-	err := tw.Template.Execute(tw.Output, results)
-	if err != nil {
-		return xerrors.Errorf("failed to write with template: %w", err)
+func (jw JsonWriter) Write(results Results) error {
+	if err := writeJSONResults(jw.Output, "", results, jw.FieldNames); err != nil {
+		return xerrors.Errorf("failed to write json: %w", err)
 	}
 	return nil
 }
@@ -3,15 +3,26 @@ package report_test
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/pkg/types"
 )
 
+func TestMain(m *testing.M) {
+	log.InitLogger(false, true)
+	os.Exit(m.Run())
+}
+
 func TestReportWriter_Table(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -122,12 +133,78 @@ func TestReportWriter_Table(t *testing.T) {
 				},
 			}
 			tableWritten := bytes.Buffer{}
-			assert.NoError(t, report.WriteResults("table", &tableWritten, inputResults, "", tc.light), tc.name)
+			assert.NoError(t, report.WriteResults(inputResults, report.Option{Format: "table", Output: &tableWritten, Light: tc.light}), tc.name)
 			assert.Equal(t, tc.expectedOutput, tableWritten.String(), tc.name)
 		})
 	}
 }
 
+func TestTableWriter_Color(t *testing.T) {
+	testCases := []struct {
+		name     string
+		color    bool
+		severity string
+		contains string
+	}{
+		{
+			name:     "critical with color",
+			color:    true,
+			severity: "CRITICAL",
+			contains: "\x1b[31mCRITICAL\x1b[0m",
+		},
+		{
+			name:     "high with color",
+			color:    true,
+			severity: "HIGH",
+			contains: "\x1b[35mHIGH\x1b[0m",
+		},
+		{
+			name:     "high without color",
+			color:    false,
+			severity: "HIGH",
+			contains: "| HIGH     |",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tableWritten := bytes.Buffer{}
+			tw := report.TableWriter{Output: &tableWritten, Light: true, Color: tc.color}
+			err := tw.Write(report.Results{
+				{
+					Target: "foo",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID:  "123",
+							PkgName:          "foo",
+							InstalledVersion: "1.2.3",
+							FixedVersion:     "3.4.5",
+							Vulnerability:    dbTypes.Vulnerability{Severity: tc.severity},
+						},
+					},
+				},
+			})
+			assert.NoError(t, err, tc.name)
+			assert.Contains(t, tableWritten.String(), tc.contains, tc.name)
+			if !tc.color {
+				assert.NotContains(t, tableWritten.String(), "\x1b[", tc.name)
+			}
+		})
+	}
+}
+
+func TestIsColorEnabled(t *testing.T) {
+	t.Run("not a terminal", func(t *testing.T) {
+		assert.False(t, report.IsColorEnabled(&bytes.Buffer{}))
+	})
+
+	t.Run("NO_COLOR is set", func(t *testing.T) {
+		os.Setenv("NO_COLOR", "1")
+		defer os.Unsetenv("NO_COLOR")
+		assert.False(t, report.IsColorEnabled(os.Stdout))
+	})
+}
+
 func TestReportWriter_JSON(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -184,7 +261,7 @@ func TestReportWriter_JSON(t *testing.T) {
 				},
 			}
 
-			assert.NoError(t, report.WriteResults("json", &jsonWritten, inputResults, "", false), tc.name)
+			assert.NoError(t, report.WriteResults(inputResults, report.Option{Format: "json", Output: &jsonWritten}), tc.name)
 
 			writtenResults := report.Results{}
 			errJson := json.Unmarshal([]byte(jsonWritten.String()), &writtenResults)
@@ -196,6 +273,91 @@ func TestReportWriter_JSON(t *testing.T) {
 
 }
 
+func TestJsonWriter_FieldNames(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.2)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2019-14697",
+					PkgName:          "musl",
+					InstalledVersion: "1.1.22-r2",
+					Vulnerability: dbTypes.Vulnerability{
+						Severity: "HIGH",
+					},
+				},
+			},
+		},
+	}
+
+	output := bytes.Buffer{}
+	jw := report.JsonWriter{
+		Output:     &output,
+		FieldNames: map[string]string{"VulnerabilityID": "cve_id", "PkgName": "package"},
+	}
+	require.NoError(t, jw.Write(results))
+	assert.Equal(t, `[
+  {
+    "Target": "alpine:3.10 (alpine 3.10.2)",
+    "Type": "alpine",
+    "Vulnerabilities": [
+      {
+        "cve_id": "CVE-2019-14697",
+        "package": "musl",
+        "InstalledVersion": "1.1.22-r2",
+        "Layer": {},
+        "Severity": "HIGH"
+      }
+    ]
+  }
+]`, output.String())
+}
+
+func TestJsonWriter_CVSSOrder(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.2)",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID: "CVE-2019-14697",
+					CVSS: types.VendorCVSS{
+						"redhat": {V3Score: 7.5},
+						"nvd":    {V2Score: 7.5, V3Score: 9.8},
+						"alpine": {V3Score: 8.1},
+						"debian": {V3Score: 9.8},
+					},
+				},
+			},
+		},
+	}
+
+	write := func() string {
+		output := bytes.Buffer{}
+		require.NoError(t, report.JsonWriter{Output: &output}.Write(results))
+		return output.String()
+	}
+	first := write()
+	for i := 0; i < 10; i++ {
+		require.Equal(t, first, write())
+	}
+	assert.Contains(t, first, `"CVSS": {
+          "alpine": {
+            "V3Score": 8.1
+          },
+          "debian": {
+            "V3Score": 9.8
+          },
+          "nvd": {
+            "V2Score": 7.5,
+            "V3Score": 9.8
+          },
+          "redhat": {
+            "V3Score": 7.5
+          }
+        }`)
+}
+
 func TestReportWriter_Template(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -230,6 +392,29 @@ func TestReportWriter_Template(t *testing.T) {
 			template: "{{ range . }}{{ range .Vulnerabilities}}{{ println .VulnerabilityID .Severity }}{{ end }}{{ end }}",
 			expected: "CVE-2019-0000 HIGH\nCVE-2019-0000 HIGH\nCVE-2019-0001 CRITICAL\n",
 		},
+		{
+			name: "escapeXML",
+			detectedVulns: []types.DetectedVulnerability{
+				{
+					VulnerabilityID: "CVE-2019-0000",
+					PkgName:         "foo",
+					Vulnerability:   dbTypes.Vulnerability{Title: `<script>alert("foo & bar")</script>`},
+				},
+			},
+			template: "{{ range . }}{{ range .Vulnerabilities}}<td>{{ escapeXML .Title }}</td>{{ end }}{{ end }}",
+			expected: "<td>&lt;script&gt;alert(&#34;foo &amp; bar&#34;)&lt;/script&gt;</td>",
+		},
+		{
+			name: "severityCount",
+			detectedVulns: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-0000", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-0001", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-0002"},
+			},
+			template: `{{ $c := severityCount . }}{{ $c.HIGH }} high, {{ $c.CRITICAL }} critical, {{ $c.UNKNOWN }} unknown` +
+				`{{ range . }}; {{ .Target }}: {{ (severityCount .).HIGH }}{{ end }}`,
+			expected: "2 high, 0 critical, 1 unknown; foojson: 2",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -241,8 +426,540 @@ func TestReportWriter_Template(t *testing.T) {
 				},
 			}
 
-			assert.NoError(t, report.WriteResults("template", &tmplWritten, inputResults, tc.template, false))
+			assert.NoError(t, report.WriteResults(inputResults, report.Option{Format: "template", Output: &tmplWritten, OutputTemplate: tc.template}))
 			assert.Equal(t, tc.expected, tmplWritten.String())
 		})
 	}
 }
+
+func TestNewTemplateWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	templateFile := filepath.Join(dir, "summary.tpl")
+	require.NoError(t, ioutil.WriteFile(templateFile, []byte("{{ range . }}{{ .Target }}{{ end }}"), 0600))
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "template file",
+			template: "@" + templateFile,
+			want:     "alpine:3.11 (alpine 3.11.5)",
+		},
+		{
+			name:     "missing template file",
+			template: "@" + filepath.Join(dir, "missing.tpl"),
+			wantErr:  "error retrieving template from path",
+		},
+		{
+			name:     "malformed template",
+			template: "{{ range . }}{{ .Target }}",
+			wantErr:  "error parsing template",
+		},
+		{
+			name:     "unknown function",
+			template: "{{ escapeHTML . }}",
+			wantErr:  "error parsing template",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw, err := report.NewTemplateWriter(&buf, tt.template)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, tw.Write(report.Results{{Target: "alpine:3.11 (alpine 3.11.5)"}}))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestTemplateWriter_JUnit(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					Vulnerability: dbTypes.Vulnerability{
+						Title:       "openssl: Segmentation fault in SSL_check_chain",
+						Description: "A NULL pointer dereference with <signature_algorithms_cert>",
+						Severity:    "HIGH",
+					},
+				},
+				{
+					VulnerabilityID:  "GHSA-xxxx",
+					PkgName:          "lodash",
+					InstalledVersion: "4.17.4",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	tw, err := report.NewTemplateWriter(&buf, "junit")
+	require.NoError(t, err)
+	require.NoError(t, tw.Write(results))
+	assert.Equal(t, `<?xml version="1.0" ?>
+<testsuites>
+    <testsuite tests="2" failures="2" name="alpine:3.11 (alpine 3.11.5)" errors="0" skipped="0" time="">
+        <properties>
+            <property name="type" value="alpine"></property>
+        </properties>
+        <testcase classname="openssl-1.1.1d-r3" name="[HIGH] CVE-2020-1967" time="">
+            <failure message="openssl: Segmentation fault in SSL_check_chain" type="description">A NULL pointer dereference with &lt;signature_algorithms_cert&gt;
+https://nvd.nist.gov/vuln/detail/CVE-2020-1967</failure>
+        </testcase>
+        <testcase classname="lodash-4.17.4" name="[LOW] GHSA-xxxx" time="">
+            <failure message="" type="description"></failure>
+        </testcase>
+    </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func TestTemplateWriter_Funcs(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "colorizeSeverity",
+			template: `{{ colorizeSeverity "CRITICAL" }} {{ colorizeSeverity "NONE" }}`,
+			want:     "\x1b[31mCRITICAL\x1b[0m NONE",
+		},
+		{
+			name:     "severityColor",
+			template: `{{ severityColor "HIGH" }} {{ severityColor "NONE" }}`,
+			want:     "#c2185b #000000",
+		},
+		{
+			name:     "cveURL",
+			template: `{{ cveURL "CVE-2019-11358" }}|{{ cveURL "NSWG-ECO-428" }}`,
+			want:     "https://nvd.nist.gov/vuln/detail/CVE-2019-11358|",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw, err := report.NewTemplateWriter(&buf, tt.template)
+			require.NoError(t, err)
+			require.NoError(t, tw.Write(nil))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestTableWriter_CVSSSources(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "foo",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					CVSS: types.VendorCVSS{
+						"nvd":    {V2Score: 5.0, V3Score: 7.5},
+						"redhat": {V2Score: 4.3},
+						"ubuntu": {V3Score: 5.9},
+					},
+					Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"},
+				},
+				{
+					VulnerabilityID:  "CVE-2020-1968",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					CVSS: types.VendorCVSS{
+						"nvd": {V3Score: 3.7},
+					},
+					Vulnerability: dbTypes.Vulnerability{Severity: "LOW"},
+				},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true, CVSSSources: []string{"nvd", "redhat"}}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `+---------+------------------+----------+-------------------+---------------+----------+-------------+
+| LIBRARY | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION | NVD CVSS | REDHAT CVSS |
++---------+------------------+----------+-------------------+---------------+----------+-------------+
+| openssl | CVE-2020-1967    | HIGH     | 1.1.1d-r3         | 1.1.1g-r0     |      7.5 |         4.3 |
++         +------------------+----------+                   +---------------+----------+-------------+
+|         | CVE-2020-1968    | LOW      |                   |               |      3.7 | -           |
++---------+------------------+----------+-------------------+---------------+----------+-------------+
+`, tableWritten.String())
+
+	tw.CVSSSources = []string{"nvd", "redhat", "ubuntu"}
+	assert.Error(t, tw.Write(results))
+}
+
+func TestTableWriter_FixStatus(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "foo",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+				},
+				{
+					VulnerabilityID:  "CVE-2020-1968",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+				},
+				{
+					VulnerabilityID:  "CVE-2019-1549",
+					PkgName:          "libcrypto",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1d-r0",
+					IsFixed:          true,
+					Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+				},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true, FixStatus: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `+-----------+------------------+----------+-------------------+---------------+--------+
+|  LIBRARY  | VULNERABILITY ID | SEVERITY | INSTALLED VERSION | FIXED VERSION |  FIX   |
++-----------+------------------+----------+-------------------+---------------+--------+
+| openssl   | CVE-2020-1967    | HIGH     | 1.1.1d-r3         | 1.1.1g-r0     | fix    |
++           +------------------+----------+                   +---------------+--------+
+|           | CVE-2020-1968    | LOW      |                   |               | no fix |
++-----------+------------------+----------+                   +---------------+--------+
+| libcrypto | CVE-2019-1549    | MEDIUM   |                   | 1.1.1d-r0     | fixed  |
++-----------+------------------+----------+-------------------+---------------+--------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_Deferred(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "centos:7 (centos 7.6.1810)",
+			Deferred: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2017-5953",
+					PkgName:          "vim-minimal",
+					InstalledVersion: "2:7.4.160-5.el7",
+					Status:           types.StatusWillNotFix,
+					Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+				},
+				{
+					VulnerabilityID:  "CVE-2017-6350",
+					PkgName:          "vim-minimal",
+					InstalledVersion: "2:7.4.160-5.el7",
+					Status:           types.StatusFixDeferred,
+					Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+				},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `
+Deferred by the vendor: 2
+
++-------------+------------------+----------+-------------------+--------------+
+|   LIBRARY   | VULNERABILITY ID | SEVERITY | INSTALLED VERSION |    STATUS    |
++-------------+------------------+----------+-------------------+--------------+
+| vim-minimal | CVE-2017-5953    | LOW      | 2:7.4.160-5.el7   | will not fix |
+| vim-minimal | CVE-2017-6350    | MEDIUM   | 2:7.4.160-5.el7   | fix deferred |
++-------------+------------------+----------+-------------------+--------------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_UnmaintainedPackages(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			UnmaintainedPackages: []types.UnmaintainedPackage{
+				{PkgName: "request", InstalledVersion: "2.88.0", Status: types.MaintenanceArchived},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `
+Unmaintained packages: 1
+
++---------+-------------------+----------+
+| LIBRARY | INSTALLED VERSION |  STATUS  |
++---------+-------------------+----------+
+| request | 2.88.0            | archived |
++---------+-------------------+----------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_Secrets(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "app/.env",
+			Class:  report.ClassSecret,
+			Secrets: []types.SecretFinding{
+				{RuleID: "aws-access-key-id", Category: "AWS", Severity: "CRITICAL", StartLine: 2, EndLine: 2, Match: "AWS_ACCESS_KEY_ID=*****"},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `Secrets: 1 (UNKNOWN: 0, LOW: 0, MEDIUM: 0, HIGH: 0, CRITICAL: 1)
+
++----------+-------------------+----------+------+-------------------------+
+| CATEGORY |      RULE ID      | SEVERITY | LINE |          MATCH          |
++----------+-------------------+----------+------+-------------------------+
+| AWS      | aws-access-key-id | CRITICAL |    2 | AWS_ACCESS_KEY_ID=***** |
++----------+-------------------+----------+------+-------------------------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_Misconfigurations(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "Dockerfile",
+			Type:   "dockerfile",
+			Class:  report.ClassConfig,
+			Misconfigurations: []types.Misconfiguration{
+				{ID: "DS002", Title: "Image user should not be 'root'", Severity: "HIGH", Message: "The last USER command of the last stage switches to 'root'"},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `Misconfigurations: 1 (UNKNOWN: 0, LOW: 0, MEDIUM: 0, HIGH: 1, CRITICAL: 0)
+
++-------+--------------------------------+----------+--------------------------------+
+|  ID   |             TITLE              | SEVERITY |            MESSAGE             |
++-------+--------------------------------+----------+--------------------------------+
+| DS002 | Image user should not be       | HIGH     | The last USER command of the   |
+|       | 'root'                         |          | last stage switches to 'root'  |
++-------+--------------------------------+----------+--------------------------------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_EOL(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.9 (alpine 3.9.6)",
+			Type:   "alpine",
+			Class:  report.ClassEOL,
+			EOL: &types.EOLFinding{
+				Family:   "alpine",
+				Name:     "3.9.6",
+				Severity: "HIGH",
+				Message:  "alpine 3.9.6 is no longer supported by the distribution since 2020-11-01, security updates are not provided",
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, "End of life: alpine 3.9.6 is no longer supported by the distribution since 2020-11-01, "+
+		"security updates are not provided (HIGH)\n\n", tableWritten.String())
+}
+
+func TestTableWriter_Skipped(t *testing.T) {
+	results := report.Results{
+		{
+			Target:       "app/Pipfile.lock",
+			Type:         "pipenv",
+			Status:       report.StatusSkipped,
+			StatusReason: "no advisories of pipenv in the DB",
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, "Skipped: no advisories of pipenv in the DB\n", tableWritten.String())
+}
+
+func TestTableWriter_Licenses(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "OS Packages",
+			Class:  report.ClassLicense,
+			Licenses: []types.DetectedLicense{
+				{PkgName: "bash", Name: "GPL-3.0-or-later", Forbidden: true},
+				{PkgName: "musl", Name: "MIT"},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `Licenses: 2 (forbidden: 1)
+
++---------+------------------+-----------+
+| PACKAGE |     LICENSE      | FORBIDDEN |
++---------+------------------+-----------+
+| bash    | GPL-3.0-or-later | yes       |
+| musl    | MIT              |           |
++---------+------------------+-----------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_Suppressed(t *testing.T) {
+	expiredAt := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			Suppressed: []types.SuppressedVulnerability{
+				{
+					DetectedVulnerability: types.DetectedVulnerability{VulnerabilityID: "CVE-2019-11358", PkgName: "jquery"},
+					Statement:             "jQuery isn't served",
+					ExpiredAt:             &expiredAt,
+				},
+				{
+					DetectedVulnerability: types.DetectedVulnerability{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist"},
+					Statement:             "Only trusted arguments",
+					VEXStatus:             "not_affected",
+					VEXJustification:      "inline_mitigations_already_exist",
+				},
+			},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten}
+	assert.NoError(t, tw.Write(results))
+	assert.Equal(t, `
+Suppressed vulnerabilities: 2
+
++----------+------------------+-------------------------------------+------------+
+| LIBRARY  | VULNERABILITY ID |              STATEMENT              |  EXPIRES   |
++----------+------------------+-------------------------------------+------------+
+| jquery   | CVE-2019-11358   | jQuery isn't served                 | 2020-06-01 |
+| minimist | CVE-2020-7598    | VEX not_affected                    |            |
+|          |                  | (inline_mitigations_already_exist): |            |
+|          |                  | Only trusted arguments              |            |
++----------+------------------+-------------------------------------+------------+
+`, tableWritten.String())
+}
+
+func TestTableWriter_Truncated(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "foo",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1968",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+				},
+			},
+			Truncated: map[string]int{"LOW": 4, "MEDIUM": 1},
+		},
+	}
+
+	tableWritten := bytes.Buffer{}
+	tw := report.TableWriter{Output: &tableWritten, Light: true}
+	assert.NoError(t, tw.Write(results))
+	assert.Contains(t, tableWritten.String(), "4 more LOW findings are truncated\n1 more MEDIUM findings are truncated\n")
+}
+
+func TestPreWriteWriter(t *testing.T) {
+	newResults := func() report.Results {
+		return report.Results{
+			{
+				Target: "foo",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{
+						VulnerabilityID:  "CVE-2020-1968",
+						PkgName:          "openssl",
+						InstalledVersion: "1.1.1d-r3",
+						Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+					},
+				},
+			},
+		}
+	}
+
+	for _, format := range []string{"json", "table"} {
+		t.Run(format, func(t *testing.T) {
+			var calls int
+			hook := func(results report.Results) report.Results {
+				calls++
+				for _, result := range results {
+					for i := range result.Vulnerabilities {
+						result.Vulnerabilities[i].Severity = "CRITICAL"
+					}
+				}
+				return results
+			}
+
+			written := bytes.Buffer{}
+			writer, err := report.NewWriter(report.Option{Format: format, Output: &written, Light: true})
+			require.NoError(t, err)
+			require.NoError(t, report.PreWriteWriter{Writer: writer, Hook: hook}.Write(newResults()))
+			assert.Equal(t, 1, calls)
+			assert.Contains(t, written.String(), "CRITICAL")
+			assert.NotContains(t, written.String(), "LOW")
+		})
+	}
+}
+
+func TestJsonWriter_Stream(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.10 (alpine 3.10.2)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", InstalledVersion: "1.1.22-r2"},
+			},
+			Packages: []types.InstalledPackage{{Name: "musl", Version: "1.1.22-r2"}},
+		},
+		{Target: "app/package-lock.json", Type: "npm"},
+		{Target: "app/Gemfile.lock", Type: "bundler", Vulnerabilities: []types.DetectedVulnerability{}},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		results report.Results
+	}{
+		{name: "several results", results: results},
+		{name: "no result", results: report.Results{}},
+		{name: "nil", results: nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.MarshalIndent(tt.results, "", "  ")
+			require.NoError(t, err)
+
+			output := bytes.Buffer{}
+			require.NoError(t, report.JsonWriter{Output: &output}.Write(tt.results))
+			assert.Equal(t, string(want), output.String(), "the streamed results are written as a whole")
+		})
+	}
+}
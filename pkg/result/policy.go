@@ -0,0 +1,112 @@
+package result
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// policyPackage is the package of the ignore policies
+const policyPackage = "data.trivy"
+
+// PolicyFilter drops the vulnerabilities ignored by a Rego policy of the package trivy.
+// Its ignore rule is evaluated against each vulnerability, with the fields of types.DetectedVulnerability
+// without the empty ones, and the Target and Type of its result, e.g.
+//
+//	package trivy
+//
+//	default ignore = false
+//
+//	ignore {
+//	  contains(input.Target, "distroless")
+//	  not input.FixedVersion
+//	}
+type PolicyFilter struct {
+	query rego.PreparedEvalQuery
+}
+
+// NewPolicyFilter compiles the policy file
+func NewPolicyFilter(ctx context.Context, policyFile string) (*PolicyFilter, error) {
+	content, err := ioutil.ReadFile(policyFile)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read the policy file: %w", err)
+	}
+	module, err := ast.ParseModule(policyFile, string(content))
+	if err != nil {
+		return nil, xerrors.Errorf("invalid policy: %w", err)
+	}
+	if module.Package.Path.String() != policyPackage {
+		return nil, xerrors.Errorf("the package of the policy is %s instead of trivy",
+			module.Package.Path.String()[len("data."):])
+	}
+	compiler, err := ast.CompileModules(map[string]string{policyFile: string(content)})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to compile the policy: %w", err)
+	}
+
+	query, err := rego.New(rego.Query(policyPackage+".ignore"), rego.Compiler(compiler)).PrepareForEval(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to prepare the ignore rule: %w", err)
+	}
+	return &PolicyFilter{query: query}, nil
+}
+
+// Apply removes the vulnerabilities for which the ignore rule is true
+func (f *PolicyFilter) Apply(ctx context.Context, results report.Results) (report.Results, error) {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			ignored, err := f.ignored(ctx, result, vuln)
+			if err != nil {
+				return nil, xerrors.Errorf("failed to evaluate the policy for %s: %w", vuln.VulnerabilityID, err)
+			}
+			if !ignored {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results, nil
+}
+
+func (f *PolicyFilter) ignored(ctx context.Context, result report.Result, vuln types.DetectedVulnerability) (bool, error) {
+	input, err := policyInput(result, vuln)
+	if err != nil {
+		return false, err
+	}
+	rs, err := f.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return false, err
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		// undefined without a default
+		return false, nil
+	}
+	ignored, ok := rs[0].Expressions[0].Value.(bool)
+	if !ok {
+		return false, xerrors.Errorf("the ignore rule isn't a boolean: %v", rs[0].Expressions[0].Value)
+	}
+	return ignored, nil
+}
+
+// policyInput returns the JSON object of the vulnerability with the Target and Type of the result
+func policyInput(result report.Result, vuln types.DetectedVulnerability) (map[string]interface{}, error) {
+	b, err := json.Marshal(vuln)
+	if err != nil {
+		return nil, err
+	}
+	var input map[string]interface{}
+	if err = json.Unmarshal(b, &input); err != nil {
+		return nil, err
+	}
+	input["Target"] = result.Target
+	input["Type"] = result.Type
+	return input, nil
+}
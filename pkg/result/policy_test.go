@@ -0,0 +1,89 @@
+package result_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/result"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestPolicyFilter_Apply(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "gcr.io/distroless/base (debian 10.3)",
+			Type:   "debian",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-0001", PkgName: "libc6"},
+				{VulnerabilityID: "CVE-2019-0002", PkgName: "openssl", FixedVersion: "1.1.1d-0+deb10u3"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-0003", PkgName: "lodash", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+				{VulnerabilityID: "CVE-2019-0004", PkgName: "lodash", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+				{VulnerabilityID: "CVE-2019-0005", PkgName: "jquery"},
+			},
+		},
+	}
+
+	f, err := result.NewPolicyFilter(context.Background(), "testdata/ignore.rego")
+	require.NoError(t, err)
+	got, err := f.Apply(context.Background(), results)
+	require.NoError(t, err)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2019-0002", PkgName: "openssl", FixedVersion: "1.1.1d-0+deb10u3"},
+	}, got[0].Vulnerabilities)
+	assert.Equal(t, []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2019-0004", PkgName: "lodash", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+		{VulnerabilityID: "CVE-2019-0005", PkgName: "jquery"},
+	}, got[1].Vulnerabilities)
+
+	t.Run("sad path: not a boolean", func(t *testing.T) {
+		f, err := result.NewPolicyFilter(context.Background(), "testdata/not-boolean.rego")
+		require.NoError(t, err)
+		_, err = f.Apply(context.Background(), report.Results{
+			{Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-0001"}}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the ignore rule isn't a boolean")
+	})
+}
+
+func TestNewPolicyFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		policyFile string
+		wantErr    string
+	}{
+		{
+			name:       "wrong package",
+			policyFile: "testdata/wrong-package.rego",
+			wantErr:    "the package of the policy is user instead of trivy",
+		},
+		{
+			name:       "syntax error",
+			policyFile: "testdata/invalid.rego",
+			wantErr:    "invalid policy",
+		},
+		{
+			name:       "missing file",
+			policyFile: "testdata/missing.rego",
+			wantErr:    "unable to read the policy file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := result.NewPolicyFilter(context.Background(), tt.policyFile)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
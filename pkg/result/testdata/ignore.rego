@@ -0,0 +1,14 @@
+package trivy
+
+default ignore = false
+
+# the unfixed vulnerabilities of the distroless images can't be fixed by the users
+ignore {
+	contains(input.Target, "distroless")
+	not input.FixedVersion
+}
+
+ignore {
+	input.PkgName == "lodash"
+	input.Severity == "LOW"
+}
@@ -0,0 +1,3 @@
+package user
+
+ignore { true }
@@ -39,7 +39,12 @@ func NewScanner(customHeaders CustomHeaders, s rpc.Scanner) Scanner {
 }
 
 func (s Scanner) Scan(target string, imageID string, layerIDs []string, options types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
-	ctx := WithCustomHeaders(context.Background(), http.Header(s.customHeaders))
+	return s.ScanContext(context.Background(), target, imageID, layerIDs, options)
+}
+
+// ScanContext is Scan aborting the request when the context is done
+func (s Scanner) ScanContext(ctx context.Context, target string, imageID string, layerIDs []string, options types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	ctx = WithCustomHeaders(ctx, http.Header(s.customHeaders))
 
 	var res *rpc.ScanResponse
 	err := r.Retry(func() error {
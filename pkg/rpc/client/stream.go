@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	r "github.com/aquasecurity/trivy/pkg/rpc"
+	"github.com/aquasecurity/trivy/pkg/types"
+	rpc "github.com/aquasecurity/trivy/rpc/scanner"
+)
+
+var scanStreamDesc = &grpc.StreamDesc{
+	StreamName:    "ScanStream",
+	ServerStreams: true,
+}
+
+// StreamScanner receives the results of a scan run by the server over gRPC
+type StreamScanner struct {
+	conn *grpc.ClientConn
+}
+
+func NewStreamScanner(conn *grpc.ClientConn) StreamScanner {
+	return StreamScanner{conn: conn}
+}
+
+// ScanStream calls fn with the result of each target as it is received.
+// Canceling the context cancels the scan on the server.
+func (s StreamScanner) ScanStream(ctx context.Context, imageName string, options types.ScanOptions, fn func(report.Result) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.conn.NewStream(ctx, scanStreamDesc, r.ScanStreamMethod)
+	if err != nil {
+		return xerrors.Errorf("failed to open the scan stream: %w", err)
+	}
+	req := &rpc.ScanRequest{
+		Target:  imageName,
+		Options: &rpc.ScanOptions{VulnType: options.VulnType},
+	}
+	if err = stream.SendMsg(req); err != nil {
+		return xerrors.Errorf("failed to send the scan request: %w", err)
+	}
+	if err = stream.CloseSend(); err != nil {
+		return xerrors.Errorf("failed to close the scan request: %w", err)
+	}
+
+	for {
+		result := new(rpc.Result)
+		err = stream.RecvMsg(result)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return xerrors.Errorf("failed to receive a result: %w", err)
+		}
+		if err = fn(r.ConvertFromRpcResults([]*rpc.Result{result})[0]); err != nil {
+			return err
+		}
+	}
+}
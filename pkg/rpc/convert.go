@@ -150,6 +150,8 @@ func ConvertFromRpcResults(rpcResults []*scanner.Result) []report.Result {
 			Target:          result.Target,
 			Vulnerabilities: vulns,
 			Type:            result.Type,
+			Status:          result.Status,
+			StatusReason:    result.StatusReason,
 		})
 	}
 	return results
@@ -271,7 +273,7 @@ func ConvertToRpcLayerInfo(diffID string, layerInfo ftypes.LayerInfo) *cache.Put
 	return &cache.PutLayerRequest{
 		DiffId: diffID,
 		LayerInfo: &cache.LayerInfo{
-			SchemaVersion: ftypes.LayerJSONSchemaVersion,
+			SchemaVersion: int32(layerInfo.SchemaVersion),
 			Digest:        layerInfo.Digest,
 			DiffId:        layerInfo.DiffID,
 			Os:            ConvertToRpcOS(layerInfo.OS),
@@ -299,11 +301,7 @@ func ConvertToRpcScanResponse(results report.Results, os *ftypes.OS, eosl bool)
 
 	var rpcResults []*scanner.Result
 	for _, result := range results {
-		rpcResults = append(rpcResults, &scanner.Result{
-			Target:          result.Target,
-			Vulnerabilities: ConvertToRpcVulns(result.Vulnerabilities),
-			Type:            result.Type,
-		})
+		rpcResults = append(rpcResults, ConvertToRpcResult(result))
 	}
 
 	return &scanner.ScanResponse{
@@ -312,3 +310,13 @@ func ConvertToRpcScanResponse(results report.Results, os *ftypes.OS, eosl bool)
 		Results: rpcResults,
 	}
 }
+
+func ConvertToRpcResult(result report.Result) *scanner.Result {
+	return &scanner.Result{
+		Target:          result.Target,
+		Vulnerabilities: ConvertToRpcVulns(result.Vulnerabilities),
+		Type:            result.Type,
+		Status:          result.Status,
+		StatusReason:    result.StatusReason,
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/rpc/common"
+	"github.com/aquasecurity/trivy/rpc/scanner"
 
 	ftypes "github.com/aquasecurity/fanal/types"
 
@@ -325,3 +330,22 @@ func TestConvertToRpcVulns(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertRpcResults_Status(t *testing.T) {
+	results := report.Results{
+		{
+			Target:       "/app/Gemfile.lock",
+			Type:         "bundler",
+			Status:       report.StatusError,
+			StatusReason: "failed to detect",
+		},
+	}
+
+	// the status goes over the wire with the result
+	b, err := proto.Marshal(ConvertToRpcScanResponse(results, nil, false))
+	require.NoError(t, err)
+	var res scanner.ScanResponse
+	require.NoError(t, proto.Unmarshal(b, &res))
+
+	assert.Equal(t, results, report.Results(ConvertFromRpcResults(res.Results)))
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/utils"
 	"github.com/cenkalti/backoff"
 	"github.com/twitchtv/twirp"
 )
@@ -12,6 +13,14 @@ const (
 	maxRetries = 10
 )
 
+var retryJitter = utils.DefaultJitter
+
+// SetRetryJitter configures the randomization of the retry delays, e.g. 0.5 for ±50% of each delay.
+// A negative jitter disables it.
+func SetRetryJitter(jitter float64) {
+	retryJitter = jitter
+}
+
 func Retry(f func() error) error {
 	operation := func() error {
 		err := f()
@@ -28,7 +37,7 @@ func Retry(f func() error) error {
 		return nil
 	}
 
-	b := backoff.WithMaxRetries(backoff.NewExponentialBackOff(), maxRetries)
+	b := backoff.WithMaxRetries(utils.NewJitteredBackOff(0, retryJitter, nil), maxRetries)
 	err := backoff.RetryNotify(operation, b, func(err error, _ time.Duration) {
 		log.Logger.Warn(err)
 		log.Logger.Info("Retrying HTTP request...")
@@ -3,9 +3,13 @@
 package server
 
 import (
+	"context"
+	"time"
+
 	"github.com/aquasecurity/fanal/cache"
 	"github.com/aquasecurity/trivy/pkg/rpc/server/library"
 	"github.com/aquasecurity/trivy/pkg/rpc/server/ospkg"
+	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/google/wire"
 )
 
@@ -14,6 +18,12 @@ func initializeScanServer(localLayerCache cache.LocalImageCache) *ScanServer {
 	return &ScanServer{}
 }
 
+func initializeDockerScanner(ctx context.Context, imageName string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache,
+	timeout time.Duration) (scanner.Scanner, func(), error) {
+	wire.Build(scanner.StandaloneDockerSet)
+	return scanner.Scanner{}, nil, nil
+}
+
 func initializeOspkgServer() *ospkg.Server {
 	wire.Build(ospkg.SuperSet)
 	return &ospkg.Server{}
@@ -24,7 +34,7 @@ func initializeLibServer() *library.Server {
 	return &library.Server{}
 }
 
-func initializeDBWorker(cacheDir string, quiet bool) dbWorker {
+func initializeDBWorker(cacheDir string, quiet bool, repositories []string) dbWorker {
 	wire.Build(DBWorkerSuperSet)
 	return dbWorker{}
 }
@@ -3,20 +3,25 @@ package server
 import (
 	"context"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/google/wire"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/afero"
 	"github.com/twitchtv/twirp"
 	"golang.org/x/xerrors"
+	"k8s.io/utils/clock"
 
 	"github.com/aquasecurity/fanal/cache"
 	"github.com/aquasecurity/trivy-db/pkg/db"
 	"github.com/aquasecurity/trivy/internal/server/config"
 	dbFile "github.com/aquasecurity/trivy/pkg/db"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/metrics"
 	"github.com/aquasecurity/trivy/pkg/utils"
 	rpcCache "github.com/aquasecurity/trivy/rpc/cache"
 	"github.com/aquasecurity/trivy/rpc/detector"
@@ -24,12 +29,15 @@ import (
 	rpcScanner "github.com/aquasecurity/trivy/rpc/scanner"
 )
 
+// MetricsPath is the path of the Prometheus metrics of the server
+const MetricsPath = "/metrics"
+
 var DBWorkerSuperSet = wire.NewSet(
 	dbFile.SuperSet,
 	newDBWorker,
 )
 
-func ListenAndServe(c config.Config, fsCache cache.FSCache) error {
+func ListenAndServe(c config.Config, cacheClient cache.Cache) error {
 	requestWg := &sync.WaitGroup{}
 	dbUpdateWg := &sync.WaitGroup{}
 
@@ -48,7 +56,7 @@ func ListenAndServe(c config.Config, fsCache cache.FSCache) error {
 	}
 
 	go func() {
-		worker := initializeDBWorker(c.CacheDir, true)
+		worker := initializeDBWorker(c.CacheDir, true, c.DBRepositories)
 		ctx := context.Background()
 		for {
 			time.Sleep(1 * time.Hour)
@@ -60,10 +68,10 @@ func ListenAndServe(c config.Config, fsCache cache.FSCache) error {
 
 	mux := http.NewServeMux()
 
-	scanHandler := rpcScanner.NewScannerServer(initializeScanServer(fsCache), nil)
+	scanHandler := rpcScanner.NewScannerServer(initializeScanServer(cacheClient), nil)
 	mux.Handle(rpcScanner.ScannerPathPrefix, withToken(withWaitGroup(scanHandler), c.Token, c.TokenHeader))
 
-	layerHandler := rpcCache.NewCacheServer(NewCacheServer(fsCache), nil)
+	layerHandler := rpcCache.NewCacheServer(NewCacheServer(cacheClient), nil)
 	mux.Handle(rpcCache.CachePathPrefix, withToken(withWaitGroup(layerHandler), c.Token, c.TokenHeader))
 
 	// osHandler is for backward compatibility
@@ -74,6 +82,28 @@ func ListenAndServe(c config.Config, fsCache cache.FSCache) error {
 	libHandler := rpcDetector.NewLibDetectorServer(initializeLibServer(), nil)
 	mux.Handle(rpcDetector.LibDetectorPathPrefix, withToken(withWaitGroup(libHandler), c.Token, c.TokenHeader))
 
+	// the metrics are scraped without the token
+	reg, err := metrics.NewRegistry(dbFile.NewMetadata(afero.NewOsFs(), c.CacheDir).Get, clock.RealClock{}, true)
+	if err != nil {
+		return xerrors.Errorf("unable to initialize the metrics: %w", err)
+	}
+	mux.Handle(MetricsPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	if c.GRPCListen != "" {
+		streamServer := NewStreamServer(newImageScanFunc(cacheClient, c.Timeout, dbUpdateWg, requestWg))
+		grpcServer := NewStreamGRPCServer(streamServer, c.Token, c.TokenHeader)
+		lis, err := net.Listen("tcp", c.GRPCListen)
+		if err != nil {
+			return xerrors.Errorf("failed to listen %s: %w", c.GRPCListen, err)
+		}
+		go func() {
+			log.Logger.Infof("Listening %s for gRPC...", c.GRPCListen)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Logger.Errorf("gRPC server error: %s", err)
+			}
+		}()
+	}
+
 	log.Logger.Infof("Listening %s...", c.Listen)
 
 	return http.ListenAndServe(c.Listen, mux)
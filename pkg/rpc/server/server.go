@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"time"
 
 	google_protobuf "github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/wire"
@@ -9,11 +10,11 @@ import (
 
 	"github.com/aquasecurity/fanal/cache"
 	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/metrics"
 	"github.com/aquasecurity/trivy/pkg/rpc"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/scanner/local"
 	"github.com/aquasecurity/trivy/pkg/types"
-	"github.com/aquasecurity/trivy/pkg/vulnerability"
 	rpcCache "github.com/aquasecurity/trivy/rpc/cache"
 	rpcScanner "github.com/aquasecurity/trivy/rpc/scanner"
 )
@@ -21,29 +22,25 @@ import (
 var ScanSuperSet = wire.NewSet(
 	local.SuperSet,
 	wire.Bind(new(scanner.Driver), new(local.Scanner)),
-	vulnerability.SuperSet,
 	NewScanServer,
 )
 
 type ScanServer struct {
 	localScanner scanner.Driver
-	vulnClient   vulnerability.Operation
 }
 
-func NewScanServer(s scanner.Driver, vulnClient vulnerability.Operation) *ScanServer {
-	return &ScanServer{localScanner: s, vulnClient: vulnClient}
+func NewScanServer(s scanner.Driver) *ScanServer {
+	return &ScanServer{localScanner: s}
 }
 
 func (s *ScanServer) Scan(_ context.Context, in *rpcScanner.ScanRequest) (*rpcScanner.ScanResponse, error) {
+	start := time.Now()
 	options := types.ScanOptions{VulnType: in.Options.VulnType}
 	results, os, eosl, err := s.localScanner.Scan(in.Target, in.ImageId, in.LayerIds, options)
+	metrics.ObserveScan(start, results, err)
 	if err != nil {
 		return nil, xerrors.Errorf("failed scan, %s: %w", in.Target, err)
 	}
-
-	for i := range results {
-		s.vulnClient.FillInfo(results[i].Vulnerabilities, results[i].Type)
-	}
 	return rpc.ConvertToRpcScanResponse(results, os, eosl), nil
 }
 
@@ -81,14 +78,14 @@ func (s *CacheServer) MissingLayers(_ context.Context, in *rpcCache.MissingLayer
 	var layerIDs []string
 	for _, layerID := range in.LayerIds {
 		l, err := s.cache.GetLayer(layerID)
-		if err != nil || l.SchemaVersion != ftypes.LayerJSONSchemaVersion {
+		missing := err != nil || l.SchemaVersion != ftypes.LayerJSONSchemaVersion
+		if missing {
 			layerIDs = append(layerIDs, layerID)
 		}
+		metrics.ObserveCacheLookup(!missing)
 	}
-	var missingImage bool
 	img, err := s.cache.GetImage(in.ImageId)
-	if err != nil || img.SchemaVersion != ftypes.ImageJSONSchemaVersion {
-		missingImage = true
-	}
+	missingImage := err != nil || img.SchemaVersion != ftypes.ImageJSONSchemaVersion
+	metrics.ObserveCacheLookup(!missingImage)
 	return &rpcCache.MissingLayersResponse{MissingImage: missingImage, MissingLayerIds: layerIDs}, nil
 }
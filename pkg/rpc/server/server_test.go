@@ -23,7 +23,6 @@ import (
 	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/types"
-	"github.com/aquasecurity/trivy/pkg/vulnerability"
 	rpcCache "github.com/aquasecurity/trivy/rpc/cache"
 	"github.com/aquasecurity/trivy/rpc/common"
 	rpcScanner "github.com/aquasecurity/trivy/rpc/scanner"
@@ -39,12 +38,11 @@ func TestScanServer_Scan(t *testing.T) {
 		in *rpcScanner.ScanRequest
 	}
 	tests := []struct {
-		name                string
-		args                args
-		scanExpectation     scanner.ScanExpectation
-		fillInfoExpectation vulnerability.FillInfoExpectation
-		want                *rpcScanner.ScanResponse
-		wantErr             string
+		name            string
+		args            args
+		scanExpectation scanner.ScanExpectation
+		want            *rpcScanner.ScanResponse
+		wantErr         string
 	}{
 		{
 			name: "happy path",
@@ -85,21 +83,6 @@ func TestScanServer_Scan(t *testing.T) {
 					},
 				},
 			},
-			fillInfoExpectation: vulnerability.FillInfoExpectation{
-				Args: vulnerability.FillInfoArgs{
-					Vulns: []types.DetectedVulnerability{
-						{
-							VulnerabilityID:  "CVE-2019-0001",
-							PkgName:          "musl",
-							InstalledVersion: "1.2.3",
-							FixedVersion:     "1.2.4",
-							Vulnerability:    dbTypes.Vulnerability{},
-							SeveritySource:   "nvd",
-						},
-					},
-					ReportType: "alpine",
-				},
-			},
 			want: &rpcScanner.ScanResponse{
 				Os: &common.OS{
 					Family: "alpine",
@@ -153,10 +136,7 @@ func TestScanServer_Scan(t *testing.T) {
 			mockDriver := new(scanner.MockDriver)
 			mockDriver.ApplyScanExpectation(tt.scanExpectation)
 
-			mockVulnClient := new(vulnerability.MockOperation)
-			mockVulnClient.ApplyFillInfoExpectation(tt.fillInfoExpectation)
-
-			s := NewScanServer(mockDriver, mockVulnClient)
+			s := NewScanServer(mockDriver)
 			got, err := s.Scan(context.Background(), tt.args.in)
 			if tt.wantErr != "" {
 				require.NotNil(t, err, tt.name)
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/metrics"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/rpc"
+	"github.com/aquasecurity/trivy/pkg/types"
+	rpcScanner "github.com/aquasecurity/trivy/rpc/scanner"
+)
+
+// ImageScanFunc scans the image in the target of ScanRequest, passing the result of each target to send as soon as
+// it is scanned, possibly from several goroutines. The scan must stop when the context is canceled.
+type ImageScanFunc func(ctx context.Context, imageName string, options types.ScanOptions, send func(report.Result)) (report.Results, error)
+
+// streamKeepalive is the interval of the pings of an idle connection, e.g. while a large image is analyzed,
+// keeping it open through the proxies closing the idle ones
+const streamKeepalive = 30 * time.Second
+
+type StreamScannerServer interface {
+	ScanStream(*rpcScanner.ScanRequest, grpc.ServerStream) error
+}
+
+var StreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: rpc.StreamServiceName,
+	HandlerType: (*StreamScannerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ScanStream",
+			Handler:       scanStreamHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func scanStreamHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(rpcScanner.ScanRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(StreamScannerServer).ScanStream(in, stream)
+}
+
+// newImageScanFunc scans the image with the analyzer on the server, waiting for the DB update as the HTTP handlers do
+func newImageScanFunc(cacheClient cache.Cache, timeout time.Duration, dbUpdateWg, requestWg *sync.WaitGroup) ImageScanFunc {
+	return func(ctx context.Context, imageName string, options types.ScanOptions, send func(report.Result)) (report.Results, error) {
+		dbUpdateWg.Wait()
+		requestWg.Add(1)
+		defer requestWg.Done()
+
+		s, cleanup, err := initializeDockerScanner(ctx, imageName, cacheClient, cacheClient, timeout)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to initialize the docker scanner: %w", err)
+		}
+		defer cleanup()
+		return s.WithResultHandler(send).ScanImageWithContext(ctx, options)
+	}
+}
+
+// StreamServer scans images on the server and streams a Result message per target
+type StreamServer struct {
+	scan ImageScanFunc
+}
+
+func NewStreamServer(scan ImageScanFunc) *StreamServer {
+	return &StreamServer{scan: scan}
+}
+
+// ScanStream sends the result of each target as soon as it is scanned, e.g. of each lock file while the others
+// are still scanned, then those of the targets not sent during the scan, e.g. of a cached scan.
+// The headers are sent first so that the client and the proxies see the response start before the first result.
+// A client disconnecting cancels the context of the scan.
+func (s *StreamServer) ScanStream(in *rpcScanner.ScanRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	var options types.ScanOptions
+	if in.Options != nil {
+		options.VulnType = in.Options.VulnType
+	}
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	// the results are sent one at a time and each target once, e.g. when the scan is retried
+	var mu sync.Mutex
+	var sendErr error
+	sent := map[string]bool{}
+	send := func(result report.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sendErr != nil || sent[result.Target] {
+			return
+		}
+		sent[result.Target] = true
+		if sendErr = stream.SendMsg(rpc.ConvertToRpcResult(result)); sendErr != nil {
+			log.Logger.Debugf("Unable to send the result of %s: %s", result.Target, sendErr)
+		}
+	}
+
+	start := time.Now()
+	results, err := s.scan(ctx, in.Target, options, send)
+	metrics.ObserveScan(start, results, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.Errorf(codes.Canceled, "scan canceled, %s: %s", in.Target, ctx.Err())
+		}
+		return status.Errorf(codes.Internal, "failed scan, %s: %s", in.Target, err)
+	}
+
+	for _, result := range results {
+		send(result)
+	}
+	return sendErr
+}
+
+// streamTokenInterceptor authenticates the streams as withToken does the HTTP requests
+func streamTokenInterceptor(token, tokenHeader string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if token != "" {
+			md, _ := metadata.FromIncomingContext(ss.Context())
+			values := md.Get(tokenHeader)
+			if len(values) == 0 || values[0] != token {
+				return status.Error(codes.Unauthenticated, "invalid token")
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// NewStreamGRPCServer returns a gRPC server with the stream scanner registered
+func NewStreamGRPCServer(s StreamScannerServer, token, tokenHeader string) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.StreamInterceptor(streamTokenInterceptor(token, tokenHeader)),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: streamKeepalive}),
+	)
+	grpcServer.RegisterService(&StreamServiceDesc, s)
+	return grpcServer
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/rpc/client"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func newStreamClient(t *testing.T, scan ImageScanFunc, token string) (client.StreamScanner, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := NewStreamGRPCServer(NewStreamServer(scan), token, "trivy-token")
+	go grpcServer.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(
+		func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	require.NoError(t, err)
+	return client.NewStreamScanner(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestStreamServer_ScanStream(t *testing.T) {
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "CVE-2020-1967",
+					PkgName:          "openssl",
+					InstalledVersion: "1.1.1d-r3",
+					FixedVersion:     "1.1.1g-r0",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+				},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID:  "NSWG-ECO-428",
+					PkgName:          "jquery",
+					InstalledVersion: "3.3.1",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+				},
+				{
+					VulnerabilityID:  "CVE-2019-11358",
+					PkgName:          "jquery",
+					InstalledVersion: "3.3.1",
+					FixedVersion:     "3.4.0",
+					Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+				},
+			},
+		},
+	}
+
+	var gotImage string
+	var gotOptions types.ScanOptions
+	c, cleanup := newStreamClient(t, func(_ context.Context, imageName string, options types.ScanOptions, _ func(report.Result)) (report.Results, error) {
+		gotImage, gotOptions = imageName, options
+		return results, nil
+	}, "")
+	defer cleanup()
+
+	var got report.Results
+	err := c.ScanStream(context.Background(), "alpine:3.11", types.ScanOptions{VulnType: []string{"os", "library"}},
+		func(result report.Result) error {
+			got = append(got, result)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "alpine:3.11", gotImage)
+	assert.Equal(t, []string{"os", "library"}, gotOptions.VulnType)
+	assert.Equal(t, results, got)
+}
+
+func TestStreamServer_ScanStream_Incremental(t *testing.T) {
+	osResult := report.Result{Target: "alpine:3.11 (alpine 3.11.5)", Type: "alpine"}
+	npmResult := report.Result{Target: "app/package-lock.json", Type: "npm"}
+	yarnResult := report.Result{Target: "web/yarn.lock", Type: "yarn"}
+
+	received := make(chan struct{})
+	c, cleanup := newStreamClient(t, func(ctx context.Context, _ string, _ types.ScanOptions, send func(report.Result)) (report.Results, error) {
+		send(osResult)
+		// the client receives the result while the image is still scanned
+		select {
+		case <-received:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		send(npmResult)
+		// the sent results aren't sent again
+		return report.Results{osResult, npmResult, yarnResult}, nil
+	}, "")
+	defer cleanup()
+
+	var got []string
+	err := c.ScanStream(context.Background(), "alpine:3.11", types.ScanOptions{}, func(result report.Result) error {
+		if len(got) == 0 {
+			close(received)
+		}
+		got = append(got, result.Target)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpine:3.11 (alpine 3.11.5)", "app/package-lock.json", "web/yarn.lock"}, got)
+}
+
+func TestStreamServer_ScanStream_Error(t *testing.T) {
+	c, cleanup := newStreamClient(t, func(context.Context, string, types.ScanOptions, func(report.Result)) (report.Results, error) {
+		return nil, xerrors.New("unable to pull the image")
+	}, "")
+	defer cleanup()
+
+	err := c.ScanStream(context.Background(), "alpine:3.11", types.ScanOptions{}, func(report.Result) error {
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to pull the image")
+}
+
+func TestStreamServer_ScanStream_Disconnect(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	c, cleanup := newStreamClient(t, func(ctx context.Context, _ string, _ types.ScanOptions, _ func(report.Result)) (report.Results, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	}, "")
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	err := c.ScanStream(ctx, "alpine:3.11", types.ScanOptions{}, func(report.Result) error {
+		return nil
+	})
+	require.Error(t, err)
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scan wasn't canceled")
+	}
+}
+
+func TestStreamServer_ScanStream_Token(t *testing.T) {
+	c, cleanup := newStreamClient(t, func(context.Context, string, types.ScanOptions, func(report.Result)) (report.Results, error) {
+		return report.Results{{Target: "alpine:3.11"}}, nil
+	}, "secret")
+	defer cleanup()
+
+	noop := func(report.Result) error { return nil }
+	err := c.ScanStream(context.Background(), "alpine:3.11", types.ScanOptions{}, noop)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid token")
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "trivy-token", "secret")
+	assert.NoError(t, c.ScanStream(ctx, "alpine:3.11", types.ScanOptions{}, noop))
+}
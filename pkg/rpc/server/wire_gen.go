@@ -1,41 +1,71 @@
 // Code generated by Wire. DO NOT EDIT.
 
 //go:generate wire
-//+build !wireinject
+//go:build !wireinject
+// +build !wireinject
 
 package server
 
 import (
+	"context"
 	"github.com/aquasecurity/fanal/analyzer"
 	"github.com/aquasecurity/fanal/cache"
 	"github.com/aquasecurity/trivy-db/pkg/db"
 	db2 "github.com/aquasecurity/trivy/pkg/db"
 	"github.com/aquasecurity/trivy/pkg/detector/library"
 	"github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
 	"github.com/aquasecurity/trivy/pkg/github"
 	"github.com/aquasecurity/trivy/pkg/indicator"
+	"github.com/aquasecurity/trivy/pkg/registry"
 	library2 "github.com/aquasecurity/trivy/pkg/rpc/server/library"
 	ospkg2 "github.com/aquasecurity/trivy/pkg/rpc/server/ospkg"
+	"github.com/aquasecurity/trivy/pkg/scanner"
 	"github.com/aquasecurity/trivy/pkg/scanner/local"
 	"github.com/aquasecurity/trivy/pkg/vulnerability"
 	"github.com/spf13/afero"
 	"k8s.io/utils/clock"
+	"time"
 )
 
 // Injectors from inject.go:
 
 func initializeScanServer(localLayerCache cache.LocalImageCache) *ScanServer {
-	applier := analyzer.NewApplier(localLayerCache)
+	applier := local.NewApplier(localLayerCache)
 	detector := ospkg.Detector{}
 	driverFactory := library.DriverFactory{}
 	libraryDetector := library.NewDetector(driverFactory)
-	scanner := local.NewScanner(applier, detector, libraryDetector)
 	config := db.Config{}
 	client := vulnerability.NewClient(config)
-	scanServer := NewScanServer(scanner, client)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	scanServer := NewScanServer(localScanner)
 	return scanServer
 }
 
+func initializeDockerScanner(ctx context.Context, imageName string, layerCache cache.ImageCache, localImageCache cache.LocalImageCache, timeout time.Duration) (scanner.Scanner, func(), error) {
+	applier := local.NewApplier(localImageCache)
+	detector := ospkg.Detector{}
+	driverFactory := library.DriverFactory{}
+	libraryDetector := library.NewDetector(driverFactory)
+	config := db.Config{}
+	client := vulnerability.NewClient(config)
+	localScanner := local.NewScanner(applier, detector, libraryDetector, client)
+	dockerOption, err := registry.GetDockerOption(ctx, imageName, timeout)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	extractor, cleanup, err := image.NewDockerExtractor(ctx, imageName, dockerOption)
+	if err != nil {
+		return scanner.Scanner{}, nil, err
+	}
+	analyzerConfig := analyzer.New(extractor, layerCache)
+	imageAnalyzer := scanner.NewImageAnalyzer(analyzerConfig)
+	scannerScanner := scanner.NewScanner(localScanner, imageAnalyzer)
+	return scannerScanner, func() {
+		cleanup()
+	}, nil
+}
+
 func initializeOspkgServer() *ospkg2.Server {
 	detector := ospkg.Detector{}
 	config := db.Config{}
@@ -53,9 +83,9 @@ func initializeLibServer() *library2.Server {
 	return server
 }
 
-func initializeDBWorker(cacheDir string, quiet bool) dbWorker {
+func initializeDBWorker(cacheDir string, quiet bool, repositories []string) dbWorker {
 	config := db.Config{}
-	client := github.NewClient()
+	client := github.NewClient(repositories)
 	progressBar := indicator.NewProgressBar(quiet)
 	realClock := clock.RealClock{}
 	fs := afero.NewOsFs()
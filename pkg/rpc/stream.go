@@ -0,0 +1,13 @@
+package rpc
+
+// StreamServiceName is the gRPC service streaming the results of a scan. It corresponds to
+//
+//	service StreamScanner {
+//	  rpc ScanStream(trivy.scanner.v1.ScanRequest) returns (stream trivy.scanner.v1.Result);
+//	}
+//
+// The service is registered by hand as Twirp, used for the other services, doesn't support streaming.
+const StreamServiceName = "trivy.scanner.v1.StreamScanner"
+
+// ScanStreamMethod is the full name of the streaming method
+const ScanStreamMethod = "/" + StreamServiceName + "/ScanStream"
@@ -0,0 +1,68 @@
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+
+	"github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// Analyzer analyzes an SBOM file as an image with a single layer holding the packages of the document,
+// which the drivers scan as they scan the layers of images. The image and the layer are identified
+// by the digest of the file.
+type Analyzer struct {
+	filePath string
+	cache    cache.ImageCache
+}
+
+func NewAnalyzer(filePath string, c cache.ImageCache) Analyzer {
+	return Analyzer{filePath: filePath, cache: c}
+}
+
+func (a Analyzer) Analyze(_ context.Context) (ftypes.ImageReference, error) {
+	b, err := ioutil.ReadFile(a.filePath)
+	if err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("unable to read %s: %w", a.filePath, err)
+	}
+	bom, err := Decode(bytes.NewReader(b))
+	if err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("invalid SBOM %s: %w", a.filePath, err)
+	}
+	if bom.Skipped > 0 {
+		log.Logger.Warnf("%d packages of %s have no package URL of a supported type and are not scanned", bom.Skipped, a.filePath)
+	}
+	if bom.OS == nil && len(bom.Packages) > 0 {
+		log.Logger.Warnf("The OS version isn't in %s, its OS packages are not scanned", a.filePath)
+	}
+
+	sum := sha256.Sum256(b)
+	id := "sha256:" + hex.EncodeToString(sum[:])
+	if err = a.cache.PutImage(id, ftypes.ImageInfo{SchemaVersion: ftypes.ImageJSONSchemaVersion}); err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("failed to put the image info into the cache: %w", err)
+	}
+	if err = a.cache.PutLayer(id, bom.LayerInfo(id)); err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("failed to put the layer info into the cache: %w", err)
+	}
+	return ftypes.ImageReference{Name: a.filePath, ID: id, LayerIDs: []string{id}}, nil
+}
+
+// LayerInfo returns the layer of the packages, without the OS packages when the OS is unknown
+func (bom BOM) LayerInfo(diffID string) ftypes.LayerInfo {
+	layer := ftypes.LayerInfo{
+		SchemaVersion: ftypes.LayerJSONSchemaVersion,
+		DiffID:        diffID,
+		OS:            bom.OS,
+		Applications:  bom.Applications,
+	}
+	if bom.OS != nil && len(bom.Packages) > 0 {
+		layer.PackageInfos = []ftypes.PackageInfo{{FilePath: "sbom", Packages: bom.Packages}}
+	}
+	return layer
+}
@@ -0,0 +1,71 @@
+package sbom
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+
+	tests := []struct {
+		name         string
+		filePath     string
+		wantOS       *ftypes.OS
+		wantPackages int
+		wantApps     int
+		wantErr      string
+	}{
+		{
+			name:         "OS packages and libraries",
+			filePath:     "testdata/cyclonedx.json",
+			wantOS:       &ftypes.OS{Family: "debian", Name: "10.3"},
+			wantPackages: 2,
+			wantApps:     3,
+		},
+		{
+			name:     "missing file",
+			filePath: "testdata/missing.json",
+			wantErr:  "unable to read",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir, err := ioutil.TempDir("", "sbom")
+			require.NoError(t, err)
+			defer os.RemoveAll(cacheDir)
+			c, err := cache.NewFSCache(cacheDir)
+			require.NoError(t, err)
+
+			ref, err := NewAnalyzer(tt.filePath, c).Analyze(context.Background())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.filePath, ref.Name)
+			assert.Regexp(t, "^sha256:[0-9a-f]{64}$", ref.ID)
+			assert.Equal(t, []string{ref.ID}, ref.LayerIDs)
+
+			layer, err := c.GetLayer(ref.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOS, layer.OS)
+			var pkgs int
+			for _, info := range layer.PackageInfos {
+				pkgs += len(info.Packages)
+			}
+			assert.Equal(t, tt.wantPackages, pkgs)
+			assert.Len(t, layer.Applications, tt.wantApps)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package sbom
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// PackageURL is a parsed package URL (https://github.com/package-url/purl-spec),
+// e.g. pkg:npm/%40babel/core@7.0.0 or pkg:deb/debian/openssl@1.1.1d-0+deb10u2?distro=debian-10
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers url.Values
+}
+
+// ParsePackageURL parses the package URL; the subpath is ignored
+func ParsePackageURL(s string) (PackageURL, error) {
+	if !strings.HasPrefix(s, "pkg:") {
+		return PackageURL{}, xerrors.Errorf("invalid package URL: %s", s)
+	}
+	rest := strings.TrimLeft(strings.TrimPrefix(s, "pkg:"), "/")
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	var p PackageURL
+	if i := strings.Index(rest, "?"); i >= 0 {
+		qualifiers, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return PackageURL{}, xerrors.Errorf("invalid qualifiers of %s: %w", s, err)
+		}
+		p.Qualifiers = qualifiers
+		rest = rest[:i]
+	}
+	// the version follows the last "@" after the name, as the "@" of the npm scopes may be unescaped
+	if i := strings.LastIndex(rest, "@"); i > strings.LastIndex(rest, "/") {
+		version, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return PackageURL{}, xerrors.Errorf("invalid version of %s: %w", s, err)
+		}
+		p.Version = version
+		rest = rest[:i]
+	}
+
+	segments := strings.Split(rest, "/")
+	if len(segments) < 2 || segments[0] == "" || segments[len(segments)-1] == "" {
+		return PackageURL{}, xerrors.Errorf("invalid package URL: %s", s)
+	}
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return PackageURL{}, xerrors.Errorf("invalid package URL %s: %w", s, err)
+		}
+		segments[i] = unescaped
+	}
+	p.Type = strings.ToLower(segments[0])
+	p.Namespace = strings.Join(segments[1:len(segments)-1], "/")
+	p.Name = segments[len(segments)-1]
+	return p, nil
+}
+
+// PackageName returns the name of the package in its ecosystem, e.g. @babel/core for npm,
+// symfony/http-foundation for composer and org.apache.logging.log4j:log4j-core for maven,
+// whose namespaces are part of the name
+func (p PackageURL) PackageName() string {
+	if p.Namespace != "" && p.Type == "maven" {
+		return p.Namespace + ":" + p.Name
+	}
+	if p.Namespace != "" && (p.Type == "npm" || p.Type == "composer" || p.Type == "golang") {
+		return p.Namespace + "/" + p.Name
+	}
+	return p.Name
+}
@@ -0,0 +1,267 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	godeptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/gobinary"
+	"github.com/aquasecurity/trivy/pkg/jar"
+	"github.com/aquasecurity/trivy/pkg/report"
+)
+
+// osPackageTypes are the package URL types of the OS packages, whose namespace is the OS family
+var osPackageTypes = map[string]bool{"apk": true, "deb": true, "rpm": true}
+
+// libraryTypes maps the package URL type of the libraries to the application type and the file name
+// whose driver detects their vulnerabilities, as the libraries of an SBOM have no lock file
+var libraryTypes = map[string][2]string{
+	"npm":      {"npm", "package-lock.json"},
+	"gem":      {"bundler", "Gemfile.lock"},
+	"pypi":     {"pipenv", "Pipfile.lock"},
+	"cargo":    {"cargo", "Cargo.lock"},
+	"composer": {"composer", "composer.lock"},
+	"golang":   {gobinary.Type, "go.mod"},
+	"maven":    {jar.Type, "pom.xml"},
+}
+
+// osTargetName matches the OS of the targets of the OS packages of trivy, e.g. "alpine:3.11 (alpine 3.11.3)"
+var osTargetName = regexp.MustCompile(`\((\S+) (\S+)\)$`)
+
+// BOM is the packages of an SBOM. The OS packages are only scanned with the OS, which is read from
+// the operating-system component of CycloneDX, the OPERATING-SYSTEM package of SPDX
+// or the distro qualifier of the package URLs, e.g. distro=alpine-3.11.3.
+type BOM struct {
+	OS           *ftypes.OS
+	Packages     []ftypes.Package
+	Applications []ftypes.Application
+	// Skipped is the number of the packages without a package URL of a supported type
+	Skipped int
+}
+
+// Decode reads a CycloneDX document in JSON or XML, or an SPDX document in JSON or tag-value.
+// The packages are identified by their package URLs.
+func Decode(r io.Reader) (BOM, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return BOM{}, xerrors.Errorf("unable to read the SBOM: %w", err)
+	}
+	b = bytes.TrimSpace(b)
+
+	switch {
+	case bytes.HasPrefix(b, []byte("{")):
+		var format struct {
+			BOMFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if err = json.Unmarshal(b, &format); err != nil {
+			return BOM{}, xerrors.Errorf("invalid JSON SBOM: %w", err)
+		}
+		switch {
+		case format.BOMFormat == "CycloneDX":
+			var bom report.CycloneDXBOM
+			if err = json.Unmarshal(b, &bom); err != nil {
+				return BOM{}, xerrors.Errorf("invalid CycloneDX BOM: %w", err)
+			}
+			return fromCycloneDX(bom), nil
+		case format.SPDXVersion != "":
+			var doc report.SPDXDocument
+			if err = json.Unmarshal(b, &doc); err != nil {
+				return BOM{}, xerrors.Errorf("invalid SPDX document: %w", err)
+			}
+			return fromSPDX(doc), nil
+		}
+		return BOM{}, xerrors.New("unknown JSON SBOM, neither CycloneDX nor SPDX")
+	case bytes.HasPrefix(b, []byte("<")):
+		var bom report.CycloneDXBOM
+		if err = xml.Unmarshal(b, &bom); err != nil {
+			return BOM{}, xerrors.Errorf("invalid CycloneDX BOM: %w", err)
+		}
+		return fromCycloneDX(bom), nil
+	case bytes.HasPrefix(b, []byte("SPDXVersion:")):
+		return fromSPDX(parseSPDXTagValue(b)), nil
+	}
+	return BOM{}, xerrors.New("unknown SBOM format, neither CycloneDX nor SPDX")
+}
+
+func fromCycloneDX(bom report.CycloneDXBOM) BOM {
+	var b builder
+	for _, component := range bom.Components {
+		if component.Type == "operating-system" && component.Version != "" {
+			b.os = &ftypes.OS{Family: strings.ToLower(component.Name), Name: component.Version}
+			continue
+		}
+		b.add(component.PURL)
+	}
+	return b.build()
+}
+
+func fromSPDX(doc report.SPDXDocument) BOM {
+	var b builder
+	for _, pkg := range doc.Packages {
+		if pkg.PrimaryPurpose == "OPERATING-SYSTEM" {
+			if pkg.VersionInfo != "" {
+				b.os = &ftypes.OS{Family: strings.ToLower(pkg.Name), Name: pkg.VersionInfo}
+			} else if m := osTargetName.FindStringSubmatch(pkg.Name); m != nil {
+				b.os = &ftypes.OS{Family: m[1], Name: m[2]}
+			}
+			continue
+		}
+		var purl string
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				purl = ref.ReferenceLocator
+			}
+		}
+		// the packages of the artifact and the targets of trivy have no package URL
+		if purl == "" && pkg.VersionInfo == "" {
+			continue
+		}
+		b.add(purl)
+	}
+	return b.build()
+}
+
+// parseSPDXTagValue reads the packages of an SPDX tag-value document, the other tags are ignored
+func parseSPDXTagValue(b []byte) report.SPDXDocument {
+	var doc report.SPDXDocument
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		i := strings.Index(scanner.Text(), ":")
+		if i < 0 {
+			continue
+		}
+		tag, value := scanner.Text()[:i], strings.TrimSpace(scanner.Text()[i+1:])
+		if tag == "PackageName" {
+			doc.Packages = append(doc.Packages, report.SPDXPackage{Name: value})
+			continue
+		}
+		if len(doc.Packages) == 0 {
+			continue
+		}
+		pkg := &doc.Packages[len(doc.Packages)-1]
+		switch tag {
+		case "PackageVersion":
+			pkg.VersionInfo = value
+		case "PrimaryPackagePurpose":
+			pkg.PrimaryPurpose = value
+		case "ExternalRef":
+			if fields := strings.Fields(value); len(fields) == 3 {
+				pkg.ExternalRefs = append(pkg.ExternalRefs, report.SPDXExternalRef{
+					ReferenceCategory: fields[0], ReferenceType: fields[1], ReferenceLocator: fields[2],
+				})
+			}
+		}
+	}
+	return doc
+}
+
+// builder collects the packages in the order they first appear, once each
+type builder struct {
+	os       *ftypes.OS
+	distro   *ftypes.OS
+	pkgs     []ftypes.Package
+	apps     []ftypes.Application
+	seen     map[string]bool
+	skipped  int
+	appIndex map[string]int
+}
+
+func (b *builder) add(purl string) {
+	p, err := ParsePackageURL(purl)
+	if err != nil {
+		b.skipped++
+		return
+	}
+	if b.seen == nil {
+		b.seen = map[string]bool{}
+		b.appIndex = map[string]int{}
+	}
+	key := p.Type + "/" + p.PackageName() + "@" + p.Version
+	if b.seen[key] {
+		return
+	}
+
+	if osPackageTypes[p.Type] {
+		b.seen[key] = true
+		b.pkgs = append(b.pkgs, newPackage(p))
+		if b.distro == nil {
+			b.distro = distro(p)
+		}
+		return
+	}
+	t, ok := libraryTypes[p.Type]
+	if !ok {
+		b.skipped++
+		return
+	}
+	b.seen[key] = true
+	i, ok := b.appIndex[p.Type]
+	if !ok {
+		i = len(b.apps)
+		b.appIndex[p.Type] = i
+		b.apps = append(b.apps, ftypes.Application{Type: t[0], FilePath: t[1]})
+	}
+	b.apps[i].Libraries = append(b.apps[i].Libraries, ftypes.LibraryInfo{
+		Library: godeptypes.Library{Name: p.PackageName(), Version: p.Version},
+	})
+}
+
+func (b *builder) build() BOM {
+	detected := b.os
+	if detected == nil {
+		detected = b.distro
+	}
+	return BOM{OS: detected, Packages: b.pkgs, Applications: b.apps, Skipped: b.skipped}
+}
+
+// newPackage converts an OS package, whose source package is the upstream qualifier when there is one
+func newPackage(p PackageURL) ftypes.Package {
+	pkg := ftypes.Package{Name: p.Name, Arch: p.Qualifiers.Get("arch")}
+	pkg.Epoch, pkg.Version = splitEpoch(p.Version)
+	if epoch, err := strconv.Atoi(p.Qualifiers.Get("epoch")); err == nil {
+		pkg.Epoch = epoch
+	}
+
+	pkg.SrcName, pkg.SrcVersion, pkg.SrcEpoch = pkg.Name, pkg.Version, pkg.Epoch
+	if upstream := p.Qualifiers.Get("upstream"); upstream != "" {
+		// e.g. upstream=openssl or upstream=openssl@1.1.1d-0+deb10u2
+		pkg.SrcName = upstream
+		if i := strings.Index(upstream, "@"); i >= 0 {
+			pkg.SrcName = upstream[:i]
+			pkg.SrcEpoch, pkg.SrcVersion = splitEpoch(upstream[i+1:])
+		}
+	}
+	return pkg
+}
+
+// splitEpoch splits the epoch of the version, e.g. 1 and 1.2.11-4 of 1:1.2.11-4
+func splitEpoch(version string) (int, string) {
+	if i := strings.Index(version, ":"); i > 0 {
+		if epoch, err := strconv.Atoi(version[:i]); err == nil {
+			return epoch, version[i+1:]
+		}
+	}
+	return 0, version
+}
+
+// distro returns the OS of the distro qualifier of the family and the version, e.g. debian-10;
+// the code names, e.g. buster, don't give the version
+func distro(p PackageURL) *ftypes.OS {
+	d := p.Qualifiers.Get("distro")
+	i := strings.LastIndex(d, "-")
+	if i <= 0 || i == len(d)-1 {
+		return nil
+	}
+	return &ftypes.OS{Family: strings.ToLower(d[:i]), Name: d[i+1:]}
+}
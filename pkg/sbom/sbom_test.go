@@ -0,0 +1,250 @@
+package sbom
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	godeptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestDecode(t *testing.T) {
+	// the results of trivy written as SBOMs
+	results := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Class:  report.ClassOSPkgs,
+			Packages: []types.InstalledPackage{
+				{Name: "musl", Version: "1.1.24-r2"},
+			},
+		},
+		{
+			Target: "app/package-lock.json",
+			Type:   "npm",
+			Class:  report.ClassLangPkgs,
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4"},
+			},
+			Packages: []types.InstalledPackage{
+				{Name: "@babel/core", Version: "7.0.0"},
+				{Name: "lodash", Version: "4.17.4"},
+			},
+		},
+	}
+	writeSBOM := func(w report.Writer, output *bytes.Buffer) string {
+		require.NoError(t, w.Write(results))
+		return output.String()
+	}
+	var spdxJSON, spdxTagValue, cyclonedxXML bytes.Buffer
+	timestamp := time.Date(2021, 8, 25, 12, 20, 30, 0, time.UTC)
+	trivyApps := []ftypes.Application{
+		{
+			Type:     "npm",
+			FilePath: "package-lock.json",
+			Libraries: []ftypes.LibraryInfo{
+				{Library: godeptypes.Library{Name: "@babel/core", Version: "7.0.0"}},
+				{Library: godeptypes.Library{Name: "lodash", Version: "4.17.4"}},
+			},
+		},
+	}
+	alpinePkgs := []ftypes.Package{
+		{Name: "musl", Version: "1.1.24-r2", SrcName: "musl", SrcVersion: "1.1.24-r2"},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    BOM
+		wantErr string
+	}{
+		{
+			name: "CycloneDX JSON with the OS component",
+			input: func() string {
+				b, err := ioutil.ReadFile("testdata/cyclonedx.json")
+				require.NoError(t, err)
+				return string(b)
+			}(),
+			want: BOM{
+				OS: &ftypes.OS{Family: "debian", Name: "10.3"},
+				Packages: []ftypes.Package{
+					{Name: "libssl1.1", Version: "1.1.1d-0+deb10u2", Arch: "amd64", SrcName: "openssl", SrcVersion: "1.1.1d-0+deb10u2"},
+					{Name: "zlib1g", Version: "1.2.11.dfsg-1", Epoch: 1, SrcName: "zlib", SrcVersion: "1.2.11.dfsg-1", SrcEpoch: 1},
+				},
+				Applications: []ftypes.Application{
+					{
+						Type:     "npm",
+						FilePath: "package-lock.json",
+						Libraries: []ftypes.LibraryInfo{
+							{Library: godeptypes.Library{Name: "@babel/core", Version: "7.0.0"}},
+							{Library: godeptypes.Library{Name: "lodash", Version: "4.17.15"}},
+						},
+					},
+					{
+						Type:     "bundler",
+						FilePath: "Gemfile.lock",
+						Libraries: []ftypes.LibraryInfo{
+							{Library: godeptypes.Library{Name: "rack", Version: "2.0.7"}},
+						},
+					},
+					{
+						Type:     "jar",
+						FilePath: "pom.xml",
+						Libraries: []ftypes.LibraryInfo{
+							{Library: godeptypes.Library{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}},
+						},
+					},
+				},
+				Skipped: 1,
+			},
+		},
+		{
+			name: "SPDX tag-value with the distro qualifiers",
+			input: func() string {
+				b, err := ioutil.ReadFile("testdata/distro.spdx")
+				require.NoError(t, err)
+				return string(b)
+			}(),
+			want: BOM{
+				OS: &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+				Packages: []ftypes.Package{
+					{Name: "musl", Version: "1.1.24-r2", SrcName: "musl", SrcVersion: "1.1.24-r2"},
+					{Name: "libcrypto1.1", Version: "1.1.1d-r3", SrcName: "openssl", SrcVersion: "1.1.1d-r3"},
+				},
+			},
+		},
+		{
+			name:  "SPDX JSON of trivy",
+			input: writeSBOM(report.SPDXWriter{Output: &spdxJSON, ArtifactName: "alpine:3.11", Timestamp: timestamp}, &spdxJSON),
+			want: BOM{
+				OS:           &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+				Packages:     alpinePkgs,
+				Applications: trivyApps,
+			},
+		},
+		{
+			name: "SPDX tag-value of trivy",
+			input: writeSBOM(report.SPDXWriter{Output: &spdxTagValue, Format: report.SPDXFormatTagValue,
+				ArtifactName: "alpine:3.11", Timestamp: timestamp}, &spdxTagValue),
+			want: BOM{
+				OS:           &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+				Packages:     alpinePkgs,
+				Applications: trivyApps,
+			},
+		},
+		{
+			name: "CycloneDX XML of trivy without the OS",
+			input: writeSBOM(report.CycloneDXWriter{Output: &cyclonedxXML, Format: report.CycloneDXFormatXML,
+				Timestamp: timestamp}, &cyclonedxXML),
+			// the vulnerable components come first
+			want: BOM{
+				Packages: alpinePkgs,
+				Applications: []ftypes.Application{
+					{
+						Type:     "npm",
+						FilePath: "package-lock.json",
+						Libraries: []ftypes.LibraryInfo{
+							{Library: godeptypes.Library{Name: "lodash", Version: "4.17.4"}},
+							{Library: godeptypes.Library{Name: "@babel/core", Version: "7.0.0"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "JSON of another format",
+			input:   `{"SchemaVersion": 2, "Results": []}`,
+			wantErr: "unknown JSON SBOM",
+		},
+		{
+			name:    "not an SBOM",
+			input:   "alpine:3.11",
+			wantErr: "unknown SBOM format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		purl        string
+		want        PackageURL
+		wantPkgName string
+		wantErr     string
+	}{
+		{
+			name:        "npm scope escaped",
+			purl:        "pkg:npm/%40babel/core@7.0.0",
+			want:        PackageURL{Type: "npm", Namespace: "@babel", Name: "core", Version: "7.0.0"},
+			wantPkgName: "@babel/core",
+		},
+		{
+			name:        "npm scope unescaped",
+			purl:        "pkg:npm/@babel/core@7.0.0",
+			want:        PackageURL{Type: "npm", Namespace: "@babel", Name: "core", Version: "7.0.0"},
+			wantPkgName: "@babel/core",
+		},
+		{
+			name:        "maven group",
+			purl:        "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+			want:        PackageURL{Type: "maven", Namespace: "org.apache.logging.log4j", Name: "log4j-core", Version: "2.14.1"},
+			wantPkgName: "org.apache.logging.log4j:log4j-core",
+		},
+		{
+			name: "qualifiers and subpath",
+			purl: "pkg:rpm/centos/openssl-libs@1.0.2k-19.el7?arch=x86_64&epoch=1#usr/lib",
+			want: PackageURL{Type: "rpm", Namespace: "centos", Name: "openssl-libs", Version: "1.0.2k-19.el7",
+				Qualifiers: map[string][]string{"arch": {"x86_64"}, "epoch": {"1"}}},
+			wantPkgName: "openssl-libs",
+		},
+		{
+			name:        "without version",
+			purl:        "pkg:gem/rack",
+			want:        PackageURL{Type: "gem", Name: "rack"},
+			wantPkgName: "rack",
+		},
+		{
+			name:    "not a package URL",
+			purl:    "https://example.com/rack",
+			wantErr: "invalid package URL",
+		},
+		{
+			name:    "without name",
+			purl:    "pkg:gem@1.0.0",
+			wantErr: "invalid package URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePackageURL(tt.purl)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPkgName, got.PackageName())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package scanner
+
+import (
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// affectedCounts counts the findings of each vulnerability across the results
+func affectedCounts(results report.Results) map[string]int {
+	counts := map[string]int{}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			counts[vuln.VulnerabilityID]++
+		}
+	}
+	return counts
+}
+
+// filterByAffectedCount keeps the findings of the vulnerabilities found at least min times.
+// The others are dropped, or moved to Uncommon when keepUncommon is set.
+func filterByAffectedCount(results report.Results, min int, keepUncommon bool) report.Results {
+	counts := affectedCounts(results)
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if counts[vuln.VulnerabilityID] >= min {
+				vulns = append(vulns, vuln)
+			} else if keepUncommon {
+				results[i].Uncommon = append(results[i].Uncommon, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
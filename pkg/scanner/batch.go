@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// DefaultBatchWorkers is the number of images scanned concurrently by BatchScanner.ScanImages
+// when ScanOptions.BatchWorkers is zero
+const DefaultBatchWorkers = 4
+
+// BatchReport is the result of BatchScanner.ScanImages.
+// Images has the results or the error of each image, and Findings has the vulnerabilities of the images
+// scanned without error deduplicated across the batch when ScanOptions.DedupBatch is set.
+type BatchReport struct {
+	Images   []ImageScan
+	Findings []report.BatchFinding
+}
+
+// AnalyzerFactory returns the analyzer of the image and a function releasing it, e.g. a wire injector of the mode
+type AnalyzerFactory func(ctx context.Context, imageName string) (Analyzer, func(), error)
+
+// ImageScan is an image scanned by BatchScanner.ScanImages, tagged with the requested name in Image.
+// Err is set when the image couldn't be analyzed or scanned, without results.
+type ImageScan struct {
+	report.ImageResults
+	Reference ftypes.ImageReference
+	Err       error
+}
+
+// BatchScanner scans several images, each with the analyzer created by its factory
+type BatchScanner struct {
+	driver  Driver
+	factory AnalyzerFactory
+}
+
+// NewBatchScanner returns a scanner of the images whose analyzers are created by the factory
+func NewBatchScanner(driver Driver, factory AnalyzerFactory) BatchScanner {
+	return BatchScanner{driver: driver, factory: factory}
+}
+
+// ScanImages scans the images concurrently with at most ScanOptions.BatchWorkers at once,
+// returning them in the order of targets. A failed image doesn't stop the others; its error is in ImageScan.Err.
+func (s BatchScanner) ScanImages(ctx context.Context, targets []string, options types.ScanOptions) (BatchReport, error) {
+	if options.BatchWorkers < 0 {
+		return BatchReport{}, xerrors.Errorf("invalid batch workers: negative count %d", options.BatchWorkers)
+	}
+	workers := options.BatchWorkers
+	if workers == 0 {
+		workers = DefaultBatchWorkers
+	}
+
+	scans := make([]ImageScan, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				scans[i] = s.scanBatchImage(ctx, targets[i], options)
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if !options.DedupBatch {
+		return BatchReport{Images: scans}, nil
+	}
+	var images []report.ImageResults
+	for _, scan := range scans {
+		if scan.Err == nil {
+			images = append(images, scan.ImageResults)
+		}
+	}
+	return BatchReport{Images: scans, Findings: report.DedupFindings(images)}, nil
+}
+
+func (s BatchScanner) scanBatchImage(ctx context.Context, target string, options types.ScanOptions) ImageScan {
+	scan := ImageScan{ImageResults: report.ImageResults{Image: target}}
+
+	analyzer, cleanup, err := s.factory(ctx, target)
+	if err != nil {
+		scan.Err = xerrors.Errorf("failed to initialize the analyzer of %s: %w", target, err)
+		return scan
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	// the results of a partial scan are kept with the error
+	r, err := NewScanner(s.driver, analyzer).ScanImageReport(ctx, options)
+	if err != nil {
+		scan.Err = xerrors.Errorf("failed to scan %s: %w", target, err)
+	}
+	scan.Reference = r.Image
+	scan.Results = r.Results
+	return scan
+}
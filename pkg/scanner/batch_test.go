@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// imagesDriver returns the vulnerabilities of each image, or fails the scan of failing
+type imagesDriver struct {
+	vulns   map[string][]types.DetectedVulnerability
+	failing string
+}
+
+func (d imagesDriver) Scan(target string, _ string, _ []string, _ types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	if target == d.failing {
+		return nil, nil, false, xerrors.New("error")
+	}
+	return report.Results{{Target: target, Type: "alpine", Vulnerabilities: d.vulns[target]}}, nil, false, nil
+}
+
+// imagesFactory returns the analyzers of the images named as their targets
+func imagesFactory(_ context.Context, imageName string) (Analyzer, func(), error) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: imageName, ID: "sha256:" + imageName},
+		},
+	})
+	return analyzer, nil, nil
+}
+
+func TestBatchScanner_ScanImages_Dedup(t *testing.T) {
+	openssl := types.DetectedVulnerability{
+		VulnerabilityID:  "CVE-2020-1967",
+		PkgName:          "openssl",
+		InstalledVersion: "1.1.1d-r3",
+		FixedVersion:     "1.1.1g-r0",
+	}
+	musl := types.DetectedVulnerability{
+		VulnerabilityID:  "CVE-2019-14697",
+		PkgName:          "musl",
+		InstalledVersion: "1.1.20-r4",
+		FixedVersion:     "1.1.20-r5",
+	}
+	vulns := map[string][]types.DetectedVulnerability{
+		"alpine:3.10":       {openssl, musl},
+		"nginx:1.17-alpine": {openssl},
+		"redis:5-alpine":    {openssl},
+	}
+	targets := []string{"alpine:3.10", "nginx:1.17-alpine", "redis:5-alpine"}
+
+	tests := []struct {
+		name         string
+		failing      string
+		options      types.ScanOptions
+		wantImages   []report.ImageResults
+		wantFindings []report.BatchFinding
+	}{
+		{
+			name:    "per image",
+			options: types.ScanOptions{VulnType: []string{"os"}},
+			wantImages: []report.ImageResults{
+				{Image: "alpine:3.10", Results: report.Results{{Target: "alpine:3.10", Type: "alpine", Vulnerabilities: []types.DetectedVulnerability{openssl, musl}}}},
+				{Image: "nginx:1.17-alpine", Results: report.Results{{Target: "nginx:1.17-alpine", Type: "alpine", Vulnerabilities: []types.DetectedVulnerability{openssl}}}},
+				{Image: "redis:5-alpine", Results: report.Results{{Target: "redis:5-alpine", Type: "alpine", Vulnerabilities: []types.DetectedVulnerability{openssl}}}},
+			},
+		},
+		{
+			name:    "deduplicated across the batch",
+			options: types.ScanOptions{VulnType: []string{"os"}, DedupBatch: true},
+			wantFindings: []report.BatchFinding{
+				{Images: []string{"alpine:3.10", "nginx:1.17-alpine", "redis:5-alpine"}, DetectedVulnerability: openssl},
+				{Images: []string{"alpine:3.10"}, DetectedVulnerability: musl},
+			},
+		},
+		{
+			name:    "the failed images are left out of the deduplicated findings",
+			failing: "nginx:1.17-alpine",
+			options: types.ScanOptions{VulnType: []string{"os"}, DedupBatch: true},
+			wantFindings: []report.BatchFinding{
+				{Images: []string{"alpine:3.10", "redis:5-alpine"}, DetectedVulnerability: openssl},
+				{Images: []string{"alpine:3.10"}, DetectedVulnerability: musl},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewBatchScanner(imagesDriver{vulns: vulns, failing: tt.failing}, imagesFactory)
+			got, err := s.ScanImages(context.Background(), targets, tt.options)
+			require.NoError(t, err)
+			require.Len(t, got.Images, len(targets))
+			assert.Equal(t, tt.wantFindings, got.Findings)
+			if tt.wantImages != nil {
+				var images []report.ImageResults
+				for _, scan := range got.Images {
+					require.NoError(t, scan.Err)
+					images = append(images, scan.ImageResults)
+				}
+				assert.Equal(t, tt.wantImages, images)
+			}
+		})
+	}
+}
+
+// concurrentDriver records the maximum number of concurrent scans and fails the scan of failing
+type concurrentDriver struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	failing  string
+}
+
+func (d *concurrentDriver) Scan(target string, _ string, _ []string, _ types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	d.mu.Lock()
+	d.inFlight++
+	if d.inFlight > d.max {
+		d.max = d.inFlight
+	}
+	d.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	d.mu.Lock()
+	d.inFlight--
+	d.mu.Unlock()
+	if target == d.failing {
+		return nil, nil, false, xerrors.New("layer not found")
+	}
+	return report.Results{{Target: target + " (alpine 3.11.5)", Type: "alpine"}}, nil, false, nil
+}
+
+func TestBatchScanner_ScanImages(t *testing.T) {
+	factory := func(_ context.Context, imageName string) (Analyzer, func(), error) {
+		if imageName == "unknown:1.0" {
+			return nil, nil, xerrors.New("image not found")
+		}
+		analyzer := new(MockAnalyzer)
+		analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+			Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+			Returns: AnalyzerAnalyzeReturns{
+				Info: ftypes.ImageReference{Name: imageName, ID: "sha256:" + imageName, LayerIDs: []string{"sha256:base"}},
+			},
+		})
+		return analyzer, func() {}, nil
+	}
+	targets := []string{"app:1.0", "sidecar:1.0", "init:1.0", "unknown:1.0", "broken:1.0"}
+
+	d := &concurrentDriver{failing: "broken:1.0"}
+	s := NewBatchScanner(d, factory)
+	got, err := s.ScanImages(context.Background(), targets, types.ScanOptions{VulnType: []string{"os"}, BatchWorkers: 2})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, d.max, 2)
+
+	require.Len(t, got.Images, len(targets))
+	assert.Empty(t, got.Findings)
+	for i, scan := range got.Images {
+		assert.Equal(t, targets[i], scan.Image)
+		switch scan.Image {
+		case "unknown:1.0":
+			require.Error(t, scan.Err)
+			assert.Contains(t, scan.Err.Error(), "image not found")
+		case "broken:1.0":
+			require.Error(t, scan.Err)
+			assert.Contains(t, scan.Err.Error(), "layer not found")
+		default:
+			require.NoError(t, scan.Err)
+			assert.Equal(t, scan.Image, scan.Reference.Name)
+			assert.Equal(t, report.Results{{Target: scan.Image + " (alpine 3.11.5)", Type: "alpine"}}, scan.Results)
+		}
+	}
+
+	_, err = s.ScanImages(context.Background(), targets, types.ScanOptions{BatchWorkers: -1})
+	assert.Error(t, err)
+}
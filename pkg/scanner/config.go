@@ -0,0 +1,297 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/extractor/fs"
+	"github.com/aquasecurity/trivy/pkg/misconf"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/secret"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// ConfigBlobProvider is implemented by analyzers that can return the raw image config
+type ConfigBlobProvider interface {
+	ConfigBlob() ([]byte, error)
+}
+
+// LayerSizeProvider is implemented by analyzers that know the size in bytes of the layers by diff ID
+type LayerSizeProvider interface {
+	LayerSizes() (map[string]int64, error)
+}
+
+// FilesystemAnalyzer is implemented by analyzers that can analyze a local directory instead of the image
+type FilesystemAnalyzer interface {
+	AnalyzeFilesystem(ctx context.Context, root string) (ftypes.ImageReference, error)
+}
+
+// RootfsAnalyzer is implemented by analyzers that can analyze the root filesystem of a host, a VM or a container
+type RootfsAnalyzer interface {
+	AnalyzeRootfs(ctx context.Context, root string) (ftypes.ImageReference, error)
+}
+
+// ImageAnalyzer is analyzer.Config exposing the image config of its extractor.
+// The files larger than the maximum file size are skipped with a warning, before they are read by the extractors
+// implementing SizeLimitedExtractor, and their layers are cached as incomplete.
+type ImageAnalyzer struct {
+	analyzer.Config
+	parallel *parallelExtractor
+	paths    *pathFilterExtractor
+	limiter  *sizeLimitExtractor
+	secrets  *secretExtractor
+	misconfs *misconfExtractor
+	licenses *licenseExtractor
+	digests  *digestCache
+}
+
+func NewImageAnalyzer(ac analyzer.Config) ImageAnalyzer {
+	// the limiter is on the extractor itself to check the sizes before the files are read
+	limiter := &sizeLimitExtractor{Extractor: ac.Extractor, incomplete: map[string]bool{}}
+	limiter.setMaxSize(DefaultMaxFileSize)
+	parallel := &parallelExtractor{Extractor: limiter}
+	paths := &pathFilterExtractor{Extractor: parallel}
+	limiter.paths = paths
+	secrets := &secretExtractor{Extractor: paths}
+	misconfs := &misconfExtractor{Extractor: secrets}
+	licenses := &licenseExtractor{Extractor: misconfs}
+	ac.Extractor = licenses
+	digests := newDigestCache(ac.Cache)
+	ac.Cache = &progressCache{ImageCache: pathFilterCache{
+		ImageCache: fileScanCache{
+			ImageCache: sizeLimitCache{ImageCache: digests, limiter: limiter},
+			secrets:    secrets, misconfs: misconfs, licenses: licenses,
+		},
+		paths: paths,
+	}}
+	return ImageAnalyzer{Config: ac, parallel: parallel, paths: paths, limiter: limiter, secrets: secrets, misconfs: misconfs,
+		licenses: licenses, digests: digests}
+}
+
+func (a ImageAnalyzer) ConfigBlob() ([]byte, error) {
+	return a.Extractor.ConfigBlob()
+}
+
+// SetMaxFileSize sets the size limit of the analyzed files. Layers analyzed before are cached with the previous limit,
+// except those with skipped files.
+func (a ImageAnalyzer) SetMaxFileSize(size int64) {
+	a.limiter.setMaxSize(size)
+}
+
+// SetPathFilter sets the directories and the files skipped by the next analyses, and the custom patterns of the files
+// of the library analyzers. The layers are cached with the filter, so the cache should be of the run only,
+// e.g. cache.NewMemoryCache of pkg/cache.
+func (a ImageAnalyzer) SetPathFilter(filter PathFilter) {
+	a.paths.setFilter(filter)
+}
+
+// SetParallel bounds the number of layers extracted at once, zero or a negative number doesn't
+func (a ImageAnalyzer) SetParallel(n int) {
+	a.parallel.setParallel(n)
+}
+
+// Warnings returns the files skipped by the last analysis, by the size limit or by the extractor,
+// and the config files it couldn't scan for misconfigurations
+func (a ImageAnalyzer) Warnings() []string {
+	return append(a.limiter.takeWarnings(), a.misconfs.takeWarnings()...)
+}
+
+// AnalyzeFilesystem analyzes the directory as an image with a single layer, with the cache and the file size limit
+// of the image analysis. The skipped files are in the warnings of the analysis.
+func (a ImageAnalyzer) AnalyzeFilesystem(ctx context.Context, root string) (ftypes.ImageReference, error) {
+	ext, err := fs.NewExtractor(root)
+	if err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("invalid filesystem: %w", err)
+	}
+	return a.analyzeDir(ctx, ext)
+}
+
+// AnalyzeRootfs analyzes the root filesystem as AnalyzeFilesystem analyzes a directory, without searching
+// the pseudo filesystems of a running host, e.g. /proc, for lock files
+func (a ImageAnalyzer) AnalyzeRootfs(ctx context.Context, root string) (ftypes.ImageReference, error) {
+	ext, err := fs.NewRootfsExtractor(root)
+	if err != nil {
+		return ftypes.ImageReference{}, xerrors.Errorf("invalid root filesystem: %w", err)
+	}
+	return a.analyzeDir(ctx, ext)
+}
+
+func (a ImageAnalyzer) analyzeDir(ctx context.Context, ext *fs.Extractor) (ftypes.ImageReference, error) {
+	limiter := &sizeLimitExtractor{Extractor: ext, incomplete: map[string]bool{}}
+	limiter.setMaxSize(a.limiter.getMaxSize())
+	paths := &pathFilterExtractor{Extractor: limiter, filter: a.paths.getFilter()}
+	limiter.paths = paths
+	secrets := &secretExtractor{Extractor: paths, scanner: a.secrets.getScanner()}
+	misconfs := &misconfExtractor{Extractor: secrets, scanner: a.misconfs.getScanner()}
+	licenses := &licenseExtractor{Extractor: misconfs, enabled: a.licenses.isEnabled()}
+	ref, err := analyzer.New(licenses, sizeLimitCache{ImageCache: a.Cache, limiter: limiter}).Analyze(ctx)
+	a.limiter.addWarnings(limiter.takeWarnings())
+	a.misconfs.addWarnings(misconfs.takeWarnings())
+
+	// the directory has a single layer
+	findings := secrets.takeFindings()
+	for _, f := range findings {
+		for i := range f {
+			f[i].Layer = ""
+		}
+	}
+	a.secrets.addFindings(findings)
+	found := misconfs.takeMisconfs()
+	for _, m := range found {
+		for i := range m {
+			m[i].Layer = ""
+		}
+	}
+	a.misconfs.addMisconfs(found)
+	a.licenses.addLicenses(licenses.takeLicenses())
+	return ref, err
+}
+
+// FileDigests returns the digests of the lock files of the last analysis, including those of the cached layers
+func (a ImageAnalyzer) FileDigests() map[string]string {
+	return a.digests.takeDigests()
+}
+
+// SetSecretScanner enables the secret detection of the next analyses, or disables it with nil.
+// Every layer is then analyzed, including those in the cache.
+func (a ImageAnalyzer) SetSecretScanner(s *secret.Scanner) {
+	a.secrets.setScanner(s)
+}
+
+// Secrets returns the secrets found by the last analysis by file path
+func (a ImageAnalyzer) Secrets() map[string][]types.SecretFinding {
+	return a.secrets.takeFindings()
+}
+
+// SetMisconfScanner enables the misconfiguration detection of the next analyses, or disables it with nil.
+// Every layer is then analyzed, including those in the cache.
+func (a ImageAnalyzer) SetMisconfScanner(s *misconf.Scanner) {
+	a.misconfs.setScanner(s)
+}
+
+// Misconfigurations returns the misconfigurations found by the last analysis by file path
+func (a ImageAnalyzer) Misconfigurations() map[string][]types.Misconfiguration {
+	return a.misconfs.takeMisconfs()
+}
+
+// SetLicenseDetection enables the license detection of the next analyses, or disables it.
+// Every layer is then analyzed, including those in the cache.
+func (a ImageAnalyzer) SetLicenseDetection(enabled bool) {
+	a.licenses.setEnabled(enabled)
+}
+
+// Licenses returns the licenses found by the last analysis by file path
+func (a ImageAnalyzer) Licenses() map[string][]types.DetectedLicense {
+	return a.licenses.takeLicenses()
+}
+
+// LayerSizes returns the layer sizes when the extractor provides them, otherwise nil
+func (a ImageAnalyzer) LayerSizes() (map[string]int64, error) {
+	provider, ok := a.limiter.Extractor.(LayerSizeProvider)
+	if !ok {
+		return nil, nil
+	}
+	return provider.LayerSizes()
+}
+
+// imageConfig is the subset of the OCI image config used for observations
+type imageConfig struct {
+	Config struct {
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	} `json:"config"`
+}
+
+// imageHistory is the subset of the OCI image config mapping layers to the commands creating them
+type imageHistory struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// imageCreated returns the creation time of the image in its config, nil when it isn't set
+func imageCreated(configBlob []byte) (*time.Time, error) {
+	var config struct {
+		Created *time.Time `json:"created"`
+	}
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, xerrors.Errorf("invalid image config: %w", err)
+	}
+	if config.Created == nil || config.Created.IsZero() {
+		return nil, nil
+	}
+	created := config.Created.UTC()
+	return &created, nil
+}
+
+// layerCreatedBy maps the diff ID of each layer to its created_by command in the history.
+// The history entries of empty layers have no diff ID.
+func layerCreatedBy(configBlob []byte) (map[string]string, error) {
+	var config imageHistory
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, xerrors.Errorf("invalid image config: %w", err)
+	}
+
+	createdBy := map[string]string{}
+	i := 0
+	for _, h := range config.History {
+		if h.EmptyLayer {
+			continue
+		}
+		if i >= len(config.RootFS.DiffIDs) {
+			break
+		}
+		if h.CreatedBy != "" {
+			createdBy[config.RootFS.DiffIDs[i]] = h.CreatedBy
+		}
+		i++
+	}
+	return createdBy, nil
+}
+
+func scanConfig(target string, configBlob []byte) (*report.Result, error) {
+	var config imageConfig
+	if err := json.Unmarshal(configBlob, &config); err != nil {
+		return nil, xerrors.Errorf("invalid image config: %w", err)
+	}
+
+	var findings []types.ConfigFinding
+	switch config.Config.User {
+	case "", "root", "0", "0:0", "root:root":
+		findings = append(findings, types.ConfigFinding{
+			ID:       "root-user",
+			Title:    "Image user should not be root",
+			Severity: "HIGH",
+		})
+	}
+
+	var ports []string
+	for port := range config.Config.ExposedPorts {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		findings = append(findings, types.ConfigFinding{
+			ID:       "exposed-port",
+			Title:    fmt.Sprintf("Port %s is exposed", port),
+			Severity: "LOW",
+		})
+	}
+
+	return &report.Result{
+		Target: fmt.Sprintf("%s (image config)", target),
+		Type:   "config",
+		Config: findings,
+	}, nil
+}
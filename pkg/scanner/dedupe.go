@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// dedupeVulns collapses the findings of each result with the same vulnerability ID, package name and installed version
+// into the one of the lowest layer in layerIDs, the diff IDs of the image from the base layer.
+// The findings of layers not in layerIDs come after the others, keeping the first one.
+func dedupeVulns(results report.Results, layerIDs []string) {
+	order := map[string]int{}
+	for i, diffID := range layerIDs {
+		order[diffID] = i
+	}
+	layerIndex := func(vuln types.DetectedVulnerability) int {
+		if i, ok := order[vuln.Layer.DiffID]; ok {
+			return i
+		}
+		return len(layerIDs)
+	}
+
+	type key struct {
+		vulnerabilityID, pkgName, installedVersion string
+	}
+	for i, result := range results {
+		index := map[key]int{}
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			k := key{vuln.VulnerabilityID, vuln.PkgName, vuln.InstalledVersion}
+			j, ok := index[k]
+			if !ok {
+				index[k] = len(vulns)
+				vulns = append(vulns, vuln)
+				continue
+			}
+			if layerIndex(vuln) < layerIndex(vulns[j]) {
+				vulns[j] = vuln
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+}
+
+// dedupeIdenticalFiles keeps the first library result of the lock files with the same type and content digest,
+// listing the targets of the others in its DuplicatePaths. The results without a known digest are kept.
+func dedupeIdenticalFiles(results report.Results, digests map[string]string) report.Results {
+	type key struct {
+		resultType, digest string
+	}
+	index := map[key]int{}
+	var deduped report.Results
+	for _, result := range results {
+		digest := digests[result.Target]
+		if result.Class == report.ClassOSPkgs || digest == "" {
+			deduped = append(deduped, result)
+			continue
+		}
+		k := key{result.Type, digest}
+		if i, ok := index[k]; ok {
+			deduped[i].DuplicatePaths = append(deduped[i].DuplicatePaths, result.Target)
+			continue
+		}
+		index[k] = len(deduped)
+		deduped = append(deduped, result)
+	}
+	return deduped
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestDedupeVulns(t *testing.T) {
+	layerIDs := []string{"sha256:base", "sha256:app", "sha256:patch"}
+	opensslIn := func(diffID string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{
+			VulnerabilityID:  "CVE-2020-1967",
+			PkgName:          "openssl",
+			InstalledVersion: "1.1.1d-r3",
+			Layer:            ftypes.Layer{Digest: "sha256:digest-" + diffID[7:], DiffID: diffID},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		vulns []types.DetectedVulnerability
+		want  []types.DetectedVulnerability
+	}{
+		{
+			name:  "same CVE in two layers",
+			vulns: []types.DetectedVulnerability{opensslIn("sha256:patch"), opensslIn("sha256:app")},
+			want:  []types.DetectedVulnerability{opensslIn("sha256:app")},
+		},
+		{
+			name:  "unknown layer",
+			vulns: []types.DetectedVulnerability{opensslIn("sha256:other"), opensslIn("sha256:base")},
+			want:  []types.DetectedVulnerability{opensslIn("sha256:base")},
+		},
+		{
+			name: "different installed versions",
+			vulns: []types.DetectedVulnerability{
+				opensslIn("sha256:base"),
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1f-r0",
+					Layer: ftypes.Layer{DiffID: "sha256:patch"}},
+				{VulnerabilityID: "CVE-2020-8169", PkgName: "curl", InstalledVersion: "7.67.0-r0",
+					Layer: ftypes.Layer{DiffID: "sha256:app"}},
+			},
+			want: []types.DetectedVulnerability{
+				opensslIn("sha256:base"),
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1f-r0",
+					Layer: ftypes.Layer{DiffID: "sha256:patch"}},
+				{VulnerabilityID: "CVE-2020-8169", PkgName: "curl", InstalledVersion: "7.67.0-r0",
+					Layer: ftypes.Layer{DiffID: "sha256:app"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: tt.vulns}}
+			dedupeVulns(results, layerIDs)
+			assert.Equal(t, tt.want, results[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestScanner_ScanImage_DedupeVulns(t *testing.T) {
+	for _, dedupe := range []bool{true, false} {
+		analyzer := new(MockAnalyzer)
+		analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+			Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+			Returns: AnalyzerAnalyzeReturns{
+				Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base", "sha256:app"}},
+			},
+		})
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args: ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+			Returns: ScanReturns{
+				Results: report.Results{
+					{
+						Target: "alpine:3.11 (alpine 3.11.5)",
+						Type:   "alpine",
+						Vulnerabilities: []types.DetectedVulnerability{
+							{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3",
+								Layer: ftypes.Layer{DiffID: "sha256:app"}},
+							{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3",
+								Layer: ftypes.Layer{DiffID: "sha256:base"}},
+						},
+					},
+				},
+			},
+		})
+
+		s := NewScanner(d, analyzer)
+		results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, DedupeVulns: dedupe})
+		assert.NoError(t, err)
+		if !dedupe {
+			assert.Len(t, results[0].Vulnerabilities, 2)
+			continue
+		}
+		if assert.Len(t, results[0].Vulnerabilities, 1) {
+			assert.Equal(t, "sha256:base", results[0].Vulnerabilities[0].Layer.DiffID)
+		}
+	}
+}
+
+func TestDedupeIdenticalFiles(t *testing.T) {
+	lodash := []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4"}}
+	results := report.Results{
+		{Target: "alpine:3.11 (alpine 3.11.5)", Class: report.ClassOSPkgs, Type: "alpine"},
+		{Target: "app/package-lock.json", Class: report.ClassLangPkgs, Type: "npm", Vulnerabilities: lodash},
+		{Target: "app/node_modules/a/package-lock.json", Class: report.ClassLangPkgs, Type: "npm", Vulnerabilities: lodash},
+		{Target: "web/package-lock.json", Class: report.ClassLangPkgs, Type: "npm"},
+		{Target: "srv/Gemfile.lock", Class: report.ClassLangPkgs, Type: "bundler"},
+		{Target: "lib/node_modules/b/package-lock.json", Class: report.ClassLangPkgs, Type: "npm", Vulnerabilities: lodash},
+	}
+	digests := map[string]string{
+		"app/package-lock.json":                "sha256:lodash",
+		"app/node_modules/a/package-lock.json": "sha256:lodash",
+		"lib/node_modules/b/package-lock.json": "sha256:lodash",
+		"web/package-lock.json":                "sha256:empty",
+		// unknown content
+		"srv/Gemfile.lock": "",
+	}
+
+	got := dedupeIdenticalFiles(results, digests)
+	assert.Equal(t, report.Results{
+		{Target: "alpine:3.11 (alpine 3.11.5)", Class: report.ClassOSPkgs, Type: "alpine"},
+		{Target: "app/package-lock.json", Class: report.ClassLangPkgs, Type: "npm", Vulnerabilities: lodash,
+			DuplicatePaths: []string{"app/node_modules/a/package-lock.json", "lib/node_modules/b/package-lock.json"}},
+		{Target: "web/package-lock.json", Class: report.ClassLangPkgs, Type: "npm"},
+		{Target: "srv/Gemfile.lock", Class: report.ClassLangPkgs, Type: "bundler"},
+	}, got)
+}
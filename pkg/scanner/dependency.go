@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"path/filepath"
+
+	"github.com/aquasecurity/trivy/pkg/dependency"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// setDependencyPaths sets the relationship and the dependency path of the findings of the lock files in the directory
+// whose dependency graph can be parsed. The findings of the packages no direct dependency requires are left as they are.
+func setDependencyPaths(root string, results report.Results) {
+	for _, result := range results {
+		if result.Class == report.ClassOSPkgs || len(result.Vulnerabilities) == 0 || !dependency.Supported(result.Target) {
+			continue
+		}
+		g, err := dependency.ParseFile(filepath.Join(root, filepath.FromSlash(result.Target)))
+		if err != nil {
+			log.Logger.Warnf("Unable to get the dependency graph of %s: %s", result.Target, err)
+			continue
+		}
+		for i, vuln := range result.Vulnerabilities {
+			path := g.Path(dependency.ID(vuln.PkgName, vuln.InstalledVersion))
+			if path == nil {
+				continue
+			}
+			result.Vulnerabilities[i].DependencyPath = path
+			if len(path) == 1 {
+				result.Vulnerabilities[i].Relationship = types.RelationshipDirect
+			} else {
+				result.Vulnerabilities[i].Relationship = types.RelationshipIndirect
+			}
+		}
+	}
+}
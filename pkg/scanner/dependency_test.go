@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestSetDependencyPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dependency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "app"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app", "package-lock.json"), []byte(`{
+  "lockfileVersion": 2,
+  "packages": {
+    "": {"dependencies": {"express": "^4.16.0", "qs": "^6.5.2"}},
+    "node_modules/body-parser": {"version": "1.18.2", "dependencies": {"qs": "6.5.1"}},
+    "node_modules/body-parser/node_modules/qs": {"version": "6.5.1"},
+    "node_modules/express": {"version": "4.16.0", "dependencies": {"body-parser": "1.18.2"}},
+    "node_modules/qs": {"version": "6.5.2"}
+  }
+}`), 0600))
+
+	results := report.Results{
+		{
+			Target: "app/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2017-1000048", PkgName: "qs", InstalledVersion: "6.5.1"},
+				{VulnerabilityID: "CVE-2022-24999", PkgName: "qs", InstalledVersion: "6.5.2"},
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash", InstalledVersion: "4.17.15"},
+			},
+		},
+		{
+			Target: "app/yarn.lock",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2022-24999", PkgName: "qs", InstalledVersion: "6.5.2"},
+			},
+		},
+		{
+			Target: "missing/package-lock.json",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2022-24999", PkgName: "qs", InstalledVersion: "6.5.2"},
+			},
+		},
+	}
+	setDependencyPaths(dir, results)
+
+	assert.Equal(t, []types.DetectedVulnerability{
+		{
+			VulnerabilityID:  "CVE-2017-1000048",
+			PkgName:          "qs",
+			InstalledVersion: "6.5.1",
+			Relationship:     types.RelationshipIndirect,
+			DependencyPath:   []string{"express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"},
+		},
+		{
+			VulnerabilityID:  "CVE-2022-24999",
+			PkgName:          "qs",
+			InstalledVersion: "6.5.2",
+			Relationship:     types.RelationshipDirect,
+			DependencyPath:   []string{"qs@6.5.2"},
+		},
+		{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash", InstalledVersion: "4.17.15"},
+	}, results[0].Vulnerabilities)
+	assert.Empty(t, results[1].Vulnerabilities[0].DependencyPath)
+	assert.Empty(t, results[2].Vulnerabilities[0].DependencyPath)
+}
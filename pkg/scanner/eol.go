@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"fmt"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+
+	"github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// eolResult returns the result of the finding of the OS no longer supported by its distribution,
+// with the end of its support when the version is on the EOL list. override is the version of osFound overriding
+// the detected one, if any.
+func eolResult(target string, osFound ftypes.OS, override, severity string) report.Result {
+	finding := types.EOLFinding{
+		Family:   osFound.Family,
+		Name:     osFound.Name,
+		Severity: severity,
+		Message: fmt.Sprintf("%s %s is not on the EOL list, it may no longer be supported by the distribution",
+			osFound.Family, osFound.Name),
+		EOLOSVersionOverride: override,
+	}
+	if eol, ok := (ospkg.Detector{}).EOLDate(osFound.Family, osFound.Name); ok {
+		finding.EOLDate = &eol
+		finding.Message = fmt.Sprintf("%s %s is no longer supported by the distribution since %s, security updates are not provided",
+			osFound.Family, osFound.Name, eol.Format("2006-01-02"))
+	}
+	return report.Result{
+		Target: fmt.Sprintf("%s (%s %s)", target, osFound.Family, osFound.Name),
+		Type:   osFound.Family,
+		Class:  report.ClassEOL,
+		EOL:    &finding,
+	}
+}
+
+// isEOSL reports whether the OS is no longer supported by its distribution, with the EOL list of its version,
+// or the support check of the detector for the OS families without one. eosl is kept when the OS isn't supported.
+func isEOSL(osFound ftypes.OS, eosl bool) bool {
+	d := ospkg.Detector{}
+	if eol, ok := d.EOLDate(osFound.Family, osFound.Name); ok {
+		return !timeNow().Before(eol)
+	}
+	supported, err := d.IsSupportedVersion(osFound.Family, osFound.Name)
+	if err != nil {
+		log.Logger.Warnf("Unable to check the end of support of %s %s: %s", osFound.Family, osFound.Name, err)
+		return eosl
+	}
+	return !supported
+}
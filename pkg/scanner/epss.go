@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"sort"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func attachEPSS(results report.Results, scores map[string]float64) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if score, ok := scores[vuln.VulnerabilityID]; ok {
+				result.Vulnerabilities[i].EPSS = &score
+			}
+		}
+	}
+}
+
+// filterByEPSS keeps the findings scored above the threshold. Unscored findings are dropped.
+func filterByEPSS(results report.Results, threshold float64) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.EPSS == nil || *vuln.EPSS <= threshold {
+				continue
+			}
+			vulns = append(vulns, vuln)
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// sortByEPSS sorts the findings of each result by descending EPSS score, keeping the order of the unscored ones last
+func sortByEPSS(results report.Results) {
+	for _, result := range results {
+		vulns := result.Vulnerabilities
+		sort.SliceStable(vulns, func(i, j int) bool {
+			if vulns[j].EPSS == nil {
+				return vulns[i].EPSS != nil
+			} else if vulns[i].EPSS == nil {
+				return false
+			}
+			return *vulns[i].EPSS > *vulns[j].EPSS
+		})
+	}
+}
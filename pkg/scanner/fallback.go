@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// temporary is implemented by transient errors such as net.Error timeouts
+type temporary interface {
+	Temporary() bool
+}
+
+// FallbackDriver scans with the fallback driver when the primary one fails with a non-transient error.
+// The results of the fallback driver have Fallback set.
+type FallbackDriver struct {
+	primary  Driver
+	fallback Driver
+}
+
+func NewFallbackDriver(primary, fallback Driver) FallbackDriver {
+	return FallbackDriver{primary: primary, fallback: fallback}
+}
+
+func (d FallbackDriver) Scan(target string, imageID string, layerIDs []string, options types.ScanOptions) (
+	report.Results, *ftypes.OS, bool, error) {
+	results, osFound, eosl, err := d.primary.Scan(target, imageID, layerIDs, options)
+	if err == nil {
+		return results, osFound, eosl, nil
+	}
+	var t temporary
+	if xerrors.As(err, &t) && t.Temporary() {
+		return nil, nil, false, err
+	}
+
+	log.Logger.Warnf("Scanning with the fallback driver as the primary one failed: %s", err)
+	results, osFound, eosl, fallbackErr := d.fallback.Scan(target, imageID, layerIDs, options)
+	if fallbackErr != nil {
+		return nil, nil, false, xerrors.Errorf("the fallback driver failed: %v, the primary one failed: %w", fallbackErr, err)
+	}
+	for i := range results {
+		results[i].Fallback = true
+	}
+	return results, osFound, eosl, nil
+}
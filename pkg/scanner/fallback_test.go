@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "connection reset" }
+func (temporaryError) Temporary() bool { return true }
+
+func TestFallbackDriver_Scan(t *testing.T) {
+	primaryResults := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"},
+			},
+		},
+	}
+	fallbackResults := report.Results{
+		{
+			Target: "alpine:3.11 (alpine 3.11.5)",
+			Type:   "alpine",
+			Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"},
+				{VulnerabilityID: "CVE-2020-1968", PkgName: "openssl"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		primaryErr   error
+		fallbackErr  error
+		wantResults  report.Results
+		wantFallback bool
+		wantErr      string
+	}{
+		{
+			name:        "primary succeeds",
+			wantResults: primaryResults,
+		},
+		{
+			name:         "failing primary triggers the fallback",
+			primaryErr:   errors.New("failed to detect vulnerabilities via RPC"),
+			wantResults:  fallbackResults,
+			wantFallback: true,
+		},
+		{
+			name:       "sad path: transient error",
+			primaryErr: xerrors.Errorf("failed: %w", temporaryError{}),
+			wantErr:    "connection reset",
+		},
+		{
+			name:        "sad path: fallback fails",
+			primaryErr:  errors.New("primary error"),
+			fallbackErr: errors.New("fallback error"),
+			wantErr:     "the fallback driver failed: fallback error, the primary one failed: primary error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := ScanArgs{Target: "alpine:3.11", ImageID: "sha256:alpine", LayerIDs: []string{"sha256:base"}, OptionsAnything: true}
+			primary := new(MockDriver)
+			primary.ApplyScanExpectation(ScanExpectation{
+				Args: args,
+				Returns: ScanReturns{
+					Results: primaryResults,
+					OsFound: &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+					Err:     tt.primaryErr,
+				},
+			})
+			fallback := new(MockDriver)
+			if tt.primaryErr != nil {
+				// a copy as Fallback is set on the results
+				results := append(report.Results{}, fallbackResults...)
+				fallback.ApplyScanExpectation(ScanExpectation{
+					Args: args,
+					Returns: ScanReturns{
+						Results: results,
+						OsFound: &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+						Err:     tt.fallbackErr,
+					},
+				})
+			}
+
+			d := NewFallbackDriver(primary, fallback)
+			gotResults, gotOS, _, err := d.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base"}, types.ScanOptions{})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &ftypes.OS{Family: "alpine", Name: "3.11.5"}, gotOS)
+
+			require.Len(t, gotResults, len(tt.wantResults))
+			for i, result := range gotResults {
+				assert.Equal(t, tt.wantFallback, result.Fallback)
+				result.Fallback = false
+				assert.Equal(t, tt.wantResults[i], result)
+			}
+			fallback.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// FileDigestProvider is implemented by analyzers that know the sha256 digests of the lock files of the last analysis
+// by path, the same for the lock files with the same libraries.
+type FileDigestProvider interface {
+	FileDigests() map[string]string
+}
+
+// digestCache computes the digests of the lock files from the applications of the layers of the last analysis:
+// those it puts in the cache, and those already in the cache when it returns them, e.g. not in the client mode.
+// The lock files of the layers it can't get have no digest.
+type digestCache struct {
+	cache.ImageCache
+	// local returns the layers already in the cache, nil when it can't
+	local cache.LocalImageCache
+
+	mu       sync.Mutex
+	layerIDs []string
+	layers   map[string]ftypes.LayerInfo
+}
+
+func newDigestCache(c cache.ImageCache) *digestCache {
+	local, _ := c.(cache.LocalImageCache)
+	return &digestCache{ImageCache: c, local: local}
+}
+
+func (c *digestCache) MissingLayers(imageID string, layerIDs []string) (bool, []string, error) {
+	c.mu.Lock()
+	c.layerIDs = append([]string(nil), layerIDs...)
+	c.layers = map[string]ftypes.LayerInfo{}
+	c.mu.Unlock()
+	return c.ImageCache.MissingLayers(imageID, layerIDs)
+}
+
+func (c *digestCache) PutLayer(diffID string, layerInfo ftypes.LayerInfo) error {
+	c.mu.Lock()
+	if c.layers != nil {
+		c.layers[diffID] = ftypes.LayerInfo{Applications: layerInfo.Applications, WhiteoutFiles: layerInfo.WhiteoutFiles}
+	}
+	c.mu.Unlock()
+	return c.ImageCache.PutLayer(diffID, layerInfo)
+}
+
+// takeDigests returns the digests of the lock files of the last analysis, applying its layers from the base one,
+// and forgets it
+func (c *digestCache) takeDigests() map[string]string {
+	c.mu.Lock()
+	layerIDs, layers := c.layerIDs, c.layers
+	c.layerIDs, c.layers = nil, nil
+	c.mu.Unlock()
+
+	apps := map[string]ftypes.Application{}
+	for _, diffID := range layerIDs {
+		layer, ok := layers[diffID]
+		if !ok {
+			if c.local == nil {
+				continue
+			}
+			var err error
+			if layer, err = c.local.GetLayer(diffID); err != nil {
+				continue
+			}
+		}
+		for _, whFile := range layer.WhiteoutFiles {
+			delete(apps, whFile)
+		}
+		for _, app := range layer.Applications {
+			apps[app.FilePath] = app
+		}
+	}
+	if len(apps) == 0 {
+		return nil
+	}
+
+	digests := map[string]string{}
+	for filePath, app := range apps {
+		digests[filePath] = applicationDigest(app)
+	}
+	return digests
+}
+
+// applicationDigest returns the sha256 digest of the type and the libraries of the application, whatever their order
+func applicationDigest(app ftypes.Application) string {
+	libs := make([]string, 0, len(app.Libraries))
+	for _, lib := range app.Libraries {
+		libs = append(libs, fmt.Sprintf("%s@%s", lib.Library.Name, lib.Library.Version))
+	}
+	sort.Strings(libs)
+	sum := sha256.Sum256([]byte(app.Type + "\n" + strings.Join(libs, "\n")))
+	return hex.EncodeToString(sum[:])
+}
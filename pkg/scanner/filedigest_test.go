@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	ptypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+)
+
+// layeredExtractor returns the files of each layer by diff ID
+type layeredExtractor struct {
+	extractor.Extractor
+	layers map[string]extractor.FileMap
+}
+
+func (e layeredExtractor) ExtractLayerFiles(diffID string, _ []string) (string, extractor.FileMap, []string, []string, error) {
+	return diffID, e.layers[diffID], nil, nil, nil
+}
+
+func npmApp(filePath string, libs ...string) ftypes.Application {
+	app := ftypes.Application{Type: "npm", FilePath: filePath}
+	for i := 0; i+1 < len(libs); i += 2 {
+		app.Libraries = append(app.Libraries, ftypes.LibraryInfo{Library: ptypes.Library{Name: libs[i], Version: libs[i+1]}})
+	}
+	return app
+}
+
+func TestImageAnalyzer_FileDigests(t *testing.T) {
+	const (
+		imageID = "sha256:image"
+		base    = "sha256:base"
+		app     = "sha256:app"
+	)
+	c := cache.NewMemoryCache()
+	a := NewImageAnalyzer(analyzer.Config{Cache: c})
+
+	// analyze calls the cache as the analysis does, putting the layers missing from the cache
+	analyze := func(layers map[string]ftypes.LayerInfo) map[string]string {
+		_, missing, err := a.Cache.MissingLayers(imageID, []string{base, app})
+		require.NoError(t, err)
+		for _, diffID := range missing {
+			layer := layers[diffID]
+			layer.SchemaVersion = ftypes.LayerJSONSchemaVersion
+			require.NoError(t, a.Cache.PutLayer(diffID, layer))
+		}
+		return a.FileDigests()
+	}
+
+	layers := map[string]ftypes.LayerInfo{
+		base: {Applications: []ftypes.Application{
+			npmApp("app/package-lock.json", "qs", "6.5.1", "express", "4.16.0"),
+			npmApp("app/node_modules/a/package-lock.json", "express", "4.16.0", "qs", "6.5.1"),
+			npmApp("srv/package-lock.json", "qs", "6.5.1"),
+			npmApp("old/package-lock.json", "qs", "6.5.1"),
+		}},
+		app: {
+			Applications:  []ftypes.Application{npmApp("srv/package-lock.json", "qs", "6.5.2")},
+			WhiteoutFiles: []string{"old/package-lock.json"},
+		},
+	}
+	digests := analyze(layers)
+	require.Len(t, digests, 3)
+	assert.Equal(t, digests["app/package-lock.json"], digests["app/node_modules/a/package-lock.json"],
+		"the same libraries in any order")
+	assert.NotEqual(t, digests["app/package-lock.json"], digests["srv/package-lock.json"])
+	assert.Equal(t, applicationDigest(npmApp("", "qs", "6.5.2")), digests["srv/package-lock.json"],
+		"the lock file of the upper layer")
+	assert.Empty(t, a.FileDigests(), "digests are returned once")
+
+	// the layers found in the cache aren't analyzed again but have the same digests
+	assert.Equal(t, digests, analyze(nil))
+}
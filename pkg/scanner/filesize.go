@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// DefaultMaxFileSize is the size limit of the files analyzed when ScanOptions.MaxFileSize is zero
+const DefaultMaxFileSize = 128 << 20
+
+// FileSizeLimiter is implemented by analyzers that can skip the files larger than a limit in bytes.
+// A negative limit disables it.
+type FileSizeLimiter interface {
+	SetMaxFileSize(size int64)
+}
+
+// SizeLimitedExtractor is implemented by extractors that skip the files larger than the limit before reading them,
+// from their tar headers or their file info, e.g. those of pkg/extractor. The files are otherwise read and then dropped.
+type SizeLimitedExtractor interface {
+	FileSizeLimiter
+	// SkippedFiles returns the sizes of the files of the layer skipped by its last extraction, by path
+	SkippedFiles(diffID string) map[string]int64
+}
+
+// WarningExtractor is implemented by extractors that skip the files they can't read with a warning,
+// e.g. the dangling symlinks of pkg/extractor/sftp
+type WarningExtractor interface {
+	// ExtractionWarnings returns the warnings of the last extraction of the layer
+	ExtractionWarnings(diffID string) []string
+}
+
+// incompleteLayerSchemaVersion is the schema version of the cached layers with skipped files.
+// The applier accepts them, but the caches report them as missing, so that they are analyzed again by the next scans
+// with the warnings of the skipped files, e.g. with a larger limit.
+const incompleteLayerSchemaVersion = -1
+
+// sizeLimitExtractor drops the files larger than maxSize before they are parsed, with a warning for each,
+// and keeps the warnings of the extractor
+type sizeLimitExtractor struct {
+	extractor.Extractor
+	// paths filters the files, those it skips are skipped without a warning
+	paths *pathFilterExtractor
+
+	mu       sync.Mutex
+	maxSize  int64
+	warnings []string
+	// incomplete are the layers with skipped files
+	incomplete map[string]bool
+}
+
+func (e *sizeLimitExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	layerDigest, files, opqDirs, whFiles, err := e.Extractor.ExtractLayerFiles(diffID, filenames)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	var extractionWarnings []string
+	if w, ok := e.Extractor.(WarningExtractor); ok {
+		extractionWarnings = w.ExtractionWarnings(diffID)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.warnings = append(e.warnings, extractionWarnings...)
+	if e.maxSize < 0 {
+		if len(extractionWarnings) > 0 {
+			e.incomplete[diffID] = true
+		} else {
+			delete(e.incomplete, diffID)
+		}
+		return layerDigest, files, opqDirs, whFiles, nil
+	}
+	skipped := map[string]int64{}
+	if limited, ok := e.Extractor.(SizeLimitedExtractor); ok {
+		for filename, size := range limited.SkippedFiles(diffID) {
+			skipped[filename] = size
+		}
+	}
+	for filename, content := range files {
+		if size := int64(len(content)); size > e.maxSize {
+			delete(files, filename)
+			skipped[filename] = size
+		}
+	}
+
+	var filenamesSkipped []string
+	for filename := range skipped {
+		if e.paths == nil || !e.paths.getFilter().skipped(filename) {
+			filenamesSkipped = append(filenamesSkipped, filename)
+		}
+	}
+	sort.Strings(filenamesSkipped)
+	for _, filename := range filenamesSkipped {
+		e.warnings = append(e.warnings, fmt.Sprintf("%s in the layer %s is skipped: %d bytes exceed the maximum file size of %d bytes",
+			filename, diffID, skipped[filename], e.maxSize))
+	}
+	if len(filenamesSkipped) > 0 || len(extractionWarnings) > 0 {
+		e.incomplete[diffID] = true
+	} else {
+		delete(e.incomplete, diffID)
+	}
+	return layerDigest, files, opqDirs, whFiles, nil
+}
+
+// setMaxSize sets the limit, and the one of the extractor if it checks the sizes before reading the files
+func (e *sizeLimitExtractor) setMaxSize(size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if size == 0 {
+		size = DefaultMaxFileSize
+	}
+	e.maxSize = size
+	if limited, ok := e.Extractor.(SizeLimitedExtractor); ok {
+		limited.SetMaxFileSize(size)
+	}
+}
+
+func (e *sizeLimitExtractor) getMaxSize() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.maxSize
+}
+
+func (e *sizeLimitExtractor) isIncomplete(diffID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.incomplete[diffID]
+}
+
+func (e *sizeLimitExtractor) addWarnings(warnings []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.warnings = append(e.warnings, warnings...)
+}
+
+// takeWarnings returns the warnings since the last call
+func (e *sizeLimitExtractor) takeWarnings() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	warnings := e.warnings
+	e.warnings = nil
+	return warnings
+}
+
+// sizeLimitCache stores the layers with files skipped by the limiter as incomplete
+type sizeLimitCache struct {
+	cache.ImageCache
+	limiter *sizeLimitExtractor
+}
+
+func (c sizeLimitCache) PutLayer(diffID string, layerInfo ftypes.LayerInfo) error {
+	if c.limiter.isIncomplete(diffID) {
+		layerInfo.SchemaVersion = incompleteLayerSchemaVersion
+	}
+	return c.ImageCache.PutLayer(diffID, layerInfo)
+}
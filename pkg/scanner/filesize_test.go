@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+)
+
+// fakeExtractor returns a package-lock.json of the given size in its only layer
+type fakeExtractor struct {
+	extractor.Extractor
+	lockfileSize int
+}
+
+func (e fakeExtractor) ExtractLayerFiles(string, []string) (string, extractor.FileMap, []string, []string, error) {
+	return "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10", extractor.FileMap{
+		"etc/alpine-release":    []byte("3.10.2"),
+		"app/package-lock.json": []byte(strings.Repeat("x", e.lockfileSize)),
+	}, nil, nil, nil
+}
+
+func TestImageAnalyzer_MaxFileSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxFileSize  int64
+		lockfileSize int
+		wantFiles    []string
+		wantWarnings []string
+	}{
+		{
+			name:         "oversized lock file",
+			maxFileSize:  1024,
+			lockfileSize: 2048,
+			wantFiles:    []string{"etc/alpine-release"},
+			wantWarnings: []string{
+				"app/package-lock.json in the layer sha256:b2a1 is skipped: 2048 bytes exceed the maximum file size of 1024 bytes",
+			},
+		},
+		{
+			name:         "within the limit",
+			maxFileSize:  1024,
+			lockfileSize: 1024,
+			wantFiles:    []string{"app/package-lock.json", "etc/alpine-release"},
+		},
+		{
+			name:         "default limit",
+			lockfileSize: 2048,
+			wantFiles:    []string{"app/package-lock.json", "etc/alpine-release"},
+		},
+		{
+			name:         "disabled",
+			maxFileSize:  -1,
+			lockfileSize: 2048,
+			wantFiles:    []string{"app/package-lock.json", "etc/alpine-release"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewImageAnalyzer(analyzer.Config{Extractor: fakeExtractor{lockfileSize: tt.lockfileSize}})
+			a.SetMaxFileSize(tt.maxFileSize)
+
+			_, files, _, _, err := a.Extractor.ExtractLayerFiles("sha256:b2a1", nil)
+			require.NoError(t, err)
+
+			var got []string
+			for filename := range files {
+				got = append(got, filename)
+			}
+			assert.ElementsMatch(t, tt.wantFiles, got)
+			assert.Equal(t, tt.wantWarnings, a.Warnings())
+			assert.Empty(t, a.Warnings(), "warnings are returned once")
+		})
+	}
+}
+
+// limitedExtractor skips a package-lock.json larger than the limit without reading it
+type limitedExtractor struct {
+	extractor.Extractor
+	lockfileSize int64
+	maxSize      int64
+}
+
+func (e *limitedExtractor) SetMaxFileSize(size int64) {
+	e.maxSize = size
+}
+
+func (e *limitedExtractor) SkippedFiles(string) map[string]int64 {
+	if e.maxSize > 0 && e.lockfileSize > e.maxSize {
+		return map[string]int64{"app/package-lock.json": e.lockfileSize}
+	}
+	return nil
+}
+
+func (e *limitedExtractor) ExtractLayerFiles(string, []string) (string, extractor.FileMap, []string, []string, error) {
+	files := extractor.FileMap{"etc/alpine-release": []byte("3.10.2")}
+	if e.SkippedFiles("") == nil {
+		files["app/package-lock.json"] = []byte(strings.Repeat("x", int(e.lockfileSize)))
+	}
+	return "", files, nil, nil, nil
+}
+
+func TestImageAnalyzer_MaxFileSize_BeforeRead(t *testing.T) {
+	c := cache.NewMemoryCache()
+	ext := &limitedExtractor{lockfileSize: 2048}
+	a := NewImageAnalyzer(analyzer.Config{Extractor: ext, Cache: c})
+	assert.Equal(t, int64(DefaultMaxFileSize), ext.maxSize)
+
+	a.SetMaxFileSize(1024)
+	assert.Equal(t, int64(1024), ext.maxSize, "the limit is checked by the extractor")
+
+	diffID := "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"
+	_, files, _, _, err := a.Extractor.ExtractLayerFiles(diffID, nil)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, []string{
+		"app/package-lock.json in the layer " + diffID + " is skipped: 2048 bytes exceed the maximum file size of 1024 bytes",
+	}, a.Warnings())
+
+	// the layer with the skipped file is analyzed again by the next scans
+	require.NoError(t, a.Cache.PutLayer(diffID, ftypes.LayerInfo{SchemaVersion: ftypes.LayerJSONSchemaVersion}))
+	_, missing, err := c.MissingLayers("sha256:image", []string{diffID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{diffID}, missing)
+
+	// until no file is skipped
+	a.SetMaxFileSize(-1)
+	_, files, _, _, err = a.Extractor.ExtractLayerFiles(diffID, nil)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	require.NoError(t, a.Cache.PutLayer(diffID, ftypes.LayerInfo{SchemaVersion: ftypes.LayerJSONSchemaVersion}))
+	_, missing, err = c.MissingLayers("sha256:image", []string{diffID})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+// warningExtractor skips a dangling symlink with a warning
+type warningExtractor struct {
+	extractor.Extractor
+}
+
+func (e warningExtractor) ExtractLayerFiles(diffID string, _ []string) (string, extractor.FileMap, []string, []string, error) {
+	return diffID, extractor.FileMap{"etc/alpine-release": []byte("3.11.5")}, nil, nil, nil
+}
+
+func (e warningExtractor) ExtractionWarnings(string) []string {
+	return []string{"the symlink /srv/app/yarn.lock is skipped: its target /opt/yarn.lock doesn't exist"}
+}
+
+func TestImageAnalyzer_ExtractionWarnings(t *testing.T) {
+	c := cache.NewMemoryCache()
+	a := NewImageAnalyzer(analyzer.Config{Extractor: warningExtractor{}, Cache: c})
+
+	diffID := "sha256:b2a1"
+	_, _, _, _, err := a.Extractor.ExtractLayerFiles(diffID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"the symlink /srv/app/yarn.lock is skipped: its target /opt/yarn.lock doesn't exist",
+	}, a.Warnings())
+
+	// the layer is analyzed again by the next scans, with the warning
+	require.NoError(t, a.Cache.PutLayer(diffID, ftypes.LayerInfo{SchemaVersion: ftypes.LayerJSONSchemaVersion}))
+	_, missing, err := c.MissingLayers("sha256:image", []string{diffID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{diffID}, missing)
+}
@@ -0,0 +1,746 @@
+package scanner
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	fos "github.com/aquasecurity/fanal/analyzer/os"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/detector/library/python"
+	"github.com/aquasecurity/trivy/pkg/expr"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+	"github.com/aquasecurity/trivy/pkg/vex"
+	"github.com/aquasecurity/trivy/pkg/vulnerability"
+)
+
+// osType is the key of ScanOptions.SeverityThresholds matching results of any OS family
+const osType = "os"
+
+var osFamilies = []string{
+	fos.RedHat, fos.Debian, fos.Ubuntu, fos.CentOS, fos.Fedora, fos.Amazon, fos.Oracle,
+	fos.OpenSUSE, fos.OpenSUSELeap, fos.OpenSUSETumbleweed, fos.SLES, fos.Photon, fos.Alpine,
+}
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// pipType is the ecosystem of ScanOptions.IgnoredEcosystems matching the results of any Python lock file
+const pipType = "pip"
+
+// genericArchiveType is the result type of packages found in an archive without identifying their ecosystem
+const genericArchiveType = "archive"
+
+// findingFields are the fields available in ScanOptions.FilterExpr
+var findingFields = map[string]expr.Kind{
+	"id":       expr.String,
+	"pkg":      expr.String,
+	"severity": expr.String,
+	"type":     expr.String,
+	"fixed":    expr.Bool,
+	"status":   expr.String,
+}
+
+// resultFilter applies the post-scan filtering configured in ScanOptions.
+// Options are validated when it is created so that invalid options fail before the scan.
+type resultFilter struct {
+	options     types.ScanOptions
+	scale       severityScale
+	thresholds  severityThresholds
+	expr        *expr.Expr
+	ignoreRules []vulnerability.IgnoreRule
+	vex         *vex.Document
+}
+
+func newResultFilter(options types.ScanOptions) (resultFilter, error) {
+	scale, err := newSeverityScale(options)
+	if err != nil {
+		return resultFilter{}, xerrors.Errorf("invalid severity levels: %w", err)
+	}
+
+	if options.DefaultSeverity != "" {
+		if _, err = scale.threshold(options.DefaultSeverity); err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid default severity: %w", err)
+		}
+	}
+
+	if err = scale.validateOverrides(options); err != nil {
+		return resultFilter{}, xerrors.Errorf("invalid severity override: %w", err)
+	}
+
+	for _, severity := range options.Severities {
+		if _, err = scale.threshold(severity); err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid severity: %w", err)
+		}
+	}
+
+	for _, pattern := range options.IgnorePkgs {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid package pattern %q: %w", pattern, err)
+		}
+	}
+
+	ignoreRules, err := readIgnoreRules(options.IgnoreFile)
+	if err != nil {
+		return resultFilter{}, xerrors.Errorf("invalid ignore file: %w", err)
+	}
+
+	var vexDoc *vex.Document
+	if options.VEXFile != "" {
+		doc, err := vex.Load(options.VEXFile)
+		if err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid VEX file: %w", err)
+		}
+		vexDoc = &doc
+	}
+
+	thresholds, err := newSeverityThresholds(options, scale)
+	if err != nil {
+		return resultFilter{}, xerrors.Errorf("invalid severity threshold: %w", err)
+	}
+
+	for severity, limit := range options.SeverityLimits {
+		if _, err = scale.threshold(severity); err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid severity limit: %w", err)
+		}
+		if limit < 0 {
+			return resultFilter{}, xerrors.Errorf("invalid severity limit: %s: negative limit %d", severity, limit)
+		}
+	}
+
+	if options.OnlyEPSSAbove < 0 || options.OnlyEPSSAbove >= 1 {
+		return resultFilter{}, xerrors.Errorf("invalid EPSS threshold: %g is not in [0, 1)", options.OnlyEPSSAbove)
+	}
+
+	if options.NearDuplicateSimilarity < 0 || options.NearDuplicateSimilarity > 1 {
+		return resultFilter{}, xerrors.Errorf("invalid near-duplicate similarity: %g is not in (0, 1]", options.NearDuplicateSimilarity)
+	}
+
+	if options.MinAffectedCount < 0 {
+		return resultFilter{}, xerrors.Errorf("invalid affected count: negative count %d", options.MinAffectedCount)
+	}
+
+	var filterExpr *expr.Expr
+	if options.FilterExpr != "" {
+		filterExpr, err = expr.Parse(options.FilterExpr, findingFields)
+		if err != nil {
+			return resultFilter{}, xerrors.Errorf("invalid filter expression: %w", err)
+		}
+	}
+
+	return resultFilter{
+		options:     options,
+		scale:       scale,
+		thresholds:  thresholds,
+		expr:        filterExpr,
+		ignoreRules: ignoreRules,
+		vex:         vexDoc,
+	}, nil
+}
+
+// readIgnoreRules reads the ignore file, or the default one if it exists.
+// The expired rules are skipped with a warning so that the vulnerabilities are reported again.
+func readIgnoreRules(ignoreFile string) ([]vulnerability.IgnoreRule, error) {
+	if ignoreFile == "" {
+		ignoreFile = vulnerability.DefaultIgnoreFile
+	}
+	rules, err := vulnerability.ReadIgnoreRules(ignoreFile)
+	if err != nil {
+		if ignoreFile == vulnerability.DefaultIgnoreFile && xerrors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var active []vulnerability.IgnoreRule
+	now := timeNow()
+	for _, rule := range rules {
+		if !rule.Active(now) {
+			log.Logger.Warnf("The ignore rule of %s expired on %s", rule.ID, rule.ExpiredAt.Format("2006-01-02"))
+			continue
+		}
+		active = append(active, rule)
+	}
+	return active, nil
+}
+
+// dropIgnored removes the vulnerabilities matching a rule, keeping them in Suppressed with show
+func dropIgnored(results report.Results, rules []vulnerability.IgnoreRule, show bool) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			rule, ok := matchIgnoreRule(rules, vuln.VulnerabilityID, result.Target)
+			if !ok {
+				vulns = append(vulns, vuln)
+				continue
+			}
+			if show {
+				suppressed := types.SuppressedVulnerability{DetectedVulnerability: vuln, Statement: rule.Statement}
+				if !rule.ExpiredAt.IsZero() {
+					expiredAt := rule.ExpiredAt
+					suppressed.ExpiredAt = &expiredAt
+				}
+				results[i].Suppressed = append(results[i].Suppressed, suppressed)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+func matchIgnoreRule(rules []vulnerability.IgnoreRule, vulnID, target string) (vulnerability.IgnoreRule, bool) {
+	for _, rule := range rules {
+		if rule.Matches(vulnID, target) {
+			return rule, true
+		}
+	}
+	return vulnerability.IgnoreRule{}, false
+}
+
+// dropNotAffected removes the vulnerabilities of the packages the VEX document states not affected or fixed,
+// keeping them in Suppressed with the statement
+func dropNotAffected(results report.Results, doc vex.Document) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			statement, ok := doc.Match(vuln.VulnerabilityID, vuln.PkgName, vuln.InstalledVersion)
+			if !ok || !statement.Suppresses() {
+				vulns = append(vulns, vuln)
+				continue
+			}
+			results[i].Suppressed = append(results[i].Suppressed, types.SuppressedVulnerability{
+				DetectedVulnerability: vuln,
+				Statement:             statement.ImpactStatement,
+				VEXStatus:             statement.Status,
+				VEXJustification:      statement.Justification,
+			})
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// dropIgnoredPkgs removes the findings of the packages matching the patterns, validated in newResultFilter
+func dropIgnoredPkgs(results report.Results, patterns []string) report.Results {
+	ignored := func(pkgName string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, pkgName); matched {
+				return true
+			}
+		}
+		return false
+	}
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if !ignored(vuln.PkgName) {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// dropUnfixed removes the findings without a fixed version
+func dropUnfixed(results report.Results) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if strings.TrimSpace(vuln.FixedVersion) != "" {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// separateDeferred moves the vulnerabilities the vendor won't fix, deferred or no longer supports to Deferred,
+// apart from the actionable ones
+func separateDeferred(results report.Results) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			switch vuln.Status {
+			case types.StatusWillNotFix, types.StatusFixDeferred, types.StatusEndOfLife:
+				results[i].Deferred = append(results[i].Deferred, vuln)
+			default:
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// dropVersionRangeMatches removes the findings detected with the lower bound of a version range
+func dropVersionRangeMatches(results report.Results) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.MatchConfidence != types.MatchConfidenceVersionRange {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// apply applies the filters to the results, on a copy of the slice so that the results of the driver aren't modified
+func (f resultFilter) apply(results report.Results) (report.Results, error) {
+	return f.filter(append(report.Results(nil), results...), true)
+}
+
+// applyTarget applies the filters to the result of a single target, e.g. passed to the handler of
+// WithResultHandler, on a copy of its vulnerabilities. The filters across the targets, i.e. of the generic
+// duplicates and of ScanOptions.MinAffectedCount, only apply to the results returned together.
+// It returns false when the result is dropped.
+func (f resultFilter) applyTarget(result report.Result) (report.Result, bool) {
+	result.Vulnerabilities = append([]types.DetectedVulnerability(nil), result.Vulnerabilities...)
+	results, err := f.filter(report.Results{result}, false)
+	if err != nil || len(results) == 0 {
+		return report.Result{}, false
+	}
+	return results[0], true
+}
+
+// filter applies the filters to the results, and those across the targets with acrossTargets
+func (f resultFilter) filter(results report.Results, acrossTargets bool) (report.Results, error) {
+	if !f.options.ScanYanked {
+		for i := range results {
+			results[i].YankedPackages = nil
+		}
+	}
+	if !f.options.ScanMaintenance {
+		for i := range results {
+			results[i].UnmaintainedPackages = nil
+		}
+	}
+
+	if len(f.ignoreRules) > 0 {
+		results = dropIgnored(results, f.ignoreRules, f.options.ShowSuppressed)
+	}
+
+	if f.vex != nil {
+		results = dropNotAffected(results, *f.vex)
+	}
+
+	if len(f.options.IgnorePkgs) > 0 {
+		results = dropIgnoredPkgs(results, f.options.IgnorePkgs)
+	}
+
+	if f.options.SeparateDeferred {
+		results = separateDeferred(results)
+	}
+
+	if f.options.IgnoreUnfixed {
+		results = dropUnfixed(results)
+	}
+
+	if f.options.SkipVersionRangeMatches {
+		results = dropVersionRangeMatches(results)
+	}
+
+	if len(f.options.IgnoredEcosystems) > 0 {
+		results = dropEcosystems(results, f.options.IgnoredEcosystems)
+	}
+
+	if !f.options.KeepGenericDuplicates && acrossTargets {
+		results = dropGenericDuplicates(results)
+	}
+
+	if f.options.AggregateNearDuplicates {
+		results = aggregateNearDuplicates(results, f.options.NearDuplicateSimilarity)
+	}
+
+	if len(f.options.EPSSScores) > 0 {
+		attachEPSS(results, f.options.EPSSScores)
+	}
+	if f.options.OnlyEPSSAbove > 0 {
+		results = filterByEPSS(results, f.options.OnlyEPSSAbove)
+	}
+	if len(f.options.KnownExploited) > 0 {
+		markKnownExploited(results, f.options.KnownExploited)
+	}
+	if f.options.OnlyKnownExploited {
+		results = filterKnownExploited(results)
+	}
+
+	if f.options.MinFixAge > 0 {
+		results = checkFixAge(results, timeNow().Add(-f.options.MinFixAge), f.options.SkipTooNewFixes)
+	}
+
+	if f.options.SeveritySource != "" {
+		selectSeveritySource(results, f.options.SeveritySource)
+	}
+	if f.options.DefaultSeverity != "" {
+		setDefaultSeverity(results, f.options.DefaultSeverity)
+	}
+	overrideSeverities(results, f.options)
+
+	if err := f.scale.mapSeverities(results); err != nil {
+		return nil, err
+	}
+
+	results = f.thresholds.filter(results, f.scale)
+
+	if len(f.options.Severities) > 0 {
+		results = f.scale.keepSeverities(results, f.options.Severities)
+	}
+
+	if f.expr != nil {
+		results = filterByExpr(results, f.expr)
+	}
+
+	if f.options.MinAffectedCount > 1 && acrossTargets {
+		results = filterByAffectedCount(results, f.options.MinAffectedCount, f.options.KeepUncommon)
+	}
+
+	if len(f.options.SeverityLimits) > 0 {
+		results = limitSeverities(results, f.options.SeverityLimits)
+	}
+
+	if f.options.SortByEPSS {
+		sortByEPSS(results)
+	}
+	return results, nil
+}
+
+// limitSeverities keeps the first findings of each severity up to its limit after sorting them
+func limitSeverities(results report.Results, limits map[string]int) report.Results {
+	for i, result := range results {
+		vulns := result.Vulnerabilities
+		sort.SliceStable(vulns, func(i, j int) bool {
+			if vulns[i].PkgName != vulns[j].PkgName {
+				return vulns[i].PkgName < vulns[j].PkgName
+			}
+			return vulns[i].VulnerabilityID < vulns[j].VulnerabilityID
+		})
+
+		var kept []types.DetectedVulnerability
+		counts := map[string]int{}
+		for _, vuln := range vulns {
+			counts[vuln.Severity]++
+			if limit := limits[vuln.Severity]; limit > 0 && counts[vuln.Severity] > limit {
+				if results[i].Truncated == nil {
+					results[i].Truncated = map[string]int{}
+				}
+				results[i].Truncated[vuln.Severity]++
+				continue
+			}
+			kept = append(kept, vuln)
+		}
+		results[i].Vulnerabilities = kept
+	}
+	return results
+}
+
+func dropEcosystems(results report.Results, ecosystems []string) report.Results {
+	var filtered report.Results
+	for _, result := range results {
+		if inEcosystems(result.Type, ecosystems) {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+func inEcosystems(resultType string, ecosystems []string) bool {
+	switch {
+	case utils.StringInSlice(resultType, ecosystems):
+		return true
+	case utils.StringInSlice(resultType, osFamilies):
+		return utils.StringInSlice(osType, ecosystems)
+	case resultType == python.ScannerTypePipenv || resultType == python.ScannerTypePoetry:
+		return utils.StringInSlice(pipType, ecosystems)
+	}
+	return false
+}
+
+// dropGenericDuplicates removes the findings in generic archive results of the packages
+// identified in another result of the same target
+func dropGenericDuplicates(results report.Results) report.Results {
+	identified := map[string]map[string]struct{}{}
+	for _, result := range results {
+		if result.Type == genericArchiveType {
+			continue
+		}
+		if identified[result.Target] == nil {
+			identified[result.Target] = map[string]struct{}{}
+		}
+		for _, vuln := range result.Vulnerabilities {
+			identified[result.Target][vuln.PkgName+"@"+vuln.InstalledVersion] = struct{}{}
+		}
+	}
+
+	var filtered report.Results
+	for _, result := range results {
+		if result.Type == genericArchiveType && identified[result.Target] != nil {
+			var vulns []types.DetectedVulnerability
+			for _, vuln := range result.Vulnerabilities {
+				if _, ok := identified[result.Target][vuln.PkgName+"@"+vuln.InstalledVersion]; ok {
+					continue
+				}
+				vulns = append(vulns, vuln)
+			}
+			if len(vulns) == 0 {
+				continue
+			}
+			result.Vulnerabilities = vulns
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// checkFixAge flags the findings whose fixed version was released after the cutoff and drops them with skip
+func checkFixAge(results report.Results, cutoff time.Time, skip bool) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.FixedVersionReleased != nil && vuln.FixedVersionReleased.After(cutoff) {
+				if skip {
+					continue
+				}
+				vuln.FixTooNew = true
+			}
+			vulns = append(vulns, vuln)
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+func filterByExpr(results report.Results, e *expr.Expr) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if !e.Eval(map[string]interface{}{
+				"id":       vuln.VulnerabilityID,
+				"pkg":      vuln.PkgName,
+				"severity": vuln.Severity,
+				"type":     result.Type,
+				"fixed":    vuln.FixedVersion != "",
+				"status":   vuln.Status,
+			}) {
+				continue
+			}
+			vulns = append(vulns, vuln)
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+// severityScale ranks severities from the lowest level.
+// The default scale is UNKNOWN, LOW, MEDIUM, HIGH and CRITICAL.
+type severityScale struct {
+	levels  []string
+	ranks   map[string]int
+	mapping map[string]string
+	// custom is true when ScanOptions.SeverityLevels is given; every severity must then be in the scale or mapped
+	custom bool
+}
+
+func newSeverityScale(options types.ScanOptions) (severityScale, error) {
+	scale := severityScale{
+		levels:  dbTypes.SeverityNames,
+		ranks:   map[string]int{},
+		mapping: options.SeverityMapping,
+		custom:  len(options.SeverityLevels) > 0,
+	}
+	if scale.custom {
+		scale.levels = options.SeverityLevels
+	}
+
+	for i, level := range scale.levels {
+		if _, ok := scale.ranks[level]; ok {
+			return severityScale{}, xerrors.Errorf("duplicate level: %s", level)
+		}
+		scale.ranks[level] = i
+	}
+	for from, to := range scale.mapping {
+		if _, ok := scale.ranks[to]; !ok {
+			return severityScale{}, xerrors.Errorf("%s is mapped to an unknown level: %s", from, to)
+		}
+	}
+	return scale, nil
+}
+
+// rank returns the position of the severity in the scale.
+// An empty severity, or with the default scale an unknown one, is regarded as the lowest level.
+func (s severityScale) rank(severity string) int {
+	if r, ok := s.ranks[severity]; ok {
+		return r
+	}
+	return 0
+}
+
+// mapSeverities rewrites the severities in ScanOptions.SeverityMapping
+// and validates that the others are in the custom scale
+func (s severityScale) mapSeverities(results report.Results) error {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if mapped, ok := s.mapping[vuln.Severity]; ok {
+				result.Vulnerabilities[i].Severity = mapped
+				continue
+			}
+			if _, ok := s.ranks[vuln.Severity]; !ok && s.custom && vuln.Severity != "" {
+				return xerrors.Errorf("severity %s of %s is not in the severity levels", vuln.Severity, vuln.VulnerabilityID)
+			}
+		}
+	}
+	return nil
+}
+
+// keepSeverities keeps the findings of the severities, regarding an empty severity as the lowest level
+func (s severityScale) keepSeverities(results report.Results, severities []string) report.Results {
+	kept := map[string]bool{}
+	for _, severity := range severities {
+		kept[severity] = true
+	}
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			severity := vuln.Severity
+			if severity == "" {
+				severity = s.levels[0]
+			}
+			if kept[severity] {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+func (s severityScale) validateOverrides(options types.ScanOptions) error {
+	for _, overrides := range []map[string]string{options.VulnSeverityOverrides, options.PkgSeverityOverrides} {
+		for key, severity := range overrides {
+			if _, err := s.threshold(severity); err != nil {
+				return xerrors.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// selectSeveritySource reports the severity of the source for the findings it rates
+func selectSeveritySource(results report.Results, source string) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if cvss, ok := vuln.CVSS[source]; ok && cvss.Severity != "" {
+				result.Vulnerabilities[i].Severity = cvss.Severity
+				result.Vulnerabilities[i].SeveritySource = source
+			}
+		}
+	}
+}
+
+func setDefaultSeverity(results report.Results, severity string) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if vuln.Severity == "" {
+				result.Vulnerabilities[i].Severity = severity
+			}
+		}
+	}
+}
+
+// overrideSeverities applies the overrides by vulnerability ID, falling back to the ones by package name
+func overrideSeverities(results report.Results, options types.ScanOptions) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if severity, ok := options.VulnSeverityOverrides[vuln.VulnerabilityID]; ok {
+				result.Vulnerabilities[i].Severity = severity
+			} else if severity, ok := options.PkgSeverityOverrides[vuln.PkgName]; ok {
+				result.Vulnerabilities[i].Severity = severity
+			}
+		}
+	}
+}
+
+type severityThresholds struct {
+	byType           map[string]int
+	defaultThreshold *int
+}
+
+func newSeverityThresholds(options types.ScanOptions, scale severityScale) (severityThresholds, error) {
+	thresholds := severityThresholds{byType: map[string]int{}}
+	for resultType, s := range options.SeverityThresholds {
+		r, err := scale.threshold(s)
+		if err != nil {
+			return severityThresholds{}, xerrors.Errorf("%s: %w", resultType, err)
+		}
+		thresholds.byType[resultType] = r
+	}
+
+	if options.DefaultSeverityThreshold != "" {
+		r, err := scale.threshold(options.DefaultSeverityThreshold)
+		if err != nil {
+			return severityThresholds{}, xerrors.Errorf("default: %w", err)
+		}
+		thresholds.defaultThreshold = &r
+	}
+	return thresholds, nil
+}
+
+func (s severityScale) threshold(severity string) (int, error) {
+	if !s.custom {
+		severity, err := dbTypes.NewSeverity(severity)
+		if err != nil {
+			return 0, err
+		}
+		return int(severity), nil
+	}
+	r, ok := s.ranks[severity]
+	if !ok {
+		return 0, xerrors.Errorf("unknown severity level: %s", severity)
+	}
+	return r, nil
+}
+
+func (t severityThresholds) lookup(resultType string) (int, bool) {
+	if s, ok := t.byType[resultType]; ok {
+		return s, true
+	}
+	if utils.StringInSlice(resultType, osFamilies) {
+		if s, ok := t.byType[osType]; ok {
+			return s, true
+		}
+	}
+	if t.defaultThreshold != nil {
+		return *t.defaultThreshold, true
+	}
+	return 0, false
+}
+
+func (t severityThresholds) filter(results report.Results, scale severityScale) report.Results {
+	for i, result := range results {
+		threshold, ok := t.lookup(result.Type)
+		if !ok {
+			continue
+		}
+
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if scale.rank(vuln.Severity) < threshold {
+				continue
+			}
+			vulns = append(vulns, vuln)
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
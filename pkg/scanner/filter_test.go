@@ -0,0 +1,1276 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestResultFilter_SeverityLevels(t *testing.T) {
+	fiveLevels := []string{"INFO", "LOW", "MODERATE", "HIGH", "CRITICAL"}
+	vulns := func(severities ...string) []types.DetectedVulnerability {
+		var vulns []types.DetectedVulnerability
+		for _, s := range severities {
+			vulns = append(vulns, types.DetectedVulnerability{
+				VulnerabilityID: "CVE-" + s,
+				Vulnerability:   dbTypes.Vulnerability{Severity: s},
+			})
+		}
+		return vulns
+	}
+
+	tests := []struct {
+		name       string
+		options    types.ScanOptions
+		vulns      []types.DetectedVulnerability
+		want       []types.DetectedVulnerability
+		wantNewErr string
+		wantErr    string
+	}{
+		{
+			name: "custom 5-level scale",
+			options: types.ScanOptions{
+				SeverityLevels:           fiveLevels,
+				DefaultSeverityThreshold: "MODERATE",
+			},
+			vulns: vulns("INFO", "LOW", "MODERATE", "HIGH", "CRITICAL"),
+			want:  vulns("MODERATE", "HIGH", "CRITICAL"),
+		},
+		{
+			name: "custom scale with mapped severities",
+			options: types.ScanOptions{
+				SeverityLevels:           fiveLevels,
+				SeverityMapping:          map[string]string{"MEDIUM": "MODERATE", "UNKNOWN": "INFO"},
+				SeverityThresholds:       map[string]string{"npm": "MODERATE"},
+				DefaultSeverityThreshold: "CRITICAL",
+			},
+			vulns: vulns("UNKNOWN", "MEDIUM", "HIGH"),
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-MEDIUM", Vulnerability: dbTypes.Vulnerability{Severity: "MODERATE"}},
+				{VulnerabilityID: "CVE-HIGH", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+			},
+		},
+		{
+			name:    "default scale",
+			options: types.ScanOptions{DefaultSeverityThreshold: "HIGH"},
+			vulns:   vulns("UNKNOWN", "MEDIUM", "HIGH", "SEVERE"),
+			want:    vulns("HIGH"),
+		},
+		{
+			name: "sad path: severity out of the scale",
+			options: types.ScanOptions{
+				SeverityLevels: fiveLevels,
+			},
+			vulns:   vulns("MEDIUM"),
+			wantErr: "severity MEDIUM of CVE-MEDIUM is not in the severity levels",
+		},
+		{
+			name: "sad path: threshold out of the scale",
+			options: types.ScanOptions{
+				SeverityLevels:           fiveLevels,
+				DefaultSeverityThreshold: "MEDIUM",
+			},
+			wantNewErr: "unknown severity level: MEDIUM",
+		},
+		{
+			name: "sad path: mapped to an unknown level",
+			options: types.ScanOptions{
+				SeverityLevels:  fiveLevels,
+				SeverityMapping: map[string]string{"MEDIUM": "MIDDLE"},
+			},
+			wantNewErr: "MEDIUM is mapped to an unknown level: MIDDLE",
+		},
+		{
+			name: "sad path: duplicate level",
+			options: types.ScanOptions{
+				SeverityLevels: []string{"LOW", "HIGH", "LOW"},
+			},
+			wantNewErr: "duplicate level: LOW",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantNewErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantNewErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := f.apply(report.Results{{Target: "package-lock.json", Type: "npm", Vulnerabilities: tt.vulns}})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_SeverityOverrides(t *testing.T) {
+	vulns := []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+		{VulnerabilityID: "CVE-2019-1551", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+		{VulnerabilityID: "CVE-2019-1547", PkgName: "openssl"},
+		{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+	}
+
+	tests := []struct {
+		name       string
+		options    types.ScanOptions
+		want       []string
+		wantNewErr string
+	}{
+		{
+			name: "all the vulnerabilities in a package are escalated",
+			options: types.ScanOptions{
+				PkgSeverityOverrides: map[string]string{"openssl": "CRITICAL"},
+			},
+			want: []string{"CRITICAL", "CRITICAL", "CRITICAL", "HIGH"},
+		},
+		{
+			name: "a vulnerability ID override takes precedence",
+			options: types.ScanOptions{
+				PkgSeverityOverrides:  map[string]string{"openssl": "CRITICAL", "musl": "LOW"},
+				VulnSeverityOverrides: map[string]string{"CVE-2019-1551": "MEDIUM"},
+			},
+			want: []string{"CRITICAL", "MEDIUM", "CRITICAL", "LOW"},
+		},
+		{
+			name: "overrides are applied before thresholds",
+			options: types.ScanOptions{
+				PkgSeverityOverrides:     map[string]string{"musl": "LOW"},
+				DefaultSeverityThreshold: "HIGH",
+			},
+			want: []string{"HIGH"},
+		},
+		{
+			name:    "unrated findings are kept unrated by default",
+			options: types.ScanOptions{},
+			want:    []string{"HIGH", "LOW", "", "HIGH"},
+		},
+		{
+			name: "unrated findings adopt the default severity",
+			options: types.ScanOptions{
+				DefaultSeverity:          "HIGH",
+				DefaultSeverityThreshold: "HIGH",
+			},
+			want: []string{"HIGH", "HIGH", "HIGH"},
+		},
+		{
+			name: "an override takes precedence over the default severity",
+			options: types.ScanOptions{
+				DefaultSeverity:       "HIGH",
+				VulnSeverityOverrides: map[string]string{"CVE-2019-1547": "MEDIUM"},
+			},
+			want: []string{"HIGH", "LOW", "MEDIUM", "HIGH"},
+		},
+		{
+			name: "sad path: unknown severity",
+			options: types.ScanOptions{
+				PkgSeverityOverrides: map[string]string{"openssl": "SEVERE"},
+			},
+			wantNewErr: "invalid severity override: openssl",
+		},
+		{
+			name:       "sad path: unknown default severity",
+			options:    types.ScanOptions{DefaultSeverity: "SEVERE"},
+			wantNewErr: "invalid default severity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantNewErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantNewErr)
+				return
+			}
+			require.NoError(t, err)
+
+			input := append([]types.DetectedVulnerability{}, vulns...)
+			got, err := f.apply(report.Results{{Target: "alpine:3.10", Type: "alpine", Vulnerabilities: input}})
+			require.NoError(t, err)
+
+			var severities []string
+			for _, v := range got[0].Vulnerabilities {
+				severities = append(severities, v.Severity)
+			}
+			assert.Equal(t, tt.want, severities)
+		})
+	}
+}
+
+func TestResultFilter_SeveritySource(t *testing.T) {
+	vulns := []types.DetectedVulnerability{
+		{
+			VulnerabilityID: "CVE-2020-1967",
+			Vulnerability:   dbTypes.Vulnerability{Severity: "HIGH"},
+			SeveritySource:  "redhat",
+			CVSS: types.VendorCVSS{
+				"nvd":    {V3Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", V3Score: 7.5, Severity: "HIGH"},
+				"redhat": {Severity: "MEDIUM"},
+			},
+		},
+		{
+			VulnerabilityID: "CVE-2019-1551",
+			Vulnerability:   dbTypes.Vulnerability{Severity: "LOW"},
+			SeveritySource:  "redhat",
+			CVSS:            types.VendorCVSS{"redhat": {Severity: "LOW"}},
+		},
+		{VulnerabilityID: "CVE-2019-1547"},
+	}
+
+	tests := []struct {
+		name        string
+		options     types.ScanOptions
+		want        []string
+		wantSources []string
+	}{
+		{
+			name:        "the severity of the data source by default",
+			options:     types.ScanOptions{},
+			want:        []string{"HIGH", "LOW", ""},
+			wantSources: []string{"redhat", "redhat", ""},
+		},
+		{
+			name:        "nvd",
+			options:     types.ScanOptions{SeveritySource: "nvd"},
+			want:        []string{"HIGH", "LOW", ""},
+			wantSources: []string{"nvd", "redhat", ""},
+		},
+		{
+			name:        "redhat",
+			options:     types.ScanOptions{SeveritySource: "redhat"},
+			want:        []string{"MEDIUM", "LOW", ""},
+			wantSources: []string{"redhat", "redhat", ""},
+		},
+		{
+			name:        "before the default severity and the overrides",
+			options:     types.ScanOptions{SeveritySource: "redhat", DefaultSeverity: "HIGH", VulnSeverityOverrides: map[string]string{"CVE-2019-1551": "CRITICAL"}},
+			want:        []string{"MEDIUM", "CRITICAL", "HIGH"},
+			wantSources: []string{"redhat", "redhat", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+
+			input := append([]types.DetectedVulnerability{}, vulns...)
+			got, err := f.apply(report.Results{{Target: "centos:7", Type: "centos", Vulnerabilities: input}})
+			require.NoError(t, err)
+
+			var severities, sources []string
+			for _, v := range got[0].Vulnerabilities {
+				severities = append(severities, v.Severity)
+				sources = append(sources, v.SeveritySource)
+			}
+			assert.Equal(t, tt.want, severities)
+			assert.Equal(t, tt.wantSources, sources)
+		})
+	}
+}
+
+func TestResultFilter_SeverityLimits(t *testing.T) {
+	vuln := func(id, severity string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{
+			VulnerabilityID: id,
+			PkgName:         "openssl",
+			Vulnerability:   dbTypes.Vulnerability{Severity: severity},
+		}
+	}
+	var vulns []types.DetectedVulnerability
+	for _, id := range []string{"CVE-2020-0008", "CVE-2020-0002", "CVE-2020-0006", "CVE-2020-0004", "CVE-2020-0001", "CVE-2020-0007", "CVE-2020-0003"} {
+		vulns = append(vulns, vuln(id, "LOW"))
+	}
+	vulns = append(vulns, vuln("CVE-2020-1002", "CRITICAL"), vuln("CVE-2020-1001", "CRITICAL"), vuln("CVE-2020-2001", "HIGH"))
+
+	tests := []struct {
+		name          string
+		limits        map[string]int
+		want          []types.DetectedVulnerability
+		wantTruncated map[string]int
+		wantNewErr    string
+	}{
+		{
+			name:   "LOW capped and CRITICAL retained",
+			limits: map[string]int{"LOW": 5, "CRITICAL": 0},
+			want: []types.DetectedVulnerability{
+				vuln("CVE-2020-0001", "LOW"),
+				vuln("CVE-2020-0002", "LOW"),
+				vuln("CVE-2020-0003", "LOW"),
+				vuln("CVE-2020-0004", "LOW"),
+				vuln("CVE-2020-0006", "LOW"),
+				vuln("CVE-2020-1001", "CRITICAL"),
+				vuln("CVE-2020-1002", "CRITICAL"),
+				vuln("CVE-2020-2001", "HIGH"),
+			},
+			wantTruncated: map[string]int{"LOW": 2},
+		},
+		{
+			name:   "limits not reached",
+			limits: map[string]int{"HIGH": 1},
+			want: []types.DetectedVulnerability{
+				vuln("CVE-2020-0001", "LOW"),
+				vuln("CVE-2020-0002", "LOW"),
+				vuln("CVE-2020-0003", "LOW"),
+				vuln("CVE-2020-0004", "LOW"),
+				vuln("CVE-2020-0006", "LOW"),
+				vuln("CVE-2020-0007", "LOW"),
+				vuln("CVE-2020-0008", "LOW"),
+				vuln("CVE-2020-1001", "CRITICAL"),
+				vuln("CVE-2020-1002", "CRITICAL"),
+				vuln("CVE-2020-2001", "HIGH"),
+			},
+		},
+		{
+			name:       "sad path: unknown severity",
+			limits:     map[string]int{"SEVERE": 1},
+			wantNewErr: "invalid severity limit",
+		},
+		{
+			name:       "sad path: negative limit",
+			limits:     map[string]int{"LOW": -1},
+			wantNewErr: "negative limit -1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(types.ScanOptions{ScanYanked: true, SeverityLimits: tt.limits})
+			if tt.wantNewErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantNewErr)
+				return
+			}
+			require.NoError(t, err)
+
+			input := make([]types.DetectedVulnerability, len(vulns))
+			copy(input, vulns)
+			got, err := f.apply(report.Results{{Target: "alpine:3.10", Vulnerabilities: input}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+			assert.Equal(t, tt.wantTruncated, got[0].Truncated)
+		})
+	}
+}
+
+func TestResultFilter_GenericDuplicates(t *testing.T) {
+	vuln := func(id, pkgName, version string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{VulnerabilityID: id, PkgName: pkgName, InstalledVersion: version}
+	}
+	newResults := func() report.Results {
+		return report.Results{
+			{
+				Target: "app/lib/app.jar",
+				Type:   "archive",
+				Vulnerabilities: []types.DetectedVulnerability{
+					vuln("CVE-2022-42889", "commons-text", "1.9"),
+					vuln("CVE-2020-9488", "log4j", "1.2.17"),
+				},
+			},
+			{
+				Target: "app/lib/app.jar",
+				Type:   "maven",
+				Vulnerabilities: []types.DetectedVulnerability{
+					vuln("CVE-2022-42889", "commons-text", "1.9"),
+				},
+			},
+			{
+				Target: "app/lib/other.jar",
+				Type:   "archive",
+				Vulnerabilities: []types.DetectedVulnerability{
+					vuln("CVE-2022-42889", "commons-text", "1.9"),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    report.Results
+	}{
+		{
+			name:    "generic duplicates are dropped",
+			options: types.ScanOptions{ScanYanked: true},
+			want: report.Results{
+				{
+					Target:          "app/lib/app.jar",
+					Type:            "archive",
+					Vulnerabilities: []types.DetectedVulnerability{vuln("CVE-2020-9488", "log4j", "1.2.17")},
+				},
+				{
+					Target:          "app/lib/app.jar",
+					Type:            "maven",
+					Vulnerabilities: []types.DetectedVulnerability{vuln("CVE-2022-42889", "commons-text", "1.9")},
+				},
+				{
+					Target:          "app/lib/other.jar",
+					Type:            "archive",
+					Vulnerabilities: []types.DetectedVulnerability{vuln("CVE-2022-42889", "commons-text", "1.9")},
+				},
+			},
+		},
+		{
+			name:    "generic duplicates are kept",
+			options: types.ScanOptions{ScanYanked: true, KeepGenericDuplicates: true},
+			want:    newResults(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+			got, err := f.apply(newResults())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("only the maven finding", func(t *testing.T) {
+		f, err := newResultFilter(types.ScanOptions{ScanYanked: true})
+		require.NoError(t, err)
+		// the jar is matched as both generic and maven
+		results := newResults()[:2]
+		results[0].Vulnerabilities = results[0].Vulnerabilities[:1]
+		got, err := f.apply(results)
+		require.NoError(t, err)
+		assert.Equal(t, report.Results{
+			{
+				Target:          "app/lib/app.jar",
+				Type:            "maven",
+				Vulnerabilities: []types.DetectedVulnerability{vuln("CVE-2022-42889", "commons-text", "1.9")},
+			},
+		}, got)
+	})
+}
+
+func TestResultFilter_MinFixAge(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	released := func(daysAgo int) *time.Time {
+		t := now.AddDate(0, 0, -daysAgo)
+		return &t
+	}
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0001", FixedVersion: "1.2.4", FixedVersionReleased: released(3)},
+			{VulnerabilityID: "CVE-2020-0002", FixedVersion: "1.2.3", FixedVersionReleased: released(60)},
+			{VulnerabilityID: "CVE-2020-0003", FixedVersion: "1.2.5"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []types.DetectedVulnerability
+	}{
+		{
+			name:    "too new fix is flagged",
+			options: types.ScanOptions{MinFixAge: 30 * 24 * time.Hour},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", FixedVersion: "1.2.4", FixedVersionReleased: released(3), FixTooNew: true},
+				{VulnerabilityID: "CVE-2020-0002", FixedVersion: "1.2.3", FixedVersionReleased: released(60)},
+				{VulnerabilityID: "CVE-2020-0003", FixedVersion: "1.2.5"},
+			},
+		},
+		{
+			name:    "too new fix is dropped",
+			options: types.ScanOptions{MinFixAge: 30 * 24 * time.Hour, SkipTooNewFixes: true},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0002", FixedVersion: "1.2.3", FixedVersionReleased: released(60)},
+				{VulnerabilityID: "CVE-2020-0003", FixedVersion: "1.2.5"},
+			},
+		},
+		{
+			name: "disabled",
+			want: newVulns(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.ScanYanked = true
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_EPSS(t *testing.T) {
+	scores := map[string]float64{"CVE-2020-0001": 0.02, "CVE-2020-0002": 0.97, "CVE-2020-0003": 0.6}
+	score := func(id string) *float64 {
+		s := scores[id]
+		return &s
+	}
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0001"},
+			{VulnerabilityID: "NSWG-ECO-428"},
+			{VulnerabilityID: "CVE-2020-0002"},
+			{VulnerabilityID: "CVE-2020-0003"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []types.DetectedVulnerability
+		wantErr string
+	}{
+		{
+			name:    "sorted by descending EPSS",
+			options: types.ScanOptions{EPSSScores: scores, SortByEPSS: true},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0002", EPSS: score("CVE-2020-0002")},
+				{VulnerabilityID: "CVE-2020-0003", EPSS: score("CVE-2020-0003")},
+				{VulnerabilityID: "CVE-2020-0001", EPSS: score("CVE-2020-0001")},
+				{VulnerabilityID: "NSWG-ECO-428"},
+			},
+		},
+		{
+			name:    "only above the threshold",
+			options: types.ScanOptions{EPSSScores: scores, OnlyEPSSAbove: 0.5},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0002", EPSS: score("CVE-2020-0002")},
+				{VulnerabilityID: "CVE-2020-0003", EPSS: score("CVE-2020-0003")},
+			},
+		},
+		{
+			name: "no EPSS data",
+			want: newVulns(),
+		},
+		{
+			name:    "invalid threshold",
+			options: types.ScanOptions{OnlyEPSSAbove: 1.5},
+			wantErr: "invalid EPSS threshold",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_KnownExploited(t *testing.T) {
+	kev := map[string]bool{"CVE-2021-44228": true, "CVE-2014-6271": true}
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2021-44228"},
+			{VulnerabilityID: "CVE-2020-0001"},
+			{VulnerabilityID: "NSWG-ECO-428"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []types.DetectedVulnerability
+	}{
+		{
+			name:    "flagged",
+			options: types.ScanOptions{KnownExploited: kev},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2021-44228", KnownExploited: true},
+				{VulnerabilityID: "CVE-2020-0001"},
+				{VulnerabilityID: "NSWG-ECO-428"},
+			},
+		},
+		{
+			name:    "only the known exploited",
+			options: types.ScanOptions{KnownExploited: kev, OnlyKnownExploited: true},
+			want: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2021-44228", KnownExploited: true},
+			},
+		},
+		{
+			name:    "with the EPSS threshold",
+			options: types.ScanOptions{KnownExploited: kev, OnlyKnownExploited: true, EPSSScores: map[string]float64{"CVE-2021-44228": 0.3}, OnlyEPSSAbove: 0.5},
+		},
+		{
+			name: "no KEV data",
+			want: newVulns(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/pom.xml", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_Severities(t *testing.T) {
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0001", Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+			{VulnerabilityID: "CVE-2020-0002", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+			{VulnerabilityID: "CVE-2020-0003"},
+			{VulnerabilityID: "CVE-2020-0004", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "critical and high",
+			options: types.ScanOptions{Severities: []string{"CRITICAL", "HIGH"}},
+			want:    []string{"CVE-2020-0001", "CVE-2020-0004"},
+		},
+		{
+			name:    "unrated as unknown",
+			options: types.ScanOptions{Severities: []string{"UNKNOWN", "LOW"}},
+			want:    []string{"CVE-2020-0002", "CVE-2020-0003"},
+		},
+		{
+			name: "every severity",
+			want: []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004"},
+		},
+		{
+			name:    "unknown severity",
+			options: types.ScanOptions{Severities: []string{"SEVERE"}},
+			wantErr: "invalid severity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			var ids []string
+			for _, vuln := range got[0].Vulnerabilities {
+				ids = append(ids, vuln.VulnerabilityID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestResultFilter_IgnoreFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-ignore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ignoreFile := filepath.Join(dir, ".trivyignore")
+	require.NoError(t, ioutil.WriteFile(ignoreFile, []byte("# accepted risks\nCVE-2019-11358\n\n  CVE-2020-0002  \n"), 0600))
+
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2019-11358"},
+			{VulnerabilityID: "CVE-2020-0001"},
+			{VulnerabilityID: "CVE-2020-0002"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []types.DetectedVulnerability
+		wantErr string
+	}{
+		{
+			name:    "ignored IDs",
+			options: types.ScanOptions{IgnoreFile: ignoreFile},
+			want:    []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0001"}},
+		},
+		{
+			name: "missing default file",
+			want: newVulns(),
+		},
+		{
+			name:    "missing file",
+			options: types.ScanOptions{IgnoreFile: filepath.Join(dir, "missing")},
+			wantErr: "invalid ignore file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_IgnoreRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-ignore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ignoreFile := filepath.Join(dir, ".trivyignore.yaml")
+	require.NoError(t, ioutil.WriteFile(ignoreFile, []byte(`vulnerabilities:
+  - id: CVE-2019-11358
+    statement: jQuery isn't served
+    expired_at: 2020-06-01
+  - id: CVE-2020-0001
+    statement: Expired
+    expired_at: 2020-01-01
+  - id: CVE-2020-0002
+    paths: ["app/*.json"]
+`), 0600))
+
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	newResults := func() report.Results {
+		return report.Results{
+			{
+				Target: "app/package-lock.json",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2019-11358", PkgName: "jquery"},
+					{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+					{VulnerabilityID: "CVE-2020-0002", PkgName: "minimist"},
+				},
+			},
+			{
+				Target: "api/Gemfile.lock",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-0002", PkgName: "rack"},
+				},
+			},
+		}
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		f, err := newResultFilter(types.ScanOptions{IgnoreFile: ignoreFile})
+		require.NoError(t, err)
+		got, err := f.apply(newResults())
+		require.NoError(t, err)
+		// the expired rule and the rule of other paths don't apply
+		assert.Equal(t, []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"}}, got[0].Vulnerabilities)
+		assert.Equal(t, []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0002", PkgName: "rack"}}, got[1].Vulnerabilities)
+		assert.Empty(t, got[0].Suppressed)
+	})
+
+	t.Run("show suppressed", func(t *testing.T) {
+		f, err := newResultFilter(types.ScanOptions{IgnoreFile: ignoreFile, ShowSuppressed: true})
+		require.NoError(t, err)
+		got, err := f.apply(newResults())
+		require.NoError(t, err)
+		expiredAt := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, []types.SuppressedVulnerability{
+			{
+				DetectedVulnerability: types.DetectedVulnerability{VulnerabilityID: "CVE-2019-11358", PkgName: "jquery"},
+				Statement:             "jQuery isn't served",
+				ExpiredAt:             &expiredAt,
+			},
+			{
+				DetectedVulnerability: types.DetectedVulnerability{VulnerabilityID: "CVE-2020-0002", PkgName: "minimist"},
+			},
+		}, got[0].Suppressed)
+		assert.Empty(t, got[1].Suppressed)
+	})
+}
+
+func TestResultFilter_VEX(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-vex")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	vexFile := filepath.Join(dir, "app.vex.json")
+	require.NoError(t, ioutil.WriteFile(vexFile, []byte(`{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "statements": [
+    {
+      "vulnerability": {"name": "CVE-2020-1967"},
+      "products": [{"@id": "pkg:apk/alpine/openssl@1.1.1d-r3"}],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path",
+      "impact_statement": "TLS 1.3 isn't used"
+    },
+    {
+      "vulnerability": {"name": "CVE-2019-1551"},
+      "products": [{"@id": "pkg:apk/alpine/openssl"}],
+      "status": "affected"
+    }
+  ]
+}`), 0600))
+
+	t.Run("not affected", func(t *testing.T) {
+		f, err := newResultFilter(types.ScanOptions{VEXFile: vexFile})
+		require.NoError(t, err)
+		got, err := f.apply(report.Results{
+			{
+				Target: "alpine:3.11 (alpine 3.11.5)",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+					{VulnerabilityID: "CVE-2019-1551", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+				},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2019-1551", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+		}, got[0].Vulnerabilities)
+		// listed without ShowSuppressed
+		assert.Equal(t, []types.SuppressedVulnerability{
+			{
+				DetectedVulnerability: types.DetectedVulnerability{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+				Statement:             "TLS 1.3 isn't used",
+				VEXStatus:             "not_affected",
+				VEXJustification:      "vulnerable_code_not_in_execute_path",
+			},
+		}, got[0].Suppressed)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		_, err := newResultFilter(types.ScanOptions{VEXFile: filepath.Join(dir, "missing.json")})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid VEX file")
+	})
+}
+
+func TestResultFilter_SkipVersionRangeMatches(t *testing.T) {
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4"},
+			{VulnerabilityID: "CVE-2020-7598", PkgName: "minimist", InstalledVersion: "^1.2.0",
+				MatchConfidence: types.MatchConfidenceVersionRange},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    []types.DetectedVulnerability
+	}{
+		{
+			name: "marked by default",
+			want: newVulns(),
+		},
+		{
+			name:    "dropped",
+			options: types.ScanOptions{SkipVersionRangeMatches: true},
+			want:    newVulns()[:1],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
+
+func TestResultFilter_ScanMaintenance(t *testing.T) {
+	unmaintained := []types.UnmaintainedPackage{
+		{PkgName: "request", InstalledVersion: "2.88.0", Status: types.MaintenanceArchived},
+	}
+	for _, scan := range []bool{true, false} {
+		f, err := newResultFilter(types.ScanOptions{ScanMaintenance: scan})
+		require.NoError(t, err)
+		got, err := f.apply(report.Results{{Target: "app/package-lock.json", UnmaintainedPackages: unmaintained}})
+		require.NoError(t, err)
+		if scan {
+			assert.Equal(t, unmaintained, got[0].UnmaintainedPackages)
+		} else {
+			assert.Empty(t, got[0].UnmaintainedPackages)
+		}
+	}
+}
+
+func TestResultFilter_MinAffectedCount(t *testing.T) {
+	newResults := func() report.Results {
+		return report.Results{
+			{Target: "app/package-lock.json", Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+				{VulnerabilityID: "CVE-2020-0002", PkgName: "jquery"},
+			}},
+			{Target: "web/package-lock.json", Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    report.Results
+		wantErr string
+	}{
+		{
+			name:    "uncommon findings dropped",
+			options: types.ScanOptions{MinAffectedCount: 2},
+			want: report.Results{
+				{Target: "app/package-lock.json", Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+				}},
+				{Target: "web/package-lock.json", Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+				}},
+			},
+		},
+		{
+			name:    "uncommon findings kept apart",
+			options: types.ScanOptions{MinAffectedCount: 2, KeepUncommon: true},
+			want: report.Results{
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+					},
+					Uncommon: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-0002", PkgName: "jquery"},
+					},
+				},
+				{Target: "web/package-lock.json", Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-0001", PkgName: "lodash"},
+				}},
+			},
+		},
+		{
+			name:    "above every count",
+			options: types.ScanOptions{MinAffectedCount: 3},
+			want: report.Results{
+				{Target: "app/package-lock.json"},
+				{Target: "web/package-lock.json"},
+			},
+		},
+		{
+			name:    "invalid count",
+			options: types.ScanOptions{MinAffectedCount: -1},
+			wantErr: "invalid affected count",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(newResults())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResultFilter_SeparateDeferred(t *testing.T) {
+	newResults := func() report.Results {
+		return report.Results{
+			{Target: "centos:7 (centos 7.6.1810)", Vulnerabilities: []types.DetectedVulnerability{
+				{VulnerabilityID: "CVE-2017-5953", PkgName: "vim-minimal", Status: types.StatusWillNotFix},
+				{VulnerabilityID: "CVE-2017-6350", PkgName: "vim-minimal", Status: types.StatusAffected},
+				{VulnerabilityID: "CVE-2018-12404", PkgName: "nss", FixedVersion: "3.44.0-4.el7"},
+				{VulnerabilityID: "CVE-2016-2183", PkgName: "nss", Status: types.StatusFixDeferred},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		want    report.Results
+	}{
+		{
+			name: "mixed by default",
+			want: newResults(),
+		},
+		{
+			name:    "kept apart",
+			options: types.ScanOptions{SeparateDeferred: true},
+			want: report.Results{
+				{
+					Target: "centos:7 (centos 7.6.1810)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2017-6350", PkgName: "vim-minimal", Status: types.StatusAffected},
+						{VulnerabilityID: "CVE-2018-12404", PkgName: "nss", FixedVersion: "3.44.0-4.el7"},
+					},
+					Deferred: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2017-5953", PkgName: "vim-minimal", Status: types.StatusWillNotFix},
+						{VulnerabilityID: "CVE-2016-2183", PkgName: "nss", Status: types.StatusFixDeferred},
+					},
+				},
+			},
+		},
+		{
+			name:    "kept apart from the fixed ones",
+			options: types.ScanOptions{SeparateDeferred: true, IgnoreUnfixed: true},
+			want: report.Results{
+				{
+					Target: "centos:7 (centos 7.6.1810)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2018-12404", PkgName: "nss", FixedVersion: "3.44.0-4.el7"},
+					},
+					Deferred: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2017-5953", PkgName: "vim-minimal", Status: types.StatusWillNotFix},
+						{VulnerabilityID: "CVE-2016-2183", PkgName: "nss", Status: types.StatusFixDeferred},
+					},
+				},
+			},
+		},
+		{
+			name:    "filtered by status",
+			options: types.ScanOptions{FilterExpr: `status != "will_not_fix"`},
+			want: report.Results{
+				{Target: "centos:7 (centos 7.6.1810)", Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2017-6350", PkgName: "vim-minimal", Status: types.StatusAffected},
+					{VulnerabilityID: "CVE-2018-12404", PkgName: "nss", FixedVersion: "3.44.0-4.el7"},
+					{VulnerabilityID: "CVE-2016-2183", PkgName: "nss", Status: types.StatusFixDeferred},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			require.NoError(t, err)
+			got, err := f.apply(newResults())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResultFilter_IgnoredEcosystems(t *testing.T) {
+	newResults := func() report.Results {
+		return report.Results{
+			{Target: "alpine:3.11 (alpine 3.11.3)", Type: "alpine", Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0001"}}},
+			{Target: "app/Pipfile.lock", Type: "pipenv", Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0002"}}},
+			{Target: "app/poetry.lock", Type: "poetry", Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0003"}}},
+			{Target: "app/package-lock.json", Type: "npm", Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-0004"}}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		ecosystems  []string
+		wantTargets []string
+	}{
+		{
+			name:        "pip",
+			ecosystems:  []string{"pip"},
+			wantTargets: []string{"alpine:3.11 (alpine 3.11.3)", "app/package-lock.json"},
+		},
+		{
+			name:        "result type",
+			ecosystems:  []string{"poetry", "npm"},
+			wantTargets: []string{"alpine:3.11 (alpine 3.11.3)", "app/Pipfile.lock"},
+		},
+		{
+			name:        "os",
+			ecosystems:  []string{"os"},
+			wantTargets: []string{"app/Pipfile.lock", "app/poetry.lock", "app/package-lock.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(types.ScanOptions{IgnoredEcosystems: tt.ecosystems})
+			require.NoError(t, err)
+			got, err := f.apply(newResults())
+			require.NoError(t, err)
+
+			var targets []string
+			for _, result := range got {
+				targets = append(targets, result.Target)
+			}
+			assert.Equal(t, tt.wantTargets, targets)
+		})
+	}
+}
+
+func TestResultFilter_AggregateNearDuplicates(t *testing.T) {
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", InstalledVersion: "4.17.4",
+				Vulnerability: dbTypes.Vulnerability{Title: "Prototype pollution in lodash defaultsDeep"}},
+			{VulnerabilityID: "NSWG-ECO-516", PkgName: "lodash", InstalledVersion: "4.17.4",
+				Vulnerability: dbTypes.Vulnerability{Title: "Prototype Pollution in lodash (defaultsDeep)"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options types.ScanOptions
+		wantIDs []string
+		wantErr string
+	}{
+		{
+			name:    "aggregated",
+			options: types.ScanOptions{AggregateNearDuplicates: true},
+			wantIDs: []string{"CVE-2019-10744"},
+		},
+		{
+			name:    "disabled",
+			wantIDs: []string{"CVE-2019-10744", "NSWG-ECO-516"},
+		},
+		{
+			name:    "invalid similarity",
+			options: types.ScanOptions{AggregateNearDuplicates: true, NearDuplicateSimilarity: 1.5},
+			wantErr: "invalid near-duplicate similarity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/package-lock.json", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+
+			var ids []string
+			for _, vuln := range got[0].Vulnerabilities {
+				ids = append(ids, vuln.VulnerabilityID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestResultFilter_IgnorePkgs(t *testing.T) {
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0001", PkgName: "test-fixtures"},
+			{VulnerabilityID: "CVE-2020-0002", PkgName: "example.com/internal/auth"},
+			{VulnerabilityID: "CVE-2020-0003", PkgName: "example.com/internal/auth/v2"},
+			{VulnerabilityID: "CVE-2020-0004", PkgName: "lodash"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		wantIDs  []string
+		wantErr  string
+	}{
+		{
+			name:     "exact match",
+			patterns: []string{"lodash"},
+			wantIDs:  []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003"},
+		},
+		{
+			name:     "wildcard matches",
+			patterns: []string{"test-*", "example.com/internal/*"},
+			wantIDs:  []string{"CVE-2020-0003", "CVE-2020-0004"},
+		},
+		{
+			name:     "no match",
+			patterns: []string{"lodash.*", "test"},
+			wantIDs:  []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004"},
+		},
+		{
+			name:     "invalid pattern",
+			patterns: []string{"test-[a"},
+			wantErr:  `invalid package pattern "test-[a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(types.ScanOptions{IgnorePkgs: tt.patterns})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/go.sum", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+
+			var ids []string
+			for _, vuln := range got[0].Vulnerabilities {
+				ids = append(ids, vuln.VulnerabilityID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestResultFilter_IgnoreUnfixed(t *testing.T) {
+	newVulns := func() []types.DetectedVulnerability {
+		return []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0001", PkgName: "openssl", FixedVersion: "1.1.1g-r0"},
+			{VulnerabilityID: "CVE-2020-0002", PkgName: "musl"},
+			{VulnerabilityID: "CVE-2020-0003", PkgName: "rack", FixedVersion: ">=3.4.0"},
+			{VulnerabilityID: "CVE-2020-0004", PkgName: "rails", FixedVersion: " "},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		ignoreUnfixed bool
+		wantIDs       []string
+	}{
+		{
+			name:          "ignore unfixed",
+			ignoreUnfixed: true,
+			wantIDs:       []string{"CVE-2020-0001", "CVE-2020-0003"},
+		},
+		{
+			name:    "keep unfixed",
+			wantIDs: []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFilter(types.ScanOptions{IgnoreUnfixed: tt.ignoreUnfixed})
+			require.NoError(t, err)
+			got, err := f.apply(report.Results{{Target: "app/Gemfile.lock", Vulnerabilities: newVulns()}})
+			require.NoError(t, err)
+
+			var ids []string
+			for _, vuln := range got[0].Vulnerabilities {
+				ids = append(ids, vuln.VulnerabilityID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func assignFindingIDs(results report.Results, prefix string) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			result.Vulnerabilities[i].FindingID = findingID(prefix, result.Target, vuln)
+		}
+	}
+}
+
+// findingID hashes the fields identifying a finding; the prefix isn't hashed so it can be changed
+// without changing the rest of the ID.
+// The severity and the fixed version are excluded as they change with the DB.
+func findingID(prefix, target string, vuln types.DetectedVulnerability) string {
+	key := strings.Join([]string{target, vuln.PkgName, vuln.InstalledVersion, vuln.VulnerabilityID}, "\x00")
+	h := sha256.Sum256([]byte(key))
+	return prefix + hex.EncodeToString(h[:])
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestAssignFindingIDs(t *testing.T) {
+	newResults := func(severity string) report.Results {
+		return report.Results{
+			{
+				Target: "alpine:3.11 (alpine 3.11.5)",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+					{
+						VulnerabilityID:  "CVE-2020-1967",
+						PkgName:          "libssl1.1",
+						InstalledVersion: "1.1.1d-r3",
+						Vulnerability:    dbTypes.Vulnerability{Severity: severity},
+					},
+				},
+			},
+			{
+				Target: "app/package-lock.json",
+				Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", InstalledVersion: "1.1.1d-r3"},
+				},
+			},
+		}
+	}
+	ids := func(results report.Results) []string {
+		var ids []string
+		for _, result := range results {
+			for _, vuln := range result.Vulnerabilities {
+				ids = append(ids, vuln.FindingID)
+			}
+		}
+		return ids
+	}
+
+	first := newResults("HIGH")
+	assignFindingIDs(first, "")
+	second := newResults("CRITICAL")
+	assignFindingIDs(second, "")
+	assert.Equal(t, ids(first), ids(second), "IDs must be stable across runs")
+
+	got := ids(first)
+	assert.Len(t, got, 3)
+	assert.NotEqual(t, got[0], got[1], "different packages")
+	assert.NotEqual(t, got[0], got[2], "different targets")
+
+	prefixed := newResults("HIGH")
+	assignFindingIDs(prefixed, "trivy:")
+	for i, id := range ids(prefixed) {
+		assert.True(t, strings.HasPrefix(id, "trivy:"), id)
+		assert.Equal(t, "trivy:"+got[i], id)
+	}
+
+	again := newResults("HIGH")
+	assignFindingIDs(again, "trivy:")
+	assert.Equal(t, ids(prefixed), ids(again))
+}
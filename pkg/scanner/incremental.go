@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// IncrementalDriver is implemented by drivers detecting the vulnerabilities of the layers passed to Scan only,
+// the lower layers listed in ScanOptions.KnownLayers being merged below them, e.g. to detect the OS
+type IncrementalDriver interface {
+	ScansIncrementally() bool
+}
+
+// knownLayers returns the lowest layers of the image in ScanOptions.KnownLayers, the layers above the first
+// unknown one being merged differently
+func knownLayers(layerIDs, known []string) []string {
+	var n int
+	for n < len(layerIDs) && utils.StringInSlice(layerIDs[n], known) {
+		n++
+	}
+	return layerIDs[:n]
+}
+
+// skipKnownLayers returns the target and the options of the driver scan skipping the known lower layers,
+// and these layers whose findings are dropped after the scan, in case the driver doesn't skip them
+func (s Scanner) skipKnownLayers(target ftypes.ImageReference, options types.ScanOptions) (
+	ftypes.ImageReference, types.ScanOptions, []string) {
+	known := knownLayers(target.LayerIDs, options.KnownLayers)
+	options.KnownLayers = nil
+	if len(known) == 0 {
+		return target, options, nil
+	}
+	log.Logger.Debugf("%d of the %d layers are already scanned", len(known), len(target.LayerIDs))
+
+	if d, ok := s.driver.(IncrementalDriver); ok && d.ScansIncrementally() {
+		options.KnownLayers = known
+		target.LayerIDs = target.LayerIDs[len(known):]
+	}
+	return target, options, known
+}
+
+// dropLayerFindings removes the findings introduced in the layers
+func dropLayerFindings(results report.Results, layerIDs []string) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if !utils.StringInSlice(vuln.Layer.DiffID, layerIDs) {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// markKnownExploited flags the findings of the CVEs of the KEV catalog
+func markKnownExploited(results report.Results, ids map[string]bool) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if ids[vuln.VulnerabilityID] {
+				result.Vulnerabilities[i].KnownExploited = true
+			}
+		}
+	}
+}
+
+// filterKnownExploited keeps the findings of the KEV catalog
+func filterKnownExploited(results report.Results) report.Results {
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		for _, vuln := range result.Vulnerabilities {
+			if vuln.KnownExploited {
+				vulns = append(vulns, vuln)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
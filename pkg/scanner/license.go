@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aquasecurity/fanal/extractor"
+
+	"github.com/aquasecurity/trivy/pkg/licensing"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// LicenseDetector is implemented by analyzers that can read the licenses of the packages from the extracted files
+type LicenseDetector interface {
+	SetLicenseDetection(enabled bool)
+	// Licenses returns the licenses found by the last analysis by file path, those of every layer with the file
+	Licenses() map[string][]types.DetectedLicense
+}
+
+// licenseExtractor reads the licenses of the extracted files when it is enabled
+type licenseExtractor struct {
+	extractor.Extractor
+
+	mu       sync.Mutex
+	enabled  bool
+	licenses map[string][]types.DetectedLicense
+}
+
+func (e *licenseExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	layerDigest, files, opqDirs, whFiles, err := e.Extractor.ExtractLayerFiles(diffID, filenames)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	if !e.isEnabled() {
+		return layerDigest, files, opqDirs, whFiles, nil
+	}
+	licenses := map[string][]types.DetectedLicense{}
+	for filename, content := range files {
+		for _, l := range licensing.Parse(filename, content) {
+			l.Layer = diffID
+			licenses[filename] = append(licenses[filename], l)
+		}
+	}
+	e.addLicenses(licenses)
+	return layerDigest, files, opqDirs, whFiles, nil
+}
+
+func (e *licenseExtractor) setEnabled(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enabled = enabled
+}
+
+func (e *licenseExtractor) isEnabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enabled
+}
+
+func (e *licenseExtractor) addLicenses(licenses map[string][]types.DetectedLicense) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.licenses == nil {
+		e.licenses = map[string][]types.DetectedLicense{}
+	}
+	for filename, l := range licenses {
+		e.licenses[filename] = append(e.licenses[filename], l...)
+	}
+}
+
+// takeLicenses returns the licenses since the last call
+func (e *licenseExtractor) takeLicenses() map[string][]types.DetectedLicense {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	licenses := e.licenses
+	e.licenses = nil
+	return licenses
+}
+
+// setLicenseDetection enables the license detection of the analyzer with SecurityCheckLicense, and disables it otherwise
+func (s Scanner) setLicenseDetection(options types.ScanOptions) {
+	detector, ok := s.analyzer.(LicenseDetector)
+	enabled := hasSecurityCheck(options, types.SecurityCheckLicense)
+	if !ok {
+		if enabled {
+			log.Logger.Warn("The analyzer doesn't detect licenses")
+		}
+		return
+	}
+	if enabled {
+		licensing.Register()
+	}
+	detector.SetLicenseDetection(enabled)
+}
+
+// licenseResults returns a result of the class report.ClassLicense per category of packages, e.g. licensing.CategoryOS,
+// with the licenses sorted by package. Only the licenses of the upper layer with each file are kept,
+// as it replaces the file of the lower layers. The licenses requiring one of the forbidden licenses are marked.
+func licenseResults(licenses map[string][]types.DetectedLicense, layerIDs []string, forbidden []string) report.Results {
+	layerIndex := map[string]int{}
+	for i, layerID := range layerIDs {
+		layerIndex[layerID] = i
+	}
+
+	byCategory := map[string][]types.DetectedLicense{}
+	for filePath, found := range licenses {
+		upper := -1
+		for _, l := range found {
+			if i, ok := layerIndex[l.Layer]; ok && i > upper {
+				upper = i
+			}
+		}
+		category := licensing.Category(filePath)
+		for _, l := range found {
+			if i, ok := layerIndex[l.Layer]; ok && i != upper {
+				continue
+			}
+			l.Forbidden = licensing.Forbidden(l.Name, forbidden)
+			byCategory[category] = append(byCategory[category], l)
+		}
+	}
+
+	var results report.Results
+	for _, category := range []string{licensing.CategoryOS, licensing.CategoryNode, licensing.CategoryPython} {
+		found := byCategory[category]
+		if len(found) == 0 {
+			continue
+		}
+		sort.Slice(found, func(i, j int) bool {
+			if found[i].PkgName != found[j].PkgName {
+				return found[i].PkgName < found[j].PkgName
+			}
+			if found[i].Name != found[j].Name {
+				return found[i].Name < found[j].Name
+			}
+			return found[i].FilePath < found[j].FilePath
+		})
+		results = append(results, report.Result{
+			Target:   category,
+			Class:    report.ClassLicense,
+			Licenses: found,
+		})
+	}
+	return results
+}
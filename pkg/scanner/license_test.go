@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	"github.com/aquasecurity/trivy/pkg/licensing"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestImageAnalyzer_Licenses(t *testing.T) {
+	a := NewImageAnalyzer(analyzer.Config{Extractor: layeredExtractor{layers: map[string]extractor.FileMap{
+		"sha256:base": {
+			"lib/apk/db/installed": []byte("P:musl\nL:MIT\n"),
+			"etc/alpine-release":   []byte("3.11.5"),
+		},
+	}}})
+
+	// disabled by default
+	_, _, _, _, err := a.Extractor.ExtractLayerFiles("sha256:base", nil)
+	require.NoError(t, err)
+	assert.Empty(t, a.Licenses())
+
+	a.SetLicenseDetection(true)
+	_, _, _, _, err = a.Extractor.ExtractLayerFiles("sha256:base", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]types.DetectedLicense{
+		"lib/apk/db/installed": {
+			{PkgName: "musl", Name: "MIT", FilePath: "lib/apk/db/installed", Layer: "sha256:base"},
+		},
+	}, a.Licenses())
+	assert.Empty(t, a.Licenses(), "licenses are returned once")
+}
+
+func TestLicenseResults(t *testing.T) {
+	licenses := map[string][]types.DetectedLicense{
+		"lib/apk/db/installed": {
+			{PkgName: "musl", Name: "MIT", FilePath: "lib/apk/db/installed", Layer: "sha256:base"},
+			{PkgName: "readline", Name: "GPL-3.0-or-later", FilePath: "lib/apk/db/installed", Layer: "sha256:base"},
+			// the database of the upper layer replaces that of the base layer
+			{PkgName: "musl", Name: "MIT", FilePath: "lib/apk/db/installed", Layer: "sha256:app"},
+			{PkgName: "bash", Name: "GPL-3.0-or-later", FilePath: "lib/apk/db/installed", Layer: "sha256:app"},
+		},
+		"app/node_modules/lodash/package.json": {
+			{PkgName: "lodash", Name: "MIT", FilePath: "app/node_modules/lodash/package.json", Layer: "sha256:app"},
+		},
+	}
+
+	got := licenseResults(licenses, []string{"sha256:base", "sha256:app"}, []string{"GPL-3.0"})
+	assert.Equal(t, report.Results{
+		{
+			Target: licensing.CategoryOS,
+			Class:  report.ClassLicense,
+			Licenses: []types.DetectedLicense{
+				{PkgName: "bash", Name: "GPL-3.0-or-later", FilePath: "lib/apk/db/installed", Forbidden: true, Layer: "sha256:app"},
+				{PkgName: "musl", Name: "MIT", FilePath: "lib/apk/db/installed", Layer: "sha256:app"},
+			},
+		},
+		{
+			Target: licensing.CategoryNode,
+			Class:  report.ClassLicense,
+			Licenses: []types.DetectedLicense{
+				{PkgName: "lodash", Name: "MIT", FilePath: "app/node_modules/lodash/package.json", Layer: "sha256:app"},
+			},
+		},
+	}, got)
+
+	assert.Empty(t, licenseResults(nil, nil, nil))
+}
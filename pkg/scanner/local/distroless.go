@@ -0,0 +1,79 @@
+package local
+
+import (
+	"strings"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor/docker"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"golang.org/x/xerrors"
+)
+
+// DefaultDistrolessRepositories are the repositories of the distroless base images
+var DefaultDistrolessRepositories = []string{"gcr.io/distroless/"}
+
+// LayerMerger merges the layers of an image even without a detected OS or OS packages,
+// so that the libraries of distroless images can still be scanned
+type LayerMerger interface {
+	MergeLayers(imageID string, layerIDs []string) (ftypes.ImageDetail, error)
+}
+
+// LayerApplier is the applier of fanal also merging the layers of images without OS packages
+type LayerApplier struct {
+	analyzer.Applier
+	cache cache.LocalImageCache
+}
+
+func NewApplier(c cache.LocalImageCache) LayerApplier {
+	return LayerApplier{Applier: analyzer.NewApplier(c), cache: c}
+}
+
+func (a LayerApplier) MergeLayers(imageID string, layerIDs []string) (ftypes.ImageDetail, error) {
+	var layers []ftypes.LayerInfo
+	for _, diffID := range layerIDs {
+		layer, _ := a.cache.GetLayer(diffID)
+		if layer.SchemaVersion == 0 {
+			return ftypes.ImageDetail{}, xerrors.Errorf("layer cache missing: %s", diffID)
+		}
+		layers = append(layers, layer)
+	}
+	return docker.ApplyLayers(layers), nil
+}
+
+// ImageOSGetter returns the OS of the configuration of an image, e.g. "windows"
+type ImageOSGetter interface {
+	ImageOS(imageID string) string
+}
+
+func (a LayerApplier) ImageOS(imageID string) string {
+	imageInfo, _ := a.cache.GetImage(imageID)
+	return imageInfo.OS
+}
+
+// isDistroless reports whether the image is in one of the repositories, or in the default ones without any
+func isDistroless(imageName string, repositories []string) bool {
+	if len(repositories) == 0 {
+		repositories = DefaultDistrolessRepositories
+	}
+	for _, repo := range repositories {
+		if strings.HasPrefix(imageName, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergingApplier is LayerApplier merging the layers even without a detected OS or OS packages,
+// for the packages not found in images, e.g. the libraries of an SBOM
+type MergingApplier struct {
+	LayerApplier
+}
+
+func NewMergingApplier(c cache.LocalImageCache) MergingApplier {
+	return MergingApplier{LayerApplier: NewApplier(c)}
+}
+
+func (a MergingApplier) ApplyLayers(imageID string, layerIDs []string) (ftypes.ImageDetail, error) {
+	return a.MergeLayers(imageID, layerIDs)
+}
@@ -0,0 +1,182 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
+	dtypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+	vuln "github.com/aquasecurity/trivy/pkg/vulnerability"
+)
+
+type fakeImageCache map[string]ftypes.LayerInfo
+
+func (c fakeImageCache) GetImage(string) (ftypes.ImageInfo, error) {
+	return ftypes.ImageInfo{}, nil
+}
+
+func (c fakeImageCache) GetLayer(diffID string) (ftypes.LayerInfo, error) {
+	return c[diffID], nil
+}
+
+func (c fakeImageCache) Clear() error {
+	return nil
+}
+
+func TestScanner_Scan_Distroless(t *testing.T) {
+	diffID := "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"
+	layerCache := fakeImageCache{
+		diffID: {
+			SchemaVersion: 1,
+			DiffID:        diffID,
+			Applications: []ftypes.Application{
+				{
+					Type:      "bundler",
+					FilePath:  "/app/Gemfile.lock",
+					Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "rails", Version: "6.0"}}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		options     types.ScanOptions
+		wantResults report.Results
+		wantErr     string
+	}{
+		{
+			name:   "distroless image",
+			target: "gcr.io/distroless/base:latest",
+			wantResults: report.Results{
+				{Target: "/app/Gemfile.lock", Type: "bundler", Class: report.ClassLangPkgs, Status: report.StatusScanned},
+			},
+		},
+		{
+			name:    "custom repository",
+			target:  "registry.example.com/base/static:1",
+			options: types.ScanOptions{DistrolessRepositories: []string{"registry.example.com/base/"}},
+			wantResults: report.Results{
+				{Target: "/app/Gemfile.lock", Type: "bundler", Class: report.ClassLangPkgs, Status: report.StatusScanned},
+			},
+		},
+		{
+			name:    "other image without OS",
+			target:  "example/app:latest",
+			wantErr: "unknown OS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			libDetector := new(MockLibraryDetector)
+			libDetector.ApplyDetectExpectations([]LibraryDetectorDetectExpectation{
+				{
+					Args: LibraryDetectorDetectArgs{
+						ImageNameAnything: true,
+						FilePath:          "/app/Gemfile.lock",
+						CreatedAnything:   true,
+						PkgsAnything:      true,
+					},
+				},
+			})
+			vulnClient := new(vuln.MockOperation)
+			vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+				Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+			})
+
+			s := NewScanner(NewApplier(layerCache), new(MockOspkgDetector), libDetector, vulnClient)
+			options := tt.options
+			options.VulnType = []string{"os", "library"}
+			gotResults, gotOS, _, err := s.Scan(tt.target, "", []string{diffID}, options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Nil(t, gotOS)
+			assert.Equal(t, tt.wantResults, gotResults)
+		})
+	}
+}
+
+// windowsImageCache is the cache of a Windows image, whose OS of the configuration is windows
+type windowsImageCache struct {
+	fakeImageCache
+}
+
+func (c windowsImageCache) GetImage(string) (ftypes.ImageInfo, error) {
+	return ftypes.ImageInfo{SchemaVersion: 1, OS: "windows"}, nil
+}
+
+func TestScanner_Scan_Windows(t *testing.T) {
+	diffID := "sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"
+	layer := ftypes.LayerInfo{
+		SchemaVersion: 1,
+		DiffID:        diffID,
+		Applications: []ftypes.Application{
+			{
+				Type:      "npm",
+				FilePath:  "Files/app/package-lock.json",
+				Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		layerCache  cache.LocalImageCache
+		wantResults report.Results
+		wantErr     string
+	}{
+		{
+			name:       "Windows image without servicing packages",
+			layerCache: windowsImageCache{fakeImageCache{diffID: layer}},
+			wantResults: report.Results{
+				{Target: "Files/app/package-lock.json", Type: "npm", Class: report.ClassLangPkgs, Status: report.StatusScanned},
+			},
+		},
+		{
+			name:       "Linux image without OS",
+			layerCache: fakeImageCache{diffID: layer},
+			wantErr:    "unknown OS",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			libDetector := new(MockLibraryDetector)
+			libDetector.ApplyDetectExpectations([]LibraryDetectorDetectExpectation{
+				{
+					Args: LibraryDetectorDetectArgs{
+						ImageNameAnything: true,
+						FilePath:          "Files/app/package-lock.json",
+						CreatedAnything:   true,
+						PkgsAnything:      true,
+					},
+				},
+			})
+			vulnClient := new(vuln.MockOperation)
+			vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+				Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+			})
+
+			s := NewScanner(NewApplier(tt.layerCache), new(MockOspkgDetector), libDetector, vulnClient)
+			gotResults, gotOS, _, err := s.Scan("mcr.microsoft.com/app:ltsc2019", "sha256:image",
+				[]string{diffID}, types.ScanOptions{VulnType: []string{"os", "library"}})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Nil(t, gotOS)
+			assert.Equal(t, tt.wantResults, gotResults)
+		})
+	}
+}
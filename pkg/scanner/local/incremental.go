@@ -0,0 +1,38 @@
+package local
+
+import (
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// ScansIncrementally implements scanner.IncrementalDriver
+func (s Scanner) ScansIncrementally() bool {
+	return true
+}
+
+// skipKnownLayers removes the packages and the libraries of the known layers, and the applications without others
+func skipKnownLayers(imageDetail ftypes.ImageDetail, knownLayers []string) ftypes.ImageDetail {
+	var pkgs []ftypes.Package
+	for _, pkg := range imageDetail.Packages {
+		if !utils.StringInSlice(pkg.Layer.DiffID, knownLayers) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	imageDetail.Packages = pkgs
+
+	var apps []ftypes.Application
+	for _, app := range imageDetail.Applications {
+		var libs []ftypes.LibraryInfo
+		for _, lib := range app.Libraries {
+			if !utils.StringInSlice(lib.Layer.DiffID, knownLayers) {
+				libs = append(libs, lib)
+			}
+		}
+		if len(libs) > 0 {
+			app.Libraries = libs
+			apps = append(apps, app)
+		}
+	}
+	imageDetail.Applications = apps
+	return imageDetail
+}
@@ -1,8 +1,10 @@
 package local
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/aquasecurity/trivy/pkg/types"
@@ -11,6 +13,7 @@ import (
 	"github.com/aquasecurity/fanal/analyzer"
 
 	"github.com/google/wire"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
 	_ "github.com/aquasecurity/fanal/analyzer/command/apk"
@@ -31,18 +34,36 @@ import (
 	_ "github.com/aquasecurity/fanal/analyzer/pkg/dpkg"
 	_ "github.com/aquasecurity/fanal/analyzer/pkg/rpmcmd"
 	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/db"
 	libDetector "github.com/aquasecurity/trivy/pkg/detector/library"
 	ospkgDetector "github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/detector/ospkg/windows"
+	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/report"
+	scannerUtils "github.com/aquasecurity/trivy/pkg/scanner/utils"
+	"github.com/aquasecurity/trivy/pkg/vulnerability"
 )
 
 var SuperSet = wire.NewSet(
-	analyzer.NewApplier,
-	wire.Bind(new(Applier), new(analyzer.Applier)),
+	NewApplier,
+	wire.Bind(new(Applier), new(LayerApplier)),
 	ospkgDetector.SuperSet,
 	wire.Bind(new(OspkgDetector), new(ospkgDetector.Detector)),
 	libDetector.SuperSet,
 	wire.Bind(new(LibraryDetector), new(libDetector.Detector)),
+	vulnerability.SuperSet,
+	NewScanner,
+)
+
+// MergingSuperSet is SuperSet with the layers merged even without an OS, see MergingApplier
+var MergingSuperSet = wire.NewSet(
+	NewMergingApplier,
+	wire.Bind(new(Applier), new(MergingApplier)),
+	ospkgDetector.SuperSet,
+	wire.Bind(new(OspkgDetector), new(ospkgDetector.Detector)),
+	libDetector.SuperSet,
+	wire.Bind(new(LibraryDetector), new(libDetector.Detector)),
+	vulnerability.SuperSet,
 	NewScanner,
 )
 
@@ -50,10 +71,21 @@ type Applier interface {
 	ApplyLayers(imageID string, layerIDs []string) (detail ftypes.ImageDetail, err error)
 }
 
+// TypedLibraryDetector is implemented by library detectors also selecting the driver by the application type,
+// e.g. for Go binaries whose file name doesn't tell it
+type TypedLibraryDetector interface {
+	DetectType(appType, filePath string, pkgs []ftypes.LibraryInfo) (detectedVulns []types.DetectedVulnerability, err error)
+}
+
 type OspkgDetector interface {
 	Detect(imageName, osFamily, osName string, created time.Time, pkgs []ftypes.Package) (detectedVulns []types.DetectedVulnerability, eosl bool, err error)
 }
 
+// RollupOspkgDetector is implemented by OS package detectors also matching the advisories of the major OS version line
+type RollupOspkgDetector interface {
+	DetectRollup(imageName, osFamily, osName string, created time.Time, pkgs []ftypes.Package) (detectedVulns []types.DetectedVulnerability, eosl bool, err error)
+}
+
 type LibraryDetector interface {
 	Detect(imageName, filePath string, created time.Time, pkgs []ftypes.LibraryInfo) (detectedVulns []types.DetectedVulnerability, err error)
 }
@@ -62,55 +94,210 @@ type Scanner struct {
 	applier       Applier
 	ospkgDetector OspkgDetector
 	libDetector   LibraryDetector
+	vulnClient    vulnerability.Operation
 }
 
-func NewScanner(applier Applier, ospkgDetector OspkgDetector, libDetector LibraryDetector) Scanner {
-	return Scanner{applier: applier, ospkgDetector: ospkgDetector, libDetector: libDetector}
+func NewScanner(applier Applier, ospkgDetector OspkgDetector, libDetector LibraryDetector,
+	vulnClient vulnerability.Operation) Scanner {
+	return Scanner{applier: applier, ospkgDetector: ospkgDetector, libDetector: libDetector, vulnClient: vulnClient}
 }
 
 func (s Scanner) Scan(target string, imageID string, layerIDs []string, options types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
-	imageDetail, err := s.applier.ApplyLayers(imageID, layerIDs)
+	return s.ScanStream(context.Background(), target, imageID, layerIDs, options, nil)
+}
+
+// ScanStream is Scan calling the handler with the result of each target as soon as it is scanned, from several
+// goroutines and in the order they finish, e.g. to stream them. The library scan stops when the context is done.
+// The handler must not keep the result, whose vulnerabilities are still processed.
+func (s Scanner) ScanStream(ctx context.Context, target string, imageID string, layerIDs []string, options types.ScanOptions,
+	handler func(report.Result)) (report.Results, *ftypes.OS, bool, error) {
+	if options.SkipDBUpdate {
+		if err := db.CheckLocalDB(utils.CacheDir()); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	// the known lower layers are merged below the others, e.g. for the OS, but their packages aren't scanned
+	appliedIDs := layerIDs
+	if len(options.KnownLayers) > 0 {
+		appliedIDs = append(append([]string{}, options.KnownLayers...), layerIDs...)
+	}
+	imageDetail, err := s.applier.ApplyLayers(imageID, appliedIDs)
+	if err == analyzer.ErrUnknownOS || err == analyzer.ErrNoPkgsDetected {
+		if isDistroless(target, options.DistrolessRepositories) {
+			imageDetail, err = s.mergeDistroless(target, imageID, appliedIDs)
+		} else if s.isWindows(imageID) {
+			imageDetail, err = s.mergeWindows(target, imageID, appliedIDs)
+		}
+	}
 	if err != nil {
 		return nil, nil, false, xerrors.Errorf("failed to apply layers: %w", err)
 	}
+	if len(options.KnownLayers) > 0 {
+		imageDetail = skipKnownLayers(imageDetail, options.KnownLayers)
+	}
+	var libraries int
+	for _, app := range imageDetail.Applications {
+		libraries += len(app.Libraries)
+	}
+	log.Logger.Debugw("Packages discovered", "target", target, "os_packages", len(imageDetail.Packages),
+		"applications", len(imageDetail.Applications), "libraries", libraries)
 
+	// the OS packages and the libraries are scanned concurrently, a failure stops the library scan
 	var eosl bool
-	var results report.Results
+	var osResult *report.Result
+	var libResults report.Results
+	g, ctx := errgroup.WithContext(ctx)
 
 	if utils.StringInSlice("os", options.VulnType) {
-		pkgs := imageDetail.Packages
-		if options.ScanRemovedPackages {
-			pkgs = mergePkgs(pkgs, imageDetail.HistoryPackages)
-		}
+		g.Go(func() error {
+			pkgs := imageDetail.Packages
+			if options.ScanRemovedPackages {
+				pkgs = mergePkgs(pkgs, imageDetail.HistoryPackages)
+			}
 
-		var result *report.Result
-		result, eosl, err = s.scanOSPkg(target, imageDetail.OS.Family, imageDetail.OS.Name, pkgs)
-		if err != nil {
-			return nil, nil, false, xerrors.Errorf("failed to scan OS packages: %w", err)
-		}
-		if result != nil {
-			results = append(results, *result)
-		}
+			var osFamily, osName string
+			if imageDetail.OS != nil {
+				osFamily, osName = imageDetail.OS.Family, imageDetail.OS.Name
+			}
+			var err error
+			osResult, eosl, err = s.scanOSPkg(target, osFamily, osName, pkgs, options.ShardSize, options.OSMinorRollup)
+			if err != nil {
+				return xerrors.Errorf("failed to scan OS packages: %w", err)
+			}
+			if osResult != nil {
+				s.scanned(*osResult, handler)
+			}
+			return nil
+		})
 	}
 
 	if utils.StringInSlice("library", options.VulnType) {
-		libResults, err := s.scanLibrary(imageDetail.Applications)
-		if err != nil {
-			return nil, nil, false, xerrors.Errorf("failed to scan application libraries: %w", err)
-		}
-		results = append(results, libResults...)
+		g.Go(func() error {
+			var err error
+			libResults, err = s.scanLibrary(ctx, imageDetail.Applications, options.PkgAliases, options.ShardSize,
+				options.Parallel, options.PartialResults, handler)
+			if err != nil {
+				return xerrors.Errorf("failed to scan application libraries: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if err = g.Wait(); err != nil {
+		return nil, nil, false, err
+	}
+
+	// the OS result always comes first
+	var results report.Results
+	if osResult != nil {
+		results = append(results, *osResult)
+	}
+	results = append(results, libResults...)
+
+	if options.ListAllPackages {
+		listPackages(results, imageDetail)
 	}
 
 	return results, imageDetail.OS, eosl, nil
 }
 
-func (s Scanner) scanOSPkg(target, osFamily, osName string, pkgs []ftypes.Package) (*report.Result, bool, error) {
+// scanned fills in the vulnerability details of the result of a target, so that callers can filter by severity,
+// and passes it to the handler if any
+func (s Scanner) scanned(result report.Result, handler func(report.Result)) {
+	s.vulnClient.FillInfo(result.Vulnerabilities, result.Type)
+	if handler != nil {
+		handler(result)
+	}
+}
+
+// ListsPackages implements scanner.PackageLister
+func (s Scanner) ListsPackages() bool {
+	return true
+}
+
+// listPackages lists the OS packages and the libraries of each application in the results
+func listPackages(results report.Results, imageDetail ftypes.ImageDetail) {
+	apps := map[string]ftypes.Application{}
+	for _, app := range imageDetail.Applications {
+		apps[app.FilePath] = app
+	}
+	for i, result := range results {
+		var pkgs []types.InstalledPackage
+		if result.Class == report.ClassOSPkgs {
+			for _, pkg := range imageDetail.Packages {
+				pkgs = append(pkgs, types.InstalledPackage{
+					Name:    pkg.Name,
+					Version: scannerUtils.FormatVersion(pkg),
+					Layer:   pkg.Layer,
+				})
+			}
+		} else {
+			for _, lib := range apps[result.Target].Libraries {
+				pkgs = append(pkgs, types.InstalledPackage{
+					Name:    lib.Library.Name,
+					Version: lib.Library.Version,
+					Layer:   lib.Layer,
+				})
+			}
+		}
+		results[i].Packages = pkgs
+	}
+}
+
+// mergeDistroless merges the layers of a distroless image without OS packages, whose OS scanning is skipped
+func (s Scanner) mergeDistroless(target, imageID string, layerIDs []string) (ftypes.ImageDetail, error) {
+	merger, ok := s.applier.(LayerMerger)
+	if !ok {
+		return ftypes.ImageDetail{}, xerrors.New("the applier can't merge the layers of distroless images")
+	}
+	imageDetail, err := merger.MergeLayers(imageID, layerIDs)
+	if err != nil {
+		return ftypes.ImageDetail{}, err
+	}
+	log.Logger.Infof("%s is a distroless image without a package manager, the OS packages are not scanned", target)
+	imageDetail.OS = nil
+	imageDetail.Packages = nil
+	return imageDetail, nil
+}
+
+// isWindows reports whether the configuration of the image is of a Windows image
+func (s Scanner) isWindows(imageID string) bool {
+	getter, ok := s.applier.(ImageOSGetter)
+	return ok && getter.ImageOS(imageID) == windows.Family
+}
+
+// mergeWindows merges the layers of a Windows image whose version or installed updates aren't detected, e.g.
+// without servicing packages, so that its libraries are still scanned while its OS scanning is skipped
+func (s Scanner) mergeWindows(target, imageID string, layerIDs []string) (ftypes.ImageDetail, error) {
+	merger, ok := s.applier.(LayerMerger)
+	if !ok {
+		return ftypes.ImageDetail{}, xerrors.New("the applier can't merge the layers of Windows images")
+	}
+	imageDetail, err := merger.MergeLayers(imageID, layerIDs)
+	if err != nil {
+		return ftypes.ImageDetail{}, err
+	}
+	log.Logger.Infof("The installed updates of the Windows image %s are unknown, only its libraries are scanned", target)
+	imageDetail.OS = nil
+	imageDetail.Packages = nil
+	return imageDetail, nil
+}
+
+func (s Scanner) scanOSPkg(target, osFamily, osName string, pkgs []ftypes.Package, shardSize int, rollup bool) (
+	*report.Result, bool, error) {
 	if osFamily == "" {
 		return nil, false, nil
 	}
-	vulns, eosl, err := s.ospkgDetector.Detect("", osFamily, osName, time.Time{}, pkgs)
+	vulns, eosl, err := s.detectOSPkgs(osFamily, osName, pkgs, shardSize, rollup)
 	if err == ospkgDetector.ErrUnsupportedOS {
-		return nil, false, nil
+		return &report.Result{
+			Target:       fmt.Sprintf("%s (%s %s)", target, osFamily, osName),
+			Type:         osFamily,
+			Class:        report.ClassOSPkgs,
+			Status:       report.StatusSkipped,
+			StatusReason: fmt.Sprintf("unsupported OS: %s %s", osFamily, osName),
+		}, false, nil
 	} else if err != nil {
 		return nil, false, xerrors.Errorf("failed vulnerability detection of OS packages: %w", err)
 	}
@@ -120,23 +307,47 @@ func (s Scanner) scanOSPkg(target, osFamily, osName string, pkgs []ftypes.Packag
 		Target:          imageDetail,
 		Vulnerabilities: vulns,
 		Type:            osFamily,
+		Class:           report.ClassOSPkgs,
+		Status:          report.StatusScanned,
 	}
 	return result, eosl, nil
 }
 
-func (s Scanner) scanLibrary(apps []ftypes.Application) (report.Results, error) {
-	var results report.Results
-	for _, app := range apps {
-		vulns, err := s.libDetector.Detect("", app.FilePath, time.Time{}, app.Libraries)
+// scanLibrary scans the applications with at most parallel of them at once, in turn for zero or one,
+// until the context is done. With partial, an application failing the detection is reported on its target
+// and the others are still scanned.
+func (s Scanner) scanLibrary(ctx context.Context, apps []ftypes.Application, aliases map[string][]string, shardSize, parallel int,
+	partial bool, handler func(report.Result)) (report.Results, error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make(report.Results, len(apps))
+	errs := make([]error, len(apps))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel && w < len(apps); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = s.scanApplication(ctx, apps[i], aliases, shardSize, partial)
+				if errs[i] == nil {
+					s.scanned(results[i], handler)
+				}
+			}
+		}()
+	}
+	for i := range apps {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// the error of the first application in order, as when they are scanned in turn
+	for _, err := range errs {
 		if err != nil {
-			return nil, xerrors.Errorf("failed vulnerability detection of libraries: %w", err)
+			return nil, err
 		}
-
-		results = append(results, report.Result{
-			Target:          app.FilePath,
-			Vulnerabilities: vulns,
-			Type:            app.Type,
-		})
 	}
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Target < results[j].Target
@@ -144,6 +355,99 @@ func (s Scanner) scanLibrary(apps []ftypes.Application) (report.Results, error)
 	return results, nil
 }
 
+func (s Scanner) scanApplication(ctx context.Context, app ftypes.Application, aliases map[string][]string, shardSize int,
+	partial bool) (report.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return report.Result{}, xerrors.Errorf("library scan stopped: %w", err)
+	}
+	vulns, err := s.detectLibraries(app, shardSize)
+	if err != nil && !partial {
+		return report.Result{}, xerrors.Errorf("failed vulnerability detection of libraries: %w", err)
+	} else if err != nil {
+		log.Logger.Warnf("failed vulnerability detection of libraries in %s: %s", app.FilePath, err)
+		return report.Result{
+			Target:       app.FilePath,
+			Type:         app.Type,
+			Class:        report.ClassLangPkgs,
+			Status:       report.StatusError,
+			StatusReason: err.Error(),
+		}, nil
+	}
+
+	if len(aliases) > 0 {
+		aliasVulns, err := s.detectAliases(app, vulns, aliases)
+		if err != nil {
+			return report.Result{}, xerrors.Errorf("failed vulnerability detection of library aliases: %w", err)
+		}
+		vulns = append(vulns, aliasVulns...)
+	}
+
+	return report.Result{
+		Target:          app.FilePath,
+		Vulnerabilities: vulns,
+		Type:            app.Type,
+		Class:           report.ClassLangPkgs,
+		Status:          report.StatusScanned,
+	}, nil
+}
+
+// detectAliases detects the vulnerabilities of the libraries without any under their former names.
+// The n-th former names of all the remaining libraries are detected together.
+func (s Scanner) detectAliases(app ftypes.Application, vulns []types.DetectedVulnerability, aliases map[string][]string) (
+	[]types.DetectedVulnerability, error) {
+	vulnerable := map[string]struct{}{}
+	for _, vuln := range vulns {
+		vulnerable[vuln.PkgName] = struct{}{}
+	}
+
+	var remaining []ftypes.LibraryInfo
+	for _, lib := range app.Libraries {
+		if _, ok := vulnerable[lib.Library.Name]; !ok && len(aliases[lib.Library.Name]) > 0 {
+			remaining = append(remaining, lib)
+		}
+	}
+
+	var aliasVulns []types.DetectedVulnerability
+	for i := 0; len(remaining) > 0; i++ {
+		// the former name of the library
+		names := map[string]string{}
+		var aliasLibs, next []ftypes.LibraryInfo
+		for _, lib := range remaining {
+			if i >= len(aliases[lib.Library.Name]) {
+				continue
+			}
+			aliasLib := lib
+			aliasLib.Library.Name = aliases[lib.Library.Name][i]
+			names[aliasLib.Library.Name] = lib.Library.Name
+			aliasLibs = append(aliasLibs, aliasLib)
+			next = append(next, lib)
+		}
+		if len(aliasLibs) == 0 {
+			break
+		}
+
+		detected, err := s.libDetector.Detect("", app.FilePath, time.Time{}, aliasLibs)
+		if err != nil {
+			return nil, err
+		}
+		matched := map[string]struct{}{}
+		for _, vuln := range detected {
+			vuln.MatchedName = vuln.PkgName
+			vuln.PkgName = names[vuln.PkgName]
+			matched[vuln.PkgName] = struct{}{}
+			aliasVulns = append(aliasVulns, vuln)
+		}
+
+		remaining = nil
+		for _, lib := range next {
+			if _, ok := matched[lib.Library.Name]; !ok {
+				remaining = append(remaining, lib)
+			}
+		}
+	}
+	return aliasVulns, nil
+}
+
 func mergePkgs(pkgs, pkgsFromCommands []ftypes.Package) []ftypes.Package {
 	// pkg has priority over pkgsFromCommands
 	uniqPkgs := map[string]struct{}{}
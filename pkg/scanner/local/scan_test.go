@@ -1,22 +1,40 @@
 package local
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aquasecurity/trivy-db/pkg/vulnsrc/vulnerability"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
 	ftypes "github.com/aquasecurity/fanal/types"
 	dtypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/db"
 	ospkgDetector "github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/utils"
+	vuln "github.com/aquasecurity/trivy/pkg/vulnerability"
 )
 
+func TestMain(m *testing.M) {
+	log.InitLogger(false, true)
+	os.Exit(m.Run())
+}
+
 func TestScanner_Scan(t *testing.T) {
 	type args struct {
 		target   string
@@ -150,7 +168,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: vulnerability.Alpine,
+					Type:   vulnerability.Alpine,
+					Class:  report.ClassOSPkgs,
+					Status: report.StatusScanned,
 				},
 				{
 					Target: "/app/Gemfile.lock",
@@ -165,7 +185,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: "bundler",
+					Type:   "bundler",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
 				},
 			},
 			wantOS: &ftypes.OS{
@@ -246,7 +268,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: "bundler",
+					Type:   "bundler",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
 				},
 			},
 			wantOS: &ftypes.OS{},
@@ -325,6 +349,13 @@ func TestScanner_Scan(t *testing.T) {
 				},
 			},
 			wantResults: report.Results{
+				{
+					Target:       "alpine:latest (fedora 27)",
+					Type:         "fedora",
+					Class:        report.ClassOSPkgs,
+					Status:       report.StatusSkipped,
+					StatusReason: "unsupported OS: fedora 27",
+				},
 				{
 					Target: "/app/Gemfile.lock",
 					Vulnerabilities: []types.DetectedVulnerability{
@@ -338,7 +369,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: "bundler",
+					Type:   "bundler",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
 				},
 			},
 			wantOS: &ftypes.OS{
@@ -463,7 +496,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: "bundler",
+					Type:   "bundler",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
 				},
 				{
 					Target: "/app/composer-lock.json",
@@ -478,7 +513,9 @@ func TestScanner_Scan(t *testing.T) {
 							},
 						},
 					},
-					Type: "composer",
+					Type:   "composer",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
 				},
 			},
 			wantOS: &ftypes.OS{
@@ -486,6 +523,104 @@ func TestScanner_Scan(t *testing.T) {
 				Name:   "3.11",
 			},
 		},
+		{
+			name: "happy path with a renamed library",
+			args: args{
+				target:   "python:3.8",
+				layerIDs: []string{"sha256:app"},
+				options: types.ScanOptions{
+					VulnType:   []string{"library"},
+					PkgAliases: map[string][]string{"pyjwt": {"python-jwt", "jwt"}},
+				},
+			},
+			applyLayersExpectation: ApplierApplyLayersExpectation{
+				Args: ApplierApplyLayersArgs{
+					LayerIDs: []string{"sha256:app"},
+				},
+				Returns: ApplierApplyLayersReturns{
+					Detail: ftypes.ImageDetail{
+						Applications: []ftypes.Application{
+							{
+								Type:     "pipenv",
+								FilePath: "/app/Pipfile.lock",
+								Libraries: []ftypes.LibraryInfo{
+									{
+										Library: dtypes.Library{Name: "pyjwt", Version: "1.5.0"},
+										Layer:   ftypes.Layer{DiffID: "sha256:app"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			libDetectExpectations: []LibraryDetectorDetectExpectation{
+				{
+					Args: LibraryDetectorDetectArgs{
+						FilePath: "/app/Pipfile.lock",
+						Pkgs: []ftypes.LibraryInfo{
+							{
+								Library: dtypes.Library{Name: "pyjwt", Version: "1.5.0"},
+								Layer:   ftypes.Layer{DiffID: "sha256:app"},
+							},
+						},
+					},
+					Returns: LibraryDetectorDetectReturns{},
+				},
+				{
+					Args: LibraryDetectorDetectArgs{
+						FilePath: "/app/Pipfile.lock",
+						Pkgs: []ftypes.LibraryInfo{
+							{
+								Library: dtypes.Library{Name: "python-jwt", Version: "1.5.0"},
+								Layer:   ftypes.Layer{DiffID: "sha256:app"},
+							},
+						},
+					},
+					Returns: LibraryDetectorDetectReturns{},
+				},
+				{
+					Args: LibraryDetectorDetectArgs{
+						FilePath: "/app/Pipfile.lock",
+						Pkgs: []ftypes.LibraryInfo{
+							{
+								Library: dtypes.Library{Name: "jwt", Version: "1.5.0"},
+								Layer:   ftypes.Layer{DiffID: "sha256:app"},
+							},
+						},
+					},
+					Returns: LibraryDetectorDetectReturns{
+						DetectedVulns: []types.DetectedVulnerability{
+							{
+								VulnerabilityID:  "CVE-2017-11424",
+								PkgName:          "jwt",
+								InstalledVersion: "1.5.0",
+								FixedVersion:     "1.5.1",
+								Layer:            ftypes.Layer{DiffID: "sha256:app"},
+							},
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "/app/Pipfile.lock",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID:  "CVE-2017-11424",
+							PkgName:          "pyjwt",
+							InstalledVersion: "1.5.0",
+							FixedVersion:     "1.5.1",
+							Layer:            ftypes.Layer{DiffID: "sha256:app"},
+							MatchedName:      "jwt",
+						},
+					},
+					Type:   "pipenv",
+					Class:  report.ClassLangPkgs,
+					Status: report.StatusScanned,
+				},
+			},
+		},
 		{
 			name: "sad path: ApplyLayers returns an error",
 			args: args{
@@ -617,6 +752,81 @@ func TestScanner_Scan(t *testing.T) {
 			},
 			wantErr: "failed to scan application libraries",
 		},
+		{
+			name: "libDetector.Detect returns an error with partial results",
+			args: args{
+				target:   "alpine:latest",
+				layerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+				options:  types.ScanOptions{VulnType: []string{"library"}, PartialResults: true},
+			},
+			applyLayersExpectation: ApplierApplyLayersExpectation{
+				Args: ApplierApplyLayersArgs{
+					LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+				},
+				Returns: ApplierApplyLayersReturns{
+					Detail: ftypes.ImageDetail{
+						OS: &ftypes.OS{
+							Family: "alpine",
+							Name:   "3.11",
+						},
+						Packages: []ftypes.Package{
+							{
+								Name:    "musl",
+								Version: "1.2.3",
+								Layer: ftypes.Layer{
+									DiffID: "sha256:ebf12965380b39889c99a9c02e82ba465f887b45975b6e389d42e9e6a3857888",
+								},
+							},
+						},
+						Applications: []ftypes.Application{
+							{
+								Type:     "bundler",
+								FilePath: "/app/Gemfile.lock",
+								Libraries: []ftypes.LibraryInfo{
+									{
+										Library: dtypes.Library{Name: "rails", Version: "6.0"},
+										Layer: ftypes.Layer{
+											DiffID: "sha256:9bdb2c849099a99c8ab35f6fd7469c623635e8f4479a0a5a3df61e22bae509f6",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			libDetectExpectations: []LibraryDetectorDetectExpectation{
+				{
+					Args: LibraryDetectorDetectArgs{
+						FilePath: "/app/Gemfile.lock",
+						Pkgs: []ftypes.LibraryInfo{
+							{
+								Library: dtypes.Library{Name: "rails", Version: "6.0"},
+								Layer: ftypes.Layer{
+									DiffID: "sha256:9bdb2c849099a99c8ab35f6fd7469c623635e8f4479a0a5a3df61e22bae509f6",
+								},
+							},
+						},
+					},
+					Returns: LibraryDetectorDetectReturns{
+						Err: errors.New("error"),
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target:       "/app/Gemfile.lock",
+					Type:         "bundler",
+					Class:        report.ClassLangPkgs,
+					Status:       report.StatusError,
+					StatusReason: "error",
+				},
+			},
+			wantOS: &ftypes.OS{
+				Family: "alpine",
+				Name:   "3.11",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -629,7 +839,12 @@ func TestScanner_Scan(t *testing.T) {
 			libDetector := new(MockLibraryDetector)
 			libDetector.ApplyDetectExpectations(tt.libDetectExpectations)
 
-			s := NewScanner(applier, ospkgDetector, libDetector)
+			vulnClient := new(vuln.MockOperation)
+			vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+				Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+			})
+
+			s := NewScanner(applier, ospkgDetector, libDetector, vulnClient)
 			gotResults, gotOS, gotEosl, err := s.Scan(tt.args.target, "", tt.args.layerIDs, tt.args.options)
 			if tt.wantErr != "" {
 				require.NotNil(t, err, tt.name)
@@ -649,3 +864,378 @@ func TestScanner_Scan(t *testing.T) {
 		})
 	}
 }
+
+func TestScanner_Scan_SkipDBUpdate(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "trivy-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	oldCacheDir := utils.CacheDir()
+	utils.SetCacheDir(cacheDir)
+	defer utils.SetCacheDir(oldCacheDir)
+
+	applier := new(MockApplier)
+	applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+		Args:    ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+		Returns: ApplierApplyLayersReturns{Detail: ftypes.ImageDetail{}},
+	})
+	s := NewScanner(applier, new(MockOspkgDetector), new(MockLibraryDetector), new(vuln.MockOperation))
+	options := types.ScanOptions{VulnType: []string{"library"}, SkipDBUpdate: true}
+
+	// no local DB
+	_, _, _, err = s.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base"}, options)
+	assert.Equal(t, db.ErrNoLocalDB, err)
+	applier.AssertNotCalled(t, "ApplyLayers", mock.Anything, mock.Anything)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, "db"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cacheDir, "db", "trivy.db"), nil, 0600))
+
+	_, _, _, err = s.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base"}, options)
+	require.NoError(t, err)
+	applier.AssertExpectations(t)
+}
+
+// orderedOspkgDetector waits for before to be closed and closes after when it detects
+type orderedOspkgDetector struct {
+	before, after chan struct{}
+	err           error
+}
+
+func (d orderedOspkgDetector) Detect(_, _, _ string, _ time.Time, pkgs []ftypes.Package) ([]types.DetectedVulnerability, bool, error) {
+	if d.before != nil {
+		<-d.before
+	}
+	if d.after != nil {
+		close(d.after)
+	}
+	return []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: pkgs[0].Name}}, false, d.err
+}
+
+// orderedLibraryDetector is orderedOspkgDetector for the library of the last application
+type orderedLibraryDetector struct {
+	before, after chan struct{}
+	last          string
+}
+
+func (d orderedLibraryDetector) Detect(_, filePath string, _ time.Time, pkgs []ftypes.LibraryInfo) ([]types.DetectedVulnerability, error) {
+	if filePath == d.last {
+		if d.before != nil {
+			<-d.before
+		}
+		if d.after != nil {
+			close(d.after)
+		}
+	}
+	return []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-10744", PkgName: pkgs[0].Library.Name}}, nil
+}
+
+func TestScanner_Scan_Concurrent(t *testing.T) {
+	detail := ftypes.ImageDetail{
+		OS:       &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+		Packages: []ftypes.Package{{Name: "openssl", Version: "1.1.1d-r3"}},
+		Applications: []ftypes.Application{
+			{Type: "npm", FilePath: "app/package-lock.json", Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}}}},
+			{Type: "bundler", FilePath: "app/Gemfile.lock", Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "rails", Version: "5.2.0"}}}},
+		},
+	}
+	wantResults := report.Results{
+		{
+			Target:          "alpine:3.11 (alpine 3.11.5)",
+			Type:            "alpine",
+			Class:           report.ClassOSPkgs,
+			Status:          report.StatusScanned,
+			Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"}},
+		},
+		{
+			Target:          "app/Gemfile.lock",
+			Type:            "bundler",
+			Class:           report.ClassLangPkgs,
+			Status:          report.StatusScanned,
+			Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-10744", PkgName: "rails"}},
+		},
+		{
+			Target:          "app/package-lock.json",
+			Type:            "npm",
+			Class:           report.ClassLangPkgs,
+			Status:          report.StatusScanned,
+			Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		libsFirst  bool
+		osErr      error
+		wantErr    string
+		wantResult report.Results
+	}{
+		{
+			name:       "OS packages scanned first",
+			wantResult: wantResults,
+		},
+		{
+			name:       "libraries scanned first",
+			libsFirst:  true,
+			wantResult: wantResults,
+		},
+		{
+			name:    "OS scan fails",
+			osErr:   errors.New("error"),
+			wantErr: "failed to scan OS packages",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := new(MockApplier)
+			applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+				Args:    ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+				Returns: ApplierApplyLayersReturns{Detail: detail},
+			})
+			vulnClient := new(vuln.MockOperation)
+			vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+				Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+			})
+
+			done := make(chan struct{})
+			osDetector := orderedOspkgDetector{err: tt.osErr}
+			libDetector := orderedLibraryDetector{last: "app/Gemfile.lock"}
+			if tt.libsFirst {
+				libDetector.after, osDetector.before = done, done
+			} else if tt.osErr == nil {
+				osDetector.after, libDetector.before = done, done
+			}
+
+			s := NewScanner(applier, osDetector, libDetector, vulnClient)
+			results, _, _, err := s.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base"},
+				types.ScanOptions{VulnType: []string{"os", "library"}})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResult, results)
+		})
+	}
+}
+
+func TestScanner_Scan_DebugLog(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	defer func(logger *zap.SugaredLogger) { log.Logger = logger }(log.Logger)
+	log.Logger = zap.New(core).Sugar()
+
+	applier := new(MockApplier)
+	applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+		Args: ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+		Returns: ApplierApplyLayersReturns{Detail: ftypes.ImageDetail{
+			Packages: []ftypes.Package{{Name: "musl", Version: "1.2.3"}, {Name: "openssl", Version: "1.1.1d-r3"}},
+			Applications: []ftypes.Application{{
+				Type:     "npm",
+				FilePath: "app/package-lock.json",
+				Libraries: []ftypes.LibraryInfo{
+					{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}},
+				},
+			}},
+		}},
+	})
+	s := NewScanner(applier, new(MockOspkgDetector), new(MockLibraryDetector), new(vuln.MockOperation))
+	_, _, _, err := s.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base"}, types.ScanOptions{})
+	require.NoError(t, err)
+
+	entries := logs.FilterMessage("Packages discovered").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zap.DebugLevel, entries[0].Level)
+	assert.Equal(t, map[string]interface{}{
+		"target": "alpine:3.11", "os_packages": int64(2), "applications": int64(1), "libraries": int64(1),
+	}, entries[0].ContextMap())
+}
+
+func TestScanner_Scan_ListAllPackages(t *testing.T) {
+	detail := ftypes.ImageDetail{
+		OS: &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+		Packages: []ftypes.Package{
+			{Name: "musl", Version: "1.1.24", Release: "r2", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+			{Name: "openssl", Version: "1.1.1d", Release: "r3", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+		},
+		Applications: []ftypes.Application{{
+			Type:     "npm",
+			FilePath: "app/package-lock.json",
+			Libraries: []ftypes.LibraryInfo{
+				{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}, Layer: ftypes.Layer{DiffID: "sha256:app"}},
+				{Library: dtypes.Library{Name: "react", Version: "16.13.1"}, Layer: ftypes.Layer{DiffID: "sha256:app"}},
+				// the version can't be parsed to detect the vulnerabilities, but the library is listed
+				{Library: dtypes.Library{Name: "internal", Version: "github:acme/internal#8f3d2a1"}, Layer: ftypes.Layer{DiffID: "sha256:app"}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name            string
+		listAllPackages bool
+		wantOSPkgs      []types.InstalledPackage
+		wantLibs        []types.InstalledPackage
+	}{
+		{
+			name:            "list all packages",
+			listAllPackages: true,
+			wantOSPkgs: []types.InstalledPackage{
+				{Name: "musl", Version: "1.1.24-r2", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+				{Name: "openssl", Version: "1.1.1d-r3", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+			},
+			wantLibs: []types.InstalledPackage{
+				{Name: "lodash", Version: "4.17.4", Layer: ftypes.Layer{DiffID: "sha256:app"}},
+				{Name: "react", Version: "16.13.1", Layer: ftypes.Layer{DiffID: "sha256:app"}},
+				{Name: "internal", Version: "github:acme/internal#8f3d2a1", Layer: ftypes.Layer{DiffID: "sha256:app"}},
+			},
+		},
+		{
+			name: "vulnerable packages only",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applier := new(MockApplier)
+			applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+				Args:    ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+				Returns: ApplierApplyLayersReturns{Detail: detail},
+			})
+			ospkgDetector := new(MockOspkgDetector)
+			ospkgDetector.ApplyDetectExpectation(OspkgDetectorDetectExpectation{
+				Args: OspkgDetectorDetectArgs{
+					ImageNameAnything: true, OsFamilyAnything: true, OsNameAnything: true, CreatedAnything: true, PkgsAnything: true,
+				},
+				Returns: OspkgDetectorDetectReturns{
+					DetectedVulns: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"}},
+				},
+			})
+			libDetector := new(MockLibraryDetector)
+			libDetector.ApplyDetectExpectation(LibraryDetectorDetectExpectation{
+				Args: LibraryDetectorDetectArgs{
+					ImageNameAnything: true, FilePathAnything: true, CreatedAnything: true, PkgsAnything: true,
+				},
+				Returns: LibraryDetectorDetectReturns{
+					DetectedVulns: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash"}},
+				},
+			})
+			vulnClient := new(vuln.MockOperation)
+			vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+				Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+			})
+
+			s := NewScanner(applier, ospkgDetector, libDetector, vulnClient)
+			results, _, _, err := s.Scan("alpine:3.11", "sha256:alpine", []string{"sha256:base", "sha256:app"},
+				types.ScanOptions{VulnType: []string{"os", "library"}, ListAllPackages: tt.listAllPackages})
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, tt.wantOSPkgs, results[0].Packages)
+			assert.Equal(t, tt.wantLibs, results[1].Packages)
+			assert.Len(t, results[0].Vulnerabilities, 1)
+			assert.Len(t, results[1].Vulnerabilities, 1)
+		})
+	}
+}
+
+func TestScanner_Scan_KnownLayers(t *testing.T) {
+	applier := new(MockApplier)
+	applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+		Args: ApplierApplyLayersArgs{ImageID: "sha256:app-image", LayerIDs: []string{"sha256:base", "sha256:app"}},
+		Returns: ApplierApplyLayersReturns{Detail: ftypes.ImageDetail{
+			OS: &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+			Packages: []ftypes.Package{
+				{Name: "musl", Version: "1.1.24-r2", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+				{Name: "nodejs", Version: "12.15.0-r1", Layer: ftypes.Layer{DiffID: "sha256:app"}},
+			},
+			Applications: []ftypes.Application{
+				{
+					Type:      "npm",
+					FilePath:  "usr/lib/node_modules/npm/package-lock.json",
+					Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "tar", Version: "4.4.8"}, Layer: ftypes.Layer{DiffID: "sha256:base"}}},
+				},
+				{
+					Type:      "npm",
+					FilePath:  "app/package-lock.json",
+					Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}, Layer: ftypes.Layer{DiffID: "sha256:app"}}},
+				},
+			},
+		}},
+	})
+	ospkgDetector := new(MockOspkgDetector)
+	ospkgDetector.ApplyDetectExpectation(OspkgDetectorDetectExpectation{
+		Args: OspkgDetectorDetectArgs{
+			ImageNameAnything: true, OsFamily: "alpine", OsName: "3.11.5", CreatedAnything: true,
+			Pkgs: []ftypes.Package{{Name: "nodejs", Version: "12.15.0-r1", Layer: ftypes.Layer{DiffID: "sha256:app"}}},
+		},
+	})
+	libDetector := new(MockLibraryDetector)
+	libDetector.ApplyDetectExpectation(LibraryDetectorDetectExpectation{
+		Args: LibraryDetectorDetectArgs{
+			ImageNameAnything: true, FilePath: "app/package-lock.json", CreatedAnything: true,
+			Pkgs: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}, Layer: ftypes.Layer{DiffID: "sha256:app"}}},
+		},
+	})
+	vulnClient := new(vuln.MockOperation)
+	vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+		Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+	})
+
+	s := NewScanner(applier, ospkgDetector, libDetector, vulnClient)
+	results, osFound, _, err := s.Scan("app:1.0", "sha256:app-image", []string{"sha256:app"},
+		types.ScanOptions{VulnType: []string{"os", "library"}, KnownLayers: []string{"sha256:base"}})
+	require.NoError(t, err)
+	assert.Equal(t, &ftypes.OS{Family: "alpine", Name: "3.11.5"}, osFound)
+	require.Len(t, results, 2)
+	assert.Equal(t, "app:1.0 (alpine 3.11.5)", results[0].Target)
+	assert.Equal(t, "app/package-lock.json", results[1].Target)
+	applier.AssertExpectations(t)
+	ospkgDetector.AssertExpectations(t)
+	libDetector.AssertExpectations(t)
+}
+
+func TestScanner_ScanStream(t *testing.T) {
+	applier := new(MockApplier)
+	applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+		Args: ApplierApplyLayersArgs{ImageID: "sha256:app-image", LayerIDs: []string{"sha256:app"}},
+		Returns: ApplierApplyLayersReturns{Detail: ftypes.ImageDetail{
+			OS:       &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+			Packages: []ftypes.Package{{Name: "musl", Version: "1.1.24-r2"}},
+			Applications: []ftypes.Application{
+				{Type: "npm", FilePath: "app/package-lock.json", Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash", Version: "4.17.4"}}}},
+				{Type: "yarn", FilePath: "web/yarn.lock", Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "jquery", Version: "3.3.9"}}}},
+			},
+		}},
+	})
+	ospkgDetector := new(MockOspkgDetector)
+	ospkgDetector.ApplyDetectExpectation(OspkgDetectorDetectExpectation{
+		Args: OspkgDetectorDetectArgs{
+			ImageNameAnything: true, OsFamily: "alpine", OsName: "3.11.5", CreatedAnything: true, PkgsAnything: true,
+		},
+	})
+	libDetector := new(MockLibraryDetector)
+	libDetector.ApplyDetectExpectation(LibraryDetectorDetectExpectation{
+		Args: LibraryDetectorDetectArgs{
+			ImageNameAnything: true, FilePathAnything: true, CreatedAnything: true, PkgsAnything: true,
+		},
+	})
+	vulnClient := new(vuln.MockOperation)
+	vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+		Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+	})
+
+	var mu sync.Mutex
+	var got []string
+	s := NewScanner(applier, ospkgDetector, libDetector, vulnClient)
+	results, _, _, err := s.ScanStream(context.Background(), "app:1.0", "sha256:app-image", []string{"sha256:app"},
+		types.ScanOptions{VulnType: []string{"os", "library"}}, func(result report.Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, result.Target)
+		})
+	require.NoError(t, err)
+
+	var want []string
+	for _, result := range results {
+		want = append(want, result.Target)
+	}
+	assert.ElementsMatch(t, want, got)
+	assert.ElementsMatch(t, []string{"app:1.0 (alpine 3.11.5)", "app/package-lock.json", "web/yarn.lock"}, got)
+}
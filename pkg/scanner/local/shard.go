@@ -0,0 +1,80 @@
+package local
+
+import (
+	"sync"
+	"time"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// detectShards calls detect concurrently on the shards [start, end) of at most shardSize of n packages
+// and merges the vulnerabilities in the order of the shards, as a single call would return them.
+// A shardSize of zero or not less than n calls detect once on all the packages.
+func detectShards(n, shardSize int, detect func(start, end int) ([]types.DetectedVulnerability, error)) (
+	[]types.DetectedVulnerability, error) {
+	if shardSize <= 0 || n <= shardSize {
+		return detect(0, n)
+	}
+
+	shards := (n + shardSize - 1) / shardSize
+	vulns := make([][]types.DetectedVulnerability, shards)
+	errs := make([]error, shards)
+
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++ {
+		start, end := i*shardSize, (i+1)*shardSize
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			vulns[i], errs[i] = detect(start, end)
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	var merged []types.DetectedVulnerability
+	for i := range vulns {
+		// the error of the first failed shard is returned as is, e.g. ospkgDetector.ErrUnsupportedOS
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		merged = append(merged, vulns[i]...)
+	}
+	return merged, nil
+}
+
+func (s Scanner) detectOSPkgs(osFamily, osName string, pkgs []ftypes.Package, shardSize int, rollup bool) (
+	[]types.DetectedVulnerability, bool, error) {
+	detect := s.ospkgDetector.Detect
+	if rollup {
+		if d, ok := s.ospkgDetector.(RollupOspkgDetector); ok {
+			detect = d.DetectRollup
+		} else {
+			log.Logger.Debug("The OS package detector doesn't match the advisories of the major OS version")
+		}
+	}
+
+	var mu sync.Mutex
+	var eosl bool
+	vulns, err := detectShards(len(pkgs), shardSize, func(start, end int) ([]types.DetectedVulnerability, error) {
+		vulns, shardEOSL, err := detect("", osFamily, osName, time.Time{}, pkgs[start:end])
+		mu.Lock()
+		eosl = eosl || shardEOSL
+		mu.Unlock()
+		return vulns, err
+	})
+	return vulns, eosl, err
+}
+
+func (s Scanner) detectLibraries(app ftypes.Application, shardSize int) ([]types.DetectedVulnerability, error) {
+	return detectShards(len(app.Libraries), shardSize, func(start, end int) ([]types.DetectedVulnerability, error) {
+		if typed, ok := s.libDetector.(TypedLibraryDetector); ok {
+			return typed.DetectType(app.Type, app.FilePath, app.Libraries[start:end])
+		}
+		return s.libDetector.Detect("", app.FilePath, time.Time{}, app.Libraries[start:end])
+	})
+}
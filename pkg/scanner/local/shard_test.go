@@ -0,0 +1,140 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	dtypes "github.com/aquasecurity/go-dep-parser/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+	vuln "github.com/aquasecurity/trivy/pkg/vulnerability"
+)
+
+// fakeDetector detects a vulnerability in every package
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(_, _, _ string, _ time.Time, pkgs []ftypes.Package) ([]types.DetectedVulnerability, bool, error) {
+	var vulns []types.DetectedVulnerability
+	for _, pkg := range pkgs {
+		vulns = append(vulns, types.DetectedVulnerability{VulnerabilityID: "CVE-" + pkg.Name, PkgName: pkg.Name})
+	}
+	return vulns, true, nil
+}
+
+type fakeLibraryDetector struct{}
+
+func (fakeLibraryDetector) Detect(_, _ string, _ time.Time, libs []ftypes.LibraryInfo) ([]types.DetectedVulnerability, error) {
+	var vulns []types.DetectedVulnerability
+	for _, lib := range libs {
+		vulns = append(vulns, types.DetectedVulnerability{VulnerabilityID: "CVE-" + lib.Library.Name, PkgName: lib.Library.Name})
+	}
+	return vulns, nil
+}
+
+func TestScanner_Scan_Sharded(t *testing.T) {
+	var detail ftypes.ImageDetail
+	detail.OS = &ftypes.OS{Family: "alpine", Name: "3.10.2"}
+	detail.Applications = []ftypes.Application{{Type: "npm", FilePath: "app/package-lock.json"}}
+	for i := 0; i < 10; i++ {
+		detail.Packages = append(detail.Packages, ftypes.Package{Name: fmt.Sprintf("pkg%d", i)})
+		detail.Applications[0].Libraries = append(detail.Applications[0].Libraries,
+			ftypes.LibraryInfo{Library: dtypes.Library{Name: fmt.Sprintf("lib%d", i)}})
+	}
+
+	scan := func(shardSize int) interface{} {
+		applier := new(MockApplier)
+		applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+			Args:    ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+			Returns: ApplierApplyLayersReturns{Detail: detail},
+		})
+		vulnClient := new(vuln.MockOperation)
+		vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+			Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+		})
+
+		s := NewScanner(applier, fakeDetector{}, fakeLibraryDetector{}, vulnClient)
+		results, osFound, eosl, err := s.Scan("alpine:3.10", "", nil, types.ScanOptions{
+			VulnType:  []string{"os", "library"},
+			ShardSize: shardSize,
+		})
+		require.NoError(t, err)
+		return []interface{}{results, osFound, eosl}
+	}
+
+	want := scan(0)
+	for _, shardSize := range []int{1, 3, 10} {
+		assert.Equal(t, want, scan(shardSize), "shard size %d", shardSize)
+	}
+}
+
+// barrierLibraryDetector blocks each call until n calls are in progress, failing after a second
+type barrierLibraryDetector struct {
+	fakeLibraryDetector
+	n       int
+	mu      *sync.Mutex
+	calls   *int
+	arrived chan struct{}
+}
+
+func (d barrierLibraryDetector) Detect(imageName, filePath string, created time.Time, libs []ftypes.LibraryInfo) (
+	[]types.DetectedVulnerability, error) {
+	d.mu.Lock()
+	*d.calls++
+	if *d.calls == d.n {
+		close(d.arrived)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.arrived:
+	case <-time.After(time.Second):
+		return nil, errors.New("the lock files are not scanned concurrently")
+	}
+	return d.fakeLibraryDetector.Detect(imageName, filePath, created, libs)
+}
+
+func TestScanner_Scan_Parallel(t *testing.T) {
+	var detail ftypes.ImageDetail
+	for _, filePath := range []string{"c/package-lock.json", "a/package-lock.json", "b/package-lock.json"} {
+		detail.Applications = append(detail.Applications, ftypes.Application{
+			Type:      "npm",
+			FilePath:  filePath,
+			Libraries: []ftypes.LibraryInfo{{Library: dtypes.Library{Name: "lodash"}}},
+		})
+	}
+
+	scan := func(libDetector LibraryDetector, parallel int) report.Results {
+		applier := new(MockApplier)
+		applier.ApplyApplyLayersExpectation(ApplierApplyLayersExpectation{
+			Args:    ApplierApplyLayersArgs{ImageIDAnything: true, LayerIDsAnything: true},
+			Returns: ApplierApplyLayersReturns{Detail: detail},
+		})
+		vulnClient := new(vuln.MockOperation)
+		vulnClient.ApplyFillInfoExpectation(vuln.FillInfoExpectation{
+			Args: vuln.FillInfoArgs{VulnsAnything: true, ReportTypeAnything: true},
+		})
+
+		s := NewScanner(applier, fakeDetector{}, libDetector, vulnClient)
+		results, _, _, err := s.Scan("alpine:3.10", "", nil, types.ScanOptions{
+			VulnType: []string{"library"},
+			Parallel: parallel,
+		})
+		require.NoError(t, err)
+		return results
+	}
+
+	want := scan(fakeLibraryDetector{}, 0)
+	require.Len(t, want, 3)
+	assert.Equal(t, "a/package-lock.json", want[0].Target)
+	assert.Equal(t, report.StatusScanned, want[0].Status)
+
+	got := scan(barrierLibraryDetector{n: 3, mu: &sync.Mutex{}, calls: new(int), arrived: make(chan struct{})}, 3)
+	assert.Equal(t, want, got)
+}
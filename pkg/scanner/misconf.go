@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aquasecurity/fanal/extractor"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/misconf"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// MisconfDetector is implemented by analyzers that can evaluate the policies against the extracted config files.
+// A nil scanner disables the detection.
+type MisconfDetector interface {
+	SetMisconfScanner(s *misconf.Scanner)
+	// Misconfigurations returns the misconfigurations found by the last analysis by file path
+	Misconfigurations() map[string][]types.Misconfiguration
+}
+
+// misconfExtractor evaluates the policies against the extracted config files when it has a scanner.
+// The config files it can't scan are reported as warnings, except the invalid YAML files.
+type misconfExtractor struct {
+	extractor.Extractor
+
+	mu       sync.Mutex
+	scanner  *misconf.Scanner
+	misconfs map[string][]types.Misconfiguration
+	warnings []string
+}
+
+func (e *misconfExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	layerDigest, files, opqDirs, whFiles, err := e.Extractor.ExtractLayerFiles(diffID, filenames)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	s := e.getScanner()
+	if s == nil {
+		return layerDigest, files, opqDirs, whFiles, nil
+	}
+	misconfs := map[string][]types.Misconfiguration{}
+	var warnings []string
+	for filename, content := range files {
+		if misconf.Type(filename) == "" {
+			continue
+		}
+		_, found, err := s.Scan(context.Background(), filename, content)
+		if xerrors.Is(err, misconf.ErrInvalidYAML) {
+			// the YAML files may be anything else
+			log.Logger.Debugf("Failed to scan %s for misconfigurations: %s", filename, err)
+			continue
+		} else if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s in the layer %s isn't scanned for misconfigurations: %s",
+				filename, diffID, err))
+			continue
+		}
+		for _, m := range found {
+			m.Layer = diffID
+			misconfs[filename] = append(misconfs[filename], m)
+		}
+	}
+	e.addMisconfs(misconfs)
+	sort.Strings(warnings)
+	e.addWarnings(warnings)
+	return layerDigest, files, opqDirs, whFiles, nil
+}
+
+func (e *misconfExtractor) setScanner(s *misconf.Scanner) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scanner = s
+}
+
+func (e *misconfExtractor) getScanner() *misconf.Scanner {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.scanner
+}
+
+func (e *misconfExtractor) addMisconfs(misconfs map[string][]types.Misconfiguration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.misconfs == nil {
+		e.misconfs = map[string][]types.Misconfiguration{}
+	}
+	for filename, m := range misconfs {
+		e.misconfs[filename] = append(e.misconfs[filename], m...)
+	}
+}
+
+// takeMisconfs returns the misconfigurations since the last call
+func (e *misconfExtractor) takeMisconfs() map[string][]types.Misconfiguration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	misconfs := e.misconfs
+	e.misconfs = nil
+	return misconfs
+}
+
+func (e *misconfExtractor) addWarnings(warnings []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.warnings = append(e.warnings, warnings...)
+}
+
+// takeWarnings returns the warnings since the last call
+func (e *misconfExtractor) takeWarnings() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	warnings := e.warnings
+	e.warnings = nil
+	return warnings
+}
+
+// setMisconfScanner enables the misconfiguration detection of the analyzer with SecurityCheckConfig, and disables it otherwise
+func (s Scanner) setMisconfScanner(ctx context.Context, options types.ScanOptions) error {
+	detector, ok := s.analyzer.(MisconfDetector)
+	if !hasSecurityCheck(options, types.SecurityCheckConfig) {
+		if ok {
+			detector.SetMisconfScanner(nil)
+		}
+		return nil
+	}
+	if !ok {
+		log.Logger.Warn("The analyzer doesn't detect misconfigurations")
+		return nil
+	}
+
+	misconfScanner, err := misconf.NewScanner(ctx, options.ConfigPolicies)
+	if err != nil {
+		return xerrors.Errorf("invalid config policies: %w", err)
+	}
+	misconf.Register()
+	detector.SetMisconfScanner(misconfScanner)
+	return nil
+}
+
+// misconfResults returns a result of the class report.ClassConfig per config file with misconfigurations, sorted by path.
+// Only the misconfigurations of the severities are kept unless there are none.
+func misconfResults(misconfs map[string][]types.Misconfiguration, severities []string) report.Results {
+	var paths []string
+	for filePath := range misconfs {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var results report.Results
+	for _, filePath := range paths {
+		var found []types.Misconfiguration
+		for _, m := range misconfs[filePath] {
+			if len(severities) == 0 || hasSeverity(severities, m.Severity) {
+				found = append(found, m)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		results = append(results, report.Result{
+			Target:            filePath,
+			Type:              misconf.Type(filePath),
+			Class:             report.ClassConfig,
+			Misconfigurations: found,
+		})
+	}
+	return results
+}
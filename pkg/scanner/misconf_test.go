@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	"github.com/aquasecurity/trivy/pkg/misconf"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestImageAnalyzer_Misconfigurations(t *testing.T) {
+	a := NewImageAnalyzer(analyzer.Config{Extractor: layeredExtractor{layers: map[string]extractor.FileMap{
+		"sha256:app": {
+			"app/Dockerfile":   []byte("FROM alpine:3.11\nUSER root\n"),
+			"app/values.yaml":  []byte("replicas: 1\n"),
+			"app/broken.yaml":  []byte("apiVersion: [\n"),
+			"app/package.json": []byte("{}"),
+			"infra/main.tf":    []byte("resource {\n"),
+		},
+	}}})
+
+	// disabled by default
+	_, _, _, _, err := a.Extractor.ExtractLayerFiles("sha256:app", nil)
+	require.NoError(t, err)
+	assert.Empty(t, a.Misconfigurations())
+	assert.Empty(t, a.Warnings())
+
+	s, err := misconf.NewScanner(context.Background(), nil)
+	require.NoError(t, err)
+	a.SetMisconfScanner(s)
+	_, _, _, _, err = a.Extractor.ExtractLayerFiles("sha256:app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]types.Misconfiguration{
+		// the YAML files without manifests and the invalid files are skipped
+		"app/Dockerfile": {
+			{
+				ID:       "DS002",
+				Title:    "Image user should not be 'root'",
+				Severity: "HIGH",
+				Message:  "The last USER command of the last stage switches to 'root'",
+				Layer:    "sha256:app",
+			},
+		},
+	}, a.Misconfigurations())
+	assert.Empty(t, a.Misconfigurations(), "misconfigurations are returned once")
+
+	// the invalid config files are in the warnings, except the YAML files
+	warnings := a.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "infra/main.tf in the layer sha256:app isn't scanned for misconfigurations: ")
+}
+
+func TestMisconfResults(t *testing.T) {
+	misconfs := map[string][]types.Misconfiguration{
+		"infra/main.tf": {
+			{ID: "TF002", Severity: "CRITICAL"},
+		},
+		"Dockerfile": {
+			{ID: "DS001", Severity: "MEDIUM"},
+			{ID: "DS002", Severity: "HIGH"},
+		},
+	}
+
+	t.Run("all severities", func(t *testing.T) {
+		assert.Equal(t, report.Results{
+			{
+				Target: "Dockerfile",
+				Type:   misconf.TypeDockerfile,
+				Class:  report.ClassConfig,
+				Misconfigurations: []types.Misconfiguration{
+					{ID: "DS001", Severity: "MEDIUM"},
+					{ID: "DS002", Severity: "HIGH"},
+				},
+			},
+			{
+				Target: "infra/main.tf",
+				Type:   misconf.TypeTerraform,
+				Class:  report.ClassConfig,
+				Misconfigurations: []types.Misconfiguration{
+					{ID: "TF002", Severity: "CRITICAL"},
+				},
+			},
+		}, misconfResults(misconfs, nil))
+	})
+
+	t.Run("severities", func(t *testing.T) {
+		got := misconfResults(misconfs, []string{"HIGH"})
+		require.Len(t, got, 1)
+		assert.Equal(t, []types.Misconfiguration{{ID: "DS002", Severity: "HIGH"}}, got[0].Misconfigurations)
+	})
+}
@@ -21,6 +21,7 @@ type ScanArgs struct {
 	LayerIDsAnything bool
 	Options          types.ScanOptions
 	OptionsAnything  bool
+	OptionsMatchedBy func(types.ScanOptions) bool
 }
 
 type ScanReturns struct {
@@ -33,6 +34,7 @@ type ScanReturns struct {
 type ScanExpectation struct {
 	Args    ScanArgs
 	Returns ScanReturns
+	Times   int
 }
 
 func (_m *MockDriver) ApplyScanExpectation(e ScanExpectation) {
@@ -54,10 +56,15 @@ func (_m *MockDriver) ApplyScanExpectation(e ScanExpectation) {
 	}
 	if e.Args.OptionsAnything {
 		args = append(args, mock.Anything)
+	} else if e.Args.OptionsMatchedBy != nil {
+		args = append(args, mock.MatchedBy(e.Args.OptionsMatchedBy))
 	} else {
 		args = append(args, e.Args.Options)
 	}
-	_m.On("Scan", args...).Return(e.Returns.Results, e.Returns.OsFound, e.Returns.Eols, e.Returns.Err)
+	call := _m.On("Scan", args...).Return(e.Returns.Results, e.Returns.OsFound, e.Returns.Eols, e.Returns.Err)
+	if e.Times > 0 {
+		call.Times(e.Times)
+	}
 }
 
 func (_m *MockDriver) ApplyScanExpectations(expectations []ScanExpectation) {
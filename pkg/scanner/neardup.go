@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// DefaultNearDuplicateSimilarity is the title similarity above which two advisories of a package are near-duplicates
+const DefaultNearDuplicateSimilarity = 0.8
+
+// aggregateNearDuplicates reports once the advisories of the same flaw filed under different IDs by different databases.
+// Two findings of a result are near-duplicates when they affect the same installed version of the package,
+// their fixed versions don't conflict, and the similarity of their titles (the Jaccard index of their words)
+// is at least similarity. Untitled findings and two CVEs are never merged.
+// The primary finding is the CVE of a cluster, or else the first one, and lists the others in RelatedVulnerabilityIDs.
+func aggregateNearDuplicates(results report.Results, similarity float64) report.Results {
+	if similarity == 0 {
+		similarity = DefaultNearDuplicateSimilarity
+	}
+
+	type cluster struct {
+		primary int // index in vulns
+		words   map[string]struct{}
+		hasCVE  bool
+	}
+	for i, result := range results {
+		var vulns []types.DetectedVulnerability
+		var clusters []*cluster
+		for _, vuln := range result.Vulnerabilities {
+			words := titleWords(vuln.Title)
+			isCVE := strings.HasPrefix(vuln.VulnerabilityID, "CVE-")
+
+			var found *cluster
+			for _, c := range clusters {
+				primary := vulns[c.primary]
+				if len(words) == 0 || (isCVE && c.hasCVE) ||
+					primary.PkgName != vuln.PkgName || primary.InstalledVersion != vuln.InstalledVersion ||
+					(primary.FixedVersion != "" && vuln.FixedVersion != "" && primary.FixedVersion != vuln.FixedVersion) {
+					continue
+				}
+				if jaccard(c.words, words) >= similarity {
+					found = c
+					break
+				}
+			}
+
+			if found == nil {
+				clusters = append(clusters, &cluster{primary: len(vulns), words: words, hasCVE: isCVE})
+				vulns = append(vulns, vuln)
+				continue
+			}
+
+			primary := &vulns[found.primary]
+			if isCVE {
+				// the CVE becomes the primary finding
+				vuln.RelatedVulnerabilityIDs = append([]string{primary.VulnerabilityID}, primary.RelatedVulnerabilityIDs...)
+				*primary = vuln
+				found.hasCVE = true
+			} else {
+				primary.RelatedVulnerabilityIDs = append(primary.RelatedVulnerabilityIDs, vuln.VulnerabilityID)
+			}
+		}
+		results[i].Vulnerabilities = vulns
+	}
+	return results
+}
+
+func titleWords(title string) map[string]struct{} {
+	words := map[string]struct{}{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = struct{}{}
+	}
+	return words
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for word := range a {
+		if _, ok := b[word]; ok {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestAggregateNearDuplicates(t *testing.T) {
+	vuln := func(id, pkgName, fixedVersion, title string) types.DetectedVulnerability {
+		return types.DetectedVulnerability{
+			VulnerabilityID:  id,
+			PkgName:          pkgName,
+			InstalledVersion: "4.17.4",
+			FixedVersion:     fixedVersion,
+			Vulnerability:    dbTypes.Vulnerability{Title: title},
+		}
+	}
+	related := func(v types.DetectedVulnerability, ids ...string) types.DetectedVulnerability {
+		v.RelatedVulnerabilityIDs = ids
+		return v
+	}
+
+	const title = "Prototype pollution in lodash defaultsDeep"
+	tests := []struct {
+		name       string
+		similarity float64
+		vulns      []types.DetectedVulnerability
+		want       []types.DetectedVulnerability
+	}{
+		{
+			name: "near-duplicates with the CVE first",
+			vulns: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-516", "lodash", "", "Prototype Pollution in lodash (defaultsDeep)"),
+			},
+			want: []types.DetectedVulnerability{
+				related(vuln("CVE-2019-10744", "lodash", "4.17.12", title), "NSWG-ECO-516"),
+			},
+		},
+		{
+			name: "the CVE becomes the primary",
+			vulns: []types.DetectedVulnerability{
+				vuln("NSWG-ECO-516", "lodash", "", "Prototype Pollution in lodash (defaultsDeep)"),
+				vuln("GHSA-jf85-cpcp-j695", "lodash", "4.17.12", "prototype pollution in lodash defaultsdeep"),
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+			},
+			want: []types.DetectedVulnerability{
+				related(vuln("CVE-2019-10744", "lodash", "4.17.12", title), "NSWG-ECO-516", "GHSA-jf85-cpcp-j695"),
+			},
+		},
+		{
+			name: "dissimilar titles",
+			vulns: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-517", "lodash", "4.17.12", "Regular expression denial of service in lodash"),
+			},
+			want: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-517", "lodash", "4.17.12", "Regular expression denial of service in lodash"),
+			},
+		},
+		{
+			name: "two CVEs",
+			vulns: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("CVE-2020-8203", "lodash", "4.17.12", title),
+			},
+			want: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("CVE-2020-8203", "lodash", "4.17.12", title),
+			},
+		},
+		{
+			name: "different packages and conflicting fixed versions",
+			vulns: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-516", "lodash.merge", "", title),
+				vuln("GHSA-jf85-cpcp-j695", "lodash", "4.17.11", title),
+			},
+			want: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-516", "lodash.merge", "", title),
+				vuln("GHSA-jf85-cpcp-j695", "lodash", "4.17.11", title),
+			},
+		},
+		{
+			name:       "custom similarity",
+			similarity: 0.5,
+			vulns: []types.DetectedVulnerability{
+				vuln("CVE-2019-10744", "lodash", "4.17.12", title),
+				vuln("NSWG-ECO-516", "lodash", "", "Prototype pollution in lodash"),
+			},
+			want: []types.DetectedVulnerability{
+				related(vuln("CVE-2019-10744", "lodash", "4.17.12", title), "NSWG-ECO-516"),
+			},
+		},
+		{
+			name: "untitled",
+			vulns: []types.DetectedVulnerability{
+				vuln("NSWG-ECO-516", "lodash", "", ""),
+				vuln("GHSA-jf85-cpcp-j695", "lodash", "", ""),
+			},
+			want: []types.DetectedVulnerability{
+				vuln("NSWG-ECO-516", "lodash", "", ""),
+				vuln("GHSA-jf85-cpcp-j695", "lodash", "", ""),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := report.Results{{Target: "package-lock.json", Vulnerabilities: tt.vulns}}
+			got := aggregateNearDuplicates(results, tt.similarity)
+			assert.Equal(t, tt.want, got[0].Vulnerabilities)
+		})
+	}
+}
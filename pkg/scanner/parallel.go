@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/aquasecurity/fanal/extractor"
+)
+
+// LayerLimiter is implemented by analyzers that can bound the number of layers extracted concurrently.
+// Zero or a negative number doesn't bound them.
+type LayerLimiter interface {
+	SetParallel(n int)
+}
+
+// parallelExtractor extracts at most cap(sem) layers at once, as the analysis extracts all the missing layers
+// concurrently, e.g. to bound the memory for an image with many layers. A nil sem doesn't bound them.
+type parallelExtractor struct {
+	extractor.Extractor
+
+	mu  sync.Mutex
+	sem chan struct{}
+}
+
+func (e *parallelExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	if sem := e.getSem(); sem != nil {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+	}
+	return e.Extractor.ExtractLayerFiles(diffID, filenames)
+}
+
+// setParallel replaces the bound, the extractions in progress release the previous one
+func (e *parallelExtractor) setParallel(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case n <= 0:
+		e.sem = nil
+	case cap(e.sem) != n:
+		e.sem = make(chan struct{}, n)
+	}
+}
+
+func (e *parallelExtractor) getSem() chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.sem
+}
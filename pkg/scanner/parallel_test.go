@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/extractor"
+)
+
+// slowExtractor records the largest number of layers extracted at once
+type slowExtractor struct {
+	extractor.Extractor
+
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (e *slowExtractor) ExtractLayerFiles(diffID string, _ []string) (string, extractor.FileMap, []string, []string, error) {
+	e.mu.Lock()
+	e.current++
+	if e.current > e.max {
+		e.max = e.current
+	}
+	e.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	e.mu.Lock()
+	e.current--
+	e.mu.Unlock()
+	return diffID, extractor.FileMap{}, nil, nil, nil
+}
+
+func TestParallelExtractor(t *testing.T) {
+	tests := []struct {
+		name     string
+		parallel int
+	}{
+		{name: "one at a time", parallel: 1},
+		{name: "bounded", parallel: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext := &slowExtractor{}
+			e := &parallelExtractor{Extractor: ext}
+			e.setParallel(tt.parallel)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 6; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, _, _, err := e.ExtractLayerFiles("sha256:layer", nil)
+					require.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+			assert.LessOrEqual(t, ext.max, tt.parallel)
+		})
+	}
+
+	t.Run("unbounded", func(t *testing.T) {
+		e := &parallelExtractor{Extractor: &slowExtractor{}}
+		e.setParallel(2)
+		e.setParallel(0)
+		assert.Nil(t, e.getSem())
+	})
+}
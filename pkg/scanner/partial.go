@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+	"golang.org/x/xerrors"
+)
+
+// PartialScanError is returned with the results of the vulnerability types scanned successfully
+// when the scan of the others failed
+type PartialScanError struct {
+	// Errors are the errors per failed vulnerability type, e.g. "library"
+	Errors map[string]error
+}
+
+func (e *PartialScanError) Error() string {
+	var vulnTypes []string
+	for vulnType := range e.Errors {
+		vulnTypes = append(vulnTypes, vulnType)
+	}
+	sort.Strings(vulnTypes)
+
+	var msgs []string
+	for _, vulnType := range vulnTypes {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", vulnType, e.Errors[vulnType]))
+	}
+	return "partial scan, failed vulnerability types: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether the scan of a vulnerability type failed with the target, e.g. context.DeadlineExceeded
+func (e *PartialScanError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if xerrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanVulnTypes runs the driver scan of all the vulnerability types, then each type separately when it fails.
+// The results of the types scanned successfully are returned with a PartialScanError, the error of the scan
+// of all the types when none is. With options.PartialResults, the types are scanned separately from the start
+// so that the results of the types scanned before the context is done are kept.
+func (s Scanner) scanVulnTypes(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions) (
+	report.Results, *ftypes.OS, bool, error) {
+	if options.PartialResults && len(options.VulnType) > 0 {
+		return s.scanEachVulnType(ctx, imageInfo, options, nil)
+	}
+	results, osFound, eosl, err := s.scanWithRetries(ctx, imageInfo, options)
+	if err == nil || len(options.VulnType) < 2 || ctx.Err() != nil {
+		return results, osFound, eosl, err
+	}
+	log.Logger.Warnf("Scan failed, scanning each vulnerability type separately: %s", err)
+	return s.scanEachVulnType(ctx, imageInfo, options, err)
+}
+
+// scanEachVulnType scans the vulnerability types one by one. A done context fails the scan, unless
+// options.PartialResults makes it the error of the types not scanned yet.
+func (s Scanner) scanEachVulnType(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions, err error) (
+	report.Results, *ftypes.OS, bool, error) {
+	partialErr := &PartialScanError{Errors: map[string]error{}}
+	var results report.Results
+	var osFound *ftypes.OS
+	var eosl, scanned bool
+	for _, vulnType := range options.VulnType {
+		typeOptions := options
+		typeOptions.VulnType = []string{vulnType}
+		var typeResults report.Results
+		var typeOS *ftypes.OS
+		var typeEOSL bool
+		typeErr := ctx.Err()
+		if typeErr == nil {
+			typeResults, typeOS, typeEOSL, typeErr = s.scanWithRetries(ctx, imageInfo, typeOptions)
+		}
+		if ctx.Err() != nil && !options.PartialResults {
+			return nil, nil, false, ctx.Err()
+		}
+		if typeErr != nil {
+			partialErr.Errors[vulnType] = typeErr
+			continue
+		}
+		scanned = true
+		results = append(results, typeResults...)
+		if typeOS != nil {
+			osFound = typeOS
+		}
+		eosl = eosl || typeEOSL
+	}
+	if !scanned && ctx.Err() == nil {
+		if err == nil {
+			err = partialErr.Errors[options.VulnType[0]]
+		}
+		return nil, nil, false, err
+	}
+	return results, osFound, eosl, partialErr
+}
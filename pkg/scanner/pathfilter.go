@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/analyzer/library"
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"golang.org/x/xerrors"
+)
+
+// lockFiles are the names of the lock files of the library analyzers, to which the files of the custom patterns are mapped
+var lockFiles = map[string]string{
+	library.Bundler:  "Gemfile.lock",
+	library.Cargo:    "Cargo.lock",
+	library.Composer: "composer.lock",
+	library.Npm:      "package-lock.json",
+	library.Pipenv:   "Pipfile.lock",
+	library.Poetry:   "poetry.lock",
+	library.Yarn:     "yarn.lock",
+}
+
+// PathFilterSetter is implemented by analyzers that can skip directories and files, and analyze the files of
+// custom patterns with the library analyzers
+type PathFilterSetter interface {
+	SetPathFilter(filter PathFilter)
+}
+
+// PathFilter skips the files in the directories of SkipDirs and the files of SkipFiles, and analyzes the files of
+// FilePatterns with the library analyzers. The patterns are path.Match patterns; those without a slash match
+// the names at any depth, e.g. node_modules, and the others the paths from the root, e.g. usr/src/app/test.
+type PathFilter struct {
+	SkipDirs     []string
+	SkipFiles    []string
+	FilePatterns []FilePattern
+}
+
+// FilePattern maps the file names matching the pattern to a library analyzer, e.g. pipenv for Pipfile.*.lock
+type FilePattern struct {
+	Analyzer string
+	Pattern  string
+}
+
+// ParseFilePatterns parses the patterns as analyzer:pattern, e.g. "npm:*-lock.json"
+func ParseFilePatterns(patterns []string) ([]FilePattern, error) {
+	var filePatterns []FilePattern
+	for _, p := range patterns {
+		ss := strings.SplitN(p, ":", 2)
+		if len(ss) != 2 || ss[1] == "" {
+			return nil, xerrors.Errorf("invalid file pattern %q, expected analyzer:pattern", p)
+		}
+		if _, ok := lockFiles[ss[0]]; !ok {
+			return nil, xerrors.Errorf("unknown analyzer %q of the file pattern %q, expected one of %s", ss[0], p,
+				strings.Join(analyzerNames(), ", "))
+		}
+		if strings.Contains(ss[1], "/") {
+			return nil, xerrors.Errorf("invalid file pattern %q: the pattern matches the file names, without a slash", p)
+		}
+		if _, err := path.Match(ss[1], ""); err != nil {
+			return nil, xerrors.Errorf("invalid file pattern %q: %w", p, err)
+		}
+		filePatterns = append(filePatterns, FilePattern{Analyzer: ss[0], Pattern: ss[1]})
+	}
+	return filePatterns, nil
+}
+
+func analyzerNames() []string {
+	var names []string
+	for name := range lockFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f PathFilter) isEmpty() bool {
+	return len(f.SkipDirs) == 0 && len(f.SkipFiles) == 0 && len(f.FilePatterns) == 0
+}
+
+// skipped reports whether the file, e.g. usr/src/app/test/package-lock.json, is skipped
+func (f PathFilter) skipped(filePath string) bool {
+	filePath = strings.TrimPrefix(filePath, "/")
+	for _, pattern := range f.SkipFiles {
+		if matchPath(pattern, filePath) {
+			return true
+		}
+	}
+	dirs := strings.Split(filePath, "/")
+	for i := 1; i < len(dirs); i++ {
+		dir := strings.Join(dirs[:i], "/")
+		for _, pattern := range f.SkipDirs {
+			if matchPath(pattern, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPath matches the pattern without a slash against the name and the others against the path
+func matchPath(pattern, filePath string) bool {
+	pattern = strings.Trim(strings.TrimPrefix(pattern, "./"), "/")
+	if !strings.Contains(pattern, "/") {
+		filePath = path.Base(filePath)
+	}
+	ok, _ := path.Match(pattern, filePath)
+	return ok
+}
+
+// alias returns the path under which the library analyzer of the pattern matching the file reads it,
+// e.g. app/Pipfile.prod.lock/Pipfile.lock for app/Pipfile.prod.lock
+func (f PathFilter) alias(filePath string) (string, bool) {
+	name := path.Base(filePath)
+	for _, p := range f.FilePatterns {
+		lockFile := lockFiles[p.Analyzer]
+		if name == lockFile {
+			return "", false
+		}
+		if ok, _ := path.Match(p.Pattern, name); ok {
+			return filePath + "/" + lockFile, true
+		}
+	}
+	return "", false
+}
+
+// original returns the path of the file read under its alias by the library analyzer
+func (f PathFilter) original(app ftypes.Application) string {
+	lockFile, ok := lockFiles[app.Type]
+	if !ok || !strings.HasSuffix(app.FilePath, "/"+lockFile) {
+		return app.FilePath
+	}
+	filePath := strings.TrimSuffix(app.FilePath, "/"+lockFile)
+	for _, p := range f.FilePatterns {
+		if p.Analyzer != app.Type {
+			continue
+		}
+		if ok, _ := path.Match(p.Pattern, path.Base(filePath)); ok {
+			return filePath
+		}
+	}
+	return app.FilePath
+}
+
+// pathFilterExtractor drops the skipped files before they are analyzed, and extracts the files of the custom patterns
+// under the names of the lock files of their analyzers, their original paths being restored by pathFilterCache
+type pathFilterExtractor struct {
+	extractor.Extractor
+
+	mu     sync.Mutex
+	filter PathFilter
+}
+
+func (e *pathFilterExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	filter := e.getFilter()
+	for _, p := range filter.FilePatterns {
+		filenames = append(filenames, p.Pattern)
+	}
+	layerDigest, files, opqDirs, whFiles, err := e.Extractor.ExtractLayerFiles(diffID, filenames)
+	if err != nil || filter.isEmpty() {
+		return layerDigest, files, opqDirs, whFiles, err
+	}
+
+	filtered := extractor.FileMap{}
+	for filename, content := range files {
+		if filter.skipped(filename) {
+			continue
+		}
+		if alias, ok := filter.alias(filename); ok {
+			filename = alias
+		}
+		filtered[filename] = content
+	}
+	return layerDigest, filtered, opqDirs, whFiles, nil
+}
+
+func (e *pathFilterExtractor) setFilter(filter PathFilter) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.filter = filter
+}
+
+func (e *pathFilterExtractor) getFilter() PathFilter {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.filter
+}
+
+// pathFilterCache restores the paths of the applications of the files of the custom patterns
+type pathFilterCache struct {
+	cache.ImageCache
+	paths *pathFilterExtractor
+}
+
+func (c pathFilterCache) PutLayer(diffID string, layerInfo ftypes.LayerInfo) error {
+	filter := c.paths.getFilter()
+	if len(filter.FilePatterns) > 0 {
+		apps := make([]ftypes.Application, len(layerInfo.Applications))
+		for i, app := range layerInfo.Applications {
+			app.FilePath = filter.original(app)
+			apps[i] = app
+		}
+		layerInfo.Applications = apps
+	}
+	return c.ImageCache.PutLayer(diffID, layerInfo)
+}
@@ -0,0 +1,160 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+)
+
+func TestParseFilePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []FilePattern
+		wantErr  string
+	}{
+		{
+			name:     "happy path",
+			patterns: []string{"pipenv:Pipfile.*.lock", "npm:*-lock.json"},
+			want: []FilePattern{
+				{Analyzer: "pipenv", Pattern: "Pipfile.*.lock"},
+				{Analyzer: "npm", Pattern: "*-lock.json"},
+			},
+		},
+		{
+			name:     "sad: missing pattern",
+			patterns: []string{"npm"},
+			wantErr:  `invalid file pattern "npm", expected analyzer:pattern`,
+		},
+		{
+			name:     "sad: unknown analyzer",
+			patterns: []string{"maven:*.pom"},
+			wantErr:  `unknown analyzer "maven" of the file pattern "maven:*.pom", expected one of bundler, cargo, composer, npm, pipenv, poetry, yarn`,
+		},
+		{
+			name:     "sad: path",
+			patterns: []string{"yarn:app/*.lock"},
+			wantErr:  `invalid file pattern "yarn:app/*.lock": the pattern matches the file names, without a slash`,
+		},
+		{
+			name:     "sad: malformed pattern",
+			patterns: []string{"cargo:[Cargo.lock"},
+			wantErr:  `invalid file pattern "cargo:[Cargo.lock"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilePatterns(tt.patterns)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestImageAnalyzer_PathFilter(t *testing.T) {
+	files := extractor.FileMap{
+		"etc/alpine-release":                        []byte("3.10.2"),
+		"app/package-lock.json":                     []byte("{}"),
+		"app/node_modules/a/package-lock.json":      []byte("{}"),
+		"app/test/fixtures/package-lock.json":       []byte("{}"),
+		"usr/src/app/test/Gemfile.lock":             []byte(""),
+		"srv/test/Gemfile.lock":                     []byte(""),
+		"srv/Pipfile.prod.lock":                     []byte("{}"),
+		"srv/Pipfile.lock":                          []byte("{}"),
+		"srv/vendor/github.com/a/b/testdata/go.sum": []byte(""),
+	}
+
+	tests := []struct {
+		name   string
+		filter PathFilter
+		want   []string
+	}{
+		{
+			name: "no filter",
+			want: []string{
+				"etc/alpine-release", "app/package-lock.json", "app/node_modules/a/package-lock.json",
+				"app/test/fixtures/package-lock.json", "usr/src/app/test/Gemfile.lock", "srv/test/Gemfile.lock",
+				"srv/Pipfile.prod.lock", "srv/Pipfile.lock", "srv/vendor/github.com/a/b/testdata/go.sum",
+			},
+		},
+		{
+			name: "directory names at any depth and paths from the root",
+			filter: PathFilter{
+				SkipDirs: []string{"node_modules", "vendor", "/usr/src/app/test/", "./app/*/fixtures"},
+			},
+			want: []string{
+				"etc/alpine-release", "app/package-lock.json", "srv/test/Gemfile.lock", "srv/Pipfile.prod.lock",
+				"srv/Pipfile.lock",
+			},
+		},
+		{
+			name: "files",
+			filter: PathFilter{
+				SkipFiles: []string{"Gemfile.lock", "app/node_modules/*/package-lock.json", "*.sum"},
+			},
+			want: []string{
+				"etc/alpine-release", "app/package-lock.json", "app/test/fixtures/package-lock.json",
+				"srv/Pipfile.prod.lock", "srv/Pipfile.lock",
+			},
+		},
+		{
+			name: "file patterns",
+			filter: PathFilter{
+				SkipDirs:     []string{"app", "usr", "test", "vendor"},
+				FilePatterns: []FilePattern{{Analyzer: "pipenv", Pattern: "Pipfile.*.lock"}},
+			},
+			want: []string{"etc/alpine-release", "srv/Pipfile.prod.lock/Pipfile.lock", "srv/Pipfile.lock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewImageAnalyzer(analyzer.Config{Extractor: layeredExtractor{layers: map[string]extractor.FileMap{
+				"sha256:base": files,
+			}}})
+			a.SetPathFilter(tt.filter)
+
+			_, got, _, _, err := a.Extractor.ExtractLayerFiles("sha256:base", nil)
+			require.NoError(t, err)
+
+			var names []string
+			for filename := range got {
+				names = append(names, filename)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+		})
+	}
+}
+
+func TestPathFilterCache_PutLayer(t *testing.T) {
+	memory := cache.NewMemoryCache()
+	a := NewImageAnalyzer(analyzer.Config{Extractor: layeredExtractor{}, Cache: memory})
+	a.SetPathFilter(PathFilter{FilePatterns: []FilePattern{{Analyzer: "pipenv", Pattern: "Pipfile.*.lock"}}})
+
+	require.NoError(t, a.Cache.PutLayer("sha256:base", ftypes.LayerInfo{
+		Applications: []ftypes.Application{
+			{Type: "pipenv", FilePath: "srv/Pipfile.prod.lock/Pipfile.lock"},
+			{Type: "pipenv", FilePath: "srv/Pipfile.lock"},
+			// another analyzer than the one of the pattern
+			{Type: "poetry", FilePath: "srv/Pipfile.dev.lock/poetry.lock"},
+		},
+	}))
+
+	got, err := memory.GetLayer("sha256:base")
+	require.NoError(t, err)
+	assert.Equal(t, []ftypes.Application{
+		{Type: "pipenv", FilePath: "srv/Pipfile.prod.lock"},
+		{Type: "pipenv", FilePath: "srv/Pipfile.lock"},
+		{Type: "poetry", FilePath: "srv/Pipfile.dev.lock/poetry.lock"},
+	}, got.Applications)
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"sync"
+
+	"github.com/aquasecurity/fanal/cache"
+	ftypes "github.com/aquasecurity/fanal/types"
+
+	"github.com/aquasecurity/trivy/pkg/progress"
+)
+
+// progressCache reports the analysis of the layers as progress.Analyze steps, the layers missing from the cache
+// being the total and each stored layer a step
+type progressCache struct {
+	cache.ImageCache
+
+	mu    sync.Mutex
+	done  int
+	total int
+}
+
+func (c *progressCache) MissingLayers(imageID string, layerIDs []string) (bool, []string, error) {
+	missingImage, missingLayerIDs, err := c.ImageCache.MissingLayers(imageID, layerIDs)
+	if err != nil {
+		return missingImage, missingLayerIDs, err
+	}
+	c.mu.Lock()
+	c.done, c.total = 0, len(missingLayerIDs)
+	c.mu.Unlock()
+	progress.Step(progress.Analyze, 0, len(missingLayerIDs), "")
+	return missingImage, missingLayerIDs, nil
+}
+
+func (c *progressCache) PutLayer(diffID string, layerInfo ftypes.LayerInfo) error {
+	if err := c.ImageCache.PutLayer(diffID, layerInfo); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.done++
+	done, total := c.done, c.total
+	c.mu.Unlock()
+	progress.Step(progress.Analyze, done, total, diffID)
+	return nil
+}
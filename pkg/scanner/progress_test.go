@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/cache"
+	"github.com/aquasecurity/trivy/pkg/progress"
+)
+
+type progressRecorder struct {
+	events []progress.Event
+}
+
+func (r *progressRecorder) Report(e progress.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestProgressCache(t *testing.T) {
+	r := &progressRecorder{}
+	progress.SetReporter(r)
+	defer progress.SetReporter(nil)
+
+	memory := cache.NewMemoryCache()
+	require.NoError(t, memory.PutLayer("sha256:cached", ftypes.LayerInfo{SchemaVersion: ftypes.LayerJSONSchemaVersion}))
+	c := &progressCache{ImageCache: memory}
+
+	_, missing, err := c.MissingLayers("sha256:image", []string{"sha256:cached", "sha256:base", "sha256:app"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sha256:base", "sha256:app"}, missing)
+	for _, diffID := range missing {
+		require.NoError(t, c.PutLayer(diffID, ftypes.LayerInfo{}))
+	}
+
+	var steps []string
+	for _, e := range r.events {
+		assert.Equal(t, progress.Analyze, e.Phase)
+		assert.Equal(t, progress.Running, e.Status)
+		steps = append(steps, fmt.Sprintf("%s %d/%d", e.Detail, e.Current, e.Total))
+	}
+	assert.Equal(t, []string{" 0/2", "sha256:base 1/2", "sha256:app 2/2"}, steps)
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/xerrors"
+
+	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// ResultCache stores the results of the driver scans of a DB version, e.g. cache.ResultCache
+type ResultCache interface {
+	Get(key string) (value []byte, found bool, err error)
+	Put(key string, value []byte) error
+}
+
+// WithResultCache returns the scanner reusing the results of the driver scans of the same artifact with the same
+// options from the cache, which must be of the DB of the driver. The analysis is still run, from the layer cache,
+// and the filters are applied to the cached results as to the scanned ones. The partial results aren't cached.
+func (s Scanner) WithResultCache(c ResultCache) Scanner {
+	s.results = c
+	return s
+}
+
+// cachedResult is the cached result of a driver scan
+type cachedResult struct {
+	Results report.Results
+	OS      *ftypes.OS `json:",omitempty"`
+	EOSL    bool       `json:",omitempty"`
+}
+
+// scanVulnTypesCached is scanVulnTypes returning the cached result of the artifact when there is one
+func (s Scanner) scanVulnTypesCached(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions) (
+	report.Results, *ftypes.OS, bool, error) {
+	if s.results == nil || imageInfo.ID == "" {
+		return s.scanVulnTypes(ctx, imageInfo, options)
+	}
+	key, err := resultKey(imageInfo, options)
+	if err != nil {
+		log.Logger.Warnf("Unable to cache the result: %s", err)
+		return s.scanVulnTypes(ctx, imageInfo, options)
+	}
+
+	if b, found, err := s.results.Get(key); err != nil {
+		log.Logger.Warnf("Unable to read the cached result: %s", err)
+	} else if found {
+		var cached cachedResult
+		if err = json.Unmarshal(b, &cached); err == nil {
+			log.Logger.Debugf("Reusing the cached result of %s", imageInfo.ID)
+			return cached.Results, cached.OS, cached.EOSL, nil
+		}
+		log.Logger.Warnf("Invalid cached result: %s", err)
+	}
+
+	results, osFound, eosl, err := s.scanVulnTypes(ctx, imageInfo, options)
+	if err != nil {
+		return results, osFound, eosl, err
+	}
+	b, err := json.Marshal(cachedResult{Results: results, OS: osFound, EOSL: eosl})
+	if err == nil {
+		err = s.results.Put(key, b)
+	}
+	if err != nil {
+		log.Logger.Warnf("Unable to cache the result: %s", err)
+	}
+	return results, osFound, eosl, nil
+}
+
+// resultKey is the key of the result of the artifact with the options, those not changing the result of the driver,
+// e.g. the parallelism and the retries, being left out
+func resultKey(imageInfo ftypes.ImageReference, options types.ScanOptions) (string, error) {
+	options.Parallel = 0
+	options.BatchWorkers = 0
+	options.Timeout = 0
+	options.Retries = 0
+	options.RetryBackoff = 0
+	options.Seed = 0
+	options.GitToken = ""
+	b, err := json.Marshal(struct {
+		ID       string
+		LayerIDs []string
+		Options  types.ScanOptions
+	}{ID: imageInfo.ID, LayerIDs: imageInfo.LayerIDs, Options: options})
+	if err != nil {
+		return "", xerrors.Errorf("unable to marshal the options: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
@@ -2,102 +2,722 @@ package scanner
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/cenkalti/backoff"
 	"github.com/google/wire"
 	"golang.org/x/xerrors"
 
 	"github.com/aquasecurity/fanal/analyzer"
 	"github.com/aquasecurity/fanal/extractor"
-	"github.com/aquasecurity/fanal/extractor/docker"
 	ftypes "github.com/aquasecurity/fanal/types"
+	"github.com/aquasecurity/trivy/pkg/db"
+	ospkgDetector "github.com/aquasecurity/trivy/pkg/detector/ospkg"
+	"github.com/aquasecurity/trivy/pkg/extractor/archive"
+	"github.com/aquasecurity/trivy/pkg/extractor/containerd"
+	"github.com/aquasecurity/trivy/pkg/extractor/fs"
+	"github.com/aquasecurity/trivy/pkg/extractor/image"
+	"github.com/aquasecurity/trivy/pkg/extractor/oci"
+	"github.com/aquasecurity/trivy/pkg/extractor/podman"
+	"github.com/aquasecurity/trivy/pkg/extractor/sftp"
+	"github.com/aquasecurity/trivy/pkg/git"
 	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/progress"
+	"github.com/aquasecurity/trivy/pkg/registry"
 	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/result"
 	"github.com/aquasecurity/trivy/pkg/rpc/client"
+	"github.com/aquasecurity/trivy/pkg/sbom"
 	"github.com/aquasecurity/trivy/pkg/scanner/local"
+	"github.com/aquasecurity/trivy/pkg/scanner/utils"
 	"github.com/aquasecurity/trivy/pkg/types"
+	pkgUtils "github.com/aquasecurity/trivy/pkg/utils"
 )
 
 // StandaloneSuperSet is used in the standalone mode
 var StandaloneSuperSet = wire.NewSet(
 	analyzer.New,
-	wire.Bind(new(Analyzer), new(analyzer.Config)),
+	NewImageAnalyzer,
+	wire.Bind(new(Analyzer), new(ImageAnalyzer)),
 	local.SuperSet,
 	wire.Bind(new(Driver), new(local.Scanner)),
 	NewScanner,
 )
 
 var StandaloneDockerSet = wire.NewSet(
-	types.GetDockerOption,
-	docker.NewDockerExtractor,
-	wire.Bind(new(extractor.Extractor), new(docker.Extractor)),
+	registry.GetDockerOption,
+	image.NewDockerExtractor,
+	wire.Bind(new(extractor.Extractor), new(*image.Extractor)),
 	StandaloneSuperSet,
 )
 
+// StandaloneBatchSet scans the images of the analyzers of the factory with BatchScanner.ScanImages, e.g. the images of trivy k8s
+var StandaloneBatchSet = wire.NewSet(
+	local.SuperSet,
+	wire.Bind(new(Driver), new(local.Scanner)),
+	NewBatchScanner,
+)
+
 var StandaloneArchiveSet = wire.NewSet(
 	types.GetDockerOption,
-	docker.NewDockerArchiveExtractor,
-	wire.Bind(new(extractor.Extractor), new(docker.Extractor)),
+	archive.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(archive.Extractor)),
+	StandaloneSuperSet,
+)
+
+// StandaloneOCISet scans an image of an OCI layout, a directory or a tarball
+var StandaloneOCISet = wire.NewSet(
+	oci.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*oci.Extractor)),
+	StandaloneSuperSet,
+)
+
+// StandaloneContainerdSet scans an image in the content store of containerd
+var StandaloneContainerdSet = wire.NewSet(
+	containerd.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*containerd.Extractor)),
 	StandaloneSuperSet,
 )
 
+// StandalonePodmanSet scans an image of the Podman service
+var StandalonePodmanSet = wire.NewSet(
+	podman.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*podman.Extractor)),
+	StandaloneSuperSet,
+)
+
+// StandaloneSFTPSet scans the filesystem of a remote host over SFTP
+var StandaloneSFTPSet = wire.NewSet(
+	sftp.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*sftp.Extractor)),
+	StandaloneSuperSet,
+)
+
+// StandaloneFilesystemSet scans a local directory with ScanFilesystem, merging its files even without OS files,
+// e.g. a project with only lock files
+var StandaloneFilesystemSet = wire.NewSet(
+	fs.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*fs.Extractor)),
+	analyzer.New,
+	NewImageAnalyzer,
+	wire.Bind(new(Analyzer), new(ImageAnalyzer)),
+	local.MergingSuperSet,
+	wire.Bind(new(Driver), new(local.Scanner)),
+	NewScanner,
+)
+
+// StandaloneSBOMSet scans the packages of an SBOM file
+var StandaloneSBOMSet = wire.NewSet(
+	sbom.NewAnalyzer,
+	wire.Bind(new(Analyzer), new(sbom.Analyzer)),
+	local.MergingSuperSet,
+	wire.Bind(new(Driver), new(local.Scanner)),
+	NewScanner,
+)
+
 // RemoteSuperSet is used in the client mode
 var RemoteSuperSet = wire.NewSet(
 	analyzer.New,
-	wire.Bind(new(Analyzer), new(analyzer.Config)),
+	NewImageAnalyzer,
+	wire.Bind(new(Analyzer), new(ImageAnalyzer)),
 	client.SuperSet,
 	wire.Bind(new(Driver), new(client.Scanner)),
 	NewScanner,
 )
 
 var RemoteDockerSet = wire.NewSet(
-	types.GetDockerOption,
-	docker.NewDockerExtractor,
-	wire.Bind(new(extractor.Extractor), new(docker.Extractor)),
+	registry.GetDockerOption,
+	image.NewDockerExtractor,
+	wire.Bind(new(extractor.Extractor), new(*image.Extractor)),
 	RemoteSuperSet,
 )
 
 var RemoteArchiveSet = wire.NewSet(
 	types.GetDockerOption,
-	docker.NewDockerArchiveExtractor,
-	wire.Bind(new(extractor.Extractor), new(docker.Extractor)),
+	archive.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(archive.Extractor)),
+	RemoteSuperSet,
+)
+
+// RemoteOCISet scans an image of an OCI layout in the client mode
+var RemoteOCISet = wire.NewSet(
+	oci.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*oci.Extractor)),
+	RemoteSuperSet,
+)
+
+// RemoteContainerdSet scans an image in the content store of containerd in the client mode
+var RemoteContainerdSet = wire.NewSet(
+	containerd.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*containerd.Extractor)),
+	RemoteSuperSet,
+)
+
+// RemotePodmanSet scans an image of the Podman service in the client mode
+var RemotePodmanSet = wire.NewSet(
+	podman.NewExtractor,
+	wire.Bind(new(extractor.Extractor), new(*podman.Extractor)),
 	RemoteSuperSet,
 )
 
 type Scanner struct {
 	driver   Driver
 	analyzer Analyzer
+	// results caches the results of the driver scans, see WithResultCache
+	results ResultCache
+	// onResult receives the result of each target of the driver scans, see WithResultHandler
+	onResult func(report.Result)
+}
+
+// WithResultHandler returns the scanner passing the result of each target to the handler as soon as the driver
+// scanned it, e.g. to stream it, with the filters of ScanOptions applying to a single target, e.g. the severities
+// and the ignore file. The policy of ScanOptions.IgnorePolicy only applies to the results returned together.
+// The drivers other than StreamingDriver and the cached results don't call it.
+// It can be called from several goroutines and must not keep the result.
+func (s Scanner) WithResultHandler(handler func(report.Result)) Scanner {
+	s.onResult = handler
+	return s
 }
 
 type Driver interface {
 	Scan(target string, imageID string, layerIDs []string, options types.ScanOptions) (results report.Results, osFound *ftypes.OS, eols bool, err error)
 }
 
+// MaintenanceStatusProvider is implemented by drivers reporting the archived and unmaintained packages
+// in Result.UnmaintainedPackages
+type MaintenanceStatusProvider interface {
+	ProvidesMaintenanceStatus() bool
+}
+
+// PackageLister is implemented by drivers listing all the packages of the targets in Result.Packages
+type PackageLister interface {
+	ListsPackages() bool
+}
+
+// ContextDriver is implemented by drivers able to stop a scan when the context is done
+type ContextDriver interface {
+	ScanContext(ctx context.Context, target string, imageID string, layerIDs []string, options types.ScanOptions) (results report.Results, osFound *ftypes.OS, eols bool, err error)
+}
+
+// StreamingDriver is implemented by drivers able to stop a scan when the context is done and passing the result
+// of each target to the handler as soon as it is scanned, see WithResultHandler
+type StreamingDriver interface {
+	ScanStream(ctx context.Context, target string, imageID string, layerIDs []string, options types.ScanOptions,
+		handler func(report.Result)) (results report.Results, osFound *ftypes.OS, eols bool, err error)
+}
+
 type Analyzer interface {
 	Analyze(ctx context.Context) (info ftypes.ImageReference, err error)
 }
 
+// WarningReporter is implemented by analyzers that report non-fatal problems (e.g. a file skipped by the size limit)
+// found during the latest analysis, such as ImageAnalyzer
+type WarningReporter interface {
+	Warnings() []string
+}
+
 func NewScanner(driver Driver, ac Analyzer) Scanner {
 	return Scanner{driver: driver, analyzer: ac}
 }
 
+func (s Scanner) scanConfig(target string) (*report.Result, error) {
+	provider, ok := s.analyzer.(ConfigBlobProvider)
+	if !ok {
+		log.Logger.Debug("The analyzer doesn't provide the image config")
+		return nil, nil
+	}
+	configBlob, err := provider.ConfigBlob()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to get config blob: %w", err)
+	}
+	return scanConfig(target, configBlob)
+}
+
+// ScanImage scans the image of the analyzer. When the scan of some vulnerability types fails,
+// the results of the others are returned with a *PartialScanError.
 func (s Scanner) ScanImage(options types.ScanOptions) (report.Results, error) {
-	ctx := context.Background()
-	imageInfo, err := s.analyzer.Analyze(ctx)
+	return s.ScanImageWithContext(context.Background(), options)
+}
+
+// ScanImageWithContext is ScanImage returning as soon as the context is done
+func (s Scanner) ScanImageWithContext(ctx context.Context, options types.ScanOptions) (report.Results, error) {
+	r, err := s.ScanImageReport(ctx, options)
+	return r.Results, err
+}
+
+// ImageReport is the result of ScanImageReport
+type ImageReport struct {
+	Image   ftypes.ImageReference
+	Results report.Results
+	// OS is the OS detected by the driver, e.g. alpine 3.11.5, or nil without OS
+	OS *ftypes.OS
+	// EOSL is true when the OS is no longer supported by the distribution
+	EOSL bool
+	// EOLOSVersionOverride is ScanOptions.EOLOSVersion when EOSL was checked for it instead of the version of OS
+	EOLOSVersionOverride string
+	// Grade is the grade of the image from A to F with ScanOptions.GradeRubric
+	Grade string
+	// Created is the creation time in the image config, nil when it is unknown, e.g. for a filesystem
+	Created *time.Time
+}
+
+// ScanImageReport is ScanImageWithContext also returning the scanned image, the detected OS and whether it is end-of-life
+func (s Scanner) ScanImageReport(ctx context.Context, options types.ScanOptions) (ImageReport, error) {
+	return s.scan(ctx, s.analyzer.Analyze, true, options)
+}
+
+// ScanFilesystem scans a local directory, e.g. an extracted rootfs or a CI workspace, as ScanImage scans an image.
+// The targets of the libraries are their paths in the directory. The image config isn't scanned.
+func (s Scanner) ScanFilesystem(ctx context.Context, path string, options types.ScanOptions) (report.Results, error) {
+	fa, ok := s.analyzer.(FilesystemAnalyzer)
+	if !ok {
+		return nil, xerrors.New("the analyzer doesn't analyze filesystems")
+	}
+	r, err := s.scan(ctx, func(ctx context.Context) (ftypes.ImageReference, error) {
+		return fa.AnalyzeFilesystem(ctx, path)
+	}, false, options)
+	if _, partial := err.(*PartialScanError); err != nil && !partial {
+		return nil, err
+	}
+	if options.DependencyTree {
+		setDependencyPaths(path, r.Results)
+	}
+	return r.Results, err
+}
+
+// ScanRootfs scans the root filesystem of a host, a VM or a container, e.g. of docker export, as ScanFilesystem
+// scans a directory. The OS and its installed packages are detected as in an image.
+func (s Scanner) ScanRootfs(ctx context.Context, root string, options types.ScanOptions) (report.Results, error) {
+	ra, ok := s.analyzer.(RootfsAnalyzer)
+	if !ok {
+		return nil, xerrors.New("the analyzer doesn't analyze root filesystems")
+	}
+	r, err := s.scan(ctx, func(ctx context.Context) (ftypes.ImageReference, error) {
+		return ra.AnalyzeRootfs(ctx, root)
+	}, false, options)
+	if _, partial := err.(*PartialScanError); err != nil && !partial {
+		return nil, err
+	}
+	return r.Results, err
+}
+
+// ScanRepository scans a single revision of a git repository as ScanFilesystem scans a directory,
+// cloning it into a temporary directory removed after the scan. The branch may also be a tag,
+// and the commit, the full hash of a commit, has priority over it. Both empty scan the default branch.
+func (s Scanner) ScanRepository(ctx context.Context, url, branch, commit string, options types.ScanOptions) (
+	report.Results, error) {
+	dir, cleanup, err := git.Clone(ctx, git.CloneOption{
+		URL:    url,
+		Branch: branch,
+		Commit: commit,
+		Token:  options.GitToken,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to clone %s: %w", url, err)
+	}
+	defer cleanup()
+	return s.ScanFilesystem(ctx, dir, options)
+}
+
+// scan analyzes the target and detects the vulnerabilities of the packages found.
+// The image config and the layers are only looked up from the analyzer for images.
+func (s Scanner) scan(ctx context.Context, analyze func(context.Context) (ftypes.ImageReference, error), image bool,
+	options types.ScanOptions) (ImageReport, error) {
+	filter, err := newResultFilter(options)
+	if err != nil {
+		return ImageReport{}, xerrors.Errorf("invalid scan options: %w", err)
+	}
+	if handler := s.onResult; handler != nil {
+		// the results dropped by the filters of their target aren't streamed
+		s.onResult = func(result report.Result) {
+			if filtered, ok := filter.applyTarget(result); ok {
+				handler(filtered)
+			}
+		}
+	}
+	var policyFilter *result.PolicyFilter
+	if options.IgnorePolicy != "" {
+		if policyFilter, err = result.NewPolicyFilter(ctx, options.IgnorePolicy); err != nil {
+			return ImageReport{}, xerrors.Errorf("invalid ignore policy: %w", err)
+		}
+	}
+
+	if options.Seed != 0 {
+		pkgUtils.SetSeed(options.Seed)
+	}
+
+	if limiter, ok := s.analyzer.(FileSizeLimiter); ok {
+		limiter.SetMaxFileSize(options.MaxFileSize)
+	}
+	if limiter, ok := s.analyzer.(LayerLimiter); ok {
+		limiter.SetParallel(options.Parallel)
+	}
+	if setter, ok := s.analyzer.(PathFilterSetter); ok {
+		filePatterns, err := ParseFilePatterns(options.FilePatterns)
+		if err != nil {
+			return ImageReport{}, xerrors.Errorf("invalid scan options: %w", err)
+		}
+		setter.SetPathFilter(PathFilter{SkipDirs: options.SkipDirs, SkipFiles: options.SkipFiles, FilePatterns: filePatterns})
+	}
+	if err = s.setSecretScanner(options); err != nil {
+		return ImageReport{}, xerrors.Errorf("invalid scan options: %w", err)
+	}
+	if err = s.setMisconfScanner(ctx, options); err != nil {
+		return ImageReport{}, xerrors.Errorf("invalid scan options: %w", err)
+	}
+	s.setLicenseDetection(options)
+
+	start := time.Now()
+	progress.Start(progress.Analyze, "")
+	imageInfo, err := analyze(ctx)
 	if err != nil {
-		return nil, xerrors.Errorf("failed analysis: %w", err)
+		return ImageReport{}, xerrors.Errorf("failed analysis: %w", err)
+	}
+	progress.Finish(progress.Analyze, imageInfo.Name)
+	log.Logger.Debugw("Analysis finished", "target", imageInfo.Name, "duration", time.Since(start), "layers", len(imageInfo.LayerIDs))
+	if err = ctx.Err(); err != nil {
+		return ImageReport{}, xerrors.Errorf("scan cancelled: %w", err)
+	}
+
+	if err = s.checkWarnings(options); err != nil {
+		return ImageReport{}, err
+	}
+	var digests map[string]string
+	if p, ok := s.analyzer.(FileDigestProvider); ok {
+		// taken after each analysis even when unused
+		digests = p.FileDigests()
+	}
+	var secrets map[string][]types.SecretFinding
+	if d, ok := s.analyzer.(SecretDetector); ok {
+		secrets = d.Secrets()
+	}
+	var misconfs map[string][]types.Misconfiguration
+	if d, ok := s.analyzer.(MisconfDetector); ok {
+		misconfs = d.Misconfigurations()
+	}
+	var licenses map[string][]types.DetectedLicense
+	if d, ok := s.analyzer.(LicenseDetector); ok {
+		licenses = d.Licenses()
+	}
+	if options.ListAllPackages {
+		if l, ok := s.driver.(PackageLister); !ok || !l.ListsPackages() {
+			log.Logger.Warn("The driver doesn't list the packages, only the vulnerable ones are reported")
+		}
+	}
+	if options.ScanMaintenance {
+		if p, ok := s.driver.(MaintenanceStatusProvider); !ok || !p.ProvidesMaintenanceStatus() {
+			log.Logger.Warn("The maintenance status of the packages is unavailable, the unmaintained packages aren't listed")
+		}
 	}
 
 	log.Logger.Debugf("Image ID: %s", imageInfo.ID)
 	log.Logger.Debugf("Layer IDs: %v", imageInfo.LayerIDs)
 
-	results, osFound, eosl, err := s.driver.Scan(imageInfo.Name, imageInfo.ID, imageInfo.LayerIDs, options)
-	if err != nil {
-		return nil, xerrors.Errorf("scan failed: %w", err)
+	// the target of the results is the image name, the ID only when the image has no name
+	target := imageInfo
+	if target.Name == "" {
+		target.Name = imageInfo.ID
+	}
+	driverTarget, driverOptions, knownLayers := s.skipKnownLayers(target, options)
+	var results report.Results
+	var osFound *ftypes.OS
+	var eosl bool
+	if hasSecurityCheck(options, types.SecurityCheckVulnerability) {
+		progress.Start(progress.Match, target.Name)
+		results, osFound, eosl, err = s.scanVulnTypesCached(ctx, driverTarget, driverOptions)
+		progress.Finish(progress.Match, target.Name)
+	}
+	partialErr, partial := err.(*PartialScanError)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if !options.PartialResults || !partial {
+			return ImageReport{}, xerrors.Errorf("scan cancelled: %w", ctxErr)
+		}
+		// the results found before are still filtered
+		ctx = context.Background()
+	}
+	if err != nil && !partial {
+		return ImageReport{}, xerrors.Errorf("scan failed: %w", err)
 	}
-	if eosl {
-		log.Logger.Warnf("This OS version is no longer supported by the distribution: %s %s", osFound.Family, osFound.Name)
+	// eolOS is the OS whose end of support is checked, the detected one unless its version is overridden
+	eolOS := osFound
+	var eolOverride string
+	if osFound != nil && options.EOLOSVersion != "" {
+		eolOS = &ftypes.OS{Family: osFound.Family, Name: options.EOLOSVersion}
+		eolOverride = options.EOLOSVersion
+		eosl = isEOSL(*eolOS, eosl)
+	}
+	if eosl && eolOS != nil {
+		log.Logger.Warnf("This OS version is no longer supported by the distribution: %s %s", eolOS.Family, eolOS.Name)
 		log.Logger.Warnf("The vulnerability detection may be insufficient because security updates are not provided")
+		markEOSL(results, eolOS.Family)
+	}
+	if len(knownLayers) > 0 {
+		results = dropLayerFindings(results, knownLayers)
+	}
+	if options.DedupeVulns {
+		dedupeVulns(results, imageInfo.LayerIDs)
+	}
+	if options.DedupeIdenticalFiles {
+		results = dedupeIdenticalFiles(results, digests)
+	}
+	markFixed(results)
+	if image {
+		s.attachLayerSizes(results)
+		s.attachLayerCreatedBy(results)
+	}
+	if !options.StaleAdvisoryCutoff.IsZero() {
+		markStale(results, options.StaleAdvisoryCutoff)
+	}
+
+	if options.ScanConfig && !image {
+		log.Logger.Debug("The config is only scanned for images")
+	} else if options.ScanConfig {
+		result, err := s.scanConfig(target.Name)
+		if err != nil {
+			return ImageReport{}, xerrors.Errorf("failed to scan image config: %w", err)
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+
+	results, err = filter.apply(results)
+	if err != nil {
+		return ImageReport{}, xerrors.Errorf("failed to filter results: %w", err)
+	}
+	if policyFilter != nil {
+		if results, err = policyFilter.Apply(ctx, results); err != nil {
+			return ImageReport{}, xerrors.Errorf("failed to filter results: %w", err)
+		}
+	}
+	results = append(results, secretResults(secrets, options.Severities)...)
+	results = append(results, misconfResults(misconfs, options.Severities)...)
+	results = append(results, licenseResults(licenses, imageInfo.LayerIDs, options.ForbiddenLicenses)...)
+	if eosl && osFound != nil && options.EOLSeverity != "" &&
+		(len(options.Severities) == 0 || hasSeverity(options.Severities, options.EOLSeverity)) {
+		results = append(results, eolResult(target.Name, *eolOS, eolOverride, options.EOLSeverity))
+	}
+
+	setNormalizedScores(results)
+
+	if options.FindingIDs {
+		assignFindingIDs(results, options.FindingIDPrefix)
+	}
+	rubric := types.DefaultGradeRubric
+	if options.GradeRubric != nil {
+		rubric = *options.GradeRubric
+	}
+	for _, result := range results {
+		log.Logger.Debugw("Target scanned", "target", result.Target, "vulnerabilities", len(result.Vulnerabilities))
+	}
+	imageReport := ImageReport{
+		Image:                imageInfo,
+		Results:              results,
+		OS:                   osFound,
+		EOSL:                 eosl,
+		EOLOSVersionOverride: eolOverride,
+		Grade:                report.Grade(results, rubric),
+	}
+	if image {
+		imageReport.Created = s.imageCreated()
+	}
+	if partial {
+		return imageReport, partialErr
+	}
+	return imageReport, nil
+}
+
+// scanWithRetries retries the driver scan failing transiently up to options.Retries times with an exponential backoff
+func (s Scanner) scanWithRetries(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions) (
+	results report.Results, osFound *ftypes.OS, eosl bool, err error) {
+	if options.Retries <= 0 {
+		return s.scanDriver(ctx, imageInfo, options)
+	}
+
+	operation := func() error {
+		results, osFound, eosl, err = s.scanDriver(ctx, imageInfo, options)
+		if err != nil && !isRetryable(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	b := pkgUtils.NewJitteredBackOff(options.RetryBackoff, 0, nil)
+	err = backoff.RetryNotify(operation, backoff.WithContext(backoff.WithMaxRetries(b, uint64(options.Retries)), ctx),
+		func(err error, next time.Duration) {
+			log.Logger.Warnf("Scan failed, retrying in %s: %s", next.Round(time.Millisecond), err)
+		})
+	return results, osFound, eosl, err
+}
+
+// isRetryable reports whether the driver scan may succeed when retried
+func isRetryable(err error) bool {
+	for _, permanent := range []error{ospkgDetector.ErrUnsupportedOS, db.ErrNoLocalDB, context.Canceled, context.DeadlineExceeded} {
+		if xerrors.Is(err, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanDriver runs the driver scan within options.Timeout. A driver unaware of the context is left running
+// in the background when the context is done before it returns.
+func (s Scanner) scanDriver(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions) (
+	report.Results, *ftypes.OS, bool, error) {
+	start := time.Now()
+	defer func() {
+		log.Logger.Debugw("Driver scan finished", "target", imageInfo.Name, "duration", time.Since(start))
+	}()
+
+	if options.Timeout <= 0 {
+		return s.scanDriverContext(ctx, imageInfo, options)
 	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	results, osFound, eosl, err := s.scanDriverContext(timeoutCtx, imageInfo, options)
+	if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return nil, nil, false, xerrors.Errorf("scan of %s timed out after %s: %w", imageInfo.Name, options.Timeout, err)
+	}
+	return results, osFound, eosl, err
+}
+
+func (s Scanner) scanDriverContext(ctx context.Context, imageInfo ftypes.ImageReference, options types.ScanOptions) (
+	report.Results, *ftypes.OS, bool, error) {
+	if d, ok := s.driver.(StreamingDriver); ok {
+		return d.ScanStream(ctx, imageInfo.Name, imageInfo.ID, imageInfo.LayerIDs, options, s.onResult)
+	}
+	if d, ok := s.driver.(ContextDriver); ok {
+		return d.ScanContext(ctx, imageInfo.Name, imageInfo.ID, imageInfo.LayerIDs, options)
+	}
+
+	type scanResult struct {
+		results report.Results
+		osFound *ftypes.OS
+		eosl    bool
+		err     error
+	}
+	done := make(chan scanResult, 1)
+	go func() {
+		var r scanResult
+		r.results, r.osFound, r.eosl, r.err = s.driver.Scan(imageInfo.Name, imageInfo.ID, imageInfo.LayerIDs, options)
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		return r.results, r.osFound, r.eosl, r.err
+	case <-ctx.Done():
+		return nil, nil, false, ctx.Err()
+	}
+}
+
+// attachLayerSizes sets LayerSize of the findings. Missing sizes only leave it empty.
+func (s Scanner) attachLayerSizes(results report.Results) {
+	provider, ok := s.analyzer.(LayerSizeProvider)
+	if !ok {
+		return
+	}
+	sizes, err := provider.LayerSizes()
+	if err != nil {
+		log.Logger.Debugf("Unable to get the layer sizes: %s", err)
+		return
+	}
+	if len(sizes) == 0 {
+		return
+	}
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			result.Vulnerabilities[i].LayerSize = sizes[vuln.Layer.DiffID]
+		}
+	}
+}
+
+// attachLayerCreatedBy sets LayerCreatedBy of the findings from the history in the image config
+func (s Scanner) attachLayerCreatedBy(results report.Results) {
+	provider, ok := s.analyzer.(ConfigBlobProvider)
+	if !ok {
+		return
+	}
+	configBlob, err := provider.ConfigBlob()
+	if err != nil {
+		log.Logger.Debugf("Unable to get config blob: %s", err)
+		return
+	}
+	createdBy, err := layerCreatedBy(configBlob)
+	if err != nil {
+		log.Logger.Debugf("Unable to get the layer history: %s", err)
+		return
+	}
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			result.Vulnerabilities[i].LayerCreatedBy = createdBy[vuln.Layer.DiffID]
+		}
+	}
+}
+
+// imageCreated returns the creation time in the image config, nil when the analyzer doesn't provide the config
+func (s Scanner) imageCreated() *time.Time {
+	provider, ok := s.analyzer.(ConfigBlobProvider)
+	if !ok {
+		return nil
+	}
+	configBlob, err := provider.ConfigBlob()
+	if err != nil {
+		log.Logger.Debugf("Unable to get config blob: %s", err)
+		return nil
+	}
+	created, err := imageCreated(configBlob)
+	if err != nil {
+		log.Logger.Debugf("Unable to get the creation time of the image: %s", err)
+	}
+	return created
+}
+
+func (s Scanner) checkWarnings(options types.ScanOptions) error {
+	reporter, ok := s.analyzer.(WarningReporter)
+	if !ok {
+		return nil
+	}
+	warnings := reporter.Warnings()
+	for _, w := range warnings {
+		log.Logger.Warnf("Analyzer warning: %s", w)
+	}
+	if options.FailOnAnalyzerWarning && len(warnings) > 0 {
+		return xerrors.Errorf("analyzer warnings: %s", strings.Join(warnings, "; "))
+	}
+	return nil
+}
 
-	return results, nil
+// markFixed sets IsFixed according to the version semantics of each result type
+func markFixed(results report.Results) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			fixed, err := utils.IsFixed(result.Type, vuln.InstalledVersion, vuln.FixedVersion)
+			if err != nil {
+				log.Logger.Debugf("Unable to compare %s versions of %s: %s", vuln.VulnerabilityID, vuln.PkgName, err)
+				continue
+			}
+			result.Vulnerabilities[i].IsFixed = fixed
+		}
+	}
+}
+
+// markEOSL sets EOSL on the result of the OS packages
+func markEOSL(results report.Results, osFamily string) {
+	for i := range results {
+		if results[i].Type == osFamily {
+			results[i].EOSL = true
+		}
+	}
+}
+
+// markStale sets StaleData on the findings whose advisory was last modified before the cutoff
+func markStale(results report.Results, cutoff time.Time) {
+	for _, result := range results {
+		for i, vuln := range result.Vulnerabilities {
+			if vuln.LastModified != nil && vuln.LastModified.Before(cutoff) {
+				result.Vulnerabilities[i].StaleData = true
+			}
+		}
+	}
 }
@@ -1,17 +1,29 @@
 package scanner
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/xerrors"
 
 	ftypes "github.com/aquasecurity/fanal/types"
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	ospkgDetector "github.com/aquasecurity/trivy/pkg/detector/ospkg"
 	"github.com/aquasecurity/trivy/pkg/log"
 	"github.com/aquasecurity/trivy/pkg/report"
 	"github.com/aquasecurity/trivy/pkg/types"
+	pkgUtils "github.com/aquasecurity/trivy/pkg/utils"
 )
 
 func TestMain(m *testing.M) {
@@ -60,6 +72,7 @@ func TestScanner_ScanImage(t *testing.T) {
 					Results: report.Results{
 						{
 							Target: "alpine:3.11",
+							Type:   "alpine",
 							Vulnerabilities: []types.DetectedVulnerability{
 								{
 									VulnerabilityID:  "CVE-2019-9999",
@@ -96,6 +109,8 @@ func TestScanner_ScanImage(t *testing.T) {
 			wantResults: report.Results{
 				{
 					Target: "alpine:3.11",
+					Type:   "alpine",
+					EOSL:   true,
 					Vulnerabilities: []types.DetectedVulnerability{
 						{
 							VulnerabilityID:  "CVE-2019-9999",
@@ -168,26 +183,1813 @@ func TestScanner_ScanImage(t *testing.T) {
 			},
 			wantErr: "scan failed",
 		},
+		{
+			name: "happy path with severity thresholds",
+			args: args{
+				options: types.ScanOptions{
+					VulnType:                 []string{"os", "library"},
+					SeverityThresholds:       map[string]string{"npm": "CRITICAL", "os": "MEDIUM"},
+					DefaultSeverityThreshold: "HIGH",
+				},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "alpine:3.11",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "alpine:3.11",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "alpine:3.11 (alpine 3.11)",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID:  "CVE-2019-9999",
+									PkgName:          "vim",
+									InstalledVersion: "1.2.3",
+									FixedVersion:     "1.2.4",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+								},
+								{
+									VulnerabilityID:  "CVE-2019-9998",
+									PkgName:          "musl",
+									InstalledVersion: "1.2.3",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+								},
+								{
+									VulnerabilityID:  "CVE-2019-9997",
+									PkgName:          "busybox",
+									InstalledVersion: "1.2.3",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "LOW"},
+								},
+							},
+							Type: "alpine",
+						},
+						{
+							Target: "node-app/package-lock.json",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID:  "CVE-2019-11358",
+									PkgName:          "jquery",
+									InstalledVersion: "3.3.9",
+									FixedVersion:     ">=3.4.0",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+								},
+							},
+							Type: "npm",
+						},
+						{
+							Target: "app/Gemfile.lock",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID:  "CVE-2020-10000",
+									PkgName:          "rails",
+									InstalledVersion: "6.0",
+									FixedVersion:     "6.1",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+								},
+							},
+							Type: "bundler",
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID:  "CVE-2019-9999",
+							PkgName:          "vim",
+							InstalledVersion: "1.2.3",
+							FixedVersion:     "1.2.4",
+							NormalizedScore:  8,
+							Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+						},
+						{
+							VulnerabilityID:  "CVE-2019-9998",
+							PkgName:          "musl",
+							InstalledVersion: "1.2.3",
+							NormalizedScore:  5.5,
+							Vulnerability:    dbTypes.Vulnerability{Severity: "MEDIUM"},
+						},
+					},
+					Type: "alpine",
+				},
+				{
+					Target: "node-app/package-lock.json",
+					Type:   "npm",
+				},
+				{
+					Target: "app/Gemfile.lock",
+					Type:   "bundler",
+				},
+			},
+		},
+		{
+			name: "happy path with yanked packages",
+			args: args{
+				options: types.ScanOptions{VulnType: []string{"library"}, ScanYanked: true},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "node:12",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "node:12",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "app/package-lock.json",
+							Type:   "npm",
+							YankedPackages: []types.YankedPackage{
+								{PkgName: "left-pad", InstalledVersion: "1.0.0", Reason: "unpublished"},
+							},
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "app/package-lock.json",
+					Type:   "npm",
+					YankedPackages: []types.YankedPackage{
+						{PkgName: "left-pad", InstalledVersion: "1.0.0", Reason: "unpublished"},
+					},
+				},
+			},
+		},
+		{
+			name: "happy path without yanked packages",
+			args: args{
+				options: types.ScanOptions{VulnType: []string{"library"}},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "node:12",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "node:12",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "app/package-lock.json",
+							Type:   "npm",
+							YankedPackages: []types.YankedPackage{
+								{PkgName: "left-pad", InstalledVersion: "1.0.0", Reason: "unpublished"},
+							},
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "app/package-lock.json",
+					Type:   "npm",
+				},
+			},
+		},
+		{
+			name: "happy path with filter expression",
+			args: args{
+				options: types.ScanOptions{
+					VulnType:   []string{"os"},
+					FilterExpr: `severity == "CRITICAL" && fixed`,
+				},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "alpine:3.11",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "alpine:3.11",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "alpine:3.11 (alpine 3.11)",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID:  "CVE-2019-9999",
+									PkgName:          "openssl",
+									InstalledVersion: "1.2.3",
+									FixedVersion:     "1.2.4",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "CRITICAL"},
+								},
+								{
+									VulnerabilityID:  "CVE-2019-9998",
+									PkgName:          "musl",
+									InstalledVersion: "1.2.3",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "CRITICAL"},
+								},
+								{
+									VulnerabilityID:  "CVE-2019-9997",
+									PkgName:          "busybox",
+									InstalledVersion: "1.2.3",
+									FixedVersion:     "1.2.4",
+									Vulnerability:    dbTypes.Vulnerability{Severity: "HIGH"},
+								},
+							},
+							Type: "alpine",
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11)",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID:  "CVE-2019-9999",
+							PkgName:          "openssl",
+							InstalledVersion: "1.2.3",
+							FixedVersion:     "1.2.4",
+							NormalizedScore:  9.5,
+							Vulnerability:    dbTypes.Vulnerability{Severity: "CRITICAL"},
+						},
+					},
+					Type: "alpine",
+				},
+			},
+		},
+		{
+			name: "happy path with range-based fixed versions",
+			args: args{
+				options: types.ScanOptions{VulnType: []string{"library"}},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "alpine:3.11",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "alpine:3.11",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "app/package-lock.json",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID:  "CVE-2019-9999",
+									PkgName:          "lodash",
+									InstalledVersion: "3.3.9",
+									FixedVersion:     ">=3.4.0",
+								},
+								{
+									VulnerabilityID:  "CVE-2019-9998",
+									PkgName:          "jquery",
+									InstalledVersion: "3.5.0",
+									FixedVersion:     ">=3.4.0",
+								},
+							},
+							Type: "npm",
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID:  "CVE-2019-9999",
+							PkgName:          "lodash",
+							InstalledVersion: "3.3.9",
+							FixedVersion:     ">=3.4.0",
+						},
+						{
+							VulnerabilityID:  "CVE-2019-9998",
+							PkgName:          "jquery",
+							InstalledVersion: "3.5.0",
+							FixedVersion:     ">=3.4.0",
+							IsFixed:          true,
+						},
+					},
+					Type: "npm",
+				},
+			},
+		},
+		{
+			name: "happy path with a stale advisory",
+			args: args{
+				options: types.ScanOptions{
+					VulnType:            []string{"library"},
+					StaleAdvisoryCutoff: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			analyzeExpectation: AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{
+						Name:     "alpine:3.11",
+						ID:       "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+						LayerIDs: []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					},
+				},
+			},
+			scanExpectation: ScanExpectation{
+				Args: ScanArgs{
+					Target:          "alpine:3.11",
+					ImageID:         "sha256:e7d92cdc71feacf90708cb59182d0df1b911f8ae022d29e8e95d75ca6a99776a",
+					LayerIDs:        []string{"sha256:5216338b40a7b96416b8b9858974bbe4acc3096ee60acbc4dfb1ee02aecceb10"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target: "app/package-lock.json",
+							Vulnerabilities: []types.DetectedVulnerability{
+								{
+									VulnerabilityID: "CVE-2018-0001",
+									PkgName:         "lodash",
+									LastModified:    timePtr(time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)),
+								},
+								{
+									VulnerabilityID: "CVE-2020-0001",
+									PkgName:         "lodash",
+									LastModified:    timePtr(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)),
+								},
+								{
+									VulnerabilityID: "CVE-2020-0002",
+									PkgName:         "jquery",
+								},
+							},
+							Type: "npm",
+						},
+					},
+				},
+			},
+			wantResults: report.Results{
+				{
+					Target: "app/package-lock.json",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{
+							VulnerabilityID: "CVE-2018-0001",
+							PkgName:         "lodash",
+							LastModified:    timePtr(time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)),
+							StaleData:       true,
+						},
+						{
+							VulnerabilityID: "CVE-2020-0001",
+							PkgName:         "lodash",
+							LastModified:    timePtr(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)),
+						},
+						{
+							VulnerabilityID: "CVE-2020-0002",
+							PkgName:         "jquery",
+						},
+					},
+					Type: "npm",
+				},
+			},
+		},
+		{
+			name: "sad path: invalid filter expression",
+			args: args{
+				options: types.ScanOptions{
+					VulnType:   []string{"os"},
+					FilterExpr: `severity = "CRITICAL"`,
+				},
+			},
+			wantErr: "invalid filter expression",
+		},
+		{
+			name: "sad path: unknown severity threshold",
+			args: args{
+				options: types.ScanOptions{
+					VulnType:           []string{"os"},
+					SeverityThresholds: map[string]string{"npm": "SEVERE"},
+				},
+			},
+			wantErr: "invalid severity threshold",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := new(MockDriver)
+			d.ApplyScanExpectation(tt.scanExpectation)
+
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(tt.analyzeExpectation)
+
+			s := NewScanner(d, analyzer)
+			gotResults, err := s.ScanImage(tt.args.options)
+			if tt.wantErr != "" {
+				require.NotNil(t, err, tt.name)
+				require.Contains(t, err.Error(), tt.wantErr, tt.name)
+				return
+			} else {
+				require.NoError(t, err, tt.name)
+			}
+
+			assert.Equal(t, tt.wantResults, gotResults, tt.name)
+		})
+	}
+}
+
+type mockConfigAnalyzer struct {
+	*MockAnalyzer
+	configBlob []byte
+}
+
+func (a mockConfigAnalyzer) ConfigBlob() ([]byte, error) {
+	return a.configBlob, nil
+}
+
+func TestScanner_ScanImage_Config(t *testing.T) {
+	tests := []struct {
+		name        string
+		configBlob  string
+		wantResults report.Results
+	}{
+		{
+			name:       "root user and exposed ports",
+			configBlob: `{"config":{"User":"","ExposedPorts":{"80/tcp":{},"22/tcp":{}}}}`,
+			wantResults: report.Results{
+				{
+					Target: "nginx:1.17 (image config)",
+					Type:   "config",
+					Config: []types.ConfigFinding{
+						{ID: "root-user", Title: "Image user should not be root", Severity: "HIGH"},
+						{ID: "exposed-port", Title: "Port 22/tcp is exposed", Severity: "LOW"},
+						{ID: "exposed-port", Title: "Port 80/tcp is exposed", Severity: "LOW"},
+					},
+				},
+			},
+		},
+		{
+			name:       "non-root user",
+			configBlob: `{"config":{"User":"nginx"}}`,
+			wantResults: report.Results{
+				{
+					Target: "nginx:1.17 (image config)",
+					Type:   "config",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{
+					TargetAnything:   true,
+					ImageIDAnything:  true,
+					LayerIDsAnything: true,
+					OptionsAnything:  true,
+				},
+			})
+
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{
+					CtxAnything: true,
+				},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "nginx:1.17"},
+				},
+			})
+
+			s := NewScanner(d, mockConfigAnalyzer{MockAnalyzer: analyzer, configBlob: []byte(tt.configBlob)})
+			gotResults, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, ScanConfig: true})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantResults, gotResults)
+		})
+	}
+}
+
+func TestScanner_ScanImage_LayerCreatedBy(t *testing.T) {
+	configBlob := `{
+  "rootfs": {"type": "layers", "diff_ids": ["sha256:base", "sha256:curl", "sha256:app"]},
+  "history": [
+    {"created_by": "/bin/sh -c #(nop) ADD file:a1b2 in / "},
+    {"created_by": "/bin/sh -c #(nop)  CMD [\"/bin/sh\"]", "empty_layer": true},
+    {"created_by": "RUN /bin/sh -c apk add --no-cache curl # buildkit"},
+    {"created_by": "COPY . /app # buildkit"}
+  ]
+}`
+
+	d := new(MockDriver)
+	d.ApplyScanExpectation(ScanExpectation{
+		Args: ScanArgs{
+			TargetAnything:   true,
+			ImageIDAnything:  true,
+			LayerIDsAnything: true,
+			OptionsAnything:  true,
+		},
+		Returns: ScanReturns{
+			Results: report.Results{
+				{
+					Target: "alpine:3.11 (alpine 3.11.5)",
+					Type:   "alpine",
+					Vulnerabilities: []types.DetectedVulnerability{
+						{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+						{VulnerabilityID: "CVE-2020-8169", PkgName: "curl", Layer: ftypes.Layer{DiffID: "sha256:curl"}},
+						{VulnerabilityID: "CVE-2020-9999", PkgName: "foo"},
+					},
+				},
+			},
+		},
+	})
+
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args:    AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{Info: ftypes.ImageReference{Name: "alpine:3.11"}},
+	})
+
+	s := NewScanner(d, mockConfigAnalyzer{MockAnalyzer: analyzer, configBlob: []byte(configBlob)})
+	results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	var got []string
+	for _, vuln := range results[0].Vulnerabilities {
+		got = append(got, vuln.LayerCreatedBy)
+	}
+	assert.Equal(t, []string{
+		"/bin/sh -c #(nop) ADD file:a1b2 in / ",
+		"RUN /bin/sh -c apk add --no-cache curl # buildkit",
+		"",
+	}, got)
+}
+
+type mockWarningAnalyzer struct {
+	*MockAnalyzer
+	warnings []string
+}
+
+func (a mockWarningAnalyzer) Warnings() []string {
+	return a.warnings
+}
+
+func TestScanner_ScanImage_AnalyzerWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		warnings []string
+		options  types.ScanOptions
+		wantErr  string
+	}{
+		{
+			name:     "warnings are ignored by default",
+			warnings: []string{"unable to parse app/package-lock.json"},
+			options:  types.ScanOptions{VulnType: []string{"library"}},
+		},
+		{
+			name:    "no warnings",
+			options: types.ScanOptions{VulnType: []string{"library"}, FailOnAnalyzerWarning: true},
+		},
+		{
+			name:     "sad path: fail on warnings",
+			warnings: []string{"unable to parse app/package-lock.json", "unable to parse Gemfile.lock"},
+			options:  types.ScanOptions{VulnType: []string{"library"}, FailOnAnalyzerWarning: true},
+			wantErr:  "analyzer warnings: unable to parse app/package-lock.json; unable to parse Gemfile.lock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "node:12", ID: "sha256:node", LayerIDs: []string{"sha256:app"}},
+				},
+			})
+
+			d := new(MockDriver)
+			if tt.wantErr == "" {
+				d.ApplyScanExpectation(ScanExpectation{
+					Args: ScanArgs{
+						Target:          "node:12",
+						ImageID:         "sha256:node",
+						LayerIDs:        []string{"sha256:app"},
+						OptionsAnything: true,
+					},
+				})
+			}
+
+			s := NewScanner(d, mockWarningAnalyzer{MockAnalyzer: analyzer, warnings: tt.warnings})
+			_, err := s.ScanImage(tt.options)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			d.AssertExpectations(t)
+		})
+	}
+}
+
+type mockLayerSizeAnalyzer struct {
+	*MockAnalyzer
+	sizes map[string]int64
+	err   error
+}
+
+func (a mockLayerSizeAnalyzer) LayerSizes() (map[string]int64, error) {
+	return a.sizes, a.err
+}
+
+func TestScanner_ScanImage_LayerSizes(t *testing.T) {
+	vulns := []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+		{VulnerabilityID: "CVE-2020-8169", PkgName: "curl", Layer: ftypes.Layer{DiffID: "sha256:curl"}},
+	}
+	tests := []struct {
+		name  string
+		sizes map[string]int64
+		err   error
+		want  []int64
+	}{
+		{
+			name:  "sizes of the layers",
+			sizes: map[string]int64{"sha256:base": 5599651, "sha256:curl": 1479789},
+			want:  []int64{5599651, 1479789},
+		},
+		{
+			name:  "missing size",
+			sizes: map[string]int64{"sha256:base": 5599651},
+			want:  []int64{5599651, 0},
+		},
+		{
+			name: "sizes unavailable",
+			err:  errors.New("error"),
+			want: []int64{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base", "sha256:curl"}},
+				},
+			})
+
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{
+					Target:          "alpine:3.11",
+					ImageID:         "sha256:alpine",
+					LayerIDs:        []string{"sha256:base", "sha256:curl"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{
+					Results: report.Results{
+						{
+							Target:          "alpine:3.11 (alpine 3.11.5)",
+							Type:            "alpine",
+							Vulnerabilities: append(vulns[:0:0], vulns...),
+						},
+					},
+				},
+			})
+
+			s := NewScanner(d, mockLayerSizeAnalyzer{MockAnalyzer: analyzer, sizes: tt.sizes, err: tt.err})
+			results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+
+			var got []int64
+			for _, vuln := range results[0].Vulnerabilities {
+				got = append(got, vuln.LayerSize)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanner_ScanImage_SkipDBUpdate(t *testing.T) {
+	for _, skip := range []bool{true, false} {
+		analyzer := new(MockAnalyzer)
+		analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+			Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+			Returns: AnalyzerAnalyzeReturns{
+				Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+			},
+		})
+
+		skip := skip
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args: ScanArgs{
+				Target:   "alpine:3.11",
+				ImageID:  "sha256:alpine",
+				LayerIDs: []string{"sha256:base"},
+				OptionsMatchedBy: func(options types.ScanOptions) bool {
+					return options.SkipDBUpdate == skip
+				},
+			},
+		})
+
+		s := NewScanner(d, analyzer)
+		_, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, SkipDBUpdate: skip})
+		require.NoError(t, err)
+		d.AssertExpectations(t)
+	}
+}
+
+func TestScanner_ScanImage_Report(t *testing.T) {
+	tests := []struct {
+		name      string
+		osFound   *ftypes.OS
+		eosl      bool
+		wantGrade string
+	}{
+		{
+			name:      "EOL OS",
+			osFound:   &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			eosl:      true,
+			wantGrade: "D",
+		},
+		{
+			name:      "supported OS",
+			osFound:   &ftypes.OS{Family: "alpine", Name: "3.11.5"},
+			wantGrade: "A",
+		},
+		{
+			name:      "no OS",
+			wantGrade: "A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+				},
+			})
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+				Returns: ScanReturns{
+					Results: report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Type: "alpine"}},
+					OsFound: tt.osFound,
+					Eols:    tt.eosl,
+				},
+			})
+
+			s := NewScanner(d, analyzer)
+			got, err := s.ScanImageReport(context.Background(), types.ScanOptions{VulnType: []string{"os"}})
+			require.NoError(t, err)
+			assert.Equal(t, "alpine:3.11", got.Image.Name)
+			assert.Equal(t, tt.osFound, got.OS)
+			assert.Equal(t, tt.eosl, got.EOSL)
+			assert.Equal(t, tt.wantGrade, got.Grade)
+			require.Len(t, got.Results, 1)
+		})
+	}
+}
+
+func TestScanner_ScanImage_EOL(t *testing.T) {
+	eolDate := time.Date(2020, 11, 1, 23, 59, 59, 0, time.UTC)
+	tests := []struct {
+		name    string
+		osFound *ftypes.OS
+		eosl    bool
+		options types.ScanOptions
+		want    report.Results
+		// wantOverride is the EOLOSVersionOverride of the report
+		wantOverride string
+	}{
+		{
+			name:    "EOL OS",
+			osFound: &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			eosl:    true,
+			options: types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "HIGH"},
+			want: report.Results{
+				{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine", EOSL: true},
+				{
+					Target: "alpine:3.9 (alpine 3.9.6)",
+					Type:   "alpine",
+					Class:  report.ClassEOL,
+					EOL: &types.EOLFinding{
+						Family:   "alpine",
+						Name:     "3.9.6",
+						Severity: "HIGH",
+						EOLDate:  &eolDate,
+						Message:  "alpine 3.9.6 is no longer supported by the distribution since 2020-11-01, security updates are not provided",
+					},
+				},
+			},
+		},
+		{
+			name:    "EOL OS below the severities",
+			osFound: &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			eosl:    true,
+			options: types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "MEDIUM", Severities: []string{"HIGH", "CRITICAL"}},
+			want:    report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine", EOSL: true}},
+		},
+		{
+			name:    "EOL OS without the finding",
+			osFound: &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			eosl:    true,
+			options: types.ScanOptions{VulnType: []string{"os"}},
+			want:    report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine", EOSL: true}},
+		},
+		{
+			name:    "supported OS",
+			osFound: &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			options: types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "HIGH"},
+			want:    report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine"}},
+		},
+		{
+			name:         "EOL OS overridden with a supported version",
+			osFound:      &ftypes.OS{Family: "alpine", Name: "3.9.6"},
+			eosl:         true,
+			options:      types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "HIGH", EOLOSVersion: "3.18"},
+			want:         report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine"}},
+			wantOverride: "3.18",
+		},
+		{
+			name:    "supported OS overridden with an EOL version",
+			osFound: &ftypes.OS{Family: "alpine", Name: "3.18.4"},
+			options: types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "HIGH", EOLOSVersion: "3.9"},
+			want: report.Results{
+				{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine", EOSL: true},
+				{
+					Target: "alpine:3.9 (alpine 3.9)",
+					Type:   "alpine",
+					Class:  report.ClassEOL,
+					EOL: &types.EOLFinding{
+						Family:               "alpine",
+						Name:                 "3.9",
+						Severity:             "HIGH",
+						EOLDate:              &eolDate,
+						Message:              "alpine 3.9 is no longer supported by the distribution since 2020-11-01, security updates are not provided",
+						EOLOSVersionOverride: "3.9",
+					},
+				},
+			},
+			wantOverride: "3.9",
+		},
+		{
+			name:    "EOL without OS",
+			eosl:    true,
+			options: types.ScanOptions{VulnType: []string{"os"}, EOLSeverity: "HIGH", EOLOSVersion: "3.18"},
+			want:    report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine"}},
+		},
+	}
+	timeNow = func() time.Time { return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = time.Now }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args:    AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{Info: ftypes.ImageReference{Name: "alpine:3.9", ID: "sha256:alpine"}},
+			})
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+				Returns: ScanReturns{
+					Results: report.Results{{Target: "alpine:3.9 (alpine 3.9.6)", Type: "alpine"}},
+					OsFound: tt.osFound,
+					Eols:    tt.eosl,
+				},
+			})
+
+			got, err := NewScanner(d, analyzer).ScanImageReport(context.Background(), tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Results)
+			assert.Equal(t, tt.wantOverride, got.EOLOSVersionOverride)
+		})
+	}
+}
+
+func TestScanner_ScanImage_Created(t *testing.T) {
+	tests := []struct {
+		name       string
+		configBlob string
+		want       *time.Time
+	}{
+		{
+			name:       "created",
+			configBlob: `{"created":"2020-04-23T19:15:16.392213124+09:00","config":{}}`,
+			want:       timePtr(time.Date(2020, 4, 23, 10, 15, 16, 392213124, time.UTC)),
+		},
+		{
+			name:       "no created",
+			configBlob: `{"config":{}}`,
+		},
+		{
+			name:       "invalid config",
+			configBlob: `{`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args:    AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine"}},
+			})
 			d := new(MockDriver)
-			d.ApplyScanExpectation(tt.scanExpectation)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args:    ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+				Returns: ScanReturns{Results: report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Type: "alpine"}}},
+			})
+
+			s := NewScanner(d, mockConfigAnalyzer{MockAnalyzer: analyzer, configBlob: []byte(tt.configBlob)})
+			got, err := s.ScanImageReport(context.Background(), types.ScanOptions{VulnType: []string{"os"}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Created)
+		})
+	}
+}
+
+// blockingDriver doesn't return before the release channel is closed
+type blockingDriver struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d blockingDriver) Scan(string, string, []string, types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	close(d.started)
+	<-d.release
+	return nil, nil, false, nil
+}
+
+func TestScanner_ScanImage_Context(t *testing.T) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+
+	t.Run("cancelled during the driver scan", func(t *testing.T) {
+		d := blockingDriver{started: make(chan struct{}), release: make(chan struct{})}
+		defer close(d.release)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-d.started
+			cancel()
+		}()
+
+		s := NewScanner(d, analyzer)
+		_, err := s.ScanImageWithContext(ctx, types.ScanOptions{VulnType: []string{"os"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scan cancelled")
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("cancelled before the driver scan", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		s := NewScanner(new(MockDriver), analyzer)
+		_, err := s.ScanImageWithContext(ctx, types.ScanOptions{VulnType: []string{"os"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scan cancelled")
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
 
+// streamingDriver passes its results to the handler before returning them
+type streamingDriver struct {
+	results report.Results
+}
+
+func (d streamingDriver) Scan(string, string, []string, types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	return d.results, nil, false, nil
+}
+
+func (d streamingDriver) ScanStream(_ context.Context, _ string, _ string, _ []string, _ types.ScanOptions,
+	handler func(report.Result)) (report.Results, *ftypes.OS, bool, error) {
+	for _, result := range d.results {
+		if handler != nil {
+			handler(result)
+		}
+	}
+	return d.results, nil, false, nil
+}
+
+func TestScanner_WithResultHandler(t *testing.T) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+	d := streamingDriver{results: report.Results{
+		{Target: "alpine:3.11 (alpine 3.11.5)", Type: "alpine", Vulnerabilities: []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+			{VulnerabilityID: "CVE-2019-14697", PkgName: "musl", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+		}},
+		{Target: "app/package-lock.json", Type: "npm", Vulnerabilities: []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2017-1000048", PkgName: "qs", Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+		}},
+	}}
+
+	var streamed report.Results
+	s := NewScanner(d, analyzer).WithResultHandler(func(result report.Result) {
+		streamed = append(streamed, result)
+	})
+	// without the generic archive deduplication, which returns new results
+	got, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os", "library"}, Severities: []string{"HIGH"},
+		IgnorePkgs: []string{"qs"}, KeepGenericDuplicates: true})
+	require.NoError(t, err)
+
+	// the streamed results are filtered as the returned ones
+	require.Len(t, streamed, 2)
+	require.Len(t, streamed[0].Vulnerabilities, 1)
+	assert.Equal(t, "CVE-2020-1967", streamed[0].Vulnerabilities[0].VulnerabilityID)
+	assert.Empty(t, streamed[1].Vulnerabilities)
+	require.Len(t, got, 2)
+	require.Len(t, got[0].Vulnerabilities, 1)
+	assert.Equal(t, "CVE-2020-1967", got[0].Vulnerabilities[0].VulnerabilityID)
+	assert.Empty(t, got[1].Vulnerabilities)
+
+	// the results of the driver aren't modified
+	assert.Len(t, d.results[0].Vulnerabilities, 2)
+}
+
+func TestScanner_ScanImage_Target(t *testing.T) {
+	tests := []struct {
+		name       string
+		imageInfo  ftypes.ImageReference
+		wantTarget string
+	}{
+		{
+			name:       "image name",
+			imageInfo:  ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:a187dde48cd2", LayerIDs: []string{"sha256:base"}},
+			wantTarget: "alpine:3.11",
+		},
+		{
+			name:       "image ID only",
+			imageInfo:  ftypes.ImageReference{ID: "sha256:a187dde48cd2", LayerIDs: []string{"sha256:base"}},
+			wantTarget: "sha256:a187dde48cd2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
 			analyzer := new(MockAnalyzer)
-			analyzer.ApplyAnalyzeExpectation(tt.analyzeExpectation)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args:    AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{Info: tt.imageInfo},
+			})
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{
+					Target:          tt.wantTarget,
+					ImageID:         "sha256:a187dde48cd2",
+					LayerIDs:        []string{"sha256:base"},
+					OptionsAnything: true,
+				},
+				Returns: ScanReturns{Results: report.Results{{Target: tt.wantTarget + " (alpine 3.11.5)"}}},
+			})
 
 			s := NewScanner(d, analyzer)
-			gotResults, err := s.ScanImage(tt.args.options)
+			results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.wantTarget+" (alpine 3.11.5)", results[0].Target)
+			d.AssertExpectations(t)
+		})
+	}
+}
+
+// incrementalDriver skips the known layers
+type incrementalDriver struct {
+	*MockDriver
+}
+
+func (incrementalDriver) ScansIncrementally() bool {
+	return true
+}
+
+func TestScanner_ScanImage_KnownLayers(t *testing.T) {
+	layerIDs := []string{"sha256:base", "sha256:runtime", "sha256:app"}
+	vulns := []types.DetectedVulnerability{
+		{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", Layer: ftypes.Layer{DiffID: "sha256:base"}},
+		{VulnerabilityID: "CVE-2019-10744", PkgName: "lodash", Layer: ftypes.Layer{DiffID: "sha256:app"}},
+	}
+
+	tests := []struct {
+		name                string
+		incremental         bool
+		knownLayers         []string
+		wantLayerIDs        []string
+		wantKnownLayers     []string
+		wantVulnerabilities []string
+	}{
+		{
+			name:                "incremental driver",
+			incremental:         true,
+			knownLayers:         []string{"sha256:other", "sha256:runtime", "sha256:base"},
+			wantLayerIDs:        []string{"sha256:app"},
+			wantKnownLayers:     []string{"sha256:base", "sha256:runtime"},
+			wantVulnerabilities: []string{"CVE-2019-10744"},
+		},
+		{
+			name:                "driver scanning all the layers",
+			knownLayers:         []string{"sha256:base"},
+			wantLayerIDs:        layerIDs,
+			wantVulnerabilities: []string{"CVE-2019-10744"},
+		},
+		{
+			name:                "known layer above an unknown one",
+			incremental:         true,
+			knownLayers:         []string{"sha256:runtime"},
+			wantLayerIDs:        layerIDs,
+			wantVulnerabilities: []string{"CVE-2020-1967", "CVE-2019-10744"},
+		},
+		{
+			name:                "no known layers",
+			incremental:         true,
+			wantLayerIDs:        layerIDs,
+			wantVulnerabilities: []string{"CVE-2020-1967", "CVE-2019-10744"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "app:1.0", ID: "sha256:app-image", LayerIDs: layerIDs},
+				},
+			})
+			d := new(MockDriver)
+			d.ApplyScanExpectation(ScanExpectation{
+				Args: ScanArgs{
+					Target:   "app:1.0",
+					ImageID:  "sha256:app-image",
+					LayerIDs: tt.wantLayerIDs,
+					OptionsMatchedBy: func(options types.ScanOptions) bool {
+						return assert.ObjectsAreEqual(tt.wantKnownLayers, options.KnownLayers)
+					},
+				},
+				Returns: ScanReturns{
+					Results: report.Results{{Target: "app/package-lock.json", Vulnerabilities: append([]types.DetectedVulnerability{}, vulns...)}},
+				},
+			})
+			var driver Driver = d
+			if tt.incremental {
+				driver = incrementalDriver{d}
+			}
+
+			s := NewScanner(driver, analyzer)
+			results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os", "library"}, KnownLayers: tt.knownLayers})
+			require.NoError(t, err)
+			d.AssertExpectations(t)
+
+			require.Len(t, results, 1)
+			var ids []string
+			for _, vuln := range results[0].Vulnerabilities {
+				ids = append(ids, vuln.VulnerabilityID)
+			}
+			assert.Equal(t, tt.wantVulnerabilities, ids)
+		})
+	}
+}
+
+func TestScanner_ScanImage_PartialResults(t *testing.T) {
+	libErr := errors.New("failed to scan application libraries: unable to parse the lock file")
+	osResult := report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Class: report.ClassOSPkgs,
+		Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"}}}}
+
+	tests := []struct {
+		name        string
+		vulnType    []string
+		osErr       error
+		wantResults report.Results
+		wantErrors  map[string]error
+		wantErr     string
+	}{
+		{
+			name:        "library scan fails",
+			vulnType:    []string{"os", "library"},
+			wantResults: osResult,
+			wantErrors:  map[string]error{"library": libErr},
+		},
+		{
+			name:     "all the scans fail",
+			vulnType: []string{"os", "library"},
+			osErr:    errors.New("failed to scan OS packages: DB error"),
+			wantErr:  "scan failed: failed to scan application libraries",
+		},
+		{
+			name:     "single vulnerability type",
+			vulnType: []string{"library"},
+			wantErr:  "scan failed: failed to scan application libraries",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+				},
+			})
+			vulnTypeArgs := func(vulnType ...string) ScanArgs {
+				return ScanArgs{
+					TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true,
+					OptionsMatchedBy: func(options types.ScanOptions) bool {
+						return assert.ObjectsAreEqual(vulnType, options.VulnType)
+					},
+				}
+			}
+			d := new(MockDriver)
+			d.ApplyScanExpectations([]ScanExpectation{
+				{Args: vulnTypeArgs("os", "library"), Returns: ScanReturns{Err: libErr}},
+				{Args: vulnTypeArgs("os"), Returns: ScanReturns{Results: osResult, Err: tt.osErr}},
+				{Args: vulnTypeArgs("library"), Returns: ScanReturns{Err: libErr}},
+			})
+
+			s := NewScanner(d, analyzer)
+			results, err := s.ScanImage(types.ScanOptions{VulnType: tt.vulnType})
 			if tt.wantErr != "" {
-				require.NotNil(t, err, tt.name)
-				require.Contains(t, err.Error(), tt.wantErr, tt.name)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, results)
 				return
-			} else {
-				require.NoError(t, err, tt.name)
 			}
+			var partialErr *PartialScanError
+			require.True(t, errors.As(err, &partialErr))
+			assert.Equal(t, tt.wantErrors, partialErr.Errors)
+			assert.Contains(t, err.Error(), "library: failed to scan application libraries")
+			assert.Equal(t, tt.wantResults, results)
+		})
+	}
+}
 
-			assert.Equal(t, tt.wantResults, gotResults, tt.name)
+func TestScanner_ScanImage_Timeout(t *testing.T) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		d := blockingDriver{started: make(chan struct{}), release: make(chan struct{})}
+		defer close(d.release)
+
+		s := NewScanner(d, analyzer)
+		start := time.Now()
+		_, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, Timeout: 50 * time.Millisecond, Retries: 2})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "scan of alpine:3.11 timed out after 50ms")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("finished within the timeout", func(t *testing.T) {
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args:    ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+			Returns: ScanReturns{Results: report.Results{{Target: "alpine:3.11 (alpine 3.11.5)"}}},
+		})
+
+		s := NewScanner(d, analyzer)
+		results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, Timeout: time.Minute})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+	})
+}
+
+// slowLibraryDriver scans the OS packages and blocks on the libraries until the release channel is closed
+type slowLibraryDriver struct {
+	osResults report.Results
+	release   chan struct{}
+}
+
+func (d slowLibraryDriver) Scan(_ string, _ string, _ []string, options types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	if len(options.VulnType) == 1 && options.VulnType[0] == "os" {
+		return d.osResults, &ftypes.OS{Family: "alpine", Name: "3.11.5"}, false, nil
+	}
+	<-d.release
+	return nil, nil, false, nil
+}
+
+func TestScanner_ScanImage_PartialResultsTimeout(t *testing.T) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+	osResults := report.Results{{Target: "alpine:3.11 (alpine 3.11.5)", Class: report.ClassOSPkgs,
+		Vulnerabilities: []types.DetectedVulnerability{{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"}}}}
+
+	tests := []struct {
+		name           string
+		partialResults bool
+		wantResults    int
+		wantErr        string
+	}{
+		{
+			name:           "partial results",
+			partialResults: true,
+			wantResults:    1,
+		},
+		{
+			name:    "without partial results",
+			wantErr: "scan cancelled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := slowLibraryDriver{osResults: osResults, release: make(chan struct{})}
+			defer close(d.release)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			s := NewScanner(d, analyzer)
+			r, err := s.ScanImageReport(ctx, types.ScanOptions{
+				VulnType:       []string{"os", "library"},
+				PartialResults: tt.partialResults,
+			})
+			results := r.Results
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			var partialErr *PartialScanError
+			require.True(t, errors.As(err, &partialErr))
+			assert.Equal(t, map[string]error{"library": context.DeadlineExceeded}, partialErr.Errors)
+			require.Len(t, results, tt.wantResults)
+			assert.Equal(t, "CVE-2020-1967", results[0].Vulnerabilities[0].VulnerabilityID)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// fsAnalyzer analyzes the directory only
+type fsAnalyzer struct {
+	*MockAnalyzer
+	root string
+}
+
+func (a *fsAnalyzer) AnalyzeFilesystem(_ context.Context, root string) (ftypes.ImageReference, error) {
+	a.root = root
+	return ftypes.ImageReference{Name: root, ID: "sha256:rootfs", LayerIDs: []string{"sha256:rootfs"}}, nil
+}
+
+func TestScanner_ScanFilesystem(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		analyzer := &fsAnalyzer{MockAnalyzer: new(MockAnalyzer)}
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args: ScanArgs{
+				Target:          "/srv/rootfs",
+				ImageID:         "sha256:rootfs",
+				LayerIDs:        []string{"sha256:rootfs"},
+				OptionsAnything: true,
+			},
+			Returns: ScanReturns{
+				Results: report.Results{{Target: "app/package-lock.json", Type: "npm"}},
+			},
+		})
+
+		s := NewScanner(d, analyzer)
+		got, err := s.ScanFilesystem(context.Background(), "/srv/rootfs", types.ScanOptions{VulnType: []string{"library"}, ScanConfig: true})
+		require.NoError(t, err)
+		assert.Equal(t, "/srv/rootfs", analyzer.root)
+		require.Len(t, got, 1)
+		assert.Equal(t, "app/package-lock.json", got[0].Target)
+		analyzer.AssertNotCalled(t, "Analyze", mock.Anything)
+	})
+
+	t.Run("sad path: not a filesystem analyzer", func(t *testing.T) {
+		s := NewScanner(new(MockDriver), new(MockAnalyzer))
+		_, err := s.ScanFilesystem(context.Background(), "/srv/rootfs", types.ScanOptions{VulnType: []string{"library"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't analyze filesystems")
+	})
+}
+
+// rootfsAnalyzer analyzes the root filesystem only
+type rootfsAnalyzer struct {
+	*MockAnalyzer
+	root string
+}
+
+func (a *rootfsAnalyzer) AnalyzeRootfs(_ context.Context, root string) (ftypes.ImageReference, error) {
+	a.root = root
+	return ftypes.ImageReference{Name: root, ID: "sha256:rootfs", LayerIDs: []string{"sha256:rootfs"}}, nil
+}
+
+func TestScanner_ScanRootfs(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		analyzer := &rootfsAnalyzer{MockAnalyzer: new(MockAnalyzer)}
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args: ScanArgs{
+				Target:          "/mnt/vm",
+				ImageID:         "sha256:rootfs",
+				LayerIDs:        []string{"sha256:rootfs"},
+				OptionsAnything: true,
+			},
+			Returns: ScanReturns{
+				Results: report.Results{{Target: "/mnt/vm (debian 10)", Class: report.ClassOSPkgs}},
+				OsFound: &ftypes.OS{Family: "debian", Name: "10"},
+			},
+		})
+
+		s := NewScanner(d, analyzer)
+		got, err := s.ScanRootfs(context.Background(), "/mnt/vm", types.ScanOptions{VulnType: []string{"os"}})
+		require.NoError(t, err)
+		assert.Equal(t, "/mnt/vm", analyzer.root)
+		require.Len(t, got, 1)
+		assert.Equal(t, "/mnt/vm (debian 10)", got[0].Target)
+		analyzer.AssertNotCalled(t, "Analyze", mock.Anything)
+	})
+
+	t.Run("sad path: not a rootfs analyzer", func(t *testing.T) {
+		s := NewScanner(new(MockDriver), new(MockAnalyzer))
+		_, err := s.ScanRootfs(context.Background(), "/mnt/vm", types.ScanOptions{VulnType: []string{"os"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't analyze root filesystems")
+	})
+}
+
+func TestScanner_ScanRepository(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not installed")
+		}
+		repo, err := ioutil.TempDir("", "repo")
+		require.NoError(t, err)
+		defer os.RemoveAll(repo)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repo, "Gemfile.lock"), []byte("GEM"), 0644))
+		for _, args := range [][]string{
+			{"init", "--quiet"},
+			{"add", "Gemfile.lock"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+		}
+
+		analyzer := &fsAnalyzer{MockAnalyzer: new(MockAnalyzer)}
+		d := new(MockDriver)
+		d.ApplyScanExpectation(ScanExpectation{
+			Args:    ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+			Returns: ScanReturns{Results: report.Results{{Target: "Gemfile.lock", Type: "bundler"}}},
+		})
+
+		s := NewScanner(d, analyzer)
+		got, err := s.ScanRepository(context.Background(), "file://"+filepath.ToSlash(repo), "", "", types.ScanOptions{VulnType: []string{"library"}})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "Gemfile.lock", got[0].Target)
+
+		// the clone is removed after the scan
+		require.NotEmpty(t, analyzer.root)
+		_, err = os.Stat(analyzer.root)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("sad path: no repository", func(t *testing.T) {
+		s := NewScanner(new(MockDriver), &fsAnalyzer{MockAnalyzer: new(MockAnalyzer)})
+		_, err := s.ScanRepository(context.Background(), "", "main", "", types.ScanOptions{VulnType: []string{"library"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to clone")
+	})
+}
+
+// retryingDriver records the retry delays of each scan
+type retryingDriver struct {
+	delays *[][]time.Duration
+}
+
+func (d retryingDriver) Scan(string, string, []string, types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	b := pkgUtils.NewJitteredBackOff(time.Second, 0, nil)
+	var delays []time.Duration
+	for i := 0; i < 3; i++ {
+		delays = append(delays, b.NextBackOff())
+	}
+	*d.delays = append(*d.delays, delays)
+	return report.Results{
+		{Target: "app/Gemfile.lock", Type: "bundler", Vulnerabilities: []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-0002", PkgName: "rails"}, {VulnerabilityID: "CVE-2020-0001", PkgName: "rack"},
+		}},
+		{Target: "app/package-lock.json", Type: "npm"},
+	}, nil, false, nil
+}
+
+func TestScanner_ScanImage_Seed(t *testing.T) {
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+	var delays [][]time.Duration
+	s := NewScanner(retryingDriver{delays: &delays}, analyzer)
+
+	options := types.ScanOptions{VulnType: []string{"library"}, Seed: 42}
+	first, err := s.ScanImageReport(context.Background(), options)
+	require.NoError(t, err)
+	second, err := s.ScanImageReport(context.Background(), options)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Results, second.Results)
+	require.Len(t, delays, 2)
+	assert.Equal(t, delays[0], delays[1])
+}
+
+func TestScanner_ScanImage_Retries(t *testing.T) {
+	transientErr := errors.New("unable to download the DB: connection reset by peer")
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "succeeds after retries",
+			retries:   3,
+			failures:  2,
+			err:       transientErr,
+			wantCalls: 3,
+		},
+		{
+			name:      "retries exhausted",
+			retries:   2,
+			failures:  5,
+			err:       transientErr,
+			wantCalls: 3,
+			wantErr:   "connection reset by peer",
+		},
+		{
+			name:      "no retries",
+			failures:  1,
+			err:       transientErr,
+			wantCalls: 1,
+			wantErr:   "connection reset by peer",
+		},
+		{
+			name:      "unsupported OS",
+			retries:   3,
+			failures:  1,
+			err:       xerrors.Errorf("failed vulnerability detection: %w", ospkgDetector.ErrUnsupportedOS),
+			wantCalls: 1,
+			wantErr:   "unsupported os",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := new(MockAnalyzer)
+			analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+				Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+				Returns: AnalyzerAnalyzeReturns{
+					Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+				},
+			})
+			args := ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true}
+			d := new(MockDriver)
+			d.ApplyScanExpectations([]ScanExpectation{
+				{Args: args, Returns: ScanReturns{Err: tt.err}, Times: tt.failures},
+				{Args: args, Returns: ScanReturns{Results: report.Results{{Target: "alpine:3.11 (alpine 3.11.5)"}}}},
+			})
+
+			s := NewScanner(d, analyzer)
+			results, err := s.ScanImage(types.ScanOptions{
+				VulnType:     []string{"os"},
+				Retries:      tt.retries,
+				RetryBackoff: time.Millisecond,
+			})
+			d.AssertNumberOfCalls(t, "Scan", tt.wantCalls)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+		})
+	}
+}
+
+func TestScanner_ScanImage_DebugLog(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	defer func(logger *zap.SugaredLogger) { log.Logger = logger }(log.Logger)
+	log.Logger = zap.New(core).Sugar()
+
+	analyzer := new(MockAnalyzer)
+	analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+		Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+		Returns: AnalyzerAnalyzeReturns{
+			Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: []string{"sha256:base"}},
+		},
+	})
+	d := new(MockDriver)
+	d.ApplyScanExpectation(ScanExpectation{
+		Args: ScanArgs{TargetAnything: true, ImageIDAnything: true, LayerIDsAnything: true, OptionsAnything: true},
+		Returns: ScanReturns{
+			Results: report.Results{
+				{Target: "alpine:3.11 (alpine 3.11.5)", Vulnerabilities: []types.DetectedVulnerability{
+					{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl"},
+				}},
+				{Target: "app/package-lock.json"},
+			},
+		},
+	})
+
+	s := NewScanner(d, analyzer)
+	_, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os", "library"}})
+	require.NoError(t, err)
+
+	for _, entry := range logs.All() {
+		assert.Equal(t, zap.DebugLevel, entry.Level, entry.Message)
+	}
+
+	analysis := logs.FilterMessage("Analysis finished").All()
+	require.Len(t, analysis, 1)
+	assert.Equal(t, "alpine:3.11", analysis[0].ContextMap()["target"])
+	assert.Contains(t, analysis[0].ContextMap(), "duration")
+
+	driver := logs.FilterMessage("Driver scan finished").All()
+	require.Len(t, driver, 1)
+	assert.Contains(t, driver[0].ContextMap(), "duration")
+
+	var targets []map[string]interface{}
+	for _, entry := range logs.FilterMessage("Target scanned").All() {
+		targets = append(targets, entry.ContextMap())
+	}
+	assert.Equal(t, []map[string]interface{}{
+		{"target": "alpine:3.11 (alpine 3.11.5)", "vulnerabilities": int64(1)},
+		{"target": "app/package-lock.json", "vulnerabilities": int64(0)},
+	}, targets)
+}
+
+// countingDriver counts the driver scans
+type countingDriver struct {
+	scans *int
+}
+
+func (d countingDriver) Scan(string, string, []string, types.ScanOptions) (report.Results, *ftypes.OS, bool, error) {
+	*d.scans++
+	return report.Results{
+		{Target: "alpine:3.11 (alpine 3.11.5)", Class: report.ClassOSPkgs, Vulnerabilities: []types.DetectedVulnerability{
+			{VulnerabilityID: "CVE-2020-1967", PkgName: "openssl", FixedVersion: "1.1.1g-r0",
+				Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"}},
+			{VulnerabilityID: "CVE-2020-28928", PkgName: "musl", Vulnerability: dbTypes.Vulnerability{Severity: "LOW"}},
+		}},
+	}, &ftypes.OS{Family: "alpine", Name: "3.11.5"}, true, nil
+}
+
+// mapResultCache is a ResultCache in memory
+type mapResultCache map[string][]byte
+
+func (c mapResultCache) Get(key string) ([]byte, bool, error) {
+	b, ok := c[key]
+	return b, ok, nil
+}
+
+func (c mapResultCache) Put(key string, value []byte) error {
+	c[key] = value
+	return nil
+}
+
+func TestScanner_ScanImage_ResultCache(t *testing.T) {
+	newAnalyzer := func(layerIDs ...string) *MockAnalyzer {
+		analyzer := new(MockAnalyzer)
+		analyzer.ApplyAnalyzeExpectation(AnalyzerAnalyzeExpectation{
+			Args: AnalyzerAnalyzeArgs{CtxAnything: true},
+			Returns: AnalyzerAnalyzeReturns{
+				Info: ftypes.ImageReference{Name: "alpine:3.11", ID: "sha256:alpine", LayerIDs: layerIDs},
+			},
 		})
+		return analyzer
 	}
+	var scans int
+	cache := mapResultCache{}
+	d := countingDriver{scans: &scans}
+	options := types.ScanOptions{VulnType: []string{"os"}}
+
+	first, err := NewScanner(d, newAnalyzer("sha256:base")).WithResultCache(cache).ScanImageReport(context.Background(), options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, scans)
+	assert.Len(t, cache, 1)
+
+	t.Run("unchanged image", func(t *testing.T) {
+		s := NewScanner(d, newAnalyzer("sha256:base")).WithResultCache(cache)
+		second, err := s.ScanImageReport(context.Background(), types.ScanOptions{VulnType: []string{"os"}, Parallel: 4, Retries: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 1, scans, "the cached result is reused")
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("filtered result", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			s := NewScanner(d, newAnalyzer("sha256:base")).WithResultCache(cache)
+			results, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, Severities: []string{"HIGH"}})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			require.Len(t, results[0].Vulnerabilities, 1)
+			assert.Equal(t, "CVE-2020-1967", results[0].Vulnerabilities[0].VulnerabilityID)
+		}
+		assert.Equal(t, 2, scans, "the result before the filters is cached")
+	})
+
+	t.Run("changed layers", func(t *testing.T) {
+		s := NewScanner(d, newAnalyzer("sha256:base", "sha256:app")).WithResultCache(cache)
+		_, err := s.ScanImage(options)
+		require.NoError(t, err)
+		assert.Equal(t, 3, scans)
+	})
+
+	t.Run("changed options", func(t *testing.T) {
+		s := NewScanner(d, newAnalyzer("sha256:base")).WithResultCache(cache)
+		_, err := s.ScanImage(types.ScanOptions{VulnType: []string{"os"}, ScanRemovedPackages: true})
+		require.NoError(t, err)
+		assert.Equal(t, 4, scans)
+	})
+
+	t.Run("without cache", func(t *testing.T) {
+		_, err := NewScanner(d, newAnalyzer("sha256:base")).ScanImage(options)
+		require.NoError(t, err)
+		assert.Equal(t, 5, scans)
+	})
 }
@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"github.com/aquasecurity/trivy-db/pkg/vulnsrc/vulnerability"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// severityScores are the middles of the CVSS v3 rating bands of the severities
+var severityScores = map[string]float64{
+	"LOW":      2.0,
+	"MEDIUM":   5.5,
+	"HIGH":     8.0,
+	"CRITICAL": 9.5,
+}
+
+func setNormalizedScores(results report.Results) {
+	for _, result := range results {
+		for i := range result.Vulnerabilities {
+			result.Vulnerabilities[i].NormalizedScore = normalizedScore(result.Vulnerabilities[i])
+		}
+	}
+}
+
+// normalizedScore returns the CVSS base score of the severity source, falling back to the one of NVD
+// and then to the highest one. Without a score, the severity is mapped into its CVSS v3 band.
+func normalizedScore(vuln types.DetectedVulnerability) float64 {
+	for _, source := range []string{vuln.SeveritySource, vulnerability.Nvd} {
+		if cvss, ok := vuln.CVSS[source]; ok && cvss.BaseScore() > 0 {
+			return cvss.BaseScore()
+		}
+	}
+	if score := vuln.CVSS.MaxScore(); score > 0 {
+		return score
+	}
+	return severityScores[vuln.Severity]
+}
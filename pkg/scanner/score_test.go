@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbTypes "github.com/aquasecurity/trivy-db/pkg/types"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestNormalizedScore(t *testing.T) {
+	tests := []struct {
+		name string
+		vuln types.DetectedVulnerability
+		want float64
+	}{
+		{
+			name: "score of the severity source",
+			vuln: types.DetectedVulnerability{
+				SeveritySource: "redhat",
+				CVSS: types.VendorCVSS{
+					"nvd":    {V3Score: 9.8},
+					"redhat": {V3Score: 7.5},
+				},
+				Vulnerability: dbTypes.Vulnerability{Severity: "HIGH"},
+			},
+			want: 7.5,
+		},
+		{
+			name: "NVD score when the severity source has none",
+			vuln: types.DetectedVulnerability{
+				SeveritySource: "alpine",
+				CVSS: types.VendorCVSS{
+					"nvd":    {V2Score: 5.0},
+					"redhat": {V3Score: 6.1},
+				},
+				Vulnerability: dbTypes.Vulnerability{Severity: "MEDIUM"},
+			},
+			want: 5.0,
+		},
+		{
+			name: "highest score of the other sources",
+			vuln: types.DetectedVulnerability{
+				CVSS: types.VendorCVSS{
+					"redhat": {V3Score: 6.1},
+					"ubuntu": {V3Score: 6.5},
+				},
+			},
+			want: 6.5,
+		},
+		{
+			name: "label only",
+			vuln: types.DetectedVulnerability{Vulnerability: dbTypes.Vulnerability{Severity: "CRITICAL"}},
+			want: 9.5,
+		},
+		{
+			name: "unknown",
+			vuln: types.DetectedVulnerability{Vulnerability: dbTypes.Vulnerability{Severity: "UNKNOWN"}},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizedScore(tt.vuln))
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package scanner
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/secret"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// SecretDetector is implemented by analyzers that can search the extracted files for secrets.
+// A nil scanner disables the detection.
+type SecretDetector interface {
+	SetSecretScanner(s *secret.Scanner)
+	// Secrets returns the secrets found by the last analysis by file path
+	Secrets() map[string][]types.SecretFinding
+}
+
+// allFiles is the file name pattern of all the files, extracted for the secret detection of the analysis only
+const allFiles = "*"
+
+// secretExtractor searches all the files of the layers for secrets when it has a scanner, the files not required by
+// the analyzers being dropped once searched
+type secretExtractor struct {
+	extractor.Extractor
+
+	mu       sync.Mutex
+	scanner  *secret.Scanner
+	findings map[string][]types.SecretFinding
+}
+
+func (e *secretExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	s := e.getScanner()
+	if s == nil {
+		return e.Extractor.ExtractLayerFiles(diffID, filenames)
+	}
+	layerDigest, files, opqDirs, whFiles, err := e.Extractor.ExtractLayerFiles(diffID,
+		append(append([]string{}, filenames...), allFiles))
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+
+	findings := map[string][]types.SecretFinding{}
+	for filename, content := range files {
+		for _, f := range s.Scan(filename, content) {
+			f.Layer = diffID
+			findings[filename] = append(findings[filename], f)
+		}
+		if !requiredFile(filename, filenames) {
+			delete(files, filename)
+		}
+	}
+	e.addFindings(findings)
+	return layerDigest, files, opqDirs, whFiles, nil
+}
+
+// requiredFile reports whether the file is one of the files, as the extractors match them: by path, by name,
+// by path.Match pattern of the name, or directly in one of the directories ending with "/"
+func requiredFile(filePath string, filenames []string) bool {
+	dir, name := path.Split(filePath)
+	for _, f := range filenames {
+		if strings.HasSuffix(f, "/") {
+			if path.Clean(f) == path.Clean(dir) {
+				return true
+			}
+			continue
+		}
+		if f == filePath || f == name {
+			return true
+		}
+		if ok, _ := path.Match(f, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *secretExtractor) setScanner(s *secret.Scanner) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scanner = s
+}
+
+func (e *secretExtractor) getScanner() *secret.Scanner {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.scanner
+}
+
+func (e *secretExtractor) addFindings(findings map[string][]types.SecretFinding) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.findings == nil {
+		e.findings = map[string][]types.SecretFinding{}
+	}
+	for filename, f := range findings {
+		e.findings[filename] = append(e.findings[filename], f...)
+	}
+}
+
+// takeFindings returns the findings since the last call
+func (e *secretExtractor) takeFindings() map[string][]types.SecretFinding {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	findings := e.findings
+	e.findings = nil
+	return findings
+}
+
+// fileScanCache reports all the layers missing while the secrets, the misconfigurations or the licenses are detected,
+// as the files of the cached layers aren't extracted
+type fileScanCache struct {
+	cache.ImageCache
+	secrets  *secretExtractor
+	misconfs *misconfExtractor
+	licenses *licenseExtractor
+}
+
+func (c fileScanCache) MissingLayers(imageID string, layerIDs []string) (bool, []string, error) {
+	missingImage, missingLayerIDs, err := c.ImageCache.MissingLayers(imageID, layerIDs)
+	if err != nil || !c.scanning() {
+		return missingImage, missingLayerIDs, err
+	}
+	return missingImage, layerIDs, nil
+}
+
+func (c fileScanCache) scanning() bool {
+	return (c.secrets != nil && c.secrets.getScanner() != nil) || (c.misconfs != nil && c.misconfs.getScanner() != nil) ||
+		(c.licenses != nil && c.licenses.isEnabled())
+}
+
+// hasSecurityCheck reports whether the scan runs the check; no checks only detect the vulnerabilities
+func hasSecurityCheck(options types.ScanOptions, check string) bool {
+	if len(options.SecurityChecks) == 0 {
+		return check == types.SecurityCheckVulnerability
+	}
+	for _, c := range options.SecurityChecks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
+// setSecretScanner enables the secret detection of the analyzer with SecurityCheckSecret, and disables it otherwise
+func (s Scanner) setSecretScanner(options types.ScanOptions) error {
+	detector, ok := s.analyzer.(SecretDetector)
+	if !hasSecurityCheck(options, types.SecurityCheckSecret) {
+		if ok {
+			detector.SetSecretScanner(nil)
+		}
+		return nil
+	}
+	if !ok {
+		log.Logger.Warn("The analyzer doesn't detect secrets")
+		return nil
+	}
+
+	var config secret.Config
+	if options.SecretConfig != "" {
+		var err error
+		if config, err = secret.LoadConfig(options.SecretConfig); err != nil {
+			return err
+		}
+	}
+	secretScanner, err := secret.NewScanner(config)
+	if err != nil {
+		return xerrors.Errorf("invalid secret config: %w", err)
+	}
+	detector.SetSecretScanner(secretScanner)
+	return nil
+}
+
+// secretResults returns a result of the class report.ClassSecret per file with secrets, sorted by path.
+// Only the secrets of the severities are kept unless there are none.
+func secretResults(findings map[string][]types.SecretFinding, severities []string) report.Results {
+	var paths []string
+	for filePath := range findings {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var results report.Results
+	for _, filePath := range paths {
+		var secrets []types.SecretFinding
+		for _, f := range findings[filePath] {
+			if len(severities) == 0 || hasSeverity(severities, f.Severity) {
+				secrets = append(secrets, f)
+			}
+		}
+		if len(secrets) == 0 {
+			continue
+		}
+		sort.SliceStable(secrets, func(i, j int) bool {
+			if secrets[i].StartLine != secrets[j].StartLine {
+				return secrets[i].StartLine < secrets[j].StartLine
+			}
+			return secrets[i].RuleID < secrets[j].RuleID
+		})
+		results = append(results, report.Result{
+			Target:  filePath,
+			Class:   report.ClassSecret,
+			Secrets: secrets,
+		})
+	}
+	return results
+}
+
+func hasSeverity(severities []string, severity string) bool {
+	for _, s := range severities {
+		if strings.EqualFold(s, severity) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/cache"
+	"github.com/aquasecurity/fanal/extractor"
+	"github.com/aquasecurity/trivy/pkg/report"
+	"github.com/aquasecurity/trivy/pkg/secret"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+const awsKey = "AKIA0123456789ABCDEF"
+
+func TestImageAnalyzer_Secrets(t *testing.T) {
+	a := NewImageAnalyzer(analyzer.Config{Extractor: patternExtractor{files: extractor.FileMap{
+		"app/.env":          []byte("DEBUG=1\nAWS_ACCESS_KEY_ID=" + awsKey + "\n"),
+		"app/settings.py":   []byte("AWS_KEY = '" + awsKey + "'\n"),
+		"app/package.json":  []byte(`{"name": "app"}`),
+		"root/.ssh/id_work": []byte("no secret"),
+	}}})
+
+	// disabled by default
+	_, files, _, _, err := a.Extractor.ExtractLayerFiles("sha256:base", []string{"package.json"})
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Empty(t, a.Secrets())
+
+	s, err := secret.NewScanner(secret.Config{})
+	require.NoError(t, err)
+	a.SetSecretScanner(s)
+	_, files, _, _, err = a.Extractor.ExtractLayerFiles("sha256:base", []string{"package.json"})
+	require.NoError(t, err)
+	assert.Equal(t, extractor.FileMap{"app/package.json": []byte(`{"name": "app"}`)}, files,
+		"the files searched for secrets only are dropped")
+	assert.Equal(t, map[string][]types.SecretFinding{
+		"app/.env": {
+			{
+				RuleID:    "aws-access-key-id",
+				Category:  "AWS",
+				Title:     "AWS Access Key ID",
+				Severity:  "CRITICAL",
+				StartLine: 2,
+				EndLine:   2,
+				Match:     "AWS_ACCESS_KEY_ID=*****",
+				Layer:     "sha256:base",
+			},
+		},
+		"app/settings.py": {
+			{
+				RuleID:    "aws-access-key-id",
+				Category:  "AWS",
+				Title:     "AWS Access Key ID",
+				Severity:  "CRITICAL",
+				StartLine: 1,
+				EndLine:   1,
+				Match:     "AWS_KEY = '*****'",
+				Layer:     "sha256:base",
+			},
+		},
+	}, a.Secrets())
+	assert.Empty(t, a.Secrets(), "secrets are returned once")
+}
+
+// patternExtractor returns the files of the single layer matching the file names as the extractors match them
+type patternExtractor struct {
+	extractor.Extractor
+	files extractor.FileMap
+}
+
+func (e patternExtractor) ExtractLayerFiles(diffID string, filenames []string) (string, extractor.FileMap, []string, []string, error) {
+	files := extractor.FileMap{}
+	for filePath, content := range e.files {
+		if requiredFile(filePath, filenames) {
+			files[filePath] = content
+		}
+	}
+	return diffID, files, nil, nil, nil
+}
+
+func TestRequiredFile(t *testing.T) {
+	filenames := []string{"package-lock.json", "etc/os-release", "var/lib/dpkg/status.d/", "*.jar"}
+	assert.True(t, requiredFile("app/package-lock.json", filenames))
+	assert.True(t, requiredFile("etc/os-release", filenames))
+	assert.True(t, requiredFile("var/lib/dpkg/status.d/base", filenames))
+	assert.True(t, requiredFile("app/lib/app.jar", filenames))
+	assert.False(t, requiredFile("var/lib/dpkg/status.d/nested/base", filenames))
+	assert.False(t, requiredFile("app/settings.py", filenames))
+}
+
+// fixedCache reports the missing layers it is given
+type fixedCache struct {
+	cache.ImageCache
+	missingLayerIDs []string
+}
+
+func (c fixedCache) MissingLayers(_ string, _ []string) (bool, []string, error) {
+	return false, c.missingLayerIDs, nil
+}
+
+func TestFileScanCache_MissingLayers(t *testing.T) {
+	secrets := &secretExtractor{}
+	c := fileScanCache{ImageCache: fixedCache{missingLayerIDs: []string{"sha256:app"}}, secrets: secrets}
+	layerIDs := []string{"sha256:base", "sha256:app"}
+
+	_, missing, err := c.MissingLayers("sha256:image", layerIDs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sha256:app"}, missing)
+
+	// the cached layers are extracted again to search them
+	s, err := secret.NewScanner(secret.Config{})
+	require.NoError(t, err)
+	secrets.setScanner(s)
+	_, missing, err = c.MissingLayers("sha256:image", layerIDs)
+	require.NoError(t, err)
+	assert.Equal(t, layerIDs, missing)
+}
+
+func TestSecretResults(t *testing.T) {
+	findings := map[string][]types.SecretFinding{
+		"root/.ssh/id_rsa": {
+			{RuleID: "private-key", Severity: "HIGH", StartLine: 1, EndLine: 27},
+		},
+		"app/.env": {
+			{RuleID: "github-pat", Severity: "CRITICAL", StartLine: 3, EndLine: 3},
+			{RuleID: "aws-access-key-id", Severity: "CRITICAL", StartLine: 1, EndLine: 1},
+		},
+	}
+
+	t.Run("all severities", func(t *testing.T) {
+		assert.Equal(t, report.Results{
+			{
+				Target: "app/.env",
+				Class:  report.ClassSecret,
+				Secrets: []types.SecretFinding{
+					{RuleID: "aws-access-key-id", Severity: "CRITICAL", StartLine: 1, EndLine: 1},
+					{RuleID: "github-pat", Severity: "CRITICAL", StartLine: 3, EndLine: 3},
+				},
+			},
+			{
+				Target: "root/.ssh/id_rsa",
+				Class:  report.ClassSecret,
+				Secrets: []types.SecretFinding{
+					{RuleID: "private-key", Severity: "HIGH", StartLine: 1, EndLine: 27},
+				},
+			},
+		}, secretResults(findings, nil))
+	})
+
+	t.Run("severities", func(t *testing.T) {
+		got := secretResults(findings, []string{"HIGH"})
+		require.Len(t, got, 1)
+		assert.Equal(t, "root/.ssh/id_rsa", got[0].Target)
+	})
+}
+
+func TestHasSecurityCheck(t *testing.T) {
+	assert.True(t, hasSecurityCheck(types.ScanOptions{}, types.SecurityCheckVulnerability))
+	assert.False(t, hasSecurityCheck(types.ScanOptions{}, types.SecurityCheckSecret))
+
+	options := types.ScanOptions{SecurityChecks: []string{types.SecurityCheckSecret}}
+	assert.False(t, hasSecurityCheck(options, types.SecurityCheckVulnerability))
+	assert.True(t, hasSecurityCheck(options, types.SecurityCheckSecret))
+}
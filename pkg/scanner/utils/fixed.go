@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+
+	fos "github.com/aquasecurity/fanal/analyzer/os"
+	debVersion "github.com/knqyf263/go-deb-version"
+	rpmVersion "github.com/knqyf263/go-rpm-version"
+	"github.com/knqyf263/go-version"
+	"golang.org/x/xerrors"
+)
+
+// IsFixed reports whether the installed version is fixed according to the fixed version of a vulnerability.
+// OS packages have a single fixed version which is compared with the version scheme of the OS family.
+// Language packages may have multiple fixed versions or ranges (e.g. "~> 5.2.4.3, >= 6.0.3.1"),
+// each of which is regarded as ">= version" when it has no operator.
+func IsFixed(resultType, installedVersion, fixedVersion string) (bool, error) {
+	if fixedVersion == "" {
+		return false, nil
+	}
+
+	switch resultType {
+	case fos.Debian, fos.Ubuntu, fos.Alpine, fos.Amazon:
+		installed, err := debVersion.NewVersion(installedVersion)
+		if err != nil {
+			return false, xerrors.Errorf("failed to parse the installed version: %w", err)
+		}
+		fixed, err := debVersion.NewVersion(fixedVersion)
+		if err != nil {
+			return false, xerrors.Errorf("failed to parse the fixed version: %w", err)
+		}
+		return !installed.LessThan(fixed), nil
+	case fos.RedHat, fos.CentOS, fos.Fedora, fos.Oracle, fos.Photon,
+		fos.OpenSUSE, fos.OpenSUSELeap, fos.OpenSUSETumbleweed, fos.SLES:
+		installed := rpmVersion.NewVersion(installedVersion)
+		fixed := rpmVersion.NewVersion(fixedVersion)
+		return !installed.LessThan(fixed), nil
+	}
+
+	installed, err := version.NewVersion(replacer.Replace(installedVersion))
+	if err != nil {
+		return false, xerrors.Errorf("failed to parse the installed version: %w", err)
+	}
+	for _, constraint := range fixedConstraints(fixedVersion) {
+		c, err := version.NewConstraint(replacer.Replace(constraint))
+		if err != nil {
+			return false, xerrors.Errorf("failed to parse the fixed version (%s): %w", constraint, err)
+		}
+		if c.Check(installed) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fixedConstraints splits comma-separated fixed versions into alternative constraints.
+// An upper bound is combined with the preceding lower bound,
+// e.g. ">= 3.0.0, < 3.8.2, >= 4.1.0" => {">= 3.0.0, < 3.8.2", ">= 4.1.0"}
+func fixedConstraints(fixedVersion string) []string {
+	var constraints []string
+	for _, s := range strings.Split(fixedVersion, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if strings.HasPrefix(s, "<") && len(constraints) > 0 {
+			constraints[len(constraints)-1] += ", " + s
+			continue
+		}
+		if strings.IndexAny(s[:1], "<>=!~^") < 0 {
+			s = ">= " + s
+		}
+		constraints = append(constraints, s)
+	}
+	return constraints
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsFixed(t *testing.T) {
+	testCases := []struct {
+		name             string
+		resultType       string
+		installedVersion string
+		fixedVersion     string
+		want             bool
+		wantErr          string
+	}{
+		{
+			name:             "npm range, not fixed",
+			resultType:       "npm",
+			installedVersion: "3.3.9",
+			fixedVersion:     ">=3.4.0",
+			want:             false,
+		},
+		{
+			name:             "npm range, fixed",
+			resultType:       "npm",
+			installedVersion: "3.5.0",
+			fixedVersion:     ">=3.4.0",
+			want:             true,
+		},
+		{
+			name:             "multiple ranges with an upper bound",
+			resultType:       "npm",
+			installedVersion: "3.9.0",
+			fixedVersion:     ">= 3.0.0, < 3.8.2, >= 4.1.0",
+			want:             false,
+		},
+		{
+			name:             "multiple ranges, fixed in a later range",
+			resultType:       "npm",
+			installedVersion: "4.2.0",
+			fixedVersion:     ">= 3.0.0, < 3.8.2, >= 4.1.0",
+			want:             true,
+		},
+		{
+			name:             "pessimistic constraint",
+			resultType:       "bundler",
+			installedVersion: "5.2.4.4",
+			fixedVersion:     "~> 5.2.4.3, >= 6.0.3.1",
+			want:             true,
+		},
+		{
+			name:             "plain version",
+			resultType:       "pipenv",
+			installedVersion: "2.19.0",
+			fixedVersion:     "2.20.0",
+			want:             false,
+		},
+		{
+			name:             "debian",
+			resultType:       "debian",
+			installedVersion: "1.1.0f-3+deb9u2",
+			fixedVersion:     "1.1.0f-3+deb9u1",
+			want:             true,
+		},
+		{
+			name:             "centos",
+			resultType:       "centos",
+			installedVersion: "1:1.0.2k-8.el7",
+			fixedVersion:     "1:1.0.2k-16.el7",
+			want:             false,
+		},
+		{
+			name:             "no fixed version",
+			resultType:       "npm",
+			installedVersion: "1.0.0",
+			want:             false,
+		},
+		{
+			name:             "sad path: invalid constraint",
+			resultType:       "npm",
+			installedVersion: "1.0.0",
+			fixedVersion:     ">= !!",
+			wantErr:          "failed to parse the fixed version",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := IsFixed(tc.resultType, tc.installedVersion, tc.fixedVersion)
+			if tc.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// vendorStatuses maps the states of the vendors to the statuses of the vulnerabilities,
+// e.g. "Will not fix" of Red Hat, "deferred" of Ubuntu or "ignored" of the Debian security tracker
+var vendorStatuses = map[string]string{
+	"affected":     types.StatusAffected,
+	"needed":       types.StatusAffected,
+	"open":         types.StatusAffected,
+	"will not fix": types.StatusWillNotFix,
+	"will_not_fix": types.StatusWillNotFix,
+	"ignored":      types.StatusWillNotFix,
+	"fix deferred": types.StatusFixDeferred,
+	"fix_deferred": types.StatusFixDeferred,
+	"deferred":     types.StatusFixDeferred,
+	"postponed":    types.StatusFixDeferred,
+	"end of life":  types.StatusEndOfLife,
+	"end-of-life":  types.StatusEndOfLife,
+	"end_of_life":  types.StatusEndOfLife,
+}
+
+// VendorStatus returns the status of the state of the vendor, empty when it is unknown
+func VendorStatus(state string) string {
+	return vendorStatuses[strings.ToLower(strings.TrimSpace(state))]
+}
+
+// StatusGetter returns the statuses of the advisories of a package of a release by vulnerability ID
+type StatusGetter interface {
+	Get(release, pkgName string) (map[string]string, error)
+}
+
+// AdvisoryStatuses reads the states of the vendors recorded with the advisories of a platform, in their Status or
+// State, which the advisories of the DB don't expose. The DBs without them have no statuses.
+type AdvisoryStatuses struct {
+	dbc            db.Operation
+	platformFormat string
+}
+
+// NewAdvisoryStatuses returns the reader of the advisories of the buckets of the format, e.g. "debian %s"
+func NewAdvisoryStatuses(platformFormat string) AdvisoryStatuses {
+	return AdvisoryStatuses{dbc: db.Config{}, platformFormat: platformFormat}
+}
+
+// Get returns the statuses of the advisories of the package by vulnerability ID
+func (s AdvisoryStatuses) Get(release, pkgName string) (map[string]string, error) {
+	advisories, err := s.dbc.ForEachAdvisory(fmt.Sprintf(s.platformFormat, release), pkgName)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get the advisories: %w", err)
+	}
+	statuses := map[string]string{}
+	for vulnID, value := range advisories {
+		var advisory struct {
+			Status interface{}
+			State  string
+		}
+		if err = json.Unmarshal(value, &advisory); err != nil {
+			return nil, xerrors.Errorf("failed to unmarshal the advisory of %s: %w", vulnID, err)
+		}
+		state, _ := advisory.Status.(string)
+		if state == "" {
+			state = advisory.State
+		}
+		if status := VendorStatus(state); status != "" {
+			statuses[vulnID] = status
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy-db/pkg/db"
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+func TestVendorStatus(t *testing.T) {
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{state: "Will not fix", want: types.StatusWillNotFix},
+		{state: " deferred ", want: types.StatusFixDeferred},
+		{state: "Fix deferred", want: types.StatusFixDeferred},
+		{state: "needed", want: types.StatusAffected},
+		{state: "end-of-life", want: types.StatusEndOfLife},
+		{state: "released", want: ""},
+		{state: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			assert.Equal(t, tt.want, VendorStatus(tt.state))
+		})
+	}
+}
+
+func TestAdvisoryStatuses_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		forEach db.ForEachAdvisoryExpectation
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			forEach: db.ForEachAdvisoryExpectation{
+				Args: db.ForEachAdvisoryArgs{Source: "ubuntu 18.04", PkgName: "openssl"},
+				Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{
+					"CVE-2019-1543": []byte(`{"VulnerabilityID":"CVE-2019-1543","Status":"Will not fix"}`),
+					"CVE-2019-1547": []byte(`{"State":"deferred"}`),
+					"CVE-2019-1549": []byte(`{"FixedVersion":"1.1.1-1ubuntu2.1~18.04.5"}`),
+					"CVE-2019-1551": []byte(`{"Status":3,"State":"needed"}`),
+				}},
+			},
+			want: map[string]string{
+				"CVE-2019-1543": types.StatusWillNotFix,
+				"CVE-2019-1547": types.StatusFixDeferred,
+				"CVE-2019-1551": types.StatusAffected,
+			},
+		},
+		{
+			name: "sad path: ForEachAdvisory returns an error",
+			forEach: db.ForEachAdvisoryExpectation{
+				Args:    db.ForEachAdvisoryArgs{Source: "ubuntu 18.04", PkgName: "openssl"},
+				Returns: db.ForEachAdvisoryReturns{Err: xerrors.New("error")},
+			},
+			wantErr: "failed to get the advisories",
+		},
+		{
+			name: "sad path: invalid advisory",
+			forEach: db.ForEachAdvisoryExpectation{
+				Args: db.ForEachAdvisoryArgs{Source: "ubuntu 18.04", PkgName: "openssl"},
+				Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{
+					"CVE-2019-1543": []byte(`{`),
+				}},
+			},
+			wantErr: "failed to unmarshal the advisory of CVE-2019-1543",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(db.MockOperation)
+			mockDB.ApplyForEachAdvisoryExpectation(tt.forEach)
+
+			s := AdvisoryStatuses{dbc: mockDB, platformFormat: "ubuntu %s"}
+			got, err := s.Get("18.04", "openssl")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
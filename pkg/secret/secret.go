@@ -0,0 +1,240 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/types"
+)
+
+// Rule detects a kind of secret with a regular expression.
+// Only the group named "secret" is masked when the expression has one, otherwise the whole match.
+type Rule struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	Regex    string `json:"regex"`
+}
+
+// AllowRule ignores the files whose path matches Path, or the secrets matching Regex, e.g. the example keys of a README.
+// Both are regular expressions; an empty one doesn't match.
+type AllowRule struct {
+	ID    string `json:"id"`
+	Path  string `json:"path"`
+	Regex string `json:"regex"`
+}
+
+// Config customizes the secret detection, e.g.
+// {"rules": [{"id": "slack-token", "severity": "HIGH", "regex": "xox[baprs]-[0-9a-zA-Z-]+"}], "allow-rules": [{"path": "^test/"}]}
+type Config struct {
+	// Rules are added to BuiltinRules, replacing the built-in rules with the same ID
+	Rules []Rule `json:"rules"`
+	// DisableRules are the IDs of the rules not applied
+	DisableRules []string    `json:"disable-rules"`
+	AllowRules   []AllowRule `json:"allow-rules"`
+}
+
+// MaxFileSize is the size limit of the files searched for secrets, the larger ones being skipped, e.g. databases
+const MaxFileSize = 10 << 20
+
+// BuiltinRules are the rules applied unless disabled
+var BuiltinRules = []Rule{
+	{
+		ID:       "aws-access-key-id",
+		Category: "AWS",
+		Title:    "AWS Access Key ID",
+		Severity: "CRITICAL",
+		Regex:    `(?P<secret>\b(A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b)`,
+	},
+	{
+		ID:       "aws-secret-access-key",
+		Category: "AWS",
+		Title:    "AWS Secret Access Key",
+		Severity: "CRITICAL",
+		Regex:    `(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})\b`,
+	},
+	{
+		ID:       "github-pat",
+		Category: "GitHub",
+		Title:    "GitHub Personal Access Token",
+		Severity: "CRITICAL",
+		Regex:    `(?P<secret>\bghp_[0-9a-zA-Z]{36}\b)`,
+	},
+	{
+		ID:       "github-token",
+		Category: "GitHub",
+		Title:    "GitHub OAuth, App or Refresh Token",
+		Severity: "CRITICAL",
+		Regex:    `(?P<secret>\bgh[ousr]_[0-9a-zA-Z]{36}\b)`,
+	},
+	{
+		ID:       "private-key",
+		Category: "AsymmetricPrivateKey",
+		Title:    "Asymmetric Private Key",
+		Severity: "HIGH",
+		Regex:    `-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY( BLOCK)?-----\s*(?P<secret>(?s:.+?))\s*-----END[ A-Z0-9_-]{0,100}PRIVATE KEY( BLOCK)?-----`,
+	},
+}
+
+// mask replaces the secrets in Match, whatever their length
+const mask = "*****"
+
+// LoadConfig reads the config from a JSON file
+func LoadConfig(filePath string) (Config, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Config{}, xerrors.Errorf("failed to open the secret config: %w", err)
+	}
+	defer f.Close()
+
+	var config Config
+	if err = json.NewDecoder(f).Decode(&config); err != nil {
+		return Config{}, xerrors.Errorf("failed to decode the secret config: %w", err)
+	}
+	return config, nil
+}
+
+type rule struct {
+	Rule
+	regex *regexp.Regexp
+}
+
+type allowRule struct {
+	path  *regexp.Regexp
+	regex *regexp.Regexp
+}
+
+// Scanner searches the text files for the secrets matching its rules
+type Scanner struct {
+	rules      []rule
+	allowRules []allowRule
+}
+
+// NewScanner compiles the rules of the config with the built-in ones
+func NewScanner(config Config) (*Scanner, error) {
+	disabled := map[string]bool{}
+	for _, id := range config.DisableRules {
+		disabled[id] = true
+	}
+	custom := map[string]bool{}
+	for _, r := range config.Rules {
+		custom[r.ID] = true
+	}
+
+	s := &Scanner{}
+	var rules []Rule
+	for _, r := range BuiltinRules {
+		if !custom[r.ID] {
+			rules = append(rules, r)
+		}
+	}
+	for _, r := range append(rules, config.Rules...) {
+		if disabled[r.ID] {
+			continue
+		}
+		if r.ID == "" || r.Regex == "" {
+			return nil, xerrors.Errorf("the rule %q has no ID or no regex", r.ID)
+		}
+		regex, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid regex of the rule %s: %w", r.ID, err)
+		}
+		s.rules = append(s.rules, rule{Rule: r, regex: regex})
+	}
+
+	for _, r := range config.AllowRules {
+		var a allowRule
+		var err error
+		if r.Path != "" {
+			if a.path, err = regexp.Compile(r.Path); err != nil {
+				return nil, xerrors.Errorf("invalid path of the allow rule %s: %w", r.ID, err)
+			}
+		}
+		if r.Regex != "" {
+			if a.regex, err = regexp.Compile(r.Regex); err != nil {
+				return nil, xerrors.Errorf("invalid regex of the allow rule %s: %w", r.ID, err)
+			}
+		}
+		s.allowRules = append(s.allowRules, a)
+	}
+	return s, nil
+}
+
+// Scan returns the secrets in the content of the file, sorted by line.
+// Binary files and the files larger than MaxFileSize are skipped.
+func (s *Scanner) Scan(filePath string, content []byte) []types.SecretFinding {
+	if len(content) > MaxFileSize || isBinary(content) {
+		return nil
+	}
+	for _, a := range s.allowRules {
+		if a.path != nil && a.path.MatchString(filePath) {
+			return nil
+		}
+	}
+
+	var findings []types.SecretFinding
+	for _, r := range s.rules {
+		group := r.regex.SubexpIndex("secret")
+		for _, loc := range r.regex.FindAllSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			if group > 0 && loc[2*group] >= 0 {
+				start, end = loc[2*group], loc[2*group+1]
+			}
+			if s.allowed(content[start:end]) {
+				continue
+			}
+			findings = append(findings, newFinding(r.Rule, content, loc[0], loc[1], start, end))
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].StartLine < findings[j].StartLine
+	})
+	return findings
+}
+
+func (s *Scanner) allowed(secret []byte) bool {
+	for _, a := range s.allowRules {
+		if a.regex != nil && a.regex.Match(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// newFinding returns the finding of the match in [matchStart, matchEnd) with the secret in [start, end) masked
+// in the lines of the match
+func newFinding(r Rule, content []byte, matchStart, matchEnd, start, end int) types.SecretFinding {
+	lineStart := bytes.LastIndexByte(content[:matchStart], '\n') + 1
+	lineEnd := len(content)
+	if i := bytes.IndexByte(content[matchEnd:], '\n'); i >= 0 {
+		lineEnd = matchEnd + i
+	}
+	startLine := bytes.Count(content[:matchStart], []byte("\n")) + 1
+
+	match := string(content[lineStart:start]) + mask + string(content[end:lineEnd])
+	return types.SecretFinding{
+		RuleID:    r.ID,
+		Category:  r.Category,
+		Title:     r.Title,
+		Severity:  r.Severity,
+		StartLine: startLine,
+		EndLine:   startLine + bytes.Count(content[matchStart:matchEnd], []byte("\n")),
+		Match:     strings.TrimSpace(match),
+	}
+}
+
+// isBinary reports whether the content has a NUL byte in its beginning, as git detects binary files
+func isBinary(content []byte) bool {
+	head := content
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	return bytes.IndexByte(head, 0) >= 0
+}
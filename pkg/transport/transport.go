@@ -0,0 +1,159 @@
+// Package transport configures http.DefaultTransport, which the registry pulls, the DB downloads, the client of
+// a server and the webhooks share, so that the proxy and the TLS options apply to all the outbound connections.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+// Options are the TLS options of the outbound connections
+type Options struct {
+	// CACert is a PEM file of the CA certificates trusted in addition to the system ones,
+	// e.g. of a proxy inspecting TLS
+	CACert string
+	// InsecureRegistries are the hosts whose certificates aren't verified, e.g. registry.local:5000
+	InsecureRegistries []string
+}
+
+// Configure applies the options to http.DefaultTransport.
+// The proxy is the one of HTTPS_PROXY, HTTP_PROXY and NO_PROXY whatever the options.
+func Configure(opts Options) error {
+	if opts.CACert != "" {
+		if err := AddCACert(opts.CACert); err != nil {
+			return err
+		}
+	}
+	for _, host := range opts.InsecureRegistries {
+		if err := AddInsecureHost(host); err != nil {
+			return err
+		}
+		log.Logger.Warnf("The certificate of %s isn't verified", host)
+	}
+	return nil
+}
+
+// AddCACert trusts the certificates of the PEM file in addition to the system ones and the files added before
+func AddCACert(path string) error {
+	t, err := defaultTransport()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.caCerts[path] {
+		return nil
+	}
+
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return xerrors.Errorf("failed to read the CA certificate: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		return xerrors.Errorf("no PEM certificate in %s", path)
+	}
+	t.pems = append(t.pems, pem)
+	t.caCerts[path] = true
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, pem := range t.pems {
+		pool.AppendCertsFromPEM(pem)
+	}
+	// the transport is replaced rather than modified, as its TLS config is read by the connections being dialed
+	secure := t.secure.Clone()
+	tlsConfig := &tls.Config{}
+	if secure.TLSClientConfig != nil {
+		tlsConfig = secure.TLSClientConfig.Clone()
+	}
+	tlsConfig.RootCAs = pool
+	secure.TLSClientConfig = tlsConfig
+	t.secure = secure
+	return nil
+}
+
+// AddInsecureHost skips the verification of the certificates of the host, with or without its port,
+// e.g. registry.local:5000 for that port only or registry.local for all of them
+func AddInsecureHost(host string) error {
+	if host == "" || strings.Contains(host, "/") {
+		return xerrors.Errorf("invalid host %q, expected a host with an optional port, e.g. registry.local:5000", host)
+	}
+	t, err := defaultTransport()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hosts[host] = true
+	return nil
+}
+
+// ExplainTLSError adds how to trust the server to the certificate verification failures,
+// e.g. of the DB download through a proxy inspecting TLS
+func ExplainTLSError(err error) error {
+	if err == nil || !strings.Contains(err.Error(), "x509: ") {
+		return err
+	}
+	return xerrors.Errorf("the certificate could not be verified; "+
+		"specify the CA of the server or of the proxy inspecting TLS with --custom-ca-cert: %w", err)
+}
+
+// hostTransport is http.DefaultTransport once configured, the transport it replaced with the CA certificates,
+// and another one skipping the verification for the insecure hosts
+type hostTransport struct {
+	mu       sync.RWMutex
+	secure   *http.Transport
+	insecure *http.Transport
+	hosts    map[string]bool
+	// caCerts are the paths of the CA certificates, whose PEM contents are pems
+	caCerts map[string]bool
+	pems    [][]byte
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	rt := t.secure
+	if t.hosts[req.URL.Host] || t.hosts[req.URL.Hostname()] {
+		rt = t.insecure
+	}
+	t.mu.RUnlock()
+	return rt.RoundTrip(req)
+}
+
+var installMu sync.Mutex
+
+// defaultTransport returns http.DefaultTransport, replaced with a hostTransport the first time
+func defaultTransport() (*hostTransport, error) {
+	installMu.Lock()
+	defer installMu.Unlock()
+
+	switch t := http.DefaultTransport.(type) {
+	case *hostTransport:
+		return t, nil
+	case *http.Transport:
+		secure := t.Clone()
+		secure.Proxy = http.ProxyFromEnvironment
+		insecure := secure.Clone()
+		insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		ht := &hostTransport{
+			secure:   secure,
+			insecure: insecure,
+			hosts:    map[string]bool{},
+			caCerts:  map[string]bool{},
+		}
+		http.DefaultTransport = ht
+		return ht, nil
+	default:
+		return nil, xerrors.New("the default HTTP transport is replaced")
+	}
+}
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/trivy/pkg/log"
+)
+
+func get(t *testing.T, rawurl string) error {
+	resp, err := http.Get(rawurl)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	require.NoError(t, log.InitLogger(false, true))
+	defaultTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	registry := httptest.NewTLSServer(handler)
+	defer registry.Close()
+	mirror := httptest.NewTLSServer(handler)
+	defer mirror.Close()
+	registryURL, err := url.Parse(registry.URL)
+	require.NoError(t, err)
+
+	// the self-signed certificates are rejected by default
+	err = get(t, mirror.URL)
+	require.Error(t, err)
+	assert.Contains(t, ExplainTLSError(err).Error(), "--custom-ca-cert")
+
+	// only the insecure registry isn't verified
+	require.NoError(t, Configure(Options{InsecureRegistries: []string{registryURL.Host}}))
+	assert.NoError(t, get(t, registry.URL))
+	assert.Error(t, get(t, mirror.URL))
+
+	dir, err := ioutil.TempDir("", "trivy-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caCert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mirror.Certificate().Raw}), 0600))
+
+	require.NoError(t, Configure(Options{CACert: caCert}))
+	require.NoError(t, AddCACert(caCert), "adding the same file again does nothing")
+	assert.NoError(t, get(t, mirror.URL))
+	assert.NoError(t, get(t, registry.URL))
+
+	// the proxy of the environment is kept whatever the options
+	ht := http.DefaultTransport.(*hostTransport)
+	assert.NotNil(t, ht.secure.Proxy)
+	assert.NotNil(t, ht.insecure.Proxy)
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	dir, err := ioutil.TempDir("", "trivy-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caCert, []byte("not a certificate"), 0600))
+
+	err = Configure(Options{CACert: caCert})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no PEM certificate")
+
+	err = Configure(Options{CACert: filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+
+	err = Configure(Options{InsecureRegistries: []string{"https://registry.local"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid host")
+}
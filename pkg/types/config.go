@@ -0,0 +1,8 @@
+package types
+
+// ConfigFinding is an observation about the image configuration, e.g. running as root
+type ConfigFinding struct {
+	ID       string `json:",omitempty"`
+	Title    string `json:",omitempty"`
+	Severity string `json:",omitempty"`
+}
@@ -0,0 +1,34 @@
+package types
+
+// CVSS has the base scores of a vulnerability given by a source
+type CVSS struct {
+	// V3Vector is the CVSS v3 vector given by a supplementary advisory, the DB has no vectors
+	V3Vector string  `json:",omitempty"`
+	V2Score  float64 `json:",omitempty"`
+	V3Score  float64 `json:",omitempty"`
+	// Severity is the severity the source rates the vulnerability, see ScanOptions.SeveritySource
+	Severity string `json:",omitempty"`
+}
+
+// VendorCVSS maps a source (e.g. nvd, redhat) to its scores.
+// encoding/json writes the sources sorted by name, so the JSON output is the same across runs.
+type VendorCVSS map[string]CVSS
+
+// BaseScore returns the CVSS v3 score, or the v2 score when the source has no v3 score
+func (c CVSS) BaseScore() float64 {
+	if c.V3Score != 0 {
+		return c.V3Score
+	}
+	return c.V2Score
+}
+
+// MaxScore returns the highest base score among the sources
+func (v VendorCVSS) MaxScore() float64 {
+	var max float64
+	for _, c := range v {
+		if s := c.BaseScore(); s > max {
+			max = s
+		}
+	}
+	return max
+}
@@ -5,20 +5,39 @@ import (
 
 	"github.com/aquasecurity/fanal/types"
 	"github.com/caarlos0/env/v6"
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/transport"
 )
 
+// DefaultDockerTimeout is the timeout of the pull of an image without one
+const DefaultDockerTimeout = 2 * time.Minute
+
 type DockerConfig struct {
 	UserName string `env:"TRIVY_USERNAME"`
 	Password string `env:"TRIVY_PASSWORD"`
 	Insecure bool   `env:"TRIVY_INSECURE" envDefault:"false"`
 	NonSSL   bool   `env:"TRIVY_NON_SSL" envDefault:"false"`
+	// CACert is a PEM file of the CA certificates trusted for registries, e.g. signing self-signed certificates,
+	// trusted for all the outbound connections as --custom-ca-cert is
+	CACert string `env:"TRIVY_REGISTRY_CA_CERT"`
 }
 
+// GetDockerOption returns the option of the registries of the environment variables, timing out after the timeout
+// or DefaultDockerTimeout without it
 func GetDockerOption(timeout time.Duration) (types.DockerOption, error) {
+	if timeout <= 0 {
+		timeout = DefaultDockerTimeout
+	}
 	cfg := DockerConfig{}
 	if err := env.Parse(&cfg); err != nil {
 		return types.DockerOption{}, err
 	}
+	if cfg.CACert != "" {
+		if err := transport.AddCACert(cfg.CACert); err != nil {
+			return types.DockerOption{}, xerrors.Errorf("invalid TRIVY_REGISTRY_CA_CERT: %w", err)
+		}
+	}
 
 	return types.DockerOption{
 		UserName:              cfg.UserName,
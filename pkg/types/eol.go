@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// EOLFinding is the finding of an OS no longer supported by its distribution, e.g. alpine 3.9.6,
+// which gets no more security updates
+type EOLFinding struct {
+	Family   string `json:",omitempty"`
+	Name     string `json:",omitempty"`
+	Severity string `json:",omitempty"`
+	// EOLDate is the end of the support of the OS version, nil when the version isn't on the EOL list
+	EOLDate *time.Time `json:",omitempty"`
+	Message string     `json:",omitempty"`
+	// EOLOSVersionOverride is set when Name is the version of ScanOptions.EOLOSVersion instead of the detected one
+	EOLOSVersionOverride string `json:",omitempty"`
+}
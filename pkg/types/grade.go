@@ -0,0 +1,32 @@
+package types
+
+// GradeRubric grades an image from the number of findings per severity and its OS support
+type GradeRubric struct {
+	// Grades are from the best, each with the maximum number of findings per severity.
+	// The severities not listed are unlimited. The image gets the first grade whose limits it meets.
+	Grades []GradeLimit
+	// FailGrade is the grade of an image meeting no limits
+	FailGrade string
+	// EOSLGrade is the best grade of an image whose OS is no longer supported; empty doesn't cap it
+	EOSLGrade string
+}
+
+// GradeLimit is a grade with the maximum number of findings per severity, e.g. {"HIGH": 5}
+type GradeLimit struct {
+	Grade     string
+	MaxCounts map[string]int
+}
+
+// DefaultGradeRubric grades A with no CRITICAL or HIGH and at most 5 MEDIUM findings,
+// B with no CRITICAL and at most 5 HIGH, C with no CRITICAL and at most 20 HIGH, D with at most 5 CRITICAL,
+// and F otherwise. An image with an end-of-life OS grades D at best.
+var DefaultGradeRubric = GradeRubric{
+	Grades: []GradeLimit{
+		{Grade: "A", MaxCounts: map[string]int{"CRITICAL": 0, "HIGH": 0, "MEDIUM": 5}},
+		{Grade: "B", MaxCounts: map[string]int{"CRITICAL": 0, "HIGH": 5}},
+		{Grade: "C", MaxCounts: map[string]int{"CRITICAL": 0, "HIGH": 20}},
+		{Grade: "D", MaxCounts: map[string]int{"CRITICAL": 5}},
+	},
+	FailGrade: "F",
+	EOSLGrade: "D",
+}
@@ -0,0 +1,14 @@
+package types
+
+// DetectedLicense is a license of a package as declared by its package manager or the package itself, e.g. MIT
+type DetectedLicense struct {
+	PkgName string `json:",omitempty"`
+	// Name is the license or license expression, e.g. "MIT OR Apache-2.0"
+	Name string `json:",omitempty"`
+	// FilePath is the file declaring the license, e.g. lib/apk/db/installed
+	FilePath string `json:",omitempty"`
+	// Forbidden is true when the license requires one of ScanOptions.ForbiddenLicenses
+	Forbidden bool `json:",omitempty"`
+	// Layer is the diff ID of the layer the file is in, empty for filesystems
+	Layer string `json:",omitempty"`
+}
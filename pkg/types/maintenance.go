@@ -0,0 +1,19 @@
+package types
+
+import (
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+const (
+	MaintenanceArchived     = "archived"
+	MaintenanceUnmaintained = "unmaintained"
+)
+
+// UnmaintainedPackage is an installed package whose project is no longer maintained
+type UnmaintainedPackage struct {
+	PkgName          string `json:",omitempty"`
+	InstalledVersion string `json:",omitempty"`
+	// Status is MaintenanceArchived or MaintenanceUnmaintained
+	Status string       `json:",omitempty"`
+	Layer  ftypes.Layer `json:",omitempty"`
+}
@@ -0,0 +1,11 @@
+package types
+
+// Misconfiguration is a violation of a policy by a config file, e.g. a Dockerfile without USER
+type Misconfiguration struct {
+	ID       string `json:",omitempty"`
+	Title    string `json:",omitempty"`
+	Severity string `json:",omitempty"`
+	Message  string `json:",omitempty"`
+	// Layer is the diff ID of the layer the file is in, empty for filesystems
+	Layer string `json:",omitempty"`
+}
@@ -0,0 +1,15 @@
+package types
+
+import (
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// InstalledPackage is a package found by the analysis, listed with ScanOptions.ListAllPackages
+// whether it has vulnerabilities or not
+type InstalledPackage struct {
+	Name    string `json:",omitempty"`
+	Version string `json:",omitempty"`
+	// License is empty when the analyzer doesn't report it
+	License string       `json:",omitempty"`
+	Layer   ftypes.Layer `json:",omitempty"`
+}
@@ -1,6 +1,216 @@
 package types
 
+import "time"
+
+// The security checks of ScanOptions.SecurityChecks
+const (
+	SecurityCheckVulnerability = "vuln"
+	SecurityCheckSecret        = "secret"
+	SecurityCheckConfig        = "config"
+	SecurityCheckLicense       = "license"
+)
+
 type ScanOptions struct {
 	VulnType            []string
 	ScanRemovedPackages bool
+
+	// ScanYanked keeps the yanked package versions reported by the driver
+	ScanYanked bool
+	// ScanMaintenance keeps the archived and unmaintained packages reported by the driver.
+	// It is warned that they can't be listed when the driver doesn't provide the maintenance status.
+	ScanMaintenance bool
+
+	// ListAllPackages lists in Result.Packages all the packages found by the analysis, including those without vulnerabilities.
+	// It is warned that they can't be listed when the driver doesn't provide them.
+	ListAllPackages bool
+
+	// ScanConfig adds observations about the image config (e.g. root user, exposed ports) to the results
+	ScanConfig bool
+
+	// SecurityChecks are the checks run by the scan: SecurityCheckVulnerability, SecurityCheckSecret, SecurityCheckConfig
+	// and SecurityCheckLicense.
+	// Empty only detects the vulnerabilities.
+	SecurityChecks []string
+	// SecretConfig is the path of the JSON file of the secret rules with SecurityCheckSecret, see secret.LoadConfig.
+	// Empty uses the built-in rules.
+	SecretConfig string
+	// ConfigPolicies are the Rego files, or the directories of Rego files, of the policies evaluated with SecurityCheckConfig
+	// in addition to misconf.BuiltinPolicies
+	ConfigPolicies []string
+	// ForbiddenLicenses are the licenses marked as forbidden with SecurityCheckLicense, e.g. GPL-3.0, see licensing.Forbidden
+	ForbiddenLicenses []string
+
+	// EOLOSVersion overrides the detected OS version only when checking the end of support of the OS,
+	// e.g. to see whether upgrading alpine 3.10 to 3.18 makes it supported. It sets EOSL and the EOL finding.
+	EOLOSVersion string
+	// SkipDBUpdate scans with the DB in the cache directory without updating it, e.g. in air-gapped environments.
+	// The scan fails with db.ErrNoLocalDB when there is none. It isn't sent to the server in the client mode.
+	SkipDBUpdate bool
+	// DedupeVulns reports once the findings of a result with the same vulnerability ID, package name and installed version
+	// found in several layers, with the layer closest to the base image introducing it
+	DedupeVulns bool
+	// SkipVersionRangeMatches drops the library findings whose installed version is a version range,
+	// e.g. transitive dependencies without pinned versions. They are kept with MatchConfidenceVersionRange by default.
+	SkipVersionRangeMatches bool
+	// OSMinorRollup also matches the advisories stored for the major version line of the OS, e.g. alpine 3 for alpine 3.11,
+	// with the drivers supporting it. The advisory of the minor version wins when both have the same vulnerability.
+	// By default only the advisories of the minor version match.
+	OSMinorRollup bool
+	// IgnoreFile is the path of the file listing the vulnerability IDs to drop, one per line, e.g. ".trivyignore",
+	// or of the YAML file of the rules with expiry dates, statements and paths, see vulnerability.ReadIgnoreRules.
+	// Empty uses vulnerability.DefaultIgnoreFile, which may be missing; another missing file is an error.
+	IgnoreFile string
+	// ShowSuppressed lists the vulnerabilities dropped by the ignore file in Result.Suppressed
+	ShowSuppressed bool
+	// VEXFile is the path of an OpenVEX or CSAF VEX document whose not_affected and fixed statements drop
+	// the vulnerabilities of the packages, always listed in Result.Suppressed with the statement, see vex.Load
+	VEXFile string
+	// IgnorePolicy is the path of the Rego file whose ignore rule drops vulnerabilities after the other filters,
+	// see result.PolicyFilter
+	IgnorePolicy string
+	// Severities keeps only the findings of the listed severities, e.g. {"CRITICAL", "HIGH"}.
+	// Unrated findings are kept with the lowest level (UNKNOWN). Empty keeps every severity.
+	Severities []string
+	// SeverityThresholds maps a result type (e.g. "npm", "bundler" or "os" for all OS packages)
+	// to the lowest severity reported for it.
+	// DefaultSeverityThreshold applies to result types not listed in the map.
+	SeverityThresholds       map[string]string
+	DefaultSeverityThreshold string
+	// SeverityLevels replaces the UNKNOWN..CRITICAL scale with custom levels ordered from the lowest,
+	// e.g. {"INFO", "LOW", "MODERATE", "HIGH", "CRITICAL"}.
+	// Every severity must then be one of the levels, or be mapped to one by SeverityMapping.
+	SeverityLevels  []string
+	SeverityMapping map[string]string
+	// DefaultSeverity is the severity of the findings without one, e.g. "HIGH" to treat unrated findings as high.
+	// It is applied before the overrides. Empty keeps them unrated, which is ranked as UNKNOWN.
+	DefaultSeverity string
+	// VulnSeverityOverrides maps a vulnerability ID to the severity reported for it.
+	// PkgSeverityOverrides maps a package name to the severity reported for all the vulnerabilities in it.
+	// A vulnerability ID override takes precedence over a package override.
+	// Both are applied before the severity thresholds and the filter expression.
+	VulnSeverityOverrides map[string]string
+	PkgSeverityOverrides  map[string]string
+	// SeveritySource is the source whose severity is reported, e.g. "nvd" or "redhat", when it rates the vulnerability.
+	// The others keep the severity of the data source of the result, falling back to NVD, as when it is empty.
+	// It is applied before the default severity and the overrides.
+	SeveritySource string
+
+	// FilterExpr is an expression evaluated per finding; only findings it matches are kept.
+	// The available fields are id, pkg, severity, type and fixed. e.g. severity == "CRITICAL" && fixed
+	FilterExpr string
+	// SeverityLimits maps a severity to the maximum number of findings of it kept per result.
+	// Findings are sorted by package name and vulnerability ID before being truncated,
+	// and the number of truncated findings is reported in the result. Zero or absent means unlimited.
+	SeverityLimits map[string]int
+	// StaleAdvisoryCutoff flags the findings whose advisory was last modified before it as StaleData.
+	// Findings without the last modified date aren't flagged. The zero value disables it.
+	StaleAdvisoryCutoff time.Time
+	// FindingIDs sets the FindingID of each finding, computed from the target, the package and the vulnerability ID
+	// so that it is the same across scans. FindingIDPrefix is prepended to the IDs, e.g. "trivy:".
+	FindingIDs      bool
+	FindingIDPrefix string
+	// MinFixAge flags the findings whose fixed version was released more recently than it as FixTooNew,
+	// and SkipTooNewFixes drops them. Findings without the release date, which the DB doesn't provide yet, aren't affected.
+	MinFixAge       time.Duration
+	SkipTooNewFixes bool
+	// PkgAliases maps a library name to its former names, e.g. {"pyjwt": {"jwt"}}.
+	// The former names are tried in order only for libraries without vulnerabilities under their own name.
+	PkgAliases map[string][]string
+	// ShardSize splits the OS packages and the libraries of each lock file into shards of at most ShardSize packages
+	// detected concurrently. The merged results are the same as without sharding. Zero disables the sharding.
+	ShardSize int
+	// Parallel is the number of layers extracted and of lock files scanned concurrently.
+	// Zero extracts all the missing layers at once and scans the lock files in turn.
+	Parallel int
+	// IgnoredEcosystems drops the results of the listed types, e.g. "npm", "os" for all OS packages
+	// or "pip" for both Pipfile.lock and poetry.lock.
+	IgnoredEcosystems []string
+	// KeepGenericDuplicates keeps the findings of a package in a generic archive result
+	// when the package is also identified in a specific ecosystem (e.g. maven) for the same target.
+	// By default only the findings of the specific ecosystem are reported.
+	KeepGenericDuplicates bool
+	// EPSSScores maps a CVE ID to its EPSS probability, e.g. parsed by vulnerability.ParseEPSS.
+	// The findings of the CVEs in it get their EPSS score; the others are left without.
+	// OnlyEPSSAbove keeps only the findings with a score above it, and SortByEPSS sorts the findings
+	// of each result by descending score with the unscored ones last.
+	EPSSScores    map[string]float64
+	OnlyEPSSAbove float64
+	SortByEPSS    bool
+	// KnownExploited is the set of the CVE IDs of the CISA KEV catalog, e.g. parsed by vulnerability.ParseKEV.
+	// The findings of the CVEs in it are flagged as KnownExploited, and OnlyKnownExploited keeps only them.
+	KnownExploited     map[string]bool
+	OnlyKnownExploited bool
+	// MinAffectedCount keeps only the findings of the vulnerabilities found at least that many times across the results,
+	// e.g. in several packages or targets. The others are dropped, or kept apart in Result.Uncommon with KeepUncommon.
+	MinAffectedCount int
+	KeepUncommon     bool
+	// DependencyTree sets the Relationship and the DependencyPath of the findings of the lock files
+	// whose dependency graph is known, e.g. package-lock.json, with Scanner.ScanFilesystem and Scanner.ScanRepository
+	DependencyTree bool
+	// MaxFileSize is the size limit in bytes of the analyzed files, e.g. lock files.
+	// Larger files are skipped with an analyzer warning. Zero uses scanner.DefaultMaxFileSize and a negative size disables it.
+	MaxFileSize int64
+	// SkipDirs and SkipFiles are the path.Match patterns of the directories and the files not analyzed, the names
+	// at any depth, e.g. node_modules, or the paths from the root with a slash, e.g. usr/src/app/test.
+	// FilePatterns analyze the files whose names match the pattern as the lock files of the analyzer, as
+	// "analyzer:pattern", e.g. "pipenv:Pipfile.*.lock". The layers analyzed with them are cached as such,
+	// so the cache should be of the run only.
+	SkipDirs     []string
+	SkipFiles    []string
+	FilePatterns []string
+	// FailOnAnalyzerWarning makes the scan fail when the analyzer reports non-fatal warnings
+	FailOnAnalyzerWarning bool
+	// DistrolessRepositories are the prefixes of the distroless image references, e.g. "gcr.io/distroless/".
+	// The OS packages of these images are not scanned when they have no package manager DB, while their libraries are.
+	// Empty uses local.DefaultDistrolessRepositories.
+	DistrolessRepositories []string
+	// BatchWorkers is the number of images BatchScanner.ScanImages scans concurrently; zero uses scanner.DefaultBatchWorkers
+	BatchWorkers int
+	// DedupBatch makes BatchScanner.ScanImages report each vulnerability of a package once with the images it is found in
+	DedupBatch bool
+	// AggregateNearDuplicates reports once the advisories of the same flaw filed under different IDs without aliases,
+	// i.e. affecting the same package version with similar titles. The others are in RelatedVulnerabilityIDs.
+	// NearDuplicateSimilarity is the minimum similarity of the titles in (0, 1]; zero uses scanner.DefaultNearDuplicateSimilarity.
+	AggregateNearDuplicates bool
+	NearDuplicateSimilarity float64
+	// DedupeIdenticalFiles reports once the libraries of lock files with the same libraries at different paths,
+	// e.g. copies in node_modules, listing the other paths in Result.DuplicatePaths.
+	// The lock files of the cached layers are compared as well, except those only in the cache of a server.
+	DedupeIdenticalFiles bool
+	// IgnorePkgs drops the findings of the packages matching any of these path.Match patterns,
+	// e.g. "test-*" or "example.com/internal/*"
+	IgnorePkgs []string
+	// IgnoreUnfixed drops the findings without a fixed version. Range-style fixes of libraries, e.g. ">=3.4.0", count as fixed.
+	IgnoreUnfixed bool
+	// SeparateDeferred moves the findings the vendor won't fix, deferred or no longer supports, see
+	// DetectedVulnerability.Status, from the vulnerabilities of the results to their Deferred findings
+	SeparateDeferred bool
+	// EOLSeverity reports the OS no longer supported by its distribution as a finding of this severity, e.g. HIGH,
+	// in a result of the ClassEOL class. Empty only warns about it in the log.
+	EOLSeverity string
+	// GradeRubric grades the image in ImageReport.Grade; nil uses DefaultGradeRubric
+	GradeRubric *GradeRubric
+	// GitToken authenticates the clone of Scanner.ScanRepository over HTTPS, e.g. a GitHub personal access token
+	GitToken string
+	// KnownLayers are the diff IDs of the layers already scanned, e.g. of the base image.
+	// The lowest layers of the image among them aren't scanned again and have no findings,
+	// the layers above the first unknown one are still scanned. Empty scans all the layers.
+	KnownLayers []string
+	// Timeout limits each driver scan, a timed out scan isn't retried. Zero means no timeout.
+	Timeout time.Duration
+	// Retries is the number of times the driver scan failing transiently, e.g. downloading the DB, is retried.
+	// Errors such as an unsupported OS or a missing DB with SkipDBUpdate are not retried. Zero doesn't retry.
+	// RetryBackoff is the delay before the first retry, growing exponentially with utils.DefaultJitter;
+	// zero uses the default initial interval of the backoff, 500ms.
+	Retries      int
+	RetryBackoff time.Duration
+	// PartialResults keeps the results of the vulnerability types scanned before the context is done,
+	// e.g. by the timeout of the scan, returned with a *scanner.PartialScanError of the other types.
+	// The types are then scanned one by one. The analysis isn't partial, a context done during it fails the scan.
+	// An application failing the detection of its libraries is then reported with the error status of its result
+	// instead of failing the scan.
+	PartialResults bool
+	// Seed seeds the randomized behavior of the run, e.g. the retry jitter, to reproduce a scan exactly.
+	// Zero keeps the source seeded with the time. It isn't sent to the server in the client mode.
+	Seed int64
 }
@@ -0,0 +1,15 @@
+package types
+
+// SecretFinding is a hardcoded credential found in a file, e.g. an AWS access key.
+// Match is the line of the secret with the secret itself masked.
+type SecretFinding struct {
+	RuleID    string `json:",omitempty"`
+	Category  string `json:",omitempty"`
+	Title     string `json:",omitempty"`
+	Severity  string `json:",omitempty"`
+	StartLine int    `json:",omitempty"`
+	EndLine   int    `json:",omitempty"`
+	Match     string `json:",omitempty"`
+	// Layer is the diff ID of the layer the file is in, empty for filesystems
+	Layer string `json:",omitempty"`
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ExplainTLSError adds how to trust the registry to certificate verification failures
+func ExplainTLSError(err error) error {
+	if err == nil || !strings.Contains(err.Error(), "x509: ") {
+		return err
+	}
+	return xerrors.Errorf("the certificate of the registry could not be verified; "+
+		"specify its CA with TRIVY_REGISTRY_CA_CERT or --custom-ca-cert, "+
+		"or --insecure-registry to skip the verification of the registry: %w", err)
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDockerOption_RegistryCA(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	defaultTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	// the self-signed certificate is rejected without the CA
+	_, err := http.Get(ts.URL)
+	require.Error(t, err)
+	assert.Contains(t, ExplainTLSError(err).Error(), "specify its CA with TRIVY_REGISTRY_CA_CERT")
+
+	dir, err := ioutil.TempDir("", "trivy-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caCert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600))
+
+	os.Setenv("TRIVY_REGISTRY_CA_CERT", caCert)
+	defer os.Unsetenv("TRIVY_REGISTRY_CA_CERT")
+	option, err := GetDockerOption(0)
+	require.NoError(t, err)
+	assert.False(t, option.InsecureSkipTLSVerify)
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGetDockerOption_InvalidRegistryCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trivy-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caCert, []byte("not a certificate"), 0600))
+
+	os.Setenv("TRIVY_REGISTRY_CA_CERT", caCert)
+	defer os.Unsetenv("TRIVY_REGISTRY_CA_CERT")
+	_, err = GetDockerOption(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no PEM certificate")
+}
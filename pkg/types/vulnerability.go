@@ -1,10 +1,32 @@
 package types
 
 import (
+	"time"
+
 	ftypes "github.com/aquasecurity/fanal/types"
 	"github.com/aquasecurity/trivy-db/pkg/types"
 )
 
+// MatchConfidenceLow is the match confidence of a vulnerability detected with a raw installed version
+const MatchConfidenceLow = "low"
+
+// MatchConfidenceVersionRange is the match confidence of a vulnerability detected with the lower bound
+// of a version range, e.g. ^4.17.4 in a loose lock file, as the installed version isn't exactly resolved
+const MatchConfidenceVersionRange = "version-range-match"
+
+const (
+	RelationshipDirect   = "direct"
+	RelationshipIndirect = "indirect"
+)
+
+// The statuses of the unfixed vulnerabilities decided by the vendors, e.g. Red Hat marking one "Will not fix"
+const (
+	StatusAffected    = "affected"
+	StatusWillNotFix  = "will_not_fix"
+	StatusFixDeferred = "fix_deferred"
+	StatusEndOfLife   = "end_of_life"
+)
+
 type DetectedVulnerability struct {
 	VulnerabilityID  string       `json:",omitempty"`
 	PkgName          string       `json:",omitempty"`
@@ -12,6 +34,65 @@ type DetectedVulnerability struct {
 	FixedVersion     string       `json:",omitempty"`
 	Layer            ftypes.Layer `json:",omitempty"`
 	SeveritySource   string       `json:",omitempty"`
+	// Status is the decision of the vendor about the vulnerability without a FixedVersion when the DB records it,
+	// e.g. StatusWillNotFix, empty otherwise
+	Status string `json:",omitempty"`
+	// IsFixed reports whether InstalledVersion satisfies FixedVersion
+	IsFixed bool `json:",omitempty"`
+	// CVSS has the base scores, vectors and severity per source when the sources provide them
+	CVSS VendorCVSS `json:",omitempty"`
+	// MatchConfidence is MatchConfidenceLow when the installed version couldn't be normalized,
+	// or MatchConfidenceVersionRange when it is a version range
+	MatchConfidence string `json:",omitempty"`
+	// LastModified is the last modified date of the advisory when the source provides it, e.g. the OSV advisories
+	LastModified *time.Time `json:",omitempty"`
+	// StaleData is true when the advisory was last modified before ScanOptions.StaleAdvisoryCutoff
+	StaleData bool `json:",omitempty"`
+	// LayerSize is the size in bytes of the layer the vulnerability is introduced in, when the analyzer provides it
+	LayerSize int64 `json:",omitempty"`
+	// FixedVersionReleased is the release date of FixedVersion when the driver provides it
+	FixedVersionReleased *time.Time `json:",omitempty"`
+	// FixTooNew is true when FixedVersion was released within ScanOptions.MinFixAge
+	FixTooNew bool `json:",omitempty"`
+	// MatchedName is the former name of the package the advisory is filed under, from ScanOptions.PkgAliases
+	MatchedName string `json:",omitempty"`
+	// LayerCreatedBy is the command creating the layer in the image history, when the image config has it
+	LayerCreatedBy string `json:",omitempty"`
+	// NormalizedScore is the severity in the 0-10 range, the best available CVSS base score
+	// or the middle of the CVSS v3 band of the severity when no source has a score
+	NormalizedScore float64 `json:",omitempty"`
+	// EPSS is the probability of exploitation in the next 30 days, when ScanOptions.EPSSScores has the CVE
+	EPSS *float64 `json:",omitempty"`
+	// KnownExploited is true when the CVE is in the KEV catalog of ScanOptions.KnownExploited
+	KnownExploited bool `json:",omitempty"`
+	// FindingID identifies the vulnerability of the package in the target across scans
+	FindingID string `json:",omitempty"`
+	// Relationship is RelationshipDirect or RelationshipIndirect when the dependency graph of the lock file is known,
+	// see ScanOptions.DependencyTree, or empty when it is unknown
+	Relationship string `json:",omitempty"`
+	// DependencyPath is the chain of the packages from the direct dependency introducing the package to it,
+	// e.g. ["express@4.16.0", "body-parser@1.18.2", "qs@6.5.1"], with ScanOptions.DependencyTree
+	DependencyPath []string `json:",omitempty"`
+	// RelatedVulnerabilityIDs are the near-duplicate advisories of the same flaw aggregated into this one,
+	// with ScanOptions.AggregateNearDuplicates
+	RelatedVulnerabilityIDs []string `json:",omitempty"`
+	// DataSource is the name of the supplementary advisory source the vulnerability is detected with,
+	// see advisory.Source, or empty for the DB
+	DataSource string `json:",omitempty"`
 
 	types.Vulnerability
 }
+
+// SuppressedVulnerability is a vulnerability dropped by a rule of the ignore file, listed with ScanOptions.ShowSuppressed,
+// or by a statement of the VEX document of ScanOptions.VEXFile
+type SuppressedVulnerability struct {
+	DetectedVulnerability
+	// Statement justifies the rule dropping the vulnerability, or is the impact statement of the VEX statement
+	Statement string `json:",omitempty"`
+	// VEXStatus is the status of the VEX statement, not_affected or fixed, empty for the ignore file
+	VEXStatus string `json:",omitempty"`
+	// VEXJustification is the justification of a not_affected VEX statement, e.g. vulnerable_code_not_present
+	VEXJustification string `json:",omitempty"`
+	// ExpiredAt is the date from which the rule no longer applies, nil when it never expires
+	ExpiredAt *time.Time `json:",omitempty"`
+}
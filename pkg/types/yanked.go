@@ -0,0 +1,13 @@
+package types
+
+import (
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+// YankedPackage is an installed package version withdrawn from its registry
+type YankedPackage struct {
+	PkgName          string       `json:",omitempty"`
+	InstalledVersion string       `json:",omitempty"`
+	Reason           string       `json:",omitempty"`
+	Layer            ftypes.Layer `json:",omitempty"`
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// DefaultJitter randomizes retry delays within ±50% so that clients failing together don't retry together
+const DefaultJitter = 0.5
+
+// JitteredBackOff is an exponential backoff whose delays are randomized within ±Jitter of each delay,
+// e.g. 0.5 for delays between 0.5 and 1.5 times the exponential one.
+type JitteredBackOff struct {
+	exponential *backoff.ExponentialBackOff
+	jitter      float64
+	random      func() float64
+}
+
+// NewJitteredBackOff returns an exponential backoff from the initial interval.
+// A zero jitter uses DefaultJitter, a negative one disables the randomization and it is capped at 1.
+// random returns a number in [0, 1); the source seeded by SetSeed is used when nil.
+func NewJitteredBackOff(initialInterval time.Duration, jitter float64, random func() float64) *JitteredBackOff {
+	switch {
+	case jitter == 0:
+		jitter = DefaultJitter
+	case jitter < 0:
+		jitter = 0
+	case jitter > 1:
+		jitter = 1
+	}
+	if random == nil {
+		random = Random
+	}
+
+	b := backoff.NewExponentialBackOff()
+	if initialInterval > 0 {
+		b.InitialInterval = initialInterval
+	}
+	// the randomization is done here with the injected random source
+	b.RandomizationFactor = 0
+	b.Reset()
+	return &JitteredBackOff{exponential: b, jitter: jitter, random: random}
+}
+
+func (b *JitteredBackOff) NextBackOff() time.Duration {
+	d := b.exponential.NextBackOff()
+	if d == backoff.Stop || b.jitter == 0 {
+		return d
+	}
+	delta := b.jitter * float64(d)
+	return time.Duration(float64(d) - delta + b.random()*2*delta)
+}
+
+func (b *JitteredBackOff) Reset() {
+	b.exponential.Reset()
+}
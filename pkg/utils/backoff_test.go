@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitteredBackOff_NextBackOff(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter float64
+		random float64
+		want   []time.Duration
+	}{
+		{
+			name:   "lower bound of the default jitter",
+			random: 0,
+			want:   []time.Duration{50 * time.Millisecond, 75 * time.Millisecond, 112500 * time.Microsecond},
+		},
+		{
+			name:   "upper bound of the default jitter",
+			random: 0.999999,
+			want:   []time.Duration{149999900, 224999850, 337499775},
+		},
+		{
+			name:   "custom jitter",
+			jitter: 0.1,
+			random: 0,
+			want:   []time.Duration{90 * time.Millisecond, 135 * time.Millisecond, 202500 * time.Microsecond},
+		},
+		{
+			name:   "disabled jitter",
+			jitter: -1,
+			random: 0,
+			want:   []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewJitteredBackOff(100*time.Millisecond, tt.jitter, func() float64 { return tt.random })
+			var got []time.Duration
+			for range tt.want {
+				got = append(got, b.NextBackOff())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJitteredBackOff_Bounds(t *testing.T) {
+	randoms := []float64{0, 0.25, 0.5, 0.75, 0.999}
+	var i int
+	b := NewJitteredBackOff(time.Second, 0, func() float64 {
+		r := randoms[i%len(randoms)]
+		i++
+		return r
+	})
+
+	delay := time.Second
+	for n := 0; n < 5; n++ {
+		got := b.NextBackOff()
+		assert.True(t, got >= delay/2 && got < delay*3/2, "retry #%d: %s is out of [%s, %s)", n+1, got, delay/2, delay*3/2)
+		delay = delay * 3 / 2
+	}
+}
+
+func TestJitteredBackOff_Seed(t *testing.T) {
+	delays := func(seed int64) []time.Duration {
+		SetSeed(seed)
+		b := NewJitteredBackOff(100*time.Millisecond, 0, nil)
+		var got []time.Duration
+		for i := 0; i < 5; i++ {
+			got = append(got, b.NextBackOff())
+		}
+		return got
+	}
+
+	first := delays(42)
+	assert.Equal(t, first, delays(42))
+	assert.NotEqual(t, first, delays(43))
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	randomMu sync.Mutex
+	random   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetSeed makes the randomized behavior, e.g. the retry jitter, deterministic so that a scan can be reproduced.
+// The source is seeded with the time by default.
+func SetSeed(seed int64) {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	random = rand.New(rand.NewSource(seed))
+}
+
+// Random returns a number in [0, 1) from the seeded source
+func Random() float64 {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	return random.Float64()
+}
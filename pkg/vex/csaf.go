@@ -0,0 +1,136 @@
+package vex
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/utils"
+)
+
+// csaf is the VEX profile of CSAF 2.0 (https://docs.oasis-open.org/csaf/csaf/v2.0/csaf-v2.0.html).
+// The statuses are keyed by the IDs of the products of the product tree.
+type csaf struct {
+	ProductTree struct {
+		Branches         []csafBranch  `json:"branches"`
+		FullProductNames []csafProduct `json:"full_product_names"`
+		// Relationships are the products of a component in another, e.g. a library in an image
+		Relationships []struct {
+			FullProductName           csafProduct `json:"full_product_name"`
+			ProductReference          string      `json:"product_reference"`
+			RelatesToProductReference string      `json:"relates_to_product_reference"`
+		} `json:"relationships"`
+	} `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE string `json:"cve"`
+		IDs []struct {
+			Text string `json:"text"`
+		} `json:"ids"`
+		ProductStatus map[string][]string `json:"product_status"`
+		Flags         []struct {
+			Label      string   `json:"label"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"flags"`
+		Threats []struct {
+			Category   string   `json:"category"`
+			Details    string   `json:"details"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"threats"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch `json:"branches"`
+	Product  *csafProduct `json:"product"`
+}
+
+type csafProduct struct {
+	ProductID                   string `json:"product_id"`
+	ProductIdentificationHelper struct {
+		PURL string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+// csafStatuses are the product statuses of CSAF with the statuses of the statements
+var csafStatuses = [][2]string{
+	{"known_not_affected", StatusNotAffected},
+	{"known_affected", StatusAffected},
+	{"fixed", StatusFixed},
+	{"under_investigation", StatusUnderInvestigation},
+}
+
+func parseCSAF(b []byte) (Document, error) {
+	var c csaf
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Document{}, xerrors.Errorf("invalid CSAF document: %w", err)
+	}
+
+	// the package URL of each product, or its ID without one
+	purls := map[string]string{}
+	var walk func(branches []csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, branch := range branches {
+			if branch.Product != nil {
+				purls[branch.Product.ProductID] = branch.Product.ProductIdentificationHelper.PURL
+			}
+			walk(branch.Branches)
+		}
+	}
+	walk(c.ProductTree.Branches)
+	for _, p := range c.ProductTree.FullProductNames {
+		purls[p.ProductID] = p.ProductIdentificationHelper.PURL
+	}
+	product := func(id string) string {
+		if purl := purls[id]; purl != "" {
+			return purl
+		}
+		return id
+	}
+	type relationship struct{ component, product string }
+	relationships := map[string]relationship{}
+	for _, r := range c.ProductTree.Relationships {
+		relationships[r.FullProductName.ProductID] = relationship{
+			component: product(r.ProductReference),
+			product:   product(r.RelatesToProductReference),
+		}
+	}
+
+	var doc Document
+	for _, v := range c.Vulnerabilities {
+		var aliases []string
+		for _, id := range v.IDs {
+			aliases = append(aliases, id.Text)
+		}
+		if v.CVE == "" && len(aliases) == 0 {
+			return Document{}, xerrors.New("a vulnerability has neither cve nor ids")
+		}
+		vulnID := v.CVE
+		if vulnID == "" {
+			vulnID = aliases[0]
+			aliases = append([]string(nil), aliases[1:]...)
+		}
+
+		for _, status := range csafStatuses {
+			for _, id := range v.ProductStatus[status[0]] {
+				s := Statement{VulnerabilityID: vulnID, Aliases: aliases, Status: status[1]}
+				if r, ok := relationships[id]; ok {
+					s.Products, s.Subcomponents = []string{r.product}, []string{r.component}
+				} else {
+					s.Products = []string{product(id)}
+				}
+				for _, flag := range v.Flags {
+					if utils.StringInSlice(id, flag.ProductIDs) {
+						s.Justification = flag.Label
+					}
+				}
+				for _, threat := range v.Threats {
+					if threat.Category == "impact" && utils.StringInSlice(id, threat.ProductIDs) {
+						s.ImpactStatement = threat.Details
+					}
+				}
+				doc.Statements = append(doc.Statements, s)
+			}
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,78 @@
+package vex
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// openVEX is an OpenVEX document (https://github.com/openvex/spec). The vulnerabilities and the products
+// are strings in v0.0.1, and objects with the subcomponents of the products since v0.2.0.
+type openVEX struct {
+	Statements []struct {
+		Vulnerability   json.RawMessage   `json:"vulnerability"`
+		Products        []json.RawMessage `json:"products"`
+		Subcomponents   []string          `json:"subcomponents"`
+		Status          string            `json:"status"`
+		Justification   string            `json:"justification"`
+		ImpactStatement string            `json:"impact_statement"`
+	} `json:"statements"`
+}
+
+type openVEXVulnerability struct {
+	Name    string   `json:"name"`
+	ID      string   `json:"@id"`
+	Aliases []string `json:"aliases"`
+}
+
+type openVEXProduct struct {
+	ID            string `json:"@id"`
+	Subcomponents []struct {
+		ID string `json:"@id"`
+	} `json:"subcomponents"`
+}
+
+func parseOpenVEX(b []byte) (Document, error) {
+	var v openVEX
+	if err := json.Unmarshal(b, &v); err != nil {
+		return Document{}, xerrors.Errorf("invalid OpenVEX document: %w", err)
+	}
+
+	var doc Document
+	for i, st := range v.Statements {
+		s := Statement{
+			Subcomponents:   st.Subcomponents,
+			Status:          st.Status,
+			Justification:   st.Justification,
+			ImpactStatement: st.ImpactStatement,
+		}
+		var vuln openVEXVulnerability
+		if err := json.Unmarshal(st.Vulnerability, &s.VulnerabilityID); err != nil {
+			if err = json.Unmarshal(st.Vulnerability, &vuln); err != nil {
+				return Document{}, xerrors.Errorf("invalid vulnerability of the statement #%d: %w", i+1, err)
+			}
+			s.VulnerabilityID, s.Aliases = vuln.Name, vuln.Aliases
+			if s.VulnerabilityID == "" {
+				s.VulnerabilityID = vuln.ID
+			}
+		}
+		if s.VulnerabilityID == "" {
+			return Document{}, xerrors.Errorf("the statement #%d has no vulnerability", i+1)
+		}
+
+		for _, raw := range st.Products {
+			var product openVEXProduct
+			if err := json.Unmarshal(raw, &product.ID); err != nil {
+				if err = json.Unmarshal(raw, &product); err != nil {
+					return Document{}, xerrors.Errorf("invalid product of the statement #%d: %w", i+1, err)
+				}
+			}
+			s.Products = append(s.Products, product.ID)
+			for _, sub := range product.Subcomponents {
+				s.Subcomponents = append(s.Subcomponents, sub.ID)
+			}
+		}
+		doc.Statements = append(doc.Statements, s)
+	}
+	return doc, nil
+}
@@ -0,0 +1,137 @@
+package vex
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/trivy/pkg/sbom"
+)
+
+const (
+	StatusNotAffected        = "not_affected"
+	StatusAffected           = "affected"
+	StatusFixed              = "fixed"
+	StatusUnderInvestigation = "under_investigation"
+)
+
+// artifactTypes are the package URL types of the artifacts, e.g. pkg:oci/app@sha256:..., whose statements
+// apply to all their packages
+var artifactTypes = map[string]bool{"oci": true, "docker": true, "github": true}
+
+// Statement is the status of a vulnerability in products, e.g. not_affected as the vulnerable code isn't used
+type Statement struct {
+	VulnerabilityID string
+	// Aliases are the other IDs of the vulnerability, e.g. the GHSA of a CVE
+	Aliases []string
+	// Products are the package URLs of the products, or their IDs in the document without a package URL
+	Products []string
+	// Subcomponents are the package URLs of the packages of the products the statement is restricted to
+	Subcomponents []string
+	Status        string
+	// Justification is the reason of not_affected, e.g. vulnerable_code_not_present
+	Justification   string
+	ImpactStatement string
+}
+
+// Document is the statements of a VEX document, in the order of the document
+type Document struct {
+	Statements []Statement
+}
+
+// Load reads an OpenVEX document or the VEX profile of CSAF, both in JSON
+func Load(filePath string) (Document, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return Document{}, xerrors.Errorf("unable to read the VEX document: %w", err)
+	}
+	doc, err := Parse(b)
+	if err != nil {
+		return Document{}, xerrors.Errorf("invalid VEX document %s: %w", filePath, err)
+	}
+	return doc, nil
+}
+
+// Parse parses an OpenVEX document or the VEX profile of CSAF
+func Parse(b []byte) (Document, error) {
+	var format struct {
+		Context  string `json:"@context"`
+		Document struct {
+			Category string `json:"category"`
+		} `json:"document"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(b), &format); err != nil {
+		return Document{}, xerrors.Errorf("invalid JSON: %w", err)
+	}
+	switch {
+	case strings.HasPrefix(format.Context, "https://openvex.dev/ns"):
+		return parseOpenVEX(b)
+	case format.Document.Category == "csaf_vex":
+		return parseCSAF(b)
+	}
+	return Document{}, xerrors.New("unknown VEX format, neither OpenVEX nor CSAF VEX")
+}
+
+// Match returns the statement of the vulnerability of the package, the last one of the document when
+// several apply as the later statements update the former
+func (d Document) Match(vulnID, pkgName, version string) (Statement, bool) {
+	for i := len(d.Statements) - 1; i >= 0; i-- {
+		s := d.Statements[i]
+		if s.hasID(vulnID) && s.appliesTo(pkgName, version) {
+			return s, true
+		}
+	}
+	return Statement{}, false
+}
+
+// Suppresses reports whether the status drops the vulnerability from the results, as the product
+// isn't affected or the vulnerability is fixed in it
+func (s Statement) Suppresses() bool {
+	return s.Status == StatusNotAffected || s.Status == StatusFixed
+}
+
+func (s Statement) hasID(vulnID string) bool {
+	if s.VulnerabilityID == vulnID {
+		return true
+	}
+	for _, alias := range s.Aliases {
+		if alias == vulnID {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesTo reports whether the package is a subcomponent of the statement or, without subcomponents,
+// one of its products. The statements of artifacts without packages, e.g. an image, apply to every package.
+func (s Statement) appliesTo(pkgName, version string) bool {
+	if len(s.Subcomponents) > 0 {
+		return matchPackages(s.Subcomponents, pkgName, version)
+	}
+	if matchPackages(s.Products, pkgName, version) {
+		return true
+	}
+	for _, product := range s.Products {
+		if p, err := sbom.ParsePackageURL(product); err == nil && !artifactTypes[p.Type] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPackages reports whether one of the package URLs is the package, of any version without a version
+func matchPackages(purls []string, pkgName, version string) bool {
+	for _, purl := range purls {
+		p, err := sbom.ParsePackageURL(purl)
+		if err != nil || p.PackageName() != pkgName {
+			continue
+		}
+		if p.Version == "" || p.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package vex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     Document
+		wantErr  string
+	}{
+		{
+			name:     "OpenVEX",
+			filePath: "testdata/openvex.json",
+			want: Document{Statements: []Statement{
+				{
+					VulnerabilityID: "CVE-2020-1967",
+					Aliases:         []string{"GHSA-jq65-29v4-4x35"},
+					Products:        []string{"pkg:oci/app@sha256%3A3f8b7b9a"},
+					Subcomponents:   []string{"pkg:apk/alpine/openssl@1.1.1d-r3"},
+					Status:          StatusNotAffected,
+					Justification:   "vulnerable_code_not_in_execute_path",
+					ImpactStatement: "The server doesn't use TLS 1.3",
+				},
+				{VulnerabilityID: "CVE-2019-11358", Products: []string{"pkg:npm/jquery"}, Status: StatusFixed},
+				{VulnerabilityID: "CVE-2019-1551", Products: []string{"pkg:oci/app@sha256%3A3f8b7b9a"}, Status: StatusUnderInvestigation},
+			}},
+		},
+		{
+			name:     "OpenVEX v0.0.1",
+			filePath: "testdata/openvex-v0.0.1.json",
+			want: Document{Statements: []Statement{
+				{
+					VulnerabilityID: "CVE-2020-1967",
+					Products:        []string{"pkg:apk/alpine/openssl@1.1.1d-r3"},
+					Status:          StatusNotAffected,
+					Justification:   "vulnerable_code_not_present",
+				},
+				{VulnerabilityID: "CVE-2020-1967", Products: []string{"pkg:apk/alpine/openssl@1.1.1d-r3"}, Status: StatusAffected},
+			}},
+		},
+		{
+			name:     "CSAF",
+			filePath: "testdata/csaf.json",
+			want: Document{Statements: []Statement{
+				{
+					VulnerabilityID: "CVE-2020-1967",
+					Products:        []string{"pkg:oci/app@sha256%3A3f8b7b9a"},
+					Subcomponents:   []string{"pkg:apk/alpine/openssl@1.1.1d-r3"},
+					Status:          StatusNotAffected,
+					Justification:   "vulnerable_code_not_in_execute_path",
+					ImpactStatement: "The server doesn't use TLS 1.3",
+				},
+				{VulnerabilityID: "GHSA-p6mc-m468-83gw", Products: []string{"pkg:npm/lodash@4.17.15"}, Status: StatusAffected},
+			}},
+		},
+		{
+			name:     "unknown format",
+			filePath: "testdata/unknown.json",
+			wantErr:  "unknown VEX format",
+		},
+		{
+			name:     "missing file",
+			filePath: "testdata/missing.json",
+			wantErr:  "unable to read the VEX document",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.filePath)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDocument_Match(t *testing.T) {
+	openVEX, err := Load("testdata/openvex.json")
+	require.NoError(t, err)
+	legacy, err := Load("testdata/openvex-v0.0.1.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		doc        Document
+		vulnID     string
+		pkgName    string
+		version    string
+		wantStatus string
+	}{
+		{
+			name:       "subcomponent",
+			doc:        openVEX,
+			vulnID:     "CVE-2020-1967",
+			pkgName:    "openssl",
+			version:    "1.1.1d-r3",
+			wantStatus: StatusNotAffected,
+		},
+		{
+			name:    "other version of the subcomponent",
+			doc:     openVEX,
+			vulnID:  "CVE-2020-1967",
+			pkgName: "openssl",
+			version: "1.1.1g-r0",
+		},
+		{
+			name:       "alias",
+			doc:        openVEX,
+			vulnID:     "GHSA-jq65-29v4-4x35",
+			pkgName:    "openssl",
+			version:    "1.1.1d-r3",
+			wantStatus: StatusNotAffected,
+		},
+		{
+			name:       "product without version",
+			doc:        openVEX,
+			vulnID:     "CVE-2019-11358",
+			pkgName:    "jquery",
+			version:    "3.3.1",
+			wantStatus: StatusFixed,
+		},
+		{
+			name:       "artifact applying to every package",
+			doc:        openVEX,
+			vulnID:     "CVE-2019-1551",
+			pkgName:    "openssl",
+			version:    "1.1.1d-r3",
+			wantStatus: StatusUnderInvestigation,
+		},
+		{
+			name:    "other package",
+			doc:     openVEX,
+			vulnID:  "CVE-2019-11358",
+			pkgName: "lodash",
+			version: "4.17.15",
+		},
+		{
+			name:       "the last statement updates the former",
+			doc:        legacy,
+			vulnID:     "CVE-2020-1967",
+			pkgName:    "openssl",
+			version:    "1.1.1d-r3",
+			wantStatus: StatusAffected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.doc.Match(tt.vulnID, tt.pkgName, tt.version)
+			assert.Equal(t, tt.wantStatus != "", ok)
+			assert.Equal(t, tt.wantStatus, got.Status)
+		})
+	}
+}
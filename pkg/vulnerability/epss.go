@@ -0,0 +1,41 @@
+package vulnerability
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// ParseEPSS parses the EPSS scores published by FIRST as CSV with "cve,epss,percentile" columns
+// into the probability per CVE ID. Comment lines starting with "#" and the header are skipped.
+func ParseEPSS(r io.Reader) (map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	scores := map[string]float64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, xerrors.Errorf("invalid EPSS CSV: %w", err)
+		}
+		if len(record) < 2 {
+			return nil, xerrors.Errorf("invalid EPSS CSV: line %d: missing the score", line)
+		}
+		if strings.EqualFold(record[0], "cve") {
+			// header
+			continue
+		}
+		score, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid EPSS score of %s: %w", record[0], err)
+		}
+		scores[record[0]] = score
+	}
+	return scores, nil
+}
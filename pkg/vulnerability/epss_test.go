@@ -0,0 +1,45 @@
+package vulnerability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEPSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]float64
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			input: `#model_version:v2022.01.01,score_date:2022-02-04T00:00:00+0000
+cve,epss,percentile
+CVE-2019-14697,0.00372,0.70686
+CVE-2019-1549,0.0121,0.83350
+`,
+			want: map[string]float64{"CVE-2019-14697": 0.00372, "CVE-2019-1549": 0.0121},
+		},
+		{
+			name:    "invalid score",
+			input:   "CVE-2019-14697,high\n",
+			wantErr: "invalid EPSS score of CVE-2019-14697",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEPSS(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
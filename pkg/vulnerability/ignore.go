@@ -0,0 +1,132 @@
+package vulnerability
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/xerrors"
+)
+
+// IgnoreRule drops a vulnerability from the results, e.g. an accepted risk
+type IgnoreRule struct {
+	ID string `json:"id"`
+	// Statement justifies the rule, e.g. "not exploitable as the feature is disabled"
+	Statement string `json:"statement"`
+	// ExpiredAt is the date from which the rule no longer applies, zero for a rule that never expires
+	ExpiredAt time.Time `json:"-"`
+	// Paths restrict the rule to the results whose target matches one of them as a path.Match pattern,
+	// e.g. "app/package-lock.json" or "*/Gemfile.lock". Empty applies the rule to every result.
+	Paths []string `json:"paths"`
+}
+
+// Active reports whether the rule applies at the time
+func (r IgnoreRule) Active(now time.Time) bool {
+	return r.ExpiredAt.IsZero() || now.Before(r.ExpiredAt)
+}
+
+// Matches reports whether the rule applies to the vulnerability of the result target
+func (r IgnoreRule) Matches(vulnID, target string) bool {
+	if r.ID != vulnID {
+		return false
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, p := range r.Paths {
+		if p == target {
+			return true
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreFile is the YAML ignore file, e.g.
+//
+//	vulnerabilities:
+//	  - id: CVE-2019-11358
+//	    statement: jQuery isn't served
+//	    expired_at: 2021-01-01
+//	    paths: ["app/package-lock.json"]
+type ignoreFile struct {
+	Vulnerabilities []struct {
+		IgnoreRule
+		ExpiredAt string `json:"expired_at"`
+	} `json:"vulnerabilities"`
+}
+
+// ReadIgnoreRules returns the rules of the ignore file. The files with the .yaml or .yml extension are structured
+// as in ignoreFile, the others list the vulnerability IDs one per line and have rules that always apply.
+func ReadIgnoreRules(ignoreFile string) ([]IgnoreRule, error) {
+	content, err := ioutil.ReadFile(ignoreFile)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to open the ignore file: %w", err)
+	}
+	switch filepath.Ext(ignoreFile) {
+	case ".yaml", ".yml":
+		rules, err := parseIgnoreYAML(content)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid ignore file %s: %w", ignoreFile, err)
+		}
+		return rules, nil
+	}
+
+	var rules []IgnoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		rules = append(rules, IgnoreRule{ID: line})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("unable to read the ignore file: %w", err)
+	}
+	return rules, nil
+}
+
+func parseIgnoreYAML(content []byte) ([]IgnoreRule, error) {
+	var f ignoreFile
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, err
+	}
+
+	var rules []IgnoreRule
+	for _, v := range f.Vulnerabilities {
+		rule := v.IgnoreRule
+		if rule.ID == "" {
+			return nil, xerrors.New("a vulnerability has no id")
+		}
+		if v.ExpiredAt != "" {
+			expiredAt, err := parseDate(v.ExpiredAt)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid expired_at of %s: %w", rule.ID, err)
+			}
+			rule.ExpiredAt = expiredAt
+		}
+		for _, p := range rule.Paths {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, xerrors.Errorf("invalid path of %s: %q: %w", rule.ID, p, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseDate parses a date such as 2021-01-01, or a time in RFC 3339
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,87 @@
+package vulnerability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		ignoreFile string
+		want       []IgnoreRule
+		wantErr    string
+	}{
+		{
+			name:       "plain",
+			ignoreFile: "testdata/.trivyignore",
+			want: []IgnoreRule{
+				{ID: "CVE-2019-0001"},
+				{ID: "CVE-2019-0002"},
+			},
+		},
+		{
+			name:       "YAML",
+			ignoreFile: "testdata/trivyignore.yaml",
+			want: []IgnoreRule{
+				{ID: "CVE-2019-0001", Statement: "The vulnerable function isn't called"},
+				{
+					ID:        "CVE-2019-0002",
+					Statement: "Accepted until the next release",
+					ExpiredAt: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+				},
+				{ID: "CVE-2019-0003", Paths: []string{"app/package-lock.json", "*/Gemfile.lock"}},
+			},
+		},
+		{
+			name:       "sad path: invalid expiry",
+			ignoreFile: "testdata/invalid-expiry.yaml",
+			wantErr:    "invalid expired_at of CVE-2019-0001",
+		},
+		{
+			name:       "sad path: missing file",
+			ignoreFile: "testdata/missing.yaml",
+			wantErr:    "unable to open the ignore file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadIgnoreRules(tt.ignoreFile)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIgnoreRule(t *testing.T) {
+	rule := IgnoreRule{
+		ID:        "CVE-2019-0003",
+		ExpiredAt: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+		Paths:     []string{"app/package-lock.json", "*/Gemfile.lock"},
+	}
+
+	assert.True(t, rule.Active(time.Date(2020, 5, 31, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, rule.Active(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, IgnoreRule{ID: "CVE-2019-0003"}.Active(time.Now()))
+
+	assert.True(t, rule.Matches("CVE-2019-0003", "app/package-lock.json"))
+	assert.True(t, rule.Matches("CVE-2019-0003", "api/Gemfile.lock"))
+	assert.False(t, rule.Matches("CVE-2019-0003", "alpine:3.10 (alpine 3.10.2)"))
+	assert.False(t, rule.Matches("CVE-2019-0004", "app/package-lock.json"))
+	assert.True(t, IgnoreRule{ID: "CVE-2019-0003"}.Matches("CVE-2019-0003", "alpine:3.10 (alpine 3.10.2)"))
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	// the rules expired or restricted to paths don't apply to every result
+	got, err := ReadIgnoreFile("testdata/trivyignore.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CVE-2019-0001"}, got)
+}
@@ -0,0 +1,30 @@
+package vulnerability
+
+import (
+	"encoding/json"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// ParseKEV parses the Known Exploited Vulnerabilities catalog published by CISA as JSON
+// into the set of its CVE IDs
+func ParseKEV(r io.Reader) (map[string]bool, error) {
+	var catalog struct {
+		Vulnerabilities []struct {
+			CveID string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, xerrors.Errorf("invalid KEV catalog: %w", err)
+	}
+
+	ids := map[string]bool{}
+	for _, v := range catalog.Vulnerabilities {
+		if v.CveID == "" {
+			return nil, xerrors.New("invalid KEV catalog: missing cveID")
+		}
+		ids[v.CveID] = true
+	}
+	return ids, nil
+}
@@ -0,0 +1,54 @@
+package vulnerability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKEV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]bool
+		wantErr string
+	}{
+		{
+			name: "happy path",
+			input: `{
+  "title": "CISA Catalog of Known Exploited Vulnerabilities",
+  "catalogVersion": "2022.02.04",
+  "count": 2,
+  "vulnerabilities": [
+    {"cveID": "CVE-2021-44228", "vendorProject": "Apache", "product": "Log4j2"},
+    {"cveID": "CVE-2014-6271", "vendorProject": "GNU", "product": "Bourne-Again Shell (Bash)"}
+  ]
+}`,
+			want: map[string]bool{"CVE-2021-44228": true, "CVE-2014-6271": true},
+		},
+		{
+			name:    "missing CVE ID",
+			input:   `{"vulnerabilities": [{"vendorProject": "Apache"}]}`,
+			wantErr: "missing cveID",
+		},
+		{
+			name:    "not JSON",
+			input:   "cve,epss,percentile\n",
+			wantErr: "invalid KEV catalog",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKEV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
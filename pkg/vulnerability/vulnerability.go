@@ -1,10 +1,9 @@
 package vulnerability
 
 import (
-	"bufio"
-	"os"
+	"encoding/json"
 	"sort"
-	"strings"
+	"time"
 
 	"github.com/aquasecurity/trivy-db/pkg/vulnsrc/vulnerability"
 
@@ -20,6 +19,9 @@ import (
 
 const (
 	DefaultIgnoreFile = ".trivyignore"
+
+	// vulnerabilityDetailBucket has the details of each vulnerability keyed by source
+	vulnerabilityDetailBucket = "vulnerability-detail"
 )
 
 var SuperSet = wire.NewSet(
@@ -43,14 +45,16 @@ func NewClient(dbc db.Config) Client {
 }
 
 func (c Client) FillInfo(vulns []types.DetectedVulnerability, reportType string) {
-	var err error
-
 	for i := range vulns {
-		vulns[i].Vulnerability, err = c.dbc.GetVulnerability(vulns[i].VulnerabilityID)
+		details, err := c.dbc.GetVulnerability(vulns[i].VulnerabilityID)
 		if err != nil {
-			log.Logger.Warnf("Error while getting vulnerability details: %s\n", err)
+			// the vulnerabilities of the supplementary advisories unknown to the DB keep the details of their advisories
+			if vulns[i].DataSource == "" {
+				log.Logger.Warnf("Error while getting vulnerability details: %s\n", err)
+			}
 			continue
 		}
+		vulns[i].Vulnerability = details
 
 		var source string
 		switch reportType {
@@ -70,6 +74,7 @@ func (c Client) FillInfo(vulns []types.DetectedVulnerability, reportType string)
 			source = vulnerability.PhpSecurityAdvisories
 		}
 		c.getVendorSeverity(&vulns[i], source)
+		vulns[i].CVSS = c.getCVSS(vulns[i].VulnerabilityID, vulns[i].Vulnerability.VendorSeverity)
 		vulns[i].Vulnerability.VendorSeverity = nil // Remove VendorSeverity from Results
 	}
 }
@@ -89,6 +94,40 @@ func (c Client) getVendorSeverity(vuln *types.DetectedVulnerability, reportType
 	}
 }
 
+// getCVSS returns the scores of the vulnerability with the severity of each source
+func (c Client) getCVSS(vulnID string, vendorSeverity dbTypes.VendorSeverity) types.VendorCVSS {
+	details, err := c.dbc.ForEachAdvisory(vulnerabilityDetailBucket, vulnID)
+	if err != nil {
+		log.Logger.Warnf("Error while getting the CVSS of %s: %s", vulnID, err)
+	}
+
+	cvss := types.VendorCVSS{}
+	for source, value := range details {
+		var detail dbTypes.VulnerabilityDetail
+		if err = json.Unmarshal(value, &detail); err != nil {
+			log.Logger.Warnf("Invalid CVSS of %s given by %s: %s", vulnID, source, err)
+			continue
+		}
+		if detail.CvssScore == 0 && detail.CvssScoreV3 == 0 {
+			continue
+		}
+		cvss[source] = types.CVSS{
+			V2Score: detail.CvssScore,
+			V3Score: detail.CvssScoreV3,
+		}
+	}
+	for source, severity := range vendorSeverity {
+		s := cvss[source]
+		s.Severity = severity.String()
+		cvss[source] = s
+	}
+
+	if len(cvss) == 0 {
+		return nil
+	}
+	return cvss
+}
+
 func (c Client) Filter(vulns []types.DetectedVulnerability, severities []dbTypes.Severity,
 	ignoreUnfixed bool, ignoreFile string) []types.DetectedVulnerability {
 	ignoredIDs := getIgnoredIDs(ignoreFile)
@@ -124,21 +163,25 @@ func (c Client) Filter(vulns []types.DetectedVulnerability, severities []dbTypes
 }
 
 func getIgnoredIDs(ignoreFile string) []string {
-	f, err := os.Open(ignoreFile)
+	// trivy must work even if no .trivyignore exist
+	ignoredIDs, _ := ReadIgnoreFile(ignoreFile)
+	return ignoredIDs
+}
+
+// ReadIgnoreFile returns the vulnerability IDs of the rules of the ignore file that apply to every result now,
+// see ReadIgnoreRules. In the plain format the blank lines and the comments starting with "#" are skipped.
+func ReadIgnoreFile(ignoreFile string) ([]string, error) {
+	rules, err := ReadIgnoreRules(ignoreFile)
 	if err != nil {
-		// trivy must work even if no .trivyignore exist
-		return nil
+		return nil, err
 	}
 
 	var ignoredIDs []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "#") || line == "" {
-			continue
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.Active(now) && len(rule.Paths) == 0 {
+			ignoredIDs = append(ignoredIDs, rule.ID)
 		}
-		ignoredIDs = append(ignoredIDs, line)
 	}
-	return ignoredIDs
+	return ignoredIDs, nil
 }
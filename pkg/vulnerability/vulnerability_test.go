@@ -36,6 +36,7 @@ func TestClient_FillInfo(t *testing.T) {
 		name                    string
 		getSeverity             []db.GetSeverityExpectation
 		getVulnerability        []db.GetVulnerabilityExpectation
+		forEachAdvisory         []db.ForEachAdvisoryExpectation
 		args                    args
 		expectedVulnerabilities []types.DetectedVulnerability
 	}{
@@ -56,6 +57,12 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args:    db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2019-0001"},
+					Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{}},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2019-0001"},
@@ -93,6 +100,17 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args: db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2019-0001"},
+					Returns: db.ForEachAdvisoryReturns{
+						Value: map[string][]byte{
+							vulnerability.Nvd:    []byte(`{"CvssScore": 5, "CvssScoreV3": 3.7, "Severity": 2}`),
+							vulnerability.Ubuntu: []byte(`{"Severity": 1}`),
+						},
+					},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2019-0001"},
@@ -109,6 +127,13 @@ func TestClient_FillInfo(t *testing.T) {
 						References:  []string{"http://example.com"},
 					},
 					SeveritySource: vulnerability.Nvd,
+					CVSS: types.VendorCVSS{
+						vulnerability.Nvd: {
+							V2Score:  5,
+							V3Score:  3.7,
+							Severity: dbTypes.SeverityLow.String(),
+						},
+					},
 				},
 			},
 		},
@@ -128,6 +153,12 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args:    db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2019-0001"},
+					Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{}},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2019-0001"},
@@ -165,6 +196,12 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args:    db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2019-0001"},
+					Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{}},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2019-0001"},
@@ -181,6 +218,7 @@ func TestClient_FillInfo(t *testing.T) {
 						References:  []string{"http://example.com"},
 					},
 					SeveritySource: vulnerability.RedHat,
+					CVSS:           types.VendorCVSS{vulnerability.RedHat: {Severity: dbTypes.SeverityLow.String()}},
 				},
 			},
 		},
@@ -201,6 +239,12 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args:    db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2019-0001"},
+					Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{}},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2019-0001"},
@@ -214,6 +258,7 @@ func TestClient_FillInfo(t *testing.T) {
 						Severity: dbTypes.SeverityLow.String(),
 					},
 					SeveritySource: vulnerability.Ubuntu,
+					CVSS:           types.VendorCVSS{vulnerability.Ubuntu: {Severity: dbTypes.SeverityLow.String()}},
 				},
 			},
 		},
@@ -237,6 +282,12 @@ func TestClient_FillInfo(t *testing.T) {
 					},
 				},
 			},
+			forEachAdvisory: []db.ForEachAdvisoryExpectation{
+				{
+					Args:    db.ForEachAdvisoryArgs{Source: "vulnerability-detail", PkgName: "CVE-2020-0001"},
+					Returns: db.ForEachAdvisoryReturns{Value: map[string][]byte{}},
+				},
+			},
 			args: args{
 				vulns: []types.DetectedVulnerability{
 					{VulnerabilityID: "CVE-2020-0001"},
@@ -253,6 +304,7 @@ func TestClient_FillInfo(t *testing.T) {
 						References:  []string{"https://www.who.int/emergencies/diseases/novel-coronavirus-2019"},
 					},
 					SeveritySource: vulnerability.PythonSafetyDB,
+					CVSS:           types.VendorCVSS{vulnerability.PythonSafetyDB: {Severity: dbTypes.SeverityCritical.String()}},
 				},
 			},
 		},
@@ -277,6 +329,36 @@ func TestClient_FillInfo(t *testing.T) {
 				{VulnerabilityID: "CVE-2019-0004"},
 			},
 		},
+		{
+			name: "a supplementary advisory unknown to the DB",
+			getVulnerability: []db.GetVulnerabilityExpectation{
+				{
+					Args: db.GetVulnerabilityArgs{
+						VulnerabilityID: "ACME-2020-0001",
+					},
+					Returns: db.GetVulnerabilityReturns{
+						Err: xerrors.New("failed to get the vulnerability"),
+					},
+				},
+			},
+			args: args{
+				vulns: []types.DetectedVulnerability{
+					{
+						VulnerabilityID: "ACME-2020-0001",
+						DataSource:      "osv:acme",
+						Vulnerability:   dbTypes.Vulnerability{Title: "internal", Severity: dbTypes.SeverityHigh.String()},
+					},
+				},
+				reportType: "npm",
+			},
+			expectedVulnerabilities: []types.DetectedVulnerability{
+				{
+					VulnerabilityID: "ACME-2020-0001",
+					DataSource:      "osv:acme",
+					Vulnerability:   dbTypes.Vulnerability{Title: "internal", Severity: dbTypes.SeverityHigh.String()},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -284,6 +366,7 @@ func TestClient_FillInfo(t *testing.T) {
 			mockDBConfig := new(db.MockOperation)
 			mockDBConfig.ApplyGetSeverityExpectations(tt.getSeverity)
 			mockDBConfig.ApplyGetVulnerabilityExpectations(tt.getVulnerability)
+			mockDBConfig.ApplyForEachAdvisoryExpectations(tt.forEachAdvisory)
 
 			c := Client{
 				dbc: mockDBConfig,
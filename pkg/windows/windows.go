@@ -0,0 +1,133 @@
+package windows
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	aos "github.com/aquasecurity/fanal/analyzer/os"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+
+	ospkgWindows "github.com/aquasecurity/trivy/pkg/detector/ospkg/windows"
+)
+
+// PackagesDir is the directory of the manifests of the servicing packages of a Windows image, e.g.
+// Package_for_KB5005568~31bf3856ad364e35~amd64~~17763.2183.1.8.mum, in the Files directory of its layers
+const PackagesDir = "Files/Windows/servicing/Packages/"
+
+var (
+	// the packages of the features of Windows are versioned with the OS build, e.g.
+	// Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum
+	featureVersion = regexp.MustCompile(`(?i)^microsoft-.*~~10\.0\.(\d+)\.\d+\.mum$`)
+	// the rollups, e.g. Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.2183.1.8.mum, with the build only
+	rollupVersion = regexp.MustCompile(`(?i)^package_for_rollupfix~.*~~(\d+)\.\d+\.\d+\.\d+\.mum$`)
+	kbName        = regexp.MustCompile(`(?i)^package_for_(kb\d+)~`)
+	// the rollups name their KB in the manifest only
+	kbIdentifier = regexp.MustCompile(`(?i)<package [^>]*identifier="(kb\d+)"`)
+)
+
+// Parse returns the OS of the manifests of the servicing packages by path, e.g. windows 10.0.17763,
+// and their KB updates. The OS is nil without any versioned package.
+func Parse(manifests map[string][]byte) (*ftypes.OS, map[string][]string) {
+	var build int
+	kbs := map[string][]string{}
+	for filePath, content := range manifests {
+		name := path.Base(filePath)
+		if !strings.EqualFold(path.Ext(name), ".mum") {
+			continue
+		}
+		if m := featureVersion.FindStringSubmatch(name); m != nil {
+			build = maxBuild(build, m[1])
+		} else if m = rollupVersion.FindStringSubmatch(name); m != nil {
+			build = maxBuild(build, m[1])
+		}
+
+		found := map[string]bool{}
+		if m := kbName.FindStringSubmatch(name); m != nil {
+			found[ospkgWindows.NormalizeKB(m[1])] = true
+		}
+		for _, m := range kbIdentifier.FindAllSubmatch(content, -1) {
+			found[ospkgWindows.NormalizeKB(string(m[1]))] = true
+		}
+		for kb := range found {
+			kbs[filePath] = append(kbs[filePath], kb)
+		}
+		sort.Strings(kbs[filePath])
+	}
+	if build == 0 {
+		return nil, kbs
+	}
+	return &ftypes.OS{Family: ospkgWindows.Family, Name: "10.0." + strconv.Itoa(build)}, kbs
+}
+
+func maxBuild(build int, s string) int {
+	if b, err := strconv.Atoi(s); err == nil && b > build {
+		return b
+	}
+	return build
+}
+
+// osAnalyzer detects the version of Windows from its servicing packages
+type osAnalyzer struct{}
+
+func (a osAnalyzer) Analyze(fileMap extractor.FileMap) (ftypes.OS, error) {
+	os, _ := Parse(packageManifests(fileMap))
+	if os == nil {
+		return ftypes.OS{}, xerrors.Errorf("windows: %w", aos.AnalyzeOSError)
+	}
+	return *os, nil
+}
+
+func (a osAnalyzer) RequiredFiles() []string {
+	return []string{PackagesDir}
+}
+
+// pkgAnalyzer returns the KB updates of the servicing packages as the packages of Windows. Each manifest is a
+// package file, so that the updates of the upper layers add to those of the base layer instead of replacing them.
+type pkgAnalyzer struct{}
+
+func (a pkgAnalyzer) Analyze(fileMap extractor.FileMap) (map[ftypes.FilePath][]ftypes.Package, error) {
+	_, kbs := Parse(packageManifests(fileMap))
+	pkgMap := map[ftypes.FilePath][]ftypes.Package{}
+	for filePath, names := range kbs {
+		for _, name := range names {
+			pkgMap[ftypes.FilePath(filePath)] = append(pkgMap[ftypes.FilePath(filePath)], ftypes.Package{Name: name})
+		}
+	}
+	if len(pkgMap) == 0 {
+		return nil, analyzer.ErrNoPkgsDetected
+	}
+	return pkgMap, nil
+}
+
+func (a pkgAnalyzer) RequiredFiles() []string {
+	return []string{PackagesDir}
+}
+
+func packageManifests(fileMap extractor.FileMap) map[string][]byte {
+	manifests := map[string][]byte{}
+	for filePath, content := range fileMap {
+		if path.Dir(filePath) == path.Clean(PackagesDir) {
+			manifests[filePath] = content
+		}
+	}
+	return manifests
+}
+
+var registerOnce sync.Once
+
+// Register enables the detection of the Windows version and of its installed KB updates in the servicing packages
+// of Windows images. The analyzers are tried after those of fanal, and only the first call is effective.
+func Register() {
+	registerOnce.Do(func() {
+		analyzer.RegisterOSAnalyzer(osAnalyzer{})
+		analyzer.RegisterPkgAnalyzer(pkgAnalyzer{})
+	})
+}
@@ -0,0 +1,110 @@
+package windows
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aquasecurity/fanal/analyzer"
+	"github.com/aquasecurity/fanal/extractor"
+	ftypes "github.com/aquasecurity/fanal/types"
+)
+
+const rollupManifest = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<assembly xmlns="urn:schemas-microsoft-com:asm.v3" manifestVersion="1.0">
+  <assemblyIdentity name="Package_for_RollupFix" version="17763.2183.1.8" language="neutral" processorArchitecture="amd64" />
+  <package identifier="KB5005568" releaseType="Update" restart="possible">
+  </package>
+</assembly>`
+
+func TestOSAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		fileMap extractor.FileMap
+		want    ftypes.OS
+		wantErr string
+	}{
+		{
+			name: "feature packages",
+			fileMap: extractor.FileMap{
+				"Files/Windows/servicing/Packages/Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum": []byte(""),
+				"Files/Windows/servicing/Packages/Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.cat": []byte(""),
+			},
+			want: ftypes.OS{Family: "windows", Name: "10.0.17763"},
+		},
+		{
+			name: "rollup",
+			fileMap: extractor.FileMap{
+				"Files/Windows/servicing/Packages/Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.2183.1.8.mum": []byte(rollupManifest),
+			},
+			want: ftypes.OS{Family: "windows", Name: "10.0.17763"},
+		},
+		{
+			name: "no versioned package",
+			fileMap: extractor.FileMap{
+				"Files/Windows/servicing/Packages/Package_for_KB4486153~31bf3856ad364e35~amd64~~.mum": []byte(""),
+			},
+			wantErr: "windows: no target os",
+		},
+		{
+			name: "other directory",
+			fileMap: extractor.FileMap{
+				"Files/Windows/WinSxS/Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum": []byte(""),
+			},
+			wantErr: "windows: no target os",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := osAnalyzer{}.Analyze(tt.fileMap)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPkgAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		fileMap extractor.FileMap
+		want    map[ftypes.FilePath][]ftypes.Package
+		wantErr error
+	}{
+		{
+			name: "updates and rollup",
+			fileMap: extractor.FileMap{
+				"Files/Windows/servicing/Packages/Package_for_KB4486153~31bf3856ad364e35~amd64~~10.0.1.0.mum":                    []byte(""),
+				"Files/Windows/servicing/Packages/Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.2183.1.8.mum":              []byte(rollupManifest),
+				"Files/Windows/servicing/Packages/Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum": []byte(""),
+			},
+			want: map[ftypes.FilePath][]ftypes.Package{
+				"Files/Windows/servicing/Packages/Package_for_KB4486153~31bf3856ad364e35~amd64~~10.0.1.0.mum":       {{Name: "KB4486153"}},
+				"Files/Windows/servicing/Packages/Package_for_RollupFix~31bf3856ad364e35~amd64~~17763.2183.1.8.mum": {{Name: "KB5005568"}},
+			},
+		},
+		{
+			name: "no update",
+			fileMap: extractor.FileMap{
+				"Files/Windows/servicing/Packages/Microsoft-Windows-Foundation-Package~31bf3856ad364e35~amd64~~10.0.17763.1.mum": []byte(""),
+			},
+			wantErr: analyzer.ErrNoPkgsDetected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pkgAnalyzer{}.Analyze(tt.fileMap)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
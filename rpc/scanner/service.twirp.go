@@ -857,28 +857,30 @@ func callClientError(ctx context.Context, h *twirp.ClientHooks, err twirp.Error)
 }
 
 var twirpFileDescriptor0 = []byte{
-	// 367 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0x4d, 0x6b, 0x83, 0x30,
-	0x18, 0x46, 0x5b, 0x6a, 0x8d, 0x83, 0x95, 0x1c, 0x86, 0x6d, 0xd9, 0x10, 0x4f, 0x65, 0x07, 0x65,
-	0x0e, 0xb6, 0xfb, 0xa0, 0x87, 0x9e, 0x3a, 0xd2, 0xb1, 0xc3, 0x2e, 0x25, 0xd5, 0x97, 0x2e, 0xa0,
-	0xc6, 0x26, 0x51, 0x26, 0xfb, 0x1f, 0xfb, 0xbd, 0xc3, 0xc4, 0xc2, 0xda, 0xd1, 0xdb, 0xfb, 0xf1,
-	0xf8, 0x7c, 0xbc, 0x06, 0x4d, 0x45, 0x95, 0xc6, 0x32, 0xa5, 0x65, 0x09, 0x22, 0x96, 0x20, 0x1a,
-	0x96, 0x42, 0x54, 0x09, 0xae, 0x38, 0x9e, 0x28, 0xc1, 0x9a, 0x36, 0xea, 0x97, 0x51, 0xf3, 0x30,
-	0x7b, 0xda, 0x33, 0xf5, 0x59, 0xef, 0xa2, 0x94, 0x17, 0x31, 0x3d, 0xd4, 0x54, 0x42, 0x5a, 0x0b,
-	0xa6, 0xda, 0x58, 0x23, 0xe3, 0x8e, 0x2a, 0xe5, 0x45, 0xc1, 0xcb, 0x53, 0xa6, 0xf0, 0xc7, 0x42,
-	0xde, 0x26, 0xa5, 0x25, 0x81, 0x43, 0x0d, 0x52, 0xe1, 0x1b, 0x34, 0x52, 0x54, 0xec, 0x41, 0xf9,
-	0x56, 0x60, 0x2d, 0x5c, 0xd2, 0x77, 0x78, 0x8a, 0xc6, 0xac, 0xa0, 0x7b, 0xd8, 0xb2, 0xcc, 0xb7,
-	0xf5, 0xc6, 0xd1, 0xfd, 0x2a, 0xc3, 0x73, 0xe4, 0xe6, 0xb4, 0x05, 0xb1, 0x65, 0x99, 0xf4, 0x07,
-	0xc1, 0x60, 0xe1, 0x92, 0xb1, 0x1e, 0xac, 0x32, 0x89, 0x9f, 0x91, 0xc3, 0x2b, 0xc5, 0x78, 0x29,
-	0xfd, 0x61, 0x60, 0x2d, 0xbc, 0xe4, 0x36, 0x3a, 0xf7, 0x1e, 0x75, 0xfa, 0x6b, 0x03, 0x22, 0x47,
-	0x74, 0x78, 0x6f, 0x7c, 0xf5, 0xf3, 0x4e, 0xa4, 0xa9, 0xf3, 0x72, 0xab, 0xda, 0x0a, 0x7c, 0xcb,
-	0x88, 0x74, 0x83, 0xb7, 0xb6, 0x82, 0xf0, 0x0b, 0x5d, 0x99, 0x0c, 0xb2, 0xe2, 0xa5, 0x04, 0x1c,
-	0x20, 0x9b, 0x4b, 0x1d, 0xc0, 0x4b, 0x26, 0xbd, 0x9e, 0x49, 0x1f, 0xad, 0x37, 0xc4, 0xe6, 0x12,
-	0x63, 0x34, 0x04, 0x2e, 0x73, 0x1d, 0x65, 0x4c, 0x74, 0x8d, 0x13, 0xe4, 0x08, 0x90, 0x75, 0xae,
-	0x4c, 0x0a, 0x2f, 0xf1, 0xff, 0x5b, 0x25, 0x1a, 0x40, 0x8e, 0xc0, 0xf0, 0x1b, 0x8d, 0xcc, 0xe8,
-	0xe2, 0xe1, 0x96, 0xe8, 0xba, 0xf3, 0x09, 0x82, 0xee, 0x58, 0xce, 0x14, 0x03, 0xe9, 0xdb, 0x9a,
-	0x7d, 0x7e, 0x6a, 0xec, 0xfd, 0x0f, 0xa8, 0x25, 0xe7, 0xdf, 0x74, 0x86, 0x75, 0xf4, 0x81, 0x26,
-	0xd7, 0x75, 0xf2, 0x8a, 0x9c, 0x8d, 0xb1, 0x86, 0x97, 0x68, 0xd8, 0x95, 0xf8, 0xc2, 0x75, 0xfb,
-	0xbf, 0x3b, 0xbb, 0xbb, 0xb4, 0x36, 0x87, 0x7b, 0x71, 0x3f, 0x9c, 0x7e, 0xb5, 0x1b, 0xe9, 0xf7,
-	0xf1, 0xf8, 0x1b, 0x00, 0x00, 0xff, 0xff, 0xb4, 0xfb, 0xa8, 0xd9, 0x86, 0x02, 0x00, 0x00,
+	// 400 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0xc1, 0x6a, 0xdb, 0x40,
+	0x10, 0x45, 0xb6, 0x6b, 0x59, 0xa3, 0x94, 0x86, 0x3d, 0x94, 0x4d, 0x42, 0x8b, 0x71, 0x2f, 0xa6,
+	0x07, 0x89, 0xaa, 0xd0, 0xde, 0x0b, 0x39, 0xe4, 0x94, 0xb2, 0x2e, 0x3d, 0xf4, 0x62, 0xd6, 0xf2,
+	0xe0, 0x2e, 0xc8, 0xbb, 0xca, 0xce, 0x4a, 0x54, 0x3f, 0xd2, 0x6f, 0xe9, 0xe7, 0x95, 0xdd, 0x55,
+	0x20, 0x71, 0xf0, 0x6d, 0xe6, 0xcd, 0xd3, 0xbc, 0xf7, 0x46, 0x0b, 0x57, 0xb6, 0xad, 0x4b, 0xaa,
+	0xa5, 0xd6, 0x68, 0x4b, 0x42, 0xdb, 0xab, 0x1a, 0x8b, 0xd6, 0x1a, 0x67, 0xd8, 0xa5, 0xb3, 0xaa,
+	0x1f, 0x8a, 0x71, 0x58, 0xf4, 0x9f, 0xae, 0xbf, 0x1c, 0x94, 0xfb, 0xdd, 0xed, 0x8a, 0xda, 0x1c,
+	0x4b, 0xf9, 0xd0, 0x49, 0xc2, 0xba, 0xb3, 0xca, 0x0d, 0x65, 0x60, 0x96, 0x7e, 0x55, 0x6d, 0x8e,
+	0x47, 0xa3, 0x9f, 0x6f, 0x5a, 0xfd, 0x4d, 0x20, 0xdf, 0xd4, 0x52, 0x0b, 0x7c, 0xe8, 0x90, 0x1c,
+	0x7b, 0x0b, 0x73, 0x27, 0xed, 0x01, 0x1d, 0x4f, 0x96, 0xc9, 0x3a, 0x13, 0x63, 0xc7, 0xae, 0x60,
+	0xa1, 0x8e, 0xf2, 0x80, 0x5b, 0xb5, 0xe7, 0x93, 0x30, 0x49, 0x43, 0x7f, 0xb7, 0x67, 0x37, 0x90,
+	0x35, 0x72, 0x40, 0xbb, 0x55, 0x7b, 0xe2, 0xd3, 0xe5, 0x74, 0x9d, 0x89, 0x45, 0x00, 0xee, 0xf6,
+	0xc4, 0xbe, 0x42, 0x6a, 0x5a, 0xa7, 0x8c, 0x26, 0x3e, 0x5b, 0x26, 0xeb, 0xbc, 0x7a, 0x57, 0x9c,
+	0x7a, 0x2f, 0xbc, 0xfe, 0x7d, 0x24, 0x89, 0x47, 0xf6, 0xea, 0x23, 0xe4, 0x4f, 0x70, 0x2f, 0xd2,
+	0x77, 0x8d, 0xde, 0xba, 0xa1, 0x45, 0x9e, 0x44, 0x11, 0x0f, 0xfc, 0x18, 0x5a, 0x5c, 0xfd, 0x81,
+	0x8b, 0x98, 0x81, 0x5a, 0xa3, 0x09, 0xd9, 0x12, 0x26, 0x86, 0x42, 0x80, 0xbc, 0xba, 0x1c, 0xf5,
+	0x62, 0xfa, 0xe2, 0x7e, 0x23, 0x26, 0x86, 0x18, 0x83, 0x19, 0x1a, 0x6a, 0x42, 0x94, 0x85, 0x08,
+	0x35, 0xab, 0x20, 0xb5, 0x48, 0x5d, 0xe3, 0x62, 0x8a, 0xbc, 0xe2, 0x2f, 0xad, 0x8a, 0x40, 0x10,
+	0x8f, 0xc4, 0xd5, 0xbf, 0x04, 0xe6, 0x11, 0x3b, 0x7b, 0xb9, 0x5b, 0x78, 0xe3, 0x8d, 0xa2, 0x95,
+	0x3b, 0xd5, 0x28, 0xa7, 0x90, 0xf8, 0x24, 0xac, 0xbf, 0x79, 0xee, 0xec, 0xe7, 0x13, 0xd2, 0x20,
+	0x4e, 0xbf, 0xf1, 0x8e, 0x43, 0xf6, 0x69, 0x58, 0x1e, 0x6a, 0x2f, 0x49, 0x4e, 0xba, 0x2e, 0xde,
+	0x36, 0x13, 0x63, 0xc7, 0x3e, 0xc0, 0xeb, 0x58, 0x6d, 0x2d, 0x4a, 0x32, 0x9a, 0xbf, 0x0a, 0xe3,
+	0x8b, 0x08, 0x8a, 0x80, 0x55, 0xdf, 0x21, 0xdd, 0xc4, 0x60, 0xec, 0x16, 0x66, 0xbe, 0x64, 0x67,
+	0xfe, 0xcd, 0xf8, 0x36, 0xae, 0xdf, 0x9f, 0x1b, 0xc7, 0xb3, 0x7f, 0xcb, 0x7e, 0xa5, 0xe3, 0x68,
+	0x37, 0x0f, 0xaf, 0xeb, 0xf3, 0xff, 0x01, 0x00, 0xe3, 0xc7, 0x01, 0xa6, 0xc4, 0x02, 0x00, 0x00,
 }